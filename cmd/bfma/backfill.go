@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Время на дозагрузку истории одного символа
+const backfillTimeout = 10 * time.Minute
+
+// runBackfill загружает с биржи историю свечей и ставок финансирования за период
+// и записывает ее в хранилище
+func runBackfill(args []string) int {
+	fs := newFlagSet("backfill")
+	loadFlags := newConfigLoadFlags(fs)
+	symbolsFlag := fs.String("symbols", "", "символы через запятую (по умолчанию все отслеживаемые)")
+	fromFlag := fs.String("from", "", "начало периода: 2006-01-02, 2006-01-02 15:04 или RFC 3339 (по умолчанию 30 дней назад)")
+	toFlag := fs.String("to", "", "конец периода, не включая (по умолчанию текущее время)")
+	intervalsFlag := fs.String("intervals", "", "интервалы свечей через запятую (по умолчанию trading.interval)")
+	funding := fs.Bool("funding", true, "загружать историю ставок финансирования")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// Даты без часового пояса указываются в часовом поясе из конфигурации
+	if err := timezone.Set(cfg.Timezone); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	to := timezone.Now()
+	if *toFlag != "" {
+		if to, err = parseExportDate(*toFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "--to: %v\n", err)
+			return 2
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if *fromFlag != "" {
+		if from, err = parseExportDate(*fromFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "--from: %v\n", err)
+			return 2
+		}
+	}
+	if !from.Before(to) {
+		fmt.Fprintln(os.Stderr, "начало периода должно быть раньше конца")
+		return 2
+	}
+
+	intervals := []models.Interval{cfg.Trading.Interval}
+	if *intervalsFlag != "" {
+		intervals = nil
+		for _, value := range strings.Split(*intervalsFlag, ",") {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			interval, err := models.ParseInterval(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "--intervals: %v\n", err)
+				return 2
+			}
+			intervals = append(intervals, interval)
+		}
+	}
+
+	symbols := cfg.TrackedSymbols()
+	if *symbolsFlag != "" {
+		symbols = nil
+		for _, symbol := range strings.Split(*symbolsFlag, ",") {
+			if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	if len(symbols) == 0 {
+		fmt.Fprintln(os.Stderr, "не указаны символы: задайте --symbols или символы в конфигурации")
+		return 2
+	}
+
+	client, err := exchange.NewBinanceClient(cfg.Binance)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	store, err := storage.NewInfluxDBStorage(cfg.Storage)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	for _, symbol := range symbols {
+		if err := backfillSymbol(client, store, symbol, intervals, from, to, *funding); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// backfillSymbol загружает историю одного символа; ход загрузки выводится в stderr
+func backfillSymbol(client *exchange.BinanceClient, store *storage.InfluxDBStorage, symbol string, intervals []models.Interval, from, to time.Time, funding bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), backfillTimeout)
+	defer cancel()
+
+	for _, interval := range intervals {
+		candles, err := client.GetKlinesRange(ctx, symbol, interval, from, to)
+		if err != nil {
+			return err
+		}
+		if len(candles) > 0 {
+			if err := store.SaveCandles(ctx, candles); err != nil {
+				return fmt.Errorf("ошибка записи свечей %s %s: %w", symbol, interval, err)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: свечей %d\n", symbol, interval, len(candles))
+	}
+
+	if !funding {
+		return nil
+	}
+	rates, err := client.GetFundingHistory(ctx, symbol, from, to)
+	if err != nil {
+		return err
+	}
+	for _, rate := range rates {
+		if err := store.SaveFundingRate(ctx, rate); err != nil {
+			return fmt.Errorf("ошибка записи ставки финансирования %s: %w", symbol, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%s: ставок финансирования %d\n", symbol, len(rates))
+	return nil
+}
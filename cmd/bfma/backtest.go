@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skalibog/bfma/internal/backtest"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// runBacktest проверяет стратегию по сигналам на сохраненной истории: воспроизводит
+// расчет сигналов, ведет по ним сделки и выводит показатели запуска
+func runBacktest(args []string) int {
+	fs := newFlagSet("backtest")
+	loadFlags := newConfigLoadFlags(fs)
+	period := newHistoryFlags(fs, 7)
+	stepFlag := fs.String("step", "1m", "шаг расчета сигналов: интервал свечей")
+	name := fs.String("name", "backtest", "название запуска")
+	balance := fs.String("balance", "10000", "начальный баланс в валюте котировки")
+	size := fs.String("size", "1000", "стоимость входа в сделку в валюте котировки")
+	fee := fs.String("fee", "0.0004", "комиссия от стоимости исполнения (0.0004 - 0,04%)")
+	strongOnly := fs.Bool("strong-only", false, "входить только по сильным сигналам")
+	orderBook := fs.Bool("orderbook", true, "исполнять заявки по глубине сохраненного стакана")
+	format := fs.String("format", "table", "формат вывода: table или json")
+	save := fs.Bool("save", true, "сохранить запуск в хранилище (/api/v1/backtests)")
+	compare := fs.String("compare", "", "ID сохраненного запуска для сравнения показателей")
+	profiling := newProfileFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "неизвестный формат %q, допустимы: table, json\n", *format)
+		return 2
+	}
+	step, err := parseStep(*stepFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--step: %v\n", err)
+		return 2
+	}
+	options := backtest.Options{StrongOnly: *strongOnly, OrderBookFills: *orderBook}
+	for _, amount := range []struct {
+		flag   string
+		value  string
+		target *models.Decimal
+	}{
+		{"--balance", *balance, &options.InitialBalance},
+		{"--size", *size, &options.PositionSize},
+		{"--fee", *fee, &options.FeeRate},
+	} {
+		if *amount.target, err = models.ParseDecimal(amount.value); err != nil || amount.target.IsNegative() {
+			fmt.Fprintf(os.Stderr, "%s: ожидается неотрицательное число, получено %q\n", amount.flag, amount.value)
+			return 2
+		}
+	}
+	if !options.PositionSize.IsPositive() {
+		fmt.Fprintln(os.Stderr, "--size должен быть положительным")
+		return 2
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	symbols, from, to, err := period.parse(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	store, err := storage.NewInfluxDBStorage(cfg.Storage)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	// Базовый запуск проверяется до воспроизведения, чтобы не ждать его зря
+	var base *models.BacktestRun
+	if *compare != "" {
+		if base, err = store.GetBacktestRun(context.Background(), *compare); err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка чтения запуска %s: %v\n", *compare, err)
+			return 1
+		}
+		if base == nil {
+			fmt.Fprintf(os.Stderr, "запуск проверки %q не найден\n", *compare)
+			return 1
+		}
+	}
+
+	// Профиль охватывает загрузку истории и воспроизведение
+	stopProfiling, err := profiling.start()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer stopProfiling()
+
+	started := timezone.Now()
+	history, err := loadHistory(cfg, store, symbols, from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка загрузки истории: %v\n", err)
+		return 1
+	}
+
+	// Прерывание останавливает воспроизведение; сделки к этому моменту не выводятся
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	engine := backtest.NewEngine(options, history)
+	replay := backtest.NewReplay(cfg.Analysis, history)
+	if err := replay.Run(ctx, from, to, step.Duration(), engine.OnSignals); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := engine.Close(to); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	run := models.NewBacktestRun(*name, started)
+	run.Symbols = symbols
+	run.Interval = step
+	run.From = from
+	run.To = to
+	run.InitialBalance = options.InitialBalance.InexactFloat64()
+	run.Parameters = backtestParameters(cfg.Analysis.SignalThresholds, options)
+	run.RecordSlippage(engine.Fills())
+	run.Finish(engine.Records(), timezone.Now())
+
+	if *save {
+		if err := store.SaveBacktestRun(context.Background(), run); err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка сохранения запуска: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Запуск сохранен: %s\n", run.ID)
+	}
+
+	if *format == "json" {
+		// Со сравнением выводится то же, что в /api/v1/backtests/compare, плюс сделки запуска
+		var result interface{} = run
+		if base != nil {
+			result = map[string]interface{}{
+				"run":  run,
+				"base": base.Summary(),
+				"diff": run.Stats.Compare(base.Stats),
+			}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка вывода результатов: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	printBacktestRun(run)
+	if base != nil {
+		printBacktestComparison(run, base)
+	}
+	return 0
+}
+
+// backtestParameters возвращает параметры стратегии для сравнения запусков
+func backtestParameters(thresholds config.SignalThresholds, options backtest.Options) map[string]string {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return map[string]string{
+		"threshold_strong_buy":  format(thresholds.StrongBuy),
+		"threshold_buy":         format(thresholds.Buy),
+		"threshold_sell":        format(thresholds.Sell),
+		"threshold_strong_sell": format(thresholds.StrongSell),
+		"position_size":         options.PositionSize.String(),
+		"fee_rate":              options.FeeRate.String(),
+		"strong_only":           strconv.FormatBool(options.StrongOnly),
+		"orderbook_fills":       strconv.FormatBool(options.OrderBookFills),
+	}
+}
+
+// printBacktestRun выводит сделки и показатели запуска таблицами
+func printBacktestRun(run *models.BacktestRun) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tSIDE\tENTRY\tEXIT\tENTRY PRICE\tEXIT PRICE\tQTY\tPNL\tRETURN\t")
+	for _, t := range run.Trades {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.2f%%\t\n", t.Symbol, t.Side,
+			timezone.In(t.EntryTime).Format("2006-01-02 15:04"), timezone.In(t.ExitTime).Format("2006-01-02 15:04"),
+			t.EntryPrice, t.ExitPrice, t.Quantity, t.PnL.StringFixed(2), t.ReturnPct)
+	}
+	w.Flush()
+
+	s := run.Stats
+	fmt.Printf("\nПериод: %s - %s, шаг %s\n", timezone.In(run.From).Format("2006-01-02 15:04"),
+		timezone.In(run.To).Format("2006-01-02 15:04"), run.Interval)
+	fmt.Printf("Сделок: %d (прибыльных %d, убыточных %d, %.1f%%)\n", s.Trades, s.Wins, s.Losses, s.WinRate)
+	fmt.Printf("Чистая прибыль: %.2f (%.2f%%), комиссии %.2f\n", s.NetPnL, s.ReturnPct, s.Fees)
+	fmt.Printf("Профит-фактор: %.2f, коэффициент Шарпа: %.2f\n", s.ProfitFactor, s.SharpeRatio)
+	fmt.Printf("Наибольшая просадка: %.2f (%.2f%%)\n", s.MaxDrawdown, s.MaxDrawdownPct)
+	fmt.Printf("Средняя сделка: %.2f%%, %s\n", s.AverageReturn, (time.Duration(s.AverageDurationMs) * time.Millisecond).Round(time.Second))
+	for _, slippage := range run.Slippage {
+		fmt.Printf("Проскальзывание %s: исполнений %d, в среднем %.1f б.п. (по стоимости %.1f, наибольшее %.1f), потери %s\n",
+			slippage.Symbol, slippage.Fills, slippage.Average, slippage.Weighted, slippage.Max, slippage.Cost.StringFixed(2))
+	}
+}
+
+// printBacktestComparison выводит основные показатели запуска рядом с базовым
+func printBacktestComparison(run, base *models.BacktestRun) {
+	diff := run.Stats.Compare(base.Stats)
+	fmt.Printf("\nСравнение с %s:\n", base.ID)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "\tBASE\tRUN\tDIFF\t")
+	rows := []struct {
+		name            string
+		base, run, diff float64
+	}{
+		{"trades", float64(base.Stats.Trades), float64(run.Stats.Trades), float64(diff.Trades)},
+		{"win_rate", base.Stats.WinRate, run.Stats.WinRate, diff.WinRate},
+		{"net_pnl", base.Stats.NetPnL, run.Stats.NetPnL, diff.NetPnL},
+		{"return_pct", base.Stats.ReturnPct, run.Stats.ReturnPct, diff.ReturnPct},
+		{"profit_factor", base.Stats.ProfitFactor, run.Stats.ProfitFactor, diff.ProfitFactor},
+		{"max_drawdown_pct", base.Stats.MaxDrawdownPct, run.Stats.MaxDrawdownPct, diff.MaxDrawdownPct},
+		{"sharpe_ratio", base.Stats.SharpeRatio, run.Stats.SharpeRatio, diff.SharpeRatio},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%+.2f\t\n", row.name, row.base, row.run, row.diff)
+	}
+	w.Flush()
+}
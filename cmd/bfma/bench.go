@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skalibog/bfma/internal/analysis/aggregator"
+	"github.com/skalibog/bfma/internal/analysis/funding"
+	"github.com/skalibog/bfma/internal/analysis/oianalysis"
+	"github.com/skalibog/bfma/internal/analysis/orderbook"
+	"github.com/skalibog/bfma/internal/analysis/technical"
+	"github.com/skalibog/bfma/internal/analysis/volumedelta"
+	"github.com/skalibog/bfma/internal/backtest"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Начальное значение генератора данных: набор одинаков при каждом запуске,
+// поэтому результаты разных версий и настроек сравнимы
+const benchSeed = 1
+
+// benchResult результат замера одного этапа
+type benchResult struct {
+	Name        string  `json:"name"`
+	Calls       int     `json:"calls"`
+	Errors      int     `json:"errors"`
+	AvgMicros   float64 `json:"avg_us"`
+	P95Micros   float64 `json:"p95_us"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+}
+
+// benchStage этап конвейера анализа: вызов для одного символа
+type benchStage struct {
+	name string
+	run  func(ctx context.Context, symbol string) error
+}
+
+// runBench замеряет задержку и выделения памяти анализаторов на синтетических
+// данных без InfluxDB и биржи (подкоманда bench)
+func runBench(args []string) int {
+	fs := newFlagSet("bench")
+	loadFlags := newConfigLoadFlags(fs)
+	symbolCount := fs.Int("symbols", 10, "число синтетических символов")
+	candleCount := fs.Int("candles", 1000, "число минутных свечей на символ")
+	iterations := fs.Int("iterations", 20, "повторов каждого этапа на символ")
+	format := fs.String("format", "table", "формат вывода: table или json")
+	profiling := newProfileFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *symbolCount <= 0 || *candleCount <= 0 || *iterations <= 0 {
+		fmt.Fprintln(os.Stderr, "--symbols, --candles и --iterations должны быть положительными")
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "неизвестный формат %q, допустимы: table, json\n", *format)
+		return 2
+	}
+
+	// Без --config замер идет с настройками по умолчанию
+	cfg := config.Default()
+	if loadFlags.configs.set {
+		loaded, err := loadFlags.load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		cfg = loaded
+	}
+
+	// Журнал анализаторов не должен влиять на замер
+	if err := logger.Configure(logger.Config{Level: "error", File: logger.Off, JSONFile: logger.Off}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	stopProfiling, err := profiling.start()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer stopProfiling()
+
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	symbols := generateBenchData(ctx, store, *symbolCount, *candleCount)
+	stages := benchStages(cfg.Analysis, store, symbols)
+
+	fmt.Fprintf(os.Stderr, "Символов: %d, свечей на символ: %d, повторов: %d\n", *symbolCount, *candleCount, *iterations)
+	results := make([]benchResult, 0, len(stages))
+	for _, stage := range stages {
+		results = append(results, measureStage(ctx, stage, symbols, *iterations))
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка вывода результатов: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "STAGE\tCALLS\tERRORS\tAVG\tP95\tALLOCS/OP\tBYTES/OP\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%.0f\t%.0f\t\n", r.Name, r.Calls, r.Errors,
+			time.Duration(r.AvgMicros*float64(time.Microsecond)).Round(time.Microsecond),
+			time.Duration(r.P95Micros*float64(time.Microsecond)).Round(time.Microsecond),
+			r.AllocsPerOp, r.BytesPerOp)
+	}
+	w.Flush()
+	return 0
+}
+
+// benchStages возвращает анализаторы по отдельности и полный цикл агрегатора
+func benchStages(cfg config.AnalysisConfig, store storage.Storage, symbols []string) []benchStage {
+	technicalAnal := technical.NewAnalyzer(cfg.Technical)
+	orderbookAnal := orderbook.NewAnalyzer(cfg.OrderBook)
+	fundingAnal := funding.NewAnalyzer(cfg.Funding)
+	oiAnal := oianalysis.NewAnalyzer(cfg.OpenInterest)
+	volumeDeltaAnal := volumedelta.NewAnalyzer(cfg.VolumeDelta)
+	analyzer := aggregator.NewAnalyzer(cfg, store, nil, symbols, nil)
+
+	discard := func(_ float64, err error) error { return err }
+	return []benchStage{
+		{"technical", func(ctx context.Context, symbol string) error {
+			return discard(technicalAnal.Analyze(ctx, store, symbol, models.Interval1m))
+		}},
+		{"orderbook", func(ctx context.Context, symbol string) error {
+			return discard(orderbookAnal.Analyze(ctx, store, symbol))
+		}},
+		{"funding", func(ctx context.Context, symbol string) error {
+			return discard(fundingAnal.Analyze(ctx, store, symbol))
+		}},
+		{"open_interest", func(ctx context.Context, symbol string) error {
+			return discard(oiAnal.Analyze(ctx, store, symbol))
+		}},
+		{"volume_delta", func(ctx context.Context, symbol string) error {
+			return discard(volumeDeltaAnal.Analyze(ctx, store, symbol))
+		}},
+		// Полный цикл анализирует все символы параллельно, как в приложении;
+		// замер на символ - время цикла, деленное на число символов
+		{"aggregator", func(ctx context.Context, symbol string) error {
+			if symbol != symbols[0] {
+				return nil
+			}
+			_, err := analyzer.GenerateSignals(ctx)
+			return err
+		}},
+	}
+}
+
+// measureStage вызывает этап iterations раз для каждого символа и считает задержку
+// и выделения памяти на вызов
+func measureStage(ctx context.Context, stage benchStage, symbols []string, iterations int) benchResult {
+	result := benchResult{Name: stage.name}
+	calls := symbols
+	if stage.name == "aggregator" {
+		calls = symbols[:1]
+	}
+
+	var durations []time.Duration
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < iterations; i++ {
+		for _, symbol := range calls {
+			started := time.Now()
+			if err := stage.run(ctx, symbol); err != nil {
+				result.Errors++
+			}
+			durations = append(durations, time.Since(started))
+		}
+	}
+	runtime.ReadMemStats(&after)
+
+	result.Calls = len(durations)
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	perSymbol := float64(len(calls)) / float64(len(symbols)) // Цикл агрегатора считается за все символы
+	ops := float64(result.Calls) / perSymbol
+	result.AvgMicros = float64(total.Microseconds()) / ops
+	result.P95Micros = float64(durations[len(durations)*95/100].Microseconds()) * perSymbol
+	result.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / ops
+	result.BytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / ops
+	return result
+}
+
+// generateBenchData заполняет хранилище синтетическими данными: случайное блуждание
+// цены с минутными и часовыми свечами, стакан, ставки финансирования и открытый интерес
+func generateBenchData(ctx context.Context, store storage.Storage, symbolCount, candleCount int) []string {
+	rng := rand.New(rand.NewSource(benchSeed))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	symbols := make([]string, 0, symbolCount)
+	for i := 0; i < symbolCount; i++ {
+		symbol := fmt.Sprintf("SYN%03dUSDT", i)
+		symbols = append(symbols, symbol)
+
+		price := 10 + rng.Float64()*1000
+		var minutes []*models.Candle
+		for j := 0; j < candleCount; j++ {
+			open := price
+			price *= 1 + rng.NormFloat64()*0.002
+			openTime := start.Add(time.Duration(j) * time.Minute)
+			volume := 100 + rng.Float64()*1000
+			takerBuy := volume * (0.3 + rng.Float64()*0.4)
+			// Цены и объемы округляются, как их передает биржа
+			priceOf := func(p float64) models.Decimal { return models.DecimalFromFloat(p).Round(4) }
+			amountOf := func(a float64) models.Decimal { return models.DecimalFromFloat(a).Round(3) }
+			minutes = append(minutes, &models.Candle{
+				Symbol:              symbol,
+				Interval:            models.Interval1m,
+				OpenTime:            openTime,
+				Open:                priceOf(open),
+				High:                priceOf(math.Max(open, price) * (1 + rng.Float64()*0.001)),
+				Low:                 priceOf(math.Min(open, price) * (1 - rng.Float64()*0.001)),
+				Close:               priceOf(price),
+				Volume:              amountOf(volume),
+				CloseTime:           openTime.Add(time.Minute),
+				QuoteVolume:         amountOf(volume * price),
+				NumTrades:           int64(volume / 2),
+				TakerBuyVolume:      amountOf(takerBuy),
+				TakerBuyQuoteVolume: amountOf(takerBuy * price),
+			})
+		}
+		store.SaveCandles(ctx, minutes)
+		store.SaveCandles(ctx, backtest.AggregateCandles(minutes, models.Interval1h))
+
+		last := minutes[len(minutes)-1]
+		book := &models.OrderBook{Symbol: symbol, Timestamp: last.CloseTime}
+		level := func(p float64) models.OrderBookLevel {
+			return models.OrderBookLevel{
+				Price:  models.DecimalFromFloat(p).Round(4),
+				Amount: models.DecimalFromFloat(rng.Float64() * 10).Round(3),
+			}
+		}
+		for i := 1; i <= 20; i++ {
+			step := price * 0.0001 * float64(i)
+			book.Bids = append(book.Bids, level(price-step))
+			book.Asks = append(book.Asks, level(price+step))
+		}
+		store.SaveOrderBook(ctx, book)
+
+		oi := 1e6 + rng.Float64()*1e6
+		for j := 0; j < 48; j++ {
+			at := last.CloseTime.Add(-time.Duration(47-j) * time.Hour)
+			store.SaveFundingRate(ctx, &models.FundingRate{
+				Symbol:          symbol,
+				Rate:            models.DecimalFromFloat(rng.NormFloat64() * 0.0003).Round(6),
+				Timestamp:       at,
+				NextFundingTime: at.Add(8 * time.Hour),
+			})
+			oi *= 1 + rng.NormFloat64()*0.01
+			store.SaveOpenInterest(ctx, &models.OpenInterest{
+				Symbol:    symbol,
+				Value:     models.DecimalFromFloat(oi).Round(2),
+				Timestamp: at,
+			})
+		}
+	}
+	return symbols
+}
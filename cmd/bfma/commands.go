@@ -0,0 +1,380 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command подкоманда bfma
+type command struct {
+	name        string
+	summary     string
+	usage       string   // Аргументы после имени подкоманды для справки
+	examples    []string // Примеры запуска для справки
+	subcommands []command
+	hidden      bool // Не выводится в справке и дополнении
+	run         func(args []string) int
+}
+
+// commands подкоманды в порядке вывода справки. Заполняется в init, потому что
+// справка сама обращается к списку.
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:    "run",
+			summary: "сбор данных, анализ и интерфейс (по умолчанию)",
+			usage:   "[флаги]",
+			examples: []string{
+				"bfma run --config config.yaml",
+				"bfma run --config config.yaml,config.prod.yaml --profile swing",
+				"bfma run --daemon --set binance.testnet=true",
+			},
+			run: runApp,
+		},
+		{
+			name:    "watch",
+			summary: "быстрый просмотр одного символа без InfluxDB и файла конфигурации",
+			usage:   "<символ> [флаги]",
+			examples: []string{
+				"bfma watch BTCUSDT",
+				"bfma watch ethusdt --interval 5m --plain",
+			},
+			run: runWatch,
+		},
+		{
+			name:    "signals",
+			summary: "последние сигналы таблицей или JSON без запуска интерфейса",
+			usage:   "[флаги]",
+			examples: []string{
+				"bfma signals --config config.yaml --symbols BTCUSDT,ETHUSDT",
+				"bfma signals --format json --source storage",
+			},
+			run: runSignals,
+		},
+		{
+			name:    "backfill",
+			summary: "загрузка истории свечей и ставок финансирования с биржи в хранилище",
+			usage:   "[флаги]",
+			examples: []string{
+				"bfma backfill --config config.yaml --from 2024-05-01",
+				"bfma backfill --symbols BTCUSDT,ETHUSDT --intervals 1h,4h --from 2024-01-01 --to 2024-04-01",
+			},
+			run: runBackfill,
+		},
+		{
+			name:    "backtest",
+			summary: "проверка стратегии по сигналам на сохраненной истории",
+			usage:   "[флаги]",
+			examples: []string{
+				"bfma backtest --config config.yaml --symbols BTCUSDT --from 2024-05-01 --to 2024-05-08",
+				"bfma backtest --step 5m --size 500 --strong-only --format json > run.json",
+				"bfma backtest --profile swing --name swing --compare 20240510T120000Z-backtest",
+				"bfma backtest --from 2024-05-01 --cpuprofile backtest.out --save=false",
+			},
+			run: runBacktest,
+		},
+		{
+			name:    "replay",
+			summary: "повтор расчета сигналов по сохраненной истории",
+			usage:   "[флаги]",
+			examples: []string{
+				"bfma replay --config config.yaml --symbols BTCUSDT --from \"2024-05-01 09:00\" --to \"2024-05-01 18:00\"",
+				"bfma replay --profile swing --step 1h --format json --output replay.json",
+			},
+			run: runReplay,
+		},
+		{
+			name:    "export",
+			summary: "выгрузка истории сигналов из хранилища в CSV или JSON",
+			usage:   "<подкоманда> [флаги]",
+			subcommands: []command{
+				{
+					name:    "signals",
+					summary: "история сигналов с разбивкой по компонентам",
+					usage:   "[флаги]",
+					examples: []string{
+						"bfma export signals --symbols BTCUSDT --from 2024-05-01 --to 2024-05-08 --output btc.csv",
+						"bfma export signals --format json --from \"2024-05-01 09:00\" > signals.json",
+					},
+					run: exportSignals,
+				},
+			},
+		},
+		{
+			name:    "config",
+			summary: "работа с конфигурацией: init, validate, explain, encrypt, decrypt, migrate",
+			usage:   "<подкоманда> [флаги]",
+			subcommands: []command{
+				{
+					name:     "init",
+					summary:  "записать шаблон config.yaml с комментариями",
+					usage:    "[флаги]",
+					examples: []string{"bfma config init --output config.yaml", "bfma config init --output - | less"},
+					run:      configInit,
+				},
+				{
+					name:     "validate",
+					summary:  "проверить конфигурацию без запуска",
+					usage:    "[флаги]",
+					examples: []string{"bfma config validate --config config.yaml --profile swing"},
+					run:      configValidate,
+				},
+				{
+					name:     "explain",
+					summary:  "итоговая конфигурация после профилей, окружения, --set и групп символов",
+					usage:    "[флаги]",
+					examples: []string{"bfma config explain --config config.yaml --profile swing"},
+					run:      configExplain,
+				},
+				{
+					name:     "encrypt",
+					summary:  "зашифровать ключи API и токены в файле конфигурации",
+					usage:    "[флаги]",
+					examples: []string{"bfma config encrypt --config config.yaml --key-file ~/.bfma.key"},
+					run:      func(args []string) int { return configCrypt("encrypt", args) },
+				},
+				{
+					name:     "decrypt",
+					summary:  "расшифровать ключи API и токены в файле конфигурации",
+					usage:    "[флаги]",
+					examples: []string{"bfma config decrypt --config config.yaml --key-file ~/.bfma.key"},
+					run:      func(args []string) int { return configCrypt("decrypt", args) },
+				},
+				{
+					name:     "migrate",
+					summary:  "обновить файлы конфигурации до текущей версии схемы",
+					usage:    "[файл...]",
+					examples: []string{"bfma config migrate config.yaml config.prod.yaml"},
+					run:      configMigrate,
+				},
+			},
+		},
+		{
+			name:    "verify",
+			summary: "проверка целостности истории в хранилище",
+			usage:   "[флаги]",
+			examples: []string{
+				"bfma verify --config config.yaml --from 2024-05-01 --to 2024-05-08",
+				"bfma verify --symbols BTCUSDT --data candles,funding --format json",
+			},
+			run: runVerify,
+		},
+		{
+			name:    "bench",
+			summary: "замер задержки и выделений памяти анализаторов на синтетических данных",
+			usage:   "[флаги]",
+			examples: []string{
+				"bfma bench --symbols 20 --candles 2000 --iterations 50",
+				"bfma bench --format json --cpuprofile bench.out > bench.json",
+			},
+			run: runBench,
+		},
+		{
+			name:     "version",
+			summary:  "версия, коммит и дата сборки; --check - проверка новых релизов",
+			usage:    "[флаги]",
+			examples: []string{"bfma version", "bfma version --check"},
+			run:      runVersion,
+		},
+		{
+			name:    "completion",
+			summary: "скрипт дополнения командной строки для bash, zsh или fish",
+			usage:   "bash|zsh|fish",
+			examples: []string{
+				"source <(bfma completion bash)",
+				"bfma completion zsh > \"${fpath[1]}/_bfma\"",
+				"bfma completion fish > ~/.config/fish/completions/bfma.fish",
+			},
+			run: runCompletion,
+		},
+		{
+			name:     "help",
+			summary:  "список подкоманд или справка по подкоманде",
+			usage:    "[подкоманда...]",
+			examples: []string{"bfma help export signals"},
+			run:      runHelp,
+		},
+		{name: "__complete", hidden: true, run: runComplete},
+	}
+
+	// Подкоманды с вложенными выбирают их по первому аргументу
+	for i := range commands {
+		if commands[i].subcommands != nil {
+			path := []string{commands[i].name}
+			commands[i].run = func(args []string) int { return runSubcommand(path, args) }
+		}
+	}
+}
+
+// runCommand выбирает подкоманду по первому аргументу и возвращает код завершения.
+// Без подкоманды или если первый аргумент - флаг, выполняется run, поэтому
+// прежний запуск "bfma --config config.yaml" продолжает работать.
+func runCommand(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && !isHelpFlag(args[0]) {
+		return runApp(args)
+	}
+	if isHelpFlag(args[0]) {
+		printUsage()
+		return 0
+	}
+
+	if cmd := findCommand(commands, args[0]); cmd != nil {
+		return cmd.run(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "неизвестная подкоманда %q\n\n", args[0])
+	printUsage()
+	return 2
+}
+
+// runSubcommand выбирает вложенную подкоманду команды path по первому аргументу
+func runSubcommand(path []string, args []string) int {
+	parent, _ := resolveCommand(path)
+	if len(args) == 0 || isHelpFlag(args[0]) {
+		printCommandHelp(os.Stderr, path, parent, nil)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	if cmd := findCommand(parent.subcommands, args[0]); cmd != nil {
+		return cmd.run(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "неизвестная подкоманда %s %q, доступны: %s\n",
+		strings.Join(path, " "), args[0], strings.Join(commandNames(parent.subcommands), ", "))
+	return 2
+}
+
+// runHelp выводит список подкоманд или справку по подкоманде с флагами и примерами
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 0
+	}
+
+	cmd, rest := resolveCommand(args)
+	if cmd == nil || len(rest) > 0 {
+		fmt.Fprintf(os.Stderr, "неизвестная подкоманда %q\n\n", strings.Join(args, " "))
+		printUsage()
+		return 2
+	}
+	if cmd.subcommands != nil {
+		printCommandHelp(os.Stderr, args, cmd, nil)
+		return 0
+	}
+	// Флаги объявляются в самой подкоманде, поэтому справку выводит она
+	cmd.run([]string{"--help"})
+	return 0
+}
+
+// printUsage выводит список подкоманд в stderr
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "использование: bfma <подкоманда> [флаги]")
+	fmt.Fprintln(os.Stderr, "\nподкоманды:")
+	printCommandList(os.Stderr, commands)
+	fmt.Fprintln(os.Stderr, "\nсправка по подкоманде: bfma help <подкоманда> или bfma <подкоманда> --help")
+}
+
+// printCommandList выводит имена и описания подкоманд
+func printCommandList(out io.Writer, list []command) {
+	for _, cmd := range list {
+		if !cmd.hidden {
+			fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+		}
+	}
+}
+
+// printCommandHelp выводит справку подкоманды: описание, использование, вложенные
+// подкоманды, флаги fs (если есть) и примеры
+func printCommandHelp(out io.Writer, path []string, cmd *command, fs *flag.FlagSet) {
+	name := "bfma " + strings.Join(path, " ")
+	if cmd == nil {
+		fmt.Fprintf(out, "использование: %s [флаги]\n", name)
+	} else {
+		fmt.Fprintf(out, "%s - %s\n\nиспользование: %s %s\n", name, cmd.summary, name, cmd.usage)
+		if cmd.subcommands != nil {
+			fmt.Fprintln(out, "\nподкоманды:")
+			printCommandList(out, cmd.subcommands)
+		}
+	}
+
+	if fs != nil && hasFlags(fs) {
+		fmt.Fprintln(out, "\nфлаги:")
+		fs.SetOutput(out)
+		fs.PrintDefaults()
+	}
+
+	if cmd != nil && len(cmd.examples) > 0 {
+		fmt.Fprintln(out, "\nпримеры:")
+		for _, example := range cmd.examples {
+			fmt.Fprintf(out, "  %s\n", example)
+		}
+	}
+}
+
+// newFlagSet создает набор флагов подкоманды; --help выводит справку из таблицы
+// подкоманд вместе с флагами. name - путь подкоманды через пробел, например "config init".
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := strings.Fields(name)
+	fs.Usage = func() {
+		cmd, _ := resolveCommand(path)
+		printCommandHelp(os.Stderr, path, cmd, fs)
+	}
+	if flagSetHook != nil {
+		flagSetHook(fs)
+	}
+	return fs
+}
+
+// resolveCommand находит подкоманду по пути и возвращает ее и оставшиеся аргументы
+func resolveCommand(path []string) (*command, []string) {
+	var cmd *command
+	list := commands
+	for i, name := range path {
+		next := findCommand(list, name)
+		if next == nil {
+			return cmd, path[i:]
+		}
+		cmd, list = next, next.subcommands
+	}
+	return cmd, nil
+}
+
+// findCommand ищет подкоманду по имени
+func findCommand(list []command, name string) *command {
+	for i := range list {
+		if list[i].name == name {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// commandNames возвращает имена подкоманд, кроме скрытых
+func commandNames(list []command) []string {
+	names := make([]string, 0, len(list))
+	for _, cmd := range list {
+		if !cmd.hidden {
+			names = append(names, cmd.name)
+		}
+	}
+	return names
+}
+
+// hasFlags сообщает, объявлены ли в наборе флаги
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// isHelpFlag сообщает, запрошена ли справка
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
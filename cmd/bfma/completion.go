@@ -0,0 +1,293 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+)
+
+// flagSetHook вызывается для каждого созданного набора флагов подкоманды.
+// Дополнение получает через него флаги, не выполняя подкоманду.
+var flagSetHook func(fs *flag.FlagSet)
+
+// Допустимые значения флагов для дополнения; ключ - путь подкоманды и флаг
+var flagValues = map[string][]string{
+	"signals --format":        {"table", "json"},
+	"signals --source":        {"auto", "admin", "storage"},
+	"export signals --format": {"csv", "json"},
+	"bench --format":          {"table", "json"},
+	"backtest --format":       {"table", "json"},
+	"replay --format":         {"csv", "json"},
+	"verify --format":         {"table", "json"},
+}
+
+// Скрипты дополнения. Кандидатов вычисляет скрытая подкоманда __complete, поэтому
+// новые подкоманды, флаги и символы из конфигурации дополняются без обновления скрипта.
+// Если кандидатов нет, дополняются имена файлов.
+var completionScripts = map[string]string{
+	"bash": `# Дополнение bfma для bash: source <(bfma completion bash)
+_bfma() {
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    local IFS=$'\n'
+    local candidates
+    candidates=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null))
+    if [ ${#candidates[@]} -eq 0 ]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+    else
+        COMPREPLY=("${candidates[@]}")
+    fi
+}
+complete -o filenames -F _bfma bfma
+`,
+	"zsh": `#compdef bfma
+# Дополнение bfma для zsh: bfma completion zsh > "${fpath[1]}/_bfma"
+_bfma() {
+    local -a candidates
+    candidates=("${(@f)$("${words[1]}" __complete "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+    if [[ -z ${candidates[1]} ]]; then
+        _files
+    else
+        compadd -Q -- "${candidates[@]}"
+    fi
+}
+if [ "$funcstack[1]" = "_bfma" ]; then
+    _bfma "$@"
+else
+    compdef _bfma bfma
+fi
+`,
+	"fish": `# Дополнение bfma для fish: bfma completion fish > ~/.config/fish/completions/bfma.fish
+function __bfma_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    set -l candidates ($tokens[1] __complete $tokens[2..-1] $current 2>/dev/null)
+    if test (count $candidates) -eq 0
+        __fish_complete_path $current
+    else
+        printf '%s\n' $candidates
+    end
+end
+complete -c bfma -f -a '(__bfma_complete)'
+`,
+}
+
+// runCompletion выводит скрипт дополнения для оболочки (подкоманда completion)
+func runCompletion(args []string) int {
+	fs := newFlagSet("completion")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	script, ok := completionScripts[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "неизвестная оболочка %q, доступны: bash, zsh, fish\n", fs.Arg(0))
+		return 2
+	}
+	fmt.Print(script)
+	return 0
+}
+
+// runComplete выводит кандидатов дополнения по одному на строку (скрытая подкоманда
+// __complete). Аргументы - слова командной строки после bfma, последнее - дополняемое.
+func runComplete(args []string) int {
+	if len(args) == 0 {
+		return 0
+	}
+	// Загрузка конфигурации для символов не должна писать в журнал
+	logger.Configure(logger.Config{Level: "error", File: logger.Off, JSONFile: logger.Off})
+
+	words, current := args[:len(args)-1], args[len(args)-1]
+	for _, candidate := range completeWords(words, current) {
+		fmt.Println(candidate)
+	}
+	return 0
+}
+
+// completeWords возвращает кандидатов для слова current после слов words
+func completeWords(words []string, current string) []string {
+	// Подкоманда определяется словами до первого флага
+	var cmd *command
+	var path []string
+	list := commands
+	consumed := 0
+	for _, word := range words {
+		next := findCommand(list, word)
+		if next == nil || next.hidden {
+			break
+		}
+		cmd, list = next, next.subcommands
+		path = append(path, word)
+		consumed++
+	}
+	// Без подкоманды флаги относятся к run
+	if cmd == nil && len(words) > 0 {
+		cmd, path = findCommand(commands, "run"), []string{"run"}
+	}
+
+	// Значение флага: --flag значение или --flag=значение
+	if name, value, ok := strings.Cut(current, "="); ok && strings.HasPrefix(name, "-") {
+		var result []string
+		for _, candidate := range completeFlagValue(path, words, name, value) {
+			result = append(result, name+"="+candidate)
+		}
+		return result
+	}
+	if len(words) > consumed {
+		prev := words[len(words)-1]
+		if f := commandFlag(cmd, prev); f != nil && !isBoolFlag(f) {
+			return completeFlagValue(path, words, prev, current)
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		if cmd != nil && cmd.subcommands != nil {
+			return nil
+		}
+		if cmd == nil {
+			cmd, path = findCommand(commands, "run"), []string{"run"}
+		}
+		var names []string
+		if fs := commandFlags(cmd); fs != nil {
+			fs.VisitAll(func(f *flag.Flag) { names = append(names, "--"+f.Name) })
+		}
+		return withPrefix(names, current)
+	}
+
+	switch {
+	case cmd == nil:
+		return withPrefix(commandNames(commands), current)
+	case cmd.name == "help":
+		// help принимает путь подкоманды
+		target, rest := resolveCommand(words[consumed:])
+		if len(rest) > 0 {
+			return nil
+		}
+		if target == nil {
+			return withPrefix(commandNames(commands), current)
+		}
+		return withPrefix(commandNames(target.subcommands), current)
+	case cmd.subcommands != nil && consumed == len(words):
+		return withPrefix(commandNames(cmd.subcommands), current)
+	case cmd.name == "completion":
+		shells := make([]string, 0, len(completionScripts))
+		for shell := range completionScripts {
+			shells = append(shells, shell)
+		}
+		sort.Strings(shells)
+		return withPrefix(shells, current)
+	}
+	return nil
+}
+
+// completeFlagValue возвращает значения флага: символы из конфигурации для --symbols
+// и известные варианты для остальных
+func completeFlagValue(path, words []string, name, value string) []string {
+	if strings.TrimLeft(name, "-") == "symbols" {
+		// Символы перечисляются через запятую, дополняется последний
+		done, last := "", value
+		if i := strings.LastIndex(value, ","); i >= 0 {
+			done, last = value[:i+1], value[i+1:]
+		}
+		var result []string
+		for _, symbol := range withPrefix(configSymbols(words), strings.ToUpper(last)) {
+			result = append(result, done+symbol)
+		}
+		return result
+	}
+
+	key := strings.Join(path, " ") + " --" + strings.TrimLeft(name, "-")
+	return withPrefix(flagValues[key], value)
+}
+
+// configSymbols возвращает отслеживаемые символы из конфигурации, указанной
+// в словах командной строки флагами --config и --profile
+func configSymbols(words []string) []string {
+	loadFlags := &configLoadFlags{configs: newConfigFlags(), profile: new(string)}
+	*loadFlags.profile = os.Getenv("BFMA_PROFILE")
+	for i, word := range words {
+		if !strings.HasPrefix(word, "-") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimLeft(word, "-"), "=")
+		if !ok && i+1 < len(words) {
+			value = words[i+1]
+		}
+		if value == "" {
+			continue
+		}
+		switch name {
+		case "config":
+			loadFlags.configs.Set(value)
+		case "profile":
+			*loadFlags.profile = value
+		}
+	}
+	// Удаленная конфигурация загружается по сети, это слишком долго для дополнения
+	if config.IsRemote(loadFlags.configs.base()) {
+		return nil
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		return nil
+	}
+	return cfg.TrackedSymbols()
+}
+
+// commandFlags возвращает флаги подкоманды. Подкоманда вызывается с --help:
+// флаги разбираются до начала работы, поэтому она завершается сразу после объявления флагов.
+func commandFlags(cmd *command) *flag.FlagSet {
+	if cmd == nil || cmd.subcommands != nil || cmd.name == "help" {
+		return nil
+	}
+
+	var captured *flag.FlagSet
+	flagSetHook = func(fs *flag.FlagSet) {
+		fs.SetOutput(io.Discard)
+		fs.Usage = func() {}
+		captured = fs
+	}
+	defer func() { flagSetHook = nil }()
+
+	cmd.run([]string{"--help"})
+	return captured
+}
+
+// commandFlag возвращает флаг подкоманды по слову командной строки (--name или -name)
+func commandFlag(cmd *command, word string) *flag.Flag {
+	if !strings.HasPrefix(word, "-") {
+		return nil
+	}
+	fs := commandFlags(cmd)
+	if fs == nil {
+		return nil
+	}
+	return fs.Lookup(strings.TrimLeft(word, "-"))
+}
+
+// isBoolFlag сообщает, что флаг не принимает значение отдельным словом
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// withPrefix оставляет кандидатов, начинающихся с prefix
+func withPrefix(candidates []string, prefix string) []string {
+	var result []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// configInit записывает шаблон config.yaml с комментариями и значениями по умолчанию
+func configInit(args []string) int {
+	fs := newFlagSet("config init")
+	output := fs.String("output", "config.yaml", "куда записать шаблон (- для вывода в консоль)")
+	force := fs.Bool("force", false, "перезаписать существующий файл")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(config.DefaultYAML())
+		return 0
+	}
+
+	if _, err := os.Stat(*output); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "файл %s уже существует, используйте --force для перезаписи\n", *output)
+		return 1
+	}
+
+	// Файл будет содержать ключи API, поэтому доступен только владельцу
+	if err := os.WriteFile(*output, config.DefaultYAML(), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка записи файла конфигурации: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Шаблон конфигурации записан в %s\n", *output)
+	return 0
+}
+
+// configLoadFlags флаги выбора конфигурации, общие для подкоманд validate и explain
+type configLoadFlags struct {
+	configs *configFlags
+	profile *string
+	sets    setFlags
+}
+
+// newConfigLoadFlags регистрирует флаги --config, --profile и --set
+func newConfigLoadFlags(fs *flag.FlagSet) *configLoadFlags {
+	f := &configLoadFlags{configs: newConfigFlags()}
+	fs.Var(f.configs, "config", "путь к файлу или каталогу конфигурации и файлы окружения")
+	f.profile = fs.String("profile", os.Getenv("BFMA_PROFILE"), "профиль конфигурации")
+	fs.Var(&f.sets, "set", "переопределить параметр конфигурации: ключ=значение")
+	return f
+}
+
+// load загружает конфигурацию так же, как при запуске приложения
+func (f *configLoadFlags) load() (*config.Config, error) {
+	configPath := f.configs.base()
+	if _, err := os.Stat(configPath); err != nil && !config.IsRemote(configPath) {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	opts := config.LoadOptions{Overlays: f.configs.overlays(), Profile: *f.profile, Sets: f.sets}
+	return config.Load(configPath, opts)
+}
+
+// configValidate проверяет конфигурацию без запуска приложения
+func configValidate(args []string) int {
+	fs := newFlagSet("config validate")
+	loadFlags := newConfigLoadFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if _, err := loadFlags.load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Конфигурация %s корректна\n", loadFlags.configs)
+	return 0
+}
+
+// configExplain выводит итоговую конфигурацию после наложения файлов окружения, профиля,
+// переменных окружения, флагов --set и секретов, а также настройки символов из групп
+func configExplain(args []string) int {
+	fs := newFlagSet("config explain")
+	loadFlags := newConfigLoadFlags(fs)
+	showSecrets := fs.Bool("show-secrets", false, "выводить значения секретов открытым текстом")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	shown := cfg
+	if !*showSecrets {
+		shown = cfg.Redacted()
+	}
+	out, err := yaml.Marshal(shown)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка сериализации конфигурации: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("# Итоговая конфигурация: %s", loadFlags.configs)
+	if *loadFlags.profile != "" {
+		fmt.Printf(", профиль %s", *loadFlags.profile)
+	}
+	fmt.Printf("\n%s", out)
+
+	// Символы без групп используют секции trading и analysis без изменений
+	var symbols yaml.MapSlice
+	for _, symbol := range cfg.TrackedSymbols() {
+		group := cfg.Group(symbol)
+		if group == nil {
+			continue
+		}
+		symbols = append(symbols, yaml.MapItem{Key: symbol, Value: yaml.MapSlice{
+			{Key: "group", Value: group.Name},
+			{Key: "interval", Value: cfg.IntervalFor(symbol)},
+			{Key: "analysis", Value: cfg.AnalysisFor(symbol)},
+		}})
+	}
+	if len(symbols) == 0 {
+		return 0
+	}
+
+	out, err = yaml.Marshal(yaml.MapSlice{{Key: "symbols", Value: symbols}})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка сериализации настроек символов: %v\n", err)
+		return 1
+	}
+	fmt.Printf("---\n# Настройки символов из групп; остальные символы используют секции trading и analysis\n%s", out)
+	return 0
+}
+
+// configMigrate обновляет файлы конфигурации до текущей версии схемы, чтобы при
+// загрузке не выводились предупреждения об устаревшем формате
+func configMigrate(args []string) int {
+	fs := newFlagSet("config migrate")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"config.yaml"}
+	}
+
+	code := 0
+	for _, path := range paths {
+		version, err := config.MigrateFile(path)
+		switch {
+		case err != nil:
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		case version == config.CurrentVersion:
+			fmt.Printf("%s: версия схемы %d, обновление не требуется\n", path, version)
+		default:
+			fmt.Printf("%s: версия схемы %d обновлена до %d\n", path, version, config.CurrentVersion)
+		}
+	}
+	return code
+}
+
+// configCrypt шифрует (encrypt) или расшифровывает (decrypt) ключи API и токены
+// в файле конфигурации. При запуске зашифрованные значения расшифровываются
+// ключом из BFMA_CONFIG_KEY_FILE или BFMA_CONFIG_PASSPHRASE.
+func configCrypt(mode string, args []string) int {
+	fs := newFlagSet("config " + mode)
+	path := fs.String("config", "config.yaml", "файл конфигурации")
+	keyFile := fs.String("key-file", os.Getenv("BFMA_CONFIG_KEY_FILE"), "файл с ключом; без него используется пароль")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	key, err := readCryptKey(*keyFile, mode == "encrypt")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	transform, done := config.EncryptFile, "Зашифровано"
+	if mode == "decrypt" {
+		transform, done = config.DecryptFile, "Расшифровано"
+	}
+	count, err := transform(*path, key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%s параметров: %d (%s)\n", done, count, *path)
+	return 0
+}
+
+// readCryptKey возвращает ключ из файла, из BFMA_CONFIG_PASSPHRASE или запрашивает
+// пароль; при шифровании введенный пароль запрашивается повторно
+func readCryptKey(keyFile string, confirm bool) ([]byte, error) {
+	if keyFile != "" {
+		return config.ReadKeyFile(keyFile)
+	}
+	if passphrase := os.Getenv("BFMA_CONFIG_PASSPHRASE"); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	prompt := func(text string) (string, error) {
+		fmt.Fprint(os.Stderr, text)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("ошибка чтения пароля: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	passphrase, err := prompt("Пароль: ")
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("пароль не может быть пустым")
+	}
+	if confirm {
+		again, err := prompt("Повторите пароль: ")
+		if err != nil {
+			return nil, err
+		}
+		if again != passphrase {
+			return nil, fmt.Errorf("пароли не совпадают")
+		}
+	}
+	return []byte(passphrase), nil
+}
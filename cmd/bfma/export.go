@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/internal/ui"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Время на выгрузку истории сигналов
+const exportTimeout = 5 * time.Minute
+
+// Форматы дат флагов --from и --to
+var exportDateLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+// exportSignals выгружает историю сигналов с разбивкой по компонентам в CSV или JSON
+func exportSignals(args []string) int {
+	fs := newFlagSet("export signals")
+	loadFlags := newConfigLoadFlags(fs)
+	symbolsFlag := fs.String("symbols", "", "символы через запятую (по умолчанию все отслеживаемые)")
+	fromFlag := fs.String("from", "", "начало периода: 2006-01-02, 2006-01-02 15:04 или RFC 3339 (по умолчанию 7 дней назад)")
+	toFlag := fs.String("to", "", "конец периода, не включая (по умолчанию текущее время)")
+	format := fs.String("format", "csv", "формат: csv или json")
+	output := fs.String("output", "-", "файл результата (- для вывода в консоль)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "неизвестный формат %q, допустимы: csv, json\n", *format)
+		return 2
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// Даты без часового пояса указываются в часовом поясе из конфигурации
+	if err := timezone.Set(cfg.Timezone); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	to := timezone.Now()
+	if *toFlag != "" {
+		if to, err = parseExportDate(*toFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "--to: %v\n", err)
+			return 2
+		}
+	}
+	from := to.AddDate(0, 0, -7)
+	if *fromFlag != "" {
+		if from, err = parseExportDate(*fromFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "--from: %v\n", err)
+			return 2
+		}
+	}
+	if !from.Before(to) {
+		fmt.Fprintln(os.Stderr, "начало периода должно быть раньше конца")
+		return 2
+	}
+
+	symbols := cfg.TrackedSymbols()
+	if *symbolsFlag != "" {
+		symbols = nil
+		for _, symbol := range strings.Split(*symbolsFlag, ",") {
+			if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	store, err := storage.NewInfluxDBStorage(cfg.Storage)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	var signals []*models.SignalResult
+	for _, symbol := range symbols {
+		history, err := store.GetSignalRange(ctx, symbol, from, to)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка чтения сигналов %s: %v\n", symbol, err)
+			return 1
+		}
+		signals = append(signals, history...)
+	}
+	// Сигналы всех символов идут по времени, как они рассчитывались
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].Timestamp.Before(signals[j].Timestamp)
+	})
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка создания файла: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if *format == "json" {
+		err = writeSignalsJSON(out, signals, cfg.Output.SchemaVersion)
+	} else {
+		err = ui.WriteSignalsCSV(out, signals, nil)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *output != "-" {
+		fmt.Fprintf(os.Stderr, "Выгружено сигналов: %d в %s\n", len(signals), *output)
+	}
+	return 0
+}
+
+// parseExportDate разбирает дату флага в часовом поясе из конфигурации
+func parseExportDate(value string) (time.Time, error) {
+	for _, layout := range exportDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, timezone.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("неверная дата %q, ожидается например 2024-05-01, \"2024-05-01 12:00\" или 2024-05-01T12:00:00Z", value)
+}
+
+// writeSignalsJSON записывает сигналы массивом JSON в версии схемы output.schema_version
+func writeSignalsJSON(out io.Writer, signals []*models.SignalResult, version int) error {
+	encoded := make([]interface{}, 0, len(signals))
+	for _, signal := range signals {
+		value, err := schema.Encode(signal, nil, version)
+		if err != nil {
+			return err
+		}
+		encoded = append(encoded, value)
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(encoded); err != nil {
+		return fmt.Errorf("ошибка записи JSON: %w", err)
+	}
+	return nil
+}
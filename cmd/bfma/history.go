@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/backtest"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Время на загрузку истории из хранилища
+const historyLoadTimeout = 10 * time.Minute
+
+// historyFlags флаги символов и периода истории подкоманд backtest, replay и verify
+type historyFlags struct {
+	symbols *string
+	from    *string
+	to      *string
+	days    int // Длина периода по умолчанию
+}
+
+func newHistoryFlags(fs *flag.FlagSet, days int) *historyFlags {
+	return &historyFlags{
+		symbols: fs.String("symbols", "", "символы через запятую (по умолчанию все отслеживаемые)"),
+		from:    fs.String("from", "", fmt.Sprintf("начало периода: 2006-01-02, 2006-01-02 15:04 или RFC 3339 (по умолчанию %d дней назад)", days)),
+		to:      fs.String("to", "", "конец периода, не включая (по умолчанию текущее время)"),
+		days:    days,
+	}
+}
+
+// parse возвращает символы и период по флагам. Даты без часового пояса указываются
+// в часовом поясе из конфигурации, поэтому он устанавливается здесь.
+func (f *historyFlags) parse(cfg *config.Config) ([]string, time.Time, time.Time, error) {
+	if err := timezone.Set(cfg.Timezone); err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+
+	var err error
+	to := timezone.Now()
+	if *f.to != "" {
+		if to, err = parseExportDate(*f.to); err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("--to: %w", err)
+		}
+	}
+	from := to.AddDate(0, 0, -f.days)
+	if *f.from != "" {
+		if from, err = parseExportDate(*f.from); err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("--from: %w", err)
+		}
+	}
+	if !from.Before(to) {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("начало периода должно быть раньше конца")
+	}
+
+	symbols := cfg.TrackedSymbols()
+	if *f.symbols != "" {
+		symbols = nil
+		for _, symbol := range strings.Split(*f.symbols, ",") {
+			if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	if len(symbols) == 0 {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("не указаны символы: задайте --symbols или символы в конфигурации")
+	}
+	return symbols, from, to, nil
+}
+
+// loadHistory загружает историю символов за период [from, to) для воспроизведения
+// анализа: с запасом до начала периода, чтобы первые сигналы рассчитывались по полным
+// данным. Журнал анализаторов при воспроизведении не нужен, ошибки пишутся только
+// в stderr.
+func loadHistory(cfg *config.Config, reader storage.HistoryReader, symbols []string, from, to time.Time) (*backtest.History, error) {
+	if err := logger.Configure(logger.Config{Level: "error", File: logger.Off, JSONFile: logger.Off}); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyLoadTimeout)
+	defer cancel()
+	return backtest.LoadHistory(ctx, reader, symbols, backtest.AnalysisIntervals, from.Add(-backtest.Warmup(cfg.Analysis)), to)
+}
+
+// parseStep разбирает шаг воспроизведения в виде интервала свечей
+func parseStep(value string) (models.Interval, error) {
+	step, err := models.ParseInterval(value)
+	if err != nil {
+		return "", err
+	}
+	if step == models.Interval1M {
+		return "", fmt.Errorf("шаг %s не поддерживается: длина месяца непостоянна", step)
+	}
+	return step, nil
+}
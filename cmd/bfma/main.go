@@ -2,123 +2,697 @@ package main
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/skalibog/bfma/pkg/logger"
 	"log"
+	"maps"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
-	"time"
 
-	"github.com/skalibog/bfma/internal/analysis/aggregator"
+	"github.com/skalibog/bfma/internal/admin"
+	"github.com/skalibog/bfma/internal/bridge"
+	"github.com/skalibog/bfma/internal/chart"
 	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/crash"
+	"github.com/skalibog/bfma/internal/desktop"
+	"github.com/skalibog/bfma/internal/discord"
+	"github.com/skalibog/bfma/internal/email"
+	"github.com/skalibog/bfma/internal/engine"
+	"github.com/skalibog/bfma/internal/escalation"
+	journal "github.com/skalibog/bfma/internal/events"
 	"github.com/skalibog/bfma/internal/exchange"
-	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/internal/execution"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/incident"
+	"github.com/skalibog/bfma/internal/latency"
+	"github.com/skalibog/bfma/internal/mqtt"
+	"github.com/skalibog/bfma/internal/notify"
+	"github.com/skalibog/bfma/internal/reports"
+	"github.com/skalibog/bfma/internal/scanner"
+	"github.com/skalibog/bfma/internal/state"
+	"github.com/skalibog/bfma/internal/stream"
+	"github.com/skalibog/bfma/internal/telegram"
 	"github.com/skalibog/bfma/internal/ui"
+	"github.com/skalibog/bfma/internal/version"
+	"github.com/skalibog/bfma/internal/watchdog"
+	"github.com/skalibog/bfma/internal/webhook"
+	"github.com/skalibog/bfma/pkg/bfma"
+	"github.com/skalibog/bfma/pkg/daemon"
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
 	"go.uber.org/zap"
 )
 
 func main() {
 	logger.Init()
-	defer logger.GetLogger().Sync()
+	code := runCommand(os.Args[1:])
+	logger.GetLogger().Sync()
+	os.Exit(code)
+}
 
+// runApp запускает сбор данных, анализ и интерфейс (подкоманда run)
+func runApp(args []string) int {
 	// Обработка флагов командной строки
-	configPath := flag.String("config", "config.yaml", "путь к файлу конфигурации")
-	flag.Parse()
+	fs := newFlagSet("run")
+	configs := newConfigFlags()
+	fs.Var(configs, "config", "путь к файлу или каталогу конфигурации; следующие файлы (повтор флага или через запятую) накладываются по порядку")
+	plain := fs.Bool("plain", false, "текстовый вывод без рамок и цвета (для программ экранного доступа)")
+	daemonMode := fs.Bool("daemon", false, "режим службы: без интерфейса, сигналы выводятся текстом в stdout, уведомления systemd (sd_notify)")
+	headless := fs.Bool("headless", false, "без интерфейса: каждый сигнал и оповещение выводятся строкой JSON в stdout, журнал - в stderr")
+	pidFile := fs.String("pid-file", "", "записать PID процесса в файл")
+	profile := fs.String("profile", os.Getenv("BFMA_PROFILE"), "профиль конфигурации (например scalping, swing, backtest)")
+	var sets setFlags
+	fs.Var(&sets, "set", "переопределить параметр конфигурации: ключ=значение (например binance.testnet=true); можно повторять")
+	profiling := newProfileFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	stopProfiling, err := profiling.start()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer stopProfiling()
 
-	// Проверяем наличие файла конфигурации
-	logger.Info("Проверка наличия файла конфигурации", zap.String("path", *configPath))
-	if _, err := os.Stat(*configPath); os.IsNotExist(err) {
-		logger.Fatal("Файл конфигурации не найден", zap.String("path", *configPath))
+	configPath := configs.base()
+
+	// Проверяем наличие файла конфигурации; при первом запуске в терминале
+	// предлагаем создать его мастером настройки
+	logger.Info("Проверка наличия файла конфигурации", zap.String("path", configPath))
+	// Без терминала (например, в контейнере) настройки берутся из значений по умолчанию,
+	// переменных окружения BFMA_* и флагов --set
+	if _, err := os.Stat(configPath); os.IsNotExist(err) && !config.IsRemote(configPath) && !*daemonMode && isTerminal(os.Stdin) {
+		logger.Info("Файл конфигурации не найден, запуск мастера настройки", zap.String("path", configPath))
+		if _, err := ui.RunSetupWizard(configPath); err != nil {
+			logger.Fatal("Ошибка создания конфигурации", zap.Error(err))
+		}
 	}
 
 	// Загружаем конфигурацию
-	cfg, err := config.Load(*configPath)
+	loadOpts := config.LoadOptions{Overlays: configs.overlays(), Profile: *profile, Sets: sets}
+	cfg, err := config.Load(configPath, loadOpts)
 	if err != nil {
+		// Логи пишутся в файл, поэтому ошибки настройки дублируем в консоль
+		fmt.Fprintln(os.Stderr, err)
 		logger.Fatal("Ошибка загрузки конфигурации", zap.Error(err))
 	}
+	// Наблюдатель сравнивает перечитанные файлы с конфигурацией в том виде, в каком она
+	// прочитана, до изменений флагами и состоянием интерфейса ниже
+	watcher := config.NewWatcher(configPath, loadOpts, cfg)
+
+	// Служба работает без терминала, поэтому интерфейс заменяется текстовым выводом.
+	// Так же и в контейнере без TTY (docker run без -t, вывод в журнал оркестратора).
+	if *headless {
+		cfg.UI.Headless = true
+	}
+	if !*plain && !*daemonMode && !cfg.UI.Headless && !isTerminal(os.Stdout) {
+		logger.Info("Стандартный вывод не подключен к терминалу, включен текстовый режим")
+		*plain = true
+	}
+	*plain = *plain || *daemonMode || cfg.UI.Headless
+	if *plain {
+		cfg.UI.Plain = true
+	}
+	// stdout занят записями JSON, поэтому журнал консоли переносится в stderr
+	if cfg.UI.Headless && cfg.Logging.Stdout {
+		cfg.Logging.Stdout, cfg.Logging.Stderr = false, true
+	}
+	reload := newReloader(cfg, *plain, *headless)
+
+	if *pidFile != "" {
+		removePID, err := daemon.WritePIDFile(*pidFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			logger.Fatal("Ошибка записи PID-файла", zap.Error(err))
+		}
+		defer removePID()
+	}
+
+	// Часовой пояс задается до перенастройки логгера, чтобы журнал сразу писался в нем
+	if err := timezone.Set(cfg.Timezone); err != nil {
+		logger.Fatal("Ошибка настройки часового пояса", zap.Error(err))
+	}
+	if err := logger.Configure(cfg.Logging); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		logger.Fatal("Ошибка настройки логирования", zap.Error(err))
+	}
+
+	// Паника записывается в отчет со стеками горутин, последними событиями и версиями
+	if err := crash.Setup(cfg.Crash, cfg.State.Dir, config.Hash(cfg)); err != nil {
+		logger.Error("Ошибка настройки отчетов о падениях", zap.Error(err))
+	}
+	defer crash.Recover()
+
+	enabled, disabled := cfg.Features.Split()
+	for _, name := range enabled {
+		logger.Warn("Включена экспериментальная подсистема", zap.String("feature", name), zap.String("description", config.Describe(name)))
+	}
+	logger.Info("Экспериментальные подсистемы", zap.Strings("enabled", enabled), zap.Strings("disabled", disabled))
+	logger.Info("Запуск bfma", zap.String("version", version.Version), zap.String("commit", version.Commit), zap.String("build_date", version.BuildDate))
 
 	// Создаем контекст с возможностью отмены через горутину
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Настраиваем обработку сигналов завершения
-	sigCh := make(chan os.Signal, 1)
+	if cfg.Updates.Check {
+		go logUpdate(ctx)
+	}
+
+	// Настраиваем обработку сигналов завершения: отмена контекста закрывает UI,
+	// после чего компоненты останавливаются по порядку в shutdown.
+	// Повторный сигнал завершает работу сразу.
+	sigCh := make(chan os.Signal, 2)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\nЗавершение работы...")
+		fmt.Fprintln(os.Stderr, "\nЗавершение работы...")
 		cancel()
-		time.Sleep(5 * time.Second) // Даем горутинам время на завершение
-		os.Exit(0)
+		<-sigCh
+		logger.Warn("Повторный сигнал завершения, выход без ожидания")
+		logger.GetLogger().Sync()
+		os.Exit(1)
 	}()
 
-	// Инициализируем хранилище
-	store, err := storage.NewInfluxDBStorage(cfg.Storage)
+	if cfg.Storage.Type == "memory" {
+		logger.Fatal("Хранилище в памяти (storage.type: memory) используется только подкомандой watch")
+	}
+
+	// Размер панелей и списки наблюдения, измененные в интерфейсе, хранятся в файле
+	// состояния, а не в config.yaml, и заменяют значения конфигурации, пока их не
+	// изменят в самом config.yaml. Удаленную конфигурацию меняют централизованно,
+	// поэтому для нее они не применяются.
+	var uiState *state.UI
+	if !config.IsRemote(configPath) {
+		uiState, err = state.NewUI(filepath.Join(cfg.State.Dir, "ui_state.json"))
+		if err != nil {
+			logger.Fatal("Ошибка загрузки настроек интерфейса", zap.Error(err))
+		}
+		if err := uiState.Apply(&cfg.UI); err != nil {
+			logger.Warn("Ошибка сохранения настроек интерфейса", zap.Error(err))
+		}
+		reload.uiState = uiState
+	}
+
+	// Загружаем список приостановленных символов
+	pauses, err := state.NewPauses(filepath.Join(cfg.State.Dir, "paused_symbols.json"))
+	if err != nil {
+		logger.Fatal("Ошибка загрузки состояния приостановки символов", zap.Error(err))
+	}
+
+	// Прогнозные ставки финансирования обновляются из потока mark price
+	fundingBoard := exchange.NewFundingBoard()
+
+	// Хранилище, клиент биржи, параметры символов, агрегатор и сборщики данных создает
+	// движок pkg/bfma, как и для встраивания в другие программы. Сборщики и цикл анализа
+	// запускаются ниже, чтобы остановка шла по порядку в shutdown.
+	setup := &engine.Setup{
+		Config:  cfg,
+		Current: reload.config,
+		OnPanic: crash.Recover,
+		Pauses:  pauses,
+		ExtraCollectors: func(symbol string) []exchange.DataCollector {
+			return []exchange.DataCollector{exchange.NewMarkPriceCollector(fundingBoard, []string{symbol})}
+		},
+	}
+	if _, err := bfma.NewEngine(bfma.Options{Setup: setup}); err != nil {
+		logger.Fatal("Ошибка инициализации движка", zap.Error(err))
+	}
+	store, cache, client, symbolDirectory := setup.Store, setup.Cache, setup.Client, setup.Symbols
+	analyzer, collectors, bus := setup.Analyzer, setup.Collectors, setup.Bus
+	// Отслеживаются символы из trading.symbols и всех списков наблюдения
+	trackedSymbols := analyzer.Symbols()
+
+	// Журнал событий пишется в хранилище в фоне, отдельно от отладочных логов
+	go journal.Start(ctx, store)
+	events.Subscribe(bus, "journal", func(e events.CollectorError) {
+		journal.Record(journal.TypeCollector, e.Symbol, "ошибка сборщика данных", map[string]string{
+			"collector": e.Collector,
+			"error":     e.Err.Error(),
+		})
+	})
+
+	// Символы с отключенными оповещениями: переключаются из UI и командами Telegram
+	mutes, err := state.NewMutes(filepath.Join(cfg.State.Dir, "muted_symbols.json"))
 	if err != nil {
-		logger.Fatal("Ошибка инициализации хранилища", zap.Error(err))
+		logger.Fatal("Ошибка загрузки списка отключенных оповещений", zap.Error(err))
 	}
-	defer store.Close()
 
-	// Инициализируем клиент биржи
-	client, err := exchange.NewBinanceClient(cfg.Binance)
+	// Ручные поправки сигналов: принимаются через API администрирования
+	overrides, err := state.NewOverrides(filepath.Join(cfg.State.Dir, "overrides.json"))
 	if err != nil {
-		logger.Fatal("Ошибка инициализации клиента биржи", zap.Error(err))
+		logger.Fatal("Ошибка загрузки ручных поправок", zap.Error(err))
 	}
 
-	// Создаем агрегатор аналитики
-	analyzer := aggregator.NewAnalyzer(cfg.Analysis, store, client, cfg.Trading.Symbols)
+	analyzer.SetOverrides(overrides)
+	// Итоги каждого цикла анализа пишутся в хранилище для разбора медленных циклов
+	analyzer.SetCycleAudit(store)
+
+	// Последние сигналы сохраняются на диск, чтобы после перезапуска или сбоя
+	// смена рекомендаций отслеживалась относительно прежних значений
+	savedSignals, err := state.NewSignals(filepath.Join(cfg.State.Dir, "signals_state.json"))
+	if err != nil {
+		logger.Fatal("Ошибка загрузки последних сигналов", zap.Error(err))
+	}
+	restored := analyzer.RestoreSignals(savedSignals)
 
 	// Инициализируем UI
 	userInterface, err := ui.NewTermUI(cfg.UI, analyzer, ctx)
 	if err != nil {
 		logger.Fatal("Ошибка инициализации пользовательского интерфейса", zap.Error(err))
 	}
+	if uiState != nil {
+		userInterface.SetStateStore(uiState)
+	}
+	userInterface.SetSchemaVersion(cfg.Output.SchemaVersion)
+	userInterface.RestoreSignals(restored)
+	// Вне торговых сессий оповещения не подаются; окна читаются из действующей конфигурации
+	alertsActive := func(channel string) bool {
+		return reload.config().Schedule.AlertsActive(channel, timezone.Now())
+	}
+	userInterface.SetAlertFilter(alertsActive)
+	userInterface.SetMuteList(mutes)
+	// Оповещения во внешние каналы проходят через распределитель: правила маршрутизации,
+	// отключенные символы, повторы и ограничение частоты
+	dispatcher := notify.NewDispatcher(func() config.NotifyConfig { return reload.config().Notify }, alertsActive, mutes)
+	userInterface.SetEventBus(bus)
+	events.Subscribe(bus, "ui", func(e events.SignalChanged) { userInterface.UpdateSignals(e.Signals) })
+	events.Subscribe(bus, "notify", func(e events.AlertFired) { dispatcher.Dispatch(e.Symbol, e.Text, e.Critical) })
+	reload.analyzer, reload.ui = analyzer, userInterface
 
-	// Запускаем сборщики данных в отдельных горутинах
-	dataCollectors := []exchange.DataCollector{
-		exchange.NewCandleCollector(client, store, cfg.Trading.Symbols, cfg.Trading.Interval),
-		exchange.NewOrderBookCollector(client, store, cfg.Trading.Symbols, cfg.Analysis.OrderBook.Depth),
-		exchange.NewFundingRateCollector(client, store, cfg.Trading.Symbols),
-		exchange.NewOpenInterestCollector(client, store, cfg.Trading.Symbols),
+	// Ручное открытие сделок из UI с подтверждением пользователя
+	if cfg.Execution.Enabled && cfg.Features.Enabled(config.FeatureExecution) {
+		risk, err := state.NewRisk(filepath.Join(cfg.State.Dir, "risk_state.json"))
+		if err != nil {
+			logger.Fatal("Ошибка загрузки состояния ограничений риска", zap.Error(err))
+		}
+		guard := execution.NewGuard(client, cfg.Risk, cfg.Execution.QuoteAsset, risk)
+		userInterface.SetTradeExecutor(execution.NewExecutor(client, cfg.Execution, cfg.Trading.RiskPerTrade, guard))
 	}
 
-	for _, collector := range dataCollectors {
-		collector := collector // Локальная копия для горутины
-		go func() {
-			defer collector.Stop()
-			if err := collector.Start(ctx); err != nil {
+	userInterface.SetFundingSource(fundingBoard)
+	userInterface.SetSymbolSource(symbolDirectory)
+	reload.collectors, reload.symbols = collectors, symbolDirectory
+
+	// Символ, снятый с торгов во время работы, исключается из анализа
+	symbolDirectory.SetDelistHandler(func(info *models.SymbolInfo) {
+		analyzer.RemoveSymbol(info.Symbol)
+		collectors.Remove(info.Symbol)
+		journal.Record(journal.TypeCollector, info.Symbol, "символ снят с торгов", map[string]string{"status": info.Status})
+	})
+	go symbolDirectory.Start(ctx)
+
+	// Обзор всех фьючерсов биржи предлагает в списки наблюдения символы с резким ростом
+	// оборота, изменением открытого интереса или крайней ставкой финансирования
+	var marketScanner *scanner.Scanner
+	if cfg.Scanner.Enabled {
+		marketScanner = scanner.NewScanner(cfg.Scanner, client, func(symbol string) bool {
+			info, ok := symbolDirectory.Info(symbol)
+			return ok && info.Trading() && info.ContractType == models.ContractPerpetual && !slices.Contains(analyzer.Symbols(), symbol)
+		})
+		marketScanner.SetCandidateHandler(func(candidate *models.ScanCandidate) {
+			userInterface.AddAlert(candidate.Symbol, candidate.Describe(), false)
+			anomalies := make([]string, len(candidate.Anomalies))
+			for i, anomaly := range candidate.Anomalies {
+				anomalies[i] = string(anomaly)
+			}
+			journal.Record(journal.TypeScanner, candidate.Symbol, "кандидат в список наблюдения", map[string]string{
+				"anomalies": strings.Join(anomalies, ","),
+				"score":     strconv.FormatFloat(candidate.Score, 'f', 1, 64),
+			})
+		})
+		go marketScanner.Start(ctx)
+	}
+
+	// Запускаем сборщики данных в отдельной горутине
+	go func() {
+		for _, symbol := range trackedSymbols {
+			if err := collectors.Add(ctx, symbol); err != nil {
 				log.Printf("Предупреждение: ошибка запуска сборщика данных: %v", err)
 			}
+		}
+	}()
+
+	// Символы, добавленные в списки наблюдения из UI, начинают отслеживаться сразу
+	userInterface.SetSymbolTracker(func(symbol string, track bool) error {
+		if !track {
+			// Символы из trading.symbols и групп отслеживаются всегда
+			if cfg := reload.config(); slices.Contains(cfg.Trading.Symbols, symbol) || cfg.Group(symbol) != nil {
+				return nil
+			}
+			analyzer.RemoveSymbol(symbol)
+			collectors.Remove(symbol)
+			return nil
+		}
+
+		if err := symbolDirectory.Check(symbol); err != nil {
+			return err
+		}
+		if err := collectors.Add(ctx, symbol); err != nil {
+			return err
+		}
+		analyzer.AddSymbol(symbol)
+		return nil
+	})
+
+	// Отслеживаем открытые позиции через user data stream
+	var tracker *exchange.PositionTracker
+	if cfg.Account.Enabled {
+		tracker = exchange.NewPositionTracker(client)
+		tracker.SetUpdateHandler(userInterface.OnPositionsUpdate)
+		userInterface.SetPositionSource(tracker)
+
+		go func() {
+			if err := tracker.Start(ctx); err != nil {
+				logger.Error("Ошибка запуска отслеживания позиций", zap.Error(err))
+			}
 		}()
 	}
 
+	// Поток /ws для веб-интерфейсов: сигналы, оповещения и изменения состояния
+	var push *admin.PushHub
+	if cfg.API.Enabled {
+		push = admin.NewPushHub(func() int { return reload.config().Output.SchemaVersion }, cfg.API.AllowedOrigins)
+		dispatcher.Register(config.ChannelPush, push.PublishAlert)
+		events.Subscribe(bus, "push", func(e events.SignalChanged) { push.PublishSignals(e.Signals) })
+		events.Subscribe(bus, "push", func(e events.BookUpdated) { push.PublishOrderBook(e.OrderBook) })
+		go push.WatchHealth(ctx)
+	}
+
+	// Графики к оповещениям в мессенджерах: свечи, смены рекомендации и уровни стакана
+	charts := chart.NewRenderer(store, func(symbol string) models.Interval { return reload.config().IntervalFor(symbol) })
+
+	// Эскалация сильных сигналов: звонок Twilio или экстренное уведомление Pushover до подтверждения
+	var escalator *escalation.Escalator
+	if cfg.Escalation.Enabled {
+		escalator = escalation.NewEscalator(cfg.Escalation, mutes)
+		events.Subscribe(bus, "escalation", func(e events.SignalChanged) { escalator.PublishSignals(e.Signals) })
+		go escalator.Start(ctx)
+	}
+
+	// Бот Telegram: оповещения в чаты и команды /signal, /mute, /ack
+	if cfg.Telegram.Enabled {
+		bot := telegram.NewBot(cfg.Telegram, analyzer, mutes, charts)
+		if escalator != nil {
+			bot.SetAcknowledger(escalator)
+		}
+		dispatcher.Register(config.ChannelTelegram, bot.PublishAlert)
+		go bot.Start(ctx)
+	}
+
+	// Оповещения в канал Discord через вебхук
+	if cfg.Discord.Enabled {
+		discordNotifier := discord.NewNotifier(cfg.Discord, analyzer, charts)
+		dispatcher.Register(config.ChannelDiscord, discordNotifier.PublishAlert)
+		go discordNotifier.Start(ctx)
+	}
+
+	// Вебхуки: сигналы и оповещения POST-запросами на внешние адреса
+	var webhooks *webhook.Publisher
+	if len(cfg.Webhooks) > 0 {
+		webhooks, err = webhook.NewPublisher(cfg.Webhooks, filepath.Join(cfg.State.Dir, "webhook_dead_letter.jsonl"),
+			func() int { return reload.config().Output.SchemaVersion })
+		if err != nil {
+			logger.Fatal("Ошибка настройки вебхуков", zap.Error(err))
+		}
+		dispatcher.Register(config.ChannelWebhook, webhooks.PublishAlert)
+		events.Subscribe(bus, "webhook", func(e events.SignalChanged) { webhooks.PublishSignals(e.Signals) })
+		go webhooks.Start(ctx)
+	}
+
+	// Публикация в брокер MQTT: последний сигнал символа и оповещения
+	var mqttPublisher *mqtt.Publisher
+	if cfg.MQTT.Enabled {
+		mqttPublisher = mqtt.NewPublisher(cfg.MQTT, func() int { return reload.config().Output.SchemaVersion })
+		dispatcher.Register(config.ChannelMQTT, mqttPublisher.PublishAlert)
+		events.Subscribe(bus, "mqtt", func(e events.SignalChanged) { mqttPublisher.PublishSignals(e.Signals) })
+		go mqttPublisher.Start(ctx)
+	}
+
+	// Поток сигналов и оповещений в Kafka или NATS
+	var streamPublisher *stream.Publisher
+	if cfg.Stream.Enabled {
+		streamPublisher = stream.NewPublisher(cfg.Stream, func() int { return reload.config().Output.SchemaVersion })
+		dispatcher.Register(config.ChannelStream, streamPublisher.PublishAlert)
+		events.Subscribe(bus, "stream", func(e events.SignalChanged) { streamPublisher.PublishSignals(e.Signals) })
+		go streamPublisher.Start(ctx)
+	}
+
+	// Оповещения по почте: отдельными письмами или сводкой за период
+	var mailer *email.Mailer
+	if cfg.Email.Enabled {
+		mailer = email.NewMailer(cfg.Email)
+		dispatcher.Register(config.ChannelEmail, mailer.PublishAlert)
+		go mailer.Start(ctx)
+	}
+
+	// Уведомления рабочего стола о сильных сигналах; кнопка уведомления открывает график
+	if cfg.Desktop.Enabled {
+		desktopNotifier := desktop.NewNotifier(cfg.Desktop, userInterface.ShowSymbol)
+		dispatcher.Register(config.ChannelDesktop, desktopNotifier.PublishAlert)
+		go desktopNotifier.Start(ctx)
+	}
+
+	// Мосты к сторонним торговым ботам: 3Commas и Freqtrade получают команды по сигналам
+	var bridges *bridge.Publisher
+	if len(cfg.Bridges) > 0 {
+		bridges = bridge.NewPublisher(cfg.Bridges)
+		events.Subscribe(bus, "bridge", func(e events.SignalChanged) { bridges.PublishSignals(e.Signals) })
+		go bridges.Start(ctx)
+	}
+
+	// Эксплуатационные сбои в PagerDuty и Opsgenie, отдельно от торговых оповещений
+	if cfg.Incidents.PagerDuty.Enabled || cfg.Incidents.Opsgenie.Enabled {
+		go incident.NewMonitor(cfg.Incidents, store).Start(ctx)
+	}
+
+	// Бюджет ошибок подсистем: режим деградации и автоматическое восстановление
+	if cfg.Watchdog.Enabled {
+		dog := watchdog.NewWatchdog(cfg.Watchdog)
+		dog.SetRemedy(health.SubsystemWebSocket, watchdog.Remedy{Apply: func(ctx context.Context) error {
+			defer health.ResetFailures(health.SubsystemWebSocket)
+			return collectors.Restart(ctx)
+		}})
+		dog.SetRemedy(health.SubsystemStorage, watchdog.Remedy{
+			Apply:   func(context.Context) error { cache.SetCacheOnly(true); return nil },
+			Restore: func() { cache.SetCacheOnly(false) },
+		})
+		go dog.Start(ctx)
+	}
+
+	// Отчеты за сутки или неделю: в каталог reports.dir и сводкой в каналы оповещений
+	if cfg.Reports.Enabled {
+		scheduler := reports.NewScheduler(cfg.Reports, store, func() []string { return reload.config().TrackedSymbols() },
+			cfg.Analysis.Funding.ExtremeThreshold, dispatcher.Dispatch)
+		go scheduler.Start(ctx)
+	}
+
 	// Запускаем аналитический процесс в горутине
-	go func() {
-		// Отложенный старт для накопления данных
-		time.Sleep(5 * time.Second)
-
-		ticker := time.NewTicker(time.Duration(cfg.Analysis.IntervalSeconds) * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				signals, err := analyzer.GenerateSignals(ctx)
-				if err != nil {
-					log.Printf("Предупреждение: ошибка при генерации сигналов: %v", err)
-					continue
-				}
-				if len(signals) > 0 {
-					userInterface.UpdateSignals(signals)
+	signalsActive := func() bool { return reload.config().Schedule.SignalsActive(timezone.Now()) }
+	events.Subscribe(bus, "latency", func(e events.SignalChanged) {
+		latency.MarkEmitted(slices.Collect(maps.Keys(e.Signals)))
+	})
+	go analyzer.Run(ctx, cfg.Analysis.Period.Std(), reload.intervalC, signalsActive)
+
+	// HTTP API администрирования: параметры анализа меняются без перезапуска
+	if cfg.Admin.Enabled {
+		adminServer := admin.NewServer(cfg.Admin)
+		admin.NewAnalysisAPI(analyzer, reload.config, filepath.Join(cfg.State.Dir, "admin_audit.jsonl")).Register(adminServer)
+		admin.NewSignalsAPI(analyzer, func() int { return reload.config().Output.SchemaVersion }).Register(adminServer)
+		admin.NewProbes(store).Register(adminServer)
+		admin.NewOverridesAPI(overrides, userInterface.AddAlert).Register(adminServer)
+		admin.NewEventsAPI(store).Register(adminServer)
+		admin.NewCyclesAPI(store).Register(adminServer)
+		admin.NewLoggingAPI().Register(adminServer)
+		admin.RegisterMetrics(adminServer)
+		admin.RegisterUsage(adminServer)
+		if cfg.Admin.Pprof {
+			admin.RegisterPprof(adminServer)
+			logger.Warn("Включено профилирование /debug/pprof/", zap.String("listen", cfg.Admin.Listen))
+		}
+
+		go func() {
+			if err := adminServer.Start(ctx); err != nil {
+				logger.Error("Ошибка запуска API администрирования", zap.Error(err))
+			}
+		}()
+	}
+
+	// HTTP API данных для внешних ботов и дашбордов; отдельный сервер со своим токеном
+	if cfg.API.Enabled {
+		apiServer := admin.NewServer(config.AdminConfig{Enabled: true, Listen: cfg.API.ListenAddr(), Token: cfg.API.Token})
+		admin.NewDataAPI(analyzer, store,
+			func(symbol string) models.Interval { return reload.config().IntervalFor(symbol) },
+			func() int { return reload.config().Output.SchemaVersion },
+		).Register(apiServer)
+		admin.NewBacktestsAPI(store).Register(apiServer)
+		if marketScanner != nil {
+			admin.NewScannerAPI(marketScanner).Register(apiServer)
+		}
+		push.Register(apiServer)
+		admin.NewProbes(store).Register(apiServer)
+
+		go func() {
+			if err := apiServer.Start(ctx); err != nil {
+				logger.Error("Ошибка запуска HTTP API данных", zap.Error(err))
+			}
+		}()
+
+		// gRPC API сигналов для ботов исполнения; тот же токен, что у HTTP API данных
+		if cfg.API.GRPCListen != "" {
+			grpcServer := admin.NewGRPCServer(cfg.API.GRPCListen, cfg.API.Token, analyzer)
+			events.Subscribe(bus, "grpc", func(e events.SignalChanged) { grpcServer.PublishSignals(e.Signals) })
+			events.Subscribe(bus, "grpc", func(e events.AlertFired) { grpcServer.PublishAlert(e) })
+
+			go func() {
+				if err := grpcServer.Start(ctx); err != nil {
+					logger.Error("Ошибка запуска gRPC API", zap.Error(err))
 				}
-			case <-ctx.Done():
-				return
+			}()
+		}
+	}
+
+	// Оповещения стратегий TradingView: внешний сигнал анализатора и оповещения панели.
+	// Отдельный сервер без токена Bearer: TradingView не передает заголовки авторизации.
+	if cfg.TradingView.Enabled {
+		tvServer := admin.NewServer(config.AdminConfig{Enabled: true, Listen: cfg.TradingView.ListenAddr()})
+		admin.NewTradingViewAPI(cfg.TradingView.Passphrase, analyzer, userInterface.AddAlert).Register(tvServer)
+
+		go func() {
+			if err := tvServer.Start(ctx); err != nil {
+				logger.Error("Ошибка запуска приема оповещений TradingView", zap.Error(err))
+			}
+		}()
+	}
+
+	// Безопасные изменения config.yaml применяются без перезапуска:
+	// при изменении файла и по сигналу SIGHUP
+	go watcher.Start(ctx, func(next *config.Config) { reload.apply(ctx, next) })
+
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			logger.Info("Получен SIGHUP, перечитываем конфигурацию")
+			watcher.Reload()
+		}
+	}()
+
+	// По SIGUSR1 файлы журнала открываются заново после внешней ротации (logrotate)
+	usrCh := make(chan os.Signal, 1)
+	signal.Notify(usrCh, syscall.SIGUSR1)
+	go func() {
+		for range usrCh {
+			if err := logger.Reopen(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
 			}
+			logger.Info("Получен SIGUSR1, файлы журнала открыты заново")
 		}
 	}()
 
-	// Запускаем UI в основном потоке (блокирующий вызов)
-	// Это последняя инструкция в основном потоке
+	// По SIGUSR2 все компоненты переключаются на отладочный уровень логирования и обратно
+	usr2Ch := make(chan os.Signal, 1)
+	signal.Notify(usr2Ch, syscall.SIGUSR2)
+	go func() {
+		for range usr2Ch {
+			debug := logger.ToggleDebug()
+			logger.Warn("Получен SIGUSR2, отладочный уровень логирования переключен", zap.Bool("debug", debug))
+		}
+	}()
+
+	// Под systemd сообщаем о готовности и подтверждаем работу сторожевому таймеру,
+	// пока цикл анализа не завис
+	if err := daemon.Notify(daemon.Ready); err != nil {
+		logger.Warn("Ошибка уведомления systemd о готовности", zap.Error(err))
+	}
+	daemon.Notify(daemon.Status(fmt.Sprintf("Отслеживается символов: %d", len(trackedSymbols))))
+	go daemon.RunWatchdog(ctx, func() bool {
+		return !health.Get().AnalysisStalled()
+	})
+
+	// Запускаем UI в основном потоке (блокирующий вызов). Он завершается по команде
+	// пользователя или по сигналу завершения.
 	userInterface.Start()
+	cancel()
+	daemon.Notify(daemon.Stopping)
+
+	steps := []shutdownStep{{name: "collectors", stop: collectors.StopAll}}
+	if tracker != nil {
+		steps = append(steps, shutdownStep{name: "positions", stop: tracker.Stop})
+	}
+	if mailer != nil {
+		steps = append(steps, shutdownStep{name: "email", stop: mailer.Stop})
+	}
+	steps = append(steps, shutdownStep{name: "events", stop: journal.Stop})
+	steps = append(steps, shutdownStep{name: "storage", stop: store.Close})
+	return shutdown(reload.config().Shutdown.Timeout.Std(), steps)
+}
+
+// isTerminal проверяет, подключен ли файл к терминалу
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// configFlags собирает пути --config: основной файл и файлы окружения по порядку
+type configFlags struct {
+	paths []string
+	set   bool // Флаг задан явно и заменил значение по умолчанию
+}
+
+func newConfigFlags() *configFlags {
+	return &configFlags{paths: []string{"config.yaml"}}
+}
+
+func (c *configFlags) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(c.paths, ",")
+}
+
+func (c *configFlags) Set(value string) error {
+	if !c.set {
+		c.paths, c.set = nil, true
+	}
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			c.paths = append(c.paths, path)
+		}
+	}
+	if len(c.paths) == 0 {
+		return fmt.Errorf("не указан путь к файлу конфигурации")
+	}
+	return nil
+}
+
+// base возвращает основной файл конфигурации
+func (c *configFlags) base() string {
+	return c.paths[0]
+}
+
+// overlays возвращает файлы окружения, накладываемые на основной
+func (c *configFlags) overlays() []string {
+	return c.paths[1:]
+}
+
+// setFlags собирает повторяющиеся флаги --set
+type setFlags []string
+
+func (s *setFlags) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *setFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
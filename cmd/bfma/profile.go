@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// profileFlags флаги записи профилей --cpuprofile и --memprofile
+type profileFlags struct {
+	cpu *string
+	mem *string
+}
+
+func newProfileFlags(fs *flag.FlagSet) *profileFlags {
+	return &profileFlags{
+		cpu: fs.String("cpuprofile", "", "записать профиль CPU в файл (go tool pprof)"),
+		mem: fs.String("memprofile", "", "записать профиль памяти в файл при завершении"),
+	}
+}
+
+// start начинает запись профиля CPU. Возвращаемая функция останавливает ее и
+// записывает профиль памяти; ее нужно вызвать при завершении команды.
+func (f *profileFlags) start() (func(), error) {
+	var cpuFile *os.File
+	if *f.cpu != "" {
+		file, err := os.Create(*f.cpu)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка создания файла профиля CPU: %w", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("ошибка запуска профилирования CPU: %w", err)
+		}
+		cpuFile = file
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		if *f.mem == "" {
+			return
+		}
+		file, err := os.Create(*f.mem)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка создания файла профиля памяти: %v\n", err)
+			return
+		}
+		defer file.Close()
+		// Профиль показывает память, занятую после сборки мусора
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(file); err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка записи профиля памяти: %v\n", err)
+		}
+	}, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/analysis/aggregator"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/crash"
+	"github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/internal/latency"
+	"github.com/skalibog/bfma/internal/state"
+	"github.com/skalibog/bfma/internal/ui"
+	"github.com/skalibog/bfma/internal/usage"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// reloader применяет изменения конфигурации к работающему приложению
+type reloader struct {
+	mu         sync.RWMutex
+	cfg        *config.Config
+	plain      bool // Текстовый режим включен флагом --plain
+	headless   bool // Вывод JSON включен флагом --headless
+	analyzer   *aggregator.Analyzer
+	collectors *exchange.SymbolCollectors
+	symbols    *exchange.SymbolDirectory
+	ui         *ui.TermUI
+	uiState    *state.UI          // Настройки, измененные в интерфейсе; nil - не применяются
+	intervalC  chan time.Duration // Новый период анализа
+}
+
+// newReloader создает обработчик перезагрузки конфигурации
+func newReloader(cfg *config.Config, plain, headless bool) *reloader {
+	return &reloader{
+		cfg:       cfg,
+		plain:     plain,
+		headless:  headless,
+		intervalC: make(chan time.Duration, 1),
+	}
+}
+
+// config возвращает действующую конфигурацию
+func (r *reloader) config() *config.Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cfg
+}
+
+// apply применяет безопасные изменения новой конфигурации next, прочитанной из файлов,
+// и сообщает о тех, что требуют перезапуска. Изменения сравниваются с действующей
+// конфигурацией после тех же поправок флагами и состоянием интерфейса.
+func (r *reloader) apply(ctx context.Context, next *config.Config) {
+	prev := r.config()
+	if r.uiState != nil {
+		if err := r.uiState.Apply(&next.UI); err != nil {
+			logger.Warn("Ошибка сохранения настроек интерфейса", zap.Error(err))
+		}
+	}
+	if r.plain {
+		next.UI.Plain = true
+	}
+	if r.headless {
+		next.UI.Headless = true
+	}
+	if next.UI.Headless && next.Logging.Stdout {
+		next.Logging.Stdout, next.Logging.Stderr = false, true
+	}
+
+	restart := config.RestartRequired(prev, next)
+	if prev.UI.Plain != next.UI.Plain {
+		restart = append(restart, "ui.plain")
+	}
+	if prev.UI.Headless != next.UI.Headless {
+		restart = append(restart, "ui.headless")
+	}
+	if len(restart) > 0 {
+		logger.Warn("Изменения конфигурации вступят в силу после перезапуска", zap.Strings("sections", restart))
+		r.ui.NotifyRestartRequired(restart)
+	}
+
+	fields := map[string]string{"changed": strings.Join(changedSections(prev, next), ",")}
+	if len(restart) > 0 {
+		fields["restart_required"] = strings.Join(restart, ",")
+	}
+	events.Record(events.TypeConfig, "", "конфигурация перезагружена", fields)
+
+	r.mu.Lock()
+	r.cfg = next
+	r.mu.Unlock()
+	crash.SetConfigHash(config.Hash(next))
+
+	if prev.Timezone != next.Timezone {
+		if err := timezone.Set(next.Timezone); err != nil {
+			logger.Error("Ошибка смены часового пояса", zap.Error(err))
+		} else {
+			logger.Info("Часовой пояс изменен", zap.String("timezone", timezone.Location().String()))
+		}
+	}
+
+	if prev.Logging.Level != next.Logging.Level || !reflect.DeepEqual(prev.Logging.Levels, next.Logging.Levels) {
+		if err := logger.SetLevels(next.Logging.Level, next.Logging.Levels); err != nil {
+			logger.Error("Ошибка смены уровней логирования", zap.Error(err))
+		} else {
+			logger.Info("Уровни логирования изменены", zap.String("level", next.Logging.Level), zap.Any("levels", next.Logging.Levels))
+		}
+	}
+
+	if prev.Latency != next.Latency {
+		latency.Configure(next.Latency)
+	}
+	if prev.Usage != next.Usage {
+		usage.Configure(next.Usage)
+	}
+
+	if !reflect.DeepEqual(prev.Analysis, next.Analysis) {
+		r.analyzer.UpdateConfig(next.Analysis)
+	}
+	if !reflect.DeepEqual(prev.Groups, next.Groups) {
+		r.analyzer.UpdateGroups(next.Groups)
+	}
+
+	if prev.Analysis.Period != next.Analysis.Period && next.Analysis.Period > 0 {
+		select {
+		case <-r.intervalC:
+		default:
+		}
+		r.intervalC <- next.Analysis.Period.Std()
+	}
+
+	// Сборщики создаются с параметрами из конфигурации, поэтому перезапускаем те,
+	// у которых сменился интервал свечей или глубина стакана
+	for _, symbol := range r.collectors.Symbols() {
+		if prev.IntervalFor(symbol) == next.IntervalFor(symbol) &&
+			prev.AnalysisFor(symbol).OrderBook.Depth == next.AnalysisFor(symbol).OrderBook.Depth {
+			continue
+		}
+		logger.Info("Перезапуск сборщиков данных с новыми параметрами", zap.String("symbol", symbol))
+		r.collectors.Remove(symbol)
+		if err := r.collectors.Add(ctx, symbol); err != nil {
+			logger.Error("Ошибка перезапуска сборщика данных", zap.String("symbol", symbol), zap.Error(err))
+		}
+	}
+
+	prevSymbols, nextSymbols := prev.TrackedSymbols(), next.TrackedSymbols()
+	for _, symbol := range nextSymbols {
+		if slices.Contains(prevSymbols, symbol) {
+			continue
+		}
+		if err := r.symbols.Check(symbol); err != nil {
+			logger.Warn("Символ не добавлен в анализ", zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		if err := r.collectors.Add(ctx, symbol); err != nil {
+			logger.Error("Ошибка запуска сборщика данных", zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		r.analyzer.AddSymbol(symbol)
+	}
+	for _, symbol := range prevSymbols {
+		if !slices.Contains(nextSymbols, symbol) {
+			r.analyzer.RemoveSymbol(symbol)
+			r.collectors.Remove(symbol)
+		}
+	}
+
+	if prev.Output.SchemaVersion != next.Output.SchemaVersion {
+		r.ui.SetSchemaVersion(next.Output.SchemaVersion)
+	}
+
+	if !reflect.DeepEqual(prev.UI, next.UI) {
+		r.ui.ApplyConfig(next.UI)
+	}
+}
+
+// changedSections возвращает секции верхнего уровня config.yaml, значения которых изменились
+func changedSections(prev, next *config.Config) []string {
+	var sections []string
+	prevValue, nextValue := reflect.ValueOf(prev).Elem(), reflect.ValueOf(next).Elem()
+	for i := 0; i < prevValue.NumField(); i++ {
+		name, _, _ := strings.Cut(prevValue.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(prevValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			sections = append(sections, name)
+		}
+	}
+	return sections
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/skalibog/bfma/internal/backtest"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/internal/ui"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// runReplay повторяет расчет сигналов по сохраненной истории с текущими настройками
+// анализа и выводит сигналы в CSV или JSON, как export signals
+func runReplay(args []string) int {
+	fs := newFlagSet("replay")
+	loadFlags := newConfigLoadFlags(fs)
+	period := newHistoryFlags(fs, 1)
+	stepFlag := fs.String("step", "1m", "шаг расчета сигналов: интервал свечей")
+	format := fs.String("format", "csv", "формат: csv или json")
+	output := fs.String("output", "-", "файл результата (- для вывода в консоль)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "неизвестный формат %q, допустимы: csv, json\n", *format)
+		return 2
+	}
+	step, err := parseStep(*stepFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--step: %v\n", err)
+		return 2
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	symbols, from, to, err := period.parse(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	store, err := storage.NewInfluxDBStorage(cfg.Storage)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	history, err := loadHistory(cfg, store, symbols, from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка загрузки истории: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var signals []*models.SignalResult
+	replay := backtest.NewReplay(cfg.Analysis, history)
+	err = replay.Run(ctx, from, to, step.Duration(), func(_ time.Time, results map[string]*models.SignalResult) error {
+		// Сигналы шага по символам, чтобы вывод не зависел от порядка расчета
+		batch := make([]*models.SignalResult, 0, len(results))
+		for _, result := range results {
+			batch = append(batch, result)
+		}
+		sort.Slice(batch, func(i, j int) bool { return batch[i].Symbol < batch[j].Symbol })
+		signals = append(signals, batch...)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка создания файла: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if *format == "json" {
+		err = writeSignalsJSON(out, signals, cfg.Output.SchemaVersion)
+	} else {
+		err = ui.WriteSignalsCSV(out, signals, nil)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *output != "-" {
+		fmt.Fprintf(os.Stderr, "Рассчитано сигналов: %d в %s\n", len(signals), *output)
+	}
+	return 0
+}
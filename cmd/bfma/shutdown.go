@@ -0,0 +1,45 @@
+package main
+
+import (
+	"time"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Время на завершение работы, если shutdown.timeout не задан
+const defaultShutdownTimeout = 10 * time.Second
+
+// shutdownStep компонент, останавливаемый при завершении работы
+type shutdownStep struct {
+	name string
+	stop func()
+}
+
+// shutdown останавливает компоненты по порядку и ждет не дольше timeout.
+// Возвращает код завершения: 1, если компоненты не успели остановиться.
+func shutdown(timeout time.Duration, steps []shutdownStep) int {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	logger.Info("Завершение работы", zap.Duration("timeout", timeout))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, step := range steps {
+			started := time.Now()
+			step.stop()
+			logger.Info("Компонент остановлен", zap.String("component", step.name), zap.Duration("elapsed", time.Since(started)))
+		}
+	}()
+
+	select {
+	case <-done:
+		logger.Info("Работа завершена")
+		return 0
+	case <-time.After(timeout):
+		logger.Error("Компоненты не остановились за отведенное время", zap.Duration("timeout", timeout))
+		return 1
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Время на получение сигналов
+const signalsTimeout = 15 * time.Second
+
+// runSignals выводит последние сигналы без запуска интерфейса (подкоманда signals).
+// Сигналы берутся из API работающего приложения (admin.enabled) или из хранилища.
+func runSignals(args []string) int {
+	fs := newFlagSet("signals")
+	loadFlags := newConfigLoadFlags(fs)
+	symbolsFlag := fs.String("symbols", "", "символы через запятую (по умолчанию все отслеживаемые)")
+	format := fs.String("format", "table", "формат вывода: table или json")
+	source := fs.String("source", "auto", "источник: auto (API работающего приложения, если недоступно - хранилище), admin или storage")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "неизвестный формат %q, допустимы: table, json\n", *format)
+		return 2
+	}
+	if *source != "auto" && *source != "admin" && *source != "storage" {
+		fmt.Fprintf(os.Stderr, "неизвестный источник %q, допустимы: auto, admin, storage\n", *source)
+		return 2
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := timezone.Set(cfg.Timezone); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var symbols []string
+	for _, symbol := range strings.Split(*symbolsFlag, ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), signalsTimeout)
+	defer cancel()
+
+	var signals []schema.SignalV3
+	switch {
+	case *source == "admin" || *source == "auto" && cfg.Admin.Enabled:
+		signals, err = adminSignals(ctx, cfg.Admin, symbols)
+		if err == nil || *source == "admin" {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "API приложения недоступно, сигналы читаются из хранилища: %v\n", err)
+		fallthrough
+	default:
+		if len(symbols) == 0 {
+			symbols = cfg.TrackedSymbols()
+		}
+		signals, err = storageSignals(ctx, cfg.Storage, symbols)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		return printSignalsJSON(signals, cfg.Output.SchemaVersion)
+	}
+	printSignalsTable(signals)
+	return 0
+}
+
+// adminSignals получает последние сигналы из API работающего приложения
+func adminSignals(ctx context.Context, cfg config.AdminConfig, symbols []string) ([]schema.SignalV3, error) {
+	listen := cfg.ListenAddr()
+	// Сервер, слушающий все интерфейсы, доступен по локальному адресу
+	if host, port, err := net.SplitHostPort(listen); err == nil && (host == "" || host == "0.0.0.0" || host == "::") {
+		listen = net.JoinHostPort("127.0.0.1", port)
+	}
+
+	query := url.Values{"schema_version": {fmt.Sprint(schema.Latest)}}
+	if len(symbols) > 0 {
+		query.Set("symbols", strings.Join(symbols, ","))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+listen+"/api/signals?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к API приложения: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return nil, fmt.Errorf("API приложения вернуло %s: %s", resp.Status, apiErr.Error)
+	}
+
+	var signals []schema.SignalV3
+	if err := json.NewDecoder(resp.Body).Decode(&signals); err != nil {
+		return nil, fmt.Errorf("ошибка разбора ответа API приложения: %w", err)
+	}
+	return signals, nil
+}
+
+// storageSignals читает последний сохраненный сигнал каждого символа из хранилища
+func storageSignals(ctx context.Context, cfg config.StorageConfig, symbols []string) ([]schema.SignalV3, error) {
+	store, err := storage.NewInfluxDBStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	var signals []schema.SignalV3
+	for _, symbol := range symbols {
+		history, err := store.GetSignalHistory(ctx, symbol, 1)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения сигналов %s: %w", symbol, err)
+		}
+		if len(history) > 0 {
+			signals = append(signals, schema.Signal(history[0], nil))
+		}
+	}
+	return signals, nil
+}
+
+// printSignalsJSON выводит сигналы массивом JSON в версии схемы output.schema_version
+func printSignalsJSON(signals []schema.SignalV3, version int) int {
+	out := make([]interface{}, 0, len(signals))
+	for _, signal := range signals {
+		converted, err := schema.Convert(signal, version)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		out = append(out, converted)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка вывода сигналов: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printSignalsTable выводит сигналы таблицей
+func printSignalsTable(signals []schema.SignalV3) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tTIME\tRECOMMENDATION\tSTRENGTH\tPRICE")
+	for _, s := range signals {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%.8g\n",
+			s.Symbol, timezone.In(s.Timestamp).Format("2006-01-02 15:04:05"), s.Recommendation, s.SignalStrength, s.CurrentPrice)
+	}
+	w.Flush()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/skalibog/bfma/internal/backtest"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Данные, которые проверяет verify
+var verifyData = []string{backtest.DataCandles, backtest.DataFunding, backtest.DataOpenInterest, backtest.DataOrderBooks}
+
+// runVerify проверяет целостность истории в хранилище перед воспроизведением:
+// пропуски и повторы свечей, неверные цены, пропуски ставок, открытого интереса
+// и стаканов. При найденных проблемах завершается с кодом 1.
+func runVerify(args []string) int {
+	fs := newFlagSet("verify")
+	loadFlags := newConfigLoadFlags(fs)
+	period := newHistoryFlags(fs, 7)
+	intervalsFlag := fs.String("intervals", "1m,1h", "интервалы свечей через запятую")
+	dataFlag := fs.String("data", strings.Join(verifyData, ","), "проверяемые данные через запятую")
+	format := fs.String("format", "table", "формат вывода: table или json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "неизвестный формат %q, допустимы: table, json\n", *format)
+		return 2
+	}
+
+	var intervals []models.Interval
+	for _, value := range strings.Split(*intervalsFlag, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		interval, err := models.ParseInterval(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--intervals: %v\n", err)
+			return 2
+		}
+		intervals = append(intervals, interval)
+	}
+	checked := make(map[string]bool)
+	for _, value := range strings.Split(*dataFlag, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		known := false
+		for _, data := range verifyData {
+			known = known || data == value
+		}
+		if !known {
+			fmt.Fprintf(os.Stderr, "--data: неизвестные данные %q, допустимы: %s\n", value, strings.Join(verifyData, ", "))
+			return 2
+		}
+		checked[value] = true
+	}
+
+	cfg, err := loadFlags.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	symbols, from, to, err := period.parse(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	store, err := storage.NewInfluxDBStorage(cfg.Storage)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyLoadTimeout)
+	defer cancel()
+	history, err := backtest.LoadHistory(ctx, store, symbols, intervals, from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка загрузки истории: %v\n", err)
+		return 1
+	}
+
+	issues := make([]backtest.Issue, 0)
+	for _, issue := range backtest.Verify(history, intervals) {
+		if checked[issue.Data] {
+			issues = append(issues, issue)
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка вывода результатов: %v\n", err)
+			return 1
+		}
+	} else if len(issues) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SYMBOL\tDATA\tINTERVAL\tFROM\tTO\tPROBLEM\t")
+		for _, issue := range issues {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t\n", issue.Symbol, issue.Data, issue.Interval,
+				formatIssueTime(issue.From), formatIssueTime(issue.To), issue.Message)
+		}
+		w.Flush()
+	}
+
+	if len(issues) > 0 {
+		fmt.Fprintf(os.Stderr, "Найдено проблем: %d\n", len(issues))
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Проблем не найдено: символов %d, период %s - %s\n", len(symbols),
+		formatIssueTime(from), formatIssueTime(to))
+	return 0
+}
+
+// formatIssueTime выводит время в часовом поясе из конфигурации; нулевое время - прочерк
+func formatIssueTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return timezone.In(t).Format("2006-01-02 15:04")
+}
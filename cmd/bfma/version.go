@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/skalibog/bfma/internal/version"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Время на запрос релизов
+const updateCheckTimeout = 15 * time.Second
+
+// runVersion выводит сведения о сборке и по --check проверяет новые релизы (подкоманда version)
+func runVersion(args []string) int {
+	fs := newFlagSet("version")
+	check := fs.Bool("check", false, "проверить новые релизы на GitHub")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	fmt.Println(version.String())
+	if !*check {
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	update, err := version.CheckUpdate(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if update == nil {
+		fmt.Println("Установлена последняя версия")
+		return 0
+	}
+
+	fmt.Printf("Доступна версия %s: %s\n", update.Latest.Tag, update.Latest.URL)
+	if len(update.Missed) > 1 {
+		fmt.Printf("Пропущенные релизы: %v\n", update.Missed)
+	}
+	if len(update.ConfigNotes) > 0 {
+		fmt.Println("\nИзменения конфигурации:")
+		for _, note := range update.ConfigNotes {
+			fmt.Printf("  %s\n", note)
+		}
+		fmt.Println("\nПосле обновления файлы можно привести к новой схеме: bfma config migrate")
+	}
+	return 0
+}
+
+// logUpdate проверяет новые релизы при запуске (updates.check) и пишет результат в журнал
+func logUpdate(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+
+	update, err := version.CheckUpdate(ctx)
+	if err != nil {
+		logger.Debug("Проверка обновлений не выполнена", zap.Error(err))
+		return
+	}
+	if update == nil {
+		logger.Debug("Установлена последняя версия bfma", zap.String("version", version.Version))
+		return
+	}
+
+	logger.Warn("Доступна новая версия bfma",
+		zap.String("current", version.Version),
+		zap.String("latest", update.Latest.Tag),
+		zap.String("url", update.Latest.URL))
+	for _, note := range update.ConfigNotes {
+		logger.Warn("Изменение конфигурации в новой версии", zap.String("note", note))
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/skalibog/bfma/internal/analysis/aggregator"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/crash"
+	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/internal/ui"
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// runWatch запускает анализ одного символа без InfluxDB и файла конфигурации
+// (подкоманда watch). Данные хранятся в памяти и теряются при выходе; для публичных
+// данных биржи ключи API не нужны.
+func runWatch(args []string) int {
+	fs := newFlagSet("watch")
+	loadFlags := newConfigLoadFlags(fs)
+	interval := fs.String("interval", "", "интервал свечей (по умолчанию trading.interval)")
+	testnet := fs.Bool("testnet", false, "данные testnet Binance")
+	plain := fs.Bool("plain", false, "текстовый вывод без рамок и цвета")
+
+	// Символ можно указать и до флагов: bfma watch BTCUSDT --plain
+	var symbol string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		symbol, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if symbol == "" && fs.NArg() > 0 {
+		symbol = fs.Arg(0)
+	}
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		fs.Usage()
+		return 2
+	}
+
+	// Без --config используются настройки по умолчанию; из файла берутся параметры
+	// анализа, интерфейса и ключи API, хранилище не используется
+	cfg := config.Default()
+	if loadFlags.configs.set {
+		loaded, err := loadFlags.load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		cfg = loaded
+	}
+	cfg.Storage.Type = "memory"
+	cfg.Trading.Symbols = []string{symbol}
+	cfg.Groups = nil
+	cfg.UI.Watchlists = nil
+	if *interval != "" {
+		cfg.Trading.Interval = models.Interval(*interval)
+	}
+	if *testnet {
+		cfg.Binance.Testnet = true
+	}
+	if *plain || !isTerminal(os.Stdout) {
+		cfg.UI.Plain = true
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := timezone.Set(cfg.Timezone); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := logger.Configure(cfg.Logging); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	logger.Info("Быстрый просмотр символа", zap.String("symbol", symbol), zap.Stringer("interval", cfg.Trading.Interval))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	store := storage.NewMemoryStorage()
+	health.SetQueueDepth(store.PendingWrites)
+
+	client, err := exchange.NewBinanceClient(cfg.Binance)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Неизвестный бирже или снятый с торгов символ сообщается сразу, а не пустым экраном
+	symbolDirectory := exchange.NewSymbolDirectory(client, store)
+	if err := symbolDirectory.Refresh(ctx); err != nil {
+		logger.Warn("Ошибка получения параметров символов", zap.Error(err))
+	}
+	if err := symbolDirectory.Check(symbol); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	bus := events.NewBus(crash.Recover)
+	analyzer := aggregator.NewAnalyzer(cfg.Analysis, store, client, cfg.Trading.Symbols, nil)
+	analyzer.SetEventBus(bus)
+	// Закрытые свечи сборщики ведут в памяти, анализаторы читают их без обращения к хранилищу
+	candleSeries := models.NewCandleSeriesSet()
+	analyzer.SetCandleSeries(candleSeries)
+	analyzer.SetFundingIntervals(symbolDirectory.FundingInterval)
+	userInterface, err := ui.NewTermUI(cfg.UI, analyzer, ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	userInterface.SetSchemaVersion(cfg.Output.SchemaVersion)
+	userInterface.OpenHistory(symbol)
+	userInterface.SetEventBus(bus)
+	events.Subscribe(bus, "ui", func(e events.SignalChanged) { userInterface.UpdateSignals(e.Signals) })
+	userInterface.SetAlertFilter(func(channel string) bool {
+		return cfg.Schedule.AlertsActive(channel, timezone.Now())
+	})
+
+	fundingBoard := exchange.NewFundingBoard()
+	userInterface.SetFundingSource(fundingBoard)
+	userInterface.SetSymbolSource(symbolDirectory)
+
+	collectors := exchange.NewSymbolCollectors(func(symbol string) []exchange.DataCollector {
+		symbols := []string{symbol}
+		candles := exchange.NewCandleCollector(client, store, symbols, cfg.IntervalFor(symbol))
+		candles.SetCandleSeries(candleSeries)
+		return []exchange.DataCollector{
+			candles,
+			exchange.NewOrderBookCollector(client, store, symbols, cfg.AnalysisFor(symbol).OrderBook.Depth),
+			exchange.NewFundingRateCollector(client, store, symbols),
+			exchange.NewOpenInterestCollector(client, store, symbols),
+			exchange.NewMarkPriceCollector(fundingBoard, symbols),
+		}
+	}, func(string) bool { return false })
+	// Закрытые свечи и ставки финансирования запускают расчет сигналов и перерисовку
+	collectors.SetEventBus(bus)
+
+	go func() {
+		if err := collectors.Add(ctx, symbol); err != nil {
+			logger.Error("Ошибка запуска сборщиков данных", zap.String("symbol", symbol), zap.Error(err))
+		}
+	}()
+	signalsActive := func() bool { return cfg.Schedule.SignalsActive(timezone.Now()) }
+	go analyzer.Run(ctx, cfg.Analysis.Period.Std(), nil, signalsActive)
+
+	userInterface.Start()
+	cancel()
+	return shutdown(cfg.Shutdown.Timeout.Std(), []shutdownStep{{name: "collectors", stop: collectors.StopAll}})
+}
@@ -0,0 +1,205 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Параметры анализа, которые меняются только через файл конфигурации
+var fixedAnalysisParams = []string{"period"}
+
+// Сколько последних записей журнала изменений отдавать
+const auditLimit = 100
+
+// AnalysisTarget - анализатор, параметры которого меняются через API
+type AnalysisTarget interface {
+	Config() config.AnalysisConfig
+	UpdateConfig(cfg config.AnalysisConfig)
+}
+
+// AuditEntry - запись журнала изменений параметров
+type AuditEntry struct {
+	Time   time.Time   `json:"time"`
+	Remote string      `json:"remote"`
+	Param  string      `json:"param"`
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+}
+
+// AnalysisAPI позволяет менять параметры анализа (веса, пороги, периоды) без перезапуска
+type AnalysisAPI struct {
+	target    AnalysisTarget
+	current   func() *config.Config // Действующая конфигурация для полной проверки
+	auditPath string
+	mu        sync.Mutex
+}
+
+// NewAnalysisAPI создает обработчики параметров анализа; изменения пишутся в auditPath
+func NewAnalysisAPI(target AnalysisTarget, current func() *config.Config, auditPath string) *AnalysisAPI {
+	return &AnalysisAPI{
+		target:    target,
+		current:   current,
+		auditPath: auditPath,
+	}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *AnalysisAPI) Register(s *Server) {
+	s.Handle("GET /api/analysis", a.get)
+	s.HandleAuthorized("PATCH /api/analysis", a.patch)
+	s.Handle("GET /api/analysis/audit", a.audit)
+}
+
+// get возвращает текущие параметры анализа
+func (a *AnalysisAPI) get(w http.ResponseWriter, r *http.Request) {
+	cfg := a.target.Config()
+	writeJSON(w, http.StatusOK, config.Params(&cfg))
+}
+
+// patch меняет параметры анализа. Тело запроса - объект {"путь": значение},
+// например {"technical.weight": 0.35, "orderbook.weight": 0.2}; все изменения
+// проверяются вместе и применяются сразу, начиная со следующего цикла анализа.
+func (a *AnalysisAPI) patch(w http.ResponseWriter, r *http.Request) {
+	var changes map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("ошибка разбора запроса: %w", err))
+		return
+	}
+	if len(changes) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("не указаны параметры"))
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cfg := a.target.Config()
+	before := config.Params(&cfg)
+
+	paths := make([]string, 0, len(changes))
+	for path := range changes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if slices.Contains(fixedAnalysisParams, path) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("параметр %s меняется только в файле конфигурации", path))
+			return
+		}
+		if err := config.SetParam(&cfg, path, fmt.Sprint(changes[path])); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// Проверяем конфигурацию целиком: сумму весов, порядок порогов и т.д.
+	full := *a.current()
+	full.Analysis = cfg
+	if err := full.Validate(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	a.target.UpdateConfig(cfg)
+
+	after := config.Params(&cfg)
+	var entries []AuditEntry
+	for _, path := range paths {
+		if reflect.DeepEqual(before[path], after[path]) {
+			continue
+		}
+		entry := AuditEntry{
+			Time:   time.Now(),
+			Remote: r.RemoteAddr,
+			Param:  "analysis." + path,
+			Old:    before[path],
+			New:    after[path],
+		}
+		entries = append(entries, entry)
+		logger.Info("Параметр анализа изменен через API",
+			zap.String("param", entry.Param), zap.Any("old", entry.Old), zap.Any("new", entry.New),
+			zap.String("remote", entry.Remote))
+	}
+
+	if err := a.writeAudit(entries); err != nil {
+		logger.Error("Ошибка записи журнала изменений", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"changes": entries,
+		"params":  after,
+	})
+}
+
+// audit возвращает последние записи журнала изменений
+func (a *AnalysisAPI) audit(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries, err := a.readAudit()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// writeAudit дописывает записи в журнал изменений (по одной записи JSON в строке)
+func (a *AnalysisAPI) writeAudit(entries []AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(a.auditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия журнала изменений: %w", err)
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("ошибка записи журнала изменений: %w", err)
+		}
+	}
+	return nil
+}
+
+// readAudit читает последние записи журнала изменений
+func (a *AnalysisAPI) readAudit() ([]AuditEntry, error) {
+	file, err := os.Open(a.auditPath)
+	if os.IsNotExist(err) {
+		return []AuditEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия журнала изменений: %w", err)
+	}
+	defer file.Close()
+
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+		if len(entries) > auditLimit {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
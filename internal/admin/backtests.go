@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Сколько запусков можно сравнить одним запросом
+const maxComparedBacktests = 20
+
+// BacktestSource - хранилище результатов проверки на истории
+type BacktestSource interface {
+	GetBacktestRuns(ctx context.Context, limit int) ([]*models.BacktestRun, error)
+	GetBacktestRun(ctx context.Context, id string) (*models.BacktestRun, error)
+}
+
+// BacktestsAPI выдает сохраненные запуски проверки стратегии на истории
+type BacktestsAPI struct {
+	source BacktestSource
+}
+
+// NewBacktestsAPI создает обработчики результатов проверки на истории
+func NewBacktestsAPI(source BacktestSource) *BacktestsAPI {
+	return &BacktestsAPI{source: source}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *BacktestsAPI) Register(s *Server) {
+	s.Handle("GET /api/v1/backtests", a.list)
+	s.Handle("GET /api/v1/backtests/compare", a.compare)
+	s.Handle("GET /api/v1/backtests/{id}", a.get)
+}
+
+// backtestComparison запуск в ответе сравнения: показатели и их разница с базовым запуском
+type backtestComparison struct {
+	Run  *models.BacktestRun     `json:"run"`
+	Diff models.PerformanceStats `json:"diff"`
+}
+
+// list возвращает запуски без сделок, новые первыми. Параметры: limit.
+func (a *BacktestsAPI) list(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	runs, err := a.source.GetBacktestRuns(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if runs == nil {
+		runs = []*models.BacktestRun{}
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// get возвращает запуск со сделками; 404, если запуск не найден
+func (a *BacktestsAPI) get(w http.ResponseWriter, r *http.Request) {
+	run, status, err := a.run(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// compare сравнивает запуски с базовым. Параметры: ids - запуски через запятую;
+// base - базовый запуск, по умолчанию первый из ids.
+func (a *BacktestsAPI) compare(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var ids []string
+	for _, id := range strings.Split(query.Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || len(ids) > maxComparedBacktests {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("ids должен содержать от 1 до %d запусков через запятую", maxComparedBacktests))
+		return
+	}
+	baseID := query.Get("base")
+	if baseID == "" {
+		baseID = ids[0]
+	}
+
+	base, status, err := a.run(r.Context(), baseID)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	result := make([]backtestComparison, 0, len(ids))
+	for _, id := range ids {
+		run, status, err := a.run(r.Context(), id)
+		if err != nil {
+			writeError(w, status, err)
+			return
+		}
+		result = append(result, backtestComparison{
+			Run:  run.Summary(),
+			Diff: run.Stats.Compare(base.Stats),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"base": base.Summary(),
+		"runs": result,
+	})
+}
+
+// run загружает запуск и возвращает HTTP-статус ошибки
+func (a *BacktestsAPI) run(ctx context.Context, id string) (*models.BacktestRun, int, error) {
+	run, err := a.source.GetBacktestRun(ctx, id)
+	if err != nil {
+		return nil, http.StatusBadGateway, err
+	}
+	if run == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("запуск проверки %q не найден", id)
+	}
+	return run, http.StatusOK, nil
+}
@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// CycleSource - хранилище аудита циклов анализа
+type CycleSource interface {
+	GetCycles(ctx context.Context, filter storage.CycleFilter) ([]*models.AnalysisCycle, error)
+}
+
+// CyclesAPI выдает аудит циклов анализа для разбора медленных циклов и частичных сбоев
+type CyclesAPI struct {
+	source CycleSource
+}
+
+// NewCyclesAPI создает обработчик аудита циклов анализа
+func NewCyclesAPI(source CycleSource) *CyclesAPI {
+	return &CyclesAPI{source: source}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *CyclesAPI) Register(s *Server) {
+	s.Handle("GET /api/analysis/cycles", a.list)
+}
+
+// cycleFailure ошибка анализатора в ответе API
+type cycleFailure struct {
+	Symbol    string `json:"symbol"`
+	Component string `json:"component,omitempty"`
+	Error     string `json:"error"`
+	Timeout   bool   `json:"timeout,omitempty"`
+}
+
+// cycle запись аудита цикла анализа в ответе API
+type cycle struct {
+	ID         string         `json:"id"`
+	Started    time.Time      `json:"started"`
+	DurationMs int64          `json:"duration_ms"`
+	Symbols    []string       `json:"symbols"`
+	Skipped    []string       `json:"skipped,omitempty"`
+	Failures   []cycleFailure `json:"failures,omitempty"`
+	Queries    int            `json:"queries"`
+}
+
+// list возвращает циклы анализа, новые первыми. Параметры: from и to (RFC 3339,
+// по умолчанию последние 30 дней), min_duration (например 2s), failed=true, limit.
+func (a *CyclesAPI) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter storage.CycleFilter
+
+	var err error
+	if filter.From, err = queryTime(query.Get("from"), "from"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.To, err = queryTime(query.Get("to"), "to"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if value := query.Get("min_duration"); value != "" {
+		if filter.MinDuration, err = time.ParseDuration(value); err != nil || filter.MinDuration < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("min_duration должен быть длительностью, например 2s, задано %q", value))
+			return
+		}
+	}
+	if value := query.Get("failed"); value != "" {
+		if filter.Failed, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed должен быть true или false, задано %q", value))
+			return
+		}
+	}
+	if filter.Limit, err = queryLimit(r); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	found, err := a.source.GetCycles(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	cycles := make([]cycle, 0, len(found))
+	for _, c := range found {
+		item := cycle{
+			ID:         c.ID,
+			Started:    timezone.In(c.Started),
+			DurationMs: c.Duration.Milliseconds(),
+			Symbols:    c.Symbols,
+			Skipped:    c.Skipped,
+			Queries:    c.Queries,
+		}
+		if item.Symbols == nil {
+			item.Symbols = []string{}
+		}
+		for _, f := range c.Failures {
+			item.Failures = append(item.Failures, cycleFailure{Symbol: f.Symbol, Component: f.Component, Error: f.Error, Timeout: f.Timeout})
+		}
+		cycles = append(cycles, item)
+	}
+	writeJSON(w, http.StatusOK, cycles)
+}
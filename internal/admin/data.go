@@ -0,0 +1,376 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/latency"
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Ограничения размера ответов API данных
+const (
+	defaultDataLimit = 100
+	maxDataLimit     = 1000
+)
+
+// DataSource - источник сигналов и символов для API данных
+type DataSource interface {
+	SignalSource
+	Symbols() []string
+	PausedSymbols() []string
+	MarketStates() map[string]*models.MarketState
+	GetSignalHistory(ctx context.Context, symbol string, limit int) ([]*models.SignalResult, error)
+	QuerySignals(ctx context.Context, query models.SignalQuery) (*models.SignalPage, error)
+}
+
+// CandleSource - хранилище свечей
+type CandleSource interface {
+	GetCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error)
+}
+
+// DataAPI - API только для чтения для внешних ботов и дашбордов: сигналы, история,
+// состояние конвейера, символы и свечи. Обслуживается отдельным сервером (секция api),
+// чтобы токен потребителя данных не давал права менять параметры.
+type DataAPI struct {
+	signals     *SignalsAPI
+	source      DataSource
+	candles     CandleSource
+	intervalFor func(symbol string) models.Interval // Интервал свечей символа по умолчанию
+}
+
+// NewDataAPI создает обработчики API данных
+func NewDataAPI(source DataSource, candles CandleSource, intervalFor func(symbol string) models.Interval, schemaVersion func() int) *DataAPI {
+	return &DataAPI{
+		signals:     NewSignalsAPI(source, schemaVersion),
+		source:      source,
+		candles:     candles,
+		intervalFor: intervalFor,
+	}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *DataAPI) Register(s *Server) {
+	s.Handle("GET /api/v1/signals", a.signals.get)
+	s.Handle("GET /api/v1/signals/history", a.query)
+	s.Handle("GET /api/v1/signals/{symbol}/history", a.history)
+	s.Handle("GET /api/v1/health", a.health)
+	s.Handle("GET /api/v1/symbols", a.symbols)
+	s.Handle("GET /api/v1/candles", a.candlesHandler)
+	s.Handle("GET /api/v1/market", a.market)
+	s.Handle("GET /api/v1/market/{symbol}", a.marketSymbol)
+}
+
+// history возвращает сохраненные сигналы символа, новые первыми. Параметры: limit, schema_version.
+func (a *DataAPI) history(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	limit, err := queryLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	version, err := a.signals.version(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	signals, err := a.source.GetSignalHistory(r.Context(), symbol, limit)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	result := make([]interface{}, 0, len(signals))
+	for _, signal := range signals {
+		encoded, err := schema.Encode(signal, nil, version)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		result = append(result, encoded)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// healthStream состояние потока данных в ответе API
+type healthStream struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	WebSocket   bool       `json:"websocket"`
+	Connections int        `json:"connections"`
+	LastData    *time.Time `json:"last_data,omitempty"`
+	Errors      int        `json:"errors"`
+}
+
+// healthDegradation подсистема в режиме деградации в ответе API
+type healthDegradation struct {
+	Subsystem string    `json:"subsystem"`
+	Reason    string    `json:"reason"`
+	Since     time.Time `json:"since"`
+}
+
+// healthLatency задержка этапа конвейера в ответе API
+type healthLatency struct {
+	Stage    string `json:"stage"`
+	Status   string `json:"status"`
+	P50Ms    int64  `json:"p50_ms"`
+	P95Ms    int64  `json:"p95_ms"`
+	P99Ms    int64  `json:"p99_ms"`
+	BudgetMs int64  `json:"budget_ms,omitempty"`
+}
+
+// health возвращает состояние конвейера данных. Если что-то в состоянии ошибки,
+// отвечает 503, чтобы API можно было использовать как проверку работоспособности.
+func (a *DataAPI) health(w http.ResponseWriter, r *http.Request) {
+	report, worst := healthReport()
+	status := http.StatusOK
+	if worst == health.SeverityError {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// healthReport собирает состояние потоков, очереди записи, анализа, деградацию подсистем
+// и задержку этапов для ответа API и возвращает худший уровень
+func healthReport() (map[string]interface{}, health.Severity) {
+	snapshot := health.Get()
+	now := time.Now()
+
+	worst := health.SeverityOK
+	note := func(severity health.Severity) string {
+		if severity > worst {
+			worst = severity
+		}
+		return severityName(severity)
+	}
+
+	streams := make([]healthStream, 0, len(snapshot.Streams))
+	for _, s := range snapshot.Streams {
+		stream := healthStream{
+			Name:        s.Name,
+			Status:      note(s.Severity(now)),
+			WebSocket:   s.WebSocket,
+			Connections: s.Connections,
+			Errors:      s.Errors,
+		}
+		if !s.LastData.IsZero() {
+			lastData := timezone.In(s.LastData)
+			stream.LastData = &lastData
+		}
+		streams = append(streams, stream)
+	}
+
+	report := map[string]interface{}{
+		"streams": streams,
+		"queue": map[string]interface{}{
+			"status":       note(snapshot.QueueSeverity()),
+			"depth":        snapshot.QueueDepth,
+			"write_errors": snapshot.WriteErrors,
+		},
+		"analysis": map[string]interface{}{
+			"status":      note(snapshot.AnalysisSeverity()),
+			"duration_ms": snapshot.AnalysisDuration.Milliseconds(),
+			"interval_ms": snapshot.AnalysisInterval.Milliseconds(),
+		},
+	}
+	if !snapshot.LastAnalysis.IsZero() {
+		report["analysis"].(map[string]interface{})["last"] = timezone.In(snapshot.LastAnalysis)
+	}
+
+	// Деградация подсистемы - предупреждение: bfma продолжает работу и пробует восстановиться
+	degraded := make([]healthDegradation, 0, len(snapshot.Degraded))
+	for _, d := range snapshot.Degraded {
+		note(health.SeverityWarn)
+		degraded = append(degraded, healthDegradation{Subsystem: d.Subsystem, Reason: d.Reason, Since: timezone.In(d.Since)})
+	}
+	report["degraded"] = degraded
+
+	// Задержка этапов выше бюджета - предупреждение: сигналы приходят с опозданием
+	stages := make([]healthLatency, 0, len(latency.Stages))
+	for _, stat := range latency.Get() {
+		severity := health.SeverityOK
+		if stat.OverBudget() {
+			severity = health.SeverityWarn
+		}
+		stages = append(stages, healthLatency{
+			Stage:    stat.Stage,
+			Status:   note(severity),
+			P50Ms:    stat.P50.Milliseconds(),
+			P95Ms:    stat.P95.Milliseconds(),
+			P99Ms:    stat.P99.Milliseconds(),
+			BudgetMs: stat.Budget.Milliseconds(),
+		})
+	}
+	report["latency"] = stages
+	report["status"] = severityName(worst)
+	return report, worst
+}
+
+// symbols возвращает отслеживаемые символы и приостановленные из них
+func (a *DataAPI) symbols(w http.ResponseWriter, r *http.Request) {
+	symbols := a.source.Symbols()
+	sort.Strings(symbols)
+	paused := a.source.PausedSymbols()
+	if paused == nil {
+		paused = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{
+		"symbols": symbols,
+		"paused":  paused,
+	})
+}
+
+// candle свеча в ответе API
+type candle struct {
+	OpenTime  time.Time      `json:"open_time"`
+	Open      models.Decimal `json:"open"`
+	High      models.Decimal `json:"high"`
+	Low       models.Decimal `json:"low"`
+	Close     models.Decimal `json:"close"`
+	Volume    models.Decimal `json:"volume"`
+	CloseTime time.Time      `json:"close_time"`
+
+	QuoteVolume         models.Decimal `json:"quote_volume"`
+	NumTrades           int64          `json:"num_trades"`
+	TakerBuyVolume      models.Decimal `json:"taker_buy_volume"`
+	TakerBuyQuoteVolume models.Decimal `json:"taker_buy_quote_volume"`
+}
+
+// candlesHandler возвращает свечи символа из хранилища. Параметры: symbol (обязательный),
+// interval (по умолчанию интервал сборщика символа), limit.
+func (a *DataAPI) candlesHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	if symbol == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("не указан параметр symbol"))
+		return
+	}
+	limit, err := queryLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	interval := a.intervalFor(symbol)
+	if value := r.URL.Query().Get("interval"); value != "" {
+		if interval, err = models.ParseInterval(value); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	candles, err := a.candles.GetCandles(r.Context(), symbol, interval, limit)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	result := make([]candle, 0, len(candles))
+	for _, c := range candles {
+		result = append(result, candle{
+			OpenTime:  timezone.In(c.OpenTime),
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Volume:    c.Volume,
+			CloseTime: timezone.In(c.CloseTime),
+
+			QuoteVolume:         c.QuoteVolume,
+			NumTrades:           c.NumTrades,
+			TakerBuyVolume:      c.TakerBuyVolume,
+			TakerBuyQuoteVolume: c.TakerBuyQuoteVolume,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"symbol":   symbol,
+		"interval": interval,
+		"candles":  result,
+	})
+}
+
+// market возвращает снимки рынка последнего цикла анализа всех символов по алфавиту
+func (a *DataAPI) market(w http.ResponseWriter, r *http.Request) {
+	states := a.source.MarketStates()
+	symbols := make([]string, 0, len(states))
+	for symbol := range states {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	result := make([]*models.MarketState, 0, len(symbols))
+	for _, symbol := range symbols {
+		result = append(result, marketStateIn(states[symbol]))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// marketSymbol возвращает снимок рынка последнего цикла анализа символа
+func (a *DataAPI) marketSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	state, ok := a.source.MarketStates()[symbol]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("снимка рынка для %s нет: символ не отслеживается или еще не анализировался", symbol))
+		return
+	}
+	writeJSON(w, http.StatusOK, marketStateIn(state))
+}
+
+// marketStateIn возвращает копию снимка рынка со временем в часовом поясе приложения
+func marketStateIn(state *models.MarketState) *models.MarketState {
+	result := *state
+	result.Timestamp = timezone.In(state.Timestamp)
+	if state.Candle != nil {
+		candle := *state.Candle
+		candle.OpenTime, candle.CloseTime = timezone.In(candle.OpenTime), timezone.In(candle.CloseTime)
+		result.Candle = &candle
+	}
+	if state.Book != nil {
+		book := *state.Book
+		book.Timestamp = timezone.In(book.Timestamp)
+		result.Book = &book
+	}
+	if state.Funding != nil {
+		funding := *state.Funding
+		funding.Timestamp, funding.NextFundingTime = timezone.In(funding.Timestamp), timezone.In(funding.NextFundingTime)
+		result.Funding = &funding
+	}
+	if state.OpenInterest != nil {
+		openInterest := *state.OpenInterest
+		openInterest.Timestamp = timezone.In(openInterest.Timestamp)
+		result.OpenInterest = &openInterest
+	}
+	return &result
+}
+
+// queryLimit разбирает параметр limit
+func queryLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultDataLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 || limit > maxDataLimit {
+		return 0, fmt.Errorf("limit должен быть в диапазоне 1..%d, задано %q", maxDataLimit, value)
+	}
+	return limit, nil
+}
+
+// severityName возвращает название уровня состояния для JSON
+func severityName(severity health.Severity) string {
+	switch severity {
+	case health.SeverityError:
+		return "error"
+	case health.SeverityWarn:
+		return "warn"
+	default:
+		return "ok"
+	}
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// EventSource - хранилище журнала событий
+type EventSource interface {
+	GetEvents(ctx context.Context, filter storage.EventFilter) ([]*models.Event, error)
+}
+
+// EventsAPI выдает журнал событий для аудита
+type EventsAPI struct {
+	source EventSource
+}
+
+// NewEventsAPI создает обработчик журнала событий
+func NewEventsAPI(source EventSource) *EventsAPI {
+	return &EventsAPI{source: source}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *EventsAPI) Register(s *Server) {
+	s.Handle("GET /api/events", a.list)
+}
+
+// list возвращает события журнала, новые первыми. Параметры: from и to (RFC 3339,
+// по умолчанию последние 30 дней), type (через запятую), symbol, limit.
+func (a *EventsAPI) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := storage.EventFilter{Symbol: strings.ToUpper(query.Get("symbol"))}
+
+	var err error
+	if filter.From, err = queryTime(query.Get("from"), "from"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.To, err = queryTime(query.Get("to"), "to"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if value := query.Get("type"); value != "" {
+		for _, eventType := range strings.Split(value, ",") {
+			eventType = strings.ToLower(strings.TrimSpace(eventType))
+			if !slices.Contains(events.Types, eventType) {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("неизвестный тип события %q: %s", eventType, strings.Join(events.Types, ", ")))
+				return
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+	if filter.Limit, err = queryLimit(r); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	found, err := a.source.GetEvents(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	for _, event := range found {
+		event.Timestamp = timezone.In(event.Timestamp)
+	}
+	if found == nil {
+		found = []*models.Event{}
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// queryTime разбирает время в формате RFC 3339; пустое значение - нулевое время
+func queryTime(value, name string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s должен быть в формате RFC 3339, например 2026-10-16T00:00:00Z, задано %q", name, value)
+	}
+	return t, nil
+}
@@ -0,0 +1,413 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/signalpb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Параметры потоков gRPC
+const (
+	grpcQueueSize    = 64              // Сообщений в очереди подписчика; переполнение закрывает поток
+	grpcStopTimeout  = 5 * time.Second // Время на завершение запросов при остановке
+	grpcEventAlert   = "alert"         // Вид события для оповещений без вида
+	grpcAuthMetadata = "authorization"
+)
+
+// GRPCSource - источник сигналов для gRPC API
+type GRPCSource interface {
+	SignalSource
+	QuerySignals(ctx context.Context, query models.SignalQuery) (*models.SignalPage, error)
+}
+
+// grpcSubscriber подписчик потока SubscribeSignals или SubscribeEvents
+type grpcSubscriber struct {
+	symbols map[string]bool // Фильтр символов; пустой - все символы
+	signals chan *signalpb.Signal
+	events  chan *signalpb.Event
+}
+
+// wants сообщает, нужно ли подписчику сообщение по символу
+func (s *grpcSubscriber) wants(symbol string) bool {
+	return symbol == "" || len(s.symbols) == 0 || s.symbols[symbol]
+}
+
+// GRPCServer - gRPC API сигналов (api/proto/bfma/v1/signals.proto) для ботов
+// исполнения: те же сигналы и история, что у API данных, и поток событий. Слушает
+// api.grpc_listen; токен api.token передается в метаданных authorization: Bearer <токен>.
+type GRPCServer struct {
+	signalpb.UnimplementedSignalServiceServer
+
+	listen string
+	token  string
+	source GRPCSource
+	server *grpc.Server
+
+	subscribers map[*grpcSubscriber]struct{}
+	closed      bool
+	mutex       sync.Mutex
+}
+
+// NewGRPCServer создает сервер gRPC API сигналов
+func NewGRPCServer(listen, token string, source GRPCSource) *GRPCServer {
+	s := &GRPCServer{
+		listen:      listen,
+		token:       token,
+		source:      source,
+		subscribers: make(map[*grpcSubscriber]struct{}),
+	}
+	s.server = grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	signalpb.RegisterSignalServiceServer(s.server, s)
+	return s
+}
+
+// Start запускает сервер и останавливает его при отмене контекста
+func (s *GRPCServer) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.listen)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.closeSubscribers()
+
+		stopped := make(chan struct{})
+		go func() {
+			s.server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(grpcStopTimeout):
+			s.server.Stop()
+		}
+	}()
+
+	logger.Info("gRPC API запущен", zap.String("listen", s.listen))
+	return s.server.Serve(listener)
+}
+
+// authorize проверяет токен Bearer в метаданных запроса, если он задан в настройках
+func (s *GRPCServer) authorize(ctx context.Context) error {
+	if s.token == "" {
+		return nil
+	}
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(grpcAuthMetadata); len(values) > 0 {
+			header = values[0]
+		}
+	}
+	if subtle.ConstantTimeCompare([]byte(header), []byte("Bearer "+s.token)) != 1 {
+		return status.Error(codes.Unauthenticated, "требуется авторизация")
+	}
+	return nil
+}
+
+// GetLatestSignals возвращает последние сигналы, отсортированные по символу
+func (s *GRPCServer) GetLatestSignals(ctx context.Context, req *signalpb.SignalsRequest) (*signalpb.SignalsResponse, error) {
+	signals := s.source.LatestSignals()
+	var symbols []string
+	if len(req.GetSymbols()) > 0 {
+		for _, symbol := range req.GetSymbols() {
+			symbol = strings.ToUpper(strings.TrimSpace(symbol))
+			if _, ok := signals[symbol]; ok {
+				symbols = append(symbols, symbol)
+			}
+		}
+	} else {
+		for symbol := range signals {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	response := &signalpb.SignalsResponse{}
+	for _, symbol := range symbols {
+		message, err := signalMessage(signals[symbol])
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		response.Signals = append(response.Signals, message)
+	}
+	return response, nil
+}
+
+// GetSignalHistory возвращает страницу истории сигналов с условиями HistoryRequest
+func (s *GRPCServer) GetSignalHistory(ctx context.Context, req *signalpb.HistoryRequest) (*signalpb.SignalsResponse, error) {
+	query, err := historyQuery(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, err := s.source.QuerySignals(ctx, query)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	response := &signalpb.SignalsResponse{NextCursor: page.NextCursor}
+	for _, signal := range page.Signals {
+		message, err := signalMessage(signal)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		response.Signals = append(response.Signals, message)
+	}
+	return response, nil
+}
+
+// SubscribeSignals отправляет новые сигналы символов запроса до отключения клиента
+func (s *GRPCServer) SubscribeSignals(req *signalpb.SubscribeRequest, stream signalpb.SignalService_SubscribeSignalsServer) error {
+	sub, err := s.subscribe(req, true)
+	if err != nil {
+		return err
+	}
+	defer s.unsubscribe(sub)
+
+	for {
+		select {
+		case message, ok := <-sub.signals:
+			if !ok {
+				return s.closedStatus()
+			}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// SubscribeEvents отправляет события приложения по символам запроса до отключения клиента
+func (s *GRPCServer) SubscribeEvents(req *signalpb.SubscribeRequest, stream signalpb.SignalService_SubscribeEventsServer) error {
+	sub, err := s.subscribe(req, false)
+	if err != nil {
+		return err
+	}
+	defer s.unsubscribe(sub)
+
+	for {
+		select {
+		case message, ok := <-sub.events:
+			if !ok {
+				return s.closedStatus()
+			}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// PublishSignals рассылает новые сигналы подписчикам SubscribeSignals
+func (s *GRPCServer) PublishSignals(signals map[string]*models.SignalResult) {
+	for symbol, signal := range signals {
+		message, err := signalMessage(signal)
+		if err != nil {
+			logger.Warn("Ошибка кодирования сигнала для gRPC", zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		s.broadcast(symbol, func(sub *grpcSubscriber) bool {
+			if sub.signals == nil {
+				return true
+			}
+			select {
+			case sub.signals <- message:
+				return true
+			default:
+				return false
+			}
+		})
+	}
+}
+
+// PublishAlert рассылает оповещение подписчикам SubscribeEvents
+func (s *GRPCServer) PublishAlert(e events.AlertFired) {
+	eventType := e.Type
+	if eventType == "" {
+		eventType = grpcEventAlert
+	}
+	message := &signalpb.Event{
+		Timestamp: timestamppb.New(e.Time),
+		Type:      eventType,
+		Symbol:    e.Symbol,
+		Message:   e.Text,
+		Critical:  e.Critical,
+	}
+	s.broadcast(e.Symbol, func(sub *grpcSubscriber) bool {
+		if sub.events == nil {
+			return true
+		}
+		select {
+		case sub.events <- message:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+// subscribe добавляет подписчика на сигналы (signals) или события
+func (s *GRPCServer) subscribe(req *signalpb.SubscribeRequest, signals bool) (*grpcSubscriber, error) {
+	sub := &grpcSubscriber{symbols: make(map[string]bool)}
+	for _, symbol := range req.GetSymbols() {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			sub.symbols[symbol] = true
+		}
+	}
+	if signals {
+		sub.signals = make(chan *signalpb.Signal, grpcQueueSize)
+	} else {
+		sub.events = make(chan *signalpb.Event, grpcQueueSize)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return nil, status.Error(codes.Unavailable, "сервер останавливается")
+	}
+	s.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// unsubscribe удаляет подписчика, если он еще не отключен
+func (s *GRPCServer) unsubscribe(sub *grpcSubscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.subscribers[sub]; ok {
+		s.remove(sub)
+	}
+}
+
+// broadcast передает сообщение подписчикам символа; send возвращает false, если
+// очередь подписчика заполнена. Подписчик, который не успевает принимать сообщения,
+// отключается.
+func (s *GRPCServer) broadcast(symbol string, send func(sub *grpcSubscriber) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for sub := range s.subscribers {
+		if !sub.wants(symbol) || send(sub) {
+			continue
+		}
+		logger.Warn("Клиент gRPC не успевает принимать сообщения, поток закрыт")
+		s.remove(sub)
+	}
+}
+
+// closeSubscribers отключает всех подписчиков при остановке сервера
+func (s *GRPCServer) closeSubscribers() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	for sub := range s.subscribers {
+		s.remove(sub)
+	}
+}
+
+// remove удаляет подписчика и закрывает его очередь; вызывается под мьютексом
+func (s *GRPCServer) remove(sub *grpcSubscriber) {
+	delete(s.subscribers, sub)
+	if sub.signals != nil {
+		close(sub.signals)
+	}
+	if sub.events != nil {
+		close(sub.events)
+	}
+}
+
+// closedStatus возвращает ошибку потока, закрытого сервером
+func (s *GRPCServer) closedStatus() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return status.Error(codes.Unavailable, "сервер останавливается")
+	}
+	return status.Error(codes.ResourceExhausted, "клиент не успевает принимать сообщения")
+}
+
+// signalMessage переводит сигнал в сообщение bfma.v1.Signal: модель кодируется
+// models.MarshalProto, поэтому поля совпадают с остальными форматами передачи
+func signalMessage(signal *models.SignalResult) (*signalpb.Signal, error) {
+	data, err := models.MarshalProto(signal)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка кодирования сигнала %s: %w", signal.Symbol, err)
+	}
+	message := &signalpb.Signal{}
+	if err := proto.Unmarshal(data, message); err != nil {
+		return nil, fmt.Errorf("ошибка разбора сигнала %s: %w", signal.Symbol, err)
+	}
+	return message, nil
+}
+
+// historyQuery переводит HistoryRequest в условия выборки истории сигналов
+func historyQuery(req *signalpb.HistoryRequest) (models.SignalQuery, error) {
+	query := models.SignalQuery{
+		MinStrength: req.GetMinStrength(),
+		Cursor:      req.GetCursor(),
+		Limit:       int(req.GetLimit()),
+	}
+	if symbol := strings.TrimSpace(req.GetSymbol()); symbol != "" {
+		query.Symbols = append(query.Symbols, symbol)
+	}
+	for _, symbol := range req.GetSymbols() {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			query.Symbols = append(query.Symbols, symbol)
+		}
+	}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	switch req.GetOrder() {
+	case signalpb.Order_ORDER_ASC:
+		query.Order = models.SignalOrderAsc
+	case signalpb.Order_ORDER_DESC:
+		query.Order = models.SignalOrderDesc
+	}
+	for _, value := range req.GetRecommendations() {
+		recommendation, err := models.ParseRecommendation(strings.TrimPrefix(value.String(), "RECOMMENDATION_"))
+		if err != nil {
+			return query, err
+		}
+		query.Recommendations = append(query.Recommendations, recommendation)
+	}
+	return query, query.Normalize()
+}
@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// signalPage страница истории сигналов в ответе API
+type signalPage struct {
+	Signals    []interface{} `json:"signals"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// query возвращает страницу истории сигналов. Параметры: symbols - символы через
+// запятую (по умолчанию все), from и to (RFC 3339, по умолчанию последние 30 дней),
+// min_strength - модуль силы сигнала не меньше, recommendation - коды рекомендаций
+// через запятую, order - desc (новые первыми, по умолчанию) или asc, cursor -
+// next_cursor предыдущей страницы, limit, schema_version.
+func (a *DataAPI) query(w http.ResponseWriter, r *http.Request) {
+	query, err := signalQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	version, err := a.signals.version(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := a.source.QuerySignals(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	result := signalPage{Signals: make([]interface{}, 0, len(page.Signals)), NextCursor: page.NextCursor}
+	for _, signal := range page.Signals {
+		encoded, err := schema.Encode(signal, nil, version)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		result.Signals = append(result.Signals, encoded)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// signalQuery разбирает условия выборки истории сигналов из параметров запроса
+func signalQuery(r *http.Request) (models.SignalQuery, error) {
+	values := r.URL.Query()
+	query := models.SignalQuery{
+		Symbols: splitList(values.Get("symbols")),
+		Order:   models.SignalOrder(strings.ToLower(values.Get("order"))),
+		Cursor:  values.Get("cursor"),
+	}
+
+	var err error
+	if query.From, err = queryTime(values.Get("from"), "from"); err != nil {
+		return query, err
+	}
+	if query.To, err = queryTime(values.Get("to"), "to"); err != nil {
+		return query, err
+	}
+	if value := values.Get("min_strength"); value != "" {
+		if query.MinStrength, err = strconv.ParseFloat(value, 64); err != nil {
+			return query, fmt.Errorf("min_strength должен быть числом, задано %q", value)
+		}
+	}
+	for _, value := range splitList(values.Get("recommendation")) {
+		recommendation, err := models.ParseRecommendation(value)
+		if err != nil {
+			return query, err
+		}
+		query.Recommendations = append(query.Recommendations, recommendation)
+	}
+	if values.Get("limit") != "" {
+		if query.Limit, err = queryLimit(r); err != nil {
+			return query, err
+		}
+	}
+
+	return query, query.Normalize()
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
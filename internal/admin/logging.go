@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Размер тела запроса смены уровней логирования
+const maxLoggingBody = 4 << 10
+
+// LoggingLevels - уровни логирования в ответе и в теле запроса:
+//
+//	{"level": "info", "levels": {"exchange": "debug", "ui": "warn"}}
+//
+// В запросе пустой level не меняет общий уровень, а пустой уровень компонента
+// снимает его, после чего для компонента действует общий.
+type LoggingLevels struct {
+	Level  string            `json:"level"`
+	Levels map[string]string `json:"levels"`
+	Debug  bool              `json:"debug"` // Отладочный уровень для всех компонентов включен по SIGUSR2
+}
+
+// Уровни, которые можно задать
+var loggingLevels = []string{"debug", "info", "warn", "error"}
+
+// LoggingAPI меняет уровни логирования без перезапуска. Изменения не сохраняются
+// в config.yaml: при перезагрузке конфигурации действуют уровни из файла.
+type LoggingAPI struct{}
+
+// NewLoggingAPI создает обработчики уровней логирования
+func NewLoggingAPI() *LoggingAPI {
+	return &LoggingAPI{}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *LoggingAPI) Register(s *Server) {
+	s.Handle("GET /api/logging", a.get)
+	s.Handle("PATCH /api/logging", a.patch)
+}
+
+// get возвращает действующие уровни
+func (a *LoggingAPI) get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, currentLevels())
+}
+
+// patch меняет общий уровень и уровни компонентов из запроса
+func (a *LoggingAPI) patch(w http.ResponseWriter, r *http.Request) {
+	var req LoggingLevels
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLoggingBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("ошибка разбора запроса: %w", err))
+		return
+	}
+
+	// Все уровни проверяются до применения, чтобы запрос не применился частично
+	req.Level = strings.ToLower(req.Level)
+	if req.Level != "" && !slices.Contains(loggingLevels, req.Level) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("неизвестный уровень %q, допустимы: %s", req.Level, strings.Join(loggingLevels, ", ")))
+		return
+	}
+	for component, level := range req.Levels {
+		level = strings.ToLower(level)
+		if component == "" {
+			writeError(w, http.StatusBadRequest, errors.New("пустое имя компонента"))
+			return
+		}
+		if level != "" && !slices.Contains(loggingLevels, level) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неизвестный уровень %q компонента %s, допустимы: %s", level, component, strings.Join(loggingLevels, ", ")))
+			return
+		}
+		req.Levels[component] = level
+	}
+
+	if req.Level != "" {
+		if err := logger.SetLevel("", req.Level); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	for component, level := range req.Levels {
+		if err := logger.SetLevel(component, level); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	levels := currentLevels()
+	logger.Warn("Уровни логирования изменены через API", zap.String("level", levels.Level),
+		zap.Any("levels", levels.Levels), zap.String("remote", r.RemoteAddr))
+	writeJSON(w, http.StatusOK, levels)
+}
+
+// currentLevels возвращает действующие уровни
+func currentLevels() LoggingLevels {
+	level, levels := logger.Levels()
+	return LoggingLevels{Level: level, Levels: levels, Debug: logger.Debugging()}
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/latency"
+	"github.com/skalibog/bfma/internal/usage"
+)
+
+// RegisterMetrics регистрирует /metrics в текстовом формате Prometheus: задержка от
+// события биржи до сигнала по этапам (p50/p95/p99), бюджеты этапов и учет нагрузки. Защищено токеном
+// API администрирования (bearer_token в настройках Prometheus).
+func RegisterMetrics(s *Server) {
+	s.Handle("GET /metrics", metrics)
+}
+
+// metrics отдает метрики в текстовом формате Prometheus
+func metrics(w http.ResponseWriter, r *http.Request) {
+	stats := latency.Get()
+	var b strings.Builder
+
+	b.WriteString("# HELP bfma_latency_seconds Задержка от события биржи до сигнала по этапам.\n")
+	b.WriteString("# TYPE bfma_latency_seconds summary\n")
+	for _, s := range stats {
+		for _, q := range []struct {
+			quantile string
+			value    time.Duration
+		}{{"0.5", s.P50}, {"0.95", s.P95}, {"0.99", s.P99}} {
+			fmt.Fprintf(&b, "bfma_latency_seconds{stage=%q,quantile=%q} %g\n", s.Stage, q.quantile, q.value.Seconds())
+		}
+		fmt.Fprintf(&b, "bfma_latency_seconds_sum{stage=%q} %g\n", s.Stage, s.Sum.Seconds())
+		fmt.Fprintf(&b, "bfma_latency_seconds_count{stage=%q} %d\n", s.Stage, s.Count)
+	}
+
+	b.WriteString("# HELP bfma_latency_budget_seconds Бюджет p95 задержки этапа.\n")
+	b.WriteString("# TYPE bfma_latency_budget_seconds gauge\n")
+	for _, s := range stats {
+		if s.Budget > 0 {
+			fmt.Fprintf(&b, "bfma_latency_budget_seconds{stage=%q} %g\n", s.Stage, s.Budget.Seconds())
+		}
+	}
+
+	b.WriteString("# HELP bfma_latency_over_budget p95 задержки этапа больше бюджета (1) или нет (0).\n")
+	b.WriteString("# TYPE bfma_latency_over_budget gauge\n")
+	for _, s := range stats {
+		over := 0
+		if s.OverBudget() {
+			over = 1
+		}
+		fmt.Fprintf(&b, "bfma_latency_over_budget{stage=%q} %d\n", s.Stage, over)
+	}
+
+	writeUsageMetrics(&b)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// writeUsageMetrics добавляет счетчики нагрузки по символам и вес запросов к API
+func writeUsageMetrics(b *strings.Builder) {
+	report := usage.Get()
+
+	for _, counter := range []struct {
+		name, help string
+		value      func(c usage.Counters) int64
+	}{
+		{"bfma_ingested_messages_total", "Сообщения биржи по символам.", func(c usage.Counters) int64 { return c.Messages }},
+		{"bfma_storage_written_bytes_total", "Байты, записанные в хранилище, по символам.", func(c usage.Counters) int64 { return c.BytesWritten }},
+		{"bfma_api_weight_total", "Вес запросов к REST API Binance по символам.", func(c usage.Counters) int64 { return c.APIWeight }},
+	} {
+		fmt.Fprintf(b, "# HELP %s %s\n", counter.name, counter.help)
+		fmt.Fprintf(b, "# TYPE %s counter\n", counter.name)
+		for _, s := range report.Symbols {
+			fmt.Fprintf(b, "%s{symbol=%q} %d\n", counter.name, s.Symbol, counter.value(s.Counters))
+		}
+	}
+
+	b.WriteString("# HELP bfma_storage_queries_total Запросы к хранилищу.\n")
+	b.WriteString("# TYPE bfma_storage_queries_total counter\n")
+	fmt.Fprintf(b, "bfma_storage_queries_total %d\n", report.Total.Queries)
+
+	b.WriteString("# HELP bfma_api_used_weight_1m Вес запросов к API за текущую минуту по ответу Binance.\n")
+	b.WriteString("# TYPE bfma_api_used_weight_1m gauge\n")
+	fmt.Fprintf(b, "bfma_api_used_weight_1m %d\n", report.UsedWeight1m)
+}
@@ -0,0 +1,185 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/state"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Ограничения ручных поправок
+const (
+	maxOverrideBody   = 16 << 10
+	maxOverrideFactor = 10.0
+)
+
+// OverrideRequest - ручная поправка в теле запроса:
+//
+//	{"symbol": "BTCUSDT", "force": "neutral", "for": "4h", "reason": "новости ФРС"}
+//	{"symbol": "ETHUSDT", "weights": {"funding": 2}, "until": "2026-10-16T18:00:00Z"}
+type OverrideRequest struct {
+	Symbol  string             `json:"symbol"`
+	Force   string             `json:"force"`   // neutral, buy, strong_buy, sell, strong_sell
+	Weights map[string]float64 `json:"weights"` // Компонент -> множитель веса 0..10
+	For     string             `json:"for"`     // Срок действия: 30m, 4h
+	Until   time.Time          `json:"until"`   // Или время окончания
+	Reason  string             `json:"reason"`
+}
+
+// OverridesAPI принимает ручные поправки сигналов: принудительную рекомендацию символа
+// и множители весов компонентов на заданный срок
+type OverridesAPI struct {
+	overrides *state.Overrides
+	alert     func(symbol, text string, critical bool)
+}
+
+// NewOverridesAPI создает обработчики ручных поправок; о новых поправках и их отмене
+// сообщается оповещением панели
+func NewOverridesAPI(overrides *state.Overrides, alert func(symbol, text string, critical bool)) *OverridesAPI {
+	return &OverridesAPI{overrides: overrides, alert: alert}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *OverridesAPI) Register(s *Server) {
+	s.Handle("GET /api/overrides", a.list)
+	s.HandleAuthorized("POST /api/overrides", a.create)
+	s.HandleAuthorized("DELETE /api/overrides/{id}", a.remove)
+}
+
+// list возвращает действующие поправки
+func (a *OverridesAPI) list(w http.ResponseWriter, r *http.Request) {
+	active := a.overrides.Active("", time.Now())
+	if active == nil {
+		active = []state.Override{}
+	}
+	writeJSON(w, http.StatusOK, active)
+}
+
+// create проверяет и сохраняет поправку; она действует со следующего цикла анализа
+func (a *OverridesAPI) create(w http.ResponseWriter, r *http.Request) {
+	var req OverrideRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOverrideBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("ошибка разбора запроса: %w", err))
+		return
+	}
+
+	now := time.Now()
+	override, err := req.override(now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	override, err = a.overrides.Add(override)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	logger.Info("Добавлена ручная поправка", zap.String("id", override.ID), zap.String("symbol", override.Symbol),
+		zap.Stringer("force", override.Force), zap.Any("weights", override.Weights), zap.Time("until", override.Until),
+		zap.String("remote", r.RemoteAddr))
+	a.alert(override.Symbol, "ручная поправка до "+timezone.In(override.Until).Format("2006-01-02 15:04")+": "+describeOverride(override), false)
+	writeJSON(w, http.StatusCreated, override)
+}
+
+// remove отменяет поправку
+func (a *OverridesAPI) remove(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var symbol string
+	for _, override := range a.overrides.Active("", time.Now()) {
+		if override.ID == id {
+			symbol = override.Symbol
+		}
+	}
+
+	found, err := a.overrides.Remove(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("поправка %s не найдена", id))
+		return
+	}
+
+	logger.Info("Ручная поправка отменена", zap.String("id", id), zap.String("remote", r.RemoteAddr))
+	if symbol != "" {
+		a.alert(symbol, "ручная поправка отменена", false)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// override проверяет запрос и возвращает поправку
+func (req OverrideRequest) override(now time.Time) (state.Override, error) {
+	override := state.Override{
+		Symbol:  strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		Force:   models.Recommendation(strings.ToUpper(strings.TrimSpace(req.Force))),
+		Reason:  req.Reason,
+		Created: now,
+		Until:   req.Until,
+	}
+	if override.Symbol == "" {
+		return override, errors.New("не указан symbol")
+	}
+	if override.Force != "" && !override.Force.Valid() {
+		return override, fmt.Errorf("неизвестная рекомендация %q: neutral, buy, strong_buy, sell или strong_sell", req.Force)
+	}
+	for component, factor := range req.Weights {
+		component = strings.ToLower(component)
+		if !slices.Contains(state.OverrideComponents, component) {
+			return override, fmt.Errorf("неизвестный компонент %q: %s", component, strings.Join(state.OverrideComponents, ", "))
+		}
+		if factor < 0 || factor > maxOverrideFactor {
+			return override, fmt.Errorf("множитель %s должен быть в диапазоне 0..%v, задано %v", component, maxOverrideFactor, factor)
+		}
+		if override.Weights == nil {
+			override.Weights = make(map[string]float64)
+		}
+		override.Weights[component] = factor
+	}
+	if override.Force == "" && len(override.Weights) == 0 {
+		return override, errors.New("нет ни force, ни weights")
+	}
+
+	switch {
+	case req.For != "" && !req.Until.IsZero():
+		return override, errors.New("укажите for или until, но не оба")
+	case req.For != "":
+		duration, err := time.ParseDuration(req.For)
+		if err != nil || duration <= 0 {
+			return override, fmt.Errorf("неверный срок %q, например 30m или 4h", req.For)
+		}
+		override.Until = now.Add(duration)
+	case req.Until.IsZero():
+		return override, errors.New("укажите срок действия: for или until")
+	case !req.Until.After(now):
+		return override, errors.New("until уже прошло")
+	}
+	return override, nil
+}
+
+// describeOverride возвращает краткое описание поправки для оповещения
+func describeOverride(override state.Override) string {
+	var parts []string
+	if override.Force != "" {
+		parts = append(parts, "рекомендация "+override.Force.String())
+	}
+	for _, component := range state.OverrideComponents {
+		if factor, ok := override.Weights[component]; ok {
+			parts = append(parts, fmt.Sprintf("вес %s ×%v", component, factor))
+		}
+	}
+	if override.Reason != "" {
+		parts = append(parts, override.Reason)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,18 @@
+package admin
+
+import (
+	"net/http/pprof"
+)
+
+// RegisterPprof регистрирует профилирование net/http/pprof: /debug/pprof/ (профили
+// CPU, памяти, горутин и блокировок) и /debug/pprof/trace (runtime/trace). Доступно
+// только при admin.pprof и только с токеном API администрирования: профили раскрывают
+// память процесса, а снятие профиля CPU или trace нагружает его.
+func RegisterPprof(s *Server) {
+	s.HandleAuthorized("GET /debug/pprof/", pprof.Index)
+	s.HandleAuthorized("GET /debug/pprof/cmdline", pprof.Cmdline)
+	s.HandleAuthorized("GET /debug/pprof/profile", pprof.Profile)
+	s.HandleAuthorized("GET /debug/pprof/symbol", pprof.Symbol)
+	s.HandleAuthorized("POST /debug/pprof/symbol", pprof.Symbol)
+	s.HandleAuthorized("GET /debug/pprof/trace", pprof.Trace)
+}
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/skalibog/bfma/internal/health"
+)
+
+// Время на проверку хранилища в /readyz
+const probeTimeout = 3 * time.Second
+
+// Pinger - хранилище, доступность которого проверяет /readyz
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Probes - проверки для оркестратора (Kubernetes, Docker HEALTHCHECK). Доступны
+// без токена и отвечают 200 или 503.
+type Probes struct {
+	storage Pinger
+}
+
+// NewProbes создает обработчики /livez и /readyz
+func NewProbes(storage Pinger) *Probes {
+	return &Probes{storage: storage}
+}
+
+// Register регистрирует обработчики на сервере
+func (p *Probes) Register(s *Server) {
+	s.HandlePublic("GET /livez", p.livez)
+	s.HandlePublic("GET /readyz", p.readyz)
+}
+
+// livez отвечает, что процесс жив: цикл анализа не завис. Как и сторожевой таймер
+// systemd, зависший анализ означает, что процесс нужно перезапустить.
+func (p *Probes) livez(w http.ResponseWriter, r *http.Request) {
+	if health.Get().AnalysisStalled() {
+		writeProbe(w, errors.New("цикл анализа не завершался дольше трех периодов"))
+		return
+	}
+	writeProbe(w, nil)
+}
+
+// readyz отвечает, что приложение готово отдавать данные: хранилище доступно,
+// свечи получены и анализ хотя бы раз выполнен
+func (p *Probes) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), probeTimeout)
+	defer cancel()
+	if err := p.storage.Ping(ctx); err != nil {
+		writeProbe(w, err)
+		return
+	}
+
+	snapshot := health.Get()
+	candles := false
+	for _, s := range snapshot.Streams {
+		if s.Name == health.StreamCandles && !s.LastData.IsZero() {
+			candles = true
+		}
+	}
+	switch {
+	case !candles:
+		writeProbe(w, errors.New("свечи еще не получены"))
+	case snapshot.LastAnalysis.IsZero():
+		writeProbe(w, errors.New("анализ еще не выполнялся"))
+	default:
+		writeProbe(w, nil)
+	}
+}
+
+// writeProbe отправляет результат проверки
+func writeProbe(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "fail", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
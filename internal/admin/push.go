@@ -0,0 +1,398 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры соединений /ws
+const (
+	pushQueueSize     = 64               // Сообщений в очереди клиента; переполнение закрывает соединение
+	pushWriteTimeout  = 10 * time.Second // Время на отправку одного сообщения
+	pushPingPeriod    = 30 * time.Second // Период проверки соединения
+	pushHealthPeriod  = 5 * time.Second  // Период проверки изменений состояния конвейера
+	pushMaxReadLength = 4096             // Максимальный размер сообщения от клиента
+)
+
+// Типы сообщений /ws
+const (
+	PushSignal         = "signal"
+	PushAlert          = "alert"
+	PushHealth         = "health"
+	PushOrderBook      = "order_book"
+	PushOrderBookDelta = "order_book_delta"
+)
+
+// pushMessage сообщение клиенту /ws
+type pushMessage struct {
+	Type   string      `json:"type"`
+	Symbol string      `json:"symbol,omitempty"`
+	Time   time.Time   `json:"time"`
+	Data   interface{} `json:"data"`
+}
+
+// pushAlert данные оповещения
+type pushAlert struct {
+	Text     string `json:"text"`
+	Critical bool   `json:"critical"`
+}
+
+// pushCommand сообщение клиента: смена фильтра символов
+type pushCommand struct {
+	Action  string   `json:"action"` // subscribe или unsubscribe
+	Symbols []string `json:"symbols"`
+}
+
+// pushClient подключенный клиент /ws
+type pushClient struct {
+	conn    *websocket.Conn
+	version int // Версия схемы сигналов
+	send    chan []byte
+	mutex   sync.Mutex
+	symbols map[string]bool // Фильтр символов; пустой - все символы
+
+	orderBooks bool            // Клиент запросил стакан
+	books      map[string]bool // Символы, снимок стакана которых клиент уже получил
+}
+
+// wants сообщает, нужно ли клиенту сообщение по символу
+func (c *pushClient) wants(symbol string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return symbol == "" || len(c.symbols) == 0 || c.symbols[symbol]
+}
+
+// PushHub рассылает сигналы, оповещения и изменения состояния конвейера клиентам
+// WebSocket (/ws), чтобы веб-интерфейсам не нужно было опрашивать API данных
+type PushHub struct {
+	upgrader      websocket.Upgrader
+	schemaVersion func() int
+	clients       map[*pushClient]struct{}
+	books         map[string]*models.OrderBook // Последний разосланный стакан символа
+	mutex         sync.Mutex
+}
+
+// NewPushHub создает рассылку. allowedOrigins - источники (Origin) веб-страниц, которым
+// разрешено подключение; пустой список разрешает только страницы с того же адреса.
+func NewPushHub(schemaVersion func() int, allowedOrigins []string) *PushHub {
+	h := &PushHub{
+		schemaVersion: schemaVersion,
+		clients:       make(map[*pushClient]struct{}),
+		books:         make(map[string]*models.OrderBook),
+	}
+	if len(allowedOrigins) > 0 {
+		h.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin)
+		}
+	}
+	return h
+}
+
+// Register регистрирует обработчик на сервере
+func (h *PushHub) Register(s *Server) {
+	s.Handle("GET /ws", h.serve)
+}
+
+// serve принимает соединение WebSocket. Параметры: symbols - фильтр символов через
+// запятую, schema_version - версия схемы сигналов, orderbook=true - присылать стакан.
+func (h *PushHub) serve(w http.ResponseWriter, r *http.Request) {
+	version := h.schemaVersion()
+	if value := r.URL.Query().Get("schema_version"); value != "" {
+		if _, err := fmt.Sscan(value, &version); err != nil || !schema.Supported(version) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неподдерживаемая версия схемы %q, допустимы 1..%d", value, schema.Latest))
+			return
+		}
+	}
+	var orderBooks bool
+	if value := r.URL.Query().Get("orderbook"); value != "" {
+		var err error
+		if orderBooks, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неверное значение orderbook %q", value))
+			return
+		}
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade уже отправил ответ с ошибкой
+		logger.Debug("Ошибка подключения к /ws", zap.String("remote", r.RemoteAddr), zap.Error(err))
+		return
+	}
+
+	client := &pushClient{
+		conn:    conn,
+		version: version,
+		send:    make(chan []byte, pushQueueSize),
+		symbols: make(map[string]bool),
+
+		orderBooks: orderBooks,
+		books:      make(map[string]bool),
+	}
+	client.update("subscribe", splitSymbols(r.URL.Query()))
+
+	h.mutex.Lock()
+	h.clients[client] = struct{}{}
+	h.mutex.Unlock()
+	logger.Info("Клиент /ws подключен", zap.String("remote", r.RemoteAddr))
+
+	go h.write(client)
+	h.read(client)
+}
+
+// read принимает команды клиента до закрытия соединения
+func (h *PushHub) read(c *pushClient) {
+	defer h.remove(c)
+
+	c.conn.SetReadLimit(pushMaxReadLength)
+	c.conn.SetReadDeadline(time.Now().Add(2 * pushPingPeriod))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(2 * pushPingPeriod))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd pushCommand
+		if err := json.Unmarshal(data, &cmd); err != nil || cmd.Action != "subscribe" && cmd.Action != "unsubscribe" {
+			continue
+		}
+		c.update(cmd.Action, cmd.Symbols)
+	}
+}
+
+// write отправляет сообщения из очереди клиента и проверяет соединение
+func (h *PushHub) write(c *pushClient) {
+	ticker := time.NewTicker(pushPingPeriod)
+	defer ticker.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(pushWriteTimeout))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(pushWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// remove отключает клиента
+func (h *PushHub) remove(c *pushClient) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+		logger.Info("Клиент /ws отключен", zap.String("remote", c.conn.RemoteAddr().String()))
+	}
+}
+
+// update меняет фильтр символов клиента. После смены фильтра стакан символа
+// присылается заново полным снимком.
+func (c *pushClient) update(action string, symbols []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+		delete(c.books, symbol)
+		if action == "subscribe" {
+			c.symbols[symbol] = true
+		} else {
+			delete(c.symbols, symbol)
+		}
+	}
+}
+
+// PublishSignals рассылает новые сигналы
+func (h *PushHub) PublishSignals(signals map[string]*models.SignalResult) {
+	for symbol, signal := range signals {
+		encoded := make(map[int][]byte) // Сообщение в каждой версии схемы кодируется один раз
+		h.broadcast(symbol, func(c *pushClient) []byte {
+			if data, ok := encoded[c.version]; ok {
+				return data
+			}
+			payload, err := schema.Encode(signal, nil, c.version)
+			if err != nil {
+				logger.Warn("Ошибка кодирования сигнала для /ws", zap.String("symbol", symbol), zap.Error(err))
+				return nil
+			}
+			data := marshalPush(pushMessage{Type: PushSignal, Symbol: symbol, Time: timezone.In(signal.Timestamp), Data: payload})
+			encoded[c.version] = data
+			return data
+		})
+	}
+}
+
+// PublishAlert рассылает оповещение интерфейса
+func (h *PushHub) PublishAlert(symbol, text string, critical bool) {
+	data := marshalPush(pushMessage{
+		Type:   PushAlert,
+		Symbol: symbol,
+		Time:   timezone.In(time.Now()),
+		Data:   pushAlert{Text: text, Critical: critical},
+	})
+	h.broadcast(symbol, func(*pushClient) []byte { return data })
+}
+
+// PublishOrderBook рассылает стакан клиентам, запросившим его: клиент получает полный
+// снимок, а затем только изменения относительно предыдущего разосланного стакана
+func (h *PushHub) PublishOrderBook(orderBook *models.OrderBook) {
+	h.mutex.Lock()
+	prev := h.books[orderBook.Symbol]
+	h.books[orderBook.Symbol] = orderBook
+	h.mutex.Unlock()
+
+	var snapshot, delta []byte
+	if prev != nil {
+		if diff := models.DiffOrderBooks(prev, orderBook); !diff.Empty() {
+			delta = marshalPush(pushMessage{Type: PushOrderBookDelta, Symbol: orderBook.Symbol, Time: timezone.In(orderBook.Timestamp), Data: diff})
+		}
+	}
+	h.broadcast(orderBook.Symbol, func(c *pushClient) []byte {
+		if !c.orderBooks {
+			return nil
+		}
+		if !c.receivedBook(orderBook.Symbol) {
+			if snapshot == nil {
+				snapshot = marshalPush(pushMessage{Type: PushOrderBook, Symbol: orderBook.Symbol, Time: timezone.In(orderBook.Timestamp), Data: orderBook})
+			}
+			return snapshot
+		}
+		return delta
+	})
+}
+
+// receivedBook сообщает, получил ли клиент снимок стакана символа, и отмечает его
+// полученным: вызывающий отправляет снимок, если ответ false
+func (c *pushClient) receivedBook(symbol string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.books[symbol] {
+		return true
+	}
+	c.books[symbol] = true
+	return false
+}
+
+// WatchHealth рассылает состояние конвейера данных при изменении уровня потоков,
+// очереди записи, анализа, задержки этапов или деградации подсистем; работает
+// до отмены контекста
+func (h *PushHub) WatchHealth(ctx context.Context) {
+	ticker := time.NewTicker(pushHealthPeriod)
+	defer ticker.Stop()
+
+	var last string
+	for {
+		select {
+		case <-ticker.C:
+			report, _ := healthReport()
+			key := healthKey(report)
+			if key == last {
+				continue
+			}
+			last = key
+			data := marshalPush(pushMessage{Type: PushHealth, Time: timezone.In(time.Now()), Data: report})
+			h.broadcast("", func(*pushClient) []byte { return data })
+		case <-ctx.Done():
+			h.mutex.Lock()
+			for c := range h.clients {
+				delete(h.clients, c)
+				close(c.send)
+			}
+			h.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// broadcast ставит сообщение в очередь клиентов, подписанных на символ. Клиент,
+// который не успевает принимать сообщения, отключается.
+func (h *PushHub) broadcast(symbol string, message func(c *pushClient) []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for c := range h.clients {
+		if !c.wants(symbol) {
+			continue
+		}
+		data := message(c)
+		if data == nil {
+			continue
+		}
+		select {
+		case c.send <- data:
+		default:
+			logger.Warn("Клиент /ws не успевает принимать сообщения, соединение закрыто",
+				zap.String("remote", c.conn.RemoteAddr().String()))
+			delete(h.clients, c)
+			close(c.send)
+		}
+	}
+}
+
+// marshalPush кодирует сообщение в JSON
+func marshalPush(message pushMessage) []byte {
+	data, err := json.Marshal(message)
+	if err != nil {
+		logger.Warn("Ошибка кодирования сообщения /ws", zap.String("type", message.Type), zap.Error(err))
+		return nil
+	}
+	return data
+}
+
+// healthKey возвращает уровни всех частей отчета о состоянии для сравнения
+func healthKey(report map[string]interface{}) string {
+	var b strings.Builder
+	b.WriteString(report["status"].(string))
+	for _, s := range report["streams"].([]healthStream) {
+		b.WriteString("|" + s.Name + "=" + s.Status)
+	}
+	b.WriteString("|queue=" + report["queue"].(map[string]interface{})["status"].(string))
+	b.WriteString("|analysis=" + report["analysis"].(map[string]interface{})["status"].(string))
+	for _, d := range report["degraded"].([]healthDegradation) {
+		b.WriteString("|degraded=" + d.Subsystem)
+	}
+	for _, l := range report["latency"].([]healthLatency) {
+		b.WriteString("|latency:" + l.Stage + "=" + l.Status)
+	}
+	return b.String()
+}
+
+// splitSymbols возвращает символы из параметра symbols
+func splitSymbols(query url.Values) []string {
+	if value := query.Get("symbols"); value != "" {
+		return strings.Split(value, ",")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// CandidateSource - обзор рынка с кандидатами в списки наблюдения
+type CandidateSource interface {
+	Candidates() []*models.ScanCandidate
+}
+
+// ScannerAPI выдает кандидатов в списки наблюдения из обзора всех фьючерсов биржи
+type ScannerAPI struct {
+	source CandidateSource
+}
+
+// NewScannerAPI создает обработчики обзора рынка
+func NewScannerAPI(source CandidateSource) *ScannerAPI {
+	return &ScannerAPI{source: source}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *ScannerAPI) Register(s *Server) {
+	s.Handle("GET /api/v1/scanner/candidates", a.candidates)
+}
+
+// candidates возвращает кандидатов последнего обзора, самые необычные первыми
+func (a *ScannerAPI) candidates(w http.ResponseWriter, r *http.Request) {
+	candidates := a.source.Candidates()
+	if candidates == nil {
+		candidates = []*models.ScanCandidate{}
+	}
+	writeJSON(w, http.StatusOK, candidates)
+}
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Server - HTTP API работающего приложения: администрирование или данные
+type Server struct {
+	config config.AdminConfig
+	mux    *http.ServeMux
+	server *http.Server
+	public map[string]bool // Пути, доступные без токена
+}
+
+// NewServer создает сервер API; обработчики регистрируются через Handle. Без адреса
+// сервер слушает адрес администрирования по умолчанию.
+func NewServer(cfg config.AdminConfig) *Server {
+	cfg.Listen = cfg.ListenAddr()
+
+	s := &Server{
+		config: cfg,
+		mux:    http.NewServeMux(),
+		public: make(map[string]bool),
+	}
+	s.server = &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           s.authorize(s.mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handle регистрирует обработчик, например "GET /api/analysis"
+func (s *Server) Handle(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// HandleAuthorized регистрирует обработчик, опасный без авторизации: он меняет сигналы
+// или работу приложения (ручные поправки) либо раскрывает состояние процесса (pprof).
+// Без токена в настройках такой обработчик не регистрируется: иначе им мог бы
+// воспользоваться любой, кто достучался до адреса.
+func (s *Server) HandleAuthorized(pattern string, handler http.HandlerFunc) {
+	if s.config.Token == "" {
+		logger.Warn("Маршрут API отключен: не задан токен", zap.String("route", pattern))
+		return
+	}
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// HandlePublic регистрирует обработчик, доступный без токена, например проверки
+// оркестратора "GET /livez"
+func (s *Server) HandlePublic(pattern string, handler http.HandlerFunc) {
+	_, path, _ := strings.Cut(pattern, " ")
+	s.public[path] = true
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// Start запускает сервер и останавливает его при отмене контекста
+func (s *Server) Start(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("HTTP API запущен", zap.String("listen", s.config.Listen))
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authorize проверяет токен Bearer, если он задан в настройках
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.config.Token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + s.config.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.public[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := r.Header.Get("Authorization")
+		// Браузер не может задать заголовок при подключении WebSocket, поэтому для /ws
+		// токен принимается и в параметре access_token
+		if header == "" && websocket.IsWebSocketUpgrade(r) && r.URL.Query().Has("access_token") {
+			header = "Bearer " + r.URL.Query().Get("access_token")
+		}
+		if subtle.ConstantTimeCompare([]byte(header), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("требуется авторизация"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSON отправляет ответ в формате JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		logger.Warn("Ошибка отправки ответа API", zap.Error(err))
+	}
+}
+
+// writeError отправляет ошибку в формате JSON
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// SignalSource - источник последних рассчитанных сигналов
+type SignalSource interface {
+	LatestSignals() map[string]*models.SignalResult
+}
+
+// SignalsAPI отдает последние сигналы работающего приложения
+type SignalsAPI struct {
+	source        SignalSource
+	schemaVersion func() int // Версия схемы по умолчанию (output.schema_version)
+}
+
+// NewSignalsAPI создает обработчик последних сигналов
+func NewSignalsAPI(source SignalSource, schemaVersion func() int) *SignalsAPI {
+	return &SignalsAPI{
+		source:        source,
+		schemaVersion: schemaVersion,
+	}
+}
+
+// Register регистрирует обработчики на сервере
+func (a *SignalsAPI) Register(s *Server) {
+	s.Handle("GET /api/signals", a.get)
+}
+
+// get возвращает последние сигналы, отсортированные по символу. Параметры запроса:
+// symbols - символы через запятую (по умолчанию все), schema_version - версия схемы.
+func (a *SignalsAPI) get(w http.ResponseWriter, r *http.Request) {
+	version, err := a.version(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	signals := a.source.LatestSignals()
+	var symbols []string
+	if value := r.URL.Query().Get("symbols"); value != "" {
+		for _, symbol := range strings.Split(value, ",") {
+			symbol = strings.ToUpper(strings.TrimSpace(symbol))
+			if _, ok := signals[symbol]; ok {
+				symbols = append(symbols, symbol)
+			}
+		}
+	} else {
+		for symbol := range signals {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	result := make([]interface{}, 0, len(symbols))
+	for _, symbol := range symbols {
+		encoded, err := schema.Encode(signals[symbol], nil, version)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		result = append(result, encoded)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// version возвращает версию схемы из параметра schema_version или версию по умолчанию
+func (a *SignalsAPI) version(r *http.Request) (int, error) {
+	version := a.schemaVersion()
+	if value := r.URL.Query().Get("schema_version"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("неверная версия схемы %q", value)
+		}
+		version = v
+	}
+	if !schema.Supported(version) {
+		return 0, fmt.Errorf("неподдерживаемая версия схемы %d, допустимы 1..%d", version, schema.Latest)
+	}
+	return version, nil
+}
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/analysis/external"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Наибольший размер тела оповещения TradingView
+const maxTradingViewBody = 64 << 10
+
+// Символ оповещений в виде текста без параметра symbol
+const tradingViewSymbol = "TRADINGVIEW"
+
+// ExternalSignalTarget - приемник внешних сигналов для компонента external
+type ExternalSignalTarget interface {
+	SetExternalSignal(symbol string, value float64)
+}
+
+// TradingViewAlert - оповещение TradingView в JSON. Тело задается в поле "Сообщение"
+// оповещения, переменные TradingView подставляются при отправке:
+//
+//	{"passphrase": "...", "symbol": "{{ticker}}", "action": "{{strategy.order.action}}"}
+type TradingViewAlert struct {
+	Passphrase string   `json:"passphrase"`
+	Symbol     string   `json:"symbol"`   // BTCUSDT, BINANCE:BTCUSDT.P
+	Action     string   `json:"action"`   // buy/long, sell/short или flat/close/exit; пусто - только оповещение
+	Strength   *float64 `json:"strength"` // Сила сигнала 0..100 (по умолчанию 100), направление задает action
+	Message    string   `json:"message"`  // Текст оповещения в панели; пусто - без оповещения
+	Critical   bool     `json:"critical"`
+}
+
+// TradingViewAPI принимает оповещения TradingView: action становится внешним сигналом
+// символа, message - оповещением в панели и внешних каналах. Тело, которое не является
+// JSON, целиком считается текстом оповещения; символ и фраза тогда передаются
+// параметрами ?symbol= и ?passphrase=.
+type TradingViewAPI struct {
+	passphrase string
+	target     ExternalSignalTarget
+	alert      func(symbol, text string, critical bool)
+}
+
+// NewTradingViewAPI создает обработчик оповещений TradingView
+func NewTradingViewAPI(passphrase string, target ExternalSignalTarget, alert func(symbol, text string, critical bool)) *TradingViewAPI {
+	return &TradingViewAPI{passphrase: passphrase, target: target, alert: alert}
+}
+
+// Register регистрирует обработчик на сервере
+func (a *TradingViewAPI) Register(s *Server) {
+	s.Handle("POST /tradingview", a.receive)
+}
+
+// receive разбирает оповещение и передает сигнал анализатору
+func (a *TradingViewAPI) receive(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTradingViewBody))
+	if err != nil {
+		status := http.StatusBadRequest
+		if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	alert := TradingViewAlert{
+		Passphrase: r.URL.Query().Get("passphrase"),
+		Symbol:     r.URL.Query().Get("symbol"),
+	}
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("{")) {
+		if err := json.Unmarshal(body, &alert); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неверный JSON: %w", err))
+			return
+		}
+		if alert.Passphrase == "" {
+			alert.Passphrase = r.URL.Query().Get("passphrase")
+		}
+	} else {
+		alert.Message = string(body)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(alert.Passphrase), []byte(a.passphrase)) != 1 {
+		logger.Warn("Оповещение TradingView с неверной фразой", zap.String("remote", r.RemoteAddr))
+		writeError(w, http.StatusUnauthorized, errors.New("неверная фраза"))
+		return
+	}
+
+	symbol := normalizeTicker(alert.Symbol)
+	action := strings.ToLower(strings.TrimSpace(alert.Action))
+	if action == "" && alert.Message == "" {
+		writeError(w, http.StatusBadRequest, errors.New("нет ни action, ни message"))
+		return
+	}
+
+	result := map[string]interface{}{"symbol": symbol}
+	if action != "" {
+		if symbol == "" {
+			writeError(w, http.StatusBadRequest, errors.New("для сигнала нужен symbol"))
+			return
+		}
+		value, err := tradingViewValue(action, alert.Strength)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		a.target.SetExternalSignal(symbol, value)
+		result["signal"] = value
+	}
+	if alert.Message != "" {
+		if symbol == "" {
+			symbol = tradingViewSymbol
+		}
+		a.alert(symbol, alert.Message, alert.Critical)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// tradingViewValue переводит действие стратегии в значение компонента от -100 до 100
+func tradingViewValue(action string, strength *float64) (float64, error) {
+	value := external.MaxValue
+	if strength != nil {
+		if *strength < 0 || *strength > external.MaxValue {
+			return 0, fmt.Errorf("strength должно быть в диапазоне 0..%v, задано %v", external.MaxValue, *strength)
+		}
+		value = *strength
+	}
+
+	switch action {
+	case "buy", "long":
+		return value, nil
+	case "sell", "short":
+		return -value, nil
+	case "flat", "close", "exit":
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("неизвестное действие %q: buy, sell, long, short, flat, close или exit", action)
+	}
+}
+
+// normalizeTicker приводит тикер TradingView к символу Binance:
+// BINANCE:BTCUSDT.P -> BTCUSDT
+func normalizeTicker(ticker string) string {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if _, symbol, ok := strings.Cut(ticker, ":"); ok {
+		ticker = symbol
+	}
+	return strings.TrimSuffix(ticker, ".P")
+}
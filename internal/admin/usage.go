@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/skalibog/bfma/internal/usage"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Наибольшее число символов для оценки нагрузки
+const maxProjectSymbols = 10000
+
+// usageResponse итоги учета нагрузки в ответе API
+type usageResponse struct {
+	usage.Report
+	Projection *usage.Projection `json:"projection,omitempty"`
+}
+
+// RegisterUsage регистрирует /api/usage: сообщения биржи, записанные байты, вес запросов
+// к API и запросы к хранилищу по символам, за все время работы и по часам
+func RegisterUsage(s *Server) {
+	s.Handle("GET /api/usage", usageReport)
+}
+
+// usageReport возвращает итоги учета нагрузки. Параметр symbols (например 300) добавляет
+// оценку нагрузки при таком числе символов по средней нагрузке на символ.
+func usageReport(w http.ResponseWriter, r *http.Request) {
+	report := usage.Get()
+	report.Since = timezone.In(report.Since)
+	for i := range report.Hours {
+		report.Hours[i].Start = timezone.In(report.Hours[i].Start)
+	}
+	response := usageResponse{Report: report}
+
+	if value := r.URL.Query().Get("symbols"); value != "" {
+		target, err := strconv.Atoi(value)
+		if err != nil || target <= 0 || target > maxProjectSymbols {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("symbols должен быть в диапазоне 1..%d, задано %q", maxProjectSymbols, value))
+			return
+		}
+		projection := report.Project(target, time.Now())
+		response.Projection = &projection
+	}
+	writeJSON(w, http.StatusOK, response)
+}
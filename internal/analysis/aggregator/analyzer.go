@@ -2,86 +2,441 @@ package aggregator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"math"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/skalibog/bfma/internal/analysis/external"
 	"github.com/skalibog/bfma/internal/analysis/funding"
 	"github.com/skalibog/bfma/internal/analysis/oianalysis"
 	"github.com/skalibog/bfma/internal/analysis/orderbook"
 	"github.com/skalibog/bfma/internal/analysis/technical"
 	"github.com/skalibog/bfma/internal/analysis/volumedelta"
 	"github.com/skalibog/bfma/internal/config"
+	journal "github.com/skalibog/bfma/internal/events"
 	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/internal/latency"
+	"github.com/skalibog/bfma/internal/state"
 	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/internal/version"
+	"github.com/skalibog/bfma/pkg/clock"
+	"github.com/skalibog/bfma/pkg/events"
 	"github.com/skalibog/bfma/pkg/logger"
 	"github.com/skalibog/bfma/pkg/models"
 )
 
-// Analyzer объединяет все аналитические компоненты
-type Analyzer struct {
+// analyzerSet анализаторы, созданные с одними настройками
+type analyzerSet struct {
 	config          config.AnalysisConfig
-	storage         storage.Storage
-	client          *exchange.BinanceClient
 	technicalAnal   *technical.Analyzer
 	orderbookAnal   *orderbook.Analyzer
 	fundingAnal     *funding.Analyzer
 	oiAnal          *oianalysis.Analyzer
 	volumeDeltaAnal *volumedelta.Analyzer
-	symbols         []string
 }
 
-// NewAnalyzer создает новый анализатор
-func NewAnalyzer(cfg config.AnalysisConfig, storage storage.Storage, client *exchange.BinanceClient, symbols []string) *Analyzer {
-	return &Analyzer{
+// newAnalyzerSet создает анализаторы с указанными настройками; intervals - периоды
+// финансирования символов по параметрам биржи, может быть nil
+func newAnalyzerSet(cfg config.AnalysisConfig, intervals funding.IntervalSource) *analyzerSet {
+	set := &analyzerSet{
 		config:          cfg,
-		storage:         storage,
-		client:          client,
 		technicalAnal:   technical.NewAnalyzer(cfg.Technical),
 		orderbookAnal:   orderbook.NewAnalyzer(cfg.OrderBook),
 		fundingAnal:     funding.NewAnalyzer(cfg.Funding),
 		oiAnal:          oianalysis.NewAnalyzer(cfg.OpenInterest),
 		volumeDeltaAnal: volumedelta.NewAnalyzer(cfg.VolumeDelta),
-		symbols:         symbols, // Инициализируем из параметра
 	}
+	set.fundingAnal.SetIntervalSource(intervals)
+	return set
+}
+
+// Analyzer объединяет все аналитические компоненты
+type Analyzer struct {
+	config       config.AnalysisConfig
+	groups       []config.SymbolGroupConfig
+	configMutex  sync.RWMutex            // Защищает настройки и анализаторы при перезагрузке настроек
+	base         *analyzerSet            // Анализаторы с основными настройками
+	symbolSets   map[string]*analyzerSet // Анализаторы символов из групп с переопределениями
+	storage      storage.Storage
+	client       *exchange.BinanceClient
+	symbols      []string
+	symbolsMutex sync.RWMutex
+	pauses       *state.Pauses
+	latest       map[string]*models.SignalResult // Последний сигнал по символу
+	states       map[string]*models.MarketState  // Снимок рынка последнего сигнала символа
+	latestMutex  sync.RWMutex
+	saved        *state.Signals          // Последние сигналы на диске для продолжения после перезапуска
+	external     *external.Signals       // Внешние сигналы (TradingView) для компонента external
+	overrides    *state.Overrides        // Ручные поправки: принудительная рекомендация и множители весов
+	clock        clock.Clock             // Время сигналов
+	cycles       CycleSink               // Аудит циклов анализа; nil - не ведется
+	bus          *events.Bus             // Шина для сигналов цикла; nil - сигналы не публикуются
+	updates      chan struct{}           // Новые свечи и ставки финансирования с прошлого расчета; nil - без шины
+	series       *models.CandleSeriesSet // Закрытые свечи в памяти от сборщиков; nil - свечи читаются из хранилища
+	intervals    funding.IntervalSource  // Периоды финансирования по параметрам биржи; nil - только по собранным ставкам
+}
+
+// CycleSink хранилище аудита циклов анализа
+type CycleSink interface {
+	SaveCycle(ctx context.Context, cycle *models.AnalysisCycle) error
+}
+
+// cycleFailures ошибки анализаторов за цикл анализа
+type cycleFailures struct {
+	mutex sync.Mutex
+	list  []models.CycleFailure
+}
+
+// add отмечает ошибку анализатора component или расчета сигнала символа (component пустой)
+func (f *cycleFailures) add(symbol, component string, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.list = append(f.list, models.CycleFailure{
+		Symbol:    symbol,
+		Component: component,
+		Error:     err.Error(),
+		Timeout:   errors.Is(err, context.DeadlineExceeded),
+	})
+}
+
+// NewAnalyzer создает новый анализатор
+func NewAnalyzer(cfg config.AnalysisConfig, storage storage.Storage, client *exchange.BinanceClient, symbols []string, pauses *state.Pauses) *Analyzer {
+	a := &Analyzer{
+		config:   cfg,
+		storage:  storage,
+		client:   client,
+		symbols:  symbols, // Инициализируем из параметра
+		pauses:   pauses,
+		latest:   make(map[string]*models.SignalResult),
+		states:   make(map[string]*models.MarketState),
+		external: external.NewSignals(),
+		clock:    clock.Real,
+	}
+	a.rebuild()
+	return a
+}
+
+// SetClock задает часы, по которым отмечается время сигналов, например симулированные
+// при воспроизведении истории. Вызывается до первого GenerateSignals.
+func (a *Analyzer) SetClock(clk clock.Clock) {
+	a.clock = clk
+}
+
+// SetCycleAudit включает запись аудита каждого цикла анализа в sink
+func (a *Analyzer) SetCycleAudit(sink CycleSink) {
+	a.cycles = sink
+}
+
+// SetEventBus включает публикацию сигналов каждого цикла анализа событием
+// events.SignalChanged и подписывает цикл анализа (Run) на закрытые свечи и ставки
+// финансирования: сигналы пересчитываются только после новых данных. Вызывается до
+// первого GenerateSignals.
+func (a *Analyzer) SetEventBus(bus *events.Bus) {
+	a.bus = bus
+	if bus == nil {
+		return
+	}
+	a.updates = make(chan struct{}, 1)
+	events.Subscribe(bus, "analyzer", func(events.CandleClosed) { a.dataUpdated() })
+	events.Subscribe(bus, "analyzer", func(events.FundingUpdated) { a.dataUpdated() })
+}
+
+// dataUpdated отмечает новые данные для следующего расчета. Не блокирует: события
+// между расчетами сливаются в одно.
+func (a *Analyzer) dataUpdated() {
+	select {
+	case a.updates <- struct{}{}:
+	default:
+	}
+}
+
+// SetCandleSeries подключает ряды закрытых свечей, которые ведут сборщики свечей:
+// анализаторы читают из них свечи без обращения к хранилищу, а при его сбое технический
+// анализ продолжается по свечам в памяти. Вызывается до первого GenerateSignals.
+func (a *Analyzer) SetCandleSeries(series *models.CandleSeriesSet) {
+	a.series = series
+}
+
+// SetFundingIntervals подключает периоды финансирования символов по параметрам биржи:
+// анализ ставок приводит их к 8 часам, пока период нельзя определить по собранным
+// ставкам. Вызывается до первого GenerateSignals.
+func (a *Analyzer) SetFundingIntervals(source funding.IntervalSource) {
+	a.configMutex.Lock()
+	defer a.configMutex.Unlock()
+
+	a.intervals = source
+	a.rebuild()
+}
+
+// IsPaused сообщает, приостановлен ли анализ символа
+func (a *Analyzer) IsPaused(symbol string) bool {
+	return a.pauses != nil && a.pauses.IsPaused(symbol)
+}
+
+// PausedSymbols возвращает список приостановленных символов
+func (a *Analyzer) PausedSymbols() []string {
+	if a.pauses == nil {
+		return nil
+	}
+	return a.pauses.Symbols()
+}
+
+// TogglePause приостанавливает или возобновляет анализ символа
+func (a *Analyzer) TogglePause(symbol string) (bool, error) {
+	if a.pauses == nil {
+		return false, fmt.Errorf("приостановка символов не настроена")
+	}
+
+	paused, err := a.pauses.Toggle(symbol)
+	if err != nil {
+		return paused, err
+	}
+
+	if paused {
+		logger.Info("Анализ символа приостановлен", zap.String("symbol", symbol))
+	} else {
+		logger.Info("Анализ символа возобновлен", zap.String("symbol", symbol))
+	}
+	return paused, nil
 }
 
-// GenerateSignals генерирует сигналы для всех отслеживаемых символов
+// SetExternalSignal задает внешний сигнал символа от -100 до 100; он учитывается
+// с весом analysis.external.weight, пока не истечет analysis.external.ttl
+func (a *Analyzer) SetExternalSignal(symbol string, value float64) {
+	a.external.Set(symbol, value, a.clock.Now())
+	logger.Info("Получен внешний сигнал", zap.String("symbol", symbol), zap.Float64("signal", value))
+}
+
+// SetOverrides подключает ручные поправки сигналов. Вызывается до первого GenerateSignals.
+func (a *Analyzer) SetOverrides(overrides *state.Overrides) {
+	a.overrides = overrides
+}
+
+// Overrides возвращает действующие ручные поправки символа (пустой символ - всех символов)
+func (a *Analyzer) Overrides(symbol string) []state.Override {
+	if a.overrides == nil {
+		return nil
+	}
+	return a.overrides.Active(symbol, a.clock.Now())
+}
+
+// Symbols возвращает список отслеживаемых символов
+func (a *Analyzer) Symbols() []string {
+	a.symbolsMutex.RLock()
+	defer a.symbolsMutex.RUnlock()
+
+	return append([]string(nil), a.symbols...)
+}
+
+// AddSymbol добавляет символ в анализ. Возвращает false, если символ уже отслеживается.
+func (a *Analyzer) AddSymbol(symbol string) bool {
+	a.symbolsMutex.Lock()
+	defer a.symbolsMutex.Unlock()
+
+	for _, s := range a.symbols {
+		if s == symbol {
+			return false
+		}
+	}
+	a.symbols = append(a.symbols, symbol)
+	logger.Info("Символ добавлен в анализ", zap.String("symbol", symbol))
+	return true
+}
+
+// RemoveSymbol исключает символ из анализа
+func (a *Analyzer) RemoveSymbol(symbol string) {
+	a.symbolsMutex.Lock()
+	defer a.symbolsMutex.Unlock()
+
+	for i, s := range a.symbols {
+		if s == symbol {
+			a.symbols = append(a.symbols[:i:i], a.symbols[i+1:]...)
+			logger.Info("Символ исключен из анализа", zap.String("symbol", symbol))
+			return
+		}
+	}
+}
+
+// GenerateSignals генерирует сигналы для всех отслеживаемых символов; при включенном
+// аудите записывает итоги цикла
 func (a *Analyzer) GenerateSignals(ctx context.Context) (map[string]*models.SignalResult, error) {
 	// Используем наш внутренний список символов
-	symbols := a.symbols
+	symbols := a.Symbols()
+
+	cycle := &models.AnalysisCycle{Started: a.clock.Now()}
+	cycle.ID = cycle.Started.UTC().Format("20060102T150405.000Z")
+	begin := time.Now()
+	ctx, queries := storage.WithQueryCounter(ctx)
+	failures := &cycleFailures{}
 
 	results := make(map[string]*models.SignalResult)
+	states := make(map[string]*models.MarketState)
 	var wg sync.WaitGroup
 	var mutex sync.Mutex
 
 	for _, symbol := range symbols {
+		if a.IsPaused(symbol) {
+			cycle.Skipped = append(cycle.Skipped, symbol)
+			continue
+		}
+		cycle.Symbols = append(cycle.Symbols, symbol)
+
 		wg.Add(1)
 		go func(sym string) {
 			defer wg.Done()
 
-			signal, err := a.generateSignalForSymbol(ctx, sym)
+			signal, state, err := a.generateSignalForSymbol(ctx, sym, cycle.ID, failures)
 			if err != nil {
 				// Логируем ошибку, но продолжаем для других символов
-				fmt.Printf("Ошибка генерации сигнала для %s: %v\n", sym, err)
+				logger.Error("Ошибка генерации сигнала", zap.String("symbol", sym), zap.Error(err))
+				health.MarkFailure(health.SubsystemAnalysis)
+				failures.add(sym, "", err)
 				return
 			}
 
+			latency.MarkAnalyzed(sym)
+
 			mutex.Lock()
 			results[sym] = signal
+			states[sym] = state
 			mutex.Unlock()
 		}(symbol)
 	}
 
 	wg.Wait()
+
+	a.latestMutex.Lock()
+	previousSignals := make(map[string]*models.SignalResult, len(results))
+	for symbol, signal := range results {
+		previous := a.latest[symbol]
+		if previous == nil || previous.RecommendationCode != signal.RecommendationCode {
+			recordChange(previous, signal)
+		}
+		if previous != nil {
+			previousSignals[symbol] = previous
+		}
+		a.latest[symbol] = signal
+		a.states[symbol] = states[symbol]
+	}
+	a.latestMutex.Unlock()
+
+	if a.saved != nil && len(results) > 0 {
+		if err := a.saved.Update(results); err != nil {
+			logger.Warn("Ошибка сохранения последних сигналов", zap.Error(err))
+		}
+	}
+
+	if a.cycles != nil {
+		cycle.Duration = time.Since(begin)
+		cycle.Queries = int(queries.Load())
+		cycle.Failures = failures.list
+		sort.Slice(cycle.Failures, func(i, j int) bool {
+			if cycle.Failures[i].Symbol != cycle.Failures[j].Symbol {
+				return cycle.Failures[i].Symbol < cycle.Failures[j].Symbol
+			}
+			return cycle.Failures[i].Component < cycle.Failures[j].Component
+		})
+		if err := a.cycles.SaveCycle(ctx, cycle); err != nil {
+			logger.Warn("Ошибка сохранения аудита цикла анализа", zap.String("cycle", cycle.ID), zap.Error(err))
+		}
+	}
+
+	if len(results) > 0 {
+		a.bus.Publish(events.SignalChanged{Signals: results, Previous: previousSignals})
+	}
 	return results, nil
 }
 
-// generateSignalForSymbol генерирует сигнал для одного символа
-func (a *Analyzer) generateSignalForSymbol(ctx context.Context, symbol string) (*models.SignalResult, error) {
+// recordChange записывает смену рекомендации в журнал событий
+func recordChange(previous, signal *models.SignalResult) {
+	fields := map[string]string{
+		"to":       signal.RecommendationCode.String(),
+		"strength": strconv.FormatFloat(signal.SignalStrength, 'f', 1, 64),
+		"price":    strconv.FormatFloat(signal.CurrentPrice, 'f', -1, 64),
+	}
+	message := signal.Recommendation
+	if previous != nil {
+		fields["from"] = previous.RecommendationCode.String()
+		message = previous.Recommendation + " → " + signal.Recommendation
+	}
+	journal.Record(journal.TypeSignal, signal.Symbol, message, fields)
+}
+
+// MarketStates возвращает снимки рынка последнего цикла анализа отслеживаемых символов
+func (a *Analyzer) MarketStates() map[string]*models.MarketState {
+	symbols := a.Symbols()
+
+	a.latestMutex.RLock()
+	defer a.latestMutex.RUnlock()
+
+	states := make(map[string]*models.MarketState, len(symbols))
+	for _, symbol := range symbols {
+		if state, ok := a.states[symbol]; ok {
+			states[symbol] = state
+		}
+	}
+	return states
+}
+
+// RestoreSignals восстанавливает последние сигналы, рассчитанные до перезапуска,
+// и дальше сохраняет новые в store. Возвращает восстановленные сигналы.
+func (a *Analyzer) RestoreSignals(store *state.Signals) map[string]*models.SignalResult {
+	signals, saved := store.Latest()
+
+	a.latestMutex.Lock()
+	for symbol, signal := range signals {
+		a.latest[symbol] = signal
+	}
+	a.saved = store
+	a.latestMutex.Unlock()
+
+	if len(signals) > 0 {
+		logger.Info("Восстановлены сигналы до перезапуска", zap.Int("symbols", len(signals)), zap.Time("saved", saved))
+	}
+	return a.LatestSignals()
+}
+
+// LatestSignals возвращает последние рассчитанные сигналы отслеживаемых символов
+func (a *Analyzer) LatestSignals() map[string]*models.SignalResult {
+	symbols := a.Symbols()
+
+	a.latestMutex.RLock()
+	defer a.latestMutex.RUnlock()
+
+	signals := make(map[string]*models.SignalResult, len(symbols))
+	for _, symbol := range symbols {
+		if signal, ok := a.latest[symbol]; ok {
+			signals[symbol] = signal
+		}
+	}
+	return signals
+}
+
+// generateSignalForSymbol генерирует сигнал для одного символа в цикле анализа cycleID
+// и снимок рынка, по которому он рассчитан; ошибки анализаторов отмечаются в failures
+func (a *Analyzer) generateSignalForSymbol(ctx context.Context, symbol, cycleID string, failures *cycleFailures) (*models.SignalResult, *models.MarketState, error) {
 	// Получаем данные для анализа
-	interval := "1m" // Получаем из конфигурации или устанавливаем по умолчанию
+	interval := models.Interval1m // Получаем из конфигурации или устанавливаем по умолчанию
+
+	// Настройки могут смениться во время анализа, поэтому берем снимок
+	set := a.analyzersFor(symbol)
+	cfg := set.config
+	technicalAnal, orderbookAnal, fundingAnal := set.technicalAnal, set.orderbookAnal, set.fundingAnal
+	oiAnal, volumeDeltaAnal := set.oiAnal, set.volumeDeltaAnal
+	// Интервалы и время прочитанных данных сохраняются в сигнале
+	var series *models.CandleSeries
+	if a.series != nil {
+		series = a.series.Lookup(symbol)
+	}
+	window := newDataWindow(a.storage, series)
 
 	// Запускаем все анализаторы параллельно
 	var wg sync.WaitGroup
@@ -93,7 +448,7 @@ func (a *Analyzer) generateSignalForSymbol(ctx context.Context, symbol string) (
 	// Технический анализ
 	go func() {
 		defer wg.Done()
-		technicalSignal, technicalErr = a.technicalAnal.Analyze(ctx, a.storage, symbol, interval)
+		technicalSignal, technicalErr = technicalAnal.Analyze(ctx, window, symbol, interval)
 		logger.Debug("AGGREGATOR: Технический анализ завершен", zap.String("symbol", symbol), zap.Float64("signal", technicalSignal))
 
 	}()
@@ -101,100 +456,120 @@ func (a *Analyzer) generateSignalForSymbol(ctx context.Context, symbol string) (
 	// Анализ стакана
 	go func() {
 		defer wg.Done()
-		orderbookSignal, orderbookErr = a.orderbookAnal.Analyze(ctx, a.storage, symbol)
+		orderbookSignal, orderbookErr = orderbookAnal.Analyze(ctx, window, symbol)
 		logger.Debug("AGGREGATOR: Анализ стакана завершен", zap.String("symbol", symbol), zap.Float64("signal", orderbookSignal))
 	}()
 
 	// Анализ ставок финансирования
 	go func() {
 		defer wg.Done()
-		fundingSignal, fundingErr = a.fundingAnal.Analyze(ctx, a.storage, symbol)
+		fundingSignal, fundingErr = fundingAnal.Analyze(ctx, window, symbol)
 		logger.Debug("AGGREGATOR: Анализ ставок финансирования завершен", zap.String("symbol", symbol), zap.Float64("signal", fundingSignal))
 	}()
 
 	// Анализ открытого интереса
 	go func() {
 		defer wg.Done()
-		oiSignal, oiErr = a.oiAnal.Analyze(ctx, a.storage, symbol)
+		oiSignal, oiErr = oiAnal.Analyze(ctx, window, symbol)
 		logger.Debug("AGGREGATOR: Анализ открытого интереса завершен", zap.String("symbol", symbol), zap.Float64("signal", oiSignal))
 	}()
 
 	// Анализ дельты объемов
 	go func() {
 		defer wg.Done()
-		volumeDeltaSignal, volumeDeltaErr = a.volumeDeltaAnal.Analyze(ctx, a.storage, symbol)
+		volumeDeltaSignal, volumeDeltaErr = volumeDeltaAnal.Analyze(ctx, window, symbol)
 		logger.Debug("AGGREGATOR: Анализ дельты объемов завершен", zap.String("symbol", symbol), zap.Float64("signal", volumeDeltaSignal))
 	}()
 
 	wg.Wait()
 
 	if technicalErr != nil {
+		failures.add(symbol, "technical", technicalErr)
 		logger.Warn("Предупреждение: технический анализ недоступен",
 			zap.String("symbol", symbol),
 			zap.Error(technicalErr),
-			zap.Int("требуется_свечей", a.config.Technical.MACDSlow+a.config.Technical.MACDSignal))
+			zap.Int("требуется_свечей", cfg.Technical.MACDSlow+cfg.Technical.MACDSignal))
 		technicalSignal = 0
 	}
 	if orderbookErr != nil {
+		failures.add(symbol, "orderbook", orderbookErr)
 		logger.Warn("Предупреждение: анализ стакана недоступен", zap.String("symbol", symbol), zap.Error(orderbookErr))
 		orderbookSignal = 0
 	}
 	if fundingErr != nil {
+		failures.add(symbol, "funding", fundingErr)
 		logger.Warn("Предупреждение: анализ финансирования недоступен", zap.String("symbol", symbol), zap.Error(fundingErr))
 		fundingSignal = 0
 	}
 	if oiErr != nil {
+		failures.add(symbol, "openInterest", oiErr)
 		logger.Warn("Предупреждение: анализ открытого интереса недоступен", zap.String("symbol", symbol), zap.Error(oiErr))
 		oiSignal = 0
 	}
 	if volumeDeltaErr != nil {
+		failures.add(symbol, "volumeDelta", volumeDeltaErr)
 		logger.Warn("Предупреждение: анализ дельты объемов недоступен", zap.String("symbol", symbol), zap.Error(volumeDeltaErr))
 		volumeDeltaSignal = 0
 	}
 
+	// Без внешнего сигнала или после истечения его срока компонент равен 0
+	externalSignal, externalOK := a.external.Value(symbol, cfg.External.TTL.Std(), a.clock.Now())
+
+	// Ручные поправки меняют веса компонентов и могут задать рекомендацию
+	var force models.Recommendation
+	var factors map[string]float64
+	if a.overrides != nil {
+		force, factors = a.overrides.Resolve(symbol, a.clock.Now())
+		applyWeights(&cfg, factors)
+	}
+
 	// Взвешиваем сигналы
-	weightedSignal := (technicalSignal * a.config.Technical.Weight) +
-		(orderbookSignal * a.config.OrderBook.Weight) +
-		(fundingSignal * a.config.Funding.Weight) +
-		(oiSignal * a.config.OpenInterest.Weight) +
-		(volumeDeltaSignal * a.config.VolumeDelta.Weight)
+	weightedSignal := (technicalSignal * cfg.Technical.Weight) +
+		(orderbookSignal * cfg.OrderBook.Weight) +
+		(fundingSignal * cfg.Funding.Weight) +
+		(oiSignal * cfg.OpenInterest.Weight) +
+		(volumeDeltaSignal * cfg.VolumeDelta.Weight) +
+		(externalSignal * cfg.External.Weight)
 
 	// Определяем рекомендацию
-	var recommendation string
-	var positionSize float64
-
-	if weightedSignal >= a.config.SignalThresholds.StrongBuy {
-		recommendation = "СИЛЬНАЯ ПОКУПКА"
-		positionSize = 1.0
-	} else if weightedSignal >= a.config.SignalThresholds.Buy {
-		recommendation = "ПОКУПКА"
-		positionSize = 0.7
-	} else if weightedSignal <= a.config.SignalThresholds.StrongSell {
-		recommendation = "СИЛЬНАЯ ПРОДАЖА"
-		positionSize = 1.0
-	} else if weightedSignal <= a.config.SignalThresholds.Sell {
-		recommendation = "ПРОДАЖА"
-		positionSize = 0.7
-	} else {
-		recommendation = "НЕЙТРАЛЬНО"
-		positionSize = 0.0
+	recommendationCode := recommendationFor(weightedSignal, cfg.SignalThresholds)
+
+	// Принудительная рекомендация: сила сигнала ставится на порог рекомендации, чтобы
+	// потребители, сравнивающие силу с порогами, видели ту же рекомендацию
+	if force != "" && force != recommendationCode {
+		logger.Info("Рекомендация задана ручной поправкой", zap.String("symbol", symbol),
+			zap.Stringer("calculated", recommendationCode), zap.Stringer("forced", force), zap.Float64("signal", weightedSignal))
+		recommendationCode = force
+		weightedSignal = thresholdStrength(force, cfg.SignalThresholds)
 	}
 
+	// Ограничения направления и силы применяются последними, в том числе к рекомендации
+	// из ручной поправки: счет без коротких позиций не может исполнить и ее
+	constrained, biasFlags := applyBias(weightedSignal, cfg.Bias)
+	if len(biasFlags) > 0 {
+		logger.Debug("Сигнал ограничен analysis.bias", zap.String("symbol", symbol),
+			zap.Float64("signal", weightedSignal), zap.Float64("constrained", constrained), zap.Strings("flags", biasFlags))
+		weightedSignal = constrained
+		recommendationCode = recommendationFor(weightedSignal, cfg.SignalThresholds)
+	}
+	recommendation, positionSize := recommendationText(recommendationCode)
+
 	// Получаем текущие рыночные данные
 	currentPrice := 0.0
-	candles, err := a.storage.GetLatestCandles(ctx, symbol, interval, 1)
+	candles, err := window.GetLatestCandles(ctx, symbol, interval, 1)
 	if err == nil && len(candles) > 0 {
-		currentPrice = candles[0].Close
+		currentPrice = candles[0].Close.InexactFloat64()
 	}
 
 	// Формируем результат
 	result := &models.SignalResult{
-		Symbol:         symbol,
-		Timestamp:      time.Now(),
-		Recommendation: recommendation,
-		SignalStrength: weightedSignal,
-		PositionSize:   positionSize,
-		CurrentPrice:   currentPrice,
+		Symbol:             symbol,
+		Timestamp:          a.clock.Now(),
+		Recommendation:     recommendation,
+		RecommendationCode: recommendationCode,
+		SignalStrength:     weightedSignal,
+		PositionSize:       positionSize,
+		CurrentPrice:       currentPrice,
 		Components: map[string]float64{
 			"technical":    technicalSignal,
 			"orderbook":    orderbookSignal,
@@ -203,16 +578,260 @@ func (a *Analyzer) generateSignalForSymbol(ctx context.Context, symbol string) (
 			"volumeDelta":  volumeDeltaSignal,
 		},
 	}
+	if cfg.External.Weight > 0 {
+		result.Components["external"] = externalSignal
+	}
+
+	// Условия расчета, по которым сигнал понятен и после смены настроек
+	result.ID = symbol + "-" + cycleID
+	result.Intervals, result.DataFrom, result.DataTo = window.Window()
+	result.EngineVersion = version.Version
+	result.ConfigHash = config.AnalysisHash(cfg)
+	result.Weights = map[string]float64{
+		"technical":    cfg.Technical.Weight,
+		"orderbook":    cfg.OrderBook.Weight,
+		"funding":      cfg.Funding.Weight,
+		"openInterest": cfg.OpenInterest.Weight,
+		"volumeDelta":  cfg.VolumeDelta.Weight,
+	}
+	if cfg.External.Weight > 0 {
+		result.Weights["external"] = cfg.External.Weight
+	}
+	result.Confidence = confidence([]component{
+		{technicalSignal, cfg.Technical.Weight, technicalErr == nil},
+		{orderbookSignal, cfg.OrderBook.Weight, orderbookErr == nil},
+		{fundingSignal, cfg.Funding.Weight, fundingErr == nil},
+		{oiSignal, cfg.OpenInterest.Weight, oiErr == nil},
+		{volumeDeltaSignal, cfg.VolumeDelta.Weight, volumeDeltaErr == nil},
+		{externalSignal, cfg.External.Weight, externalOK},
+	})
+	if force != "" {
+		result.Flags = append(result.Flags, models.SignalFlagForced)
+	}
+	if len(factors) > 0 {
+		result.Flags = append(result.Flags, models.SignalFlagReweighted)
+	}
+	if technicalErr != nil || orderbookErr != nil || fundingErr != nil || oiErr != nil || volumeDeltaErr != nil {
+		result.Flags = append(result.Flags, models.SignalFlagDegraded)
+	}
+	result.Flags = append(result.Flags, biasFlags...)
 
 	// Сохраняем сигнал в хранилище
 	if err := a.storage.SaveSignal(ctx, result); err != nil {
-		fmt.Printf("Предупреждение: не удалось сохранить сигнал: %v\n", err)
+		logger.Warn("Не удалось сохранить сигнал", zap.String("symbol", symbol), zap.Error(err))
+	}
+
+	state := window.MarketState(symbol, interval, result.Timestamp)
+	state.SignalID = result.ID
+	return result, state, nil
+}
+
+// component значение компонента сигнала с весом; ok - компонент рассчитан
+type component struct {
+	value, weight float64
+	ok            bool
+}
+
+// confidence оценивает уверенность сигнала от 0 до 1: доля веса компонентов, рассчитанных
+// по данным, умноженная на согласованность их направлений (1 - все рассчитанные
+// компоненты указывают в одну сторону)
+func confidence(components []component) float64 {
+	var total, covered, sum, magnitude float64
+	for _, c := range components {
+		if c.weight <= 0 {
+			continue
+		}
+		total += c.weight
+		if !c.ok {
+			continue
+		}
+		covered += c.weight
+		sum += c.weight * c.value
+		magnitude += c.weight * math.Abs(c.value)
+	}
+	if total == 0 || magnitude == 0 {
+		return 0
+	}
+	return covered / total * math.Abs(sum) / magnitude
+}
+
+// signalText переводчик текста рекомендации в сигнале: сигналы хранят текст на локали
+// по умолчанию, программы сравнивают код рекомендации
+var signalText = func() *i18n.Translator {
+	tr, err := i18n.New(i18n.DefaultLocale)
+	if err != nil {
+		panic(fmt.Sprintf("каталог локали по умолчанию: %v", err))
+	}
+	return tr
+}()
+
+// recommendationText возвращает текст рекомендации и размер позиции
+func recommendationText(code models.Recommendation) (string, float64) {
+	switch code {
+	case models.RecommendationStrongBuy, models.RecommendationStrongSell:
+		return code.Localize(signalText), 1.0
+	case models.RecommendationBuy, models.RecommendationSell:
+		return code.Localize(signalText), 0.7
+	default:
+		return models.RecommendationNeutral.Localize(signalText), 0.0
 	}
+}
 
-	return result, nil
+// recommendationFor возвращает рекомендацию по силе сигнала и порогам
+func recommendationFor(strength float64, thresholds config.SignalThresholds) models.Recommendation {
+	switch {
+	case strength >= thresholds.StrongBuy:
+		return models.RecommendationStrongBuy
+	case strength >= thresholds.Buy:
+		return models.RecommendationBuy
+	case strength <= thresholds.StrongSell:
+		return models.RecommendationStrongSell
+	case strength <= thresholds.Sell:
+		return models.RecommendationSell
+	default:
+		return models.RecommendationNeutral
+	}
+}
+
+// applyBias ограничивает силу сигнала направлением и наибольшим модулем из настроек;
+// возвращает новую силу и признаки сигнала для ограничений, которые ее изменили
+func applyBias(strength float64, bias config.BiasConfig) (float64, []string) {
+	var flags []string
+	switch {
+	case bias.Direction == config.BiasLongOnly && strength < 0:
+		strength = 0
+		flags = append(flags, models.SignalFlagLongOnly)
+	case bias.Direction == config.BiasShortOnly && strength > 0:
+		strength = 0
+		flags = append(flags, models.SignalFlagShortOnly)
+	}
+	if bias.MaxStrength > 0 && math.Abs(strength) > bias.MaxStrength {
+		strength = math.Copysign(bias.MaxStrength, strength)
+		flags = append(flags, models.SignalFlagCapped)
+	}
+	return strength, flags
+}
+
+// thresholdStrength возвращает силу сигнала на пороге рекомендации
+func thresholdStrength(code models.Recommendation, thresholds config.SignalThresholds) float64 {
+	switch code {
+	case models.RecommendationStrongBuy:
+		return thresholds.StrongBuy
+	case models.RecommendationBuy:
+		return thresholds.Buy
+	case models.RecommendationStrongSell:
+		return thresholds.StrongSell
+	case models.RecommendationSell:
+		return thresholds.Sell
+	default:
+		return 0
+	}
+}
+
+// applyWeights умножает веса компонентов на множители ручных поправок
+func applyWeights(cfg *config.AnalysisConfig, factors map[string]float64) {
+	for component, factor := range factors {
+		switch component {
+		case "technical":
+			cfg.Technical.Weight *= factor
+		case "orderbook":
+			cfg.OrderBook.Weight *= factor
+		case "funding":
+			cfg.Funding.Weight *= factor
+		case "open_interest":
+			cfg.OpenInterest.Weight *= factor
+		case "volume_delta":
+			cfg.VolumeDelta.Weight *= factor
+		case "external":
+			cfg.External.Weight *= factor
+		}
+	}
+}
+
+// analyzersFor возвращает анализаторы символа с учетом его группы
+func (a *Analyzer) analyzersFor(symbol string) *analyzerSet {
+	a.configMutex.RLock()
+	defer a.configMutex.RUnlock()
+
+	if set, ok := a.symbolSets[symbol]; ok {
+		return set
+	}
+	return a.base
+}
+
+// Config возвращает действующие основные настройки анализа (без переопределений групп)
+func (a *Analyzer) Config() config.AnalysisConfig {
+	a.configMutex.RLock()
+	defer a.configMutex.RUnlock()
+
+	return a.config
+}
+
+// Thresholds возвращает пороговые значения рекомендаций символа с учетом его группы
+func (a *Analyzer) Thresholds(symbol string) config.SignalThresholds {
+	return a.analyzersFor(symbol).config.SignalThresholds
+}
+
+// UpdateConfig применяет новые настройки анализа (веса, пороги, параметры индикаторов)
+// начиная со следующего цикла анализа
+func (a *Analyzer) UpdateConfig(cfg config.AnalysisConfig) {
+	a.configMutex.Lock()
+	defer a.configMutex.Unlock()
+
+	a.config = cfg
+	a.rebuild()
+	logger.Info("Настройки анализа обновлены")
+}
+
+// UpdateGroups задает группы символов, настройки анализа которых переопределяют основные
+func (a *Analyzer) UpdateGroups(groups []config.SymbolGroupConfig) {
+	a.configMutex.Lock()
+	defer a.configMutex.Unlock()
+
+	a.groups = groups
+	a.rebuild()
+	logger.Info("Группы символов обновлены", zap.Int("groups", len(groups)))
+}
+
+// rebuild пересоздает анализаторы по текущим настройкам; вызывается под configMutex
+func (a *Analyzer) rebuild() {
+	a.base = newAnalyzerSet(a.config, a.intervals)
+	a.symbolSets = make(map[string]*analyzerSet)
+
+	for _, group := range a.groups {
+		if group.Analysis.IsZero() {
+			continue
+		}
+		cfg, err := group.Analysis.Apply(a.config)
+		if err != nil {
+			logger.Error("Ошибка настроек группы символов", zap.String("group", group.Name), zap.Error(err))
+			continue
+		}
+
+		// Символы группы используют общие анализаторы
+		set := newAnalyzerSet(cfg, a.intervals)
+		for _, symbol := range group.Symbols {
+			a.symbolSets[symbol] = set
+		}
+	}
 }
 
 // GetSignalHistory возвращает историю сигналов для символа
 func (a *Analyzer) GetSignalHistory(ctx context.Context, symbol string, limit int) ([]*models.SignalResult, error) {
 	return a.storage.GetSignalHistory(ctx, symbol, limit)
 }
+
+// QuerySignals возвращает страницу истории сигналов по условиям query
+func (a *Analyzer) QuerySignals(ctx context.Context, query models.SignalQuery) (*models.SignalPage, error) {
+	return a.storage.QuerySignals(ctx, query)
+}
+
+// SaveNote сохраняет заметку пользователя к символу или сигналу
+func (a *Analyzer) SaveNote(ctx context.Context, note *models.Note) error {
+	return a.storage.SaveNote(ctx, note)
+}
+
+// GetNotes возвращает заметки символа (или всех символов, если symbol пуст), новые первыми
+func (a *Analyzer) GetNotes(ctx context.Context, symbol string, limit int) ([]*models.Note, error) {
+	return a.storage.GetNotes(ctx, symbol, limit)
+}
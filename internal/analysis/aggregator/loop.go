@@ -0,0 +1,78 @@
+package aggregator
+
+import (
+	"context"
+	"time"
+
+	"github.com/skalibog/bfma/internal/crash"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Задержка первого расчета, пока сборщики накапливают данные
+const warmupDelay = 5 * time.Second
+
+// Run рассчитывает сигналы, пока не отменен контекст; сигналы публикуются в шину
+// событий (SetEventBus). С шиной расчет идет после закрытия свечи или новой ставки
+// финансирования, но не чаще одного раза за interval; без шины - каждые interval.
+// Первый расчет откладывается, пока сборщики накапливают данные. Цикл идет по часам анализатора (SetClock), как и сборщики:
+// при воспроизведении истории их заменяют симулированные. Новый период приходит
+// через intervalC (nil - период не меняется). Пока active возвращает false (вне
+// торговой сессии), расчет пропускается; nil - расчет идет всегда.
+func (a *Analyzer) Run(ctx context.Context, interval time.Duration, intervalC <-chan time.Duration, active func() bool) {
+	defer crash.Recover()
+
+	// Отложенный старт для накопления данных
+	select {
+	case <-a.clock.After(warmupDelay):
+	case <-ctx.Done():
+		return
+	}
+
+	health.SetAnalysisInterval(interval)
+
+	ticker := a.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	paused := false
+	pending := true // Первый расчет идет по накопленным данным
+	for {
+		select {
+		case <-a.updates:
+			pending = true
+		case interval := <-intervalC:
+			ticker.Reset(interval)
+			health.SetAnalysisInterval(interval)
+			logger.Info("Период анализа изменен", zap.Duration("interval", interval))
+		case <-ticker.C():
+			if active != nil && !active() {
+				if !paused {
+					paused = true
+					logger.Info("Вне торговой сессии, расчет сигналов приостановлен")
+				}
+				health.MarkAnalysisIdle()
+				continue
+			}
+			if paused {
+				paused = false
+				logger.Info("Начало торговой сессии, расчет сигналов возобновлен")
+			}
+			// Без новых данных сигналы не изменятся; цикл при этом не считается зависшим
+			if a.updates != nil && !pending {
+				health.MarkAnalysisIdle()
+				continue
+			}
+			pending = false
+
+			started := time.Now()
+			_, err := a.GenerateSignals(ctx)
+			health.MarkAnalysis(time.Since(started))
+			if err != nil {
+				logger.Warn("Ошибка при генерации сигналов", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package aggregator
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// dataWindow хранилище для расчета одного сигнала: запоминает интервалы свечей, время
+// и последние значения данных, прочитанных анализаторами. Чтение и запись передаются
+// хранилищу без изменений.
+type dataWindow struct {
+	storage.Storage
+	series    *models.CandleSeries // Закрытые свечи символа в памяти; nil - не ведутся
+	mutex     sync.Mutex
+	intervals []string
+	from, to  time.Time
+
+	// Последние прочитанные данные для снимка рынка
+	candles      map[models.Interval]*models.Candle
+	orderBook    *models.OrderBook
+	funding      *models.FundingRate
+	openInterest [2]*models.OpenInterest // Последнее и предыдущее значения
+}
+
+// newDataWindow создает учет данных сигнала поверх store; свечи, которых хватает
+// в ряду series, читаются из памяти
+func newDataWindow(store storage.Storage, series *models.CandleSeries) *dataWindow {
+	return &dataWindow{Storage: store, series: series}
+}
+
+// observe учитывает время прочитанных данных
+func (w *dataWindow) observe(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.from.IsZero() || t.Before(w.from) {
+		w.from = t
+	}
+	if t.After(w.to) {
+		w.to = t
+	}
+}
+
+// observeCandles учитывает интервал и время открытия свечей: последняя свеча еще
+// не закрыта, и время ее закрытия в будущем
+func (w *dataWindow) observeCandles(interval models.Interval, candles []*models.Candle) {
+	if len(candles) == 0 {
+		return
+	}
+	w.mutex.Lock()
+	if !slices.Contains(w.intervals, interval.String()) {
+		w.intervals = append(w.intervals, interval.String())
+	}
+	if w.candles == nil {
+		w.candles = make(map[models.Interval]*models.Candle)
+	}
+	for _, candle := range candles {
+		if last := w.candles[interval]; last == nil || candle.OpenTime.After(last.OpenTime) {
+			w.candles[interval] = candle
+		}
+	}
+	w.mutex.Unlock()
+
+	for _, candle := range candles {
+		w.observe(candle.OpenTime)
+	}
+}
+
+// Window возвращает интервалы свечей по порядку и время самых ранних и самых свежих данных
+func (w *dataWindow) Window() (intervals []string, from, to time.Time) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	intervals = slices.Clone(w.intervals)
+	slices.Sort(intervals)
+	return intervals, w.from, w.to
+}
+
+// GetCandles получает свечи и учитывает их. Если в ряду в памяти хватает закрытых
+// свечей, хранилище не читается; при ошибке хранилища берутся свечи ряда.
+func (w *dataWindow) GetCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
+	if w.series != nil && limit > 0 && w.series.Len(interval) >= limit {
+		candles := w.series.Candles(interval, limit)
+		w.observeCandles(interval, candles)
+		return candles, nil
+	}
+	candles, err := w.Storage.GetCandles(ctx, symbol, interval, limit)
+	candles, err = w.seriesFallback(interval, limit, candles, err)
+	w.observeCandles(interval, candles)
+	return candles, err
+}
+
+// GetLatestCandles получает последние свечи и учитывает их. Последняя свеча нужна
+// еще не закрытой, поэтому ряд в памяти используется только при ошибке хранилища.
+func (w *dataWindow) GetLatestCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
+	candles, err := w.Storage.GetLatestCandles(ctx, symbol, interval, limit)
+	candles, err = w.seriesFallback(interval, limit, candles, err)
+	w.observeCandles(interval, candles)
+	return candles, err
+}
+
+// seriesFallback заменяет свечи из хранилища свечами ряда в памяти, если хранилище
+// вернуло ошибку, а ряд не пуст
+func (w *dataWindow) seriesFallback(interval models.Interval, limit int, candles []*models.Candle, err error) ([]*models.Candle, error) {
+	if err == nil || w.series == nil {
+		return candles, err
+	}
+	if cached := w.series.Candles(interval, limit); len(cached) > 0 {
+		return cached, nil
+	}
+	return candles, err
+}
+
+// GetLatestOrderBook получает последний стакан и учитывает его время
+func (w *dataWindow) GetLatestOrderBook(ctx context.Context, symbol string) (*models.OrderBook, error) {
+	orderBook, err := w.Storage.GetLatestOrderBook(ctx, symbol)
+	if orderBook != nil {
+		w.observe(orderBook.Timestamp)
+		w.mutex.Lock()
+		w.orderBook = orderBook
+		w.mutex.Unlock()
+	}
+	return orderBook, err
+}
+
+// GetOrderBooks получает снимки стакана и учитывает их время; первый снимок - последний стакан
+func (w *dataWindow) GetOrderBooks(ctx context.Context, symbol string, since time.Time) ([]*models.OrderBook, error) {
+	orderBooks, err := w.Storage.GetOrderBooks(ctx, symbol, since)
+	for _, orderBook := range orderBooks {
+		w.observe(orderBook.Timestamp)
+	}
+	if len(orderBooks) > 0 {
+		w.mutex.Lock()
+		w.orderBook = orderBooks[0]
+		w.mutex.Unlock()
+	}
+	return orderBooks, err
+}
+
+// GetFundingRates получает ставки финансирования и учитывает их время
+func (w *dataWindow) GetFundingRates(ctx context.Context, symbol string, limit int) ([]*models.FundingRate, error) {
+	rates, err := w.Storage.GetFundingRates(ctx, symbol, limit)
+	for _, rate := range rates {
+		w.observe(rate.Timestamp)
+	}
+	w.mutex.Lock()
+	for _, rate := range rates {
+		if w.funding == nil || rate.Timestamp.After(w.funding.Timestamp) {
+			w.funding = rate
+		}
+	}
+	w.mutex.Unlock()
+	return rates, err
+}
+
+// GetOpenInterest получает открытый интерес и учитывает его время
+func (w *dataWindow) GetOpenInterest(ctx context.Context, symbol string, limit int) ([]*models.OpenInterest, error) {
+	values, err := w.Storage.GetOpenInterest(ctx, symbol, limit)
+	for _, value := range values {
+		w.observe(value.Timestamp)
+	}
+	w.mutex.Lock()
+	for _, value := range values {
+		switch last, previous := w.openInterest[0], w.openInterest[1]; {
+		case last == nil || value.Timestamp.After(last.Timestamp):
+			w.openInterest = [2]*models.OpenInterest{value, last}
+		case value.Timestamp.Before(last.Timestamp) && (previous == nil || value.Timestamp.After(previous.Timestamp)):
+			w.openInterest[1] = value
+		}
+	}
+	w.mutex.Unlock()
+	return values, err
+}
+
+// MarketState возвращает снимок рынка по прочитанным данным: последнюю свечу
+// интервала interval, сводку стакана, ставку финансирования и открытый интерес
+func (w *dataWindow) MarketState(symbol string, interval models.Interval, now time.Time) *models.MarketState {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	state := &models.MarketState{Symbol: symbol, Timestamp: now}
+	if candle := w.candles[interval]; candle != nil {
+		copied := *candle
+		state.Candle = &copied
+		state.Price = candle.Close.InexactFloat64()
+		if candle.HasTakerVolume() {
+			state.Delta = candle.Delta().InexactFloat64()
+		}
+	}
+	if w.orderBook != nil {
+		state.Book = w.orderBook.Summary()
+		if state.Price == 0 {
+			state.Price = state.Book.MidPrice
+		}
+	}
+	if w.funding != nil {
+		copied := *w.funding
+		state.Funding = &copied
+		state.FundingBps = copied.Bps()
+		state.FundingAnnualized = copied.Annualized(models.DefaultFundingIntervalsPerDay)
+	}
+	if last, previous := w.openInterest[0], w.openInterest[1]; last != nil {
+		copied := *last
+		state.OpenInterest = &copied
+		if previous != nil && !previous.Value.IsZero() {
+			state.OpenInterestChange = last.Value.Sub(previous.Value).Div(previous.Value).InexactFloat64() * 100
+		}
+	}
+	return state
+}
@@ -0,0 +1,51 @@
+// Package external хранит сигналы, пришедшие извне (оповещения стратегий TradingView),
+// для компонента external агрегатора
+package external
+
+import (
+	"sync"
+	"time"
+)
+
+// Границы значения внешнего сигнала, как у остальных компонентов
+const (
+	MinValue = -100.0
+	MaxValue = 100.0
+)
+
+// signal последний внешний сигнал символа
+type signal struct {
+	value float64
+	time  time.Time
+}
+
+// Signals последние внешние сигналы по символам
+type Signals struct {
+	signals map[string]signal
+	mutex   sync.RWMutex
+}
+
+// NewSignals создает пустое хранилище внешних сигналов
+func NewSignals() *Signals {
+	return &Signals{signals: make(map[string]signal)}
+}
+
+// Set запоминает сигнал символа, полученный в момент at; значение ограничивается
+// диапазоном [MinValue, MaxValue]
+func (s *Signals) Set(symbol string, value float64, at time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.signals[symbol] = signal{value: max(MinValue, min(MaxValue, value)), time: at}
+}
+
+// Value возвращает сигнал символа, если он не старше ttl (0 - без срока)
+func (s *Signals) Value(symbol string, ttl time.Duration, now time.Time) (float64, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sig, ok := s.signals[symbol]
+	if !ok || (ttl > 0 && now.Sub(sig.time) > ttl) {
+		return 0, false
+	}
+	return sig.value, true
+}
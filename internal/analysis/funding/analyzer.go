@@ -7,16 +7,24 @@ import (
 	"github.com/skalibog/bfma/pkg/logger"
 	"go.uber.org/zap"
 	"math"
-	// "time"
+	"time"
 
 	"github.com/skalibog/bfma/internal/config"
 	"github.com/skalibog/bfma/internal/storage"
 	"github.com/skalibog/bfma/pkg/models"
 )
 
+// Сколько последних ставок читается для определения периода финансирования: при сборе
+// раз в 10 минут - больше двух 8-часовых периодов
+const intervalDetectionRates = 120
+
+// IntervalSource возвращает период финансирования символа по параметрам биржи; 0 - неизвестен
+type IntervalSource func(symbol string) time.Duration
+
 // Analyzer реализует анализатор ставок финансирования
 type Analyzer struct {
-	config config.FundingConfig
+	config    config.FundingConfig
+	intervals IntervalSource // nil - период определяется только по собранным ставкам
 }
 
 // NewAnalyzer создает новый анализатор ставок финансирования
@@ -26,20 +34,44 @@ func NewAnalyzer(cfg config.FundingConfig) *Analyzer {
 	}
 }
 
+// SetIntervalSource задает период финансирования символов по параметрам биржи: он
+// используется, пока период нельзя определить по собранным ставкам
+func (a *Analyzer) SetIntervalSource(source IntervalSource) {
+	a.intervals = source
+}
+
 // Analyze анализирует ставки финансирования и возвращает сигнал от -100 до 100
 func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol string) (float64, error) {
 	logger.Info("Начало анализа ставок финансирования")
 
-	// Получаем историю ставок финансирования
-	fundingRates, err := storage.GetFundingRates(ctx, symbol, a.config.Periods)
+	// Получаем историю ставок финансирования: для определения периода - больше, чем
+	// учитывается в сигнале
+	history, err := storage.GetFundingRates(ctx, symbol, max(a.config.Periods, intervalDetectionRates))
 	if err != nil {
 		return 0, fmt.Errorf("ошибка получения ставок финансирования: %w", err)
 	}
 
-	if len(fundingRates) == 0 {
+	if len(history) == 0 {
 		return 0, fmt.Errorf("нет данных о ставках финансирования для %s", symbol)
 	}
 
+	// Символы с финансированием каждые 4 или 1 час платят меньшие ставки чаще: без
+	// приведения к 8 часам их крайние значения не достигали бы порогов
+	fallback := models.DefaultFundingInterval
+	if a.intervals != nil {
+		if interval := a.intervals(symbol); interval > 0 {
+			fallback = interval
+		}
+	}
+	intervals := models.FundingIntervals(history, fallback)
+	if a.config.Periods > 0 && len(history) > a.config.Periods {
+		history = history[:a.config.Periods]
+	}
+	fundingRates := make([]*models.FundingRate, len(history))
+	for i, rate := range history {
+		fundingRates[i] = rate.Per8h(intervals[i])
+	}
+
 	// Анализируем различные аспекты ставок финансирования
 	extremeSignal := a.analyzeExtremes(fundingRates)
 	trendSignal := a.analyzeTrend(fundingRates)
@@ -53,7 +85,8 @@ func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol
 		zap.String("symbol", symbol),
 		zap.Int("data_count", len(fundingRates)),
 		zap.Int("candles_count", len(fundingRates)),
-		zap.Int("periods", a.config.Periods))
+		zap.Int("periods", a.config.Periods),
+		zap.Duration("funding_interval", intervals[0]))
 
 	logger.Info("Анализ ставок финансирования завершен",
 		zap.String("symbol", symbol),
@@ -72,10 +105,7 @@ func (a *Analyzer) analyzeExtremes(rates []*models.FundingRate) float64 {
 	}
 
 	// Получаем текущую ставку финансирования
-	currentRate, err := parseRate(rates[0].Rate)
-	if err != nil {
-		return 0
-	}
+	currentRate := rates[0].Float()
 
 	// Определяем экстремальное значение на основе исторических данных
 	// Обычно ставка финансирования находится в пределах от -0.75% до +0.75%
@@ -93,7 +123,7 @@ func (a *Analyzer) analyzeExtremes(rates []*models.FundingRate) float64 {
 		signal = 100 * math.Min(math.Abs(currentRate)/0.01, 1.0)
 	} else {
 		// В пределах нормы, слабый сигнал
-		signal = -currentRate * 10000
+		signal = -rates[0].Bps()
 	}
 
 	return signal
@@ -109,11 +139,7 @@ func (a *Analyzer) analyzeTrend(rates []*models.FundingRate) float64 {
 	// Парсим ставки
 	var fundingValues []float64
 	for _, rate := range rates {
-		value, err := parseRate(rate.Rate)
-		if err != nil {
-			continue
-		}
-		fundingValues = append(fundingValues, value)
+		fundingValues = append(fundingValues, rate.Float())
 	}
 
 	if len(fundingValues) < 3 {
@@ -146,12 +172,8 @@ func (a *Analyzer) analyzeChange(rates []*models.FundingRate) float64 {
 	}
 
 	// Получаем текущую и предыдущую ставки
-	currentRate, err1 := parseRate(rates[0].Rate)
-	prevRate, err2 := parseRate(rates[1].Rate)
-
-	if err1 != nil || err2 != nil {
-		return 0
-	}
+	currentRate := rates[0].Float()
+	prevRate := rates[1].Float()
 
 	// Рассчитываем изменение
 	change := currentRate - prevRate
@@ -199,13 +221,3 @@ func calculateSlope(values []float64) float64 {
 
 	return slope
 }
-
-// parseRate парсит строковое представление ставки в число
-func parseRate(rateStr string) (float64, error) {
-	var rate float64
-	_, err := fmt.Sscanf(rateStr, "%f", &rate)
-	if err != nil {
-		return 0, err
-	}
-	return rate, nil
-}
@@ -7,7 +7,6 @@ import (
 	"github.com/skalibog/bfma/pkg/logger"
 	"go.uber.org/zap"
 	"math"
-	"strconv"
 
 	"github.com/skalibog/bfma/internal/config"
 	"github.com/skalibog/bfma/internal/storage"
@@ -43,7 +42,7 @@ func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol
 	}
 
 	// Получаем исторические свечи для анализа дивергенции
-	candles, err := storage.GetCandles(ctx, symbol, "1h", a.config.Lookback)
+	candles, err := storage.GetCandles(ctx, symbol, models.Interval1h, a.config.Lookback)
 	if err != nil {
 		return 0, fmt.Errorf("ошибка получения исторических свечей: %w", err)
 	}
@@ -79,12 +78,8 @@ func (a *Analyzer) analyzeOIChange(data []*models.OpenInterest) float64 {
 	}
 
 	// Получаем текущий и предыдущий открытый интерес
-	currentOI, err1 := parseOI(data[0].Value)
-	prevOI, err2 := parseOI(data[1].Value)
-
-	if err1 != nil || err2 != nil {
-		return 0
-	}
+	currentOI := data[0].Value.InexactFloat64()
+	prevOI := data[1].Value.InexactFloat64()
 
 	// Рассчитываем процентное изменение
 	if prevOI == 0 {
@@ -129,12 +124,8 @@ func (a *Analyzer) analyzeOIvsPriceDivergence(openInterest []*models.OpenInteres
 	// Обратите внимание, что данные OI и свечи могут иметь разные временные метки
 	// Здесь мы упрощаем и просто берем последние значения
 	for i := 0; i < len(openInterest) && i < len(candles) && i < 5; i++ {
-		oi, err := parseOI(openInterest[i].Value)
-		if err != nil {
-			continue
-		}
-		oiValues = append(oiValues, oi)
-		priceValues = append(priceValues, candles[i].Close)
+		oiValues = append(oiValues, openInterest[i].Value.InexactFloat64())
+		priceValues = append(priceValues, candles[i].Close.InexactFloat64())
 	}
 
 	if len(oiValues) < 3 || len(priceValues) < 3 {
@@ -185,11 +176,7 @@ func (a *Analyzer) analyzeOITrend(data []*models.OpenInterest) float64 {
 	// Подготавливаем данные для анализа тренда
 	oiValues := make([]float64, 0, len(data))
 	for _, oi := range data {
-		value, err := parseOI(oi.Value)
-		if err != nil {
-			continue
-		}
-		oiValues = append(oiValues, value)
+		oiValues = append(oiValues, oi.Value.InexactFloat64())
 	}
 
 	if len(oiValues) < 3 {
@@ -240,8 +227,3 @@ func calculateSlope(values []float64) float64 {
 
 	return slope
 }
-
-// parseOI парсит строковое представление открытого интереса в число
-func parseOI(oiStr string) (float64, error) {
-	return strconv.ParseFloat(oiStr, 64)
-}
@@ -4,8 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"sort"
-	"strconv"
+	"time"
 
 	"github.com/skalibog/bfma/internal/config"
 	"github.com/skalibog/bfma/internal/storage"
@@ -24,25 +23,37 @@ func NewAnalyzer(cfg config.OrderBookConfig) *Analyzer {
 	}
 }
 
-// Analyze анализирует стакан заявок и возвращает сигнал от -100 до 100
+// Окно снимков стакана по умолчанию
+const defaultWindow = 5 * time.Minute
+
+// Analyze анализирует стакан заявок за окно config.Window и возвращает сигнал от -100
+// до 100. Дисбаланс и глубина усредняются по снимкам окна, поэтому разовый перекос
+// стакана весит меньше устойчивого; уровни и спреды берутся из последнего стакана.
 func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol string) (float64, error) {
-	// Получаем последнее состояние стакана
+	// Получаем последнее состояние стакана: окно отсчитывается от его времени
 	orderBook, err := storage.GetLatestOrderBook(ctx, symbol)
 	if err != nil {
 		return 0, fmt.Errorf("ошибка получения стакана: %w", err)
 	}
 
-	// Конвертируем строковые значения в числа для анализа
-	bids, asks, err := a.convertOrderBookLevels(orderBook)
-	if err != nil {
-		return 0, fmt.Errorf("ошибка конвертации уровней стакана: %w", err)
+	// Переводим уровни в числа для анализа
+	bids, asks := orderBook.Levels()
+	if len(bids) == 0 || len(asks) == 0 {
+		return 0, fmt.Errorf("стакан %s пуст", symbol)
+	}
+
+	// Снимки за окно; без истории анализируется один последний стакан
+	since := orderBook.Timestamp.Add(-orDefault(a.config.Window, defaultWindow))
+	books, err := storage.GetOrderBooks(ctx, symbol, since)
+	if err != nil || len(books) == 0 {
+		books = []*models.OrderBook{orderBook}
 	}
 
 	// Рассчитываем различные метрики стакана
-	imbalanceSignal := a.calculateImbalance(bids, asks)
-	depthSignal := a.calculateDepth(bids, asks, orderBook.Timestamp)
-	supportResistanceSignal := a.calculateSupportResistance(bids, asks)
-	spreadsSignal := a.calculateSpreads(bids, asks)
+	imbalanceSignal := a.calculateImbalance(books)
+	depthSignal := a.calculateWindowDepth(books)
+	supportResistanceSignal := a.calculateSupportResistance(bids, asks, books)
+	spreadsSignal := a.calculateSpreads(orderBook, bids, asks)
 
 	// Комбинируем сигналы с весами
 	weightedSignal := (imbalanceSignal * 0.4) +
@@ -53,86 +64,29 @@ func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol
 	return weightedSignal, nil
 }
 
-// convertOrderBookLevels конвертирует строковые цены и объемы в числа
-func (a *Analyzer) convertOrderBookLevels(orderBook *models.OrderBook) ([]OrderLevel, []OrderLevel, error) {
-	bids := make([]OrderLevel, len(orderBook.Bids))
-	asks := make([]OrderLevel, len(orderBook.Asks))
-
-	// Обрабатываем биды
-	for i, bid := range orderBook.Bids {
-		price, err := strconv.ParseFloat(bid.Price, 64)
-		if err != nil {
-			return nil, nil, fmt.Errorf("ошибка парсинга цены бида: %w", err)
-		}
-
-		amount, err := strconv.ParseFloat(bid.Amount, 64)
-		if err != nil {
-			return nil, nil, fmt.Errorf("ошибка парсинга объема бида: %w", err)
-		}
-
-		bids[i] = OrderLevel{
-			Price:  price,
-			Amount: amount,
-		}
+// calculateImbalance рассчитывает дисбаланс между спросом и предложением по всем
+// уровням: средний по снимкам окна, умноженный на долю снимков с тем же знаком
+func (a *Analyzer) calculateImbalance(books []*models.OrderBook) float64 {
+	imbalances := make([]float64, len(books))
+	var mean float64
+	for i, orderBook := range books {
+		imbalances[i] = orderBook.Imbalance(0)
+		mean += imbalances[i]
 	}
+	mean /= float64(len(books))
 
-	// Обрабатываем аски
-	for i, ask := range orderBook.Asks {
-		price, err := strconv.ParseFloat(ask.Price, 64)
-		if err != nil {
-			return nil, nil, fmt.Errorf("ошибка парсинга цены аска: %w", err)
-		}
-
-		amount, err := strconv.ParseFloat(ask.Amount, 64)
-		if err != nil {
-			return nil, nil, fmt.Errorf("ошибка парсинга объема аска: %w", err)
-		}
-
-		asks[i] = OrderLevel{
-			Price:  price,
-			Amount: amount,
+	// Устойчивость дисбаланса: разовый перекос в одном снимке почти не влияет
+	var consistent int
+	for _, imbalance := range imbalances {
+		if imbalance*mean > 0 {
+			consistent++
 		}
 	}
-
-	// Сортируем биды по убыванию цены
-	sort.Slice(bids, func(i, j int) bool {
-		return bids[i].Price > bids[j].Price
-	})
-
-	// Сортируем аски по возрастанию цены
-	sort.Slice(asks, func(i, j int) bool {
-		return asks[i].Price < asks[j].Price
-	})
-
-	return bids, asks, nil
-}
-
-// calculateImbalance рассчитывает дисбаланс между спросом и предложением
-func (a *Analyzer) calculateImbalance(bids, asks []OrderLevel) float64 {
-	// Рассчитываем суммарный объем на покупку и продажу
-	var totalBidVolume, totalAskVolume float64
-
-	for _, bid := range bids {
-		totalBidVolume += bid.Amount
-	}
-
-	for _, ask := range asks {
-		totalAskVolume += ask.Amount
-	}
-
-	// Если объемы нулевые, возвращаем 0
-	if totalBidVolume == 0 && totalAskVolume == 0 {
-		return 0
-	}
-
-	// Рассчитываем дисбаланс как пропорцию между объемами
-	totalVolume := totalBidVolume + totalAskVolume
-	bidRatio := totalBidVolume / totalVolume
-	askRatio := totalAskVolume / totalVolume
+	persistence := float64(consistent) / float64(len(books))
 
 	// Нормализуем к диапазону -100..100
 	// Положительные значения указывают на преобладание покупателей
-	imbalance := (bidRatio - askRatio) * 100
+	imbalance := mean * persistence * 100
 
 	// Применяем порог дисбаланса
 	if math.Abs(imbalance) < a.config.ImbalanceThreshold {
@@ -142,36 +96,25 @@ func (a *Analyzer) calculateImbalance(bids, asks []OrderLevel) float64 {
 	return imbalance
 }
 
-// calculateDepth анализирует глубину стакана и концентрацию ликвидности
-func (a *Analyzer) calculateDepth(bids, asks []OrderLevel, timestamp interface{}) float64 {
-	// Уровни для анализа (% от текущей цены)
-	depthLevels := []float64{0.005, 0.01, 0.02, 0.05} // 0.5%, 1%, 2%, 5%
+// calculateWindowDepth усредняет сигнал глубины по снимкам окна
+func (a *Analyzer) calculateWindowDepth(books []*models.OrderBook) float64 {
+	var total float64
+	for _, orderBook := range books {
+		total += a.calculateDepth(orderBook)
+	}
+	return total / float64(len(books))
+}
 
-	// Рассчитываем среднюю цену между лучшим бидом и аском (mid price)
-	midPrice := (bids[0].Price + asks[0].Price) / 2
+// calculateDepth анализирует глубину стакана и концентрацию ликвидности
+func (a *Analyzer) calculateDepth(orderBook *models.OrderBook) float64 {
+	// Уровни для анализа (% от средней цены)
+	depthLevels := []float64{0.5, 1, 2, 5}
 
 	// Объемы на различных уровнях глубины
 	bidDepthVolumes := make([]float64, len(depthLevels))
 	askDepthVolumes := make([]float64, len(depthLevels))
-
-	// Заполняем объемы по бидам
-	for _, bid := range bids {
-		priceDeviation := 1 - (bid.Price / midPrice)
-		for i, level := range depthLevels {
-			if priceDeviation <= level {
-				bidDepthVolumes[i] += bid.Amount
-			}
-		}
-	}
-
-	// Заполняем объемы по аскам
-	for _, ask := range asks {
-		priceDeviation := (ask.Price / midPrice) - 1
-		for i, level := range depthLevels {
-			if priceDeviation <= level {
-				askDepthVolumes[i] += ask.Amount
-			}
-		}
+	for i, level := range depthLevels {
+		bidDepthVolumes[i], askDepthVolumes[i] = orderBook.DepthWithin(level)
 	}
 
 	// Сравниваем объемы на разных уровнях глубины
@@ -204,7 +147,8 @@ func (a *Analyzer) calculateDepth(bids, asks []OrderLevel, timestamp interface{}
 }
 
 // calculateSupportResistance анализирует уровни поддержки и сопротивления
-func (a *Analyzer) calculateSupportResistance(bids, asks []OrderLevel) float64 {
+// с учетом того, как долго уровни держатся в снимках окна books
+func (a *Analyzer) calculateSupportResistance(bids, asks []OrderLevel, books []*models.OrderBook) float64 {
 	// Нужно как минимум несколько уровней для анализа
 	if len(bids) < 3 || len(asks) < 3 {
 		return 0
@@ -235,9 +179,10 @@ func (a *Analyzer) calculateSupportResistance(bids, asks []OrderLevel) float64 {
 	supportDistance := (currentPrice - closestSupport.Price) / currentPrice
 	resistanceDistance := (closestResistance.Price - currentPrice) / currentPrice
 
-	// Оцениваем силу уровней по объему
-	supportStrength := math.Min(1.0, closestSupport.Amount/1000) // Нормализация объема
-	resistanceStrength := math.Min(1.0, closestResistance.Amount/1000)
+	// Оцениваем силу уровней по объему и устойчивости: уровень, появившийся в последнем
+	// снимке, может быть снят так же быстро
+	supportStrength := math.Min(1.0, closestSupport.Amount/1000) * levelPersistence(books, *closestSupport, true) // Нормализация объема
+	resistanceStrength := math.Min(1.0, closestResistance.Amount/1000) * levelPersistence(books, *closestResistance, false)
 
 	// Рассчитываем сигнал на основе расстояния и силы уровней
 	// Чем ближе поддержка и дальше сопротивление, тем более бычий сигнал
@@ -260,10 +205,24 @@ func (a *Analyzer) calculateSupportResistance(bids, asks []OrderLevel) float64 {
 	return signal
 }
 
+// KeyLevels возвращает ближайшие к текущей цене уровни стакана с высокой концентрацией
+// ордеров: поддержку среди бидов и сопротивление среди асков; nil - уровня нет
+func KeyLevels(orderBook *models.OrderBook) (support, resistance *OrderLevel, err error) {
+	bids, asks := orderBook.Levels()
+	if len(bids) == 0 || len(asks) == 0 {
+		return nil, nil, nil
+	}
+
+	currentPrice := orderBook.MidPrice()
+	support = findClosestLevel(findSignificantLevels(bids), currentPrice, false)
+	resistance = findClosestLevel(findSignificantLevels(asks), currentPrice, true)
+	return support, resistance, nil
+}
+
 // calculateSpreads анализирует спреды и распределение ордеров
-func (a *Analyzer) calculateSpreads(bids, asks []OrderLevel) float64 {
+func (a *Analyzer) calculateSpreads(orderBook *models.OrderBook, bids, asks []OrderLevel) float64 {
 	// Текущий спред
-	currentSpread := (asks[0].Price - bids[0].Price) / ((asks[0].Price + bids[0].Price) / 2)
+	currentSpread := orderBook.Spread() / orderBook.MidPrice()
 
 	// Рассчитываем средние спреды между уровнями
 	bidSpreads := calculateAverageSpreads(bids, 5)
@@ -288,6 +247,36 @@ func (a *Analyzer) calculateSpreads(bids, asks []OrderLevel) float64 {
 	return signal
 }
 
+// levelPersistence возвращает долю снимков, в которых на цене уровня стоит не меньше
+// половины его объема: 1 - уровень держится все окно
+func levelPersistence(books []*models.OrderBook, level OrderLevel, bid bool) float64 {
+	if len(books) == 0 {
+		return 1
+	}
+	var present int
+	for _, orderBook := range books {
+		levels := orderBook.Asks
+		if bid {
+			levels = orderBook.Bids
+		}
+		for _, l := range levels {
+			if l.Price.InexactFloat64() == level.Price && l.Amount.InexactFloat64() >= level.Amount/2 {
+				present++
+				break
+			}
+		}
+	}
+	return float64(present) / float64(len(books))
+}
+
+// orDefault возвращает value или fallback, если value не задано
+func orDefault(value config.Duration, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value.Std()
+	}
+	return fallback
+}
+
 // findSignificantLevels находит уровни с высокой концентрацией ордеров
 func findSignificantLevels(levels []OrderLevel) []OrderLevel {
 	if len(levels) == 0 {
@@ -372,7 +361,4 @@ func calculateAverageSpreads(levels []OrderLevel, count int) float64 {
 }
 
 // OrderLevel представляет уровень с численными значениями
-type OrderLevel struct {
-	Price  float64
-	Amount float64
-}
+type OrderLevel = models.PriceLevel
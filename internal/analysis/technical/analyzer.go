@@ -11,6 +11,7 @@ import (
 	"github.com/markcheno/go-talib"
 	"github.com/skalibog/bfma/internal/config"
 	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
 )
 
 // Analyzer реализует анализатор технических индикаторов
@@ -26,10 +27,10 @@ func NewAnalyzer(cfg config.TechnicalConfig) *Analyzer {
 }
 
 // Analyze выполняет технический анализ для символа
-func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol, interval string) (float64, error) {
+func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol string, interval models.Interval) (float64, error) {
 	logger.Debug("Начало технического анализа",
 		zap.String("symbol", symbol),
-		zap.String("interval", interval))
+		zap.Stringer("interval", interval))
 
 	// Получаем исторические свечи
 	candles, err := storage.GetCandles(ctx, symbol, interval, 100)
@@ -57,10 +58,10 @@ func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol,
 	volumes := make([]float64, len(candles))
 
 	for i, c := range candles {
-		closes[i] = c.Close
-		highs[i] = c.High
-		lows[i] = c.Low
-		volumes[i] = c.Volume
+		closes[i] = c.Close.InexactFloat64()
+		highs[i] = c.High.InexactFloat64()
+		lows[i] = c.Low.InexactFloat64()
+		volumes[i] = c.Volume.InexactFloat64()
 	}
 
 	// Рассчитываем индикаторы
@@ -28,7 +28,7 @@ func NewAnalyzer(cfg config.VolumeDeltaConfig) *Analyzer {
 // Analyze анализирует дельту объемов и возвращает сигнал от -100 до 100
 func (a *Analyzer) Analyze(ctx context.Context, storage storage.Storage, symbol string) (float64, error) {
 	// Получаем исторические свечи для анализа
-	candles, err := storage.GetCandles(ctx, symbol, "1m", a.config.Lookback*60) // Минутные свечи
+	candles, err := storage.GetCandles(ctx, symbol, models.Interval1m, a.config.Lookback*60) // Минутные свечи
 	if err != nil {
 		return 0, fmt.Errorf("ошибка получения свечей: %w", err)
 	}
@@ -69,19 +69,13 @@ func (a *Analyzer) analyzeCumulativeDelta(candles []*models.Candle) float64 {
 	for i := 0; i < a.config.Lookback && i < len(candles); i++ {
 		candle := candles[i]
 
-		// Оцениваем дельту объема на основе направления свечи
-		// Если свеча бычья (close > open), считаем объем положительным
-		// Если свеча медвежья (close < open), считаем объем отрицательным
-		delta := candle.Volume
-		if candle.Close < candle.Open {
-			delta = -delta
-		}
+		delta := candleDelta(candle)
 
 		// Взвешиваем более недавние свечи сильнее
 		weight := 1.0 - (float64(i) / float64(a.config.Lookback))
 
 		cumulativeDelta += delta * weight
-		totalVolume += math.Abs(delta) * weight
+		totalVolume += candle.Volume.InexactFloat64() * weight
 	}
 
 	// Нормализуем дельту относительно общего объема
@@ -95,6 +89,19 @@ func (a *Analyzer) analyzeCumulativeDelta(candles []*models.Candle) float64 {
 	return normalizedDelta * 100
 }
 
+// candleDelta возвращает дельту объема свечи по объемам агрессивных покупок и продаж.
+// У свечей без них дельта оценивается по направлению свечи: объем бычьей свечи (close > open)
+// считается положительным, медвежьей (close < open) - отрицательным.
+func candleDelta(candle *models.Candle) float64 {
+	if candle.HasTakerVolume() {
+		return candle.Delta().InexactFloat64()
+	}
+	if candle.Close.LessThan(candle.Open) {
+		return -candle.Volume.InexactFloat64()
+	}
+	return candle.Volume.InexactFloat64()
+}
+
 // analyzeVolumeImpulses анализирует импульсы объема
 func (a *Analyzer) analyzeVolumeImpulses(candles []*models.Candle) float64 {
 	if len(candles) < 30 {
@@ -104,7 +111,7 @@ func (a *Analyzer) analyzeVolumeImpulses(candles []*models.Candle) float64 {
 	// Рассчитываем средний объем
 	var totalVolume float64
 	for i := 0; i < 30 && i < len(candles); i++ {
-		totalVolume += candles[i].Volume
+		totalVolume += candles[i].Volume.InexactFloat64()
 	}
 	avgVolume := totalVolume / 30
 
@@ -115,7 +122,7 @@ func (a *Analyzer) analyzeVolumeImpulses(candles []*models.Candle) float64 {
 		candle := candles[i]
 
 		// Проверяем на значительное превышение среднего объема
-		volumeRatio := candle.Volume / avgVolume
+		volumeRatio := candle.Volume.InexactFloat64() / avgVolume
 
 		if volumeRatio >= a.config.SignificanceThreshold {
 			// Обнаружен объемный импульс
@@ -126,8 +133,13 @@ func (a *Analyzer) analyzeVolumeImpulses(candles []*models.Candle) float64 {
 				impulseStrength = a.config.SignificanceThreshold * 10 // Ограничиваем максимальную силу
 			}
 
-			// Направление определяется направлением свечи
-			if candle.Close > candle.Open {
+			// Направление определяется преобладанием агрессивных покупок или продаж,
+			// а без объемов taker - направлением свечи
+			bullish := candle.Close.GreaterThan(candle.Open)
+			if candle.HasTakerVolume() {
+				bullish = candle.Delta().IsPositive()
+			}
+			if bullish {
 				// Бычий импульс
 				impulseSignal += impulseStrength
 			} else {
@@ -155,10 +167,12 @@ func (a *Analyzer) analyzeVolumePriceRelation(candles []*models.Candle) float64
 		previous := candles[i]
 
 		// Изменение объема
-		volumeChange := (current.Volume - previous.Volume) / previous.Volume
+		currentVolume, previousVolume := current.Volume.InexactFloat64(), previous.Volume.InexactFloat64()
+		volumeChange := (currentVolume - previousVolume) / previousVolume
 
 		// Изменение цены
-		priceChange := (current.Close - previous.Close) / previous.Close
+		currentClose, previousClose := current.Close.InexactFloat64(), previous.Close.InexactFloat64()
+		priceChange := (currentClose - previousClose) / previousClose
 
 		// Анализируем расхождения между изменениями объема и цены
 		if math.Abs(volumeChange) > 0.1 { // Значительное изменение объема
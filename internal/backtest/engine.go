@@ -0,0 +1,189 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Options параметры проверки стратегии на истории
+type Options struct {
+	InitialBalance models.Decimal // Начальный баланс в валюте котировки: от него считаются доходность и просадка
+	PositionSize   models.Decimal // Стоимость входа в сделку в валюте котировки
+	FeeRate        models.Decimal // Комиссия от стоимости исполнения: 0.0004 - 0,04%
+	StrongOnly     bool           // Входить только по сильным сигналам (STRONG_BUY, STRONG_SELL)
+	OrderBookFills bool           // Исполнять заявки по глубине сохраненного стакана, а не по закрытию свечи
+}
+
+const (
+	// Знаков объема входа после запятой
+	quantityPrecision = 8
+	// Снимок стакана пишется раз в минуту; более старый снимок для исполнения не годится
+	maxOrderBookAge = 5 * time.Minute
+)
+
+// Engine ведет сделки по сигналам воспроизведения. Стратегия простая и повторяет
+// то, как трейдер читает рекомендации: покупка открывает длинную позицию, продажа -
+// короткую, а нейтральная или противоположная рекомендация закрывает открытую сделку.
+// Расчетная цена исполнения - закрытие последней минутной свечи на шаге; с
+// Options.OrderBookFills заявка исполняется по уровням последнего снимка стакана,
+// а расчетная цена остается для учета проскальзывания.
+type Engine struct {
+	options Options
+	history *History
+	open    map[string]*models.Trade // Открытая сделка символа
+	closed  []*models.Trade          // Закрытые сделки по порядку закрытия
+	fills   []models.Fill
+}
+
+// NewEngine создает учет сделок по истории history
+func NewEngine(options Options, history *History) *Engine {
+	return &Engine{
+		options: options,
+		history: history,
+		open:    make(map[string]*models.Trade),
+	}
+}
+
+// OnSignals учитывает сигналы шага at: закрывает и открывает сделки. Подходит как
+// StepFunc для Replay.Run.
+func (e *Engine) OnSignals(at time.Time, signals map[string]*models.SignalResult) error {
+	// Символы по порядку, чтобы сделки одного шага шли в одном порядке при каждом запуске
+	symbols := make([]string, 0, len(signals))
+	for symbol := range signals {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		signal := signals[symbol]
+		price, ok := e.history.Price(symbol, at)
+		if !ok || !price.IsPositive() {
+			continue
+		}
+
+		side, enter := e.target(signal.RecommendationCode)
+		if trade := e.open[symbol]; trade != nil {
+			// Сделка в ту же сторону остается открытой
+			if enter && trade.Side == side {
+				continue
+			}
+			if err := e.exit(trade, price, at); err != nil {
+				return err
+			}
+		}
+		if enter {
+			if err := e.enter(symbol, side, signal.ID, price, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// target возвращает сторону позиции по рекомендации; enter false - позиции быть не должно
+func (e *Engine) target(code models.Recommendation) (models.Side, bool) {
+	switch code {
+	case models.RecommendationStrongBuy:
+		return models.PositionSideLong, true
+	case models.RecommendationStrongSell:
+		return models.PositionSideShort, true
+	case models.RecommendationBuy:
+		return models.PositionSideLong, !e.options.StrongOnly
+	case models.RecommendationSell:
+		return models.PositionSideShort, !e.options.StrongOnly
+	}
+	return "", false
+}
+
+// enter открывает сделку символа на стоимость options.PositionSize
+func (e *Engine) enter(symbol string, side models.Side, signalID string, price models.Decimal, at time.Time) error {
+	quantity := e.options.PositionSize.Div(price).Truncate(quantityPrecision)
+	if !quantity.IsPositive() {
+		return nil
+	}
+	fill := e.fill(models.Fill{
+		Symbol:   symbol,
+		Side:     side,
+		Price:    price,
+		Quantity: quantity,
+		Time:     at,
+	})
+	trade, err := models.NewTrade(models.TradeSourceBacktest, fill)
+	if err != nil {
+		return err
+	}
+	trade.SignalID = signalID
+	e.open[symbol] = trade
+	e.fills = append(e.fills, fill)
+	return nil
+}
+
+// exit закрывает весь открытый объем сделки
+func (e *Engine) exit(trade *models.Trade, price models.Decimal, at time.Time) error {
+	fill := e.fill(models.Fill{
+		Symbol:   trade.Symbol,
+		Side:     trade.Side,
+		Reduce:   true,
+		Price:    price,
+		Quantity: trade.Quantity,
+		Time:     at,
+	})
+	if err := trade.Apply(fill); err != nil {
+		return fmt.Errorf("выход из сделки %s: %w", trade.ID, err)
+	}
+	delete(e.open, trade.Symbol)
+	e.closed = append(e.closed, trade)
+	e.fills = append(e.fills, fill)
+	return nil
+}
+
+// fill исполняет заявку: по стакану к моменту исполнения, если он есть, и с комиссией
+// от стоимости исполнения. Без снимка стакана исполнение остается по расчетной цене
+// и в проскальзывании не учитывается.
+func (e *Engine) fill(fill models.Fill) models.Fill {
+	if e.options.OrderBookFills {
+		if book := e.history.OrderBook(fill.Symbol, fill.Time, maxOrderBookAge); book != nil {
+			// Если глубины не хватило, цена считается по всему стакану: это оценка снизу
+			fill, _ = book.SimulateFill(fill)
+		}
+	}
+	fill.Fee = fill.Notional().Mul(e.options.FeeRate)
+	return fill
+}
+
+// Close закрывает сделки, открытые к концу периода at, по последней цене
+func (e *Engine) Close(at time.Time) error {
+	symbols := make([]string, 0, len(e.open))
+	for symbol := range e.open {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		price, ok := e.history.Price(symbol, at)
+		if !ok {
+			continue
+		}
+		if err := e.exit(e.open[symbol], price, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Records возвращает записи закрытых сделок по порядку закрытия
+func (e *Engine) Records() []models.TradeRecord {
+	records := make([]models.TradeRecord, 0, len(e.closed))
+	for _, trade := range e.closed {
+		records = append(records, models.NewTradeRecord(trade))
+	}
+	return records
+}
+
+// Fills возвращает все исполнения по порядку; по ним считается проскальзывание запуска
+func (e *Engine) Fills() []models.Fill {
+	return e.fills
+}
@@ -0,0 +1,181 @@
+// Package backtest воспроизводит расчет сигналов по сохраненной истории и проверяет
+// на ней стратегию: история загружается из хранилища за период, анализатор получает
+// ее по шагам симулированного времени, а сделки по сигналам учитываются моделями
+// pkg/models. Здесь же проверка целостности истории перед воспроизведением.
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// AnalysisIntervals интервалы свечей, которые читают анализаторы: минутные -
+// технический анализ и дельта объемов, часовые - анализ открытого интереса
+var AnalysisIntervals = []models.Interval{models.Interval1m, models.Interval1h}
+
+// Свечей, которые читает технический анализ
+const technicalCandles = 100
+
+// Warmup возвращает, за сколько до начала периода нужна история, чтобы первые сигналы
+// рассчитывались по полным данным: по наибольшей глубине, которую читают анализаторы
+func Warmup(cfg config.AnalysisConfig) time.Duration {
+	minutes := time.Duration(max(technicalCandles, cfg.VolumeDelta.Lookback*60)) * time.Minute
+	hours := time.Duration(cfg.OpenInterest.Lookback) * time.Hour
+	// Ставки финансирования приходят раз в 8 часов; по нескольким из них определяется период
+	funding := time.Duration(max(cfg.Funding.Periods, 3)+1) * 8 * time.Hour
+	return max(minutes, hours, funding)
+}
+
+// History данные символов за период [From, To) по возрастанию времени
+type History struct {
+	From    time.Time
+	To      time.Time
+	Symbols []string
+	symbols map[string]*symbolHistory
+}
+
+// symbolHistory данные одного символа
+type symbolHistory struct {
+	candles      map[models.Interval][]*models.Candle
+	funding      []*models.FundingRate
+	openInterest []*models.OpenInterest
+	orderBooks   []*models.OrderBook
+}
+
+// LoadHistory загружает из хранилища свечи интервалов intervals, ставки
+// финансирования, открытый интерес и снимки стакана символов за период [from, to)
+func LoadHistory(ctx context.Context, reader storage.HistoryReader, symbols []string, intervals []models.Interval, from, to time.Time) (*History, error) {
+	h := &History{
+		From:    from,
+		To:      to,
+		Symbols: symbols,
+		symbols: make(map[string]*symbolHistory, len(symbols)),
+	}
+	for _, symbol := range symbols {
+		sh := &symbolHistory{candles: make(map[models.Interval][]*models.Candle, len(intervals))}
+		for _, interval := range intervals {
+			candles, err := reader.GetCandleRange(ctx, symbol, interval, from, to)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", symbol, interval, err)
+			}
+			sh.candles[interval] = candles
+		}
+
+		var err error
+		if sh.funding, err = reader.GetFundingRateRange(ctx, symbol, from, to); err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		if sh.openInterest, err = reader.GetOpenInterestRange(ctx, symbol, from, to); err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		if sh.orderBooks, err = reader.GetOrderBookRange(ctx, symbol, from, to); err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		h.symbols[symbol] = sh
+	}
+	return h, nil
+}
+
+// Candles возвращает свечи символа и интервала, старые первыми
+func (h *History) Candles(symbol string, interval models.Interval) []*models.Candle {
+	if sh := h.symbols[symbol]; sh != nil {
+		return sh.candles[interval]
+	}
+	return nil
+}
+
+// FundingRates возвращает ставки финансирования символа, старые первыми
+func (h *History) FundingRates(symbol string) []*models.FundingRate {
+	if sh := h.symbols[symbol]; sh != nil {
+		return sh.funding
+	}
+	return nil
+}
+
+// OpenInterest возвращает открытый интерес символа, старые первыми
+func (h *History) OpenInterest(symbol string) []*models.OpenInterest {
+	if sh := h.symbols[symbol]; sh != nil {
+		return sh.openInterest
+	}
+	return nil
+}
+
+// OrderBooks возвращает снимки стакана символа, старые первыми
+func (h *History) OrderBooks(symbol string) []*models.OrderBook {
+	if sh := h.symbols[symbol]; sh != nil {
+		return sh.orderBooks
+	}
+	return nil
+}
+
+// Aggregate собирает свечи интервала из минутных у символов, для которых свечи
+// интервала не загружены, например если дозагружались только минутные
+func (h *History) Aggregate(interval models.Interval) {
+	for _, sh := range h.symbols {
+		if len(sh.candles[interval]) == 0 {
+			sh.candles[interval] = AggregateCandles(sh.candles[models.Interval1m], interval)
+		}
+	}
+}
+
+// Price возвращает цену закрытия последней минутной свечи символа, закрытой к
+// моменту at. ok false - закрытых свечей еще нет.
+func (h *History) Price(symbol string, at time.Time) (models.Decimal, bool) {
+	candles := h.Candles(symbol, models.Interval1m)
+	i := sort.Search(len(candles), func(i int) bool { return candles[i].CloseTime.After(at) })
+	if i == 0 {
+		return models.ZeroDecimal, false
+	}
+	return candles[i-1].Close, true
+}
+
+// OrderBook возвращает последний снимок стакана символа к моменту at, сделанный
+// не раньше чем за maxAge до него; nil - подходящего снимка нет
+func (h *History) OrderBook(symbol string, at time.Time, maxAge time.Duration) *models.OrderBook {
+	books := h.OrderBooks(symbol)
+	i := sort.Search(len(books), func(i int) bool { return books[i].Timestamp.After(at) })
+	if i == 0 || at.Sub(books[i-1].Timestamp) > maxAge {
+		return nil
+	}
+	return books[i-1]
+}
+
+// AggregateCandles собирает свечи большего интервала из минутных, старых первыми
+func AggregateCandles(minutes []*models.Candle, interval models.Interval) []*models.Candle {
+	var result []*models.Candle
+	var current *models.Candle
+	for _, m := range minutes {
+		openTime := interval.Truncate(m.OpenTime)
+		if current == nil || !current.OpenTime.Equal(openTime) {
+			current = &models.Candle{
+				Symbol:    m.Symbol,
+				Interval:  interval,
+				OpenTime:  openTime,
+				Open:      m.Open,
+				High:      m.High,
+				Low:       m.Low,
+				CloseTime: interval.Next(openTime),
+			}
+			result = append(result, current)
+		}
+		if m.High.GreaterThan(current.High) {
+			current.High = m.High
+		}
+		if m.Low.LessThan(current.Low) {
+			current.Low = m.Low
+		}
+		current.Close = m.Close
+		current.Volume = current.Volume.Add(m.Volume)
+		current.QuoteVolume = current.QuoteVolume.Add(m.QuoteVolume)
+		current.NumTrades += m.NumTrades
+		current.TakerBuyVolume = current.TakerBuyVolume.Add(m.TakerBuyVolume)
+		current.TakerBuyQuoteVolume = current.TakerBuyQuoteVolume.Add(m.TakerBuyQuoteVolume)
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skalibog/bfma/internal/analysis/aggregator"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/clock"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// StepFunc получает сигналы шага воспроизведения at; ошибка останавливает воспроизведение
+type StepFunc func(at time.Time, signals map[string]*models.SignalResult) error
+
+// Replay воспроизводит расчет сигналов по истории: на каждом шаге анализатор видит
+// в хранилище в памяти только данные, известные к этому моменту (свечи - закрытые),
+// а время сигналов идет по симулированным часам
+type Replay struct {
+	history  *History
+	store    *storage.MemoryStorage
+	clock    *clock.Simulated
+	analyzer *aggregator.Analyzer
+	cursors  map[string]*cursor // Сколько данных символа уже передано в хранилище
+}
+
+// cursor позиции следующих непереданных данных символа
+type cursor struct {
+	candles      map[models.Interval]int
+	funding      int
+	openInterest int
+	orderBooks   int
+}
+
+// NewReplay создает воспроизведение истории с настройками анализа cfg. Часовые свечи,
+// которых нет в истории, собираются из минутных.
+func NewReplay(cfg config.AnalysisConfig, history *History) *Replay {
+	history.Aggregate(models.Interval1h)
+
+	store := storage.NewMemoryStorage()
+	sim := clock.NewSimulated(history.From)
+	analyzer := aggregator.NewAnalyzer(cfg, store, nil, history.Symbols, nil)
+	analyzer.SetClock(sim)
+
+	cursors := make(map[string]*cursor, len(history.Symbols))
+	for _, symbol := range history.Symbols {
+		cursors[symbol] = &cursor{candles: make(map[models.Interval]int)}
+	}
+	return &Replay{
+		history:  history,
+		store:    store,
+		clock:    sim,
+		analyzer: analyzer,
+		cursors:  cursors,
+	}
+}
+
+// Run рассчитывает сигналы с шагом step в периоде [from, to) и передает их onStep.
+// Символ без данных к шагу пропускается анализатором с ошибкой в журнале, как
+// в приложении.
+func (r *Replay) Run(ctx context.Context, from, to time.Time, step time.Duration, onStep StepFunc) error {
+	if step <= 0 {
+		return fmt.Errorf("шаг воспроизведения должен быть положительным")
+	}
+	for at := from; at.Before(to); at = at.Add(step) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.feed(ctx, at); err != nil {
+			return err
+		}
+		r.clock.Set(at)
+
+		signals, err := r.analyzer.GenerateSignals(ctx)
+		if err != nil {
+			return fmt.Errorf("расчет сигналов на %s: %w", at.UTC().Format(time.RFC3339), err)
+		}
+		if err := onStep(at, signals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// feed передает в хранилище данные, известные к моменту at
+func (r *Replay) feed(ctx context.Context, at time.Time) error {
+	for _, symbol := range r.history.Symbols {
+		c := r.cursors[symbol]
+
+		for _, interval := range AnalysisIntervals {
+			candles := r.history.Candles(symbol, interval)
+			i := c.candles[interval]
+			for ; i < len(candles) && !candles[i].CloseTime.After(at); i++ {
+				if err := r.store.SaveCandle(ctx, candles[i]); err != nil {
+					return err
+				}
+			}
+			c.candles[interval] = i
+		}
+
+		rates := r.history.FundingRates(symbol)
+		for ; c.funding < len(rates) && !rates[c.funding].Timestamp.After(at); c.funding++ {
+			if err := r.store.SaveFundingRate(ctx, rates[c.funding]); err != nil {
+				return err
+			}
+		}
+
+		values := r.history.OpenInterest(symbol)
+		for ; c.openInterest < len(values) && !values[c.openInterest].Timestamp.After(at); c.openInterest++ {
+			if err := r.store.SaveOpenInterest(ctx, values[c.openInterest]); err != nil {
+				return err
+			}
+		}
+
+		books := r.history.OrderBooks(symbol)
+		for ; c.orderBooks < len(books) && !books[c.orderBooks].Timestamp.After(at); c.orderBooks++ {
+			if err := r.store.SaveOrderBook(ctx, books[c.orderBooks]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
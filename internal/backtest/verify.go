@@ -0,0 +1,184 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Виды данных в проблемах истории
+const (
+	DataCandles      = "candles"
+	DataFunding      = "funding"
+	DataOpenInterest = "open_interest"
+	DataOrderBooks   = "orderbooks"
+)
+
+// Issue проблема сохраненной истории символа
+type Issue struct {
+	Symbol   string          `json:"symbol"`
+	Data     string          `json:"data"`               // Data*
+	Interval models.Interval `json:"interval,omitempty"` // Интервал свечей
+	From     time.Time       `json:"from"`               // Начало участка с проблемой
+	To       time.Time       `json:"to"`                 // Конец участка; для одной записи совпадает с From
+	Message  string          `json:"message"`
+}
+
+// Во сколько раз промежуток между записями должен превышать обычный (медианный),
+// чтобы считаться пропуском: ставки и открытый интерес собираются не строго по часам
+const gapFactor = 3
+
+// Verify проверяет историю: пропуски и повторы свечей, неверные цены и объемы свечей,
+// пропуски ставок финансирования, открытого интереса и снимков стакана, пересекающиеся
+// стаканы. Проблемы возвращаются по символам и времени.
+func Verify(h *History, intervals []models.Interval) []Issue {
+	var issues []Issue
+	for _, symbol := range h.Symbols {
+		for _, interval := range intervals {
+			issues = append(issues, verifyCandles(symbol, interval, h.Candles(symbol, interval), h.From, h.To)...)
+		}
+		issues = append(issues, verifyGaps(symbol, DataFunding, timesOf(h.FundingRates(symbol), func(r *models.FundingRate) time.Time { return r.Timestamp }))...)
+		issues = append(issues, verifyGaps(symbol, DataOpenInterest, timesOf(h.OpenInterest(symbol), func(oi *models.OpenInterest) time.Time { return oi.Timestamp }))...)
+		issues = append(issues, verifyOrderBooks(symbol, h.OrderBooks(symbol))...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Symbol != issues[j].Symbol {
+			return issues[i].Symbol < issues[j].Symbol
+		}
+		return issues[i].From.Before(issues[j].From)
+	})
+	return issues
+}
+
+// verifyCandles проверяет свечи интервала в периоде [from, to): каждая свеча на своем
+// месте, без повторов и пропусков, с согласованными ценами и объемами
+func verifyCandles(symbol string, interval models.Interval, candles []*models.Candle, from, to time.Time) []Issue {
+	issue := func(start, end time.Time, format string, args ...interface{}) Issue {
+		return Issue{Symbol: symbol, Data: DataCandles, Interval: interval, From: start, To: end, Message: fmt.Sprintf(format, args...)}
+	}
+	// Последняя свеча периода, которая уже должна быть закрыта
+	last := interval.Truncate(to)
+	if !last.Before(to) {
+		last = last.Add(-interval.Duration())
+	}
+
+	if len(candles) == 0 {
+		return []Issue{issue(from, to, "нет свечей за период")}
+	}
+
+	var issues []Issue
+	expected := interval.Truncate(from)
+	if expected.Before(from) {
+		expected = interval.Next(from)
+	}
+	for _, candle := range candles {
+		switch {
+		case candle.OpenTime.Before(expected):
+			issues = append(issues, issue(candle.OpenTime, candle.OpenTime, "повтор свечи"))
+			continue
+		case candle.OpenTime.After(expected):
+			issues = append(issues, missing(issue, interval, expected, candle.OpenTime))
+		}
+		if problem := candleProblem(candle); problem != "" {
+			issues = append(issues, issue(candle.OpenTime, candle.OpenTime, "%s", problem))
+		}
+		expected = interval.Next(candle.OpenTime)
+	}
+	if !expected.After(last) {
+		issues = append(issues, missing(issue, interval, expected, interval.Next(last)))
+	}
+	return issues
+}
+
+// missing описывает пропуск свечей с открытием в [start, end)
+func missing(issue func(start, end time.Time, format string, args ...interface{}) Issue, interval models.Interval, start, end time.Time) Issue {
+	count := 0
+	for t := start; t.Before(end); t = interval.Next(t) {
+		count++
+	}
+	return issue(start, end, "пропущено свечей: %d", count)
+}
+
+// candleProblem возвращает описание несогласованных цен или объемов свечи; пусто - свеча верна
+func candleProblem(c *models.Candle) string {
+	switch {
+	case !c.Open.IsPositive() || !c.High.IsPositive() || !c.Low.IsPositive() || !c.Close.IsPositive():
+		return "цена не больше 0"
+	case c.High.LessThan(c.Low):
+		return fmt.Sprintf("максимум %s меньше минимума %s", c.High, c.Low)
+	case c.High.LessThan(c.Open) || c.High.LessThan(c.Close):
+		return fmt.Sprintf("максимум %s меньше цены открытия или закрытия", c.High)
+	case c.Low.GreaterThan(c.Open) || c.Low.GreaterThan(c.Close):
+		return fmt.Sprintf("минимум %s больше цены открытия или закрытия", c.Low)
+	case c.Volume.IsNegative() || c.QuoteVolume.IsNegative():
+		return "отрицательный объем"
+	case c.TakerBuyVolume.GreaterThan(c.Volume):
+		return fmt.Sprintf("объем покупок %s больше объема свечи %s", c.TakerBuyVolume, c.Volume)
+	}
+	return ""
+}
+
+// verifyGaps отмечает промежутки между записями больше gapFactor обычных. Обычный
+// промежуток - медианный, поэтому проверка не зависит от периода сбора.
+func verifyGaps(symbol, data string, times []time.Time) []Issue {
+	if len(times) == 0 {
+		return []Issue{{Symbol: symbol, Data: data, Message: "нет данных за период"}}
+	}
+	if len(times) < 3 {
+		return nil
+	}
+
+	gaps := make([]time.Duration, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		gaps = append(gaps, times[i].Sub(times[i-1]))
+	}
+	sorted := append([]time.Duration(nil), gaps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	usual := sorted[len(sorted)/2]
+
+	var issues []Issue
+	for i, gap := range gaps {
+		if usual > 0 && gap > gapFactor*usual {
+			issues = append(issues, Issue{
+				Symbol:  symbol,
+				Data:    data,
+				From:    times[i],
+				To:      times[i+1],
+				Message: fmt.Sprintf("нет данных %s при обычном промежутке %s", gap, usual),
+			})
+		}
+	}
+	return issues
+}
+
+// verifyOrderBooks проверяет пропуски снимков стакана и стаканы, где лучшая цена
+// покупки не ниже лучшей цены продажи или пуста одна из сторон
+func verifyOrderBooks(symbol string, books []*models.OrderBook) []Issue {
+	issues := verifyGaps(symbol, DataOrderBooks, timesOf(books, func(ob *models.OrderBook) time.Time { return ob.Timestamp }))
+	for _, book := range books {
+		top := book.Top(1)
+		message := ""
+		switch {
+		case len(top.Bids) == 0 || len(top.Asks) == 0:
+			message = "пустая сторона стакана"
+		case !top.Bids[0].Price.LessThan(top.Asks[0].Price):
+			message = fmt.Sprintf("лучшая покупка %s не ниже лучшей продажи %s", top.Bids[0].Price, top.Asks[0].Price)
+		default:
+			continue
+		}
+		issues = append(issues, Issue{Symbol: symbol, Data: DataOrderBooks, From: book.Timestamp, To: book.Timestamp, Message: message})
+	}
+	return issues
+}
+
+// timesOf возвращает время записей
+func timesOf[T any](items []T, at func(T) time.Time) []time.Time {
+	times := make([]time.Time, len(items))
+	for i, item := range items {
+		times[i] = at(item)
+	}
+	return times
+}
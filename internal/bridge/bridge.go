@@ -0,0 +1,296 @@
+// Package bridge переводит сигналы bfma в команды сторонних торговых ботов:
+// вебхуки сигнальных ботов 3Commas и REST API Freqtrade (forceenter/forceexit).
+// Команды отправляются только при смене желаемой позиции символа, а не на каждый сигнал.
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Параметры отправки команд
+const (
+	queueSize      = 64               // Команд в очереди моста; при переполнении новые отбрасываются
+	maxAttempts    = 3                // Попыток отправить команду
+	initialBackoff = time.Second      // Пауза перед первым повтором, дальше удваивается
+	requestTimeout = 10 * time.Second // Время на один запрос к боту
+	maxErrorBody   = 512              // Сколько байт ответа с ошибкой попадает в журнал
+)
+
+// Позиции символа с точки зрения моста
+const (
+	positionFlat  = "flat"
+	positionLong  = "long"
+	positionShort = "short"
+)
+
+// Команды ботам; совпадают с действиями сигнальных ботов 3Commas
+const (
+	ActionEnterLong  = "enter_long"
+	ActionExitLong   = "exit_long"
+	ActionEnterShort = "enter_short"
+	ActionExitShort  = "exit_short"
+)
+
+// Order команда боту
+type Order struct {
+	Symbol string
+	Action string
+	Price  float64 // Цена на момент сигнала
+	Time   time.Time
+}
+
+// adapter отправляет команды в формате конкретного бота
+type adapter interface {
+	send(ctx context.Context, order Order) error
+}
+
+// statusError ответ бота с кодом ошибки
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("ответ %d: %s", e.code, e.body)
+}
+
+// retryable сообщает, стоит ли повторять команду: ошибки сети, 429 и 5xx
+func retryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusTooManyRequests || status.code >= 500
+	}
+	return true
+}
+
+// bridge мост к одному боту
+type bridge struct {
+	cfg       config.BridgeConfig
+	adapter   adapter
+	queue     chan Order
+	positions map[string]string // Символ -> позиция, которую мост считает открытой
+	mutex     sync.Mutex
+}
+
+// Publisher переводит сигналы в команды всех настроенных ботов
+type Publisher struct {
+	bridges []*bridge
+}
+
+// NewPublisher создает мосты из настроек
+func NewPublisher(bridges []config.BridgeConfig) *Publisher {
+	client := &http.Client{Timeout: requestTimeout}
+	p := &Publisher{}
+	for _, cfg := range bridges {
+		b := &bridge{cfg: cfg, queue: make(chan Order, queueSize), positions: make(map[string]string)}
+		switch cfg.Type {
+		case config.BridgeFreqtrade:
+			b.adapter = &freqtrade{cfg: cfg, client: client}
+		default:
+			b.adapter = &threeCommas{cfg: cfg, client: client}
+		}
+		p.bridges = append(p.bridges, b)
+	}
+	return p
+}
+
+// Start отправляет команды до отмены контекста; каждый мост обслуживается отдельно,
+// чтобы недоступный бот не задерживал остальные
+func (p *Publisher) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, b := range p.bridges {
+		logger.Info("Запуск моста к торговому боту", zap.String("bridge", b.cfg.Name), zap.String("type", b.cfg.Type))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// PublishSignals переводит новые сигналы в команды. Первый сигнал символа после
+// запуска только задает исходную позицию: состояние бота до запуска неизвестно.
+func (p *Publisher) PublishSignals(signals map[string]*models.SignalResult) {
+	for _, b := range p.bridges {
+		for symbol, signal := range signals {
+			if len(b.cfg.Symbols) > 0 && !slices.Contains(b.cfg.Symbols, symbol) {
+				continue
+			}
+			b.update(symbol, signal)
+		}
+	}
+}
+
+// update сравнивает желаемую позицию с текущей и ставит в очередь команды перехода
+func (b *bridge) update(symbol string, signal *models.SignalResult) {
+	b.mutex.Lock()
+	current, known := b.positions[symbol]
+	if !known {
+		current = positionFlat
+	}
+	target := desiredPosition(b.cfg, signal.RecommendationCode, current)
+	b.positions[symbol] = target
+	b.mutex.Unlock()
+
+	if !known || target == current {
+		return
+	}
+
+	var actions []string
+	switch current {
+	case positionLong:
+		actions = append(actions, ActionExitLong)
+	case positionShort:
+		actions = append(actions, ActionExitShort)
+	}
+	switch target {
+	case positionLong:
+		actions = append(actions, ActionEnterLong)
+	case positionShort:
+		actions = append(actions, ActionEnterShort)
+	}
+
+	for _, action := range actions {
+		order := Order{Symbol: symbol, Action: action, Price: signal.CurrentPrice, Time: signal.Timestamp}
+		select {
+		case b.queue <- order:
+		default:
+			logger.Warn("Очередь моста переполнена, команда пропущена", zap.String("bridge", b.cfg.Name),
+				zap.String("symbol", symbol), zap.String("action", action))
+		}
+	}
+}
+
+// desiredPosition возвращает позицию, которую должен держать бот по рекомендации.
+// Сигнал, не дотягивающий до входа (BUY при strong_only), закрывает только
+// противоположную позицию.
+func desiredPosition(cfg config.BridgeConfig, code models.Recommendation, current string) string {
+	switch code {
+	case models.RecommendationStrongBuy:
+		return positionLong
+	case models.RecommendationBuy:
+		if !cfg.StrongOnly {
+			return positionLong
+		}
+		if current == positionShort {
+			return positionFlat
+		}
+	case models.RecommendationStrongSell:
+		return shortOrFlat(cfg)
+	case models.RecommendationSell:
+		if !cfg.StrongOnly {
+			return shortOrFlat(cfg)
+		}
+		if current == positionLong {
+			return positionFlat
+		}
+	case models.RecommendationNeutral:
+		if cfg.ExitOnNeutral {
+			return positionFlat
+		}
+	}
+	return current
+}
+
+// shortOrFlat возвращает позицию по сигналу продажи
+func shortOrFlat(cfg config.BridgeConfig) string {
+	if cfg.Short {
+		return positionShort
+	}
+	return positionFlat
+}
+
+// run отправляет команды по порядку: закрытие позиции всегда предшествует открытию
+// противоположной
+func (b *bridge) run(ctx context.Context) {
+	for {
+		select {
+		case order := <-b.queue:
+			b.deliver(ctx, order)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver отправляет команду с повторами
+func (b *bridge) deliver(ctx context.Context, order Order) {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := b.adapter.send(ctx, order)
+		if err == nil {
+			logger.Info("Команда отправлена торговому боту", zap.String("bridge", b.cfg.Name),
+				zap.String("symbol", order.Symbol), zap.String("action", order.Action))
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if attempt == maxAttempts || !retryable(err) {
+			logger.Error("Команда торговому боту не отправлена", zap.String("bridge", b.cfg.Name),
+				zap.String("symbol", order.Symbol), zap.String("action", order.Action), zap.Int("attempts", attempt), zap.Error(err))
+			return
+		}
+
+		logger.Warn("Ошибка отправки команды торговому боту, повтор", zap.String("bridge", b.cfg.Name),
+			zap.Int("attempt", attempt), zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// doJSON выполняет запрос с телом JSON и разбирает ответ в result (nil - ответ не нужен)
+func doJSON(ctx context.Context, client *http.Client, method, url string, body, result interface{}, auth func(*http.Request)) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth != nil {
+		auth(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
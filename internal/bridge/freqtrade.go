@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Метка сделок Freqtrade, открытых по сигналам bfma
+const freqtradeEntryTag = "bfma"
+
+// Котируемые активы, по которым символ Binance делится на пару Freqtrade
+var freqtradeQuotes = []string{"USDT", "USDC", "FDUSD", "BUSD"}
+
+// freqtradeTrade открытая сделка из /api/v1/status
+type freqtradeTrade struct {
+	TradeID int    `json:"trade_id"`
+	Pair    string `json:"pair"`
+	IsShort bool   `json:"is_short"`
+}
+
+// freqtrade управляет ботом Freqtrade через REST API: forceenter открывает сделку,
+// forceexit закрывает открытые сделки пары. В конфигурации бота нужен
+// force_entry_enable: true.
+type freqtrade struct {
+	cfg    config.BridgeConfig
+	client *http.Client
+}
+
+func (f *freqtrade) send(ctx context.Context, order Order) error {
+	pair := f.pair(order.Symbol)
+	switch order.Action {
+	case ActionEnterLong, ActionEnterShort:
+		side := "long"
+		if order.Action == ActionEnterShort {
+			side = "short"
+		}
+		body := map[string]string{"pair": pair, "side": side, "entry_tag": freqtradeEntryTag}
+		return doJSON(ctx, f.client, http.MethodPost, f.endpoint("forceenter"), body, nil, f.auth)
+	default:
+		var trades []freqtradeTrade
+		if err := doJSON(ctx, f.client, http.MethodGet, f.endpoint("status"), nil, &trades, f.auth); err != nil {
+			return err
+		}
+		short := order.Action == ActionExitShort
+		for _, trade := range trades {
+			if trade.Pair != pair || trade.IsShort != short {
+				continue
+			}
+			body := map[string]interface{}{"tradeid": trade.TradeID, "ordertype": "market"}
+			if err := doJSON(ctx, f.client, http.MethodPost, f.endpoint("forceexit"), body, nil, f.auth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// endpoint возвращает адрес метода REST API
+func (f *freqtrade) endpoint(method string) string {
+	return strings.TrimSuffix(f.cfg.URL, "/") + "/api/v1/" + method
+}
+
+// auth добавляет к запросу учетные данные api_server бота
+func (f *freqtrade) auth(req *http.Request) {
+	req.SetBasicAuth(f.cfg.Username, f.cfg.Password)
+}
+
+// pair возвращает пару Freqtrade: из pairs или фьючерсную пару BTC/USDT:USDT
+func (f *freqtrade) pair(symbol string) string {
+	if pair, ok := f.cfg.Pairs[symbol]; ok {
+		return pair
+	}
+	for _, quote := range freqtradeQuotes {
+		if base, ok := strings.CutSuffix(symbol, quote); ok && base != "" {
+			return base + "/" + quote + ":" + quote
+		}
+	}
+	return symbol
+}
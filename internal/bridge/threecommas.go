@@ -0,0 +1,59 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Адрес вебхуков сигнальных ботов 3Commas по умолчанию
+const threeCommasURL = "https://api.3commas.io/signal_bots/webhooks"
+
+// Сколько секунд сигнал остается действительным для 3Commas
+const threeCommasMaxLag = "300"
+
+// threeCommasSignal тело вебхука сигнального бота 3Commas
+type threeCommasSignal struct {
+	Secret       string `json:"secret"`
+	MaxLag       string `json:"max_lag"`
+	Timestamp    string `json:"timestamp"`
+	TriggerPrice string `json:"trigger_price"`
+	TVExchange   string `json:"tv_exchange"`
+	TVInstrument string `json:"tv_instrument"`
+	Action       string `json:"action"`
+	BotUUID      string `json:"bot_uuid"`
+}
+
+// threeCommas отправляет команды сигнальному боту 3Commas в формате вебхука TradingView
+type threeCommas struct {
+	cfg    config.BridgeConfig
+	client *http.Client
+}
+
+func (t *threeCommas) send(ctx context.Context, order Order) error {
+	url := t.cfg.URL
+	if url == "" {
+		url = threeCommasURL
+	}
+	signal := threeCommasSignal{
+		Secret:       t.cfg.Secret,
+		MaxLag:       threeCommasMaxLag,
+		Timestamp:    order.Time.UTC().Format("2006-01-02T15:04:05Z"),
+		TriggerPrice: strconv.FormatFloat(order.Price, 'f', -1, 64),
+		TVExchange:   "BINANCE",
+		TVInstrument: t.instrument(order.Symbol),
+		Action:       order.Action,
+		BotUUID:      t.cfg.BotUUID,
+	}
+	return doJSON(ctx, t.client, http.MethodPost, url, signal, nil, nil)
+}
+
+// instrument возвращает тикер TradingView: из pairs или бессрочный фьючерс BTCUSDT.P
+func (t *threeCommas) instrument(symbol string) string {
+	if pair, ok := t.cfg.Pairs[symbol]; ok {
+		return pair
+	}
+	return symbol + ".P"
+}
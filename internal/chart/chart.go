@@ -0,0 +1,301 @@
+// Package chart рисует PNG-снимки графика символа для оповещений в мессенджерах:
+// свечи, отметки смены рекомендации, уровни стакана и диапазон периода, ниже - сила
+// сигнала. Подписей на изображении нет: цены уровней возвращаются для текста сообщения.
+package chart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/analysis/orderbook"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Размеры и глубина графика
+const (
+	width      = 800
+	height     = 450
+	padding    = 12
+	candles    = 120 // Свечей на графике
+	history    = 500 // Сигналов для отметок и силы сигнала
+	minCandles = 2   // Меньше свечей - графика нет
+	dash       = 6   // Длина штриха линий уровней
+)
+
+// Цвета графика
+var (
+	colorBackground = color.RGBA{0x1e, 0x1e, 0x2e, 0xff}
+	colorGrid       = color.RGBA{0x44, 0x44, 0x55, 0xff}
+	colorUp         = color.RGBA{0x9e, 0xce, 0x6a, 0xff}
+	colorDown       = color.RGBA{0xf7, 0x76, 0x8e, 0xff}
+	colorRange      = color.RGBA{0x88, 0x88, 0x99, 0xff}
+	colorSupport    = color.RGBA{0x73, 0xda, 0xca, 0xff}
+	colorResistance = color.RGBA{0xff, 0x9e, 0x64, 0xff}
+)
+
+// ErrNotEnoughData свечей для графика пока мало
+var ErrNotEnoughData = errors.New("недостаточно свечей для графика")
+
+// Source данные для графика
+type Source interface {
+	GetCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error)
+	GetSignalHistory(ctx context.Context, symbol string, limit int) ([]*models.SignalResult, error)
+	GetLatestOrderBook(ctx context.Context, symbol string) (*models.OrderBook, error)
+}
+
+// Level ключевой уровень на графике
+type Level struct {
+	Name  string // Сопротивление, поддержка, максимум или минимум периода
+	Price float64
+	color color.RGBA
+}
+
+// Snapshot снимок графика
+type Snapshot struct {
+	PNG    []byte
+	Levels []Level
+}
+
+// Describe возвращает цены уровней для текста оповещения
+func (s *Snapshot) Describe() string {
+	parts := make([]string, 0, len(s.Levels))
+	for _, level := range s.Levels {
+		parts = append(parts, level.Name+" "+strconv.FormatFloat(level.Price, 'f', -1, 64))
+	}
+	return "Уровни: " + strings.Join(parts, ", ")
+}
+
+// Renderer рисует графики по данным хранилища
+type Renderer struct {
+	source   Source
+	interval func(symbol string) models.Interval // Интервал свечей символа
+}
+
+// NewRenderer создает отрисовку графиков
+func NewRenderer(source Source, interval func(symbol string) models.Interval) *Renderer {
+	return &Renderer{source: source, interval: interval}
+}
+
+// Render рисует график символа. Без стакана и истории сигналов график рисуется без
+// уровней стакана и отметок; без свечей возвращается ErrNotEnoughData.
+func (r *Renderer) Render(ctx context.Context, symbol string) (*Snapshot, error) {
+	bars, err := r.source.GetCandles(ctx, symbol, r.interval(symbol), candles)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки свечей: %w", err)
+	}
+	if len(bars) < minCandles {
+		return nil, ErrNotEnoughData
+	}
+	signals, err := r.source.GetSignalHistory(ctx, symbol, history)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки истории сигналов: %w", err)
+	}
+	// Хранилище возвращает новые данные первыми
+	slices.Reverse(bars)
+	slices.Reverse(signals)
+
+	var levels []Level
+	if book, err := r.source.GetLatestOrderBook(ctx, symbol); err == nil && book != nil {
+		support, resistance, err := orderbook.KeyLevels(book)
+		if err == nil && resistance != nil {
+			levels = append(levels, Level{Name: "сопротивление", Price: resistance.Price, color: colorResistance})
+		}
+		if err == nil && support != nil {
+			levels = append(levels, Level{Name: "поддержка", Price: support.Price, color: colorSupport})
+		}
+	}
+	low, high := bars[0].Low.InexactFloat64(), bars[0].High.InexactFloat64()
+	for _, bar := range bars {
+		low, high = min(low, bar.Low.InexactFloat64()), max(high, bar.High.InexactFloat64())
+	}
+	levels = append(levels,
+		Level{Name: "максимум", Price: high, color: colorRange},
+		Level{Name: "минимум", Price: low, color: colorRange})
+
+	data, err := render(bars, signals, levels)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{PNG: data, Levels: levels}, nil
+}
+
+// render рисует PNG: сверху свечи с уровнями и отметками сигналов, снизу сила сигнала
+// по свечам. Свечи и сигналы передаются от старых к новым.
+func render(bars []*models.Candle, signals []*models.SignalResult, levels []Level) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	split := height * 7 / 10
+	priceArea := image.Rect(padding, padding, width-padding, split-padding/2)
+	strengthArea := image.Rect(padding, split+padding/2, width-padding, height-padding)
+
+	// Шкала цены охватывает свечи и уровни; место сверху и снизу оставлено под отметки
+	low, high := bars[0].Low.InexactFloat64(), bars[0].High.InexactFloat64()
+	for _, bar := range bars {
+		low, high = min(low, bar.Low.InexactFloat64()), max(high, bar.High.InexactFloat64())
+	}
+	for _, level := range levels {
+		low, high = min(low, level.Price), max(high, level.Price)
+	}
+	margin := (high - low) * 0.08
+	low, high = low-margin, high+margin
+	y := func(price float64) int {
+		return priceArea.Max.Y - scale(price-low, high-low, priceArea.Dy())
+	}
+
+	for _, level := range levels {
+		dashedLine(img, priceArea.Min.X, priceArea.Max.X, y(level.Price), level.color)
+	}
+
+	slot := float64(priceArea.Dx()) / float64(len(bars))
+	body := max(1, int(slot*0.6))
+	center := func(i int) int {
+		return priceArea.Min.X + int((float64(i)+0.5)*slot)
+	}
+	for i, bar := range bars {
+		c := colorUp
+		if bar.Close.LessThan(bar.Open) {
+			c = colorDown
+		}
+		x := center(i)
+		open, close := bar.Open.InexactFloat64(), bar.Close.InexactFloat64()
+		vline(img, x, y(bar.High.InexactFloat64()), y(bar.Low.InexactFloat64()), c)
+		top, bottom := y(max(open, close)), y(min(open, close))
+		fill(img, image.Rect(x-body/2, top, x-body/2+body, bottom+1), c)
+	}
+
+	// Сигналы относятся к свече, в которой получены; у свечи остается последний
+	strength := make([]*models.SignalResult, len(bars))
+	var previous models.Recommendation
+	for _, signal := range signals {
+		i := candleAt(bars, signal)
+		if i < 0 {
+			continue
+		}
+		strength[i] = signal
+		code := signal.RecommendationCode
+		if code == previous {
+			continue
+		}
+		if previous != "" {
+			marker(img, center(i), y(bars[i].Low.InexactFloat64()), y(bars[i].High.InexactFloat64()), code)
+		}
+		previous = code
+	}
+
+	var peak float64
+	for _, signal := range strength {
+		if signal != nil {
+			peak = max(peak, abs(signal.SignalStrength))
+		}
+	}
+	zero := strengthArea.Min.Y + strengthArea.Dy()/2
+	hline(img, strengthArea.Min.X, strengthArea.Max.X, zero, colorGrid)
+	for i, signal := range strength {
+		if signal == nil {
+			continue
+		}
+		x := center(i)
+		h := scale(abs(signal.SignalStrength), peak, strengthArea.Dy()/2)
+		if signal.SignalStrength >= 0 {
+			fill(img, image.Rect(x-body/2, zero-h, x-body/2+body, zero), colorUp)
+		} else {
+			fill(img, image.Rect(x-body/2, zero, x-body/2+body, zero+h), colorDown)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// candleAt возвращает индекс свечи, в которой получен сигнал; -1 - сигнал вне графика
+func candleAt(bars []*models.Candle, signal *models.SignalResult) int {
+	if signal.Timestamp.Before(bars[0].OpenTime) {
+		return -1
+	}
+	i, _ := slices.BinarySearchFunc(bars, signal.Timestamp, func(bar *models.Candle, t time.Time) int {
+		return bar.OpenTime.Compare(t)
+	})
+	if i == len(bars) || bars[i].OpenTime.After(signal.Timestamp) {
+		i--
+	}
+	return i
+}
+
+// marker рисует отметку смены рекомендации: треугольник вверх под свечой для покупки,
+// вниз над свечой для продажи; у сильного сигнала отметка крупнее
+func marker(img *image.RGBA, x, low, high int, code models.Recommendation) {
+	size := 4
+	if code.Strong() {
+		size = 7
+	}
+	switch code {
+	case models.RecommendationBuy, models.RecommendationStrongBuy:
+		for row := 0; row <= size; row++ {
+			hline(img, x-row, x+row, low+3+row, colorUp)
+		}
+	case models.RecommendationSell, models.RecommendationStrongSell:
+		for row := 0; row <= size; row++ {
+			hline(img, x-row, x+row, high-3-row, colorDown)
+		}
+	default:
+		hline(img, x-size, x+size, high-4, colorRange)
+	}
+}
+
+// scale переводит value из диапазона [0, span] в пиксели [0, size]
+func scale(value, span float64, size int) int {
+	if span <= 0 {
+		return size / 2
+	}
+	return int(value / span * float64(size))
+}
+
+// abs возвращает модуль числа
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// fill закрашивает прямоугольник
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r.Canon(), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// hline рисует горизонтальную линию
+func hline(img *image.RGBA, x0, x1, y int, c color.Color) {
+	for x := x0; x <= x1; x++ {
+		img.Set(x, y, c)
+	}
+}
+
+// dashedLine рисует горизонтальную штриховую линию
+func dashedLine(img *image.RGBA, x0, x1, y int, c color.Color) {
+	for x := x0; x <= x1; x++ {
+		if (x-x0)/dash%2 == 0 {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+// vline рисует вертикальную линию
+func vline(img *image.RGBA, x, y0, y1 int, c color.Color) {
+	for y := min(y0, y1); y <= max(y0, y1); y++ {
+		img.Set(x, y, c)
+	}
+}
@@ -1,7 +1,15 @@
 package config
 
 import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+
 	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
@@ -9,11 +17,44 @@ import (
 
 // Config представляет полную конфигурацию приложения
 type Config struct {
-	Binance  BinanceConfig  `yaml:"binance"`
-	Trading  TradingConfig  `yaml:"trading"`
-	Analysis AnalysisConfig `yaml:"analysis"`
-	Storage  StorageConfig  `yaml:"storage"`
-	UI       UIConfig       `yaml:"ui"`
+	Version     int                 `yaml:"version"`  // Версия схемы файла, см. CurrentVersion
+	Timezone    string              `yaml:"timezone"` // Часовой пояс времени в UI, журналах и экспорте: local, UTC или имя IANA
+	Binance     BinanceConfig       `yaml:"binance"`
+	Trading     TradingConfig       `yaml:"trading"`
+	Analysis    AnalysisConfig      `yaml:"analysis"`
+	Groups      []SymbolGroupConfig `yaml:"groups,omitempty"` // Группы символов с общими переопределениями
+	Storage     StorageConfig       `yaml:"storage"`
+	UI          UIConfig            `yaml:"ui"`
+	State       StateConfig         `yaml:"state"`
+	Account     AccountConfig       `yaml:"account"`
+	Execution   ExecutionConfig     `yaml:"execution"`
+	Risk        RiskConfig          `yaml:"risk"` // Ограничения риска, проверяются перед каждой заявкой
+	Logging     logger.Config       `yaml:"logging"`
+	Admin       AdminConfig         `yaml:"admin"`
+	API         APIConfig           `yaml:"api"`           // HTTP API данных для внешних программ
+	Telegram    TelegramConfig      `yaml:"telegram"`      // Оповещения и команды через бота Telegram
+	Discord     DiscordConfig       `yaml:"discord"`       // Оповещения в канал Discord
+	Webhooks    []WebhookConfig     `yaml:"webhooks"`      // Отправка сигналов и оповещений на внешние адреса
+	Email       EmailConfig         `yaml:"email"`         // Оповещения по почте (SMTP)
+	MQTT        MQTTConfig          `yaml:"mqtt"`          // Публикация сигналов и оповещений в брокер MQTT
+	Stream      StreamConfig        `yaml:"stream"`        // Поток сигналов и оповещений в Kafka или NATS
+	Notify      NotifyConfig        `yaml:"notifications"` // Маршрутизация и ограничение частоты оповещений
+	TradingView TradingViewConfig   `yaml:"tradingview"`   // Прием оповещений TradingView
+	Desktop     DesktopConfig       `yaml:"desktop"`       // Уведомления рабочего стола о сильных сигналах
+	Bridges     []BridgeConfig      `yaml:"bridges"`       // Команды сторонним торговым ботам по сигналам
+	Incidents   IncidentsConfig     `yaml:"incidents"`     // Эксплуатационные сбои в PagerDuty и Opsgenie
+	Watchdog    WatchdogConfig      `yaml:"watchdog"`      // Бюджет ошибок подсистем и автоматическое восстановление
+	Scanner     ScannerConfig       `yaml:"scanner"`       // Обзор всех фьючерсов биржи: кандидаты в списки наблюдения
+	Crash       CrashConfig         `yaml:"crash"`         // Отчеты о падениях на диск и в Sentry
+	Latency     LatencyConfig       `yaml:"latency"`       // Задержка от события биржи до сигнала по этапам
+	Usage       UsageConfig         `yaml:"usage"`         // Учет объема данных и запросов по символам
+	Escalation  EscalationConfig    `yaml:"escalation"`    // Звонки и экстренные push о самых сильных сигналах
+	Reports     ReportsConfig       `yaml:"reports"`       // Ежедневные и еженедельные отчеты
+	Shutdown    ShutdownConfig      `yaml:"shutdown"`
+	Output      OutputConfig        `yaml:"output"`
+	Updates     UpdatesConfig       `yaml:"updates"`
+	Schedule    ScheduleConfig      `yaml:"schedule"` // Окна торговых сессий и тихие часы оповещений
+	Features    Features            `yaml:"features"` // Включенные экспериментальные подсистемы
 }
 
 // BinanceConfig содержит настройки подключения к Binance
@@ -25,20 +66,22 @@ type BinanceConfig struct {
 
 // TradingConfig содержит настройки торговли
 type TradingConfig struct {
-	Symbols      []string `yaml:"symbols"`
-	Interval     string   `yaml:"interval"`
-	RiskPerTrade float64  `yaml:"risk_per_trade"`
+	Symbols      []string        `yaml:"symbols"`
+	Interval     models.Interval `yaml:"interval"`
+	RiskPerTrade float64         `yaml:"risk_per_trade"`
 }
 
 // AnalysisConfig содержит настройки аналитических модулей
 type AnalysisConfig struct {
-	IntervalSeconds  int                `yaml:"interval_seconds"`
+	Period           Duration           `yaml:"period"` // Период расчета сигналов ("10s")
 	Technical        TechnicalConfig    `yaml:"technical"`
 	OrderBook        OrderBookConfig    `yaml:"orderbook"`
 	Funding          FundingConfig      `yaml:"funding"`
 	OpenInterest     OpenInterestConfig `yaml:"open_interest"`
 	VolumeDelta      VolumeDeltaConfig  `yaml:"volume_delta"`
+	External         ExternalConfig     `yaml:"external"` // Внешние сигналы (TradingView)
 	SignalThresholds SignalThresholds   `yaml:"signal"`
+	Bias             BiasConfig         `yaml:"bias"` // Ограничения направления и силы сигналов после агрегации
 }
 
 // TechnicalConfig настройки технического анализа
@@ -53,9 +96,10 @@ type TechnicalConfig struct {
 
 // OrderBookConfig настройки анализа стакана
 type OrderBookConfig struct {
-	Weight             float64 `yaml:"weight"`
-	Depth              int     `yaml:"depth"`
-	ImbalanceThreshold float64 `yaml:"imbalance_threshold"`
+	Weight             float64  `yaml:"weight"`
+	Depth              int      `yaml:"depth"`
+	ImbalanceThreshold float64  `yaml:"imbalance_threshold"`
+	Window             Duration `yaml:"window"` // Окно снимков стакана для анализа (по умолчанию 5m, до 1h)
 }
 
 // FundingConfig настройки анализа ставок финансирования
@@ -79,6 +123,12 @@ type VolumeDeltaConfig struct {
 	SignificanceThreshold float64 `yaml:"significance_threshold"`
 }
 
+// ExternalConfig настройки компонента внешних сигналов, например из оповещений TradingView
+type ExternalConfig struct {
+	Weight float64  `yaml:"weight"`
+	TTL    Duration `yaml:"ttl"` // Сколько действует внешний сигнал; 0 - до следующего
+}
+
 // SignalThresholds пороговые значения для сигналов
 type SignalThresholds struct {
 	StrongBuy  float64 `yaml:"threshold_strong_buy"`
@@ -87,35 +137,598 @@ type SignalThresholds struct {
 	StrongSell float64 `yaml:"threshold_strong_sell"`
 }
 
+// Ограничения направления сигналов
+const (
+	BiasBoth      = "both"       // Покупка и продажа, по умолчанию
+	BiasLongOnly  = "long_only"  // Только покупка: счета без коротких позиций
+	BiasShortOnly = "short_only" // Только продажа
+)
+
+// BiasConfig ограничения сигналов после агрегации: для счетов, которые не могут
+// открывать короткие позиции, и на время ограничений биржи. Для отдельных символов
+// задаются в analysis группы.
+type BiasConfig struct {
+	Direction   string  `yaml:"direction"`    // both (по умолчанию), long_only или short_only
+	MaxStrength float64 `yaml:"max_strength"` // Наибольший модуль силы сигнала; 0 - без ограничения
+}
+
 // StorageConfig настройки хранения данных
 type StorageConfig struct {
-	Type         string `yaml:"type"`
+	Type         string `yaml:"type"` // influxdb (по умолчанию) или memory - данные в памяти процесса (bfma watch)
 	URL          string `yaml:"url"`
 	Token        string `yaml:"token"`
 	Organization string `yaml:"organization"`
 	Bucket       string `yaml:"bucket"`
 }
 
+// StateConfig настройки хранения состояния между перезапусками
+type StateConfig struct {
+	Dir string `yaml:"dir"` // Каталог для файлов состояния (по умолчанию текущий)
+}
+
+// AccountConfig настройки отслеживания счета
+type AccountConfig struct {
+	Enabled bool `yaml:"enabled"` // Показывать открытые позиции (нужен API-ключ с правом чтения)
+}
+
+// ExecutionConfig настройки ручного открытия сделок из UI
+type ExecutionConfig struct {
+	Enabled       bool    `yaml:"enabled"`         // Разрешить отправку заявок (нужен API-ключ с правом торговли)
+	QuoteAsset    string  `yaml:"quote_asset"`     // Актив маржи (по умолчанию USDT)
+	StopLossPct   float64 `yaml:"stop_loss_pct"`   // Расстояние до стоп-лосса в процентах от цены
+	TakeProfitPct float64 `yaml:"take_profit_pct"` // Расстояние до тейк-профита в процентах от цены
+	MaxNotional   float64 `yaml:"max_notional"`    // Максимальный объем позиции в активе маржи (0 - без ограничения)
+}
+
+// RiskConfig ограничения риска. Проверяются перед отправкой каждой заявки
+// независимо от сигнала; 0 отключает ограничение.
+type RiskConfig struct {
+	MaxPositionNotional   float64 `yaml:"max_position_notional"`    // Максимальный объем позиции по символу с учетом открытой, в активе маржи
+	MaxLeverage           int     `yaml:"max_leverage"`             // Максимальное плечо, установленное на бирже для символа
+	MaxDailyOrders        int     `yaml:"max_daily_orders"`         // Максимум заявок за день
+	KillSwitchDrawdownPct float64 `yaml:"kill_switch_drawdown_pct"` // Просадка капитала за день в процентах, после которой заявки блокируются до следующего дня
+}
+
+// Адреса серверов по умолчанию; слушается только локальный интерфейс
+const (
+	DefaultAdminListen       = "127.0.0.1:8090"
+	DefaultAPIListen         = "127.0.0.1:8091"
+	DefaultTradingViewListen = "127.0.0.1:8092"
+)
+
+// AdminConfig настройки HTTP API администрирования
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"` // Адрес (по умолчанию 127.0.0.1:8090)
+	Token   string `yaml:"token"`  // Токен Bearer; пустой - без авторизации и без маршрутов, меняющих сигналы
+	Pprof   bool   `yaml:"pprof"`  // Профилирование /debug/pprof/ (CPU, память, горутины, trace)
+}
+
+// ListenAddr возвращает адрес сервера администрирования с учетом значения по умолчанию
+func (c AdminConfig) ListenAddr() string {
+	return listenOr(c.Listen, DefaultAdminListen)
+}
+
+// APIConfig настройки HTTP API данных (только чтение) для внешних ботов и дашбордов
+type APIConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"` // Адрес (по умолчанию 127.0.0.1:8091)
+	Token   string `yaml:"token"`  // Токен Bearer; пустой - без авторизации
+	// Источники веб-страниц, которым разрешено подключение к /ws ("*" - любые);
+	// пустой список разрешает только страницы с адреса самого API
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	// Адрес gRPC API сигналов (api/proto/bfma/v1/signals.proto) с тем же токеном;
+	// пустой - gRPC не запускается
+	GRPCListen string `yaml:"grpc_listen"`
+}
+
+// ListenAddr возвращает адрес HTTP API данных с учетом значения по умолчанию
+func (c APIConfig) ListenAddr() string {
+	return listenOr(c.Listen, DefaultAPIListen)
+}
+
+// listenOr возвращает адрес listen или адрес по умолчанию, если он не задан
+func listenOr(listen, fallback string) string {
+	if listen == "" {
+		return fallback
+	}
+	return listen
+}
+
+// DesktopConfig уведомления рабочего стола о сильных сигналах при запуске на рабочей станции
+type DesktopConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Sound   bool `yaml:"sound"` // Звук уведомления
+}
+
+// EscalationConfig звонок или экстренный push, когда сила сигнала символа достигает
+// порога. Повторяется, пока оповещение не подтверждено или сигнал не ослаб.
+type EscalationConfig struct {
+	Enabled     bool           `yaml:"enabled"`
+	MinStrength float64        `yaml:"min_strength"` // Порог модуля силы сигнала (0..100)
+	Symbols     []string       `yaml:"symbols"`      // Только эти символы; пусто - все
+	Repeat      Duration       `yaml:"repeat"`       // Повтор звонков без подтверждения (по умолчанию 5m)
+	MaxRepeats  int            `yaml:"max_repeats"`  // Повторов без подтверждения (по умолчанию 3)
+	Pushover    PushoverConfig `yaml:"pushover"`
+	Twilio      TwilioConfig   `yaml:"twilio"`
+}
+
+// PushoverConfig экстренные уведомления Pushover (приоритет 2): приложение повторяет
+// их само, пока уведомление не подтверждено
+type PushoverConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Token   string   `yaml:"token"`  // Ключ приложения
+	User    string   `yaml:"user"`   // Ключ пользователя или группы
+	Sound   string   `yaml:"sound"`  // Звук; пустой - звук по умолчанию
+	Retry   Duration `yaml:"retry"`  // Повтор уведомления в приложении (не меньше 30s, по умолчанию 60s)
+	Expire  Duration `yaml:"expire"` // Сколько повторять (не больше 3h, по умолчанию 1h)
+}
+
+// TwilioConfig телефонные звонки через Twilio; ответ на звонок считается подтверждением
+type TwilioConfig struct {
+	Enabled    bool     `yaml:"enabled"`
+	AccountSID string   `yaml:"account_sid"`
+	AuthToken  string   `yaml:"auth_token"`
+	From       string   `yaml:"from"`     // Номер Twilio в формате +15551234567
+	To         []string `yaml:"to"`       // Номера для звонков
+	Language   string   `yaml:"language"` // Язык озвучивания (по умолчанию ru-RU)
+}
+
+// Периоды и форматы отчетов
+const (
+	ReportDaily    = "daily"
+	ReportWeekly   = "weekly"
+	ReportMarkdown = "markdown"
+	ReportHTML     = "html"
+)
+
+// ReportsConfig отчет за сутки или неделю: сигналы, точность, аномалии и экстремумы
+// ставок финансирования. Время задается в часовом поясе timezone.
+type ReportsConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Period      string   `yaml:"period"`       // daily или weekly
+	At          string   `yaml:"at"`           // Время формирования ЧЧ:ММ (по умолчанию 00:00)
+	Weekday     string   `yaml:"weekday"`      // День недельного отчета: mon..sun (по умолчанию mon)
+	Format      string   `yaml:"format"`       // markdown или html
+	Dir         string   `yaml:"dir"`          // Каталог отчетов; пустой - отчет не сохраняется
+	Notify      bool     `yaml:"notify"`       // Краткая сводка в каналы оповещений
+	Horizon     Duration `yaml:"horizon"`      // Через сколько проверяется, угадал ли сигнал направление (по умолчанию 4h)
+	AnomalyJump float64  `yaml:"anomaly_jump"` // Скачок силы сигнала между расчетами, считающийся аномалией (по умолчанию 40)
+}
+
+// Уровни серьезности инцидентов (как в PagerDuty)
+const (
+	IncidentCritical = "critical"
+	IncidentError    = "error"
+	IncidentWarning  = "warning"
+	IncidentInfo     = "info"
+)
+
+// IncidentsConfig передача эксплуатационных сбоев в системы дежурств отдельно от
+// торговых оповещений. Инцидент открывается, когда сбой длится дольше порога,
+// и закрывается, когда он устранен.
+type IncidentsConfig struct {
+	CheckInterval   Duration         `yaml:"check_interval"`   // Период проверки (по умолчанию 30s)
+	StorageDown     Duration         `yaml:"storage_down"`     // Хранилище недоступно или очередь записи переполнена (по умолчанию 1m)
+	WebSocketDown   Duration         `yaml:"websocket_down"`   // Поток WebSocket без соединения или данных (по умолчанию 5m)
+	AnalysisOverrun Duration         `yaml:"analysis_overrun"` // Цикл анализа дольше периода или завис (по умолчанию 5m)
+	Severity        IncidentSeverity `yaml:"severity"`
+	PagerDuty       PagerDutyConfig  `yaml:"pagerduty"`
+	Opsgenie        OpsgenieConfig   `yaml:"opsgenie"`
+}
+
+// IncidentSeverity уровни сбоев: critical, error, warning или info
+type IncidentSeverity struct {
+	Storage   string `yaml:"storage"`   // По умолчанию critical
+	WebSocket string `yaml:"websocket"` // По умолчанию error
+	Analysis  string `yaml:"analysis"`  // По умолчанию warning
+}
+
+// PagerDutyConfig отправка инцидентов через PagerDuty Events API v2
+type PagerDutyConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	RoutingKey string `yaml:"routing_key"` // Ключ интеграции Events API v2 сервиса
+	URL        string `yaml:"url"`         // Пустой - https://events.pagerduty.com/v2/enqueue
+}
+
+// OpsgenieConfig отправка инцидентов через Opsgenie Alert API
+type OpsgenieConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	APIKey  string `yaml:"api_key"` // Ключ интеграции API
+	URL     string `yaml:"url"`     // Пустой - https://api.opsgenie.com; для EU https://api.eu.opsgenie.com
+}
+
+// WatchdogConfig бюджет ошибок подсистем. Когда ошибок подсистемы за окно больше
+// бюджета, она переводится в режим деградации, а bfma пробует ее восстановить.
+type WatchdogConfig struct {
+	Enabled       bool         `yaml:"enabled"`
+	CheckInterval Duration     `yaml:"check_interval"` // Период проверки (по умолчанию 15s)
+	Window        Duration     `yaml:"window"`         // Окно подсчета ошибок (по умолчанию 5m)
+	Budgets       ErrorBudgets `yaml:"budgets"`
+	Remediate     bool         `yaml:"remediate"` // Переподключать потоки и читать из кэша при деградации
+	Cooldown      Duration     `yaml:"cooldown"`  // Пауза между попытками восстановления подсистемы (по умолчанию 2m)
+}
+
+// ScannerConfig обзор всех фьючерсов биржи, а не только отслеживаемых символов: общие
+// запросы суточной статистики и ставок финансирования, открытый интерес - только самых
+// ликвидных символов. Символы с аномалиями предлагаются в списки наблюдения.
+type ScannerConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	Interval       Duration `yaml:"interval"`         // Период обзора (по умолчанию 5m, не меньше 1m)
+	Lookback       Duration `yaml:"lookback"`         // С обзором какой давности сравниваются оборот и открытый интерес (по умолчанию 1h)
+	MinQuoteVolume float64  `yaml:"min_quote_volume"` // Суточный оборот в валюте котировки не меньше (по умолчанию 10 000 000)
+	VolumeGrowth   float64  `yaml:"volume_growth"`    // Рост суточного оборота за lookback, % (по умолчанию 25)
+	OIChange       float64  `yaml:"oi_change"`        // Модуль изменения открытого интереса за lookback, % (по умолчанию 10)
+	FundingBps     float64  `yaml:"funding_bps"`      // Модуль ставки финансирования, б.п. (по умолчанию 10)
+	OISymbols      int      `yaml:"oi_symbols"`       // Открытый интерес запрашивается у стольких самых ликвидных символов (по умолчанию 50)
+	MaxCandidates  int      `yaml:"max_candidates"`   // Сколько кандидатов хранить, самые необычные первыми (по умолчанию 20)
+}
+
+// ErrorBudgets допустимое число ошибок подсистемы за окно; 0 - значение по умолчанию
+type ErrorBudgets struct {
+	Storage   int `yaml:"storage"`   // Ошибки записи и чтения хранилища (по умолчанию 10)
+	Analysis  int `yaml:"analysis"`  // Ошибки расчета сигнала символа (по умолчанию 20)
+	WebSocket int `yaml:"websocket"` // Обрывы потоков WebSocket (по умолчанию 3)
+	Exchange  int `yaml:"exchange"`  // Ошибки запросов REST к бирже (по умолчанию 10)
+}
+
+// CrashConfig отчеты о падениях: стеки горутин, последние события журнала, отпечаток
+// конфигурации и версии пишутся в файл и, если настроено, отправляются в Sentry
+type CrashConfig struct {
+	Dir    string       `yaml:"dir"`    // Каталог отчетов; пустой - crash в state.dir
+	Events int          `yaml:"events"` // Сколько последних событий журнала включать (по умолчанию 100)
+	Keep   int          `yaml:"keep"`   // Сколько последних отчетов хранить (по умолчанию 20)
+	Sentry SentryConfig `yaml:"sentry"`
+}
+
+// SentryConfig отправка отчетов о падениях в Sentry
+type SentryConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	DSN         string `yaml:"dsn"`         // https://<ключ>@<хост>/<проект>
+	Environment string `yaml:"environment"` // Окружение в Sentry (production, staging)
+}
+
+// LatencyConfig учет задержки от события биржи до сигнала: по последним замерам этапа
+// считаются p50/p95/p99, p95 сравнивается с бюджетом этапа
+type LatencyConfig struct {
+	Samples int            `yaml:"samples"` // Сколько последних замеров этапа учитывать (по умолчанию 1000)
+	Budgets LatencyBudgets `yaml:"budgets"`
+}
+
+// LatencyBudgets бюджеты p95 задержки этапов; 0 - без бюджета
+type LatencyBudgets struct {
+	Stored   Duration `yaml:"stored"`   // Событие биржи → запись в хранилище
+	Analyzed Duration `yaml:"analyzed"` // Запись в хранилище → расчет сигнала
+	Emitted  Duration `yaml:"emitted"`  // Расчет сигнала → отправка потребителям
+	Total    Duration `yaml:"total"`    // Событие биржи → отправка сигнала
+}
+
+// UsageConfig учет объема работы по символам: сообщения биржи, записанные байты,
+// вес запросов к API и запросы к хранилищу
+type UsageConfig struct {
+	Retention Duration `yaml:"retention"` // Сколько хранить почасовые счетчики (по умолчанию 48h)
+}
+
+// TradingViewConfig прием оповещений TradingView через вебхук. TradingView не передает
+// заголовки авторизации, поэтому запрос проверяется по фразе в теле или в адресе.
+type TradingViewConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Listen     string `yaml:"listen"`     // Адрес (по умолчанию 127.0.0.1:8092)
+	Passphrase string `yaml:"passphrase"` // Поле passphrase в JSON или параметр ?passphrase=
+}
+
+// ListenAddr возвращает адрес приема оповещений с учетом значения по умолчанию
+func (c TradingViewConfig) ListenAddr() string {
+	return listenOr(c.Listen, DefaultTradingViewListen)
+}
+
+// DiscordConfig оповещения в канал Discord через вебхук канала
+type DiscordConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	WebhookURL string `yaml:"webhook_url"` // Адрес вебхука: настройки канала -> Интеграции -> Вебхуки
+	Charts     bool   `yaml:"charts"`      // Прикладывать к оповещениям график
+}
+
+// TelegramConfig настройки бота Telegram: оповещения и команды /signal, /mute
+type TelegramConfig struct {
+	Enabled bool    `yaml:"enabled"`
+	Token   string  `yaml:"token"`             // Токен бота от @BotFather
+	ChatIDs []int64 `yaml:"chat_ids"`          // Чаты для оповещений; команды принимаются только из них
+	Charts  bool    `yaml:"charts"`            // Прикладывать к оповещениям график: свечи, сигналы и уровни
+	APIURL  string  `yaml:"api_url,omitempty"` // Адрес Bot API (по умолчанию https://api.telegram.org)
+}
+
+// EmailConfig настройки оповещений по почте
+type EmailConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Host     string   `yaml:"host"`     // Сервер SMTP
+	Port     int      `yaml:"port"`     // 587 (по умолчанию, STARTTLS) или 465 (TLS)
+	Username string   `yaml:"username"` // Пустой - без авторизации
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`   // Отправитель: bot@example.com или "bfma <bot@example.com>"
+	To       []string `yaml:"to"`     // Получатели
+	Digest   Duration `yaml:"digest"` // Период сводки; 0 - каждое оповещение отдельным письмом
+}
+
+// MQTTConfig настройки публикации в брокер MQTT
+type MQTTConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Broker      string `yaml:"broker"`    // tcp://host:1883 или tls://host:8883
+	ClientID    string `yaml:"client_id"` // Пустой - bfma-<имя хоста>
+	Username    string `yaml:"username"`  // Пустой - без авторизации
+	Password    string `yaml:"password"`
+	TopicPrefix string `yaml:"topic_prefix"` // Корень тем (по умолчанию bfma)
+	QoS         int    `yaml:"qos"`          // 0 или 1
+	Retain      bool   `yaml:"retain"`       // Сигналы сохраняются брокером: подписчик сразу получает последний
+}
+
+// Брокеры потока сигналов
+const (
+	StreamKafka = "kafka"
+	StreamNATS  = "nats"
+)
+
+// Форматы сообщений потока
+const (
+	StreamFormatJSON     = "json"
+	StreamFormatProtobuf = "protobuf"
+)
+
+// StreamConfig настройки потока сигналов и оповещений в брокер сообщений
+type StreamConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	Type      string   `yaml:"type"`      // kafka или nats
+	Brokers   []string `yaml:"brokers"`   // Адреса host:port; используется первый доступный
+	Topic     string   `yaml:"topic"`     // Корень тем (по умолчанию bfma)
+	Format    string   `yaml:"format"`    // json (по умолчанию) или protobuf
+	JetStream bool     `yaml:"jetstream"` // NATS: ждать подтверждения потока JetStream
+	Username  string   `yaml:"username"`  // Kafka: SASL PLAIN; NATS: user
+	Password  string   `yaml:"password"`
+	TLS       bool     `yaml:"tls"`
+}
+
+// NotifyConfig маршрутизация оповещений по внешним каналам (push, telegram, webhook,
+// email, mqtt, stream, desktop, discord) и ограничение их частоты
+type NotifyConfig struct {
+	MinInterval Duration      `yaml:"min_interval"`     // Не чаще одного оповещения символа в канал за период; важные не ограничиваются
+	DedupWindow Duration      `yaml:"dedup_window"`     // Повтор предыдущего оповещения символа в течение периода не подается
+	Routes      []NotifyRoute `yaml:"routes,omitempty"` // Правила выбора каналов; пусто - все каналы
+}
+
+// NotifyRoute правило: оповещения подходящих символов подаются в каналы правила.
+// Оповещение, не подошедшее ни к одному правилу, во внешние каналы не подается.
+type NotifyRoute struct {
+	Name         string   `yaml:"name"`
+	Symbols      []string `yaml:"symbols,omitempty"`       // Пусто - все символы
+	CriticalOnly bool     `yaml:"critical_only,omitempty"` // Только важные оповещения (сильные сигналы)
+	Channels     []string `yaml:"channels"`
+}
+
+// Matches сообщает, подходит ли оповещение к правилу
+func (r NotifyRoute) Matches(symbol string, critical bool) bool {
+	if r.CriticalOnly && !critical {
+		return false
+	}
+	return len(r.Symbols) == 0 || slices.Contains(r.Symbols, symbol)
+}
+
+// События, отправляемые вебхукам
+const (
+	WebhookSignal = "signal" // Каждый новый сигнал
+	WebhookAlert  = "alert"  // Оповещение из панели оповещений
+)
+
+// WebhookConfig адрес, на который POST-запросом отправляются сигналы и оповещения
+type WebhookConfig struct {
+	Name       string            `yaml:"name"`
+	URL        string            `yaml:"url"`
+	Events     []string          `yaml:"events,omitempty"`      // signal, alert; пусто - все события
+	Symbols    []string          `yaml:"symbols,omitempty"`     // Только эти символы; пусто - все
+	Secret     string            `yaml:"secret,omitempty"`      // Ключ подписи HMAC-SHA256 (заголовок X-BFMA-Signature)
+	Template   string            `yaml:"template,omitempty"`    // Шаблон тела text/template; пусто - событие в JSON
+	Headers    map[string]string `yaml:"headers,omitempty"`     // Дополнительные заголовки запроса
+	MaxRetries int               `yaml:"max_retries,omitempty"` // Повторов после неудачной доставки (по умолчанию 5)
+	Timeout    Duration          `yaml:"timeout,omitempty"`     // Время на один запрос (по умолчанию 10s)
+}
+
+// Форматы мостов к сторонним торговым ботам
+const (
+	BridgeThreeCommas = "3commas"   // Вебхук сигнального бота 3Commas
+	BridgeFreqtrade   = "freqtrade" // REST API Freqtrade (forceenter/forceexit)
+)
+
+// BridgeConfig мост, переводящий сигналы в команды стороннего торгового бота.
+// Команды отправляются при смене рекомендации: покупка открывает длинную позицию,
+// продажа закрывает ее и, если разрешено, открывает короткую.
+type BridgeConfig struct {
+	Name          string            `yaml:"name"`
+	Type          string            `yaml:"type"`                      // 3commas или freqtrade
+	URL           string            `yaml:"url,omitempty"`             // Адрес вебхука 3Commas (по умолчанию общий) или api_server Freqtrade
+	Symbols       []string          `yaml:"symbols,omitempty"`         // Только эти символы; пусто - все
+	Pairs         map[string]string `yaml:"pairs,omitempty"`           // Символ -> инструмент бота (BTCUSDT.P для 3Commas, BTC/USDT:USDT для Freqtrade)
+	StrongOnly    bool              `yaml:"strong_only,omitempty"`     // Входить только по STRONG_BUY/STRONG_SELL
+	Short         bool              `yaml:"short,omitempty"`           // Открывать короткие позиции по сигналам продажи
+	ExitOnNeutral bool              `yaml:"exit_on_neutral,omitempty"` // Закрывать позицию при NEUTRAL
+	BotUUID       string            `yaml:"bot_uuid,omitempty"`        // Идентификатор сигнального бота 3Commas
+	Secret        string            `yaml:"secret,omitempty"`          // Секрет вебхука 3Commas
+	Username      string            `yaml:"username,omitempty"`        // Пользователь api_server Freqtrade
+	Password      string            `yaml:"password,omitempty"`        // Пароль api_server Freqtrade
+}
+
+// ShutdownConfig настройки завершения работы
+type ShutdownConfig struct {
+	Timeout Duration `yaml:"timeout"` // Сколько ждать остановки сборщиков и записи буферов (по умолчанию 10s)
+}
+
+// OutputConfig настройки сигналов, передаваемых внешним программам
+type OutputConfig struct {
+	SchemaVersion int `yaml:"schema_version"` // Версия схемы JSON сигналов (0 - последняя)
+}
+
+// UpdatesConfig настройки проверки обновлений
+type UpdatesConfig struct {
+	Check bool `yaml:"check"` // Проверять релизы на GitHub при запуске
+}
+
 // UIConfig настройки пользовательского интерфейса
 type UIConfig struct {
-	RefreshRate int  `yaml:"refresh_rate_ms"`
-	ShowCharts  bool `yaml:"show_charts"`
+	RefreshRate Duration            `yaml:"refresh_rate"` // период перерисовки экрана ("500ms")
+	ShowCharts  bool                `yaml:"show_charts"`
+	Locale      string              `yaml:"locale"`      // ru (по умолчанию) или en
+	SplitRatio  float64             `yaml:"split_ratio"` // доля высоты под панель сигналов (0..1)
+	AlertBell   bool                `yaml:"alert_bell"`  // звуковой сигнал терминала при важных оповещениях
+	Watchlists  []WatchlistConfig   `yaml:"watchlists"`
+	ExportDir   string              `yaml:"export_dir"`       // каталог для CSV-экспорта сигналов (по умолчанию текущий)
+	Keymap      map[string][]string `yaml:"keymap,omitempty"` // переназначение клавиш: действие -> клавиши
+	Plain       bool                `yaml:"plain"`            // текстовый вывод без рамок и цвета для программ экранного доступа
+	Headless    bool                `yaml:"headless"`         // без интерфейса: сигналы и оповещения строками JSON в stdout
+}
+
+// WatchlistConfig именованный список символов, переключаемый в UI
+type WatchlistConfig struct {
+	Name      string   `yaml:"name"`
+	Symbols   []string `yaml:"symbols"`
+	Positions bool     `yaml:"positions,omitempty"` // Показывать символы открытых позиций вместо списка
+	Sort      string   `yaml:"sort,omitempty"`      // symbol (по умолчанию), strength_desc или strength_asc
+}
+
+// TrackedSymbols возвращает символы из trading.symbols, групп и всех списков наблюдения без повторов
+func (c *Config) TrackedSymbols() []string {
+	seen := make(map[string]bool)
+	var symbols []string
+
+	add := func(list []string) {
+		for _, symbol := range list {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	add(c.Trading.Symbols)
+	for _, group := range c.Groups {
+		add(group.Symbols)
+	}
+	for _, wl := range c.UI.Watchlists {
+		add(wl.Symbols)
+	}
+	return symbols
+}
+
+//go:embed default.yaml
+var defaultYAML []byte
+
+// DefaultYAML возвращает шаблон config.yaml с комментариями и значениями по умолчанию
+func DefaultYAML() []byte {
+	return defaultYAML
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию из шаблона
+func Default() *Config {
+	var config Config
+	if err := yaml.Unmarshal(defaultYAML, &config); err != nil {
+		panic(fmt.Sprintf("ошибка разбора шаблона конфигурации: %v", err))
+	}
+	return &config
+}
+
+// Hash возвращает отпечаток конфигурации (SHA-256 ее YAML). По нему в отчетах о сбоях
+// видно, с одной ли конфигурацией случились падения, без раскрытия самих значений.
+func Hash(cfg *Config) string {
+	return fingerprint(cfg)
+}
+
+// AnalysisHash возвращает отпечаток настроек анализа. Сигнал хранит отпечаток настроек,
+// с которыми рассчитан, поэтому в истории видно, какие сигналы сравнимы между собой.
+func AnalysisHash(cfg AnalysisConfig) string {
+	hash := fingerprint(cfg)
+	if len(hash) > 16 {
+		hash = hash[:16]
+	}
+	return hash
 }
 
-// Load загружает конфигурацию из файла
-func Load(path string) (*Config, error) {
-	data, err := ioutil.ReadFile(path)
+// fingerprint возвращает SHA-256 YAML значения
+func fingerprint(v interface{}) string {
+	data, err := yaml.Marshal(v)
 	if err != nil {
-		logger.Fatal("Ошибка чтения файла конфигурации", zap.Error(err))
+		return ""
 	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Save записывает полную конфигурацию в файл
+func Save(path string, cfg *Config) error {
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+	}
+
+	// Файл содержит ключи API, поэтому доступен только владельцу
+	if err := ioutil.WriteFile(path, out, 0600); err != nil {
+		return fmt.Errorf("ошибка записи файла конфигурации: %w", err)
+	}
+
+	logger.Info("Сохранена конфигурация", zap.String("path", path))
+	return nil
+}
+
+// LoadOptions параметры загрузки конфигурации
+type LoadOptions struct {
+	Overlays []string // Файлы окружения, накладываемые на основной по порядку (config.prod.yaml)
+	Profile  string   // Профиль, накладываемый на базовую конфигурацию (пусто - без профиля)
+	Sets     []string // Переопределения из флагов --set
+}
+
+// Load загружает конфигурацию из файла или каталога, обновляет устаревший формат,
+// накладывает файлы окружения и выбранный профиль и применяет переопределения из переменных окружения и флагов --set.
+// Если файла нет, за основу берутся значения по умолчанию. Вместо файла можно указать
+// удаленный источник (https://, consul://, etcd://), он читается через локальный кэш.
+func Load(path string, opts LoadOptions) (*Config, error) {
+	path, err := localCopy(path)
+	if err != nil {
+		return nil, err
+	}
+	overlays := make([]string, len(opts.Overlays))
+	for i, overlay := range opts.Overlays {
+		if overlays[i], err = localCopy(overlay); err != nil {
+			return nil, err
+		}
+	}
+	opts.Overlays = overlays
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		logger.Fatal("Ошибка разбора файла конфигурации", zap.Error(err))
+	_, err = os.Stat(path)
+	switch {
+	case os.IsNotExist(err) && opts.Profile == "" && len(opts.Overlays) == 0:
+		logger.Info("Файл конфигурации не найден, используются значения по умолчанию", zap.String("path", path))
+		config = *Default()
+	case err != nil:
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	default:
+		data, err := readProfile(path, opts.Overlays, opts.Profile)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("ошибка разбора файла конфигурации: %w", err)
+		}
+	}
+
+	if err := ApplyOverrides(&config, opts.Sets); err != nil {
+		return nil, err
+	}
+	if err := ResolveSecrets(&config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	logger.Debug("Загружена конфигурация", zap.String("path", path), zap.Any("config", config))
+	logger.Debug("Загружена конфигурация", zap.String("path", path), zap.Any("config", config.Redacted()))
 
-	logger.Info("Загружена конфигурация", zap.Any("Symbols", config.Trading.Symbols))
+	logger.Info("Загружена конфигурация", zap.String("profile", opts.Profile), zap.Any("Symbols", config.Trading.Symbols))
 	return &config, nil
 }
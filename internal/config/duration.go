@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration длительность в конфигурации: строка вида "30s", "250ms" или "1m30s".
+// Число без единицы допускается только для 0; старые числовые параметры
+// (interval_seconds, refresh_rate_ms) переводятся миграцией схемы.
+type Duration time.Duration
+
+// UnmarshalYAML разбирает строку длительности
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value interface{}
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	parsed, err := parseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalYAML записывает длительность строкой
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON записывает длительность строкой, как в файле конфигурации
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Std возвращает значение как time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String возвращает длительность в формате time.Duration ("30s", "250ms")
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// parseDuration разбирает значение YAML в длительность
+func parseDuration(value interface{}) (Duration, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("неверная длительность %q, ожидается например 30s, 250ms или 1m", v)
+		}
+		return Duration(parsed), nil
+	case int:
+		if v == 0 {
+			return 0, nil
+		}
+	case float64:
+		if v == 0 {
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("длительность %v указана без единицы, ожидается например %vs или %vms", value, value, value)
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Параметры шифрования секретов в файле конфигурации
+const (
+	encryptedPrefix  = "enc:v1:" // Префикс зашифрованного значения
+	encryptSaltSize  = 16
+	encryptKeySize   = 32     // AES-256
+	encryptIteration = 200000 // Итерации PBKDF2-HMAC-SHA256
+)
+
+// EncryptionKey возвращает ключ шифрования конфигурации: содержимое файла из
+// BFMA_CONFIG_KEY_FILE или пароль из BFMA_CONFIG_PASSPHRASE
+func EncryptionKey() ([]byte, error) {
+	if path := os.Getenv("BFMA_CONFIG_KEY_FILE"); path != "" {
+		return ReadKeyFile(path)
+	}
+	if passphrase := os.Getenv("BFMA_CONFIG_PASSPHRASE"); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	return nil, fmt.Errorf("не задан ключ шифрования конфигурации: BFMA_CONFIG_KEY_FILE или BFMA_CONFIG_PASSPHRASE")
+}
+
+// ReadKeyFile читает ключ шифрования из файла; перевод строки в конце не учитывается
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла ключа: %w", err)
+	}
+	key := []byte(strings.TrimRight(string(data), "\r\n"))
+	if len(key) == 0 {
+		return nil, fmt.Errorf("файл ключа %s пуст", path)
+	}
+	return key, nil
+}
+
+// IsEncrypted сообщает, что значение зашифровано командой bfma config encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EncryptValue шифрует значение AES-256-GCM ключом, полученным из key через PBKDF2
+// со случайной солью. Результат: enc:v1:<base64(соль, nonce, шифротекст)>.
+func EncryptValue(key []byte, value string) (string, error) {
+	salt := make([]byte, encryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("ошибка генерации соли: %w", err)
+	}
+	aead, err := newCipher(key, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("ошибка генерации nonce: %w", err)
+	}
+
+	sealed := aead.Seal(append(salt, nonce...), nonce, []byte(value), nil)
+	return encryptedPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptValue расшифровывает значение, полученное EncryptValue
+func DecryptValue(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("значение не зашифровано: ожидается префикс %s", encryptedPrefix)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("ошибка разбора зашифрованного значения: %w", err)
+	}
+	if len(data) < encryptSaltSize {
+		return "", fmt.Errorf("зашифрованное значение повреждено")
+	}
+
+	aead, err := newCipher(key, data[:encryptSaltSize])
+	if err != nil {
+		return "", err
+	}
+	data = data[encryptSaltSize:]
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("зашифрованное значение повреждено")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("неверный ключ или значение повреждено")
+	}
+	return string(plain), nil
+}
+
+// newCipher создает AES-GCM с ключом, полученным из пароля и соли
+func newCipher(key, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2(key, salt, encryptIteration, encryptKeySize))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации шифра: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 вычисляет ключ PBKDF2-HMAC-SHA256 (RFC 8018)
+func pbkdf2(password, salt []byte, iterations, size int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < size; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:size]
+}
+
+// resolveEncrypted расшифровывает значение enc:v1:... ключом из окружения
+func resolveEncrypted(ctx context.Context, ref *url.URL) (string, error) {
+	key, err := EncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	return DecryptValue(key, ref.String())
+}
+
+// EncryptFile шифрует секреты (ключи API и токены) в файле конфигурации, включая
+// профили из секции profiles. Уже зашифрованные значения и ссылки на хранилища
+// секретов (vault://, aws-sm://, keyring://) не меняются. Возвращает число
+// зашифрованных параметров.
+func EncryptFile(path string, key []byte) (int, error) {
+	return transformSecrets(path, func(value string) (string, bool, error) {
+		if value == "" || IsEncrypted(value) {
+			return value, false, nil
+		}
+		if ref, err := url.Parse(value); err == nil && secretResolvers[ref.Scheme] != nil {
+			return value, false, nil
+		}
+		encrypted, err := EncryptValue(key, value)
+		return encrypted, err == nil, err
+	})
+}
+
+// DecryptFile расшифровывает секреты в файле конфигурации. Возвращает число
+// расшифрованных параметров.
+func DecryptFile(path string, key []byte) (int, error) {
+	return transformSecrets(path, func(value string) (string, bool, error) {
+		if !IsEncrypted(value) {
+			return value, false, nil
+		}
+		plain, err := DecryptValue(key, value)
+		return plain, err == nil, err
+	})
+}
+
+// transformSecrets применяет transform к параметрам из secretParams и перезаписывает файл,
+// если хотя бы одно значение изменилось
+func transformSecrets(path string, transform func(value string) (string, bool, error)) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("ошибка разбора файла конфигурации: %w", err)
+	}
+
+	docs := map[string]yaml.MapSlice{"": doc}
+	if profiles, ok := lookupSlice(doc, profilesKey); ok {
+		for _, item := range profiles {
+			if profile, ok := item.Value.(yaml.MapSlice); ok {
+				docs[fmt.Sprintf("%s.%v.", profilesKey, item.Key)] = profile
+			}
+		}
+	}
+
+	changed := 0
+	for prefix, d := range docs {
+		for _, param := range secretParams {
+			section, name, _ := strings.Cut(param, ".")
+			fields, ok := lookupSlice(d, section)
+			if !ok {
+				continue
+			}
+			for i := range fields {
+				value, ok := fields[i].Value.(string)
+				if !ok || fields[i].Key != name {
+					continue
+				}
+				next, done, err := transform(value)
+				if err != nil {
+					return 0, fmt.Errorf("%s%s: %w", prefix, param, err)
+				}
+				if done {
+					fields[i].Value = next
+					changed++
+				}
+			}
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+	}
+	// Расшифрованный файл содержит ключи API, поэтому доступен только владельцу
+	if err := ioutil.WriteFile(path, out, 0600); err != nil {
+		return 0, fmt.Errorf("ошибка записи файла конфигурации: %w", err)
+	}
+	return changed, nil
+}
+
+// lookupSlice возвращает вложенную секцию документа по ключу
+func lookupSlice(doc yaml.MapSlice, key string) (yaml.MapSlice, bool) {
+	for _, item := range doc {
+		if item.Key == key {
+			section, ok := item.Value.(yaml.MapSlice)
+			return section, ok
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"sort"
+)
+
+// Экспериментальные подсистемы, которые включаются только явно в секции features
+const (
+	FeatureExecution = "execution" // Отправка заявок из UI (дополнительно нужен execution.enabled)
+)
+
+// knownFeatures описания известных флагов для журнала и сообщений об ошибках
+var knownFeatures = map[string]string{
+	FeatureExecution: "отправка заявок из интерфейса",
+}
+
+// Features флаги экспериментальных подсистем: имя -> включена
+type Features map[string]bool
+
+// Enabled сообщает, включена ли подсистема
+func (f Features) Enabled(name string) bool {
+	return f[name]
+}
+
+// Split возвращает имена известных подсистем: включенные и выключенные
+func (f Features) Split() (enabled, disabled []string) {
+	for name := range knownFeatures {
+		if f.Enabled(name) {
+			enabled = append(enabled, name)
+		} else {
+			disabled = append(disabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	sort.Strings(disabled)
+	return enabled, disabled
+}
+
+// Describe возвращает описание подсистемы для журнала
+func Describe(name string) string {
+	return knownFeatures[name]
+}
+
+// KnownFeatures возвращает имена известных флагов в алфавитном порядке
+func KnownFeatures() []string {
+	names := make([]string, 0, len(knownFeatures))
+	for name := range knownFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/skalibog/bfma/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+// SymbolGroupConfig группа символов с общими настройками, заданными поверх основных
+type SymbolGroupConfig struct {
+	Name     string           `yaml:"name"`
+	Symbols  []string         `yaml:"symbols"`
+	Interval models.Interval  `yaml:"interval,omitempty"` // Интервал свечей сборщика (по умолчанию trading.interval)
+	Analysis AnalysisOverride `yaml:"analysis,omitempty"` // Параметры анализа, отличающиеся от секции analysis
+}
+
+// AnalysisOverride частичная секция analysis: заданные в ней параметры заменяют
+// основные, остальные берутся из секции analysis
+type AnalysisOverride struct {
+	data []byte // Исходный YAML переопределений
+}
+
+// UnmarshalYAML реализует yaml.Unmarshaler и сразу проверяет типы значений
+func (o *AnalysisOverride) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	doc := make(document)
+	if err := unmarshal(&doc); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var check AnalysisConfig
+	if err := yaml.Unmarshal(data, &check); err != nil {
+		return fmt.Errorf("ошибка в переопределениях анализа: %w", err)
+	}
+
+	o.data = data
+	return nil
+}
+
+// MarshalYAML реализует yaml.Marshaler
+func (o AnalysisOverride) MarshalYAML() (interface{}, error) {
+	doc := make(document)
+	if err := yaml.Unmarshal(o.data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// IsZero сообщает, что переопределений нет (для omitempty)
+func (o AnalysisOverride) IsZero() bool {
+	return len(o.data) == 0
+}
+
+// Apply возвращает настройки анализа base с примененными переопределениями
+func (o AnalysisOverride) Apply(base AnalysisConfig) (AnalysisConfig, error) {
+	if len(o.data) == 0 {
+		return base, nil
+	}
+	if err := yaml.Unmarshal(o.data, &base); err != nil {
+		return base, fmt.Errorf("ошибка применения переопределений анализа: %w", err)
+	}
+	return base, nil
+}
+
+// Group возвращает группу, в которую входит символ, или nil
+func (c *Config) Group(symbol string) *SymbolGroupConfig {
+	for i := range c.Groups {
+		for _, s := range c.Groups[i].Symbols {
+			if s == symbol {
+				return &c.Groups[i]
+			}
+		}
+	}
+	return nil
+}
+
+// IntervalFor возвращает интервал свечей для сборщика символа с учетом его группы
+func (c *Config) IntervalFor(symbol string) models.Interval {
+	if group := c.Group(symbol); group != nil && group.Interval != "" {
+		return group.Interval
+	}
+	return c.Trading.Interval
+}
+
+// AnalysisFor возвращает настройки анализа символа с учетом его группы
+func (c *Config) AnalysisFor(symbol string) AnalysisConfig {
+	group := c.Group(symbol)
+	if group == nil {
+		return c.Analysis
+	}
+
+	// Переопределения проверены при загрузке, поэтому ошибки здесь не ожидаются
+	cfg, err := group.Analysis.Apply(c.Analysis)
+	if err != nil {
+		return c.Analysis
+	}
+	return cfg
+}
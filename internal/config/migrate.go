@@ -0,0 +1,297 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// CurrentVersion версия схемы конфигурации, которую понимает эта сборка.
+// Файлы без поля version считаются версией 0.
+const CurrentVersion = 3
+
+// Ключ версии схемы в документе
+const versionKey = "version"
+
+// migration переводит документ из версии from в версию from+1.
+// Возвращает описания выполненных изменений для предупреждений.
+type migration struct {
+	from  int
+	apply func(doc document) []string
+}
+
+// migrations по порядку версий
+var migrations = []migration{
+	{from: 0, apply: migrateV0},
+	{from: 1, apply: migrateV1},
+	{from: 2, apply: migrateV2},
+}
+
+// documentVersion возвращает версию схемы документа
+func documentVersion(doc document) (int, error) {
+	value, ok := doc[versionKey]
+	if !ok {
+		return 0, nil
+	}
+	version, ok := value.(int)
+	if !ok || version < 0 {
+		return 0, fmt.Errorf("%s: ожидается целое неотрицательное число, задано %v", versionKey, value)
+	}
+	return version, nil
+}
+
+// migrateDocument приводит документ к текущей версии схемы. Если изменения были,
+// пишет предупреждение, чтобы пользователь обновил файл. Профили из секции profiles
+// мигрируются вместе с документом.
+func migrateDocument(path string, doc document) error {
+	version, err := documentVersion(doc)
+	if err != nil {
+		return fmt.Errorf("ошибка в файле конфигурации %s: %w", path, err)
+	}
+	if version > CurrentVersion {
+		return fmt.Errorf("файл конфигурации %s создан более новой версией bfma (версия схемы %d, поддерживается до %d)",
+			path, version, CurrentVersion)
+	}
+
+	var changes []string
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		changes = append(changes, m.apply(doc)...)
+		if profiles, ok := doc[profilesKey].(document); ok {
+			for name, profile := range profiles {
+				if p, ok := profile.(document); ok {
+					for _, change := range m.apply(p) {
+						changes = append(changes, fmt.Sprintf("%s.%v: %s", profilesKey, name, change))
+					}
+				}
+			}
+		}
+	}
+	doc[versionKey] = CurrentVersion
+
+	for _, change := range changes {
+		logger.Warn("Устаревший формат конфигурации, обновите файл",
+			zap.String("path", path), zap.String("change", change))
+	}
+	return nil
+}
+
+// MigrateFile обновляет файл конфигурации до текущей версии схемы и перезаписывает его.
+// Порядок ключей сохраняется, новые ключи добавляются в конец секций; комментарии
+// не сохраняются. Возвращает исходную версию файла; файл текущей версии не меняется.
+func MigrateFile(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	var original yaml.MapSlice
+	doc := make(document)
+	if err := yaml.Unmarshal(data, &original); err != nil {
+		return 0, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+
+	version, err := documentVersion(doc)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка в файле конфигурации %s: %w", path, err)
+	}
+	if version == CurrentVersion {
+		return version, nil
+	}
+	if err := migrateDocument(path, doc); err != nil {
+		return 0, err
+	}
+
+	out, err := yaml.Marshal(orderLike(doc, original))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+	}
+	// Файл содержит ключи API, поэтому доступен только владельцу
+	if err := ioutil.WriteFile(path, out, 0600); err != nil {
+		return 0, fmt.Errorf("ошибка записи файла конфигурации: %w", err)
+	}
+	return version, nil
+}
+
+// orderLike возвращает документ с порядком ключей как в original; ключи,
+// которых не было в original, добавляются в конец
+func orderLike(doc document, original yaml.MapSlice) yaml.MapSlice {
+	var ordered yaml.MapSlice
+	seen := make(map[interface{}]bool)
+	add := func(key, value interface{}, before interface{}) {
+		value = orderValue(value, before)
+		ordered = append(ordered, yaml.MapItem{Key: key, Value: value})
+		seen[key] = true
+	}
+
+	// Версия схемы всегда первой
+	if version, ok := doc[versionKey]; ok {
+		add(versionKey, version, nil)
+	}
+	for _, item := range original {
+		if value, ok := doc[item.Key]; ok && !seen[item.Key] {
+			add(item.Key, value, item.Value)
+		}
+	}
+
+	var rest []string
+	for key := range doc {
+		if !seen[key] {
+			rest = append(rest, fmt.Sprint(key))
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		add(key, doc[key], nil)
+	}
+	return ordered
+}
+
+// orderValue упорядочивает вложенные секции и элементы списков по original
+func orderValue(value, original interface{}) interface{} {
+	switch v := value.(type) {
+	case document:
+		prev, _ := original.(yaml.MapSlice)
+		return orderLike(v, prev)
+	case []interface{}:
+		prev, _ := original.([]interface{})
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			var before interface{}
+			if i < len(prev) {
+				before = prev[i]
+			}
+			items[i] = orderValue(item, before)
+		}
+		return items
+	}
+	return value
+}
+
+// migrateV0 обновляет формат, описанный в первых версиях README:
+// секция signal на верхнем уровне и пороги с символом процента ("0.1%").
+func migrateV0(doc document) []string {
+	var changes []string
+
+	analysis, _ := doc["analysis"].(document)
+	if signal, ok := doc["signal"]; ok {
+		if analysis == nil {
+			analysis = make(document)
+			doc["analysis"] = analysis
+		}
+		if _, exists := analysis["signal"]; exists {
+			changes = append(changes, "секция signal проигнорирована: уже задана analysis.signal")
+		} else {
+			analysis["signal"] = signal
+			changes = append(changes, "секция signal перенесена в analysis.signal")
+		}
+		delete(doc, "signal")
+	}
+
+	if analysis != nil {
+		changes = append(changes, stripPercents("analysis", analysis)...)
+	}
+	return changes
+}
+
+// migrateV1 включает флаг features.execution для файлов, где уже разрешена отправка
+// заявок: с версии 2 экспериментальные подсистемы включаются только явно
+func migrateV1(doc document) []string {
+	execution, _ := doc["execution"].(document)
+	if enabled, _ := execution["enabled"].(bool); !enabled {
+		return nil
+	}
+
+	features, ok := doc["features"].(document)
+	if !ok {
+		features = make(document)
+		doc["features"] = features
+	}
+	if _, set := features[FeatureExecution]; set {
+		return nil
+	}
+	features[FeatureExecution] = true
+	return []string{"execution.enabled: добавлен флаг features.execution: true"}
+}
+
+// migrateV2 заменяет числовые периоды длительностями: analysis.interval_seconds
+// становится analysis.period ("10s"), ui.refresh_rate_ms - ui.refresh_rate ("500ms")
+func migrateV2(doc document) []string {
+	var changes []string
+
+	if analysis, ok := doc["analysis"].(document); ok {
+		changes = append(changes, renameDuration("analysis", analysis, "interval_seconds", "period", time.Second)...)
+	}
+	if groups, ok := doc["groups"].([]interface{}); ok {
+		for i, item := range groups {
+			group, _ := item.(document)
+			if analysis, ok := group["analysis"].(document); ok {
+				changes = append(changes, renameDuration(fmt.Sprintf("groups[%d].analysis", i), analysis, "interval_seconds", "period", time.Second)...)
+			}
+		}
+	}
+	if ui, ok := doc["ui"].(document); ok {
+		changes = append(changes, renameDuration("ui", ui, "refresh_rate_ms", "refresh_rate", time.Millisecond)...)
+	}
+	return changes
+}
+
+// renameDuration переносит число из параметра from в параметр to строкой длительности
+func renameDuration(prefix string, section document, from, to string, unit time.Duration) []string {
+	value, ok := section[from]
+	if !ok {
+		return nil
+	}
+	delete(section, from)
+
+	if _, exists := section[to]; exists {
+		return []string{fmt.Sprintf("%s.%s проигнорирован: уже задан %s.%s", prefix, from, prefix, to)}
+	}
+	var number float64
+	switch v := value.(type) {
+	case int:
+		number = float64(v)
+	case float64:
+		number = v
+	default:
+		// Нечисловое значение оставляем как есть, ошибку покажет разбор длительности
+		section[to] = value
+		return []string{fmt.Sprintf("%s.%s переименован в %s.%s", prefix, from, prefix, to)}
+	}
+
+	duration := time.Duration(number * float64(unit)).String()
+	section[to] = duration
+	return []string{fmt.Sprintf("%s.%s: %v заменено на %s.%s: %s", prefix, from, value, prefix, to, duration)}
+}
+
+// stripPercents заменяет строки вида "5%" числами во вложенных секциях
+func stripPercents(prefix string, doc document) []string {
+	var changes []string
+	for key, value := range doc {
+		path := fmt.Sprintf("%s.%v", prefix, key)
+		switch v := value.(type) {
+		case document:
+			changes = append(changes, stripPercents(path, v)...)
+		case string:
+			number, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(v, "%")), 64)
+			if err != nil || !strings.HasSuffix(v, "%") {
+				continue
+			}
+			doc[key] = number
+			changes = append(changes, fmt.Sprintf("%s: значение %q заменено числом %v (в процентах)", path, v, number))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix префикс переменных окружения, переопределяющих настройки
+const EnvPrefix = "BFMA_"
+
+// ApplyOverrides применяет переопределения поверх файла конфигурации: сначала
+// переменные окружения (BFMA_BINANCE_API_KEY), затем значения флагов --set вида
+// ключ=значение, где ключ - путь yaml через точку (binance.api_key)
+func ApplyOverrides(cfg *Config, sets []string) error {
+	fields := make(map[string]reflect.Value)
+	collectFields(reflect.ValueOf(cfg).Elem(), "", fields)
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		name := EnvName(path)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(fields[path], value); err != nil {
+			return fmt.Errorf("ошибка в переменной окружения %s: %w", name, err)
+		}
+	}
+
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok {
+			return fmt.Errorf("неверный формат %q, ожидается ключ=значение", set)
+		}
+		key = strings.TrimSpace(key)
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("неизвестный параметр конфигурации %q", key)
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("ошибка в параметре %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// Params возвращает значения параметров структуры настроек по путям yaml
+func Params(v interface{}) map[string]interface{} {
+	fields := make(map[string]reflect.Value)
+	collectFields(reflect.ValueOf(v).Elem(), "", fields)
+
+	params := make(map[string]interface{}, len(fields))
+	for path, field := range fields {
+		params[path] = field.Interface()
+	}
+	return params
+}
+
+// SetParam записывает значение параметра структуры настроек по пути yaml.
+// Значение разбирается так же, как во флаге --set.
+func SetParam(v interface{}, path, value string) error {
+	fields := make(map[string]reflect.Value)
+	collectFields(reflect.ValueOf(v).Elem(), "", fields)
+
+	field, ok := fields[path]
+	if !ok {
+		return fmt.Errorf("неизвестный параметр %q", path)
+	}
+	return setField(field, value)
+}
+
+// EnvName возвращает имя переменной окружения для пути yaml
+func EnvName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// collectFields собирает поля конфигурации по путям yaml; вложенные структуры раскрываются
+func collectFields(v reflect.Value, prefix string, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		path := prefix + tag
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			collectFields(field, path+".", fields)
+			continue
+		}
+		fields[path] = field
+	}
+}
+
+// setField записывает значение в поле. Строки берутся как есть, списки строк
+// можно перечислить через запятую, остальные типы разбираются как YAML.
+func setField(field reflect.Value, value string) error {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+		return nil
+	case field.Type() == reflect.TypeOf([]string(nil)) && !strings.HasPrefix(strings.TrimSpace(value), "["):
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+		return nil
+	}
+
+	parsed := reflect.New(field.Type())
+	if err := yaml.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+		return fmt.Errorf("неверное значение %q: %w", value, err)
+	}
+	field.Set(parsed.Elem())
+	return nil
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Ключи документа, относящиеся к профилям, а не к настройкам
+const (
+	profilesKey = "profiles" // Секция профилей в одном файле
+	extendsKey  = "extends"  // Профиль, от которого наследуются настройки
+)
+
+// Имя базового файла, если конфигурация задана каталогом
+const baseFileName = "base.yaml"
+
+// document - разобранный YAML-документ конфигурации
+type document = map[interface{}]interface{}
+
+// BasePath возвращает файл базовой конфигурации: сам path или base.yaml в каталоге
+func BasePath(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, baseFileName)
+	}
+	return path
+}
+
+// readProfile читает конфигурацию, накладывает на нее файлы окружения по порядку,
+// а затем выбранный профиль. Профили задаются секцией profiles или отдельными файлами
+// <профиль>.yaml в каталоге рядом с base.yaml; профиль может наследовать другой через extends.
+func readProfile(path string, overlays []string, profile string) ([]byte, error) {
+	base, err := readDocument(BasePath(path))
+	if err != nil {
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		doc, err := readDocument(overlay)
+		if err != nil {
+			return nil, err
+		}
+		mergeDocuments(base, doc)
+	}
+
+	// Профили из секции profiles основного файла
+	inline, _ := base[profilesKey].(document)
+	delete(base, profilesKey)
+
+	lookup := func(name string) (document, error) {
+		if p, ok := inline[name].(document); ok {
+			return p, nil
+		}
+		if BasePath(path) != path {
+			file := filepath.Join(path, name+".yaml")
+			if _, err := os.Stat(file); err == nil {
+				return readDocument(file)
+			}
+		}
+		return nil, fmt.Errorf("профиль %q не найден (доступны: %s)", name, strings.Join(Profiles(path), ", "))
+	}
+
+	if profile != "" {
+		// Цепочка наследования от самого общего профиля к выбранному
+		var chain []document
+		seen := make(map[string]bool)
+		for name := profile; name != ""; {
+			if seen[name] {
+				return nil, fmt.Errorf("циклическое наследование профилей: %s", name)
+			}
+			seen[name] = true
+
+			p, err := lookup(name)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, p)
+			name, _ = p[extendsKey].(string)
+		}
+
+		for i := len(chain) - 1; i >= 0; i-- {
+			mergeDocuments(base, chain[i])
+		}
+	}
+	delete(base, extendsKey)
+
+	return yaml.Marshal(base)
+}
+
+// readDocument читает YAML-файл в виде документа
+func readDocument(path string) (document, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	doc := make(document)
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+	if err := migrateDocument(path, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// mergeDocuments накладывает src на dst: вложенные секции объединяются,
+// значения и списки заменяются целиком
+func mergeDocuments(dst, src document) {
+	for key, value := range src {
+		if key == extendsKey {
+			continue
+		}
+		if srcMap, ok := value.(document); ok {
+			if dstMap, ok := dst[key].(document); ok {
+				mergeDocuments(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// Profiles возвращает имена доступных профилей
+func Profiles(path string) []string {
+	var names []string
+	if doc, err := readDocument(BasePath(path)); err == nil {
+		if inline, ok := doc[profilesKey].(document); ok {
+			for name := range inline {
+				names = append(names, fmt.Sprint(name))
+			}
+		}
+	}
+
+	if BasePath(path) != path {
+		files, _ := filepath.Glob(filepath.Join(path, "*.yaml"))
+		for _, file := range files {
+			if name := filepath.Base(file); name != baseFileName {
+				names = append(names, strings.TrimSuffix(name, ".yaml"))
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// sourceFiles возвращает файлы, из которых собирается конфигурация
+func sourceFiles(path string, overlays []string) []string {
+	files := []string{path}
+	if BasePath(path) != path {
+		files, _ = filepath.Glob(filepath.Join(path, "*.yaml"))
+	}
+	return append(files, overlays...)
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Параметры удаленных источников конфигурации
+const (
+	remoteTimeout  = 10 * time.Second // Время на получение конфигурации
+	remoteInterval = 30 * time.Second // Период опроса удаленного источника на изменения
+	remoteMaxSize  = 1 << 20          // Максимальный размер конфигурации
+)
+
+// remoteFetchers получают конфигурацию по схеме URI
+var remoteFetchers = map[string]func(ctx context.Context, ref *url.URL) ([]byte, error){
+	"http":   fetchHTTP,
+	"https":  fetchHTTP,
+	"consul": fetchConsul,
+	"etcd":   fetchEtcd,
+}
+
+// IsRemote сообщает, что путь конфигурации указывает на удаленный источник:
+// https://, consul:// или etcd://
+func IsRemote(path string) bool {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	_, ok := remoteFetchers[ref.Scheme]
+	return ok
+}
+
+// localCopy возвращает локальный файл с конфигурацией. Удаленная конфигурация
+// скачивается в кэш; если источник недоступен, используется последняя сохраненная копия.
+func localCopy(path string) (string, error) {
+	if !IsRemote(path) {
+		return path, nil
+	}
+
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	cache, err := remoteCachePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	data, err := remoteFetchers[ref.Scheme](ctx, ref)
+	cancel()
+	if err != nil {
+		if _, statErr := os.Stat(cache); statErr != nil {
+			return "", fmt.Errorf("ошибка получения конфигурации %s: %w", redactURL(ref), err)
+		}
+		logger.Warn("Удаленная конфигурация недоступна, используется сохраненная копия",
+			zap.String("source", redactURL(ref)), zap.String("cache", cache), zap.Error(err))
+		return cache, nil
+	}
+
+	// Перезаписываем кэш только при изменении, чтобы не трогать время изменения файла
+	if old, err := os.ReadFile(cache); err != nil || !bytes.Equal(old, data) {
+		if err := os.MkdirAll(filepath.Dir(cache), 0700); err != nil {
+			return "", fmt.Errorf("ошибка создания каталога кэша конфигурации: %w", err)
+		}
+		// Конфигурация может содержать ключи API, поэтому доступна только владельцу
+		if err := os.WriteFile(cache, data, 0600); err != nil {
+			return "", fmt.Errorf("ошибка записи кэша конфигурации: %w", err)
+		}
+		logger.Info("Получена удаленная конфигурация", zap.String("source", redactURL(ref)), zap.String("cache", cache))
+	}
+	return cache, nil
+}
+
+// remoteCachePath возвращает файл кэша для удаленного источника
+func remoteCachePath(path string) (string, error) {
+	dir := os.Getenv("BFMA_CONFIG_CACHE")
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("не удалось определить каталог кэша, задайте BFMA_CONFIG_CACHE: %w", err)
+		}
+		dir = filepath.Join(base, "bfma")
+	}
+
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(dir, "remote-"+hex.EncodeToString(sum[:8])+".yaml"), nil
+}
+
+// redactURL убирает из адреса пароль и параметры запроса для вывода в журнал
+func redactURL(ref *url.URL) string {
+	safe := *ref
+	safe.User = nil
+	safe.RawQuery = ""
+	return safe.String()
+}
+
+// fetchHTTP скачивает конфигурацию по HTTP(S). Если задан BFMA_CONFIG_TOKEN,
+// он передается в заголовке Authorization: Bearer.
+func fetchHTTP(ctx context.Context, ref *url.URL) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("BFMA_CONFIG_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return doRemote(req)
+}
+
+// fetchConsul читает ключ из Consul KV: consul://127.0.0.1:8500/bfma/config.
+// Без адреса используется CONSUL_HTTP_ADDR; токен берется из CONSUL_HTTP_TOKEN.
+func fetchConsul(ctx context.Context, ref *url.URL) ([]byte, error) {
+	addr := remoteAddr(ref, os.Getenv("CONSUL_HTTP_ADDR"), "127.0.0.1:8500", os.Getenv("CONSUL_HTTP_SSL") == "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		addr+"/v1/kv/"+strings.TrimPrefix(ref.Path, "/")+"?raw", nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("CONSUL_HTTP_TOKEN"); token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+	return doRemote(req)
+}
+
+// fetchEtcd читает ключ из etcd через JSON-шлюз API v3: etcd://127.0.0.1:2379/bfma/config.
+// Без адреса используется первый адрес из ETCD_ENDPOINTS.
+func fetchEtcd(ctx context.Context, ref *url.URL) ([]byte, error) {
+	endpoint, _, _ := strings.Cut(os.Getenv("ETCD_ENDPOINTS"), ",")
+	addr := remoteAddr(ref, endpoint, "127.0.0.1:2379", false)
+
+	body, err := json.Marshal(map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(ref.Path))})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	data, err := doRemote(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		KVs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("ошибка разбора ответа etcd: %w", err)
+	}
+	if len(result.KVs) == 0 {
+		return nil, fmt.Errorf("ключ %s не найден в etcd", ref.Path)
+	}
+	value, err := base64.StdEncoding.DecodeString(result.KVs[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора значения etcd: %w", err)
+	}
+	return value, nil
+}
+
+// remoteAddr возвращает базовый адрес HTTP API: из URI, переменной окружения или по умолчанию
+func remoteAddr(ref *url.URL, env, fallback string, tls bool) string {
+	addr := ref.Host
+	if addr == "" {
+		addr = env
+	}
+	if addr == "" {
+		addr = fallback
+	}
+	if strings.Contains(addr, "://") {
+		return strings.TrimRight(addr, "/")
+	}
+	if tls {
+		return "https://" + addr
+	}
+	return "http://" + addr
+}
+
+// doRemote выполняет запрос и возвращает тело успешного ответа
+func doRemote(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ответа: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ответ %s", resp.Status)
+	}
+	if len(data) > remoteMaxSize {
+		return nil, fmt.Errorf("конфигурация больше %d байт", remoteMaxSize)
+	}
+	return data, nil
+}
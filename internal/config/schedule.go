@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Каналы оповещений, для которых можно задать свои окна
+const (
+	ChannelBell     = "bell"     // Звуковой сигнал и подсветка панели оповещений
+	ChannelPlain    = "plain"    // Текстовый вывод оповещений (--plain, --daemon)
+	ChannelPush     = "push"     // Поток /ws HTTP API данных
+	ChannelTelegram = "telegram" // Бот Telegram
+	ChannelWebhook  = "webhook"  // Вебхуки с событием alert
+	ChannelEmail    = "email"    // Письма по SMTP
+	ChannelMQTT     = "mqtt"     // Темы <prefix>/alerts/<символ> брокера MQTT
+	ChannelStream   = "stream"   // Тема alerts в Kafka или NATS
+	ChannelDesktop  = "desktop"  // Уведомления рабочего стола
+	ChannelDiscord  = "discord"  // Вебхук канала Discord
+)
+
+// Дни недели в окнах сессий
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ScheduleConfig окна торговых сессий. Вне сессий оповещения не подаются, а при
+// pause_signals не рассчитываются и сигналы. Время задается в часовом поясе timezone.
+type ScheduleConfig struct {
+	Sessions     []SessionWindow            `yaml:"sessions"`           // Окна сессий; пусто - круглосуточно
+	PauseSignals bool                       `yaml:"pause_signals"`      // Не рассчитывать сигналы вне сессий
+	Channels     map[string][]SessionWindow `yaml:"channels,omitempty"` // Свои окна канала оповещений; пустой список - всегда
+}
+
+// SessionWindow окно времени "09:00"-"18:00". Если to не позже from, окно переходит
+// через полночь. Дни недели (mon..sun) относятся к началу окна; пусто - каждый день.
+type SessionWindow struct {
+	From string   `yaml:"from"`
+	To   string   `yaml:"to"`
+	Days []string `yaml:"days,omitempty"`
+}
+
+// SignalsActive сообщает, рассчитываются ли сигналы в момент t
+func (s ScheduleConfig) SignalsActive(t time.Time) bool {
+	return !s.PauseSignals || inWindows(s.Sessions, t)
+}
+
+// AlertsActive сообщает, подаются ли оповещения канала в момент t
+func (s ScheduleConfig) AlertsActive(channel string, t time.Time) bool {
+	if windows, ok := s.Channels[channel]; ok {
+		return inWindows(windows, t)
+	}
+	return inWindows(s.Sessions, t)
+}
+
+// inWindows сообщает, попадает ли t в одно из окон; без окон - всегда
+func inWindows(windows []SessionWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains сообщает, попадает ли t в окно. Окно с ошибкой формата не содержит ничего
+// (формат проверяется при загрузке конфигурации).
+func (w SessionWindow) Contains(t time.Time) bool {
+	from, to, err := w.bounds()
+	if err != nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	start := t // День начала окна
+	switch {
+	case from < to:
+		if minute < from || minute >= to {
+			return false
+		}
+	case minute >= from:
+		// Окно через полночь, первая часть
+	case minute < to:
+		start = t.AddDate(0, 0, -1)
+	default:
+		return false
+	}
+	return w.onDay(start.Weekday())
+}
+
+// onDay сообщает, начинается ли окно в день day
+func (w SessionWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekdays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// bounds возвращает начало и конец окна в минутах от полуночи
+func (w SessionWindow) bounds() (int, int, error) {
+	from, err := parseClock(w.From)
+	if err != nil {
+		return 0, 0, fmt.Errorf("from: %w", err)
+	}
+	to, err := parseClock(w.To)
+	if err != nil {
+		return 0, 0, fmt.Errorf("to: %w", err)
+	}
+	return from, to, nil
+}
+
+// validate возвращает ошибки формата окна
+func (w SessionWindow) validate() []string {
+	var problems []string
+	if _, _, err := w.bounds(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, name := range w.Days {
+		if _, ok := weekdays[strings.ToLower(name)]; !ok {
+			problems = append(problems, fmt.Sprintf("days: неизвестный день %q, допустимы mon, tue, wed, thu, fri, sat, sun", name))
+		}
+	}
+	return problems
+}
+
+// Clock возвращает время формирования отчета в минутах от полуночи
+func (r ReportsConfig) Clock() int {
+	if r.At == "" {
+		return 0
+	}
+	minutes, _ := parseClock(r.At)
+	return minutes
+}
+
+// Day возвращает день недельного отчета
+func (r ReportsConfig) Day() time.Weekday {
+	if day, ok := weekdays[strings.ToLower(r.Weekday)]; ok {
+		return day
+	}
+	return time.Monday
+}
+
+// parseClock разбирает время "HH:MM" в минуты от полуночи
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("неверное время %q, ожидается ЧЧ:ММ", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
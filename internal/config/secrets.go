@@ -0,0 +1,235 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Время на получение одного секрета
+const secretTimeout = 10 * time.Second
+
+// secretResolvers получают значения секретов по схеме URI
+var secretResolvers = map[string]func(ctx context.Context, ref *url.URL) (string, error){
+	"vault":   resolveVault,
+	"aws-sm":  resolveAWSSecret,
+	"keyring": resolveKeyring,
+	"enc":     resolveEncrypted,
+}
+
+// ResolveSecrets заменяет строковые параметры вида vault://, aws-sm:// и keyring://
+// значениями секретов, а зашифрованные значения enc:v1:... расшифровывает, чтобы ключи
+// не хранились в YAML открытым текстом
+func ResolveSecrets(cfg *Config) error {
+	fields := make(map[string]reflect.Value)
+	collectFields(reflect.ValueOf(cfg).Elem(), "", fields)
+
+	paths := make([]string, 0, len(fields))
+	for path, field := range fields {
+		if field.Kind() == reflect.String {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		field := fields[path]
+		ref, err := url.Parse(field.String())
+		if err != nil {
+			continue
+		}
+		resolve, ok := secretResolvers[ref.Scheme]
+		if !ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+		value, err := resolve(ctx, ref)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("ошибка получения секрета для %s: %w", path, err)
+		}
+
+		field.SetString(value)
+		logger.Info("Секрет получен", zap.String("param", path), zap.String("scheme", ref.Scheme))
+	}
+	return nil
+}
+
+// resolveVault читает поле секрета из HashiCorp Vault по HTTP API:
+// vault://secret/data/bfma#api_key. Адрес и токен берутся из VAULT_ADDR и VAULT_TOKEN
+// (или ~/.vault-token); поддерживаются движки KV v1 и v2.
+func resolveVault(ctx context.Context, ref *url.URL) (string, error) {
+	field := ref.Fragment
+	if field == "" {
+		return "", fmt.Errorf("не указано поле секрета: vault://<путь>#<поле>")
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = "http://127.0.0.1:8200"
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			data, _ := os.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if token == "" {
+		return "", fmt.Errorf("не задан VAULT_TOKEN")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimRight(addr, "/")+"/v1/"+ref.Host+ref.Path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка запроса к Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения ответа Vault: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault вернул статус %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("ошибка разбора ответа Vault: %w", err)
+	}
+
+	// В KV v2 значения вложены в data.data
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("поле %q не найдено в секрете", field)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// resolveAWSSecret читает секрет из AWS Secrets Manager через AWS CLI, чтобы
+// использовать стандартную цепочку учетных данных: aws-sm://<id секрета>[#<ключ JSON>]
+func resolveAWSSecret(ctx context.Context, ref *url.URL) (string, error) {
+	id := strings.TrimPrefix(ref.Host+ref.Path, "/")
+	if id == "" {
+		return "", fmt.Errorf("не указан идентификатор секрета: aws-sm://<id>[#<ключ>]")
+	}
+
+	value, err := runSecretCommand(ctx, "aws", "secretsmanager", "get-secret-value",
+		"--secret-id", id, "--query", "SecretString", "--output", "text")
+	if err != nil {
+		return "", err
+	}
+	if ref.Fragment == "" {
+		return value, nil
+	}
+
+	// Секрет с несколькими значениями хранится как JSON-объект
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("секрет %s не является JSON-объектом: %w", id, err)
+	}
+	field, ok := fields[ref.Fragment]
+	if !ok {
+		return "", fmt.Errorf("ключ %q не найден в секрете %s", ref.Fragment, id)
+	}
+	return fmt.Sprint(field), nil
+}
+
+// resolveKeyring читает пароль из системного хранилища: keyring://<сервис>/<учетная запись>.
+// В macOS используется security, в Linux - secret-tool (Secret Service).
+func resolveKeyring(ctx context.Context, ref *url.URL) (string, error) {
+	service, account := ref.Host, strings.TrimPrefix(ref.Path, "/")
+	if service == "" || account == "" {
+		return "", fmt.Errorf("ожидается keyring://<сервис>/<учетная запись>")
+	}
+
+	if runtime.GOOS == "darwin" {
+		return runSecretCommand(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	}
+	return runSecretCommand(ctx, "secret-tool", "lookup", "service", service, "account", account)
+}
+
+// runSecretCommand запускает утилиту и возвращает ее вывод без перевода строки в конце
+func runSecretCommand(ctx context.Context, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("не найдена утилита %s: %w", name, err)
+	}
+
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("ошибка %s: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("ошибка запуска %s: %w", name, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// Параметры, значения которых не выводятся открытым текстом
+var secretParams = []string{"binance.api_key", "binance.api_secret", "storage.token", "admin.token", "api.token", "telegram.token", "email.password", "mqtt.password", "stream.password", "tradingview.passphrase", "discord.webhook_url", "escalation.pushover.token", "escalation.twilio.auth_token", "incidents.pagerduty.routing_key", "incidents.opsgenie.api_key", "crash.sentry.dsn"}
+
+// Значение, которым заменяются секреты при выводе
+const redactedValue = "***"
+
+// Redacted возвращает копию конфигурации со скрытыми значениями секретов
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	fields := make(map[string]reflect.Value)
+	collectFields(reflect.ValueOf(&redacted).Elem(), "", fields)
+
+	for _, path := range secretParams {
+		if field := fields[path]; field.String() != "" {
+			field.SetString(redactedValue)
+		}
+	}
+	// Ключи подписи вебхуков лежат в списке и не попадают в пути secretParams
+	redacted.Webhooks = slices.Clone(c.Webhooks)
+	for i := range redacted.Webhooks {
+		if redacted.Webhooks[i].Secret != "" {
+			redacted.Webhooks[i].Secret = redactedValue
+		}
+	}
+	// То же для секретов мостов к торговым ботам
+	redacted.Bridges = slices.Clone(c.Bridges)
+	for i := range redacted.Bridges {
+		if redacted.Bridges[i].Secret != "" {
+			redacted.Bridges[i].Secret = redactedValue
+		}
+		if redacted.Bridges[i].Password != "" {
+			redacted.Bridges[i].Password = redactedValue
+		}
+	}
+	return &redacted
+}
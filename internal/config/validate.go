@@ -0,0 +1,772 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Глубины стакана, допустимые в REST API Binance Futures
+var knownDepths = []int{5, 10, 20, 50, 100, 500, 1000}
+
+// Допустимые уровни логирования
+var knownLogLevels = []string{"debug", "info", "warn", "error"}
+
+// Допустимые режимы сортировки списков наблюдения
+var knownSorts = []string{"", "symbol", "strength_desc", "strength_asc"}
+
+// Каналы оповещений, для которых можно задать свои окна
+var alertChannels = []string{ChannelBell, ChannelPlain, ChannelPush, ChannelTelegram, ChannelWebhook, ChannelEmail, ChannelMQTT, ChannelStream, ChannelDesktop, ChannelDiscord}
+
+// Внешние каналы, которые выбираются правилами notifications.routes
+var routeChannels = alertChannels[2:]
+
+// Уровни серьезности инцидентов
+var incidentSeverities = []string{IncidentCritical, IncidentError, IncidentWarning, IncidentInfo}
+
+// Допустимое отклонение суммы весов анализаторов от 1
+const weightSumTolerance = 0.01
+
+// Validate проверяет конфигурацию и возвращает все найденные проблемы одной ошибкой
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	required := func(path, value string) {
+		if strings.TrimSpace(value) == "" {
+			add(path, "обязательный параметр (можно задать переменной %s)", EnvName(path))
+		}
+	}
+
+	if _, err := timezone.Load(c.Timezone); err != nil {
+		add("timezone", "неизвестный часовой пояс %q, допустимы local, UTC или имя IANA (Europe/Moscow)", c.Timezone)
+	}
+
+	// Биржа
+	hasKeys := c.Binance.APIKey != "" && c.Binance.APISecret != ""
+	if (c.Binance.APIKey == "") != (c.Binance.APISecret == "") {
+		add("binance", "api_key и api_secret задаются вместе")
+	}
+	if c.Account.Enabled && !hasKeys {
+		add("account.enabled", "нужны binance.api_key и binance.api_secret с правом чтения")
+	}
+	if c.Execution.Enabled && !hasKeys {
+		add("execution.enabled", "нужны binance.api_key и binance.api_secret с правом торговли")
+	}
+	if c.Execution.Enabled && !c.Features.Enabled(FeatureExecution) {
+		add("execution.enabled", "отправка заявок экспериментальная, включите features.%s: true", FeatureExecution)
+	}
+
+	// Экспериментальные подсистемы
+	for _, name := range sortedFlags(c.Features) {
+		if Describe(name) == "" {
+			add("features."+name, "неизвестная подсистема, доступны: %s", strings.Join(KnownFeatures(), ", "))
+		}
+	}
+
+	// Торговля
+	if len(c.Trading.Symbols) == 0 && len(c.Groups) == 0 {
+		add("trading.symbols", "укажите хотя бы один символ в trading.symbols или groups")
+	}
+	for _, symbol := range c.Trading.Symbols {
+		if symbol != strings.ToUpper(symbol) || strings.TrimSpace(symbol) != symbol || symbol == "" {
+			add("trading.symbols", "символ %q должен быть в верхнем регистре без пробелов, например BTCUSDT", symbol)
+		}
+	}
+	if _, err := models.ParseInterval(string(c.Trading.Interval)); err != nil {
+		add("trading.interval", "%v", err)
+	}
+
+	// Анализ
+	analysisProblems := validateAnalysis("analysis", c.Analysis)
+	problems = append(problems, analysisProblems...)
+
+	// Группы символов
+	groupNames := make(map[string]bool)
+	grouped := make(map[string]string)
+	for i, group := range c.Groups {
+		path := fmt.Sprintf("groups[%d]", i)
+		switch {
+		case group.Name == "":
+			add(path+".name", "укажите имя группы")
+		case groupNames[group.Name]:
+			add(path+".name", "группа %q уже объявлена", group.Name)
+		}
+		groupNames[group.Name] = true
+
+		if len(group.Symbols) == 0 {
+			add(path+".symbols", "укажите хотя бы один символ")
+		}
+		for _, symbol := range group.Symbols {
+			if other, ok := grouped[symbol]; ok {
+				add(path+".symbols", "символ %s уже входит в группу %q", symbol, other)
+			}
+			grouped[symbol] = group.Name
+		}
+		if group.Interval != "" {
+			if _, err := models.ParseInterval(string(group.Interval)); err != nil {
+				add(path+".interval", "%v", err)
+			}
+		}
+
+		analysis, err := group.Analysis.Apply(c.Analysis)
+		if err != nil {
+			add(path+".analysis", "%v", err)
+			continue
+		}
+		if analysis.Period != c.Analysis.Period {
+			add(path+".analysis.period", "период анализа общий для всех символов и задается в analysis.period")
+		}
+		// Ошибки, унаследованные из секции analysis, уже выведены для нее
+		for _, problem := range validateAnalysis(path+".analysis", analysis) {
+			if !slices.Contains(analysisProblems, strings.Replace(problem, path+".analysis", "analysis", 1)) {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	// Хранилище
+	switch c.Storage.Type {
+	case "", "influxdb":
+		required("storage.url", c.Storage.URL)
+		required("storage.token", c.Storage.Token)
+		required("storage.organization", c.Storage.Organization)
+		required("storage.bucket", c.Storage.Bucket)
+	case "memory":
+		// Параметры подключения не нужны
+	default:
+		add("storage.type", "неизвестный тип хранилища %q, поддерживаются influxdb и memory", c.Storage.Type)
+	}
+
+	// Расписание сессий
+	for i, window := range c.Schedule.Sessions {
+		for _, problem := range window.validate() {
+			add(fmt.Sprintf("schedule.sessions[%d]", i), "%s", problem)
+		}
+	}
+	if c.Schedule.PauseSignals && len(c.Schedule.Sessions) == 0 {
+		add("schedule.pause_signals", "укажите окна в schedule.sessions")
+	}
+	for _, channel := range slices.Sorted(maps.Keys(c.Schedule.Channels)) {
+		if !slices.Contains(alertChannels, channel) {
+			add("schedule.channels."+channel, "неизвестный канал, доступны: %s", strings.Join(alertChannels, ", "))
+		}
+		for i, window := range c.Schedule.Channels[channel] {
+			for _, problem := range window.validate() {
+				add(fmt.Sprintf("schedule.channels.%s[%d]", channel, i), "%s", problem)
+			}
+		}
+	}
+
+	// Маршрутизация оповещений
+	if c.Notify.MinInterval < 0 {
+		add("notifications.min_interval", "не может быть отрицательным, задано %s", c.Notify.MinInterval)
+	}
+	if c.Notify.DedupWindow < 0 {
+		add("notifications.dedup_window", "не может быть отрицательным, задано %s", c.Notify.DedupWindow)
+	}
+	for i, route := range c.Notify.Routes {
+		path := fmt.Sprintf("notifications.routes[%d]", i)
+		if len(route.Channels) == 0 {
+			add(path+".channels", "укажите хотя бы один канал")
+		}
+		for _, channel := range route.Channels {
+			if !slices.Contains(routeChannels, channel) {
+				add(path+".channels", "неизвестный канал %q, доступны: %s", channel, strings.Join(routeChannels, ", "))
+			}
+		}
+	}
+
+	// Интерфейс
+	if c.UI.Locale != "" && !slices.Contains(i18n.Locales(), c.UI.Locale) {
+		add("ui.locale", "неизвестный язык %q, доступны: %s", c.UI.Locale, strings.Join(i18n.Locales(), ", "))
+	}
+	if c.UI.RefreshRate < 0 {
+		add("ui.refresh_rate", "не может быть отрицательным, задано %s", c.UI.RefreshRate)
+	}
+	if c.UI.SplitRatio < 0 || c.UI.SplitRatio >= 1 {
+		add("ui.split_ratio", "доля высоты должна быть в диапазоне [0, 1), задано %v", c.UI.SplitRatio)
+	}
+	names := make(map[string]bool)
+	for i, wl := range c.UI.Watchlists {
+		path := fmt.Sprintf("ui.watchlists[%d]", i)
+		switch {
+		case wl.Name == "":
+			add(path+".name", "укажите имя списка")
+		case names[wl.Name]:
+			add(path+".name", "список %q уже объявлен", wl.Name)
+		}
+		names[wl.Name] = true
+		if !slices.Contains(knownSorts, wl.Sort) {
+			add(path+".sort", "неизвестная сортировка %q, допустимы: symbol, strength_desc, strength_asc", wl.Sort)
+		}
+	}
+
+	// Исполнение заявок
+	if c.Execution.Enabled {
+		if c.Trading.RiskPerTrade <= 0 || c.Trading.RiskPerTrade > 1 {
+			add("trading.risk_per_trade", "доля счета на сделку должна быть в диапазоне (0, 1], задано %v", c.Trading.RiskPerTrade)
+		}
+		if c.Execution.StopLossPct < 0 {
+			add("execution.stop_loss_pct", "не может быть отрицательным, задано %v", c.Execution.StopLossPct)
+		}
+		if c.Execution.TakeProfitPct < 0 {
+			add("execution.take_profit_pct", "не может быть отрицательным, задано %v", c.Execution.TakeProfitPct)
+		}
+		if c.Execution.MaxNotional < 0 {
+			add("execution.max_notional", "не может быть отрицательным, задано %v", c.Execution.MaxNotional)
+		}
+	}
+
+	// Ограничения риска
+	if c.Risk.MaxPositionNotional < 0 {
+		add("risk.max_position_notional", "не может быть отрицательным, задано %v", c.Risk.MaxPositionNotional)
+	}
+	if c.Risk.MaxLeverage < 0 || c.Risk.MaxLeverage > 125 {
+		add("risk.max_leverage", "должно быть в диапазоне 0..125, задано %d", c.Risk.MaxLeverage)
+	}
+	if c.Risk.MaxDailyOrders < 0 {
+		add("risk.max_daily_orders", "не может быть отрицательным, задано %d", c.Risk.MaxDailyOrders)
+	}
+	if c.Risk.KillSwitchDrawdownPct < 0 || c.Risk.KillSwitchDrawdownPct >= 100 {
+		add("risk.kill_switch_drawdown_pct", "должно быть в диапазоне [0, 100), задано %v", c.Risk.KillSwitchDrawdownPct)
+	}
+
+	// Профилирование раскрывает память процесса, поэтому без токена не включается
+	if c.Admin.Enabled && c.Admin.Pprof {
+		required("admin.token", c.Admin.Token)
+	}
+
+	// Серверы слушают разные адреса: API данных - отдельный, чтобы токен потребителей
+	// не давал доступ к администрированию. Сравниваются адреса с учетом значений по
+	// умолчанию, а адрес на всех интерфейсах совпадает с любым адресом того же порта.
+	var listens []listenField
+	if c.Admin.Enabled {
+		listens = append(listens, listenField{"admin.listen", c.Admin.ListenAddr()})
+	}
+	if c.API.Enabled {
+		listens = append(listens, listenField{"api.listen", c.API.ListenAddr()})
+		if c.API.GRPCListen != "" {
+			listens = append(listens, listenField{"api.grpc_listen", c.API.GRPCListen})
+		}
+	}
+	if c.TradingView.Enabled {
+		listens = append(listens, listenField{"tradingview.listen", c.TradingView.ListenAddr()})
+	}
+	for i, field := range listens {
+		if _, _, err := net.SplitHostPort(field.addr); err != nil {
+			add(field.path, "неверный адрес %q, ожидается host:port, например 127.0.0.1:8090", field.addr)
+			continue
+		}
+		for _, other := range listens[:i] {
+			if listenConflict(field.addr, other.addr) {
+				add(field.path, "адрес %q совпадает с %s %q, укажите другой адрес", field.addr, other.path, other.addr)
+			}
+		}
+	}
+
+	// Прием оповещений TradingView: без фразы адрес позволял бы подменять сигналы
+	if c.TradingView.Enabled {
+		required("tradingview.passphrase", c.TradingView.Passphrase)
+	}
+
+	// Бот Telegram
+	if c.Telegram.Enabled {
+		required("telegram.token", c.Telegram.Token)
+		if len(c.Telegram.ChatIDs) == 0 {
+			add("telegram.chat_ids", "укажите хотя бы один чат для оповещений")
+		}
+	}
+
+	// Оповещения в Discord
+	if c.Discord.Enabled {
+		if u, err := url.Parse(c.Discord.WebhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			add("discord.webhook_url", "нужен адрес вебхука https://discord.com/api/webhooks/..., задано %q", c.Discord.WebhookURL)
+		}
+	}
+
+	// Вебхуки
+	webhooks := make(map[string]bool)
+	for i, webhook := range c.Webhooks {
+		path := fmt.Sprintf("webhooks[%d]", i)
+		switch {
+		case webhook.Name == "":
+			add(path+".name", "укажите имя вебхука")
+		case webhooks[webhook.Name]:
+			add(path+".name", "вебхук %q уже объявлен", webhook.Name)
+		}
+		webhooks[webhook.Name] = true
+		if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(path+".url", "нужен адрес http:// или https://, задано %q", webhook.URL)
+		}
+		for _, event := range webhook.Events {
+			if event != WebhookSignal && event != WebhookAlert {
+				add(path+".events", "неизвестное событие %q, допустимы: %s, %s", event, WebhookSignal, WebhookAlert)
+			}
+		}
+		if webhook.MaxRetries < 0 {
+			add(path+".max_retries", "не может быть отрицательным, задано %d", webhook.MaxRetries)
+		}
+		if webhook.Timeout < 0 {
+			add(path+".timeout", "не может быть отрицательным, задано %s", webhook.Timeout)
+		}
+	}
+
+	// Мосты к торговым ботам
+	bridges := make(map[string]bool)
+	for i, bridge := range c.Bridges {
+		path := fmt.Sprintf("bridges[%d]", i)
+		switch {
+		case bridge.Name == "":
+			add(path+".name", "укажите имя моста")
+		case bridges[bridge.Name]:
+			add(path+".name", "мост %q уже объявлен", bridge.Name)
+		}
+		bridges[bridge.Name] = true
+		if bridge.URL != "" || bridge.Type == BridgeFreqtrade {
+			if u, err := url.Parse(bridge.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				add(path+".url", "нужен адрес http:// или https://, задано %q", bridge.URL)
+			}
+		}
+		switch bridge.Type {
+		case BridgeThreeCommas:
+			if bridge.BotUUID == "" || bridge.Secret == "" {
+				add(path, "для 3Commas нужны bot_uuid и secret из настроек сигнального бота")
+			}
+		case BridgeFreqtrade:
+			if bridge.Username == "" || bridge.Password == "" {
+				add(path, "для Freqtrade нужны username и password из api_server бота")
+			}
+		default:
+			add(path+".type", "неизвестный формат %q, допустимы: %s, %s", bridge.Type, BridgeThreeCommas, BridgeFreqtrade)
+		}
+	}
+
+	// Оповещения по почте
+	if c.Email.Enabled {
+		required("email.host", c.Email.Host)
+		if c.Email.Port < 0 || c.Email.Port > 65535 {
+			add("email.port", "должно быть в диапазоне 0..65535, задано %d", c.Email.Port)
+		}
+		if _, err := mail.ParseAddress(c.Email.From); err != nil {
+			add("email.from", "неверный адрес %q", c.Email.From)
+		}
+		if len(c.Email.To) == 0 {
+			add("email.to", "укажите хотя бы одного получателя")
+		}
+		for i, to := range c.Email.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				add(fmt.Sprintf("email.to[%d]", i), "неверный адрес %q", to)
+			}
+		}
+		if c.Email.Digest < 0 {
+			add("email.digest", "не может быть отрицательным, задано %s", c.Email.Digest)
+		}
+	}
+
+	// Инциденты PagerDuty и Opsgenie
+	if c.Incidents.PagerDuty.Enabled {
+		required("incidents.pagerduty.routing_key", c.Incidents.PagerDuty.RoutingKey)
+	}
+	if c.Incidents.Opsgenie.Enabled {
+		required("incidents.opsgenie.api_key", c.Incidents.Opsgenie.APIKey)
+	}
+	for _, service := range []struct{ path, url string }{
+		{"incidents.pagerduty.url", c.Incidents.PagerDuty.URL},
+		{"incidents.opsgenie.url", c.Incidents.Opsgenie.URL},
+	} {
+		if service.url == "" {
+			continue
+		}
+		if u, err := url.Parse(service.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(service.path, "нужен адрес http:// или https://, задано %q", service.url)
+		}
+	}
+	for _, threshold := range []struct {
+		path  string
+		value Duration
+	}{
+		{"incidents.check_interval", c.Incidents.CheckInterval},
+		{"incidents.storage_down", c.Incidents.StorageDown},
+		{"incidents.websocket_down", c.Incidents.WebSocketDown},
+		{"incidents.analysis_overrun", c.Incidents.AnalysisOverrun},
+	} {
+		if threshold.value < 0 {
+			add(threshold.path, "не может быть отрицательным, задано %s", threshold.value)
+		}
+	}
+	for _, severity := range []struct{ path, value string }{
+		{"incidents.severity.storage", c.Incidents.Severity.Storage},
+		{"incidents.severity.websocket", c.Incidents.Severity.WebSocket},
+		{"incidents.severity.analysis", c.Incidents.Severity.Analysis},
+	} {
+		if severity.value != "" && !slices.Contains(incidentSeverities, severity.value) {
+			add(severity.path, "неизвестный уровень %q, допустимы: %s", severity.value, strings.Join(incidentSeverities, ", "))
+		}
+	}
+
+	// Бюджет ошибок подсистем
+	for _, value := range []struct {
+		path  string
+		value Duration
+	}{
+		{"watchdog.check_interval", c.Watchdog.CheckInterval},
+		{"watchdog.window", c.Watchdog.Window},
+		{"watchdog.cooldown", c.Watchdog.Cooldown},
+	} {
+		if value.value < 0 {
+			add(value.path, "не может быть отрицательным, задано %s", value.value)
+		}
+	}
+	for _, budget := range []struct {
+		path  string
+		value int
+	}{
+		{"watchdog.budgets.storage", c.Watchdog.Budgets.Storage},
+		{"watchdog.budgets.analysis", c.Watchdog.Budgets.Analysis},
+		{"watchdog.budgets.websocket", c.Watchdog.Budgets.WebSocket},
+		{"watchdog.budgets.exchange", c.Watchdog.Budgets.Exchange},
+	} {
+		if budget.value < 0 {
+			add(budget.path, "не может быть отрицательным, задано %d", budget.value)
+		}
+	}
+
+	// Обзор рынка
+	if c.Scanner.Enabled {
+		if c.Scanner.Interval != 0 && c.Scanner.Interval < Duration(time.Minute) {
+			add("scanner.interval", "должно быть не меньше 1m, задано %s", c.Scanner.Interval)
+		}
+		if c.Scanner.Lookback < 0 {
+			add("scanner.lookback", "не может быть отрицательным, задано %s", c.Scanner.Lookback)
+		}
+		for _, value := range []struct {
+			path  string
+			value float64
+		}{
+			{"scanner.min_quote_volume", c.Scanner.MinQuoteVolume},
+			{"scanner.volume_growth", c.Scanner.VolumeGrowth},
+			{"scanner.oi_change", c.Scanner.OIChange},
+			{"scanner.funding_bps", c.Scanner.FundingBps},
+		} {
+			if value.value < 0 {
+				add(value.path, "не может быть отрицательным, задано %v", value.value)
+			}
+		}
+		if c.Scanner.OISymbols < 0 {
+			add("scanner.oi_symbols", "не может быть отрицательным, задано %d", c.Scanner.OISymbols)
+		}
+		if c.Scanner.MaxCandidates < 0 {
+			add("scanner.max_candidates", "не может быть отрицательным, задано %d", c.Scanner.MaxCandidates)
+		}
+	}
+
+	// Отчеты о падениях
+	if c.Crash.Events < 0 {
+		add("crash.events", "не может быть отрицательным, задано %d", c.Crash.Events)
+	}
+	if c.Crash.Keep < 0 {
+		add("crash.keep", "не может быть отрицательным, задано %d", c.Crash.Keep)
+	}
+	if c.Crash.Sentry.Enabled {
+		required("crash.sentry.dsn", c.Crash.Sentry.DSN)
+		if c.Crash.Sentry.DSN != "" {
+			if u, err := url.Parse(c.Crash.Sentry.DSN); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User.Username() == "" {
+				add("crash.sentry.dsn", "нужен DSN вида https://<ключ>@<хост>/<проект>")
+			}
+		}
+	}
+
+	// Бюджеты задержки
+	if c.Latency.Samples < 0 {
+		add("latency.samples", "не может быть отрицательным, задано %d", c.Latency.Samples)
+	}
+	for _, budget := range []struct {
+		path  string
+		value Duration
+	}{
+		{"latency.budgets.stored", c.Latency.Budgets.Stored},
+		{"latency.budgets.analyzed", c.Latency.Budgets.Analyzed},
+		{"latency.budgets.emitted", c.Latency.Budgets.Emitted},
+		{"latency.budgets.total", c.Latency.Budgets.Total},
+	} {
+		if budget.value < 0 {
+			add(budget.path, "не может быть отрицательным, задано %s", budget.value)
+		}
+	}
+
+	if c.Usage.Retention < 0 {
+		add("usage.retention", "не может быть отрицательным, задано %s", c.Usage.Retention)
+	}
+
+	// Эскалация сильных сигналов
+	if c.Escalation.Enabled {
+		if c.Escalation.MinStrength <= 0 || c.Escalation.MinStrength > 100 {
+			add("escalation.min_strength", "должно быть в диапазоне (0, 100], задано %v", c.Escalation.MinStrength)
+		}
+		if c.Escalation.Repeat < 0 {
+			add("escalation.repeat", "не может быть отрицательным, задано %s", c.Escalation.Repeat)
+		}
+		if c.Escalation.MaxRepeats < 0 {
+			add("escalation.max_repeats", "не может быть отрицательным, задано %d", c.Escalation.MaxRepeats)
+		}
+		if !c.Escalation.Pushover.Enabled && !c.Escalation.Twilio.Enabled {
+			add("escalation", "включите pushover или twilio")
+		}
+	}
+	if pushover := c.Escalation.Pushover; c.Escalation.Enabled && pushover.Enabled {
+		required("escalation.pushover.token", pushover.Token)
+		required("escalation.pushover.user", pushover.User)
+		if pushover.Retry != 0 && pushover.Retry < Duration(30*time.Second) {
+			add("escalation.pushover.retry", "Pushover принимает не меньше 30s, задано %s", pushover.Retry)
+		}
+		if pushover.Expire < 0 || pushover.Expire > Duration(3*time.Hour) {
+			add("escalation.pushover.expire", "должно быть в диапазоне 0..3h, задано %s", pushover.Expire)
+		}
+	}
+	if twilio := c.Escalation.Twilio; c.Escalation.Enabled && twilio.Enabled {
+		required("escalation.twilio.account_sid", twilio.AccountSID)
+		required("escalation.twilio.auth_token", twilio.AuthToken)
+		required("escalation.twilio.from", twilio.From)
+		if len(twilio.To) == 0 {
+			add("escalation.twilio.to", "укажите хотя бы один номер")
+		}
+	}
+
+	// Отчеты
+	if c.Reports.Enabled {
+		if c.Reports.Period != ReportDaily && c.Reports.Period != ReportWeekly {
+			add("reports.period", "допустимы %s и %s, задано %q", ReportDaily, ReportWeekly, c.Reports.Period)
+		}
+		if c.Reports.At != "" {
+			if _, err := parseClock(c.Reports.At); err != nil {
+				add("reports.at", "%v", err)
+			}
+		}
+		if _, ok := weekdays[strings.ToLower(c.Reports.Weekday)]; c.Reports.Weekday != "" && !ok {
+			add("reports.weekday", "неизвестный день %q, допустимы mon, tue, wed, thu, fri, sat, sun", c.Reports.Weekday)
+		}
+		if c.Reports.Format != ReportMarkdown && c.Reports.Format != ReportHTML {
+			add("reports.format", "допустимы %s и %s, задано %q", ReportMarkdown, ReportHTML, c.Reports.Format)
+		}
+		if c.Reports.Dir == "" && !c.Reports.Notify {
+			add("reports", "укажите dir или включите notify")
+		}
+		if c.Reports.Horizon < 0 {
+			add("reports.horizon", "не может быть отрицательным, задано %s", c.Reports.Horizon)
+		}
+		if c.Reports.AnomalyJump < 0 {
+			add("reports.anomaly_jump", "не может быть отрицательным, задано %v", c.Reports.AnomalyJump)
+		}
+	}
+
+	// Публикация MQTT
+	if c.MQTT.Enabled {
+		u, err := url.Parse(c.MQTT.Broker)
+		if err != nil || u.Host == "" || !slices.Contains([]string{"tcp", "mqtt", "tls", "ssl", "mqtts"}, u.Scheme) {
+			add("mqtt.broker", "нужен адрес tcp://host:1883 или tls://host:8883, задано %q", c.MQTT.Broker)
+		}
+		if c.MQTT.QoS != 0 && c.MQTT.QoS != 1 {
+			add("mqtt.qos", "поддерживаются 0 и 1, задано %d", c.MQTT.QoS)
+		}
+		if strings.ContainsAny(c.MQTT.TopicPrefix, "+#") {
+			add("mqtt.topic_prefix", "не может содержать символы подстановки + и #")
+		}
+	}
+
+	// Поток в брокер сообщений
+	if c.Stream.Enabled {
+		if c.Stream.Type != StreamKafka && c.Stream.Type != StreamNATS {
+			add("stream.type", "неизвестный брокер %q, поддерживаются %s и %s", c.Stream.Type, StreamKafka, StreamNATS)
+		}
+		if len(c.Stream.Brokers) == 0 {
+			add("stream.brokers", "укажите хотя бы один адрес host:port")
+		}
+		for i, broker := range c.Stream.Brokers {
+			if _, _, err := net.SplitHostPort(broker); err != nil {
+				add(fmt.Sprintf("stream.brokers[%d]", i), "нужен адрес host:port, задано %q", broker)
+			}
+		}
+		if c.Stream.Format != "" && c.Stream.Format != StreamFormatJSON && c.Stream.Format != StreamFormatProtobuf {
+			add("stream.format", "неизвестный формат %q, поддерживаются %s и %s", c.Stream.Format, StreamFormatJSON, StreamFormatProtobuf)
+		}
+		if c.Stream.JetStream && c.Stream.Type != StreamNATS {
+			add("stream.jetstream", "используется только с type: %s", StreamNATS)
+		}
+		if strings.ContainsAny(c.Stream.Topic, " *>") {
+			add("stream.topic", "не может содержать пробелы и символы подстановки * и >")
+		}
+	}
+
+	// Завершение работы
+	if c.Shutdown.Timeout < 0 {
+		add("shutdown.timeout", "не может быть отрицательным, задано %s", c.Shutdown.Timeout)
+	}
+
+	// Вывод сигналов
+	if !schema.Supported(c.Output.SchemaVersion) {
+		add("output.schema_version", "неподдерживаемая версия схемы %d, допустимы 0 (последняя) или 1..%d", c.Output.SchemaVersion, schema.Latest)
+	}
+
+	// Логирование
+	if l := c.Logging.Level; l != "" && !slices.Contains(knownLogLevels, l) {
+		add("logging.level", "неизвестный уровень %q, допустимы: %s", l, strings.Join(knownLogLevels, ", "))
+	}
+	for _, component := range slices.Sorted(maps.Keys(c.Logging.Levels)) {
+		if l := c.Logging.Levels[component]; !slices.Contains(knownLogLevels, l) {
+			add("logging.levels."+component, "неизвестный уровень %q, допустимы: %s", l, strings.Join(knownLogLevels, ", "))
+		}
+	}
+	if f := c.Logging.Format; f != "" && f != logger.FormatConsole && f != logger.FormatJSON {
+		add("logging.format", "неизвестный формат %q, допустимы: %s, %s", f, logger.FormatConsole, logger.FormatJSON)
+	}
+	if c.Logging.MaxSizeMB < 0 || c.Logging.RotateHours < 0 || c.Logging.MaxAgeDays < 0 || c.Logging.MaxBackups < 0 {
+		add("logging", "max_size_mb, rotate_hours, max_age_days и max_backups не могут быть отрицательными")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("ошибки в конфигурации (%d):\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+}
+
+// validateAnalysis проверяет настройки анализа; prefix - путь секции для сообщений
+func validateAnalysis(prefix string, a AnalysisConfig) []string {
+	var problems []string
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	positive := func(path string, value int) {
+		if value <= 0 {
+			add(path, "должно быть больше 0, задано %d", value)
+		}
+	}
+
+	if a.Period < Duration(time.Second) {
+		add(prefix+".period", "должно быть не меньше 1s, задано %s", a.Period)
+	}
+
+	weights := map[string]float64{
+		prefix + ".technical.weight":     a.Technical.Weight,
+		prefix + ".orderbook.weight":     a.OrderBook.Weight,
+		prefix + ".funding.weight":       a.Funding.Weight,
+		prefix + ".open_interest.weight": a.OpenInterest.Weight,
+		prefix + ".volume_delta.weight":  a.VolumeDelta.Weight,
+		prefix + ".external.weight":      a.External.Weight,
+	}
+	sum := 0.0
+	for _, path := range sortedKeys(weights) {
+		if weights[path] < 0 {
+			add(path, "вес не может быть отрицательным, задано %v", weights[path])
+		}
+		sum += weights[path]
+	}
+	if math.Abs(sum-1) > weightSumTolerance {
+		add(prefix+".*.weight", "сумма весов анализаторов должна быть равна 1, сейчас %.2f", sum)
+	}
+
+	positive(prefix+".technical.rsi_period", a.Technical.RSIPeriod)
+	positive(prefix+".technical.bb_period", a.Technical.BBPeriod)
+	positive(prefix+".technical.macd_fast", a.Technical.MACDFast)
+	positive(prefix+".technical.macd_slow", a.Technical.MACDSlow)
+	positive(prefix+".technical.macd_signal", a.Technical.MACDSignal)
+	if a.Technical.MACDFast >= a.Technical.MACDSlow {
+		add(prefix+".technical.macd_fast", "должно быть меньше macd_slow (%d), задано %d", a.Technical.MACDSlow, a.Technical.MACDFast)
+	}
+	if !slices.Contains(knownDepths, a.OrderBook.Depth) {
+		add(prefix+".orderbook.depth", "допустимая глубина стакана: %v, задано %d", knownDepths, a.OrderBook.Depth)
+	}
+	if a.OrderBook.Window < 0 || a.OrderBook.Window > Duration(time.Hour) {
+		add(prefix+".orderbook.window", "должно быть в диапазоне [0, 1h], задано %s", a.OrderBook.Window)
+	}
+	positive(prefix+".funding.periods", a.Funding.Periods)
+	positive(prefix+".open_interest.lookback", a.OpenInterest.Lookback)
+	positive(prefix+".volume_delta.lookback", a.VolumeDelta.Lookback)
+	if a.External.TTL < 0 {
+		add(prefix+".external.ttl", "не может быть отрицательным, задано %s", a.External.TTL)
+	}
+
+	t := a.SignalThresholds
+	if !(t.StrongBuy > t.Buy && t.Buy > t.Sell && t.Sell > t.StrongSell) {
+		add(prefix+".signal", "пороги должны убывать: threshold_strong_buy (%v) > threshold_buy (%v) > threshold_sell (%v) > threshold_strong_sell (%v)",
+			t.StrongBuy, t.Buy, t.Sell, t.StrongSell)
+	}
+
+	switch a.Bias.Direction {
+	case "", BiasBoth, BiasLongOnly, BiasShortOnly:
+	default:
+		add(prefix+".bias.direction", "допустимы %s, %s, %s, задано %q", BiasBoth, BiasLongOnly, BiasShortOnly, a.Bias.Direction)
+	}
+	if a.Bias.MaxStrength < 0 || a.Bias.MaxStrength > 100 {
+		add(prefix+".bias.max_strength", "должно быть в диапазоне [0, 100], задано %v", a.Bias.MaxStrength)
+	}
+
+	return problems
+}
+
+// listenField адрес сервера и параметр конфигурации, в котором он задан
+type listenField struct {
+	path string
+	addr string
+}
+
+// listenConflict сообщает, что два адреса host:port нельзя слушать одновременно:
+// порт один и тот же, а хосты совпадают или один из них - все интерфейсы
+// (":8090", "0.0.0.0:8090", "[::]:8090"). Порт 0 выбирается системой и не совпадает.
+func listenConflict(a, b string) bool {
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil || portA != portB || portA == "0" {
+		return false
+	}
+	hostA, hostB = listenHost(hostA), listenHost(hostB)
+	return hostA == "" || hostB == "" || hostA == hostB
+}
+
+// listenHost приводит хост адреса к виду для сравнения: все интерфейсы - пустая
+// строка, localhost - 127.0.0.1, IP-адрес - каноническая запись
+func listenHost(host string) string {
+	if strings.EqualFold(host, "localhost") {
+		return "127.0.0.1"
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsUnspecified() {
+			return ""
+		}
+		return ip.String()
+	}
+	return strings.ToLower(host)
+}
+
+// sortedFlags возвращает имена флагов в алфавитном порядке
+func sortedFlags(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// sortedKeys возвращает ключи в алфавитном порядке
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Пауза после уведомления об изменении файла: редакторы сохраняют файл в несколько
+// записей или через переименование временного файла
+const settleDelay = 200 * time.Millisecond
+
+// Watcher следит за файлами конфигурации через уведомления файловой системы (fsnotify)
+// и перечитывает их при изменении или по запросу (например, по SIGHUP). Удаленные
+// источники опрашиваются периодически.
+type Watcher struct {
+	path    string
+	opts    LoadOptions
+	loaded  string // Отпечаток конфигурации, как она прочитана из источников последний раз
+	remote  bool   // Среди источников есть удаленные
+	reloadC chan struct{}
+}
+
+// NewWatcher создает наблюдателя за файлами конфигурации. loaded - конфигурация сразу
+// после Load, до изменений флагами командной строки и состоянием интерфейса: с ней
+// сравниваются перечитанные файлы, поэтому изменения, внесенные самим приложением,
+// не считаются правкой конфигурации.
+func NewWatcher(path string, opts LoadOptions, loaded *Config) *Watcher {
+	w := &Watcher{
+		path:    path,
+		opts:    opts,
+		loaded:  Hash(loaded),
+		reloadC: make(chan struct{}, 1),
+	}
+	for _, source := range append([]string{path}, opts.Overlays...) {
+		w.remote = w.remote || IsRemote(source)
+	}
+	return w
+}
+
+// Reload запрашивает немедленное перечитывание файла
+func (w *Watcher) Reload() {
+	select {
+	case w.reloadC <- struct{}{}:
+	default:
+	}
+}
+
+// Start запускает наблюдение до отмены контекста. onChange вызывается из горутины
+// наблюдателя с новой конфигурацией, если она отличается от прочитанной прежде.
+// Если уведомления файловой системы недоступны, файлы перечитываются только по Reload.
+func (w *Watcher) Start(ctx context.Context, onChange func(next *Config)) {
+	var eventsC <-chan fsnotify.Event
+	var errorsC <-chan error
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Warn("Уведомления об изменении файлов конфигурации недоступны, изменения применяются по SIGHUP", zap.Error(err))
+	} else {
+		defer notifier.Close()
+		for _, dir := range w.dirs() {
+			if err := notifier.Add(dir); err != nil {
+				logger.Warn("Ошибка наблюдения за каталогом конфигурации", zap.String("dir", dir), zap.Error(err))
+			}
+		}
+		eventsC, errorsC = notifier.Events, notifier.Errors
+	}
+
+	// Без удаленных источников канал опроса остается nil и не срабатывает
+	var remoteC <-chan time.Time
+	if w.remote {
+		remoteTicker := time.NewTicker(remoteInterval)
+		defer remoteTicker.Stop()
+		remoteC = remoteTicker.C
+	}
+
+	// Файл перечитывается, когда уведомления о нем стихли на settleDelay
+	var settleC <-chan time.Time
+	for {
+		select {
+		case event := <-eventsC:
+			if w.relevant(event.Name) {
+				settleC = time.After(settleDelay)
+			}
+		case err := <-errorsC:
+			logger.Warn("Ошибка наблюдения за файлами конфигурации", zap.Error(err))
+		case <-settleC:
+			settleC = nil
+			w.reload(onChange)
+		case <-remoteC:
+			w.reload(onChange)
+		case <-w.reloadC:
+			w.reload(onChange)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dirs возвращает каталоги локальных файлов конфигурации. Наблюдение идет за каталогами,
+// а не за файлами: при сохранении через переименование файл заменяется новым.
+func (w *Watcher) dirs() []string {
+	var dirs []string
+	add := func(dir string) {
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if !IsRemote(w.path) && BasePath(w.path) != w.path {
+		add(filepath.Clean(w.path))
+	}
+	for _, file := range sourceFiles(w.path, w.opts.Overlays) {
+		if !IsRemote(file) {
+			add(filepath.Dir(filepath.Clean(file)))
+		}
+	}
+	return dirs
+}
+
+// relevant сообщает, относится ли уведомление о файле name к конфигурации: это один
+// из ее файлов, новый файл *.yaml в каталоге конфигурации или подмена каталога ..data,
+// которой Kubernetes обновляет ConfigMap
+func (w *Watcher) relevant(name string) bool {
+	name = filepath.Clean(name)
+	if strings.HasPrefix(filepath.Base(name), "..") {
+		return true
+	}
+	if BasePath(w.path) != w.path && filepath.Dir(name) == filepath.Clean(w.path) && filepath.Ext(name) == ".yaml" {
+		return true
+	}
+	for _, file := range sourceFiles(w.path, w.opts.Overlays) {
+		if filepath.Clean(file) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reload перечитывает файлы; при ошибке продолжаем работать с прежней конфигурацией
+func (w *Watcher) reload(onChange func(next *Config)) {
+	next, err := Load(w.path, w.opts)
+	if err != nil {
+		logger.Error("Ошибка перезагрузки конфигурации, изменения не применены", zap.Error(err))
+		return
+	}
+	loaded := Hash(next)
+	if loaded == w.loaded {
+		return
+	}
+
+	w.loaded = loaded
+	logger.Info("Конфигурация изменена", zap.String("path", w.path))
+	onChange(next)
+}
+
+// RestartRequired возвращает секции, изменения которых применяются только после перезапуска
+func RestartRequired(prev, next *Config) []string {
+	var sections []string
+	if !reflect.DeepEqual(prev.Binance, next.Binance) {
+		sections = append(sections, "binance")
+	}
+	if !reflect.DeepEqual(prev.Storage, next.Storage) {
+		sections = append(sections, "storage")
+	}
+	if !reflect.DeepEqual(prev.State, next.State) {
+		sections = append(sections, "state")
+	}
+	if !reflect.DeepEqual(prev.Account, next.Account) {
+		sections = append(sections, "account")
+	}
+	if !reflect.DeepEqual(prev.Execution, next.Execution) || prev.Trading.RiskPerTrade != next.Trading.RiskPerTrade {
+		sections = append(sections, "execution")
+	}
+	if prev.Risk != next.Risk {
+		sections = append(sections, "risk")
+	}
+	// Уровни логирования применяются без перезапуска
+	prevLogging, nextLogging := prev.Logging, next.Logging
+	prevLogging.Level, prevLogging.Levels = "", nil
+	nextLogging.Level, nextLogging.Levels = "", nil
+	if !reflect.DeepEqual(prevLogging, nextLogging) {
+		sections = append(sections, "logging")
+	}
+	if prev.Admin != next.Admin {
+		sections = append(sections, "admin")
+	}
+	if !reflect.DeepEqual(prev.API, next.API) {
+		sections = append(sections, "api")
+	}
+	if prev.TradingView != next.TradingView {
+		sections = append(sections, "tradingview")
+	}
+	if !reflect.DeepEqual(prev.Telegram, next.Telegram) {
+		sections = append(sections, "telegram")
+	}
+	if prev.Discord != next.Discord {
+		sections = append(sections, "discord")
+	}
+	if !reflect.DeepEqual(prev.Webhooks, next.Webhooks) {
+		sections = append(sections, "webhooks")
+	}
+	if !reflect.DeepEqual(prev.Email, next.Email) {
+		sections = append(sections, "email")
+	}
+	if prev.MQTT != next.MQTT {
+		sections = append(sections, "mqtt")
+	}
+	if !reflect.DeepEqual(prev.Stream, next.Stream) {
+		sections = append(sections, "stream")
+	}
+	if prev.Desktop != next.Desktop {
+		sections = append(sections, "desktop")
+	}
+	if !reflect.DeepEqual(prev.Bridges, next.Bridges) {
+		sections = append(sections, "bridges")
+	}
+	if prev.Incidents != next.Incidents {
+		sections = append(sections, "incidents")
+	}
+	if prev.Watchdog != next.Watchdog {
+		sections = append(sections, "watchdog")
+	}
+	if prev.Scanner != next.Scanner {
+		sections = append(sections, "scanner")
+	}
+	if prev.Crash != next.Crash {
+		sections = append(sections, "crash")
+	}
+	if !reflect.DeepEqual(prev.Escalation, next.Escalation) {
+		sections = append(sections, "escalation")
+	}
+	if prev.Reports != next.Reports {
+		sections = append(sections, "reports")
+	}
+	if !reflect.DeepEqual(prev.Features, next.Features) {
+		sections = append(sections, "features")
+	}
+	return sections
+}
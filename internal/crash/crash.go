@@ -0,0 +1,238 @@
+// Package crash оформляет падения bfma в отчеты: стеки всех горутин, последние события
+// журнала, отпечаток конфигурации и версии. Паника, перехваченная Recover, записывается
+// сразу; паника в другой горутине попадает в файл вывода аварийного завершения и
+// оформляется в отчет при следующем запуске. Отчеты хранятся в каталоге и, если
+// настроено, отправляются в Sentry.
+package crash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/internal/version"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию
+const (
+	defaultEvents = 100
+	defaultKeep   = 20
+	outputName    = "crash.out" // Файл вывода аварийного завершения среды Go
+	sendTimeout   = 10 * time.Second
+)
+
+// Report отчет о падении
+type Report struct {
+	Time       time.Time       `json:"time"`
+	Panic      string          `json:"panic"`
+	Recovered  bool            `json:"recovered"` // false - оформлен при следующем запуске из вывода среды Go
+	Stacks     string          `json:"stacks"`
+	Events     []*models.Event `json:"events,omitempty"`
+	ConfigHash string          `json:"config_hash,omitempty"`
+	Version    string          `json:"version"`
+	Commit     string          `json:"commit"`
+	BuildDate  string          `json:"build_date"`
+	GoVersion  string          `json:"go_version"`
+	OS         string          `json:"os"`
+	Arch       string          `json:"arch"`
+	Host       string          `json:"host,omitempty"`
+}
+
+var (
+	mutex      sync.Mutex
+	cfg        config.CrashConfig
+	dir        string // Пусто - отчеты не пишутся (Setup не вызывался)
+	configHash string
+	output     *os.File
+)
+
+// Setup готовит каталог отчетов, оформляет падение предыдущего запуска, если оно было,
+// и направляет вывод аварийного завершения среды Go в файл каталога
+func Setup(crashCfg config.CrashConfig, stateDir, hash string) error {
+	target := crashCfg.Dir
+	if target == "" {
+		target = filepath.Join(stateDir, "crash")
+	}
+	if err := os.MkdirAll(target, 0700); err != nil {
+		return fmt.Errorf("ошибка создания каталога отчетов о падениях: %w", err)
+	}
+
+	mutex.Lock()
+	cfg, dir, configHash = crashCfg, target, hash
+	mutex.Unlock()
+
+	path := filepath.Join(target, outputName)
+	if data, err := os.ReadFile(path); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		report := newReport(firstLine(string(data)), string(data), false)
+		// События и конфигурация текущего запуска к прошлому падению не относятся
+		report.Events, report.ConfigHash = nil, ""
+		if file, err := save(report); err != nil {
+			logger.Error("Ошибка записи отчета о падении предыдущего запуска", zap.Error(err))
+		} else {
+			logger.Warn("Предыдущий запуск завершился аварийно, сохранен отчет", zap.String("path", file))
+		}
+		send(report)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла вывода аварийного завершения: %w", err)
+	}
+	if err := debug.SetCrashOutput(file, debug.CrashOptions{}); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка настройки вывода аварийного завершения: %w", err)
+	}
+	mutex.Lock()
+	if output != nil {
+		output.Close()
+	}
+	output = file
+	mutex.Unlock()
+	return nil
+}
+
+// SetConfigHash обновляет отпечаток конфигурации после ее перезагрузки
+func SetConfigHash(hash string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	configHash = hash
+}
+
+// Recover перехватывает панику, записывает отчет и продолжает панику, чтобы процесс
+// завершился как обычно. Вызывается через defer в начале горутины.
+func Recover() {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	mutex.Lock()
+	configured := dir != ""
+	mutex.Unlock()
+	if configured {
+		report := newReport(fmt.Sprint(value), allStacks(), true)
+		if file, err := save(report); err != nil {
+			logger.Error("Ошибка записи отчета о падении", zap.Error(err))
+		} else {
+			logger.Error("Паника, сохранен отчет о падении", zap.String("panic", report.Panic), zap.String("path", file))
+		}
+		send(report)
+		logger.GetLogger().Sync()
+
+		// Отчет уже записан: вывод среды Go не должен оформиться в отчет повторно
+		debug.SetCrashOutput(nil, debug.CrashOptions{})
+	}
+	panic(value)
+}
+
+// newReport собирает отчет с последними событиями, отпечатком конфигурации и версиями
+func newReport(panicValue, stacks string, recovered bool) *Report {
+	mutex.Lock()
+	limit := orDefault(cfg.Events, defaultEvents)
+	hash := configHash
+	mutex.Unlock()
+
+	host, _ := os.Hostname()
+	return &Report{
+		Time:       time.Now(),
+		Panic:      panicValue,
+		Recovered:  recovered,
+		Stacks:     stacks,
+		Events:     events.Recent(limit),
+		ConfigHash: hash,
+		Version:    version.Version,
+		Commit:     version.Commit,
+		BuildDate:  version.BuildDate,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Host:       host,
+	}
+}
+
+// save записывает отчет в каталог и удаляет старые отчеты сверх crash.keep
+func save(report *Report) (string, error) {
+	mutex.Lock()
+	target, keep := dir, orDefault(cfg.Keep, defaultKeep)
+	mutex.Unlock()
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(target, "crash-"+report.Time.UTC().Format("20060102T150405.000Z")+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+
+	files, err := filepath.Glob(filepath.Join(target, "crash-*.json"))
+	if err == nil && len(files) > keep {
+		// Имена содержат время, поэтому сортировка по имени - по времени
+		sort.Strings(files)
+		for _, old := range files[:len(files)-keep] {
+			os.Remove(old)
+		}
+	}
+	return path, nil
+}
+
+// send отправляет отчет в Sentry, если она включена
+func send(report *Report) {
+	mutex.Lock()
+	sentryCfg := cfg.Sentry
+	mutex.Unlock()
+	if !sentryCfg.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := sendSentry(ctx, sentryCfg, report); err != nil {
+		logger.Error("Ошибка отправки отчета о падении в Sentry", zap.Error(err))
+		return
+	}
+	logger.Info("Отчет о падении отправлен в Sentry")
+}
+
+// allStacks возвращает стеки всех горутин
+func allStacks() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// firstLine возвращает первую непустую строку вывода: сообщение паники
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func orDefault(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
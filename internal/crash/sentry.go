@@ -0,0 +1,108 @@
+package crash
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Сколько байт ответа Sentry с ошибкой попадает в журнал
+const maxErrorBody = 512
+
+// sentryEvent событие Sentry; стеки передаются текстом в extra
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	Release     string                 `json:"release"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Message     string                 `json:"message"`
+	Tags        map[string]string      `json:"tags"`
+	Extra       map[string]interface{} `json:"extra"`
+}
+
+// sendSentry отправляет отчет конвертом (envelope) в Sentry по DSN
+func sendSentry(ctx context.Context, cfg config.SentryConfig, report *Report) error {
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil {
+		return fmt.Errorf("неверный DSN: %w", err)
+	}
+	key := dsn.User.Username()
+	path := strings.TrimSuffix(dsn.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if key == "" || project == "" {
+		return fmt.Errorf("в DSN нет ключа или проекта")
+	}
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/envelope/", dsn.Scheme, dsn.Host, path[:max(0, slash)], project)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	event := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   report.Time.UTC().Format(time.RFC3339),
+		Platform:    "go",
+		Level:       "fatal",
+		Logger:      "bfma",
+		Release:     "bfma@" + report.Version,
+		Environment: cfg.Environment,
+		ServerName:  report.Host,
+		Message:     "panic: " + report.Panic,
+		Tags: map[string]string{
+			"commit":    report.Commit,
+			"go":        report.GoVersion,
+			"os":        report.OS + "/" + report.Arch,
+			"recovered": fmt.Sprint(report.Recovered),
+		},
+		Extra: map[string]interface{}{
+			"stacks":      report.Stacks,
+			"events":      report.Events,
+			"config_hash": report.ConfigHash,
+			"build_date":  report.BuildDate,
+		},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// Конверт: заголовок, заголовок элемента и само событие, по строке JSON
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(map[string]string{"event_id": event.EventID, "dsn": cfg.DSN})
+	json.NewEncoder(&body).Encode(map[string]interface{}{"type": "event", "length": len(payload)})
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=bfma/%s", key, report.Version))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("ответ %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Package desktop показывает уведомления рабочего стола о сильных сигналах при
+// запуске bfma на рабочей станции: notify-send в Linux, terminal-notifier или
+// osascript в macOS, всплывающие уведомления PowerShell в Windows.
+package desktop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Параметры уведомлений
+const (
+	queueSize   = 32               // Уведомлений в очереди; при переполнении новые отбрасываются
+	showTimeout = 10 * time.Second // Время на запуск утилиты уведомлений
+	actionOpen  = "open"           // Действие уведомления notify-send, открывающее график
+)
+
+// notification уведомление в очереди
+type notification struct {
+	symbol string
+	text   string
+}
+
+// Notifier показывает уведомления рабочего стола о важных оповещениях
+type Notifier struct {
+	cfg     config.DesktopConfig
+	open    func(symbol string) // Открывает график истории символа в интерфейсе
+	queue   chan notification
+	actions bool // notify-send поддерживает кнопки (--action)
+	once    sync.Once
+}
+
+// NewNotifier создает уведомитель. open вызывается, когда пользователь нажимает
+// кнопку уведомления (только Linux); может быть nil.
+func NewNotifier(cfg config.DesktopConfig, open func(symbol string)) *Notifier {
+	return &Notifier{cfg: cfg, open: open, queue: make(chan notification, queueSize)}
+}
+
+// Start показывает уведомления из очереди до отмены контекста
+func (n *Notifier) Start(ctx context.Context) {
+	logger.Info("Запуск уведомлений рабочего стола", zap.String("os", runtime.GOOS))
+	for {
+		select {
+		case msg := <-n.queue:
+			if err := n.show(ctx, msg); err != nil {
+				logger.Warn("Ошибка показа уведомления рабочего стола", zap.String("symbol", msg.symbol), zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PublishAlert ставит в очередь важное оповещение (сильный сигнал); остальные
+// оповещения остаются в панели. Не блокирует: при переполнении очереди
+// уведомление отбрасывается.
+func (n *Notifier) PublishAlert(symbol, text string, critical bool) {
+	if !critical {
+		return
+	}
+	select {
+	case n.queue <- notification{symbol: symbol, text: text}:
+	default:
+		logger.Warn("Очередь уведомлений рабочего стола переполнена, уведомление пропущено", zap.String("symbol", symbol))
+	}
+}
+
+// show показывает уведомление средствами операционной системы
+func (n *Notifier) show(ctx context.Context, msg notification) error {
+	title := "bfma: " + msg.symbol
+	body := msg.text + "\n" + instructions(msg.symbol)
+
+	switch runtime.GOOS {
+	case "darwin":
+		return n.showDarwin(ctx, title, body, msg.symbol)
+	case "windows":
+		return n.showWindows(ctx, title, body)
+	default:
+		return n.showLinux(ctx, title, body, msg.symbol)
+	}
+}
+
+// showLinux показывает уведомление через notify-send. Если notify-send поддерживает
+// кнопки, уведомление получает кнопку "Открыть график": notify-send ждет нажатия
+// в отдельной горутине и выводит имя действия, после чего график открывается в интерфейсе.
+func (n *Notifier) showLinux(ctx context.Context, title, body, symbol string) error {
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return fmt.Errorf("не найдена утилита notify-send (пакет libnotify-bin или libnotify): %w", err)
+	}
+	n.once.Do(func() {
+		out, _ := exec.CommandContext(ctx, "notify-send", "--help").Output()
+		n.actions = strings.Contains(string(out), "--action")
+	})
+
+	args := []string{"--app-name=bfma", "--urgency=critical"}
+	if !n.cfg.Sound {
+		args = append(args, "--hint=boolean:suppress-sound:true")
+	}
+	if !n.actions || n.open == nil {
+		return run(ctx, "notify-send", append(args, title, body)...)
+	}
+
+	args = append(args, "--action="+actionOpen+"=Открыть график", "--wait", title, body)
+	go func() {
+		out, err := exec.CommandContext(ctx, "notify-send", args...).Output()
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("Ошибка показа уведомления рабочего стола", zap.String("symbol", symbol), zap.Error(err))
+			}
+			return
+		}
+		if strings.TrimSpace(string(out)) == actionOpen {
+			n.open(symbol)
+		}
+	}()
+	return nil
+}
+
+// showDarwin показывает уведомление через terminal-notifier, если он установлен
+// (нажатие переводит фокус на терминал с bfma), иначе через osascript
+func (n *Notifier) showDarwin(ctx context.Context, title, body, symbol string) error {
+	if _, err := exec.LookPath("terminal-notifier"); err == nil {
+		args := []string{"-title", title, "-message", body, "-group", "bfma." + symbol}
+		if n.cfg.Sound {
+			args = append(args, "-sound", "default")
+		}
+		if bundle := terminalBundle(); bundle != "" {
+			args = append(args, "-activate", bundle)
+		}
+		return run(ctx, "terminal-notifier", args...)
+	}
+
+	// Текст передается через переменные окружения, чтобы не экранировать его в AppleScript
+	script := `display notification (system attribute "BFMA_BODY") with title (system attribute "BFMA_TITLE")`
+	if n.cfg.Sound {
+		script += ` sound name "default"`
+	}
+	return runEnv(ctx, []string{"BFMA_TITLE=" + title, "BFMA_BODY=" + body}, "osascript", "-e", script)
+}
+
+// Уведомление Windows через WinRT от имени PowerShell: своего идентификатора
+// приложения у bfma нет
+const windowsToastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName('text')
+$texts.Item(0).AppendChild($template.CreateTextNode($env:BFMA_TITLE)) | Out-Null
+$texts.Item(1).AppendChild($template.CreateTextNode($env:BFMA_BODY)) | Out-Null
+if ($env:BFMA_SILENT -eq '1') {
+  $audio = $template.CreateElement('audio')
+  $audio.SetAttribute('silent', 'true')
+  $template.DocumentElement.AppendChild($audio) | Out-Null
+}
+$app = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($app).Show([Windows.UI.Notifications.ToastNotification]::new($template))
+`
+
+// showWindows показывает всплывающее уведомление Windows
+func (n *Notifier) showWindows(ctx context.Context, title, body string) error {
+	silent := "0"
+	if !n.cfg.Sound {
+		silent = "1"
+	}
+	env := []string{"BFMA_TITLE=" + title, "BFMA_BODY=" + body, "BFMA_SILENT=" + silent}
+	return runEnv(ctx, env, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+}
+
+// instructions подсказывает, как открыть график символа
+func instructions(symbol string) string {
+	return fmt.Sprintf("График: выберите %s в bfma и нажмите H или запустите bfma watch %s", symbol, symbol)
+}
+
+// terminalBundle возвращает идентификатор приложения терминала macOS, в котором запущен bfma
+func terminalBundle() string {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "Apple_Terminal":
+		return "com.apple.Terminal"
+	case "iTerm.app":
+		return "com.googlecode.iterm2"
+	case "WezTerm":
+		return "com.github.wez.wezterm"
+	case "vscode":
+		return "com.microsoft.VSCode"
+	}
+	return ""
+}
+
+// run запускает утилиту уведомлений
+func run(ctx context.Context, name string, args ...string) error {
+	return runEnv(ctx, nil, name, args...)
+}
+
+// runEnv запускает утилиту уведомлений с дополнительными переменными окружения
+func runEnv(ctx context.Context, env []string, name string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, showTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("ошибка %s: %s", name, msg)
+		}
+		return fmt.Errorf("ошибка запуска %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Package discord отправляет оповещения в канал Discord через вебхук канала,
+// при необходимости с графиком символа во вложении.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/chart"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Параметры отправки
+const (
+	queueSize        = 64               // Оповещений в очереди; при переполнении новые отбрасываются
+	sendTimeout      = 20 * time.Second // Время на отправку одного оповещения
+	maxContentLength = 2000             // Наибольшая длина текста сообщения Discord
+	maxErrorBody     = 512              // Сколько байт ответа с ошибкой попадает в журнал
+)
+
+// SignalSource источник последних сигналов для текста оповещения
+type SignalSource interface {
+	LatestSignals() map[string]*models.SignalResult
+}
+
+// ChartRenderer рисует график символа для оповещения
+type ChartRenderer interface {
+	Render(ctx context.Context, symbol string) (*chart.Snapshot, error)
+}
+
+// notification оповещение в очереди отправки
+type notification struct {
+	symbol   string
+	text     string
+	critical bool
+}
+
+// message тело сообщения вебхука
+type message struct {
+	Content     string       `json:"content"`
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+// attachment описание вложения; id совпадает с номером в имени части files[n]
+type attachment struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+}
+
+// Notifier отправляет оповещения в Discord
+type Notifier struct {
+	cfg    config.DiscordConfig
+	source SignalSource
+	charts ChartRenderer
+	client *http.Client
+	queue  chan notification
+}
+
+// NewNotifier создает отправку оповещений. charts рисует графики при включенном discord.charts.
+func NewNotifier(cfg config.DiscordConfig, source SignalSource, charts ChartRenderer) *Notifier {
+	return &Notifier{
+		cfg:    cfg,
+		source: source,
+		charts: charts,
+		client: &http.Client{Timeout: sendTimeout},
+		queue:  make(chan notification, queueSize),
+	}
+}
+
+// Start отправляет оповещения из очереди до отмены контекста
+func (n *Notifier) Start(ctx context.Context) {
+	logger.Info("Запуск оповещений Discord", zap.Bool("charts", n.cfg.Charts))
+	for {
+		select {
+		case msg := <-n.queue:
+			if err := n.send(ctx, msg); err != nil {
+				logger.Warn("Ошибка отправки оповещения в Discord", zap.String("symbol", msg.symbol), zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PublishAlert ставит оповещение в очередь. Не блокирует: при переполнении очереди
+// оповещение отбрасывается.
+func (n *Notifier) PublishAlert(symbol, text string, critical bool) {
+	select {
+	case n.queue <- notification{symbol: symbol, text: text, critical: critical}:
+	default:
+		logger.Warn("Очередь оповещений Discord переполнена, оповещение пропущено", zap.String("symbol", symbol))
+	}
+}
+
+// send отправляет оповещение с кратким описанием сигнала и графиком
+func (n *Notifier) send(ctx context.Context, msg notification) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	text := "**" + msg.symbol + "**: " + msg.text
+	if msg.critical {
+		text = "❗ " + text
+	}
+	signal := n.source.LatestSignals()[msg.symbol]
+	if signal != nil {
+		text += fmt.Sprintf("\n%s, сила %.1f, цена %s", signal.Recommendation, signal.SignalStrength,
+			strconv.FormatFloat(signal.CurrentPrice, 'f', -1, 64))
+	}
+
+	var snapshot *chart.Snapshot
+	if signal != nil && n.cfg.Charts {
+		var err error
+		snapshot, err = n.charts.Render(ctx, msg.symbol)
+		if err != nil && !errors.Is(err, chart.ErrNotEnoughData) {
+			logger.Warn("Ошибка построения графика", zap.String("symbol", msg.symbol), zap.Error(err))
+		}
+	}
+	if snapshot == nil {
+		return n.post(ctx, message{Content: truncate(text)}, nil)
+	}
+	text += "\n" + snapshot.Describe()
+	return n.post(ctx, message{Content: truncate(text), Attachments: []attachment{{ID: 0, Filename: "chart.png"}}}, snapshot.PNG)
+}
+
+// post выполняет запрос к вебхуку: JSON или multipart с изображением
+func (n *Notifier) post(ctx context.Context, msg message, image []byte) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	body, contentType := io.Reader(bytes.NewReader(payload)), "application/json"
+	if image != nil {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		w.WriteField("payload_json", string(payload))
+		part, err := w.CreateFormFile("files[0]", "chart.png")
+		if err != nil {
+			return err
+		}
+		part.Write(image)
+		if err := w.Close(); err != nil {
+			return err
+		}
+		body, contentType = &buf, w.FormDataContentType()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.WebhookURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// Адрес вебхука содержит токен, поэтому в ошибку попадает только причина
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("ответ %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// truncate обрезает текст до длины, которую принимает Discord
+func truncate(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxContentLength {
+		return text
+	}
+	return string(runes[:maxContentLength-1]) + "…"
+}
@@ -0,0 +1,264 @@
+// Package email отправляет оповещения по SMTP: каждое оповещение отдельным письмом
+// или сводкой за период (digest).
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры отправки
+const (
+	defaultPort  = 587              // Порт SMTP с STARTTLS
+	implicitTLS  = 465              // Порт SMTP с TLS с начала соединения
+	sendTimeout  = 30 * time.Second // Время на отправку одного письма
+	queueSize    = 64               // Писем в очереди; при переполнении новые отбрасываются
+	maxDigestLen = 500              // Оповещений в одной сводке; более ранние отбрасываются
+)
+
+// alert оповещение для письма
+type alert struct {
+	time     time.Time
+	symbol   string
+	text     string
+	critical bool
+}
+
+// Mailer отправитель оповещений по SMTP
+type Mailer struct {
+	cfg     config.EmailConfig
+	queue   chan []alert // Письма: одно оповещение или сводка
+	pending []alert      // Оповещения текущей сводки
+	dropped int          // Сколько оповещений не поместилось в сводку
+	mutex   sync.Mutex
+	done    chan struct{} // Закрывается после остановки Start
+}
+
+// NewMailer создает отправителя
+func NewMailer(cfg config.EmailConfig) *Mailer {
+	return &Mailer{cfg: cfg, queue: make(chan []alert, queueSize), done: make(chan struct{})}
+}
+
+// Start отправляет письма до отмены контекста. Накопленная сводка отправляется
+// и при завершении работы.
+func (m *Mailer) Start(ctx context.Context) {
+	defer close(m.done)
+	logger.Info("Запуск оповещений по почте", zap.Strings("to", m.cfg.To), zap.Duration("digest", m.cfg.Digest.Std()))
+
+	var tick <-chan time.Time
+	if m.cfg.Digest > 0 {
+		ticker := time.NewTicker(m.cfg.Digest.Std())
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case alerts := <-m.queue:
+			m.send(ctx, alerts)
+		case <-tick:
+			m.flush(ctx)
+		case <-ctx.Done():
+			// Контекст уже отменен, сводке дается отдельное время на отправку
+			m.flush(context.Background())
+			return
+		}
+	}
+}
+
+// Stop ждет отправки последней сводки после отмены контекста Start
+func (m *Mailer) Stop() {
+	<-m.done
+}
+
+// PublishAlert отправляет оповещение письмом или добавляет его в сводку. Не блокирует.
+func (m *Mailer) PublishAlert(symbol, text string, critical bool) {
+	a := alert{time: time.Now(), symbol: symbol, text: text, critical: critical}
+	if m.cfg.Digest > 0 {
+		m.mutex.Lock()
+		m.pending = append(m.pending, a)
+		if len(m.pending) > maxDigestLen {
+			m.dropped += len(m.pending) - maxDigestLen
+			m.pending = m.pending[len(m.pending)-maxDigestLen:]
+		}
+		m.mutex.Unlock()
+		return
+	}
+
+	select {
+	case m.queue <- []alert{a}:
+	default:
+		logger.Warn("Очередь писем переполнена, оповещение пропущено", zap.String("symbol", symbol))
+	}
+}
+
+// flush отправляет накопленную сводку
+func (m *Mailer) flush(ctx context.Context) {
+	m.mutex.Lock()
+	alerts, dropped := m.pending, m.dropped
+	m.pending, m.dropped = nil, 0
+	m.mutex.Unlock()
+
+	if len(alerts) == 0 {
+		return
+	}
+	if dropped > 0 {
+		logger.Warn("Сводка оповещений переполнена, ранние оповещения не вошли в письмо", zap.Int("dropped", dropped))
+	}
+	m.send(ctx, alerts)
+}
+
+// send формирует и отправляет письмо
+func (m *Mailer) send(ctx context.Context, alerts []alert) {
+	subject, body := compose(alerts)
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	if err := m.deliver(ctx, m.message(subject, body)); err != nil {
+		logger.Warn("Ошибка отправки письма", zap.String("host", m.cfg.Host), zap.Int("alerts", len(alerts)), zap.Error(err))
+	}
+}
+
+// compose возвращает тему и текст письма: одно оповещение или сводку
+func compose(alerts []alert) (string, string) {
+	if len(alerts) == 1 {
+		a := alerts[0]
+		subject := "bfma: " + a.symbol + ": " + a.text
+		if a.critical {
+			subject = "bfma ❗ " + a.symbol + ": " + a.text
+		}
+		return subject, timezone.In(a.time).Format("2006-01-02 15:04:05") + " " + a.symbol + ": " + a.text + "\n"
+	}
+
+	critical := 0
+	var sb strings.Builder
+	for _, a := range alerts {
+		mark := "  "
+		if a.critical {
+			mark = "! "
+			critical++
+		}
+		fmt.Fprintf(&sb, "%s%s %s: %s\n", mark, timezone.In(a.time).Format("2006-01-02 15:04:05"), a.symbol, a.text)
+	}
+	subject := fmt.Sprintf("bfma: сводка, оповещений %d", len(alerts))
+	if critical > 0 {
+		subject += fmt.Sprintf(", важных %d", critical)
+	}
+	return subject, sb.String()
+}
+
+// message формирует письмо в UTF-8: тема в кодировке MIME, текст в quoted-printable
+func (m *Mailer) message(subject, body string) []byte {
+	var buf bytes.Buffer
+	to := make([]string, len(m.cfg.To))
+	for i, addr := range m.cfg.To {
+		to[i] = header(addr)
+	}
+	fmt.Fprintf(&buf, "From: %s\r\n", header(m.cfg.From))
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	qp.Close()
+	return buf.Bytes()
+}
+
+// deliver передает письмо серверу SMTP. На порту 465 соединение шифруется сразу,
+// на остальных - командой STARTTLS, если сервер ее поддерживает.
+func (m *Mailer) deliver(ctx context.Context, msg []byte) error {
+	port := m.cfg.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if port == implicitTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && port != implicitTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("ошибка STARTTLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("ошибка авторизации: %w", err)
+		}
+	}
+
+	if err := client.Mail(address(m.cfg.From)); err != nil {
+		return err
+	}
+	for _, to := range m.cfg.To {
+		if err := client.Rcpt(address(to)); err != nil {
+			return fmt.Errorf("получатель %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// address возвращает адрес из записи вида "bfma <bot@example.com>"
+func address(value string) string {
+	parsed, err := mail.ParseAddress(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return parsed.Address
+}
+
+// header возвращает адрес для заголовка письма; имя в UTF-8 кодируется по RFC 2047
+func header(value string) string {
+	parsed, err := mail.ParseAddress(value)
+	if err != nil {
+		return value
+	}
+	return parsed.String()
+}
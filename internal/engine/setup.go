@@ -0,0 +1,39 @@
+// Package engine связывает bfma run с движком pkg/bfma: cmd/bfma строит конвейер сбора
+// данных и анализа через bfma.NewEngine и получает его части, которые не входят
+// в публичный API движка.
+package engine
+
+import (
+	"github.com/skalibog/bfma/internal/analysis/aggregator"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/internal/state"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Setup параметры движка для bfma run (bfma.Options.Setup). Поля до Parts задает
+// cmd/bfma, Parts заполняет bfma.NewEngine. С Setup движок не передает сигналы
+// в канал Signals и не запускается Start: сборщики и цикл анализа запускает cmd/bfma.
+type Setup struct {
+	Config          *config.Config                               // Загруженная конфигурация; заменяет остальные поля Options
+	Current         func() *config.Config                        // Действующая конфигурация для сборщиков новых символов; nil - Config
+	OnPanic         func()                                       // Обработчик паники подписчиков шины событий (events.NewBus)
+	Pauses          *state.Pauses                                // Приостановленные символы; их сборщики не запускаются
+	ExtraCollectors func(symbol string) []exchange.DataCollector // Дополнительные сборщики символа; nil - нет
+
+	Parts
+}
+
+// Parts части движка
+type Parts struct {
+	Store        *storage.InfluxDBStorage // Хранилище; nil при storage.type: memory
+	Cache        *storage.FallbackStorage // Хранилище анализатора с последними прочитанными данными
+	Client       *exchange.BinanceClient
+	Symbols      *exchange.SymbolDirectory
+	Analyzer     *aggregator.Analyzer
+	Collectors   *exchange.SymbolCollectors
+	CandleSeries *models.CandleSeriesSet
+	Bus          *events.Bus
+}
@@ -0,0 +1,314 @@
+// Package escalation будит трейдера звонком Twilio или экстренным уведомлением
+// Pushover, когда сила сигнала символа достигает порога, и повторяет вызов, пока он
+// не подтвержден: ответом на звонок, кнопкой в Pushover или командой /ack бота.
+package escalation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию и параметры проверки
+const (
+	defaultRepeat     = 5 * time.Minute
+	defaultMaxRepeats = 3
+	checkPeriod       = 15 * time.Second // Период проверки подтверждений и повторов
+	requestTimeout    = 10 * time.Second // Время на один запрос к Twilio или Pushover
+	maxErrorBody      = 512              // Сколько байт ответа с ошибкой попадает в журнал
+)
+
+// MuteList символы с отключенными оповещениями: по ним не звонят
+type MuteList interface {
+	Muted(symbol string) bool
+}
+
+// page вызов по сильному сигналу
+type page struct {
+	Symbol   string
+	Text     string
+	Strength float64
+}
+
+// channel способ вызова
+type channel interface {
+	name() string
+	// send вызывает и возвращает идентификаторы для проверки подтверждения
+	send(ctx context.Context, p page) ([]string, error)
+	// acknowledged сообщает, подтвержден ли вызов с идентификатором
+	acknowledged(ctx context.Context, id string) (bool, error)
+	// repeats сообщает, повторяет ли служба вызов сама (повторно не отправляется)
+	repeats() bool
+	// cancel прекращает повторы вызова службой
+	cancel(ctx context.Context, id string) error
+}
+
+// escalation активный вызов по символу
+type escalation struct {
+	page     page
+	attempts int
+	next     time.Time
+	ids      map[channel][]string // Идентификаторы вызовов по способам
+	done     bool                 // Подтвержден или повторы исчерпаны; ждет ослабления сигнала
+	canceled bool                 // Повторы служб после завершения отменены
+}
+
+// Escalator следит за сильными сигналами и ведет вызовы
+type Escalator struct {
+	cfg      config.EscalationConfig
+	mutes    MuteList
+	channels []channel
+	mutex    sync.Mutex
+	active   map[string]*escalation
+	ended    []*escalation // Снятые вызовы, повторы которых еще не отменены
+	wakeC    chan struct{}
+}
+
+// NewEscalator создает эскалацию для включенных способов вызова
+func NewEscalator(cfg config.EscalationConfig, mutes MuteList) *Escalator {
+	client := &http.Client{Timeout: requestTimeout}
+	e := &Escalator{cfg: cfg, mutes: mutes, active: make(map[string]*escalation), wakeC: make(chan struct{}, 1)}
+	if cfg.Pushover.Enabled {
+		e.channels = append(e.channels, &pushover{cfg: cfg.Pushover, client: client})
+	}
+	if cfg.Twilio.Enabled {
+		e.channels = append(e.channels, &twilio{cfg: cfg.Twilio, client: client})
+	}
+	return e
+}
+
+// Start отправляет вызовы и проверяет подтверждения до отмены контекста
+func (e *Escalator) Start(ctx context.Context) {
+	logger.Info("Запуск эскалации сильных сигналов", zap.Float64("min_strength", e.cfg.MinStrength),
+		zap.Int("channels", len(e.channels)))
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.wakeC:
+		case <-ctx.Done():
+			return
+		}
+		e.process(ctx, time.Now())
+	}
+}
+
+// PublishSignals открывает вызов, когда сила сигнала символа достигает порога, и
+// снимает его, когда сигнал ослаб. Смена направления сильного сигнала - новый вызов.
+func (e *Escalator) PublishSignals(signals map[string]*models.SignalResult) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	opened := false
+	for symbol, signal := range signals {
+		if len(e.cfg.Symbols) > 0 && !slices.Contains(e.cfg.Symbols, symbol) {
+			continue
+		}
+		current := e.active[symbol]
+		if current != nil && abs(signal.SignalStrength) >= e.cfg.MinStrength && sameSign(current.page.Strength, signal.SignalStrength) {
+			continue
+		}
+		if current != nil {
+			delete(e.active, symbol)
+			e.ended = append(e.ended, current)
+		}
+		if abs(signal.SignalStrength) < e.cfg.MinStrength || e.mutes.Muted(symbol) {
+			continue
+		}
+		e.active[symbol] = &escalation{
+			page: page{
+				Symbol:   symbol,
+				Strength: signal.SignalStrength,
+				Text: fmt.Sprintf("%s: %s, сила %.0f, цена %s", symbol, signal.Recommendation, signal.SignalStrength,
+					strconv.FormatFloat(signal.CurrentPrice, 'f', -1, 64)),
+			},
+			ids: make(map[channel][]string),
+		}
+		opened = true
+	}
+	if opened {
+		select {
+		case e.wakeC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Acknowledge подтверждает вызов символа, пустой символ - все вызовы. Возвращает
+// подтвержденные символы.
+func (e *Escalator) Acknowledge(symbol string) []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var acked []string
+	for s, esc := range e.active {
+		if esc.done || (symbol != "" && s != symbol) {
+			continue
+		}
+		esc.done = true
+		acked = append(acked, s)
+		logger.Info("Эскалация подтверждена", zap.String("symbol", s), zap.String("by", "command"))
+	}
+	sort.Strings(acked)
+	return acked
+}
+
+// process проверяет подтверждения и отправляет первые и повторные вызовы
+func (e *Escalator) process(ctx context.Context, now time.Time) {
+	e.mutex.Lock()
+	pending := make(map[string]*escalation)
+	var finished []*escalation
+	for symbol, esc := range e.active {
+		switch {
+		case !esc.done:
+			pending[symbol] = esc
+		case !esc.canceled:
+			esc.canceled = true
+			finished = append(finished, esc)
+		}
+	}
+	for _, esc := range e.ended {
+		if !esc.canceled {
+			esc.canceled = true
+			finished = append(finished, esc)
+		}
+	}
+	e.ended = nil
+	e.mutex.Unlock()
+
+	// Запросы к службам выполняются без блокировки, чтобы не задерживать анализ
+	for _, esc := range finished {
+		e.cancel(ctx, esc)
+	}
+	for symbol, esc := range pending {
+		if by := e.acknowledgedBy(ctx, esc); by != "" {
+			e.finish(symbol, esc, "Эскалация подтверждена", zap.String("by", by))
+			continue
+		}
+		if e.isDone(esc) {
+			continue
+		}
+		if esc.attempts > 0 && now.Before(esc.next) {
+			continue
+		}
+		if esc.attempts > e.maxRepeats() {
+			e.finish(symbol, esc, "Эскалация не подтверждена, повторы прекращены", zap.Int("attempts", esc.attempts))
+			continue
+		}
+
+		for _, c := range e.channels {
+			if esc.attempts > 0 && c.repeats() {
+				continue
+			}
+			ids, err := c.send(ctx, esc.page)
+			if err != nil {
+				logger.Warn("Ошибка вызова эскалации", zap.String("channel", c.name()), zap.String("symbol", symbol), zap.Error(err))
+				continue
+			}
+			e.mutex.Lock()
+			esc.ids[c] = append(esc.ids[c], ids...)
+			e.mutex.Unlock()
+		}
+		esc.attempts++
+		esc.next = now.Add(e.repeat())
+		logger.Info("Вызов эскалации", zap.String("symbol", symbol), zap.Int("attempt", esc.attempts))
+	}
+}
+
+// acknowledgedBy возвращает способ, которым вызов подтвержден; пусто - не подтвержден
+func (e *Escalator) acknowledgedBy(ctx context.Context, esc *escalation) string {
+	for c, list := range e.ids(esc) {
+		for _, id := range list {
+			ok, err := c.acknowledged(ctx, id)
+			if err != nil {
+				logger.Warn("Ошибка проверки подтверждения эскалации", zap.String("channel", c.name()), zap.Error(err))
+				continue
+			}
+			if ok {
+				return c.name()
+			}
+		}
+	}
+	return ""
+}
+
+// cancel прекращает повторы вызовов, которые службы повторяют сами
+func (e *Escalator) cancel(ctx context.Context, esc *escalation) {
+	for c, list := range e.ids(esc) {
+		for _, id := range list {
+			if err := c.cancel(ctx, id); err != nil {
+				logger.Warn("Ошибка отмены вызова эскалации", zap.String("channel", c.name()), zap.Error(err))
+			}
+		}
+	}
+}
+
+// ids возвращает копию идентификаторов вызовов
+func (e *Escalator) ids(esc *escalation) map[channel][]string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ids := make(map[channel][]string, len(esc.ids))
+	for c, list := range esc.ids {
+		ids[c] = slices.Clone(list)
+	}
+	return ids
+}
+
+// isDone сообщает, что вызов уже завершен, например командой /ack
+func (e *Escalator) isDone(esc *escalation) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return esc.done
+}
+
+// finish завершает вызов; следующий откроется после ослабления сигнала или смены направления
+func (e *Escalator) finish(symbol string, esc *escalation, message string, fields ...zap.Field) {
+	e.mutex.Lock()
+	done := esc.done
+	esc.done = true
+	e.mutex.Unlock()
+	if !done {
+		logger.Info(message, append([]zap.Field{zap.String("symbol", symbol)}, fields...)...)
+	}
+}
+
+// repeat возвращает период повторных вызовов
+func (e *Escalator) repeat() time.Duration {
+	if e.cfg.Repeat > 0 {
+		return e.cfg.Repeat.Std()
+	}
+	return defaultRepeat
+}
+
+// maxRepeats возвращает число повторов без подтверждения
+func (e *Escalator) maxRepeats() int {
+	if e.cfg.MaxRepeats > 0 {
+		return e.cfg.MaxRepeats
+	}
+	return defaultMaxRepeats
+}
+
+// sameSign сообщает, что сигналы одного направления
+func sameSign(a, b float64) bool {
+	return (a >= 0) == (b >= 0)
+}
+
+// abs возвращает модуль числа
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
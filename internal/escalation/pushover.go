@@ -0,0 +1,123 @@
+package escalation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Адрес Pushover API и параметры экстренных уведомлений по умолчанию
+const (
+	pushoverURL           = "https://api.pushover.net/1"
+	pushoverEmergency     = "2" // Приоритет, который повторяется до подтверждения
+	defaultPushoverRetry  = time.Minute
+	defaultPushoverExpire = time.Hour
+)
+
+// pushover отправляет экстренные уведомления; Pushover сам повторяет их до подтверждения
+type pushover struct {
+	cfg    config.PushoverConfig
+	client *http.Client
+}
+
+func (p *pushover) name() string { return "pushover" }
+
+func (p *pushover) repeats() bool { return true }
+
+func (p *pushover) send(ctx context.Context, pg page) ([]string, error) {
+	form := url.Values{
+		"token":    {p.cfg.Token},
+		"user":     {p.cfg.User},
+		"title":    {"bfma: " + pg.Symbol},
+		"message":  {pg.Text},
+		"priority": {pushoverEmergency},
+		"retry":    {seconds(p.cfg.Retry, defaultPushoverRetry)},
+		"expire":   {seconds(p.cfg.Expire, defaultPushoverExpire)},
+	}
+	if p.cfg.Sound != "" {
+		form.Set("sound", p.cfg.Sound)
+	}
+
+	var result struct {
+		Receipt string `json:"receipt"`
+	}
+	if err := p.do(ctx, http.MethodPost, pushoverURL+"/messages.json", form, &result); err != nil {
+		return nil, err
+	}
+	if result.Receipt == "" {
+		return nil, errors.New("Pushover не вернул квитанцию")
+	}
+	return []string{result.Receipt}, nil
+}
+
+func (p *pushover) acknowledged(ctx context.Context, receipt string) (bool, error) {
+	endpoint := pushoverURL + "/receipts/" + url.PathEscape(receipt) + ".json?token=" + url.QueryEscape(p.cfg.Token)
+	var result struct {
+		Acknowledged int `json:"acknowledged"`
+	}
+	if err := p.do(ctx, http.MethodGet, endpoint, nil, &result); err != nil {
+		return false, err
+	}
+	return result.Acknowledged == 1, nil
+}
+
+func (p *pushover) cancel(ctx context.Context, receipt string) error {
+	endpoint := pushoverURL + "/receipts/" + url.PathEscape(receipt) + "/cancel.json"
+	return p.do(ctx, http.MethodPost, endpoint, url.Values{"token": {p.cfg.Token}}, nil)
+}
+
+// do выполняет запрос к Pushover и разбирает ответ в result
+func (p *pushover) do(ctx context.Context, method, endpoint string, form url.Values, result interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return doRequest(p.client, req, result)
+}
+
+// seconds переводит длительность в секунды для параметров формы
+func seconds(value config.Duration, fallback time.Duration) string {
+	d := fallback
+	if value > 0 {
+		d = value.Std()
+	}
+	return strconv.Itoa(int(d.Seconds()))
+}
+
+// doRequest выполняет запрос; ответ не 2xx считается ошибкой, иначе тело разбирается в result
+func doRequest(client *http.Client, req *http.Request, result interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		// Адрес запроса может содержать токен, поэтому в ошибку попадает только причина
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("ответ %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
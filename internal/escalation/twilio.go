@@ -0,0 +1,100 @@
+package escalation
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Адрес Twilio API и язык озвучивания по умолчанию
+const (
+	twilioURL       = "https://api.twilio.com/2010-04-01"
+	defaultLanguage = "ru-RU"
+)
+
+// twilio звонит на номера и зачитывает текст вызова. Звонок считается подтвержденным,
+// если на него ответил человек, а не автоответчик.
+type twilio struct {
+	cfg    config.TwilioConfig
+	client *http.Client
+}
+
+func (t *twilio) name() string { return "twilio" }
+
+func (t *twilio) repeats() bool { return false }
+
+func (t *twilio) send(ctx context.Context, pg page) ([]string, error) {
+	language := t.cfg.Language
+	if language == "" {
+		language = defaultLanguage
+	}
+	var text strings.Builder
+	xml.EscapeText(&text, []byte(pg.Text))
+	twiml := `<Response><Say language="` + language + `">` + text.String() + `</Say></Response>`
+
+	var sids []string
+	var lastErr error
+	for _, to := range t.cfg.To {
+		form := url.Values{
+			"To":               {to},
+			"From":             {t.cfg.From},
+			"Twiml":            {twiml},
+			"MachineDetection": {"Enable"},
+		}
+		var result struct {
+			SID string `json:"sid"`
+		}
+		if err := t.do(ctx, http.MethodPost, t.accountURL()+"/Calls.json", form, &result); err != nil {
+			lastErr = err
+			continue
+		}
+		sids = append(sids, result.SID)
+	}
+	// Звонок хотя бы на один номер - вызов состоялся
+	if len(sids) == 0 {
+		return nil, lastErr
+	}
+	return sids, nil
+}
+
+func (t *twilio) acknowledged(ctx context.Context, sid string) (bool, error) {
+	var result struct {
+		Status     string `json:"status"`
+		AnsweredBy string `json:"answered_by"`
+	}
+	if err := t.do(ctx, http.MethodGet, t.accountURL()+"/Calls/"+url.PathEscape(sid)+".json", nil, &result); err != nil {
+		return false, err
+	}
+	answered := result.Status == "in-progress" || result.Status == "completed"
+	return answered && !strings.HasPrefix(result.AnsweredBy, "machine"), nil
+}
+
+// cancel ничего не делает: Twilio не повторяет звонки сам
+func (t *twilio) cancel(context.Context, string) error { return nil }
+
+func (t *twilio) accountURL() string {
+	return twilioURL + "/Accounts/" + url.PathEscape(t.cfg.AccountSID)
+}
+
+// do выполняет запрос к Twilio с авторизацией и разбирает ответ в result
+func (t *twilio) do(ctx context.Context, method, endpoint string, form url.Values, result interface{}) error {
+	var req *http.Request
+	var err error
+	if form != nil {
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, strings.NewReader(form.Encode()))
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.SetBasicAuth(t.cfg.AccountSID, t.cfg.AuthToken)
+	return doRequest(t.client, req, result)
+}
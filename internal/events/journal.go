@@ -0,0 +1,132 @@
+// Package events ведет журнал событий: смены сигналов, оповещения, перезапуски
+// сборщиков, перезагрузки конфигурации, торговые операции и деградацию подсистем. Журнал хранится в
+// хранилище данных отдельно от отладочных логов и читается через API администратора.
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Типы событий
+const (
+	TypeSignal    = "signal"    // Смена рекомендации символа
+	TypeAlert     = "alert"     // Оповещение
+	TypeCollector = "collector" // Запуск и остановка сборщиков символа
+	TypeConfig    = "config"    // Перезагрузка конфигурации
+	TypeTrade     = "trade"     // Торговая операция
+	TypeHealth    = "health"    // Деградация и восстановление подсистем
+	TypeScanner   = "scanner"   // Кандидаты в списки наблюдения из обзора рынка
+)
+
+// Types все типы событий
+var Types = []string{TypeSignal, TypeAlert, TypeCollector, TypeConfig, TypeTrade, TypeHealth, TypeScanner}
+
+// Параметры записи
+const (
+	queueSize    = 1024
+	writeTimeout = 10 * time.Second
+	recentSize   = 500 // Сколько последних событий держится в памяти для отчетов о сбоях
+)
+
+// Sink хранилище журнала
+type Sink interface {
+	SaveEvent(ctx context.Context, event *models.Event) error
+}
+
+var (
+	queue   = make(chan *models.Event, queueSize)
+	started atomic.Bool
+	dropped atomic.Int64
+	done    = make(chan struct{})
+
+	recent      []*models.Event // Последние события по порядку записи
+	recentMutex sync.Mutex
+)
+
+// Record добавляет событие в журнал. Не блокирует: запись в хранилище идет в фоне,
+// при переполнении очереди событие отбрасывается. События до Start ждут в очереди;
+// без Start (подкоманды) журнал не ведется.
+func Record(eventType, symbol, message string, fields map[string]string) {
+	event := &models.Event{
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Symbol:    symbol,
+		Message:   message,
+		Fields:    fields,
+	}
+
+	recentMutex.Lock()
+	if len(recent) >= recentSize {
+		recent = append(recent[:0], recent[1:]...)
+	}
+	recent = append(recent, event)
+	recentMutex.Unlock()
+
+	select {
+	case queue <- event:
+	default:
+		if started.Load() && dropped.Add(1) == 1 {
+			logger.Warn("Очередь журнала событий переполнена, события отбрасываются")
+		}
+	}
+}
+
+// Recent возвращает до n последних событий, старые первыми. События хранятся в памяти
+// и доступны, даже если хранилище недоступно.
+func Recent(n int) []*models.Event {
+	recentMutex.Lock()
+	defer recentMutex.Unlock()
+
+	n = max(0, min(n, len(recent)))
+	return append([]*models.Event(nil), recent[len(recent)-n:]...)
+}
+
+// Start записывает события в sink до отмены контекста
+func Start(ctx context.Context, sink Sink) {
+	defer close(done)
+	started.Store(true)
+
+	for {
+		select {
+		case event := <-queue:
+			save(context.Background(), sink, event)
+		case <-ctx.Done():
+			// Контекст уже отменен, оставшимся в очереди событиям дается отдельное время на запись
+			started.Store(false)
+			for {
+				select {
+				case event := <-queue:
+					save(context.Background(), sink, event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stop ждет записи событий, оставшихся в очереди после отмены контекста Start
+func Stop() {
+	<-done
+}
+
+// save записывает событие в хранилище
+func save(ctx context.Context, sink Sink, event *models.Event) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if err := sink.SaveEvent(ctx, event); err != nil {
+		logger.Error("Ошибка записи журнала событий", zap.String("type", event.Type), zap.Error(err))
+		return
+	}
+	if n := dropped.Swap(0); n > 0 {
+		logger.Warn("Отброшено событий журнала", zap.Int64("count", n))
+	}
+}
@@ -0,0 +1,284 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Интервал продления ключа user data stream (ключ живет 60 минут)
+const listenKeyKeepalive = 30 * time.Minute
+
+// GetPositions получает открытые позиции счета
+func (c *BinanceClient) GetPositions(ctx context.Context) ([]*models.Position, error) {
+	risks, err := c.futures.NewGetPositionRiskService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения позиций: %w", err)
+	}
+
+	var positions []*models.Position
+	for _, r := range risks {
+		amount, _ := models.ParseDecimal(r.PositionAmt)
+		if amount.IsZero() {
+			continue
+		}
+		entry, _ := models.ParseDecimal(r.EntryPrice)
+		mark, _ := models.ParseDecimal(r.MarkPrice)
+		pnl, _ := models.ParseDecimal(r.UnRealizedProfit)
+		leverage, _ := strconv.Atoi(r.Leverage)
+
+		positions = append(positions, &models.Position{
+			Symbol:        r.Symbol,
+			Side:          positionSide(r.PositionSide, amount),
+			Amount:        amount.Abs(),
+			EntryPrice:    entry,
+			MarkPrice:     mark,
+			UnrealizedPnL: pnl,
+			Leverage:      leverage,
+			UpdateTime:    time.Now(),
+		})
+	}
+
+	return positions, nil
+}
+
+// GetLeverage возвращает плечо, установленное на бирже для символа
+func (c *BinanceClient) GetLeverage(ctx context.Context, symbol string) (int, error) {
+	risks, err := c.futures.NewGetPositionRiskService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения плеча %s: %w", symbol, err)
+	}
+
+	leverage := 0
+	for _, r := range risks {
+		if l, err := strconv.Atoi(r.Leverage); err == nil && l > leverage {
+			leverage = l
+		}
+	}
+	if leverage == 0 {
+		return 0, fmt.Errorf("не найдено плечо для %s", symbol)
+	}
+	return leverage, nil
+}
+
+// positionSide определяет сторону позиции; в one-way режиме (BOTH) - по знаку объема
+func positionSide(side string, amount models.Decimal) models.Side {
+	switch side {
+	case string(futures.PositionSideTypeLong):
+		return models.PositionSideLong
+	case string(futures.PositionSideTypeShort):
+		return models.PositionSideShort
+	}
+	if amount.IsNegative() {
+		return models.PositionSideShort
+	}
+	return models.PositionSideLong
+}
+
+// PositionTracker отслеживает открытые позиции через user data stream
+type PositionTracker struct {
+	client    *BinanceClient
+	positions map[string]*models.Position // Ключ: символ и сторона
+	leverage  map[string]int
+	mutex     sync.RWMutex
+	onUpdate  func()
+	stopC     chan struct{}
+	done      chan struct{}
+}
+
+// NewPositionTracker создает новый трекер позиций
+func NewPositionTracker(client *BinanceClient) *PositionTracker {
+	return &PositionTracker{
+		client:    client,
+		positions: make(map[string]*models.Position),
+		leverage:  make(map[string]int),
+		done:      make(chan struct{}),
+	}
+}
+
+// SetUpdateHandler задает функцию, вызываемую при изменении позиций
+func (t *PositionTracker) SetUpdateHandler(onUpdate func()) {
+	t.onUpdate = onUpdate
+}
+
+// Positions возвращает открытые позиции, отсортированные по символу
+func (t *PositionTracker) Positions() []*models.Position {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	positions := make([]*models.Position, 0, len(t.positions))
+	for _, p := range t.positions {
+		position := *p
+		positions = append(positions, &position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Symbol == positions[j].Symbol {
+			return positions[i].Side < positions[j].Side
+		}
+		return positions[i].Symbol < positions[j].Symbol
+	})
+	return positions
+}
+
+// Start загружает текущие позиции и подписывается на user data stream
+func (t *PositionTracker) Start(ctx context.Context) error {
+	positions, err := t.client.GetPositions(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	for _, p := range positions {
+		t.positions[p.Symbol+p.Side.String()] = p
+		t.leverage[p.Symbol] = p.Leverage
+	}
+	t.mutex.Unlock()
+	t.notify()
+
+	logger.Info("Загружены открытые позиции", zap.Int("count", len(positions)))
+
+	listenKey, err := t.client.futures.NewStartUserStreamService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения ключа user data stream: %w", err)
+	}
+
+	errHandler := func(err error) {
+		health.MarkError(health.StreamUserData)
+		logger.Error("Ошибка WebSocket user data stream", zap.Error(err))
+	}
+
+	doneC, stopC, err := futures.WsUserDataServe(listenKey, t.handleEvent, errHandler)
+	if err != nil {
+		return fmt.Errorf("ошибка подписки на user data stream: %w", err)
+	}
+	t.stopC = stopC
+	health.Watch(health.StreamUserData, doneC)
+
+	// Периодически продлеваем ключ, иначе биржа закроет поток
+	go func() {
+		ticker := time.NewTicker(listenKeyKeepalive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := t.client.futures.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx)
+				if err != nil {
+					logger.Error("Ошибка продления ключа user data stream", zap.Error(err))
+				}
+			case <-t.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// handleEvent обрабатывает события user data stream
+func (t *PositionTracker) handleEvent(event *futures.WsUserDataEvent) {
+	health.MarkData(health.StreamUserData)
+
+	switch event.Event {
+	case futures.UserDataEventTypeAccountUpdate:
+		t.mutex.Lock()
+		for _, p := range event.AccountUpdate.Positions {
+			amount, _ := models.ParseDecimal(p.Amount)
+			side := positionSide(string(p.Side), amount)
+			key := p.Symbol + side.String()
+
+			if amount.IsZero() {
+				delete(t.positions, key)
+				// В one-way режиме закрытие приходит без знака, удаляем обе стороны
+				if p.Side == futures.PositionSideTypeBoth {
+					delete(t.positions, p.Symbol+models.PositionSideShort.String())
+				}
+				continue
+			}
+
+			entry, _ := models.ParseDecimal(p.EntryPrice)
+			mark, _ := models.ParseDecimal(p.MarkPrice)
+			pnl, _ := models.ParseDecimal(p.UnrealizedPnL)
+
+			t.positions[key] = &models.Position{
+				Symbol:        p.Symbol,
+				Side:          side,
+				Amount:        amount.Abs(),
+				EntryPrice:    entry,
+				MarkPrice:     mark,
+				UnrealizedPnL: pnl,
+				Leverage:      t.leverage[p.Symbol],
+				UpdateTime:    time.Unix(0, event.Time*int64(time.Millisecond)),
+			}
+		}
+		t.mutex.Unlock()
+
+	case futures.UserDataEventTypeAccountConfigUpdate:
+		update := event.AccountConfigUpdate
+		if update.Symbol == "" {
+			return
+		}
+
+		t.mutex.Lock()
+		t.leverage[update.Symbol] = int(update.Leverage)
+		for _, p := range t.positions {
+			if p.Symbol == update.Symbol {
+				p.Leverage = int(update.Leverage)
+			}
+		}
+		t.mutex.Unlock()
+
+	case futures.UserDataEventTypeOrderTradeUpdate:
+		// Исполнения заявок только записываются в журнал; позиции обновит ACCOUNT_UPDATE
+		update := event.OrderTradeUpdate
+		if update.ExecutionType == futures.OrderExecutionTypeTrade {
+			events.Record(events.TypeTrade, update.Symbol, "исполнение заявки", map[string]string{
+				"order_id":     strconv.FormatInt(update.ID, 10),
+				"side":         string(update.Side),
+				"type":         string(update.Type),
+				"status":       string(update.Status),
+				"quantity":     update.LastFilledQty,
+				"price":        update.LastFilledPrice,
+				"realized_pnl": update.RealizedPnL,
+			})
+		}
+		return
+
+	case futures.UserDataEventTypeListenKeyExpired:
+		logger.Warn("Ключ user data stream истек, позиции больше не обновляются")
+		return
+
+	default:
+		return
+	}
+
+	t.notify()
+}
+
+// notify сообщает подписчику об изменении позиций
+func (t *PositionTracker) notify() {
+	if t.onUpdate != nil {
+		t.onUpdate()
+	}
+}
+
+// Stop останавливает трекер позиций
+func (t *PositionTracker) Stop() {
+	if t.stopC != nil {
+		close(t.stopC)
+		close(t.done)
+		t.stopC = nil
+	}
+}
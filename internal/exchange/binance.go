@@ -3,25 +3,34 @@ package exchange
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"io/ioutil"
 	"net/http"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/latency"
 	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/internal/usage"
+	"github.com/skalibog/bfma/pkg/clock"
+	"github.com/skalibog/bfma/pkg/events"
 	"github.com/skalibog/bfma/pkg/logger"
 	"github.com/skalibog/bfma/pkg/models"
 )
 
 // BinanceClient клиент для взаимодействия с Binance
 type BinanceClient struct {
-	futures *futures.Client
-	spot    *binance.Client
+	futures      *futures.Client
+	spot         *binance.Client
+	filters      map[string]*models.SymbolFilters // Кэш фильтров символов
+	filtersMutex sync.Mutex
 }
 
 // NewBinanceClient создает новый клиент Binance
@@ -34,6 +43,7 @@ func NewBinanceClient(cfg config.BinanceConfig) (*BinanceClient, error) {
 
 	// После установки режима создаем клиенты
 	futuresClient := futures.NewClient(cfg.APIKey, cfg.APISecret)
+	futuresClient.HTTPClient = newWeightClient()
 	spotClient := binance.NewClient(cfg.APIKey, cfg.APISecret)
 
 	// Отладочный вывод
@@ -46,43 +56,54 @@ func NewBinanceClient(cfg config.BinanceConfig) (*BinanceClient, error) {
 }
 
 // GetKlines получает исторические свечи
-func (c *BinanceClient) GetKlines(ctx context.Context, symbol, interval string, limit int) ([]*models.Candle, error) {
+func (c *BinanceClient) GetKlines(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
 	klines, err := c.futures.NewKlinesService().
 		Symbol(symbol).
-		Interval(interval).
+		Interval(interval.String()).
 		Limit(limit).
 		Do(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения свечей: %w", err)
 	}
 
-	logger.Info("Klines", zap.String("symbol", symbol), zap.String("interval", interval), zap.Int("limit", limit), zap.Int("count", len(klines)))
+	logger.Info("Klines", zap.String("symbol", symbol), zap.Stringer("interval", interval), zap.Int("limit", limit), zap.Int("count", len(klines)))
 	candles := make([]*models.Candle, len(klines))
 	for i, k := range klines {
-		// Преобразуем строковые значения в float64
-		open, _ := strconv.ParseFloat(k.Open, 64)
-		high, _ := strconv.ParseFloat(k.High, 64)
-		low, _ := strconv.ParseFloat(k.Low, 64)
-		close, _ := strconv.ParseFloat(k.Close, 64)
-		volume, _ := strconv.ParseFloat(k.Volume, 64)
-
-		candle := &models.Candle{
-			Symbol:    symbol,
-			Interval:  interval,
-			OpenTime:  time.Unix(k.OpenTime/1000, 0),
-			Open:      open,
-			High:      high,
-			Low:       low,
-			Close:     close,
-			Volume:    volume,
-			CloseTime: time.Unix(k.CloseTime/1000, 0),
-		}
-		candles[i] = candle
+		candles[i] = candleFromKline(symbol, interval, k)
 	}
 
 	return candles, nil
 }
 
+// candleFromKline переводит свечу REST API биржи в модель
+func candleFromKline(symbol string, interval models.Interval, k *futures.Kline) *models.Candle {
+	// Цены и объемы биржа передает строками; они сохраняются без потери точности
+	open, _ := models.ParseDecimal(k.Open)
+	high, _ := models.ParseDecimal(k.High)
+	low, _ := models.ParseDecimal(k.Low)
+	close, _ := models.ParseDecimal(k.Close)
+	volume, _ := models.ParseDecimal(k.Volume)
+	quoteVolume, _ := models.ParseDecimal(k.QuoteAssetVolume)
+	takerBuyVolume, _ := models.ParseDecimal(k.TakerBuyBaseAssetVolume)
+	takerBuyQuoteVolume, _ := models.ParseDecimal(k.TakerBuyQuoteAssetVolume)
+
+	return &models.Candle{
+		Symbol:              symbol,
+		Interval:            interval,
+		OpenTime:            time.Unix(k.OpenTime/1000, 0),
+		Open:                open,
+		High:                high,
+		Low:                 low,
+		Close:               close,
+		Volume:              volume,
+		CloseTime:           time.Unix(k.CloseTime/1000, 0),
+		QuoteVolume:         quoteVolume,
+		NumTrades:           k.TradeNum,
+		TakerBuyVolume:      takerBuyVolume,
+		TakerBuyQuoteVolume: takerBuyQuoteVolume,
+	}
+}
+
 // GetOrderBook получает стакан заявок
 func (c *BinanceClient) GetOrderBook(ctx context.Context, symbol string, limit int) (*models.OrderBook, error) {
 	ob, err := c.futures.NewDepthService().
@@ -93,28 +114,22 @@ func (c *BinanceClient) GetOrderBook(ctx context.Context, symbol string, limit i
 		return nil, fmt.Errorf("ошибка получения стакана: %w", err)
 	}
 
-	orderBook := &models.OrderBook{
-		Symbol:    symbol,
-		Timestamp: time.Now(),
-		Bids:      make([]models.OrderBookLevel, len(ob.Bids)),
-		Asks:      make([]models.OrderBookLevel, len(ob.Asks)),
-	}
-
-	for i, bid := range ob.Bids {
-		orderBook.Bids[i] = models.OrderBookLevel{
-			Price:  bid.Price,
-			Amount: bid.Quantity,
-		}
+	bids, err := convertLevels(ob.Bids)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора стакана: %w", err)
 	}
-
-	for i, ask := range ob.Asks {
-		orderBook.Asks[i] = models.OrderBookLevel{
-			Price:  ask.Price,
-			Amount: ask.Quantity,
-		}
+	asks, err := convertLevels(ob.Asks)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора стакана: %w", err)
 	}
 
-	return orderBook, nil
+	return &models.OrderBook{
+		Symbol:       symbol,
+		Timestamp:    time.Now(),
+		Bids:         bids,
+		Asks:         asks,
+		LastUpdateID: ob.LastUpdateID,
+	}, nil
 }
 
 // GetFundingRate получает текущую ставку финансирования
@@ -133,9 +148,14 @@ func (c *BinanceClient) GetFundingRate(ctx context.Context, symbol string) (*mod
 	// NextFundingTime - это timestamp в миллисекундах, преобразуем в time.Time
 	nextFundingTime := time.Unix(rates[0].NextFundingTime/1000, 0)
 
+	value, err := models.ParseDecimal(rates[0].LastFundingRate)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора ставки финансирования: %w", err)
+	}
+
 	rate := &models.FundingRate{
 		Symbol:          symbol,
-		Rate:            rates[0].LastFundingRate,
+		Rate:            value,
 		Timestamp:       time.Now(),
 		NextFundingTime: nextFundingTime,
 	}
@@ -164,8 +184,7 @@ func (c *BinanceClient) GetOpenInterest(ctx context.Context, symbol string) (*mo
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.futures.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
 	}
@@ -181,31 +200,107 @@ func (c *BinanceClient) GetOpenInterest(ctx context.Context, symbol string) (*mo
 		return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
 	}
 
+	value, err := models.ParseDecimal(oiResp.OpenInterest)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора открытого интереса: %w", err)
+	}
+
 	return &models.OpenInterest{
 		Symbol:    symbol,
-		Value:     oiResp.OpenInterest,
+		Value:     value,
 		Timestamp: time.Unix(oiResp.Time/1000, 0),
 	}, nil
 }
 
+// convertLevels переводит уровни стакана Binance в модель без потери точности
+func convertLevels(levels []common.PriceLevel) ([]models.OrderBookLevel, error) {
+	result := make([]models.OrderBookLevel, len(levels))
+	for i, level := range levels {
+		price, err := models.ParseDecimal(level.Price)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := models.ParseDecimal(level.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = models.OrderBookLevel{Price: price, Amount: amount}
+	}
+	return result, nil
+}
+
 // DataCollector интерфейс для сборщиков данных
 type DataCollector interface {
 	Start(ctx context.Context) error
 	Stop()
+	SetPauseFilter(isPaused func(symbol string) bool)
+	SetClock(clk clock.Clock)
+	SetEventBus(bus *events.Bus)
+}
+
+// pauseFilter позволяет сборщикам пропускать данные приостановленных символов
+type pauseFilter struct {
+	isPaused func(symbol string) bool
+}
+
+// SetPauseFilter задает функцию проверки приостановки символа
+func (f *pauseFilter) SetPauseFilter(isPaused func(symbol string) bool) {
+	f.isPaused = isPaused
+}
+
+// skip сообщает, нужно ли пропустить данные символа
+func (f *pauseFilter) skip(symbol string) bool {
+	return f.isPaused != nil && f.isPaused(symbol)
+}
+
+// clocked задает сборщику часы; без SetClock используются системные
+type clocked struct {
+	clock clock.Clock
+}
+
+// SetClock задает часы сборщика, например симулированные при воспроизведении истории
+func (c *clocked) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// published позволяет сборщикам сообщать о новых данных и ошибках в шину событий
+type published struct {
+	bus *events.Bus
+}
+
+// SetEventBus задает шину событий сборщика; без шины события не публикуются
+func (p *published) SetEventBus(bus *events.Bus) {
+	p.bus = bus
+}
+
+// collectorError публикует ошибку сборщика
+func (p *published) collectorError(collector, symbol string, err error) {
+	p.bus.Publish(events.CollectorError{Collector: collector, Symbol: symbol, Err: err, Time: time.Now()})
+}
+
+// clk возвращает часы сборщика
+func (c *clocked) clk() clock.Clock {
+	if c.clock == nil {
+		return clock.Real
+	}
+	return c.clock
 }
 
 // CandleCollector сборщик данных о свечах
 type CandleCollector struct {
+	pauseFilter
+	clocked
+	published
 	client   *BinanceClient
 	storage  storage.Storage
 	symbols  []string
-	interval string
-	doneC    chan struct{}
-	stopC    chan struct{}
+	interval models.Interval
+	series   *models.CandleSeriesSet // Закрытые свечи в памяти для анализаторов; nil - не ведутся
+	stopC    []chan struct{}         // По одному каналу остановки на символ
 }
 
 // NewCandleCollector создает новый сборщик свечей
-func NewCandleCollector(client *BinanceClient, storage storage.Storage, symbols []string, interval string) *CandleCollector {
+func NewCandleCollector(client *BinanceClient, storage storage.Storage, symbols []string, interval models.Interval) *CandleCollector {
 	return &CandleCollector{
 		client:   client,
 		storage:  storage,
@@ -214,17 +309,45 @@ func NewCandleCollector(client *BinanceClient, storage storage.Storage, symbols
 	}
 }
 
+// SetCandleSeries включает ведение рядов закрытых свечей в памяти: ряд символа
+// заполняется загруженной историей и дополняется каждой закрытой свечой
+func (c *CandleCollector) SetCandleSeries(series *models.CandleSeriesSet) {
+	c.series = series
+}
+
+// resetSeries очищает ряд свечей символа
+func (c *CandleCollector) resetSeries(symbol string) {
+	if c.series != nil {
+		c.series.Remove(symbol)
+	}
+}
+
+// appendSeries добавляет закрытые свечи в ряд символа
+func (c *CandleCollector) appendSeries(symbol string, candles ...*models.Candle) {
+	if c.series == nil {
+		return
+	}
+	series := c.series.Series(symbol)
+	for _, candle := range candles {
+		series.Append(candle)
+	}
+}
+
 // Start запускает сборщик данных
 func (c *CandleCollector) Start(ctx context.Context) error {
 	logger.Info("Запуск сборщика свечей",
 		zap.Strings("symbols", c.symbols),
-		zap.String("interval", c.interval))
+		zap.Stringer("interval", c.interval))
 
 	// Загружаем исторические данные
 	for _, symbol := range c.symbols {
+		if c.skip(symbol) {
+			continue
+		}
+
 		logger.Info("Загрузка исторических свечей",
 			zap.String("symbol", symbol),
-			zap.String("interval", c.interval),
+			zap.Stringer("interval", c.interval),
 			zap.Int("limit", 1000)) // Увеличил лимит до 1000
 
 		candles, err := c.client.GetKlines(ctx, symbol, c.interval, 500) // Увеличил до 1000
@@ -249,50 +372,81 @@ func (c *CandleCollector) Start(ctx context.Context) error {
 		logger.Info("Исторические свечи сохранены",
 			zap.String("symbol", symbol),
 			zap.Int("count", len(candles)))
+
+		// Ряд заполняется заново, так как за время остановки сборщика в нем мог появиться
+		// пропуск. Последняя загруженная свеча еще не закрыта: она попадет в ряд при закрытии.
+		c.resetSeries(symbol)
+		now := c.clk().Now()
+		for _, candle := range candles {
+			if candle.CloseTime.Before(now) {
+				c.appendSeries(symbol, candle)
+			}
+		}
 	}
 
 	// Подписываемся на обновления свечей через WebSocket
 	for _, symbol := range c.symbols {
 		wsKlineHandler := func(event *futures.WsKlineEvent) {
+			if c.skip(symbol) {
+				return
+			}
+
 			logger.Debug("Получено WS событие свечи",
 				zap.String("symbol", symbol),
-				zap.Time("time", time.Now()),
-				zap.String("interval", c.interval),
+				zap.Time("time", c.clk().Now()),
+				zap.Stringer("interval", c.interval),
 				zap.Bool("is_final", event.Kline.IsFinal))
 			k := event.Kline
 
-			// Преобразуем строковые значения в float64
-			open, _ := strconv.ParseFloat(k.Open, 64)
-			high, _ := strconv.ParseFloat(k.High, 64)
-			low, _ := strconv.ParseFloat(k.Low, 64)
-			closes, _ := strconv.ParseFloat(k.Close, 64)
-			volume, _ := strconv.ParseFloat(k.Volume, 64)
+			// Цены и объемы биржа передает строками; они сохраняются без потери точности
+			open, _ := models.ParseDecimal(k.Open)
+			high, _ := models.ParseDecimal(k.High)
+			low, _ := models.ParseDecimal(k.Low)
+			closes, _ := models.ParseDecimal(k.Close)
+			volume, _ := models.ParseDecimal(k.Volume)
+			quoteVolume, _ := models.ParseDecimal(k.QuoteVolume)
+			takerBuyVolume, _ := models.ParseDecimal(k.ActiveBuyVolume)
+			takerBuyQuoteVolume, _ := models.ParseDecimal(k.ActiveBuyQuoteVolume)
 
 			candle := &models.Candle{
-				Symbol:    symbol,
-				Interval:  c.interval,
-				OpenTime:  time.Unix(k.StartTime/1000, 0),
-				Open:      open,
-				High:      high,
-				Low:       low,
-				Close:     closes,
-				Volume:    volume,
-				CloseTime: time.Unix(k.EndTime/1000, 0),
+				Symbol:              symbol,
+				Interval:            c.interval,
+				OpenTime:            time.Unix(k.StartTime/1000, 0),
+				Open:                open,
+				High:                high,
+				Low:                 low,
+				Close:               closes,
+				Volume:              volume,
+				CloseTime:           time.Unix(k.EndTime/1000, 0),
+				QuoteVolume:         quoteVolume,
+				NumTrades:           k.TradeNum,
+				TakerBuyVolume:      takerBuyVolume,
+				TakerBuyQuoteVolume: takerBuyQuoteVolume,
 			}
 
+			health.MarkData(health.StreamCandles)
+			usage.AddMessage(symbol)
 			c.storage.SaveCandle(ctx, candle)
+			latency.MarkStored(symbol, time.UnixMilli(event.Time))
+			if k.IsFinal {
+				c.appendSeries(symbol, candle)
+				c.bus.Publish(events.CandleClosed{Candle: candle})
+			}
 		}
 
 		errHandler := func(err error) {
+			health.MarkError(health.StreamCandles)
+			c.collectorError(health.StreamCandles, symbol, err)
 			logger.Error("Ошибка WebSocket для свечей", zap.String("symbol", symbol), zap.Error(err))
 		}
 
-		var err error
-		c.doneC, c.stopC, err = futures.WsKlineServe(symbol, c.interval, wsKlineHandler, errHandler)
+		doneC, stopC, err := futures.WsKlineServe(symbol, c.interval.String(), wsKlineHandler, errHandler)
 		if err != nil {
 			logger.Error("Ошибка подписки на WebSocket для свечей", zap.String("symbol", symbol), zap.Error(err))
 			return fmt.Errorf("ошибка подписки на WebSocket для свечей %s: %w", symbol, err)
 		}
+		c.stopC = append(c.stopC, stopC)
+		health.Watch(health.StreamCandles, doneC)
 	}
 
 	return nil
@@ -300,21 +454,38 @@ func (c *CandleCollector) Start(ctx context.Context) error {
 
 // Stop останавливает сборщик данных
 func (c *CandleCollector) Stop() {
-	if c.stopC != nil {
-		close(c.stopC)
+	for _, stopC := range c.stopC {
+		close(stopC)
 	}
+	c.stopC = nil
 }
 
-// OrderBookCollector сборщик данных о стакане заявок
+// Как часто в хранилище записывается полный снимок стакана; между снимками
+// записываются только изменения
+const orderBookSnapshotInterval = time.Minute
+
+// OrderBookCollector сборщик данных о стакане заявок. Стакан каждого символа ведется
+// локально: снимок REST API дополняется потоком изменений WebSocket, при пропуске
+// обновлений снимок загружается заново.
 type OrderBookCollector struct {
+	pauseFilter
+	clocked
+	published
 	client       *BinanceClient
 	storage      storage.Storage
 	symbols      []string
 	depth        int
+	books        map[string]*localBook
 	doneChannels []chan struct{} // Было: doneC chan struct{}
 	stopChannels []chan struct{} // Было: stopC chan struct{}
 }
 
+// localBook локальный стакан символа
+type localBook struct {
+	orderBook *models.OrderBook
+	savedAt   time.Time // Время последнего снимка в хранилище
+}
+
 // NewOrderBookCollector создает новый сборщик стакана заявок
 func NewOrderBookCollector(client *BinanceClient, storage storage.Storage, symbols []string, depth int) *OrderBookCollector {
 	return &OrderBookCollector{
@@ -322,6 +493,7 @@ func NewOrderBookCollector(client *BinanceClient, storage storage.Storage, symbo
 		storage: storage,
 		symbols: symbols,
 		depth:   depth,
+		books:   make(map[string]*localBook),
 	}
 }
 
@@ -329,66 +501,129 @@ func NewOrderBookCollector(client *BinanceClient, storage storage.Storage, symbo
 func (c *OrderBookCollector) Start(ctx context.Context) error {
 	// Загружаем начальный стакан через REST API
 	for _, symbol := range c.symbols {
-		orderBook, err := c.client.GetOrderBook(ctx, symbol, c.depth)
-		if err != nil {
+		if c.skip(symbol) {
+			continue
+		}
+		if err := c.load(ctx, symbol); err != nil {
 			logger.Error("Ошибка загрузки стакана", zap.Error(err))
 			continue // Продолжаем с другими символами вместо полной остановки
 		}
-		c.storage.SaveOrderBook(ctx, orderBook)
 	}
 
-	// Используем один обработчик для всех символов
+	// Используем один обработчик для всех символов; поток обрабатывает события
+	// по очереди, поэтому локальные стаканы не нужно защищать мьютексом
 	handler := func(event *futures.WsDepthEvent) {
 		symbol := event.Symbol // Получаем символ из события
+		if c.skip(symbol) {
+			return
+		}
 
 		logger.Debug("Получено WS событие стакана",
 			zap.String("symbol", symbol),
-			zap.Time("time", time.Now()),
-			zap.Int("depth", c.depth))
-
-		// Создаем объект стакана и сохраняем
-		orderBook := &models.OrderBook{
-			Symbol:    symbol,
-			Timestamp: time.Now(),
-			Bids:      make([]models.OrderBookLevel, len(event.Bids)),
-			Asks:      make([]models.OrderBookLevel, len(event.Asks)),
-		}
+			zap.Time("time", c.clk().Now()),
+			zap.Int64("update_id", event.LastUpdateID))
 
-		// Заполняем данными
-		for i, bid := range event.Bids {
-			orderBook.Bids[i] = models.OrderBookLevel{
-				Price:  bid.Price,
-				Amount: bid.Quantity,
-			}
+		bids, err := convertLevels(event.Bids)
+		if err != nil {
+			logger.Error("Ошибка разбора стакана", zap.String("symbol", symbol), zap.Error(err))
+			return
 		}
-		for i, ask := range event.Asks {
-			orderBook.Asks[i] = models.OrderBookLevel{
-				Price:  ask.Price,
-				Amount: ask.Quantity,
-			}
+		asks, err := convertLevels(event.Asks)
+		if err != nil {
+			logger.Error("Ошибка разбора стакана", zap.String("symbol", symbol), zap.Error(err))
+			return
+		}
+		delta := &models.OrderBookDelta{
+			Symbol:        symbol,
+			Timestamp:     c.clk().Now(),
+			FirstUpdateID: event.FirstUpdateID,
+			LastUpdateID:  event.LastUpdateID,
+			PrevUpdateID:  event.PrevLastUpdateID,
+			Bids:          bids,
+			Asks:          asks,
 		}
 
-		// Сохраняем в базу
-		if err := c.storage.SaveOrderBook(ctx, orderBook); err != nil {
-			logger.Error("Ошибка сохранения стакана",
+		health.MarkData(health.StreamOrderBook)
+		usage.AddMessage(symbol)
+		if err := c.apply(ctx, delta); err != nil {
+			logger.Error("Ошибка обновления стакана",
 				zap.String("symbol", symbol), zap.Error(err))
+			return
 		}
+		latency.MarkStored(symbol, time.UnixMilli(event.Time))
 	}
 
 	errHandler := func(err error) {
+		health.MarkError(health.StreamOrderBook)
+		c.collectorError(health.StreamOrderBook, "", err)
 		logger.Error("Ошибка WebSocket", zap.Error(err))
 		// Просто логируем ошибку и продолжаем работу
 	}
-	symbolsMap := make(map[string]string)
-	for _, sym := range c.symbols {
-		// Для Binance API нужен формат "symbol@depth"
-		symbolsMap[sym] = sym + "@depth"
+
+	logger.Info("Подписка на WebSocket для стакана", zap.Strings("symbols", c.symbols))
+	doneC, stopC, err := futures.WsCombinedDiffDepthServe(c.symbols, handler, errHandler)
+	if err != nil {
+		return err
 	}
+	c.doneChannels = append(c.doneChannels, doneC)
+	c.stopChannels = append(c.stopChannels, stopC)
+	health.Watch(health.StreamOrderBook, doneC)
 
-	logger.Info("Подписка на WebSocket для стакана", zap.Any("symbols", symbolsMap))
-	_, _, err := futures.WsCombinedDepthServe(symbolsMap, handler, errHandler)
+	return nil
+}
 
-	return err
+// load загружает снимок стакана через REST API и сохраняет его
+func (c *OrderBookCollector) load(ctx context.Context, symbol string) error {
+	orderBook, err := c.client.GetOrderBook(ctx, symbol, c.depth)
+	if err != nil {
+		return err
+	}
+	orderBook.Timestamp = c.clk().Now()
+	c.books[symbol] = &localBook{orderBook: orderBook}
+	return c.save(ctx, c.books[symbol], nil)
+}
+
+// apply применяет изменение к локальному стакану символа. Стакан без снимка или
+// с пропущенными обновлениями загружается заново; изменение, уже учтенное снимком,
+// пропускается.
+func (c *OrderBookCollector) apply(ctx context.Context, delta *models.OrderBookDelta) error {
+	book, ok := c.books[delta.Symbol]
+	if !ok {
+		if err := c.load(ctx, delta.Symbol); err != nil {
+			return err
+		}
+		book = c.books[delta.Symbol]
+	}
+
+	applied, err := book.orderBook.Apply(delta)
+	if errors.Is(err, models.ErrOrderBookGap) {
+		logger.Warn("Пропущены обновления стакана, снимок загружается заново",
+			zap.String("symbol", delta.Symbol), zap.Error(err))
+		delete(c.books, delta.Symbol)
+		return c.load(ctx, delta.Symbol)
+	}
+	if err != nil || !applied {
+		return err
+	}
+	return c.save(ctx, book, delta)
+}
+
+// save записывает в хранилище снимок стакана, если прошлый записан больше
+// orderBookSnapshotInterval назад или изменения нет, иначе только изменение,
+// и публикует стакан в шину событий
+func (c *OrderBookCollector) save(ctx context.Context, book *localBook, delta *models.OrderBookDelta) error {
+	orderBook := book.orderBook.Top(c.depth)
+	now := c.clk().Now()
+	if delta == nil || now.Sub(book.savedAt) >= orderBookSnapshotInterval {
+		if err := c.storage.SaveOrderBook(ctx, orderBook); err != nil {
+			return fmt.Errorf("ошибка сохранения стакана: %w", err)
+		}
+		book.savedAt = now
+	} else if err := c.storage.SaveOrderBookDelta(ctx, delta); err != nil {
+		return fmt.Errorf("ошибка сохранения изменения стакана: %w", err)
+	}
+	c.bus.Publish(events.BookUpdated{OrderBook: orderBook})
+	return nil
 }
 
 // Stop останавливает сборщик данных
@@ -398,14 +633,18 @@ func (c *OrderBookCollector) Stop() {
 			close(stopC)
 		}
 	}
+	c.stopChannels = nil
 }
 
 // FundingRateCollector сборщик данных о ставках финансирования
 type FundingRateCollector struct {
+	pauseFilter
+	clocked
+	published
 	client  *BinanceClient
 	storage storage.Storage
 	symbols []string
-	ticker  *time.Ticker
+	ticker  clock.Ticker
 	done    chan struct{}
 }
 
@@ -423,26 +662,40 @@ func NewFundingRateCollector(client *BinanceClient, storage storage.Storage, sym
 func (c *FundingRateCollector) Start(ctx context.Context) error {
 	// Загружаем текущие ставки финансирования
 	for _, symbol := range c.symbols {
+		if c.skip(symbol) {
+			continue
+		}
+
 		rate, err := c.client.GetFundingRate(ctx, symbol)
 		if err != nil {
+			health.MarkError(health.StreamFunding)
 			return fmt.Errorf("ошибка загрузки ставки финансирования для %s: %w", symbol, err)
 		}
 
 		if err := c.storage.SaveFundingRate(ctx, rate); err != nil {
 			return fmt.Errorf("ошибка сохранения ставки финансирования для %s: %w", symbol, err)
 		}
+		health.MarkData(health.StreamFunding)
+		usage.AddMessage(symbol)
+		c.bus.Publish(events.FundingUpdated{Rate: rate})
 	}
 
 	// Запускаем периодическое обновление ставок финансирования
-	c.ticker = time.NewTicker(10 * time.Minute) // Обновляем каждый час
+	c.ticker = c.clk().NewTicker(10 * time.Minute) // Обновляем каждый час
 
 	go func() {
 		for {
 			select {
-			case <-c.ticker.C:
+			case <-c.ticker.C():
 				for _, symbol := range c.symbols {
+					if c.skip(symbol) {
+						continue
+					}
+
 					rate, err := c.client.GetFundingRate(ctx, symbol)
 					if err != nil {
+						health.MarkError(health.StreamFunding)
+						c.collectorError(health.StreamFunding, symbol, err)
 						logger.Error("Ошибка получения ставки финансирования",
 							zap.String("symbol", symbol),
 							zap.Error(err))
@@ -453,7 +706,11 @@ func (c *FundingRateCollector) Start(ctx context.Context) error {
 						logger.Error("Ошибка сохранения ставки финансирования",
 							zap.String("symbol", symbol),
 							zap.Error(err))
+						continue
 					}
+					health.MarkData(health.StreamFunding)
+					usage.AddMessage(symbol)
+					c.bus.Publish(events.FundingUpdated{Rate: rate})
 				}
 			case <-c.done:
 				return
@@ -474,10 +731,13 @@ func (c *FundingRateCollector) Stop() {
 
 // OpenInterestCollector сборщик данных о открытом интересе
 type OpenInterestCollector struct {
+	pauseFilter
+	clocked
+	published
 	client  *BinanceClient
 	storage storage.Storage
 	symbols []string
-	ticker  *time.Ticker
+	ticker  clock.Ticker
 	done    chan struct{}
 }
 
@@ -495,33 +755,49 @@ func NewOpenInterestCollector(client *BinanceClient, storage storage.Storage, sy
 func (c *OpenInterestCollector) Start(ctx context.Context) error {
 	// Загружаем текущий открытый интерес
 	for _, symbol := range c.symbols {
+		if c.skip(symbol) {
+			continue
+		}
+
 		oi, err := c.client.GetOpenInterest(ctx, symbol)
 		if err != nil {
+			health.MarkError(health.StreamOpenInterest)
 			return fmt.Errorf("ошибка загрузки открытого интереса для %s: %w", symbol, err)
 		}
 
 		if err := c.storage.SaveOpenInterest(ctx, oi); err != nil {
 			return fmt.Errorf("ошибка сохранения открытого интереса для %s: %w", symbol, err)
 		}
+		health.MarkData(health.StreamOpenInterest)
+		usage.AddMessage(symbol)
 	}
 
 	// Запускаем периодическое обновление открытого интереса
-	c.ticker = time.NewTicker(15 * time.Minute) // Обновляем каждые 15 минут
+	c.ticker = c.clk().NewTicker(15 * time.Minute) // Обновляем каждые 15 минут
 
 	go func() {
 		for {
 			select {
-			case <-c.ticker.C:
+			case <-c.ticker.C():
 				for _, symbol := range c.symbols {
+					if c.skip(symbol) {
+						continue
+					}
+
 					oi, err := c.client.GetOpenInterest(context.Background(), symbol)
 					if err != nil {
-						fmt.Printf("Ошибка получения открытого интереса для %s: %v\n", symbol, err)
+						health.MarkError(health.StreamOpenInterest)
+						c.collectorError(health.StreamOpenInterest, symbol, err)
+						logger.Error("Ошибка получения открытого интереса", zap.String("symbol", symbol), zap.Error(err))
 						continue
 					}
 
 					if err := c.storage.SaveOpenInterest(context.Background(), oi); err != nil {
-						fmt.Printf("Ошибка сохранения открытого интереса для %s: %v\n", symbol, err)
+						logger.Error("Ошибка сохранения открытого интереса", zap.String("symbol", symbol), zap.Error(err))
+						continue
 					}
+					health.MarkData(health.StreamOpenInterest)
+					usage.AddMessage(symbol)
 				}
 			case <-c.done:
 				return
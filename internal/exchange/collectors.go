@@ -0,0 +1,127 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	journal "github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CollectorFactory создает сборщики данных для одного символа
+type CollectorFactory func(symbol string) []DataCollector
+
+// SymbolCollectors управляет сборщиками данных по символам, позволяя
+// добавлять и удалять символы во время работы
+type SymbolCollectors struct {
+	factory  CollectorFactory
+	isPaused func(symbol string) bool
+	bus      *events.Bus
+	running  map[string][]DataCollector
+	mutex    sync.Mutex
+}
+
+// NewSymbolCollectors создает менеджер сборщиков данных
+func NewSymbolCollectors(factory CollectorFactory, isPaused func(symbol string) bool) *SymbolCollectors {
+	return &SymbolCollectors{
+		factory:  factory,
+		isPaused: isPaused,
+		running:  make(map[string][]DataCollector),
+	}
+}
+
+// SetEventBus задает шину, в которую сборщики публикуют новые данные и ошибки.
+// Вызывается до первого Add.
+func (m *SymbolCollectors) SetEventBus(bus *events.Bus) {
+	m.bus = bus
+}
+
+// Add запускает сборщики данных для символа. Повторное добавление ничего не делает.
+func (m *SymbolCollectors) Add(ctx context.Context, symbol string) error {
+	m.mutex.Lock()
+	if _, ok := m.running[symbol]; ok {
+		m.mutex.Unlock()
+		return nil
+	}
+	collectors := m.factory(symbol)
+	m.running[symbol] = collectors
+	m.mutex.Unlock()
+
+	for i, collector := range collectors {
+		collector.SetPauseFilter(m.isPaused)
+		collector.SetEventBus(m.bus)
+		if err := collector.Start(ctx); err != nil {
+			// Останавливаем уже запущенные сборщики символа
+			for _, started := range collectors[:i+1] {
+				started.Stop()
+			}
+
+			m.mutex.Lock()
+			delete(m.running, symbol)
+			m.mutex.Unlock()
+
+			journal.Record(journal.TypeCollector, symbol, "ошибка запуска сборщиков данных", map[string]string{"error": err.Error()})
+			return fmt.Errorf("ошибка запуска сборщика данных для %s: %w", symbol, err)
+		}
+	}
+
+	logger.Info("Запущены сборщики данных символа", zap.String("symbol", symbol))
+	journal.Record(journal.TypeCollector, symbol, "сборщики данных запущены", nil)
+	return nil
+}
+
+// Remove останавливает сборщики данных символа
+func (m *SymbolCollectors) Remove(symbol string) {
+	m.mutex.Lock()
+	collectors, ok := m.running[symbol]
+	delete(m.running, symbol)
+	m.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	for _, collector := range collectors {
+		collector.Stop()
+	}
+	logger.Info("Остановлены сборщики данных символа", zap.String("symbol", symbol))
+	journal.Record(journal.TypeCollector, symbol, "сборщики данных остановлены", nil)
+}
+
+// Symbols возвращает отсортированный список символов с запущенными сборщиками
+func (m *SymbolCollectors) Symbols() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	symbols := make([]string, 0, len(m.running))
+	for symbol := range m.running {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// StopAll останавливает сборщики всех символов
+func (m *SymbolCollectors) StopAll() {
+	for _, symbol := range m.Symbols() {
+		m.Remove(symbol)
+	}
+}
+
+// Restart перезапускает сборщики всех символов, например чтобы заново открыть
+// оборвавшиеся потоки WebSocket
+func (m *SymbolCollectors) Restart(ctx context.Context) error {
+	var errs []error
+	for _, symbol := range m.Symbols() {
+		m.Remove(symbol)
+		if err := m.Add(ctx, symbol); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
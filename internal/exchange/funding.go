@@ -0,0 +1,117 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/usage"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// FundingBoard хранит актуальные прогнозные ставки финансирования и время следующего расчета
+type FundingBoard struct {
+	rates map[string]*models.FundingRate
+	mutex sync.RWMutex
+}
+
+// NewFundingBoard создает новую таблицу ставок финансирования
+func NewFundingBoard() *FundingBoard {
+	return &FundingBoard{
+		rates: make(map[string]*models.FundingRate),
+	}
+}
+
+// Funding возвращает последнюю известную ставку финансирования символа
+func (b *FundingBoard) Funding(symbol string) (*models.FundingRate, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	rate, ok := b.rates[symbol]
+	if !ok {
+		return nil, false
+	}
+	copied := *rate
+	return &copied, true
+}
+
+// set обновляет ставку финансирования символа
+func (b *FundingBoard) set(rate *models.FundingRate) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rates[rate.Symbol] = rate
+}
+
+// MarkPriceCollector подписывается на поток mark price, в котором биржа каждую
+// секунду публикует прогнозную ставку финансирования, и обновляет FundingBoard
+type MarkPriceCollector struct {
+	pauseFilter
+	clocked
+	published
+	board   *FundingBoard
+	symbols []string
+	stopC   []chan struct{}
+}
+
+// NewMarkPriceCollector создает новый сборщик потока mark price
+func NewMarkPriceCollector(board *FundingBoard, symbols []string) *MarkPriceCollector {
+	return &MarkPriceCollector{
+		board:   board,
+		symbols: symbols,
+	}
+}
+
+// Start запускает сборщик данных
+func (c *MarkPriceCollector) Start(ctx context.Context) error {
+	for _, symbol := range c.symbols {
+		handler := func(event *futures.WsMarkPriceEvent) {
+			if c.skip(event.Symbol) {
+				return
+			}
+
+			rate, err := models.ParseDecimal(event.FundingRate)
+			if err != nil {
+				logger.Error("Ошибка разбора ставки финансирования", zap.String("symbol", event.Symbol), zap.Error(err))
+				return
+			}
+
+			health.MarkData(health.StreamMarkPrice)
+			usage.AddMessage(event.Symbol)
+			c.board.set(&models.FundingRate{
+				Symbol:          event.Symbol,
+				Rate:            rate,
+				Timestamp:       time.Unix(0, event.Time*int64(time.Millisecond)),
+				NextFundingTime: time.Unix(0, event.NextFundingTime*int64(time.Millisecond)),
+			})
+		}
+
+		errHandler := func(err error) {
+			health.MarkError(health.StreamMarkPrice)
+			c.collectorError(health.StreamMarkPrice, symbol, err)
+			logger.Error("Ошибка WebSocket для mark price", zap.String("symbol", symbol), zap.Error(err))
+		}
+
+		doneC, stopC, err := futures.WsMarkPriceServeWithRate(symbol, time.Second, handler, errHandler)
+		if err != nil {
+			return fmt.Errorf("ошибка подписки на WebSocket для mark price %s: %w", symbol, err)
+		}
+		c.stopC = append(c.stopC, stopC)
+		health.Watch(health.StreamMarkPrice, doneC)
+	}
+
+	return nil
+}
+
+// Stop останавливает сборщик данных
+func (c *MarkPriceCollector) Stop() {
+	for _, stopC := range c.stopC {
+		close(stopC)
+	}
+	c.stopC = nil
+}
@@ -0,0 +1,89 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Наибольшее число записей в одном запросе истории к бирже
+const (
+	klinesPageLimit  = 1500
+	fundingPageLimit = 1000
+)
+
+// Загрузка истории за период страницами: для дозагрузки данных в хранилище
+
+// GetKlinesRange возвращает закрытые свечи с временем открытия в [from, to) по
+// возрастанию времени; период длиннее одного запроса загружается страницами
+func (c *BinanceClient) GetKlinesRange(ctx context.Context, symbol string, interval models.Interval, from, to time.Time) ([]*models.Candle, error) {
+	now := time.Now()
+	var candles []*models.Candle
+	for start := from; start.Before(to); {
+		klines, err := c.futures.NewKlinesService().
+			Symbol(symbol).
+			Interval(interval.String()).
+			StartTime(start.UnixMilli()).
+			EndTime(to.UnixMilli() - 1).
+			Limit(klinesPageLimit).
+			Do(ctx)
+		if err != nil {
+			return candles, fmt.Errorf("ошибка получения свечей %s с %s: %w", symbol, start.Format(time.RFC3339), err)
+		}
+		if len(klines) == 0 {
+			break
+		}
+		for _, k := range klines {
+			candle := candleFromKline(symbol, interval, k)
+			if candle.CloseTime.Before(now) {
+				candles = append(candles, candle)
+			}
+		}
+		start = time.UnixMilli(klines[len(klines)-1].OpenTime + 1)
+		if len(klines) < klinesPageLimit {
+			break
+		}
+	}
+	return candles, nil
+}
+
+// GetFundingHistory возвращает расчеты финансирования символа за [from, to) по
+// возрастанию времени. У прошедших расчетов время следующего расчета совпадает со
+// временем самого расчета: по разнице соседних определяется период финансирования.
+func (c *BinanceClient) GetFundingHistory(ctx context.Context, symbol string, from, to time.Time) ([]*models.FundingRate, error) {
+	var rates []*models.FundingRate
+	for start := from; start.Before(to); {
+		history, err := c.futures.NewFundingRateService().
+			Symbol(symbol).
+			StartTime(start.UnixMilli()).
+			EndTime(to.UnixMilli() - 1).
+			Limit(fundingPageLimit).
+			Do(ctx)
+		if err != nil {
+			return rates, fmt.Errorf("ошибка получения истории финансирования %s с %s: %w", symbol, start.Format(time.RFC3339), err)
+		}
+		if len(history) == 0 {
+			break
+		}
+		for _, h := range history {
+			value, err := models.ParseDecimal(h.FundingRate)
+			if err != nil {
+				return rates, fmt.Errorf("ошибка разбора ставки финансирования %s: %w", symbol, err)
+			}
+			at := time.UnixMilli(h.FundingTime)
+			rates = append(rates, &models.FundingRate{
+				Symbol:          symbol,
+				Rate:            value,
+				Timestamp:       at,
+				NextFundingTime: at,
+			})
+		}
+		start = time.UnixMilli(history[len(history)-1].FundingTime + 1)
+		if len(history) < fundingPageLimit {
+			break
+		}
+	}
+	return rates, nil
+}
@@ -0,0 +1,58 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Запросы по всем символам биржи сразу: для обзора рынка одного запроса на цикл
+// достаточно, подписки на потоки каждого символа не нужны
+
+// GetTickers возвращает суточную статистику всех символов фьючерсной биржи
+func (c *BinanceClient) GetTickers(ctx context.Context) ([]*models.MarketTicker, error) {
+	stats, err := c.futures.NewListPriceChangeStatsService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения суточной статистики: %w", err)
+	}
+
+	tickers := make([]*models.MarketTicker, 0, len(stats))
+	for _, s := range stats {
+		ticker := &models.MarketTicker{
+			Symbol:    s.Symbol,
+			Timestamp: time.UnixMilli(s.CloseTime),
+		}
+		ticker.LastPrice, _ = strconv.ParseFloat(s.LastPrice, 64)
+		ticker.PriceChangePct, _ = strconv.ParseFloat(s.PriceChangePercent, 64)
+		ticker.QuoteVolume, _ = strconv.ParseFloat(s.QuoteVolume, 64)
+		tickers = append(tickers, ticker)
+	}
+	return tickers, nil
+}
+
+// GetFundingRates возвращает текущие ставки финансирования всех символов биржи
+func (c *BinanceClient) GetFundingRates(ctx context.Context) ([]*models.FundingRate, error) {
+	indexes, err := c.futures.NewPremiumIndexService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ставок финансирования: %w", err)
+	}
+
+	now := time.Now()
+	rates := make([]*models.FundingRate, 0, len(indexes))
+	for _, index := range indexes {
+		value, err := models.ParseDecimal(index.LastFundingRate)
+		if err != nil {
+			continue // У символов без бессрочного контракта ставки нет
+		}
+		rates = append(rates, &models.FundingRate{
+			Symbol:          index.Symbol,
+			Rate:            value,
+			Timestamp:       now,
+			NextFundingTime: time.UnixMilli(index.NextFundingTime),
+		})
+	}
+	return rates, nil
+}
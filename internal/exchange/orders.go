@@ -0,0 +1,153 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// GetAvailableBalance возвращает доступный баланс фьючерсного счета в указанном активе
+func (c *BinanceClient) GetAvailableBalance(ctx context.Context, asset string) (float64, error) {
+	balances, err := c.futures.NewGetBalanceService().Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения баланса: %w", err)
+	}
+
+	for _, b := range balances {
+		if b.Asset == asset {
+			available, err := strconv.ParseFloat(b.AvailableBalance, 64)
+			if err != nil {
+				return 0, fmt.Errorf("ошибка разбора баланса %s: %w", asset, err)
+			}
+			return available, nil
+		}
+	}
+
+	return 0, fmt.Errorf("не найден баланс в %s", asset)
+}
+
+// GetEquity возвращает капитал фьючерсного счета в указанном активе:
+// баланс кошелька с нереализованной прибылью
+func (c *BinanceClient) GetEquity(ctx context.Context, asset string) (float64, error) {
+	balances, err := c.futures.NewGetBalanceService().Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения баланса: %w", err)
+	}
+
+	for _, b := range balances {
+		if b.Asset != asset {
+			continue
+		}
+		wallet, err := strconv.ParseFloat(b.Balance, 64)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка разбора баланса %s: %w", asset, err)
+		}
+		pnl, _ := strconv.ParseFloat(b.CrossUnPnl, 64)
+		return wallet + pnl, nil
+	}
+
+	return 0, fmt.Errorf("не найден баланс в %s", asset)
+}
+
+// GetSymbolFilters возвращает шаг цены и объема символа
+func (c *BinanceClient) GetSymbolFilters(ctx context.Context, symbol string) (*models.SymbolFilters, error) {
+	c.filtersMutex.Lock()
+	defer c.filtersMutex.Unlock()
+
+	if filters, ok := c.filters[symbol]; ok {
+		return filters, nil
+	}
+
+	infos, err := c.GetSymbolInfos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Кэшируем фильтры всех символов, они меняются редко
+	if c.filters == nil {
+		c.filters = make(map[string]*models.SymbolFilters)
+	}
+	for _, info := range infos {
+		c.filters[info.Symbol] = info.Filters()
+	}
+
+	filters, ok := c.filters[symbol]
+	if !ok {
+		return nil, fmt.Errorf("символ %s не найден на бирже", symbol)
+	}
+	return filters, nil
+}
+
+// PlaceBracketOrder открывает позицию по рынку и выставляет стоп-лосс и тейк-профит,
+// закрывающие всю позицию
+func (c *BinanceClient) PlaceBracketOrder(ctx context.Context, ticket *models.OrderTicket) error {
+	entrySide, exitSide := futures.SideTypeBuy, futures.SideTypeSell
+	if ticket.Side == models.PositionSideShort {
+		entrySide, exitSide = futures.SideTypeSell, futures.SideTypeBuy
+	}
+
+	entry, err := c.futures.NewCreateOrderService().
+		Symbol(ticket.Symbol).
+		Side(entrySide).
+		Type(futures.OrderTypeMarket).
+		Quantity(formatDecimal(ticket.Quantity)).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия позиции: %w", err)
+	}
+
+	logger.Info("Открыта позиция",
+		zap.String("symbol", ticket.Symbol),
+		zap.Stringer("side", ticket.Side),
+		zap.Float64("quantity", ticket.Quantity),
+		zap.Int64("order_id", entry.OrderID))
+
+	exits := []struct {
+		orderType futures.OrderType
+		price     float64
+		name      string
+	}{
+		{futures.OrderTypeStopMarket, ticket.StopLoss, "стоп-лосс"},
+		{futures.OrderTypeTakeProfitMarket, ticket.TakeProfit, "тейк-профит"},
+	}
+
+	for _, exit := range exits {
+		if exit.price <= 0 {
+			continue
+		}
+
+		_, err := c.futures.NewCreateOrderService().
+			Symbol(ticket.Symbol).
+			Side(exitSide).
+			Type(exit.orderType).
+			StopPrice(formatDecimal(exit.price)).
+			ClosePosition(true).
+			Do(ctx)
+		if err != nil {
+			// Позиция уже открыта, поэтому сообщаем, какая защита не выставлена
+			return fmt.Errorf("позиция открыта, но не выставлен %s: %w", exit.name, err)
+		}
+	}
+
+	return nil
+}
+
+// RoundToStep округляет значение вниз до шага биржи
+func RoundToStep(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	// Убираем погрешность двоичного представления, чтобы не получить 0.30000000000000004
+	return math.Round(math.Floor(value/step+1e-9)*step*1e10) / 1e10
+}
+
+// formatDecimal форматирует число без экспоненты и лишних нулей
+func formatDecimal(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
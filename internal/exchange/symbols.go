@@ -0,0 +1,234 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Как часто параметры символов обновляются с биржи
+const symbolRefreshInterval = 6 * time.Hour
+
+// GetSymbolInfos возвращает параметры всех символов фьючерсной биржи
+func (c *BinanceClient) GetSymbolInfos(ctx context.Context) ([]*models.SymbolInfo, error) {
+	info, err := c.futures.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения параметров биржи: %w", err)
+	}
+
+	// Биржа перечисляет только символы с измененными параметрами финансирования,
+	// у остальных бессрочных контрактов период стандартный
+	intervals := make(map[string]time.Duration)
+	fundingInfos, err := c.futures.NewFundingRateInfoService().Do(ctx)
+	if err != nil {
+		logger.Warn("Ошибка получения периодов финансирования", zap.Error(err))
+	}
+	for _, f := range fundingInfos {
+		if f.FundingIntervalHours > 0 {
+			intervals[f.Symbol] = time.Duration(f.FundingIntervalHours) * time.Hour
+		}
+	}
+
+	now := time.Now()
+	infos := make([]*models.SymbolInfo, 0, len(info.Symbols))
+	for _, s := range info.Symbols {
+		symbol := &models.SymbolInfo{
+			Symbol:            s.Symbol,
+			BaseAsset:         s.BaseAsset,
+			QuoteAsset:        s.QuoteAsset,
+			MarginAsset:       s.MarginAsset,
+			ContractType:      string(s.ContractType),
+			Status:            s.Status,
+			PricePrecision:    s.PricePrecision,
+			QuantityPrecision: s.QuantityPrecision,
+			OnboardDate:       time.UnixMilli(s.OnboardDate),
+			DeliveryDate:      time.UnixMilli(s.DeliveryDate),
+			UpdateTime:        now,
+		}
+		if symbol.ContractType == models.ContractPerpetual && err == nil {
+			symbol.FundingInterval = models.DefaultFundingInterval
+			if interval, ok := intervals[s.Symbol]; ok {
+				symbol.FundingInterval = interval
+			}
+		}
+		if lot := s.LotSizeFilter(); lot != nil {
+			symbol.StepSize, _ = strconv.ParseFloat(lot.StepSize, 64)
+			symbol.MinQuantity, _ = strconv.ParseFloat(lot.MinQuantity, 64)
+		}
+		if price := s.PriceFilter(); price != nil {
+			symbol.TickSize, _ = strconv.ParseFloat(price.TickSize, 64)
+		}
+		if notional := s.MinNotionalFilter(); notional != nil {
+			symbol.MinNotional, _ = strconv.ParseFloat(notional.Notional, 64)
+		}
+		infos = append(infos, symbol)
+	}
+	return infos, nil
+}
+
+// SymbolDirectory справочник параметров символов: загружается из хранилища при запуске
+// и периодически обновляется с биржи. Символы, которые перестали торговаться,
+// передаются обработчику делистинга.
+type SymbolDirectory struct {
+	client   *BinanceClient
+	storage  storage.Storage
+	symbols  map[string]*models.SymbolInfo
+	mutex    sync.RWMutex
+	onDelist func(info *models.SymbolInfo)
+}
+
+// NewSymbolDirectory создает пустой справочник символов
+func NewSymbolDirectory(client *BinanceClient, storage storage.Storage) *SymbolDirectory {
+	return &SymbolDirectory{
+		client:  client,
+		storage: storage,
+		symbols: make(map[string]*models.SymbolInfo),
+	}
+}
+
+// SetDelistHandler задает обработчик символов, которые перестали торговаться
+func (d *SymbolDirectory) SetDelistHandler(handler func(info *models.SymbolInfo)) {
+	d.onDelist = handler
+}
+
+// Load загружает параметры символов, сохраненные при прошлом обновлении: справочник
+// работает, даже если биржа при запуске недоступна
+func (d *SymbolDirectory) Load(ctx context.Context) error {
+	infos, err := d.storage.GetSymbolInfos(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки параметров символов: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, info := range infos {
+		d.symbols[info.Symbol] = info
+	}
+	return nil
+}
+
+// Refresh обновляет параметры символов с биржи и сохраняет их в хранилище
+func (d *SymbolDirectory) Refresh(ctx context.Context) error {
+	infos, err := d.client.GetSymbolInfos(ctx)
+	if err != nil {
+		return err
+	}
+
+	var delisted []*models.SymbolInfo
+	d.mutex.Lock()
+	for _, info := range infos {
+		previous, ok := d.symbols[info.Symbol]
+		if ok && previous.Trading() && !info.Trading() {
+			delisted = append(delisted, info)
+		}
+		if ok && info.FundingInterval == 0 {
+			// Периоды финансирования не получены: остается известный
+			info.FundingInterval = previous.FundingInterval
+		}
+		d.symbols[info.Symbol] = info
+	}
+	d.mutex.Unlock()
+
+	for _, info := range infos {
+		if err := d.storage.SaveSymbolInfo(ctx, info); err != nil {
+			logger.Warn("Ошибка сохранения параметров символа", zap.String("symbol", info.Symbol), zap.Error(err))
+		}
+	}
+
+	logger.Info("Параметры символов обновлены", zap.Int("symbols", len(infos)))
+	for _, info := range delisted {
+		logger.Warn("Символ перестал торговаться", zap.String("symbol", info.Symbol), zap.String("status", info.Status))
+		if d.onDelist != nil {
+			d.onDelist(info)
+		}
+	}
+	return nil
+}
+
+// Start обновляет параметры символов каждые symbolRefreshInterval до отмены контекста
+func (d *SymbolDirectory) Start(ctx context.Context) {
+	ticker := time.NewTicker(symbolRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil {
+				logger.Warn("Ошибка обновления параметров символов", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Info возвращает параметры символа
+func (d *SymbolDirectory) Info(symbol string) (*models.SymbolInfo, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	info, ok := d.symbols[symbol]
+	return info, ok
+}
+
+// FundingInterval возвращает период финансирования символа по параметрам биржи;
+// 0 - неизвестен
+func (d *SymbolDirectory) FundingInterval(symbol string) time.Duration {
+	info, ok := d.Info(symbol)
+	if !ok {
+		return 0
+	}
+	return info.FundingInterval
+}
+
+// Check проверяет, что символ есть на бирже и торгуется. Пока параметры символов
+// не загружены, проверка пропускается.
+func (d *SymbolDirectory) Check(symbol string) error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if len(d.symbols) == 0 {
+		return nil
+	}
+	info, ok := d.symbols[symbol]
+	switch {
+	case !ok:
+		return fmt.Errorf("символ %s не найден на бирже", symbol)
+	case !info.Trading():
+		return fmt.Errorf("символ %s не торгуется (статус %s)", symbol, info.Status)
+	}
+	return nil
+}
+
+// Tradable возвращает символы, прошедшие Check; остальные пропускаются с предупреждением
+func (d *SymbolDirectory) Tradable(symbols []string) []string {
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if err := d.Check(symbol); err != nil {
+			logger.Warn("Символ исключен из анализа", zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		result = append(result, symbol)
+	}
+	return result
+}
+
+// Symbols возвращает параметры всех известных символов по алфавиту
+func (d *SymbolDirectory) Symbols() []*models.SymbolInfo {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	infos := make([]*models.SymbolInfo, 0, len(d.symbols))
+	for _, info := range d.symbols {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Symbol < infos[j].Symbol })
+	return infos
+}
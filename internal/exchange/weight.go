@@ -0,0 +1,80 @@
+package exchange
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/skalibog/bfma/internal/usage"
+)
+
+// weightTransport учитывает вес запросов к REST API Binance: вес запроса по таблице
+// весов относится к символу запроса, а вес за минуту из ответа биржи показывает
+// расход лимита всеми запросами с этого IP
+type weightTransport struct {
+	next http.RoundTripper
+}
+
+// newWeightClient возвращает HTTP-клиент, учитывающий вес запросов
+func newWeightClient() *http.Client {
+	return &http.Client{Transport: weightTransport{next: http.DefaultTransport}}
+}
+
+// RoundTrip выполняет запрос и учитывает его вес
+func (t weightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	usage.AddWeight(query.Get("symbol"), requestWeight(req.URL.Path, query))
+	if used, err := strconv.Atoi(resp.Header.Get("X-Mbx-Used-Weight-1m")); err == nil {
+		usage.ObserveUsedWeight(used)
+	}
+	return resp, nil
+}
+
+// requestWeight возвращает вес запроса USDⓈ-M Futures по документации Binance
+func requestWeight(path string, query url.Values) int {
+	limit := func(fallback int) int {
+		if n, err := strconv.Atoi(query.Get("limit")); err == nil {
+			return n
+		}
+		return fallback
+	}
+
+	switch path {
+	case "/fapi/v1/klines":
+		switch n := limit(500); {
+		case n < 100:
+			return 1
+		case n < 500:
+			return 2
+		case n <= 1000:
+			return 5
+		default:
+			return 10
+		}
+	case "/fapi/v1/depth":
+		switch n := limit(500); {
+		case n <= 50:
+			return 2
+		case n <= 100:
+			return 5
+		case n <= 500:
+			return 10
+		default:
+			return 20
+		}
+	case "/fapi/v1/premiumIndex":
+		if query.Get("symbol") == "" {
+			return 10
+		}
+		return 1
+	case "/fapi/v2/balance", "/fapi/v2/positionRisk":
+		return 5
+	default:
+		return 1
+	}
+}
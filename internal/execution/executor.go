@@ -0,0 +1,191 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию для расчета заявки
+const (
+	defaultQuoteAsset    = "USDT"
+	defaultStopLossPct   = 1.0
+	defaultTakeProfitPct = 2.0
+)
+
+// Executor рассчитывает и отправляет заявки, подтвержденные пользователем
+type Executor struct {
+	client       *exchange.BinanceClient
+	config       config.ExecutionConfig
+	riskPerTrade float64
+	guard        *Guard
+}
+
+// NewExecutor создает новый исполнитель заявок; guard проверяет ограничения риска
+// перед каждой отправкой
+func NewExecutor(client *exchange.BinanceClient, cfg config.ExecutionConfig, riskPerTrade float64, guard *Guard) *Executor {
+	if cfg.QuoteAsset == "" {
+		cfg.QuoteAsset = defaultQuoteAsset
+	}
+	if cfg.StopLossPct <= 0 {
+		cfg.StopLossPct = defaultStopLossPct
+	}
+	if cfg.TakeProfitPct <= 0 {
+		cfg.TakeProfitPct = defaultTakeProfitPct
+	}
+
+	return &Executor{
+		client:       client,
+		config:       cfg,
+		riskPerTrade: riskPerTrade,
+		guard:        guard,
+	}
+}
+
+// SuggestTicket предлагает заявку по сигналу: направление по рекомендации, стоп-лосс
+// и тейк-профит на настроенном расстоянии от цены, объем такой, чтобы при срабатывании
+// стоп-лосса потерять risk_per_trade от баланса с учетом силы сигнала
+func (e *Executor) SuggestTicket(ctx context.Context, signal *models.SignalResult) (*models.OrderTicket, error) {
+	if signal.CurrentPrice <= 0 {
+		return nil, fmt.Errorf("нет текущей цены для %s", signal.Symbol)
+	}
+
+	side := models.PositionSideLong
+	if signal.RecommendationCode == models.RecommendationSell || signal.RecommendationCode == models.RecommendationStrongSell {
+		side = models.PositionSideShort
+	}
+
+	filters, err := e.client.GetSymbolFilters(ctx, signal.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := e.client.GetAvailableBalance(ctx, e.config.QuoteAsset)
+	if err != nil {
+		return nil, err
+	}
+
+	price := signal.CurrentPrice
+	risk := balance * e.riskPerTrade * signal.PositionSize
+	quantity := risk / (price * e.config.StopLossPct / 100)
+	if e.config.MaxNotional > 0 {
+		quantity = math.Min(quantity, e.config.MaxNotional/price)
+	}
+	quantity = e.guard.MaxQuantity(quantity, price)
+	quantity = exchange.RoundToStep(quantity, filters.StepSize)
+	if quantity < filters.MinQuantity || quantity*price < filters.MinNotional {
+		quantity = 0
+	}
+
+	ticket := &models.OrderTicket{
+		Symbol:     signal.Symbol,
+		Side:       side,
+		Quantity:   quantity,
+		EntryPrice: price,
+	}
+	e.applyExits(ticket, filters.TickSize)
+
+	return ticket, nil
+}
+
+// Recalculate пересчитывает стоп-лосс и тейк-профит после смены направления заявки
+func (e *Executor) Recalculate(ctx context.Context, ticket *models.OrderTicket) error {
+	filters, err := e.client.GetSymbolFilters(ctx, ticket.Symbol)
+	if err != nil {
+		return err
+	}
+	e.applyExits(ticket, filters.TickSize)
+	return nil
+}
+
+// applyExits рассчитывает цены стоп-лосса и тейк-профита от цены входа
+func (e *Executor) applyExits(ticket *models.OrderTicket, tickSize float64) {
+	direction := 1.0
+	if ticket.Side == models.PositionSideShort {
+		direction = -1.0
+	}
+
+	ticket.StopLoss = exchange.RoundToStep(ticket.EntryPrice*(1-direction*e.config.StopLossPct/100), tickSize)
+	ticket.TakeProfit = exchange.RoundToStep(ticket.EntryPrice*(1+direction*e.config.TakeProfitPct/100), tickSize)
+}
+
+// Validate проверяет заявку перед отправкой
+func Validate(ticket *models.OrderTicket) error {
+	if ticket.Quantity <= 0 {
+		return fmt.Errorf("объем заявки должен быть больше нуля")
+	}
+
+	long := ticket.Side == models.PositionSideLong
+	if ticket.StopLoss > 0 && (long && ticket.StopLoss >= ticket.EntryPrice || !long && ticket.StopLoss <= ticket.EntryPrice) {
+		return fmt.Errorf("стоп-лосс %.8g находится не с той стороны от цены %.8g", ticket.StopLoss, ticket.EntryPrice)
+	}
+	if ticket.TakeProfit > 0 && (long && ticket.TakeProfit <= ticket.EntryPrice || !long && ticket.TakeProfit >= ticket.EntryPrice) {
+		return fmt.Errorf("тейк-профит %.8g находится не с той стороны от цены %.8g", ticket.TakeProfit, ticket.EntryPrice)
+	}
+	return nil
+}
+
+// Execute отправляет подтвержденную пользователем заявку на биржу
+func (e *Executor) Execute(ctx context.Context, ticket *models.OrderTicket) error {
+	if err := Validate(ticket); err != nil {
+		return err
+	}
+
+	filters, err := e.client.GetSymbolFilters(ctx, ticket.Symbol)
+	if err != nil {
+		return err
+	}
+
+	order := *ticket
+	order.Quantity = exchange.RoundToStep(order.Quantity, filters.StepSize)
+	order.StopLoss = exchange.RoundToStep(order.StopLoss, filters.TickSize)
+	order.TakeProfit = exchange.RoundToStep(order.TakeProfit, filters.TickSize)
+	if order.Quantity < filters.MinQuantity {
+		return fmt.Errorf("объем %g меньше минимального %g", order.Quantity, filters.MinQuantity)
+	}
+	if order.Quantity*order.EntryPrice < filters.MinNotional {
+		return fmt.Errorf("стоимость заявки %.2f меньше минимальной %.2f", order.Quantity*order.EntryPrice, filters.MinNotional)
+	}
+	if err := e.guard.Check(ctx, &order); err != nil {
+		logger.Warn("Заявка отклонена ограничениями риска", zap.String("symbol", order.Symbol), zap.Error(err))
+		events.Record(events.TypeTrade, order.Symbol, "заявка отклонена ограничениями риска", orderFields(&order, err))
+		return fmt.Errorf("заявка отклонена ограничениями риска: %w", err)
+	}
+
+	logger.Info("Отправка заявки, подтвержденной пользователем",
+		zap.String("symbol", order.Symbol),
+		zap.Stringer("side", order.Side),
+		zap.Float64("quantity", order.Quantity),
+		zap.Float64("stop_loss", order.StopLoss),
+		zap.Float64("take_profit", order.TakeProfit))
+
+	if err := e.client.PlaceBracketOrder(ctx, &order); err != nil {
+		events.Record(events.TypeTrade, order.Symbol, "ошибка отправки заявки", orderFields(&order, err))
+		return err
+	}
+	e.guard.Record()
+	events.Record(events.TypeTrade, order.Symbol, "заявка отправлена", orderFields(&order, nil))
+	return nil
+}
+
+// orderFields возвращает параметры заявки для журнала событий
+func orderFields(order *models.OrderTicket, err error) map[string]string {
+	fields := map[string]string{
+		"side":        order.Side.String(),
+		"quantity":    strconv.FormatFloat(order.Quantity, 'f', -1, 64),
+		"stop_loss":   strconv.FormatFloat(order.StopLoss, 'f', -1, 64),
+		"take_profit": strconv.FormatFloat(order.TakeProfit, 'f', -1, 64),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	return fields
+}
@@ -0,0 +1,119 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/internal/state"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Guard проверяет ограничения риска перед отправкой любой заявки, независимо
+// от силы сигнала и введенного пользователем объема
+type Guard struct {
+	client     *exchange.BinanceClient
+	config     config.RiskConfig
+	quoteAsset string
+	state      *state.Risk
+}
+
+// NewGuard создает проверку ограничений риска; счетчики за день хранятся в state
+func NewGuard(client *exchange.BinanceClient, cfg config.RiskConfig, quoteAsset string, state *state.Risk) *Guard {
+	if quoteAsset == "" {
+		quoteAsset = defaultQuoteAsset
+	}
+	return &Guard{
+		client:     client,
+		config:     cfg,
+		quoteAsset: quoteAsset,
+		state:      state,
+	}
+}
+
+// Check возвращает ошибку, если заявка нарушает ограничения риска
+func (g *Guard) Check(ctx context.Context, ticket *models.OrderTicket) error {
+	cfg := g.config
+
+	// Капитал нужен для учета аварийного стопа и начала нового дня
+	equity, err := g.client.GetEquity(ctx, g.quoteAsset)
+	if err != nil {
+		return err
+	}
+	day, err := g.state.Today(timezone.Now().Format("2006-01-02"), equity)
+	if err != nil {
+		return err
+	}
+
+	if day.Halted {
+		return fmt.Errorf("аварийный стоп до конца дня: %s", day.HaltReason)
+	}
+	if cfg.KillSwitchDrawdownPct > 0 && day.StartEquity > 0 {
+		drawdown := (day.StartEquity - equity) / day.StartEquity * 100
+		if drawdown >= cfg.KillSwitchDrawdownPct {
+			reason := fmt.Sprintf("просадка капитала за день %.2f%% (лимит %.2f%%)", drawdown, cfg.KillSwitchDrawdownPct)
+			if err := g.state.Halt(reason); err != nil {
+				logger.Error("Ошибка сохранения аварийного стопа", zap.Error(err))
+			}
+			logger.Warn("Сработал аварийный стоп, заявки заблокированы до конца дня",
+				zap.Float64("start_equity", day.StartEquity), zap.Float64("equity", equity))
+			return fmt.Errorf("аварийный стоп до конца дня: %s", reason)
+		}
+	}
+
+	if cfg.MaxDailyOrders > 0 && day.Orders >= cfg.MaxDailyOrders {
+		return fmt.Errorf("достигнут лимит заявок за день: %d", cfg.MaxDailyOrders)
+	}
+
+	if cfg.MaxLeverage > 0 {
+		leverage, err := g.client.GetLeverage(ctx, ticket.Symbol)
+		if err != nil {
+			return err
+		}
+		if leverage > cfg.MaxLeverage {
+			return fmt.Errorf("плечо %s на бирже %dx больше допустимого %dx", ticket.Symbol, leverage, cfg.MaxLeverage)
+		}
+	}
+
+	if cfg.MaxPositionNotional > 0 {
+		// Учитываем уже открытую позицию: в ту же сторону объем складывается
+		amount := ticket.Side.Sign() * ticket.Quantity
+		positions, err := g.client.GetPositions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, p := range positions {
+			if p.Symbol == ticket.Symbol {
+				amount += p.Side.Sign() * p.Amount.InexactFloat64()
+			}
+		}
+
+		notional := math.Abs(amount) * ticket.EntryPrice
+		if notional > cfg.MaxPositionNotional {
+			return fmt.Errorf("объем позиции %s %.2f %s больше допустимого %.2f",
+				ticket.Symbol, notional, g.quoteAsset, cfg.MaxPositionNotional)
+		}
+	}
+
+	return nil
+}
+
+// Record учитывает отправленную заявку в дневном лимите
+func (g *Guard) Record() {
+	if err := g.state.RecordOrder(); err != nil {
+		logger.Error("Ошибка сохранения счетчика заявок", zap.Error(err))
+	}
+}
+
+// MaxQuantity ограничивает предлагаемый объем заявки лимитом объема позиции
+func (g *Guard) MaxQuantity(quantity, price float64) float64 {
+	if g.config.MaxPositionNotional <= 0 || price <= 0 {
+		return quantity
+	}
+	return math.Min(quantity, g.config.MaxPositionNotional/price)
+}
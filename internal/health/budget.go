@@ -0,0 +1,84 @@
+package health
+
+import (
+	"sort"
+	"time"
+)
+
+// Подсистемы бюджета ошибок
+const (
+	SubsystemStorage   = "storage"   // Ошибки записи, чтения и проверки хранилища
+	SubsystemAnalysis  = "analysis"  // Ошибки расчета сигнала символа
+	SubsystemWebSocket = "websocket" // Обрывы потоков WebSocket
+	SubsystemExchange  = "exchange"  // Ошибки запросов REST к бирже
+)
+
+// Сколько последних ошибок подсистемы хранить для подсчета за окно
+const maxFailures = 1000
+
+// Degradation подсистема в режиме деградации: ошибок больше бюджета
+type Degradation struct {
+	Subsystem string
+	Reason    string
+	Since     time.Time
+}
+
+var (
+	failures = make(map[string][]time.Time) // Подсистема -> время ошибок по возрастанию
+	degraded = make(map[string]Degradation)
+)
+
+// MarkFailure отмечает ошибку подсистемы
+func MarkFailure(subsystem string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	markFailure(subsystem)
+}
+
+// markFailure отмечает ошибку подсистемы. Вызывающий должен удерживать mutex.
+func markFailure(subsystem string) {
+	times := append(failures[subsystem], time.Now())
+	if len(times) > maxFailures {
+		times = times[len(times)-maxFailures:]
+	}
+	failures[subsystem] = times
+}
+
+// Failures возвращает число ошибок подсистемы не раньше since
+func Failures(subsystem string, since time.Time) int {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	times := failures[subsystem]
+	return len(times) - sort.Search(len(times), func(i int) bool { return !times[i].Before(since) })
+}
+
+// ResetFailures забывает ошибки подсистемы, например после переподключения
+func ResetFailures(subsystem string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	delete(failures, subsystem)
+}
+
+// SetDegraded переводит подсистему в режим деградации или обновляет причину
+func SetDegraded(subsystem, reason string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	d, ok := degraded[subsystem]
+	if !ok {
+		d = Degradation{Subsystem: subsystem, Since: time.Now()}
+	}
+	d.Reason = reason
+	degraded[subsystem] = d
+}
+
+// ClearDegraded снимает режим деградации подсистемы
+func ClearDegraded(subsystem string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	delete(degraded, subsystem)
+}
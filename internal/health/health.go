@@ -0,0 +1,268 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Потоки данных конвейера
+const (
+	StreamCandles      = "candles"
+	StreamOrderBook    = "orderbook"
+	StreamFunding      = "funding"
+	StreamMarkPrice    = "mark_price"
+	StreamOpenInterest = "open_interest"
+	StreamUserData     = "user_data"
+)
+
+// Severity уровень серьезности состояния
+type Severity int
+
+const (
+	SeverityOK Severity = iota
+	SeverityWarn
+	SeverityError
+)
+
+// staleness задает, через сколько без новых данных поток считается устаревшим
+// (предупреждение и ошибка). Значения учитывают частоту опроса каждого потока.
+var staleness = map[string][2]time.Duration{
+	StreamCandles:      {30 * time.Second, 2 * time.Minute},
+	StreamOrderBook:    {10 * time.Second, time.Minute},
+	StreamFunding:      {15 * time.Minute, 30 * time.Minute},
+	StreamMarkPrice:    {10 * time.Second, time.Minute},
+	StreamOpenInterest: {20 * time.Minute, 45 * time.Minute},
+}
+
+// Пороги очереди записи в хранилище
+const (
+	queueWarn  = 500
+	queueError = 5000
+)
+
+// StreamStatus состояние потока данных
+type StreamStatus struct {
+	Name        string
+	WebSocket   bool // Поток получает данные через WebSocket
+	Connections int  // Открытых WebSocket-соединений
+	LastData    time.Time
+	Errors      int
+}
+
+// Snapshot снимок состояния конвейера данных
+type Snapshot struct {
+	Streams          []StreamStatus // Отсортированы по имени
+	QueueDepth       int
+	WriteErrors      int
+	AnalysisDuration time.Duration
+	AnalysisInterval time.Duration
+	LastAnalysis     time.Time
+	Degraded         []Degradation // Подсистемы в режиме деградации, по имени
+}
+
+var (
+	mutex            sync.Mutex
+	streams          = make(map[string]*StreamStatus)
+	queueDepth       func() int
+	writeErrors      int
+	analysisDuration time.Duration
+	analysisInterval time.Duration
+	lastAnalysis     time.Time
+)
+
+// stream возвращает состояние потока, создавая его при необходимости.
+// Вызывающий должен удерживать mutex.
+func stream(name string) *StreamStatus {
+	s, ok := streams[name]
+	if !ok {
+		s = &StreamStatus{Name: name}
+		streams[name] = s
+	}
+	return s
+}
+
+// Connected отмечает открытие WebSocket-соединения потока
+func Connected(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	s := stream(name)
+	s.WebSocket = true
+	s.Connections++
+}
+
+// Disconnected отмечает закрытие WebSocket-соединения потока
+func Disconnected(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	s := stream(name)
+	s.Connections = max(0, s.Connections-1)
+}
+
+// Watch отмечает соединение открытым и закрытым после закрытия doneC
+func Watch(name string, doneC <-chan struct{}) {
+	Connected(name)
+	go func() {
+		<-doneC
+		Disconnected(name)
+	}()
+}
+
+// MarkData отмечает получение данных потоком
+func MarkData(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	stream(name).LastData = time.Now()
+}
+
+// MarkError отмечает ошибку потока. Ошибка потока WebSocket расходует бюджет
+// подсистемы websocket, ошибка опроса REST - подсистемы exchange.
+func MarkError(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	s := stream(name)
+	s.Errors++
+	if s.WebSocket {
+		markFailure(SubsystemWebSocket)
+	} else {
+		markFailure(SubsystemExchange)
+	}
+}
+
+// SetQueueDepth задает функцию получения глубины очереди записи в хранилище
+func SetQueueDepth(depth func() int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	queueDepth = depth
+}
+
+// MarkWriteError отмечает ошибку записи в хранилище
+func MarkWriteError() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	writeErrors++
+	markFailure(SubsystemStorage)
+}
+
+// SetAnalysisInterval задает интервал цикла анализа для оценки его длительности
+func SetAnalysisInterval(interval time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	analysisInterval = interval
+}
+
+// MarkAnalysis отмечает завершение цикла анализа
+func MarkAnalysis(duration time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	analysisDuration = duration
+	lastAnalysis = time.Now()
+}
+
+// MarkAnalysisIdle отмечает, что цикл анализа работает, но расчет пропущен
+// (вне торговой сессии), чтобы такой цикл не считался зависшим
+func MarkAnalysisIdle() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	lastAnalysis = time.Now()
+}
+
+// Get возвращает снимок состояния конвейера
+func Get() Snapshot {
+	mutex.Lock()
+	snapshot := Snapshot{
+		Streams:          make([]StreamStatus, 0, len(streams)),
+		WriteErrors:      writeErrors,
+		AnalysisDuration: analysisDuration,
+		AnalysisInterval: analysisInterval,
+		LastAnalysis:     lastAnalysis,
+	}
+	for _, s := range streams {
+		snapshot.Streams = append(snapshot.Streams, *s)
+	}
+	for _, d := range degraded {
+		snapshot.Degraded = append(snapshot.Degraded, d)
+	}
+	depth := queueDepth
+	mutex.Unlock()
+
+	// Функция глубины очереди может обращаться к хранилищу, вызываем ее без блокировки
+	if depth != nil {
+		snapshot.QueueDepth = depth()
+	}
+
+	sort.Slice(snapshot.Streams, func(i, j int) bool {
+		return snapshot.Streams[i].Name < snapshot.Streams[j].Name
+	})
+	sort.Slice(snapshot.Degraded, func(i, j int) bool {
+		return snapshot.Degraded[i].Subsystem < snapshot.Degraded[j].Subsystem
+	})
+	return snapshot
+}
+
+// Severity оценивает состояние потока по соединению и возрасту последних данных
+func (s StreamStatus) Severity(now time.Time) Severity {
+	if s.WebSocket && s.Connections == 0 {
+		return SeverityError
+	}
+	if s.LastData.IsZero() {
+		return SeverityWarn
+	}
+
+	limits, ok := staleness[s.Name]
+	if !ok {
+		return SeverityOK
+	}
+
+	age := now.Sub(s.LastData)
+	switch {
+	case age > limits[1]:
+		return SeverityError
+	case age > limits[0]:
+		return SeverityWarn
+	default:
+		return SeverityOK
+	}
+}
+
+// QueueSeverity оценивает глубину очереди записи
+func (s Snapshot) QueueSeverity() Severity {
+	switch {
+	case s.QueueDepth >= queueError:
+		return SeverityError
+	case s.QueueDepth >= queueWarn || s.WriteErrors > 0:
+		return SeverityWarn
+	default:
+		return SeverityOK
+	}
+}
+
+// AnalysisStalled сообщает, что анализ давно не завершался - конвейер, скорее всего, завис
+func (s Snapshot) AnalysisStalled() bool {
+	return s.AnalysisInterval > 0 && !s.LastAnalysis.IsZero() && time.Since(s.LastAnalysis) > 3*s.AnalysisInterval
+}
+
+// AnalysisSeverity оценивает длительность цикла анализа относительно его интервала
+func (s Snapshot) AnalysisSeverity() Severity {
+	if s.AnalysisInterval <= 0 {
+		return SeverityOK
+	}
+
+	switch {
+	case s.AnalysisStalled() || s.AnalysisDuration >= s.AnalysisInterval:
+		return SeverityError
+	case s.AnalysisDuration >= s.AnalysisInterval/2:
+		return SeverityWarn
+	default:
+		return SeverityOK
+	}
+}
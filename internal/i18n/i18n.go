@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultLocale локаль по умолчанию
+const DefaultLocale = "ru"
+
+//go:embed locales/*.yaml
+var catalogs embed.FS
+
+// Translator переводит ключи сообщений в строки выбранной локали
+type Translator struct {
+	locale   string
+	messages map[string]string
+	fallback map[string]string
+}
+
+// New создает переводчик для указанной локали.
+// Отсутствующие в каталоге ключи берутся из локали по умолчанию.
+func New(locale string) (*Translator, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	fallback, err := loadCatalog(DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := fallback
+	if locale != DefaultLocale {
+		messages, err = loadCatalog(locale)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Translator{
+		locale:   locale,
+		messages: messages,
+		fallback: fallback,
+	}, nil
+}
+
+// Locale возвращает код текущей локали
+func (t *Translator) Locale() string {
+	return t.locale
+}
+
+// T возвращает перевод ключа. Аргументы подставляются через fmt.Sprintf.
+// Если ключ не найден, возвращается сам ключ.
+func (t *Translator) T(key string, args ...interface{}) string {
+	msg, ok := t.messages[key]
+	if !ok {
+		msg, ok = t.fallback[key]
+	}
+	if !ok {
+		msg = key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Locales возвращает список доступных локалей
+func Locales() []string {
+	entries, err := catalogs.ReadDir("locales")
+	if err != nil {
+		return nil
+	}
+
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		locales = append(locales, name[:len(name)-len(".yaml")])
+	}
+	return locales
+}
+
+// loadCatalog загружает каталог сообщений локали
+func loadCatalog(locale string) (map[string]string, error) {
+	data, err := catalogs.ReadFile("locales/" + locale + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("неизвестная локаль %q (доступны: %v)", locale, Locales())
+	}
+
+	messages := make(map[string]string)
+	if err := yaml.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("ошибка разбора каталога сообщений %s: %w", locale, err)
+	}
+
+	return messages, nil
+}
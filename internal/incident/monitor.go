@@ -0,0 +1,279 @@
+// Package incident следит за эксплуатационными сбоями (хранилище недоступно, поток
+// WebSocket без соединения, цикл анализа не укладывается в период) и открывает по ним
+// инциденты в PagerDuty и Opsgenie отдельно от торговых оповещений.
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию
+const (
+	defaultCheckInterval   = 30 * time.Second
+	defaultStorageDown     = time.Minute
+	defaultWebSocketDown   = 5 * time.Minute
+	defaultAnalysisOverrun = 5 * time.Minute
+	pingTimeout            = 5 * time.Second  // Время на проверку хранилища
+	requestTimeout         = 10 * time.Second // Время на один запрос к системе дежурств
+	maxErrorBody           = 512              // Сколько байт ответа с ошибкой попадает в журнал
+)
+
+// Виды сбоев; к ним привязаны порог и уровень из настроек
+const (
+	KindStorage   = "storage"
+	KindWebSocket = "websocket"
+	KindAnalysis  = "analysis"
+)
+
+// Incident эксплуатационный сбой
+type Incident struct {
+	Key      string // Постоянный ключ сбоя: по нему инцидент закрывается и не дублируется
+	Kind     string
+	Summary  string
+	Severity string // critical, error, warning или info
+	Source   string // Имя хоста
+	Details  map[string]interface{}
+}
+
+// Pinger хранилище, доступность которого проверяется
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// service система дежурств
+type service interface {
+	name() string
+	trigger(ctx context.Context, incident Incident) error
+	resolve(ctx context.Context, incident Incident) error
+}
+
+// Monitor периодически проверяет состояние конвейера и открывает или закрывает инциденты
+type Monitor struct {
+	cfg      config.IncidentsConfig
+	storage  Pinger
+	services []service
+	source   string
+	since    map[string]time.Time // Ключ сбоя -> когда он впервые замечен
+	open     map[string]Incident  // Открытые инциденты
+}
+
+// NewMonitor создает наблюдение за сбоями для включенных систем дежурств
+func NewMonitor(cfg config.IncidentsConfig, storage Pinger) *Monitor {
+	client := &http.Client{Timeout: requestTimeout}
+	m := &Monitor{cfg: cfg, storage: storage, since: make(map[string]time.Time), open: make(map[string]Incident)}
+	if cfg.PagerDuty.Enabled {
+		m.services = append(m.services, &pagerDuty{cfg: cfg.PagerDuty, client: client})
+	}
+	if cfg.Opsgenie.Enabled {
+		m.services = append(m.services, &opsgenie{cfg: cfg.Opsgenie, client: client})
+	}
+	m.source, _ = os.Hostname()
+	if m.source == "" {
+		m.source = "bfma"
+	}
+	return m
+}
+
+// Start проверяет состояние до отмены контекста. Открытые инциденты при остановке
+// не закрываются: остановленный bfma сбоев не устранил.
+func (m *Monitor) Start(ctx context.Context) {
+	interval := orDefault(m.cfg.CheckInterval, defaultCheckInterval)
+	logger.Info("Запуск передачи инцидентов", zap.Int("services", len(m.services)), zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.check(ctx, time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check сравнивает текущие сбои с открытыми инцидентами
+func (m *Monitor) check(ctx context.Context, now time.Time) {
+	failing := m.failures(ctx, now)
+
+	for key, incident := range failing {
+		since, ok := m.since[key]
+		if !ok {
+			since = now
+			m.since[key] = now
+		}
+		if _, open := m.open[key]; open || now.Sub(since) < m.threshold(incident.Kind) {
+			continue
+		}
+		incident.Details["since"] = since.UTC().Format(time.RFC3339)
+		if m.notify(ctx, incident, true) {
+			m.open[key] = incident
+		}
+	}
+
+	for key := range m.since {
+		if _, ok := failing[key]; !ok {
+			delete(m.since, key)
+		}
+	}
+	for key, incident := range m.open {
+		if _, ok := failing[key]; ok {
+			continue
+		}
+		if m.notify(ctx, incident, false) {
+			delete(m.open, key)
+		}
+	}
+}
+
+// failures возвращает текущие сбои по ключам
+func (m *Monitor) failures(ctx context.Context, now time.Time) map[string]Incident {
+	snapshot := health.Get()
+	failing := make(map[string]Incident)
+	add := func(key, kind, summary string, details map[string]interface{}) {
+		failing[key] = Incident{
+			Key:      "bfma:" + m.source + ":" + key,
+			Kind:     kind,
+			Summary:  fmt.Sprintf("bfma на %s: %s", m.source, summary),
+			Severity: m.severity(kind),
+			Source:   m.source,
+			Details:  details,
+		}
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	err := m.storage.Ping(pingCtx)
+	cancel()
+	switch {
+	case err != nil:
+		add(KindStorage, KindStorage, "хранилище недоступно", map[string]interface{}{"error": err.Error()})
+	case snapshot.QueueSeverity() == health.SeverityError:
+		add(KindStorage, KindStorage, "очередь записи в хранилище переполнена",
+			map[string]interface{}{"queue_depth": snapshot.QueueDepth, "write_errors": snapshot.WriteErrors})
+	}
+
+	for _, s := range snapshot.Streams {
+		if !s.WebSocket || s.Severity(now) != health.SeverityError {
+			continue
+		}
+		details := map[string]interface{}{"stream": s.Name, "connections": s.Connections, "errors": s.Errors}
+		if !s.LastData.IsZero() {
+			details["last_data"] = s.LastData.UTC().Format(time.RFC3339)
+		}
+		add(KindWebSocket+":"+s.Name, KindWebSocket, "поток "+s.Name+" без соединения или данных", details)
+	}
+
+	if snapshot.AnalysisSeverity() == health.SeverityError {
+		summary := "цикл анализа дольше периода"
+		if snapshot.AnalysisStalled() {
+			summary = "цикл анализа завис"
+		}
+		add(KindAnalysis, KindAnalysis, summary, map[string]interface{}{
+			"duration": snapshot.AnalysisDuration.String(),
+			"interval": snapshot.AnalysisInterval.String(),
+		})
+	}
+	return failing
+}
+
+// notify открывает или закрывает инцидент во всех системах дежурств. Возвращает
+// false, если хотя бы одна не ответила: попытка повторится при следующей проверке.
+func (m *Monitor) notify(ctx context.Context, incident Incident, trigger bool) bool {
+	ok := true
+	for _, s := range m.services {
+		var err error
+		if trigger {
+			err = s.trigger(ctx, incident)
+		} else {
+			err = s.resolve(ctx, incident)
+		}
+		if err != nil {
+			ok = false
+			logger.Warn("Ошибка передачи инцидента", zap.String("service", s.name()), zap.String("key", incident.Key),
+				zap.Bool("trigger", trigger), zap.Error(err))
+		}
+	}
+	if ok {
+		logger.Info("Инцидент передан", zap.String("key", incident.Key), zap.String("severity", incident.Severity),
+			zap.Bool("trigger", trigger))
+	}
+	return ok
+}
+
+// threshold возвращает, сколько должен длиться сбой, чтобы открылся инцидент
+func (m *Monitor) threshold(kind string) time.Duration {
+	switch kind {
+	case KindStorage:
+		return orDefault(m.cfg.StorageDown, defaultStorageDown)
+	case KindWebSocket:
+		return orDefault(m.cfg.WebSocketDown, defaultWebSocketDown)
+	default:
+		return orDefault(m.cfg.AnalysisOverrun, defaultAnalysisOverrun)
+	}
+}
+
+// severity возвращает уровень сбоя из настроек или по умолчанию
+func (m *Monitor) severity(kind string) string {
+	switch kind {
+	case KindStorage:
+		return orDefaultString(m.cfg.Severity.Storage, config.IncidentCritical)
+	case KindWebSocket:
+		return orDefaultString(m.cfg.Severity.WebSocket, config.IncidentError)
+	default:
+		return orDefaultString(m.cfg.Severity.Analysis, config.IncidentWarning)
+	}
+}
+
+func orDefault(value config.Duration, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value.Std()
+	}
+	return fallback
+}
+
+func orDefaultString(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// postJSON отправляет JSON; ответ не 2xx считается ошибкой
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}, headers map[string]string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("ответ %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
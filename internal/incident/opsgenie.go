@@ -0,0 +1,72 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Адрес Opsgenie Alert API по умолчанию
+const opsgenieURL = "https://api.opsgenie.com"
+
+// Приоритеты Opsgenie по уровням серьезности
+var opsgeniePriorities = map[string]string{
+	config.IncidentCritical: "P1",
+	config.IncidentError:    "P2",
+	config.IncidentWarning:  "P3",
+	config.IncidentInfo:     "P5",
+}
+
+// opsgenieAlert оповещение Alert API
+type opsgenieAlert struct {
+	Message  string            `json:"message"`
+	Alias    string            `json:"alias"`
+	Priority string            `json:"priority"`
+	Source   string            `json:"source"`
+	Tags     []string          `json:"tags"`
+	Details  map[string]string `json:"details,omitempty"` // Opsgenie принимает только строки
+}
+
+// opsgenie открывает и закрывает оповещения через Alert API
+type opsgenie struct {
+	cfg    config.OpsgenieConfig
+	client *http.Client
+}
+
+func (o *opsgenie) name() string { return "opsgenie" }
+
+func (o *opsgenie) trigger(ctx context.Context, incident Incident) error {
+	details := make(map[string]string, len(incident.Details))
+	for name, value := range incident.Details {
+		details[name] = fmt.Sprint(value)
+	}
+	alert := opsgenieAlert{
+		Message:  incident.Summary,
+		Alias:    incident.Key,
+		Priority: opsgeniePriorities[incident.Severity],
+		Source:   incident.Source,
+		Tags:     []string{"bfma", incident.Kind},
+		Details:  details,
+	}
+	return postJSON(ctx, o.client, o.baseURL()+"/v2/alerts", alert, o.headers())
+}
+
+func (o *opsgenie) resolve(ctx context.Context, incident Incident) error {
+	endpoint := o.baseURL() + "/v2/alerts/" + url.PathEscape(incident.Key) + "/close?identifierType=alias"
+	return postJSON(ctx, o.client, endpoint, map[string]string{"source": incident.Source}, o.headers())
+}
+
+func (o *opsgenie) baseURL() string {
+	if o.cfg.URL == "" {
+		return opsgenieURL
+	}
+	return strings.TrimSuffix(o.cfg.URL, "/")
+}
+
+func (o *opsgenie) headers() map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + o.cfg.APIKey}
+}
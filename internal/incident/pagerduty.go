@@ -0,0 +1,65 @@
+package incident
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Адрес PagerDuty Events API v2 по умолчанию
+const pagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutyEvent событие Events API v2
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger или resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+// pagerDutyPayload описание инцидента; уровни совпадают с уровнями настроек
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Component     string                 `json:"component"`
+	Group         string                 `json:"group"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// pagerDuty открывает и закрывает инциденты через Events API v2
+type pagerDuty struct {
+	cfg    config.PagerDutyConfig
+	client *http.Client
+}
+
+func (p *pagerDuty) name() string { return "pagerduty" }
+
+func (p *pagerDuty) trigger(ctx context.Context, incident Incident) error {
+	return p.send(ctx, pagerDutyEvent{
+		RoutingKey:  p.cfg.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    incident.Key,
+		Payload: &pagerDutyPayload{
+			Summary:       incident.Summary,
+			Source:        incident.Source,
+			Severity:      incident.Severity,
+			Component:     incident.Kind,
+			Group:         "bfma",
+			CustomDetails: incident.Details,
+		},
+	})
+}
+
+func (p *pagerDuty) resolve(ctx context.Context, incident Incident) error {
+	return p.send(ctx, pagerDutyEvent{RoutingKey: p.cfg.RoutingKey, EventAction: "resolve", DedupKey: incident.Key})
+}
+
+func (p *pagerDuty) send(ctx context.Context, event pagerDutyEvent) error {
+	url := p.cfg.URL
+	if url == "" {
+		url = pagerDutyURL
+	}
+	return postJSON(ctx, p.client, url, event, nil)
+}
@@ -0,0 +1,192 @@
+// Package latency измеряет задержку от события биржи до сигнала по этапам: событие
+// биржи → запись в хранилище → расчет сигнала → отправка сигнала. По последним замерам
+// каждого этапа считаются p50, p95 и p99 и сравниваются с бюджетом задержки.
+package latency
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Этапы конвейера
+const (
+	StageStored   = "stored"   // Событие биржи → запись в хранилище
+	StageAnalyzed = "analyzed" // Запись в хранилище → расчет сигнала символа
+	StageEmitted  = "emitted"  // Расчет сигнала → отправка потребителям
+	StageTotal    = "total"    // Событие биржи → отправка сигнала
+)
+
+// Stages этапы в порядке прохождения
+var Stages = []string{StageStored, StageAnalyzed, StageEmitted, StageTotal}
+
+// Сколько последних замеров этапа хранить по умолчанию
+const defaultWindow = 1000
+
+// symbolTimes время прохождения этапов последними данными символа
+type symbolTimes struct {
+	event    time.Time // Время последнего события биржи
+	stored   time.Time // Когда оно записано
+	analyzed time.Time // Когда по символу рассчитан сигнал
+}
+
+// samples последние замеры этапа (кольцевой буфер) и итоги за все время
+type samples struct {
+	values []time.Duration
+	next   int
+	count  int64
+	sum    time.Duration
+}
+
+func (s *samples) add(value time.Duration, window int) {
+	if len(s.values) < window {
+		s.values = append(s.values, value)
+	} else {
+		s.values[s.next] = value
+		s.next = (s.next + 1) % len(s.values)
+	}
+	s.count++
+	s.sum += value
+}
+
+var (
+	mutex   sync.Mutex
+	window  = defaultWindow
+	budgets = make(map[string]time.Duration)
+	stages  = make(map[string]*samples)
+	symbols = make(map[string]*symbolTimes)
+)
+
+// Configure задает число хранимых замеров этапа и бюджеты p95 этапов
+func Configure(cfg config.LatencyConfig) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	window = cfg.Samples
+	if window <= 0 {
+		window = defaultWindow
+	}
+	budgets = map[string]time.Duration{
+		StageStored:   cfg.Budgets.Stored.Std(),
+		StageAnalyzed: cfg.Budgets.Analyzed.Std(),
+		StageEmitted:  cfg.Budgets.Emitted.Std(),
+		StageTotal:    cfg.Budgets.Total.Std(),
+	}
+	for _, s := range stages {
+		if len(s.values) > window {
+			s.values, s.next = s.values[len(s.values)-window:], 0
+		}
+	}
+}
+
+// observe добавляет замер этапа. Отрицательная задержка (расхождение часов с биржей)
+// считается нулевой. Вызывающий должен удерживать mutex.
+func observe(stage string, value time.Duration) {
+	s, ok := stages[stage]
+	if !ok {
+		s = &samples{}
+		stages[stage] = s
+	}
+	s.add(max(0, value), window)
+}
+
+// times возвращает время этапов символа. Вызывающий должен удерживать mutex.
+func times(symbol string) *symbolTimes {
+	t, ok := symbols[symbol]
+	if !ok {
+		t = &symbolTimes{}
+		symbols[symbol] = t
+	}
+	return t
+}
+
+// MarkStored отмечает запись в хранилище данных события биржи со временем eventTime
+func MarkStored(symbol string, eventTime time.Time) {
+	now := time.Now()
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	observe(StageStored, now.Sub(eventTime))
+	t := times(symbol)
+	t.event, t.stored = eventTime, now
+}
+
+// MarkAnalyzed отмечает расчет сигнала символа
+func MarkAnalyzed(symbol string) {
+	now := time.Now()
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	t := times(symbol)
+	if !t.stored.IsZero() {
+		observe(StageAnalyzed, now.Sub(t.stored))
+	}
+	t.analyzed = now
+}
+
+// MarkEmitted отмечает отправку сигналов символов потребителям
+func MarkEmitted(symbolList []string) {
+	now := time.Now()
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, symbol := range symbolList {
+		t := times(symbol)
+		if !t.analyzed.IsZero() {
+			observe(StageEmitted, now.Sub(t.analyzed))
+		}
+		if !t.event.IsZero() {
+			observe(StageTotal, now.Sub(t.event))
+		}
+	}
+}
+
+// Stats задержка этапа по последним замерам
+type Stats struct {
+	Stage  string
+	P50    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+	Count  int64         // Замеров за все время
+	Sum    time.Duration // Сумма замеров за все время
+	Budget time.Duration // Бюджет p95; 0 - не задан
+}
+
+// OverBudget сообщает, что p95 этапа больше бюджета
+func (s Stats) OverBudget() bool {
+	return s.Budget > 0 && s.P95 > s.Budget
+}
+
+// Get возвращает задержку этапов в порядке Stages; этапы без замеров пропускаются
+func Get() []Stats {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var result []Stats
+	for _, stage := range Stages {
+		s, ok := stages[stage]
+		if !ok || len(s.values) == 0 {
+			continue
+		}
+		sorted := append([]time.Duration(nil), s.values...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		result = append(result, Stats{
+			Stage:  stage,
+			P50:    quantile(sorted, 0.5),
+			P95:    quantile(sorted, 0.95),
+			P99:    quantile(sorted, 0.99),
+			Count:  s.count,
+			Sum:    s.sum,
+			Budget: budgets[stage],
+		})
+	}
+	return result
+}
+
+// quantile возвращает квантиль q отсортированных замеров (ближайший ранг)
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	i := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
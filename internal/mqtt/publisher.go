@@ -0,0 +1,217 @@
+// Package mqtt публикует сигналы и оповещения в брокер MQTT для домашней
+// автоматизации (Node-RED, Home Assistant) и ботов:
+//
+//	<prefix>/signals/BTCUSDT - последний сигнал, сохраняемое (retained) сообщение
+//	<prefix>/alerts/BTCUSDT  - оповещения
+//	<prefix>/status          - online или offline (завещание при обрыве связи)
+//
+// Клиент - eclipse/paho.mqtt.golang, MQTT 3.1.1 с QoS 0 и 1.
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры соединения
+const (
+	keepAlive       = 30 * time.Second       // Период PINGREQ
+	dialTimeout     = 10 * time.Second       // Время на подключение и CONNACK
+	writeTimeout    = 10 * time.Second       // Время на отправку пакета
+	initialBackoff  = time.Second            // Пауза перед повтором первого подключения
+	maxBackoff      = time.Minute            // Наибольшая пауза между переподключениями
+	disconnectQuiet = 250 * time.Millisecond // Время на отправку offline перед отключением
+	queueSize       = 256                    // Сообщений в очереди; при переполнении новые отбрасываются
+)
+
+// Состояния в теме <prefix>/status
+const (
+	statusOnline  = "online"
+	statusOffline = "offline"
+)
+
+// message сообщение для публикации
+type message struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+// alertPayload тело оповещения
+type alertPayload struct {
+	Symbol   string    `json:"symbol"`
+	Time     time.Time `json:"time"`
+	Text     string    `json:"text"`
+	Critical bool      `json:"critical"`
+}
+
+// Publisher издатель сигналов и оповещений
+type Publisher struct {
+	cfg           config.MQTTConfig
+	schemaVersion func() int
+	queue         chan message
+}
+
+// NewPublisher создает издателя
+func NewPublisher(cfg config.MQTTConfig, schemaVersion func() int) *Publisher {
+	return &Publisher{
+		cfg:           cfg,
+		schemaVersion: schemaVersion,
+		queue:         make(chan message, queueSize),
+	}
+}
+
+// Start подключается к брокеру и публикует сообщения до отмены контекста. До
+// первого подключения сообщения ждут в очереди: при чистой сессии клиент забывает
+// отложенное до подключения. После обрыва связи клиент переподключается сам,
+// неподтвержденные сообщения QoS 1 он хранит и отправляет повторно.
+func (p *Publisher) Start(ctx context.Context) {
+	logger.Info("Запуск публикации MQTT", zap.String("broker", p.brokerHost()), zap.String("prefix", p.prefix()))
+	client := paho.NewClient(p.clientOptions())
+	select {
+	case <-client.Connect().Done():
+	case <-ctx.Done():
+		client.Disconnect(0)
+		return
+	}
+
+	for {
+		select {
+		case m := <-p.queue:
+			p.publish(client, m)
+		case <-ctx.Done():
+			if client.IsConnectionOpen() {
+				client.Publish(p.prefix()+"/status", 0, true, statusOffline).WaitTimeout(disconnectQuiet)
+			}
+			client.Disconnect(uint(disconnectQuiet / time.Millisecond))
+			return
+		}
+	}
+}
+
+// clientOptions возвращает параметры клиента: завещание offline, online после
+// каждого подключения, переподключение с паузой до maxBackoff
+func (p *Publisher) clientOptions() *paho.ClientOptions {
+	return paho.NewClientOptions().
+		AddBroker(p.brokerURL()).
+		SetClientID(p.clientID()).
+		SetUsername(p.cfg.Username).
+		SetPassword(p.cfg.Password).
+		SetKeepAlive(keepAlive).
+		SetConnectTimeout(dialTimeout).
+		SetWriteTimeout(writeTimeout).
+		SetWill(p.prefix()+"/status", statusOffline, 1, true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(initialBackoff).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(maxBackoff).
+		SetOnConnectHandler(func(client paho.Client) {
+			logger.Info("Подключено к брокеру MQTT", zap.String("broker", p.brokerHost()))
+			client.Publish(p.prefix()+"/status", 1, true, statusOnline)
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			logger.Warn("Соединение с брокером MQTT прервано", zap.Error(err))
+		})
+}
+
+// publish передает сообщение клиенту с настроенным QoS. Подтверждения не ждет:
+// сообщения QoS 1 клиент доставит после переподключения.
+func (p *Publisher) publish(client paho.Client, m message) {
+	token := client.Publish(m.topic, byte(p.cfg.QoS), m.retain, m.payload)
+	if token.WaitTimeout(0) && token.Error() != nil {
+		logger.Warn("Ошибка публикации MQTT", zap.String("topic", m.topic), zap.Error(token.Error()))
+	}
+}
+
+// PublishSignals публикует новые сигналы сохраняемыми сообщениями: подписчик
+// сразу получает последний сигнал символа
+func (p *Publisher) PublishSignals(signals map[string]*models.SignalResult) {
+	for symbol, signal := range signals {
+		data, err := schema.Encode(signal, nil, p.schemaVersion())
+		if err != nil {
+			logger.Warn("Ошибка кодирования сигнала для MQTT", zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		payload, err := json.Marshal(data)
+		if err != nil {
+			continue
+		}
+		p.enqueue(message{topic: p.prefix() + "/signals/" + symbol, payload: payload, retain: p.cfg.Retain})
+	}
+}
+
+// PublishAlert публикует оповещение интерфейса
+func (p *Publisher) PublishAlert(symbol, text string, critical bool) {
+	payload, err := json.Marshal(alertPayload{Symbol: symbol, Time: timezone.In(time.Now()), Text: text, Critical: critical})
+	if err != nil {
+		return
+	}
+	p.enqueue(message{topic: p.prefix() + "/alerts/" + symbol, payload: payload})
+}
+
+// enqueue ставит сообщение в очередь. Не блокирует: при переполнении очереди
+// новые сообщения отбрасываются.
+func (p *Publisher) enqueue(m message) {
+	select {
+	case p.queue <- m:
+	default:
+		logger.Warn("Очередь MQTT переполнена, сообщение пропущено", zap.String("topic", m.topic))
+	}
+}
+
+// prefix возвращает корень тем (по умолчанию bfma)
+func (p *Publisher) prefix() string {
+	if p.cfg.TopicPrefix == "" {
+		return "bfma"
+	}
+	return p.cfg.TopicPrefix
+}
+
+// clientID возвращает идентификатор клиента (по умолчанию bfma-<имя хоста>)
+func (p *Publisher) clientID() string {
+	if p.cfg.ClientID != "" {
+		return p.cfg.ClientID
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return "bfma"
+	}
+	return "bfma-" + host
+}
+
+// brokerURL возвращает адрес брокера с портом по умолчанию: 8883 для tls://,
+// ssl:// и mqtts://, иначе 1883
+func (p *Publisher) brokerURL() string {
+	broker, err := url.Parse(p.cfg.Broker)
+	if err != nil || broker.Port() != "" {
+		return p.cfg.Broker
+	}
+	port := "1883"
+	switch broker.Scheme {
+	case "tls", "ssl", "mqtts":
+		port = "8883"
+	}
+	broker.Host = net.JoinHostPort(broker.Hostname(), port)
+	return broker.String()
+}
+
+// brokerHost возвращает адрес брокера без имени пользователя и пароля для журнала
+func (p *Publisher) brokerHost() string {
+	broker, err := url.Parse(p.cfg.Broker)
+	if err != nil {
+		return ""
+	}
+	return broker.Host
+}
@@ -0,0 +1,174 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+func TestBrokerURL(t *testing.T) {
+	for broker, want := range map[string]string{
+		"tcp://localhost":      "tcp://localhost:1883",
+		"tcp://localhost:1884": "tcp://localhost:1884",
+		"tls://broker.local":   "tls://broker.local:8883",
+		"mqtts://broker.local": "mqtts://broker.local:8883",
+	} {
+		p := NewPublisher(config.MQTTConfig{Broker: broker}, func() int { return 0 })
+		if got := p.brokerURL(); got != want {
+			t.Errorf("%s: %s, ожидалось %s", broker, got, want)
+		}
+	}
+}
+
+// runBroker запускает брокер MQTT на адресе addr ("127.0.0.1:0" - любой свободный порт)
+func runBroker(t *testing.T, addr string) (*mochi.Server, string) {
+	t.Helper()
+	server := mochi.New(nil)
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	return server, tcp.Address()
+}
+
+// subscribe подписывается на темы bfma/# и возвращает канал полученных сообщений
+func subscribe(t *testing.T, addr, clientID string) (paho.Client, <-chan paho.Message) {
+	t.Helper()
+	messages := make(chan paho.Message, 16)
+	client := paho.NewClient(paho.NewClientOptions().AddBroker("tcp://" + addr).SetClientID(clientID))
+	if token := client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("подписчик не подключился: %v", token.Error())
+	}
+	token := client.Subscribe("bfma/#", 1, func(_ paho.Client, msg paho.Message) { messages <- msg })
+	if !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("ошибка подписки: %v", token.Error())
+	}
+	return client, messages
+}
+
+// waitMessage ждет сообщение в теме topic и возвращает его; сообщения других тем
+// пропускаются
+func waitMessage(t *testing.T, messages <-chan paho.Message, topic string) paho.Message {
+	t.Helper()
+	return waitMessages(t, messages, topic)[topic]
+}
+
+// waitMessages ждет по сообщению в каждой из тем topics и возвращает последние
+// сообщения тем
+func waitMessages(t *testing.T, messages <-chan paho.Message, topics ...string) map[string]paho.Message {
+	t.Helper()
+	received := make(map[string]paho.Message)
+	timeout := time.After(10 * time.Second)
+	for len(received) < len(topics) {
+		select {
+		case msg := <-messages:
+			for _, topic := range topics {
+				if msg.Topic() == topic {
+					received[topic] = msg
+				}
+			}
+		case <-timeout:
+			t.Fatalf("получены сообщения тем %v, ожидались %v", received, topics)
+		}
+	}
+	return received
+}
+
+func TestPublisherBroker(t *testing.T) {
+	server, addr := runBroker(t, "127.0.0.1:0")
+	defer server.Close()
+
+	p := NewPublisher(config.MQTTConfig{Broker: "tcp://" + addr, ClientID: "bfma-test", QoS: 1, Retain: true}, func() int { return 0 })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Start(ctx)
+		close(done)
+	}()
+
+	p.PublishSignals(map[string]*models.SignalResult{"BTCUSDT": {
+		Symbol:             "BTCUSDT",
+		Timestamp:          time.Now(),
+		RecommendationCode: models.RecommendationBuy,
+		CurrentPrice:       84250.5,
+	}})
+	p.PublishAlert("BTCUSDT", "Сильный сигнал", true)
+
+	// Первый подписчик дожидается отправки сигнала
+	subscriber, messages := subscribe(t, addr, "test-first")
+	waitMessage(t, messages, "bfma/signals/BTCUSDT")
+	subscriber.Disconnect(0)
+
+	// Подписчик, подключившийся позже, получает сигнал и состояние сохраненными
+	// сообщениями
+	subscriber, messages = subscribe(t, addr, "test-second")
+	defer subscriber.Disconnect(0)
+
+	retained := waitMessages(t, messages, "bfma/status", "bfma/signals/BTCUSDT")
+	if msg := retained["bfma/status"]; string(msg.Payload()) != statusOnline || !msg.Retained() {
+		t.Fatalf("состояние %q (retained %v), ожидалось сохраненное online", msg.Payload(), msg.Retained())
+	}
+	signal := retained["bfma/signals/BTCUSDT"]
+	var data map[string]interface{}
+	if err := json.Unmarshal(signal.Payload(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data["symbol"] != "BTCUSDT" || !signal.Retained() {
+		t.Fatalf("неверный сигнал: %s (retained %v)", signal.Payload(), signal.Retained())
+	}
+
+	// Завершение работы публикует offline
+	cancel()
+	<-done
+	if msg := waitMessage(t, messages, "bfma/status"); string(msg.Payload()) != statusOffline {
+		t.Fatalf("состояние %q, ожидалось offline", msg.Payload())
+	}
+}
+
+func TestPublisherReconnect(t *testing.T) {
+	server, addr := runBroker(t, "127.0.0.1:0")
+
+	p := NewPublisher(config.MQTTConfig{Broker: "tcp://" + addr, ClientID: "bfma-test", QoS: 1}, func() int { return 0 })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Start(ctx)
+
+	subscriber, messages := subscribe(t, addr, "test-first")
+	waitMessage(t, messages, "bfma/status")
+	subscriber.Disconnect(0)
+
+	// Брокер перезапускается на том же адресе: издатель подключается заново,
+	// снова публикует online и доставляет новые оповещения
+	server.Close()
+	server, _ = runBroker(t, addr)
+	defer server.Close()
+
+	subscriber, messages = subscribe(t, addr, "test-second")
+	defer subscriber.Disconnect(0)
+	if msg := waitMessage(t, messages, "bfma/status"); string(msg.Payload()) != statusOnline {
+		t.Fatalf("состояние %q, ожидалось online", msg.Payload())
+	}
+	p.PublishAlert("ETHUSDT", "после переподключения", false)
+
+	var alert alertPayload
+	if err := json.Unmarshal(waitMessage(t, messages, "bfma/alerts/ETHUSDT").Payload(), &alert); err != nil {
+		t.Fatal(err)
+	}
+	if alert.Symbol != "ETHUSDT" || alert.Text != "после переподключения" {
+		t.Fatalf("неверное оповещение: %+v", alert)
+	}
+}
@@ -0,0 +1,110 @@
+// Package notify распределяет оповещения по внешним каналам (поток /ws, Telegram,
+// вебхуки, почта, MQTT, Kafka/NATS, рабочий стол): выбирает каналы по правилам, отбрасывает
+// оповещения отключенных символов, повторы и слишком частые оповещения.
+package notify
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Handler передает оповещение клиенту канала
+type Handler func(symbol, text string, critical bool)
+
+// MuteList символы с отключенными оповещениями
+type MuteList interface {
+	Muted(symbol string) bool
+}
+
+// delivery последнее оповещение символа, поданное в канал
+type delivery struct {
+	text string
+	time time.Time
+}
+
+// Dispatcher распределяет оповещения по зарегистрированным каналам
+type Dispatcher struct {
+	settings func() config.NotifyConfig
+	allowed  func(channel string) bool // Подается ли оповещение в канал сейчас (тихие часы)
+	mutes    MuteList
+	handlers map[string]Handler
+	last     map[string]delivery // Канал и символ -> последнее поданное оповещение
+	mutex    sync.Mutex
+}
+
+// NewDispatcher создает распределитель. Настройки читаются при каждом оповещении,
+// поэтому изменения notifications применяются без перезапуска. allowed и mutes
+// могут быть nil.
+func NewDispatcher(settings func() config.NotifyConfig, allowed func(channel string) bool, mutes MuteList) *Dispatcher {
+	return &Dispatcher{
+		settings: settings,
+		allowed:  allowed,
+		mutes:    mutes,
+		handlers: make(map[string]Handler),
+		last:     make(map[string]delivery),
+	}
+}
+
+// Register задает обработчик канала (config.ChannelPush, ChannelTelegram, ...)
+func (d *Dispatcher) Register(channel string, handler Handler) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.handlers[channel] = handler
+}
+
+// Dispatch подает оповещение в каналы, выбранные правилами notifications.routes.
+// Обработчики каналов не блокируют, поэтому вызываются под блокировкой: так
+// порядок оповещений в каждом канале совпадает с порядком вызовов.
+func (d *Dispatcher) Dispatch(symbol, text string, critical bool) {
+	if d.mutes != nil && d.mutes.Muted(symbol) {
+		logger.Debug("Оповещение отключенного символа не подается", zap.String("symbol", symbol))
+		return
+	}
+
+	cfg := d.settings()
+	now := time.Now()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for channel, handler := range d.handlers {
+		if !routed(cfg.Routes, channel, symbol, critical) {
+			continue
+		}
+		if d.allowed != nil && !d.allowed(channel) {
+			continue
+		}
+
+		key := channel + "/" + symbol
+		last, ok := d.last[key]
+		if ok && cfg.DedupWindow > 0 && last.text == text && now.Sub(last.time) < cfg.DedupWindow.Std() {
+			logger.Debug("Повтор оповещения не подается", zap.String("channel", channel), zap.String("symbol", symbol))
+			continue
+		}
+		if ok && !critical && cfg.MinInterval > 0 && now.Sub(last.time) < cfg.MinInterval.Std() {
+			logger.Debug("Частое оповещение не подается", zap.String("channel", channel), zap.String("symbol", symbol))
+			continue
+		}
+
+		d.last[key] = delivery{text: text, time: now}
+		handler(symbol, text, critical)
+	}
+}
+
+// routed сообщает, выбран ли канал для оповещения; без правил - все каналы
+func routed(routes []config.NotifyRoute, channel, symbol string, critical bool) bool {
+	if len(routes) == 0 {
+		return true
+	}
+	for _, route := range routes {
+		if route.Matches(symbol, critical) && slices.Contains(route.Channels, channel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Title возвращает заголовок отчета
+func (r *Report) Title() string {
+	name := "сутки"
+	if r.Period == config.ReportWeekly {
+		name = "неделю"
+	}
+	return fmt.Sprintf("Отчет bfma за %s: %s - %s", name, formatTime(r.From), formatTime(r.To))
+}
+
+// Summary возвращает краткую сводку для каналов оповещений
+func (r *Report) Summary() string {
+	total := r.Totals()
+	text := fmt.Sprintf("%s\nРасчетов %d, смен рекомендации %d (покупка %d, продажа %d), точность %s",
+		r.Title(), total.Signals, total.Changes, total.Buys, total.Sells, accuracy(total))
+	text += fmt.Sprintf("\nАномалий %d, экстремумов ставок финансирования %d", len(r.Anomalies), len(r.Funding))
+	if len(r.Funding) > 0 {
+		extreme := r.Funding[0]
+		text += fmt.Sprintf(", наибольшая %s %s", extreme.Symbol, formatRate(extreme.Rate))
+	}
+	return text
+}
+
+// Markdown возвращает отчет в Markdown
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title())
+
+	b.WriteString("## Сигналы\n\n")
+	if len(r.Symbols) == 0 {
+		b.WriteString("Сигналов за период нет.\n\n")
+	} else {
+		b.WriteString("| Символ | Расчетов | Смен | Покупка | Продажа | Сила | Точность | Ход | Ставка | Последняя |\n")
+		b.WriteString("|---|---:|---:|---:|---:|---|---:|---:|---:|---|\n")
+		for _, s := range r.Symbols {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %s | %s | %s | %s | %s |\n",
+				s.Symbol, s.Signals, s.Changes, s.Buys, s.Sells, strengthRange(s), accuracy(s), move(s), funding(s), s.Recommendation)
+		}
+		fmt.Fprintf(&b, "\nТочность - доля смен рекомендации на покупку или продажу, после которых цена через %s "+
+			"пошла в сторону сигнала; ход - средний ход цены в сторону сигнала. Ставка - ставка финансирования "+
+			"с наибольшим модулем.\n\n", formatDuration(r.Horizon))
+	}
+
+	b.WriteString("## Аномалии\n\n")
+	if len(r.Anomalies) == 0 {
+		b.WriteString("Резких скачков силы сигнала нет.\n\n")
+	}
+	for _, a := range r.Anomalies {
+		fmt.Fprintf(&b, "- %s %s: сила %.0f → %.0f, цена %s\n", formatTime(a.Time), a.Symbol, a.From, a.To, formatPrice(a.Price))
+	}
+	if len(r.Anomalies) > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Экстремумы ставок финансирования\n\n")
+	if len(r.Funding) == 0 {
+		b.WriteString("Ставки финансирования в пределах нормы.\n")
+	}
+	for _, f := range r.Funding {
+		fmt.Fprintf(&b, "- %s %s: %s\n", formatTime(f.Time), f.Symbol, formatRate(f.Rate))
+	}
+	return b.String()
+}
+
+// htmlTemplate страница отчета
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":     formatTime,
+	"price":    formatPrice,
+	"rate":     formatRate,
+	"strength": strengthRange,
+	"accuracy": accuracy,
+	"move":     move,
+	"funding":  funding,
+	"duration": formatDuration,
+	"round":    func(v float64) string { return strconv.FormatFloat(v, 'f', 0, 64) },
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; }
+td.num { text-align: right; }
+p.note { color: #666; font-size: 90%; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<h2>Сигналы</h2>
+{{if .Symbols}}<table>
+<tr><th>Символ</th><th>Расчетов</th><th>Смен</th><th>Покупка</th><th>Продажа</th><th>Сила</th><th>Точность</th><th>Ход</th><th>Ставка</th><th>Последняя</th></tr>
+{{range .Symbols}}<tr><td>{{.Symbol}}</td><td class="num">{{.Signals}}</td><td class="num">{{.Changes}}</td><td class="num">{{.Buys}}</td><td class="num">{{.Sells}}</td><td>{{strength .}}</td><td class="num">{{accuracy .}}</td><td class="num">{{move .}}</td><td class="num">{{funding .}}</td><td>{{.Recommendation}}</td></tr>
+{{end}}</table>
+<p class="note">Точность - доля смен рекомендации на покупку или продажу, после которых цена через {{duration .Horizon}} пошла в сторону сигнала; ход - средний ход цены в сторону сигнала. Ставка - ставка финансирования с наибольшим модулем.</p>
+{{else}}<p>Сигналов за период нет.</p>
+{{end}}<h2>Аномалии</h2>
+{{if .Anomalies}}<ul>
+{{range .Anomalies}}<li>{{time .Time}} {{.Symbol}}: сила {{round .From}} → {{round .To}}, цена {{price .Price}}</li>
+{{end}}</ul>
+{{else}}<p>Резких скачков силы сигнала нет.</p>
+{{end}}<h2>Экстремумы ставок финансирования</h2>
+{{if .Funding}}<ul>
+{{range .Funding}}<li>{{time .Time}} {{.Symbol}}: {{rate .Rate}}</li>
+{{end}}</ul>
+{{else}}<p>Ставки финансирования в пределах нормы.</p>
+{{end}}</body>
+</html>
+`))
+
+// HTML возвращает отчет страницей HTML
+func (r *Report) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func formatTime(t time.Time) string {
+	return timezone.In(t).Format("2006-01-02 15:04")
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%+.4f%%", rate)
+}
+
+func formatDuration(d time.Duration) string {
+	return strings.TrimSuffix(strings.TrimSuffix(d.String(), "0s"), "0m")
+}
+
+func strengthRange(s SymbolStats) string {
+	if s.Signals == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f..%.0f", s.MinStrength, s.MaxStrength)
+}
+
+func accuracy(s SymbolStats) string {
+	if s.Evaluated == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%% (%d из %d)", s.Accuracy(), s.Hits, s.Evaluated)
+}
+
+func move(s SymbolStats) string {
+	if s.Evaluated == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f%%", s.Move)
+}
+
+func funding(s SymbolStats) string {
+	if !s.HasFunding {
+		return "-"
+	}
+	return formatRate(s.FundingMax)
+}
@@ -0,0 +1,232 @@
+// Package reports формирует отчеты за сутки или неделю: активность сигналов, точность
+// смен рекомендации, аномальные скачки силы сигнала и экстремумы ставок финансирования.
+// Отчет сохраняется в Markdown или HTML и кратко подается в каналы оповещений.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Значения по умолчанию и ограничения отчета
+const (
+	defaultHorizon     = 4 * time.Hour
+	defaultAnomalyJump = 40
+	fundingLimit       = 200 // Ставок финансирования символа на неделю с запасом
+	maxAnomalies       = 20  // Аномалий в отчете; выбираются самые крупные
+)
+
+// Source данные для отчета
+type Source interface {
+	GetSignalRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.SignalResult, error)
+	GetFundingRates(ctx context.Context, symbol string, limit int) ([]*models.FundingRate, error)
+}
+
+// Options параметры расчета отчета
+type Options struct {
+	Horizon          time.Duration // Через сколько проверяется направление сигнала
+	AnomalyJump      float64       // Скачок силы сигнала, считающийся аномалией
+	FundingThreshold float64       // Экстремальная ставка финансирования, в процентах
+}
+
+// SymbolStats активность сигналов символа за период
+type SymbolStats struct {
+	Symbol         string
+	Signals        int // Расчетов сигнала
+	Changes        int // Смен рекомендации
+	Buys           int // Смен на покупку
+	Sells          int // Смен на продажу
+	MinStrength    float64
+	MaxStrength    float64
+	Evaluated      int     // Смен на покупку или продажу, для которых прошло время проверки
+	Hits           int     // Из них цена пошла в сторону сигнала
+	Move           float64 // Средний ход цены в сторону сигнала, в процентах
+	Recommendation string  // Последняя рекомендация
+	FundingMax     float64 // Ставка финансирования с наибольшим модулем, в процентах
+	HasFunding     bool
+}
+
+// Accuracy возвращает долю угаданных направлений в процентах
+func (s SymbolStats) Accuracy() float64 {
+	if s.Evaluated == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Evaluated) * 100
+}
+
+// Anomaly резкое изменение силы сигнала между соседними расчетами
+type Anomaly struct {
+	Symbol string
+	Time   time.Time
+	From   float64
+	To     float64
+	Price  float64
+}
+
+// Jump возвращает модуль скачка силы
+func (a Anomaly) Jump() float64 {
+	return math.Abs(a.To - a.From)
+}
+
+// FundingExtreme ставка финансирования за порогом
+type FundingExtreme struct {
+	Symbol string
+	Time   time.Time
+	Rate   float64 // В процентах
+}
+
+// Report отчет за период [From, To)
+type Report struct {
+	Period    string // daily или weekly
+	From      time.Time
+	To        time.Time
+	Horizon   time.Duration
+	Symbols   []SymbolStats
+	Anomalies []Anomaly
+	Funding   []FundingExtreme
+}
+
+// Totals возвращает суммарную активность по всем символам
+func (r *Report) Totals() SymbolStats {
+	var total SymbolStats
+	for _, s := range r.Symbols {
+		total.Signals += s.Signals
+		total.Changes += s.Changes
+		total.Buys += s.Buys
+		total.Sells += s.Sells
+		total.Evaluated += s.Evaluated
+		total.Hits += s.Hits
+	}
+	return total
+}
+
+// Build рассчитывает отчет по символам за период [from, to)
+func Build(ctx context.Context, source Source, symbols []string, period string, from, to time.Time, opts Options) (*Report, error) {
+	if opts.Horizon <= 0 {
+		opts.Horizon = defaultHorizon
+	}
+	if opts.AnomalyJump <= 0 {
+		opts.AnomalyJump = defaultAnomalyJump
+	}
+
+	report := &Report{Period: period, From: from, To: to, Horizon: opts.Horizon}
+	for _, symbol := range symbols {
+		signals, err := source.GetSignalRange(ctx, symbol, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения сигналов %s: %w", symbol, err)
+		}
+		rates, err := source.GetFundingRates(ctx, symbol, fundingLimit)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения ставок финансирования %s: %w", symbol, err)
+		}
+		if len(signals) == 0 && len(rates) == 0 {
+			continue
+		}
+
+		stats := signalStats(symbol, signals, opts.Horizon)
+		report.Anomalies = append(report.Anomalies, anomalies(symbol, signals, opts.AnomalyJump)...)
+		for _, rate := range rates {
+			if rate.Timestamp.Before(from) || !rate.Timestamp.Before(to) {
+				continue
+			}
+			value := rate.Percent()
+			if !stats.HasFunding || math.Abs(value) > math.Abs(stats.FundingMax) {
+				stats.FundingMax, stats.HasFunding = value, true
+			}
+			if opts.FundingThreshold > 0 && math.Abs(value) >= opts.FundingThreshold {
+				report.Funding = append(report.Funding, FundingExtreme{Symbol: symbol, Time: rate.Timestamp, Rate: value})
+			}
+		}
+		report.Symbols = append(report.Symbols, stats)
+	}
+
+	sort.Slice(report.Anomalies, func(i, j int) bool {
+		return report.Anomalies[i].Jump() > report.Anomalies[j].Jump()
+	})
+	if len(report.Anomalies) > maxAnomalies {
+		report.Anomalies = report.Anomalies[:maxAnomalies]
+	}
+	sort.Slice(report.Funding, func(i, j int) bool {
+		return math.Abs(report.Funding[i].Rate) > math.Abs(report.Funding[j].Rate)
+	})
+	return report, nil
+}
+
+// signalStats считает активность и точность сигналов символа. Проверяются смены
+// рекомендации на покупку или продажу: цена первого расчета не раньше horizon после
+// смены сравнивается с ценой смены. Первый расчет периода - точка отсчета, не смена.
+func signalStats(symbol string, signals []*models.SignalResult, horizon time.Duration) SymbolStats {
+	stats := SymbolStats{Symbol: symbol, Signals: len(signals)}
+	var moves float64
+	for i, signal := range signals {
+		if i == 0 {
+			stats.MinStrength, stats.MaxStrength = signal.SignalStrength, signal.SignalStrength
+		}
+		stats.MinStrength = min(stats.MinStrength, signal.SignalStrength)
+		stats.MaxStrength = max(stats.MaxStrength, signal.SignalStrength)
+		stats.Recommendation = signal.Recommendation
+		if i == 0 || signal.RecommendationCode == signals[i-1].RecommendationCode {
+			continue
+		}
+		stats.Changes++
+
+		direction := float64(signal.RecommendationCode.Direction())
+		switch direction {
+		case 1:
+			stats.Buys++
+		case -1:
+			stats.Sells++
+		default:
+			continue
+		}
+		later := laterSignal(signals[i+1:], signal.Timestamp.Add(horizon))
+		if later == nil || signal.CurrentPrice <= 0 {
+			continue
+		}
+		move := direction * (later.CurrentPrice - signal.CurrentPrice) / signal.CurrentPrice * 100
+		stats.Evaluated++
+		if move > 0 {
+			stats.Hits++
+		}
+		moves += move
+	}
+	if stats.Evaluated > 0 {
+		stats.Move = moves / float64(stats.Evaluated)
+	}
+	return stats
+}
+
+// anomalies возвращает скачки силы сигнала не меньше jump
+func anomalies(symbol string, signals []*models.SignalResult, jump float64) []Anomaly {
+	var found []Anomaly
+	for i := 1; i < len(signals); i++ {
+		previous, current := signals[i-1].SignalStrength, signals[i].SignalStrength
+		if math.Abs(current-previous) < jump {
+			continue
+		}
+		found = append(found, Anomaly{
+			Symbol: symbol,
+			Time:   signals[i].Timestamp,
+			From:   previous,
+			To:     current,
+			Price:  signals[i].CurrentPrice,
+		})
+	}
+	return found
+}
+
+// laterSignal возвращает первый сигнал не раньше at
+func laterSignal(signals []*models.SignalResult, at time.Time) *models.SignalResult {
+	i := sort.Search(len(signals), func(i int) bool {
+		return !signals[i].Timestamp.Before(at)
+	})
+	if i == len(signals) {
+		return nil
+	}
+	return signals[i]
+}
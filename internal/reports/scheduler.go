@@ -0,0 +1,139 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры формирования
+const (
+	// Symbol символ, с которым сводка отчета подается в каналы оповещений; по нему
+	// отчет можно направить правилом notifications.routes
+	Symbol = "bfma"
+
+	buildTimeout = time.Minute // Время на чтение данных отчета
+)
+
+// Publisher подает сводку отчета в каналы оповещений
+type Publisher func(symbol, text string, critical bool)
+
+// Scheduler формирует отчеты по расписанию
+type Scheduler struct {
+	cfg     config.ReportsConfig
+	source  Source
+	symbols func() []string // Отслеживаемые символы на момент отчета
+	options Options
+	publish Publisher // nil - сводка не подается
+}
+
+// NewScheduler создает формирование отчетов. publish вызывается при включенном reports.notify.
+func NewScheduler(cfg config.ReportsConfig, source Source, symbols func() []string, fundingThreshold float64, publish Publisher) *Scheduler {
+	return &Scheduler{
+		cfg:     cfg,
+		source:  source,
+		symbols: symbols,
+		options: Options{Horizon: cfg.Horizon.Std(), AnomalyJump: cfg.AnomalyJump, FundingThreshold: fundingThreshold},
+		publish: publish,
+	}
+}
+
+// Start формирует отчеты до отмены контекста. Отчет охватывает период, закончившийся
+// в момент формирования.
+func (s *Scheduler) Start(ctx context.Context) {
+	next := s.next(time.Now())
+	logger.Info("Запуск формирования отчетов", zap.String("period", s.cfg.Period), zap.Time("next", next))
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+		if err := s.Generate(ctx, s.previous(next), next); err != nil {
+			logger.Error("Ошибка формирования отчета", zap.Error(err))
+		}
+		next = s.next(next)
+	}
+}
+
+// Generate формирует отчет за период [from, to), сохраняет его и подает сводку
+func (s *Scheduler) Generate(ctx context.Context, from, to time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, buildTimeout)
+	defer cancel()
+
+	report, err := Build(ctx, s.source, s.symbols(), s.cfg.Period, from, to, s.options)
+	if err != nil {
+		return err
+	}
+
+	summary := report.Summary()
+	if s.cfg.Dir != "" {
+		path, err := s.save(report)
+		if err != nil {
+			return err
+		}
+		summary += "\nОтчет: " + path
+		logger.Info("Отчет сохранен", zap.String("path", path))
+	}
+	if s.cfg.Notify && s.publish != nil {
+		s.publish(Symbol, summary, false)
+	}
+	return nil
+}
+
+// save записывает отчет в каталог reports.dir и возвращает путь файла
+func (s *Scheduler) save(report *Report) (string, error) {
+	content, ext := report.Markdown(), "md"
+	if s.cfg.Format == config.ReportHTML {
+		var err error
+		if content, err = report.HTML(); err != nil {
+			return "", err
+		}
+		ext = "html"
+	}
+	if err := os.MkdirAll(s.cfg.Dir, 0755); err != nil {
+		return "", fmt.Errorf("ошибка создания каталога отчетов: %w", err)
+	}
+	name := fmt.Sprintf("bfma-%s-%s.%s", s.cfg.Period, timezone.In(report.From).Format("2006-01-02"), ext)
+	path := filepath.Join(s.cfg.Dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("ошибка записи отчета: %w", err)
+	}
+	return path, nil
+}
+
+// next возвращает ближайшее время формирования после t
+func (s *Scheduler) next(t time.Time) time.Time {
+	local := timezone.In(t)
+	clock := s.cfg.Clock()
+	at := time.Date(local.Year(), local.Month(), local.Day(), clock/60, clock%60, 0, 0, local.Location())
+	if s.cfg.Period == config.ReportWeekly {
+		at = at.AddDate(0, 0, (int(s.cfg.Day())-int(at.Weekday())+7)%7)
+	}
+	for !at.After(t) {
+		at = at.AddDate(0, 0, s.days())
+	}
+	return at
+}
+
+// previous возвращает начало периода отчета, сформированного в момент at
+func (s *Scheduler) previous(at time.Time) time.Time {
+	return at.AddDate(0, 0, -s.days())
+}
+
+// days возвращает длину периода в днях
+func (s *Scheduler) days() int {
+	if s.cfg.Period == config.ReportWeekly {
+		return 7
+	}
+	return 1
+}
@@ -0,0 +1,276 @@
+// Package scanner обзор всех фьючерсов биржи, а не только отслеживаемых символов.
+// Раз в период запрашиваются суточная статистика и ставки финансирования всех символов
+// (по одному запросу REST) и открытый интерес самых ликвидных; символы с резким ростом
+// оборота, изменением открытого интереса или крайней ставкой предлагаются в списки
+// наблюдения.
+package scanner
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию
+const (
+	defaultInterval       = 5 * time.Minute
+	defaultLookback       = time.Hour
+	defaultMinQuoteVolume = 10_000_000
+	defaultVolumeGrowth   = 25
+	defaultOIChange       = 10
+	defaultFundingBps     = 10
+	defaultOISymbols      = 50
+	defaultMaxCandidates  = 20
+)
+
+// Source данные биржи для обзора
+type Source interface {
+	GetTickers(ctx context.Context) ([]*models.MarketTicker, error)
+	GetFundingRates(ctx context.Context) ([]*models.FundingRate, error)
+	GetOpenInterest(ctx context.Context, symbol string) (*models.OpenInterest, error)
+}
+
+// sample значение показателя символа на момент обзора
+type sample struct {
+	time  time.Time
+	value float64
+}
+
+// Scanner периодический обзор рынка. История оборота и открытого интереса меняется
+// только в Scan, список кандидатов читается из других горутин под мьютексом.
+type Scanner struct {
+	cfg         config.ScannerConfig
+	source      Source
+	eligible    func(symbol string) bool // Символ можно предложить: торгуется и еще не отслеживается
+	onCandidate func(candidate *models.ScanCandidate)
+
+	volumes      map[string][]sample  // Суточный оборот по обзорам, по возрастанию времени
+	openInterest map[string][]sample  // Открытый интерес по обзорам, по возрастанию времени
+	notified     map[string]time.Time // Символ -> время последнего оповещения о нем
+
+	mutex      sync.RWMutex
+	candidates []*models.ScanCandidate
+}
+
+// NewScanner создает обзор рынка; eligible отбирает символы, которые можно предложить
+func NewScanner(cfg config.ScannerConfig, source Source, eligible func(symbol string) bool) *Scanner {
+	return &Scanner{
+		cfg:          cfg,
+		source:       source,
+		eligible:     eligible,
+		volumes:      make(map[string][]sample),
+		openInterest: make(map[string][]sample),
+		notified:     make(map[string]time.Time),
+	}
+}
+
+// SetCandidateHandler задает обработчик новых кандидатов: о символе сообщается не чаще
+// раза за lookback, пока он остается кандидатом. Вызывается до Start.
+func (s *Scanner) SetCandidateHandler(handler func(candidate *models.ScanCandidate)) {
+	s.onCandidate = handler
+}
+
+// Start выполняет обзор сразу и затем каждый период до отмены контекста
+func (s *Scanner) Start(ctx context.Context) {
+	interval := orDefault(s.cfg.Interval, defaultInterval)
+	logger.Info("Запуск обзора рынка", zap.Duration("interval", interval),
+		zap.Duration("lookback", orDefault(s.cfg.Lookback, defaultLookback)))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Scan(ctx, time.Now()); err != nil {
+			logger.Warn("Ошибка обзора рынка", zap.Error(err))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Candidates возвращает кандидатов последнего обзора, самые необычные первыми
+func (s *Scanner) Candidates() []*models.ScanCandidate {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return append([]*models.ScanCandidate(nil), s.candidates...)
+}
+
+// Scan выполняет один обзор рынка на момент now
+func (s *Scanner) Scan(ctx context.Context, now time.Time) error {
+	tickers, err := s.source.GetTickers(ctx)
+	if err != nil {
+		health.MarkFailure(health.SubsystemExchange)
+		return err
+	}
+	// Без ставок обзор продолжается по обороту и открытому интересу
+	funding := make(map[string]float64)
+	if rates, err := s.source.GetFundingRates(ctx); err != nil {
+		health.MarkFailure(health.SubsystemExchange)
+		logger.Warn("Обзор рынка без ставок финансирования", zap.Error(err))
+	} else {
+		for _, rate := range rates {
+			funding[rate.Symbol] = rate.Bps()
+		}
+	}
+
+	lookback := orDefault(s.cfg.Lookback, defaultLookback)
+	cutoff := now.Add(-lookback)
+
+	// Ликвидные символы вне отслеживаемых, самые ликвидные первыми
+	minVolume := orDefaultFloat(s.cfg.MinQuoteVolume, defaultMinQuoteVolume)
+	var liquid []*models.MarketTicker
+	for _, ticker := range tickers {
+		if ticker.QuoteVolume >= minVolume && s.eligible(ticker.Symbol) {
+			liquid = append(liquid, ticker)
+		}
+	}
+	sort.Slice(liquid, func(i, j int) bool { return liquid[i].QuoteVolume > liquid[j].QuoteVolume })
+
+	volumeGrowth := orDefaultFloat(s.cfg.VolumeGrowth, defaultVolumeGrowth)
+	oiChange := orDefaultFloat(s.cfg.OIChange, defaultOIChange)
+	fundingBps := orDefaultFloat(s.cfg.FundingBps, defaultFundingBps)
+	oiSymbols := orDefaultInt(s.cfg.OISymbols, defaultOISymbols)
+
+	var candidates []*models.ScanCandidate
+	for i, ticker := range liquid {
+		candidate := &models.ScanCandidate{
+			Symbol:         ticker.Symbol,
+			Price:          ticker.LastPrice,
+			PriceChangePct: ticker.PriceChangePct,
+			QuoteVolume:    ticker.QuoteVolume,
+			DetectedAt:     now,
+		}
+
+		// Рост суточного оборота за lookback: резкий всплеск торговли за последний час
+		if base, ok := baseline(s.volumes[ticker.Symbol], cutoff); ok && base > 0 {
+			candidate.VolumeGrowthPct = (ticker.QuoteVolume/base - 1) * 100
+			if candidate.VolumeGrowthPct >= volumeGrowth {
+				candidate.Anomalies = append(candidate.Anomalies, models.AnomalyVolume)
+				candidate.Score += candidate.VolumeGrowthPct / volumeGrowth
+			}
+		}
+		record(s.volumes, ticker.Symbol, sample{time: now, value: ticker.QuoteVolume}, cutoff)
+
+		// Открытый интерес запрашивается по символу, поэтому только у самых ликвидных
+		if i < oiSymbols {
+			if oi, err := s.source.GetOpenInterest(ctx, ticker.Symbol); err != nil {
+				logger.Debug("Обзор рынка без открытого интереса символа", zap.String("symbol", ticker.Symbol), zap.Error(err))
+			} else {
+				value := oi.Value.InexactFloat64()
+				if base, ok := baseline(s.openInterest[ticker.Symbol], cutoff); ok && base > 0 {
+					candidate.OpenInterestChangePct = (value/base - 1) * 100
+					if math.Abs(candidate.OpenInterestChangePct) >= oiChange {
+						candidate.Anomalies = append(candidate.Anomalies, models.AnomalyOpenInterest)
+						candidate.Score += math.Abs(candidate.OpenInterestChangePct) / oiChange
+					}
+				}
+				record(s.openInterest, ticker.Symbol, sample{time: now, value: value}, cutoff)
+			}
+		}
+
+		candidate.FundingBps = funding[ticker.Symbol]
+		if math.Abs(candidate.FundingBps) >= fundingBps {
+			candidate.Anomalies = append(candidate.Anomalies, models.AnomalyFunding)
+			candidate.Score += math.Abs(candidate.FundingBps) / fundingBps
+		}
+
+		if len(candidate.Anomalies) > 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if limit := orDefaultInt(s.cfg.MaxCandidates, defaultMaxCandidates); len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	s.prune(now.Add(-2 * lookback))
+
+	s.mutex.Lock()
+	s.candidates = candidates
+	s.mutex.Unlock()
+
+	logger.Debug("Обзор рынка завершен", zap.Int("symbols", len(tickers)), zap.Int("liquid", len(liquid)),
+		zap.Int("candidates", len(candidates)))
+
+	for _, candidate := range candidates {
+		if last, ok := s.notified[candidate.Symbol]; ok && now.Sub(last) < lookback {
+			continue
+		}
+		s.notified[candidate.Symbol] = now
+		if s.onCandidate != nil {
+			s.onCandidate(candidate)
+		}
+	}
+	return nil
+}
+
+// prune удаляет историю символов, которые не попадали в обзор с момента before,
+// и отметки оповещений старше before
+func (s *Scanner) prune(before time.Time) {
+	for _, history := range []map[string][]sample{s.volumes, s.openInterest} {
+		for symbol, samples := range history {
+			if samples[len(samples)-1].time.Before(before) {
+				delete(history, symbol)
+			}
+		}
+	}
+	for symbol, last := range s.notified {
+		if last.Before(before) {
+			delete(s.notified, symbol)
+		}
+	}
+}
+
+// baseline возвращает последнее значение не новее cutoff; false - обзоров такой
+// давности еще нет
+func baseline(samples []sample, cutoff time.Time) (float64, bool) {
+	for i := len(samples) - 1; i >= 0; i-- {
+		if !samples[i].time.After(cutoff) {
+			return samples[i].value, true
+		}
+	}
+	return 0, false
+}
+
+// record добавляет значение в историю символа. Из значений не новее cutoff остается
+// последнее: с ним сравниваются следующие обзоры, более старые уже не нужны.
+func record(history map[string][]sample, symbol string, value sample, cutoff time.Time) {
+	samples := append(history[symbol], value)
+	i := 0
+	for i+1 < len(samples) && !samples[i+1].time.After(cutoff) {
+		i++
+	}
+	history[symbol] = samples[i:]
+}
+
+func orDefault(value config.Duration, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value.Std()
+	}
+	return fallback
+}
+
+func orDefaultFloat(value, fallback float64) float64 {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func orDefaultInt(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
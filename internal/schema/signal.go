@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Версии схемы JSON сигналов. Новые поля SignalResult попадают только в новую версию,
+// поэтому потребители, закрепившие старую версию, получают прежний формат.
+const (
+	V1     = 1  // Исходный формат: текст рекомендации без кода, без заметок
+	V2     = 2  // Добавлены schema_version, recommendation_code и notes
+	V3     = 3  // Добавлены условия расчета: id, интервалы, окно данных, версия, веса, уверенность, признаки
+	Latest = V3 // Последняя версия
+)
+
+// Note заметка к сигналу
+type Note struct {
+	Timestamp  time.Time  `json:"timestamp"`
+	Text       string     `json:"text"`
+	SignalTime *time.Time `json:"signal_time,omitempty"`
+}
+
+// SignalV3 сигнал в формате версии 3
+type SignalV3 struct {
+	SchemaVersion      int                `json:"schema_version"`
+	ID                 string             `json:"id,omitempty"`
+	Symbol             string             `json:"symbol"`
+	Timestamp          time.Time          `json:"timestamp"`
+	Recommendation     string             `json:"recommendation"`
+	RecommendationCode string             `json:"recommendation_code"`
+	SignalStrength     float64            `json:"signal_strength"`
+	PositionSize       float64            `json:"position_size"`
+	CurrentPrice       float64            `json:"current_price"`
+	Components         map[string]float64 `json:"components"`
+	Weights            map[string]float64 `json:"weights,omitempty"`
+	Confidence         float64            `json:"confidence"`
+	Flags              []string           `json:"flags,omitempty"`
+	Intervals          []string           `json:"intervals,omitempty"`
+	DataFrom           *time.Time         `json:"data_from,omitempty"`
+	DataTo             *time.Time         `json:"data_to,omitempty"`
+	EngineVersion      string             `json:"engine_version,omitempty"`
+	ConfigHash         string             `json:"config_hash,omitempty"`
+	Notes              []Note             `json:"notes,omitempty"`
+}
+
+// SignalV2 сигнал в формате версии 2
+type SignalV2 struct {
+	SchemaVersion      int                `json:"schema_version"`
+	Symbol             string             `json:"symbol"`
+	Timestamp          time.Time          `json:"timestamp"`
+	Recommendation     string             `json:"recommendation"`
+	RecommendationCode string             `json:"recommendation_code"`
+	SignalStrength     float64            `json:"signal_strength"`
+	PositionSize       float64            `json:"position_size"`
+	CurrentPrice       float64            `json:"current_price"`
+	Components         map[string]float64 `json:"components"`
+	Notes              []Note             `json:"notes,omitempty"`
+}
+
+// SignalV1 сигнал в формате версии 1
+type SignalV1 struct {
+	Symbol         string             `json:"symbol"`
+	Timestamp      time.Time          `json:"timestamp"`
+	Recommendation string             `json:"recommendation"`
+	SignalStrength float64            `json:"signal_strength"`
+	PositionSize   float64            `json:"position_size"`
+	CurrentPrice   float64            `json:"current_price"`
+	Components     map[string]float64 `json:"components"`
+}
+
+// downgrades переводят сигнал версии n (ключ) в версию n-1
+var downgrades = map[int]func(signal interface{}) interface{}{
+	V3: func(signal interface{}) interface{} {
+		s := signal.(SignalV3)
+		return SignalV2{
+			SchemaVersion:      V2,
+			Symbol:             s.Symbol,
+			Timestamp:          s.Timestamp,
+			Recommendation:     s.Recommendation,
+			RecommendationCode: s.RecommendationCode,
+			SignalStrength:     s.SignalStrength,
+			PositionSize:       s.PositionSize,
+			CurrentPrice:       s.CurrentPrice,
+			Components:         s.Components,
+			Notes:              s.Notes,
+		}
+	},
+	V2: func(signal interface{}) interface{} {
+		s := signal.(SignalV2)
+		return SignalV1{
+			Symbol:         s.Symbol,
+			Timestamp:      s.Timestamp,
+			Recommendation: s.Recommendation,
+			SignalStrength: s.SignalStrength,
+			PositionSize:   s.PositionSize,
+			CurrentPrice:   s.CurrentPrice,
+			Components:     s.Components,
+		}
+	},
+}
+
+// Supported сообщает, поддерживается ли версия схемы; 0 означает последнюю
+func Supported(version int) bool {
+	return version >= 0 && version <= Latest
+}
+
+// Signal возвращает сигнал в последней версии схемы. Время переводится
+// в настроенный часовой пояс.
+func Signal(signal *models.SignalResult, notes []*models.Note) SignalV3 {
+	s := SignalV3{
+		SchemaVersion:      Latest,
+		ID:                 signal.ID,
+		Symbol:             signal.Symbol,
+		Timestamp:          timezone.In(signal.Timestamp),
+		Recommendation:     signal.Recommendation,
+		RecommendationCode: string(signal.RecommendationCode),
+		SignalStrength:     signal.SignalStrength,
+		PositionSize:       signal.PositionSize,
+		CurrentPrice:       signal.CurrentPrice,
+		Components:         signal.Components,
+		Weights:            signal.Weights,
+		Confidence:         signal.Confidence,
+		Flags:              signal.Flags,
+		Intervals:          signal.Intervals,
+		EngineVersion:      signal.EngineVersion,
+		ConfigHash:         signal.ConfigHash,
+	}
+	if !signal.DataFrom.IsZero() {
+		from, to := timezone.In(signal.DataFrom), timezone.In(signal.DataTo)
+		s.DataFrom, s.DataTo = &from, &to
+	}
+	for _, note := range notes {
+		n := Note{Timestamp: timezone.In(note.Timestamp), Text: note.Text}
+		if !note.SignalTime.IsZero() {
+			signalTime := timezone.In(note.SignalTime)
+			n.SignalTime = &signalTime
+		}
+		s.Notes = append(s.Notes, n)
+	}
+	return s
+}
+
+// Convert приводит сигнал последней версии к версии version (0 - последняя)
+func Convert(signal SignalV3, version int) (interface{}, error) {
+	if !Supported(version) {
+		return nil, fmt.Errorf("неподдерживаемая версия схемы сигналов %d, допустимы 1..%d", version, Latest)
+	}
+	if version == 0 {
+		version = Latest
+	}
+
+	var converted interface{} = signal
+	for v := Latest; v > version; v-- {
+		converted = downgrades[v](converted)
+	}
+	return converted, nil
+}
+
+// Encode возвращает сигнал с заметками в указанной версии схемы для сериализации в JSON
+func Encode(signal *models.SignalResult, notes []*models.Note, version int) (interface{}, error) {
+	return Convert(Signal(signal, notes), version)
+}
@@ -0,0 +1,106 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Mutes хранит символы с отключенными оповещениями и сохраняет список на диск.
+// Нулевое время окончания означает отключение до явного включения.
+type Mutes struct {
+	path    string
+	symbols map[string]time.Time
+	mutex   sync.RWMutex
+}
+
+// NewMutes загружает список символов с отключенными оповещениями из файла.
+// Отсутствие файла не считается ошибкой.
+func NewMutes(path string) (*Mutes, error) {
+	m := &Mutes{
+		path:    path,
+		symbols: make(map[string]time.Time),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения файла состояния: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &m.symbols); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла состояния: %w", err)
+	}
+
+	return m, nil
+}
+
+// Muted сообщает, отключены ли оповещения символа в текущий момент
+func (m *Mutes) Muted(symbol string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	until, ok := m.symbols[symbol]
+	return ok && (until.IsZero() || time.Now().Before(until))
+}
+
+// Symbols возвращает символы с действующим отключением и время его окончания
+func (m *Mutes) Symbols() map[string]time.Time {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	now := time.Now()
+	symbols := make(map[string]time.Time, len(m.symbols))
+	for symbol, until := range m.symbols {
+		if until.IsZero() || now.Before(until) {
+			symbols[symbol] = until
+		}
+	}
+	return symbols
+}
+
+// Mute отключает оповещения символа до until; нулевое время - до Unmute
+func (m *Mutes) Mute(symbol string, until time.Time) error {
+	m.mutex.Lock()
+	m.symbols[symbol] = until
+	m.mutex.Unlock()
+
+	return m.save()
+}
+
+// Unmute включает оповещения символа
+func (m *Mutes) Unmute(symbol string) error {
+	m.mutex.Lock()
+	delete(m.symbols, symbol)
+	m.mutex.Unlock()
+
+	return m.save()
+}
+
+// Toggle отключает оповещения символа без срока или включает их и возвращает
+// новое состояние
+func (m *Mutes) Toggle(symbol string) (bool, error) {
+	if m.Muted(symbol) {
+		return false, m.Unmute(symbol)
+	}
+	return true, m.Mute(symbol, time.Time{})
+}
+
+// save записывает действующие отключения на диск; истекшие отбрасываются
+func (m *Mutes) save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(m.Symbols(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации состояния: %w", err)
+	}
+
+	return writeFile(m.path, data)
+}
@@ -0,0 +1,151 @@
+package state
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Компоненты сигнала, вес которых можно изменить поправкой (имена секций analysis)
+var OverrideComponents = []string{"technical", "orderbook", "funding", "open_interest", "volume_delta", "external"}
+
+// Override ручная поправка символа до времени окончания: принудительная рекомендация
+// и/или множители весов компонентов
+type Override struct {
+	ID      string                `json:"id"`
+	Symbol  string                `json:"symbol"`
+	Force   models.Recommendation `json:"force,omitempty"`   // Код рекомендации: NEUTRAL, BUY, STRONG_BUY, SELL, STRONG_SELL
+	Weights map[string]float64    `json:"weights,omitempty"` // Компонент -> множитель веса
+	Reason  string                `json:"reason,omitempty"`
+	Created time.Time             `json:"created"`
+	Until   time.Time             `json:"until"`
+}
+
+// Overrides хранит ручные поправки сигналов и сохраняет их на диск; истекшие
+// поправки не действуют и отбрасываются при сохранении
+type Overrides struct {
+	path      string
+	overrides []Override
+	mutex     sync.RWMutex
+}
+
+// NewOverrides загружает ручные поправки из файла. Отсутствие файла не считается ошибкой.
+func NewOverrides(path string) (*Overrides, error) {
+	o := &Overrides{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return o, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения файла состояния: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &o.overrides); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла состояния: %w", err)
+	}
+	return o, nil
+}
+
+// Active возвращает действующие поправки символа (пустой символ - всех символов)
+// в порядке создания
+func (o *Overrides) Active(symbol string, now time.Time) []Override {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	var active []Override
+	for _, override := range o.overrides {
+		if now.Before(override.Until) && (symbol == "" || override.Symbol == symbol) {
+			active = append(active, override)
+		}
+	}
+	return active
+}
+
+// Resolve сводит действующие поправки символа: принудительная рекомендация берется из
+// последней поправки, множители одного компонента перемножаются
+func (o *Overrides) Resolve(symbol string, now time.Time) (force models.Recommendation, weights map[string]float64) {
+	for _, override := range o.Active(symbol, now) {
+		if override.Force != "" {
+			force = override.Force
+		}
+		for component, factor := range override.Weights {
+			if weights == nil {
+				weights = make(map[string]float64)
+			}
+			if current, ok := weights[component]; ok {
+				factor *= current
+			}
+			weights[component] = factor
+		}
+	}
+	return force, weights
+}
+
+// Add сохраняет поправку и возвращает ее с присвоенным идентификатором
+func (o *Overrides) Add(override Override) (Override, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return Override{}, err
+	}
+	override.ID = hex.EncodeToString(id)
+
+	o.mutex.Lock()
+	// Истекшие поправки больше не нужны
+	o.overrides = slices.DeleteFunc(o.overrides, func(existing Override) bool {
+		return !override.Created.Before(existing.Until)
+	})
+	o.overrides = append(o.overrides, override)
+	sort.SliceStable(o.overrides, func(i, j int) bool {
+		return o.overrides[i].Created.Before(o.overrides[j].Created)
+	})
+	o.mutex.Unlock()
+
+	return override, o.save()
+}
+
+// Remove отменяет поправку. Возвращает false, если поправки с таким идентификатором нет.
+func (o *Overrides) Remove(id string) (bool, error) {
+	o.mutex.Lock()
+	found := false
+	for i, override := range o.overrides {
+		if override.ID == id {
+			o.overrides = append(o.overrides[:i:i], o.overrides[i+1:]...)
+			found = true
+			break
+		}
+	}
+	o.mutex.Unlock()
+
+	if !found {
+		return false, nil
+	}
+	return true, o.save()
+}
+
+// save записывает действующие поправки на диск
+func (o *Overrides) save() error {
+	if o.path == "" {
+		return nil
+	}
+
+	active := o.Active("", time.Now())
+	if active == nil {
+		active = []Override{}
+	}
+	data, err := json.MarshalIndent(active, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации состояния: %w", err)
+	}
+
+	return writeFile(o.path, data)
+}
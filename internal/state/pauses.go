@@ -0,0 +1,107 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Pauses хранит список приостановленных символов и сохраняет его на диск
+type Pauses struct {
+	path    string
+	symbols map[string]bool
+	mutex   sync.RWMutex
+}
+
+// NewPauses загружает список приостановленных символов из файла.
+// Отсутствие файла не считается ошибкой.
+func NewPauses(path string) (*Pauses, error) {
+	p := &Pauses{
+		path:    path,
+		symbols: make(map[string]bool),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения файла состояния: %w", err)
+	}
+
+	var symbols []string
+	if err := json.Unmarshal(data, &symbols); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла состояния: %w", err)
+	}
+	for _, symbol := range symbols {
+		p.symbols[symbol] = true
+	}
+
+	return p, nil
+}
+
+// IsPaused сообщает, приостановлен ли символ
+func (p *Pauses) IsPaused(symbol string) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.symbols[symbol]
+}
+
+// Symbols возвращает отсортированный список приостановленных символов
+func (p *Pauses) Symbols() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	symbols := make([]string, 0, len(p.symbols))
+	for symbol := range p.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Pause приостанавливает символ
+func (p *Pauses) Pause(symbol string) error {
+	return p.set(symbol, true)
+}
+
+// Resume возобновляет символ
+func (p *Pauses) Resume(symbol string) error {
+	return p.set(symbol, false)
+}
+
+// Toggle переключает состояние символа и возвращает новое значение
+func (p *Pauses) Toggle(symbol string) (bool, error) {
+	paused := !p.IsPaused(symbol)
+	return paused, p.set(symbol, paused)
+}
+
+// set меняет состояние символа и сохраняет файл
+func (p *Pauses) set(symbol string, paused bool) error {
+	p.mutex.Lock()
+	if paused {
+		p.symbols[symbol] = true
+	} else {
+		delete(p.symbols, symbol)
+	}
+	p.mutex.Unlock()
+
+	return p.save()
+}
+
+// save записывает список приостановленных символов на диск
+func (p *Pauses) save() error {
+	if p.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(p.Symbols(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации состояния: %w", err)
+	}
+
+	return writeFile(p.path, data)
+}
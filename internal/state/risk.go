@@ -0,0 +1,93 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// RiskDay счетчики ограничений риска за один день
+type RiskDay struct {
+	Day         string    `json:"day"`          // Дата в формате 2006-01-02
+	Orders      int       `json:"orders"`       // Отправлено заявок за день
+	StartEquity float64   `json:"start_equity"` // Капитал на начало дня
+	Halted      bool      `json:"halted"`       // Сработал аварийный стоп
+	HaltedAt    time.Time `json:"halted_at,omitempty"`
+	HaltReason  string    `json:"halt_reason,omitempty"`
+}
+
+// Risk хранит дневные счетчики ограничений риска и сохраняет их на диск,
+// чтобы перезапуск не обнулял лимиты
+type Risk struct {
+	path  string
+	day   RiskDay
+	mutex sync.Mutex
+}
+
+// NewRisk загружает счетчики из файла. Отсутствие файла не считается ошибкой.
+func NewRisk(path string) (*Risk, error) {
+	r := &Risk{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения файла состояния: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.day); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла состояния: %w", err)
+	}
+	return r, nil
+}
+
+// Today возвращает счетчики за день day; при смене дня счетчики сбрасываются,
+// а капитал на начало дня берется из equity
+func (r *Risk) Today(day string, equity float64) (RiskDay, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.day.Day == day && r.day.StartEquity > 0 {
+		return r.day, nil
+	}
+	r.day = RiskDay{Day: day, StartEquity: equity}
+	return r.day, r.save()
+}
+
+// RecordOrder увеличивает счетчик отправленных за день заявок
+func (r *Risk) RecordOrder() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.day.Orders++
+	return r.save()
+}
+
+// Halt включает аварийный стоп до конца дня
+func (r *Risk) Halt(reason string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.day.Halted = true
+	r.day.HaltedAt = time.Now()
+	r.day.HaltReason = reason
+	return r.save()
+}
+
+// save записывает счетчики на диск; вызывается под mutex
+func (r *Risk) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.day, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации состояния: %w", err)
+	}
+
+	return writeFile(r.path, data)
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// signalsFile содержимое файла последних сигналов
+type signalsFile struct {
+	Saved   time.Time                       `json:"saved"`
+	Signals map[string]*models.SignalResult `json:"signals"` // Последний сигнал по символу
+}
+
+// Signals хранит последний рассчитанный сигнал каждого символа, чтобы после
+// перезапуска рекомендации сравнивались с прежними, а не начинались с нуля
+type Signals struct {
+	path  string
+	data  signalsFile
+	mutex sync.Mutex
+}
+
+// NewSignals загружает последние сигналы из файла. Отсутствие файла не считается ошибкой.
+func NewSignals(path string) (*Signals, error) {
+	s := &Signals{path: path, data: signalsFile{Signals: make(map[string]*models.SignalResult)}}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения файла состояния: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.data); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла состояния: %w", err)
+	}
+	if s.data.Signals == nil {
+		s.data.Signals = make(map[string]*models.SignalResult)
+	}
+	upgradeLegacySignals(data, s.data.Signals)
+	return s, nil
+}
+
+// legacySignal поля сигнала, имена которых в файле состояния изменились, когда
+// у SignalResult появились теги json (RecommendationCode → recommendation_code)
+type legacySignal struct {
+	RecommendationCode string
+	SignalStrength     float64
+	PositionSize       float64
+	CurrentPrice       float64
+}
+
+// upgradeLegacySignals дополняет сигналы файла прежнего формата полями с прежними именами
+func upgradeLegacySignals(data []byte, signals map[string]*models.SignalResult) {
+	var legacy struct {
+		Signals map[string]legacySignal
+	}
+	if json.Unmarshal(data, &legacy) != nil {
+		return
+	}
+	for symbol, old := range legacy.Signals {
+		signal, ok := signals[symbol]
+		if !ok || signal == nil || signal.RecommendationCode != "" || old.RecommendationCode == "" {
+			continue
+		}
+		signal.RecommendationCode = models.Recommendation(old.RecommendationCode)
+		signal.SignalStrength = old.SignalStrength
+		signal.PositionSize = old.PositionSize
+		signal.CurrentPrice = old.CurrentPrice
+	}
+}
+
+// Latest возвращает сохраненные сигналы и время сохранения
+func (s *Signals) Latest() (map[string]*models.SignalResult, time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	signals := make(map[string]*models.SignalResult, len(s.data.Signals))
+	for symbol, signal := range s.data.Signals {
+		signals[symbol] = signal
+	}
+	return signals, s.data.Saved
+}
+
+// Update запоминает новые сигналы и сохраняет файл
+func (s *Signals) Update(signals map[string]*models.SignalResult) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for symbol, signal := range signals {
+		s.data.Signals[symbol] = signal
+	}
+	s.data.Saved = time.Now()
+	return s.save()
+}
+
+// save записывает сигналы на диск
+func (s *Signals) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации состояния: %w", err)
+	}
+	return writeFile(s.path, data)
+}
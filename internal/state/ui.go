@@ -0,0 +1,140 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// uiFile содержимое файла настроек, измененных в интерфейсе
+type uiFile struct {
+	SplitRatio float64                  `json:"split_ratio,omitempty"` // 0 - размер панелей не менялся
+	Watchlists []config.WatchlistConfig `json:"watchlists"`            // null - списки не менялись; [] - все удалены
+	Config     *uiConfigValues          `json:"config,omitempty"`      // nil - файл старого формата
+}
+
+// uiConfigValues значения конфигурации, поверх которых сохранены изменения интерфейса
+type uiConfigValues struct {
+	SplitRatio float64                  `json:"split_ratio"`
+	Watchlists []config.WatchlistConfig `json:"watchlists"`
+}
+
+// UI хранит настройки, измененные в интерфейсе: размер панелей и списки наблюдения.
+// Они хранятся отдельно от config.yaml, чтобы файл конфигурации оставался таким, как
+// его написал пользователь (с комментариями), а значения из профилей, переменных
+// окружения и --set не переносились в базовый файл. При загрузке конфигурации они
+// заменяют соответствующие значения секции ui, пока в конфигурации остаются значения,
+// поверх которых они сделаны: правка config.yaml отменяет изменения интерфейса.
+type UI struct {
+	path  string
+	data  uiFile
+	mutex sync.Mutex
+}
+
+// NewUI загружает настройки интерфейса из файла. Отсутствие файла не считается ошибкой.
+func NewUI(path string) (*UI, error) {
+	u := &UI{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return u, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения файла состояния: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &u.data); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла состояния: %w", err)
+	}
+	return u, nil
+}
+
+// Apply заменяет в настройках ui, прочитанных из конфигурации, значения, измененные
+// в интерфейсе. Если значение в конфигурации с тех пор изменилось, действует оно,
+// а изменение интерфейса удаляется из файла состояния.
+func (u *UI) Apply(cfg *config.UIConfig) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	// В файле старого формата значения конфигурации не записаны: изменения интерфейса
+	// считаются сделанными поверх текущих
+	base := u.data.Config
+	if base == nil {
+		base = &uiConfigValues{SplitRatio: cfg.SplitRatio, Watchlists: copyWatchlists(cfg.Watchlists)}
+	}
+
+	dropped := false
+	if u.data.SplitRatio > 0 && base.SplitRatio != cfg.SplitRatio {
+		u.data.SplitRatio = 0
+		dropped = true
+	}
+	if u.data.Watchlists != nil && !reflect.DeepEqual(copyWatchlists(base.Watchlists), copyWatchlists(cfg.Watchlists)) {
+		u.data.Watchlists = nil
+		dropped = true
+	}
+	u.data.Config = &uiConfigValues{SplitRatio: cfg.SplitRatio, Watchlists: copyWatchlists(cfg.Watchlists)}
+
+	if u.data.SplitRatio > 0 {
+		cfg.SplitRatio = u.data.SplitRatio
+	}
+	if u.data.Watchlists != nil {
+		cfg.Watchlists = copyWatchlists(u.data.Watchlists)
+	}
+	if dropped {
+		return u.save()
+	}
+	return nil
+}
+
+// SetSplitRatio запоминает размер панелей; на диск он записывается Save
+func (u *UI) SetSplitRatio(ratio float64) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.data.SplitRatio = ratio
+}
+
+// SetWatchlists запоминает списки наблюдения; на диск они записываются Save
+func (u *UI) SetWatchlists(watchlists []config.WatchlistConfig) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.data.Watchlists = copyWatchlists(watchlists)
+}
+
+// Save записывает на диск текущие значения. Запись идет под мьютексом и берет
+// последние значения, поэтому параллельные вызовы не оставляют в файле более старые.
+func (u *UI) Save() error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	return u.save()
+}
+
+// save записывает текущие значения на диск; вызывается под мьютексом
+func (u *UI) save() error {
+	if u.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(u.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации состояния: %w", err)
+	}
+	return writeFile(u.path, data)
+}
+
+// copyWatchlists копирует списки наблюдения вместе с символами; пустой список
+// остается пустым, а не nil
+func copyWatchlists(watchlists []config.WatchlistConfig) []config.WatchlistConfig {
+	result := make([]config.WatchlistConfig, len(watchlists))
+	for i, wl := range watchlists {
+		wl.Symbols = append([]string(nil), wl.Symbols...)
+		result[i] = wl
+	}
+	return result
+}
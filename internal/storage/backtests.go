@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Запуск проверки на истории хранится точкой measurement backtest_runs со временем
+// запуска: параметры и показатели - JSON-полями. Сделки запуска хранятся отдельно
+// в measurement backtest_trades с тегом запуска.
+
+// SaveBacktestRun сохраняет запуск проверки и его сделки
+func (s *InfluxDBStorage) SaveBacktestRun(ctx context.Context, run *models.BacktestRun) error {
+	parameters, err := json.Marshal(run.Parameters)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования параметров запуска %s: %w", run.ID, err)
+	}
+	stats, err := json.Marshal(run.Stats)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования показателей запуска %s: %w", run.ID, err)
+	}
+	slippage, err := json.Marshal(run.Slippage)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования проскальзывания запуска %s: %w", run.ID, err)
+	}
+
+	points := []*write.Point{influxdb2.NewPoint(
+		"backtest_runs",
+		map[string]string{
+			"name": run.Name,
+		},
+		versioned(models.ModelBacktestRun, map[string]interface{}{
+			"id":              run.ID,
+			"symbols":         strings.Join(run.Symbols, ","),
+			"interval":        run.Interval.String(),
+			"from":            run.From.UnixMilli(),
+			"to":              run.To.UnixMilli(),
+			"initial_balance": run.InitialBalance,
+			"parameters":      string(parameters),
+			"stats":           string(stats),
+			"slippage":        string(slippage),
+			"finished_at":     run.FinishedAt.UnixMilli(),
+		}),
+		run.StartedAt,
+	)}
+
+	for _, trade := range run.Trades {
+		points = append(points, influxdb2.NewPoint(
+			"backtest_trades",
+			map[string]string{
+				"run":    run.ID,
+				"symbol": trade.Symbol,
+			},
+			map[string]interface{}{
+				"trade_id":    trade.TradeID,
+				"side":        trade.Side.String(),
+				"signal_id":   trade.SignalID,
+				"exit_time":   trade.ExitTime.UnixMilli(),
+				"entry_price": decimalField(trade.EntryPrice),
+				"exit_price":  decimalField(trade.ExitPrice),
+				"quantity":    decimalField(trade.Quantity),
+				"fees":        decimalField(trade.Fees),
+				"pnl":         decimalField(trade.PnL),
+				"return_pct":  trade.ReturnPct,
+				"duration_ms": trade.DurationMs,
+			},
+			trade.EntryTime,
+		))
+	}
+
+	s.writePoints(points...)
+	return nil
+}
+
+// GetBacktestRuns получает запуски проверки без сделок, новые первыми
+func (s *InfluxDBStorage) GetBacktestRuns(ctx context.Context, limit int) ([]*models.BacktestRun, error) {
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: -365d)
+			|> filter(fn: (r) => r._measurement == "backtest_runs")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> group()
+			|> sort(columns: ["_time"], desc: true)
+			|> limit(n: %d)
+	`, s.bucket, limit)
+
+	return s.queryBacktestRuns(ctx, query)
+}
+
+// GetBacktestRun получает запуск проверки со сделками; nil - запуск не найден
+func (s *InfluxDBStorage) GetBacktestRun(ctx context.Context, id string) (*models.BacktestRun, error) {
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: -365d)
+			|> filter(fn: (r) => r._measurement == "backtest_runs")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> filter(fn: (r) => r.id == "%s")
+			|> group()
+			|> limit(n: 1)
+	`, s.bucket, id)
+
+	runs, err := s.queryBacktestRuns(ctx, query)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	run := runs[0]
+
+	query = fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: -365d)
+			|> filter(fn: (r) => r._measurement == "backtest_trades")
+			|> filter(fn: (r) => r.run == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> group()
+			|> sort(columns: ["_time"])
+	`, s.bucket, id)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса сделок запуска проверки: %w", err)
+	}
+
+	for result.Next() {
+		record := result.Record()
+		values := record.Values()
+
+		trade := models.TradeRecord{EntryTime: record.Time()}
+		trade.TradeID, _ = values["trade_id"].(string)
+		trade.Symbol, _ = values["symbol"].(string)
+		side, _ := values["side"].(string)
+		trade.Side = models.Side(side)
+		trade.SignalID, _ = values["signal_id"].(string)
+		if exitTime, _ := values["exit_time"].(int64); exitTime > 0 {
+			trade.ExitTime = time.UnixMilli(exitTime)
+		}
+		trade.EntryPrice = decimalValue(values, "entry_price")
+		trade.ExitPrice = decimalValue(values, "exit_price")
+		trade.Quantity = decimalValue(values, "quantity")
+		trade.Fees = decimalValue(values, "fees")
+		trade.PnL = decimalValue(values, "pnl")
+		trade.ReturnPct, _ = values["return_pct"].(float64)
+		trade.DurationMs, _ = values["duration_ms"].(int64)
+		run.Trades = append(run.Trades, trade)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return run, nil
+}
+
+// queryBacktestRuns выполняет запрос запусков проверки
+func (s *InfluxDBStorage) queryBacktestRuns(ctx context.Context, query string) ([]*models.BacktestRun, error) {
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса запусков проверки: %w", err)
+	}
+
+	var runs []*models.BacktestRun
+	for result.Next() {
+		record := result.Record()
+		values := record.Values()
+		if !migrateRecord(models.ModelBacktestRun, values) {
+			continue
+		}
+
+		run := &models.BacktestRun{StartedAt: record.Time()}
+		run.ID, _ = values["id"].(string)
+		run.Name, _ = values["name"].(string)
+		if symbols, _ := values["symbols"].(string); symbols != "" {
+			run.Symbols = strings.Split(symbols, ",")
+		}
+		interval, _ := values["interval"].(string)
+		run.Interval = models.Interval(interval)
+		from, _ := values["from"].(int64)
+		to, _ := values["to"].(int64)
+		run.From, run.To = time.UnixMilli(from), time.UnixMilli(to)
+		run.InitialBalance, _ = values["initial_balance"].(float64)
+		finishedAt, _ := values["finished_at"].(int64)
+		run.FinishedAt = time.UnixMilli(finishedAt)
+		if parameters, _ := values["parameters"].(string); parameters != "" {
+			if err := json.Unmarshal([]byte(parameters), &run.Parameters); err != nil {
+				return nil, fmt.Errorf("ошибка декодирования параметров запуска %s: %w", run.ID, err)
+			}
+		}
+		if stats, _ := values["stats"].(string); stats != "" {
+			if err := json.Unmarshal([]byte(stats), &run.Stats); err != nil {
+				return nil, fmt.Errorf("ошибка декодирования показателей запуска %s: %w", run.ID, err)
+			}
+		}
+		if slippage, _ := values["slippage"].(string); slippage != "" && slippage != "null" {
+			if err := json.Unmarshal([]byte(slippage), &run.Slippage); err != nil {
+				return nil, fmt.Errorf("ошибка декодирования проскальзывания запуска %s: %w", run.ID, err)
+			}
+		}
+		runs = append(runs, run)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// Десятичные значения (цены и объемы свечей, ставка финансирования, открытый
+// интерес, уровни стакана) хранятся в InfluxDB строками, чтобы запись и чтение не теряли точность
+
+// decimalField возвращает значение поля InfluxDB для десятичного числа
+func decimalField(value models.Decimal) string {
+	return value.String()
+}
+
+// parseDecimalField разбирает десятичное число из поля InfluxDB. Кроме строк
+// принимаются числа: так поле могло быть записано внешними инструментами.
+func parseDecimalField(value interface{}) (models.Decimal, error) {
+	switch v := value.(type) {
+	case string:
+		return models.ParseDecimal(v)
+	case float64:
+		return models.DecimalFromFloat(v), nil
+	case int64:
+		return models.DecimalFromFloat(float64(v)), nil
+	case nil:
+		return models.ZeroDecimal, fmt.Errorf("поле не задано")
+	default:
+		return models.ZeroDecimal, fmt.Errorf("неподдерживаемый тип поля %T", value)
+	}
+}
+
+// decimalValue возвращает десятичное число из поля прочитанной записи: строкой или,
+// у записей, сохраненных до перехода на десятичные числа, числом. Отсутствующее или
+// неверное значение - ноль.
+func decimalValue(values map[string]interface{}, name string) models.Decimal {
+	d, err := parseDecimalField(values[name])
+	if err != nil {
+		return models.ZeroDecimal
+	}
+	return d
+}
+
+// candleFields возвращает поля точки свечи: цены и объемы десятичными строками
+func candleFields(candle *models.Candle) map[string]interface{} {
+	fields := map[string]interface{}{"num_trades": candle.NumTrades}
+	for name, value := range map[string]models.Decimal{
+		"open":                   candle.Open,
+		"high":                   candle.High,
+		"low":                    candle.Low,
+		"close":                  candle.Close,
+		"volume":                 candle.Volume,
+		"quote_volume":           candle.QuoteVolume,
+		"taker_buy_volume":       candle.TakerBuyVolume,
+		"taker_buy_quote_volume": candle.TakerBuyQuoteVolume,
+	} {
+		fields[name] = decimalField(value)
+	}
+	return fields
+}
+
+// versioned отмечает поля точки текущей версией модели
+func versioned(model string, fields map[string]interface{}) map[string]interface{} {
+	models.Migrations.Stamp(model, fields)
+	return fields
+}
+
+// migrateRecord приводит поля прочитанной записи к текущей версии модели. Запись,
+// которую не удалось преобразовать, пропускается с предупреждением.
+func migrateRecord(model string, values map[string]interface{}) bool {
+	if err := models.Migrations.Migrate(model, values); err != nil {
+		logger.Warn("Пропущена запись несовместимой версии", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// convertOrderBookLevels конвертирует уровни стакана в строку для хранения:
+// массив JSON, где цена и объем записаны строками
+func convertOrderBookLevels(levels []models.OrderBookLevel) string {
+	type level struct {
+		Price  string `json:"price"`
+		Amount string `json:"amount"`
+	}
+	out := make([]level, len(levels))
+	for i, l := range levels {
+		out[i] = level{Price: decimalField(l.Price), Amount: decimalField(l.Amount)}
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// parseOrderBookLevels парсит строку в уровни стакана
+func parseOrderBookLevels(data string) []models.OrderBookLevel {
+	var levels []models.OrderBookLevel
+	if err := json.Unmarshal([]byte(data), &levels); err != nil {
+		logger.Error("Ошибка парсинга стакана", zap.Error(err))
+		return []models.OrderBookLevel{}
+	}
+	return levels
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
+)
+
+// FallbackStorage запоминает результаты последних успешных выборок хранилища.
+// Если хранилище не ответило или включен режим кэша, выборка возвращается из
+// памяти: анализ продолжается на последних полученных данных. Запись передается
+// хранилищу без изменений.
+type FallbackStorage struct {
+	Storage
+	cacheOnly atomic.Bool
+	results   map[string]interface{} // Ключ выборки -> последний успешный результат
+	mutex     sync.Mutex
+}
+
+// NewFallbackStorage создает хранилище с кэшем выборок поверх storage
+func NewFallbackStorage(storage Storage) *FallbackStorage {
+	return &FallbackStorage{Storage: storage, results: make(map[string]interface{})}
+}
+
+// SetCacheOnly включает или выключает чтение только из кэша, без обращений к хранилищу.
+// Выборки, которых еще нет в кэше, по-прежнему идут в хранилище.
+func (s *FallbackStorage) SetCacheOnly(cacheOnly bool) {
+	if s.cacheOnly.Swap(cacheOnly) != cacheOnly {
+		logger.Info("Чтение из кэша вместо хранилища", zap.Bool("cache_only", cacheOnly))
+	}
+}
+
+// cached выполняет выборку read или возвращает ее последний успешный результат
+func cached[T any](s *FallbackStorage, key string, read func() (T, error)) (T, error) {
+	s.mutex.Lock()
+	last, ok := s.results[key]
+	s.mutex.Unlock()
+
+	if ok && s.cacheOnly.Load() {
+		return last.(T), nil
+	}
+
+	result, err := read()
+	if err != nil {
+		health.MarkFailure(health.SubsystemStorage)
+		if !ok {
+			return result, err
+		}
+		logger.Debug("Ошибка чтения из хранилища, используется кэш", zap.String("key", key), zap.Error(err))
+		return last.(T), nil
+	}
+
+	s.mutex.Lock()
+	s.results[key] = result
+	s.mutex.Unlock()
+	return result, nil
+}
+
+// GetCandles получает свечи из хранилища или кэша
+func (s *FallbackStorage) GetCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
+	return cached(s, fmt.Sprintf("candles|%s|%s|%d", symbol, interval, limit), func() ([]*models.Candle, error) {
+		return s.Storage.GetCandles(ctx, symbol, interval, limit)
+	})
+}
+
+// GetLatestCandles получает последние свечи из хранилища или кэша
+func (s *FallbackStorage) GetLatestCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
+	return cached(s, fmt.Sprintf("latest_candles|%s|%s|%d", symbol, interval, limit), func() ([]*models.Candle, error) {
+		return s.Storage.GetLatestCandles(ctx, symbol, interval, limit)
+	})
+}
+
+// GetLatestOrderBook получает последний стакан из хранилища или кэша
+func (s *FallbackStorage) GetLatestOrderBook(ctx context.Context, symbol string) (*models.OrderBook, error) {
+	return cached(s, "orderbook|"+symbol, func() (*models.OrderBook, error) {
+		return s.Storage.GetLatestOrderBook(ctx, symbol)
+	})
+}
+
+// GetOrderBooks получает снимки стакана из хранилища или кэша. Ключ кэша не зависит
+// от since: окно сдвигается каждый цикл, а при сбое нужны последние полученные снимки.
+func (s *FallbackStorage) GetOrderBooks(ctx context.Context, symbol string, since time.Time) ([]*models.OrderBook, error) {
+	return cached(s, "orderbooks|"+symbol, func() ([]*models.OrderBook, error) {
+		return s.Storage.GetOrderBooks(ctx, symbol, since)
+	})
+}
+
+// GetFundingRates получает ставки финансирования из хранилища или кэша
+func (s *FallbackStorage) GetFundingRates(ctx context.Context, symbol string, limit int) ([]*models.FundingRate, error) {
+	return cached(s, fmt.Sprintf("funding|%s|%d", symbol, limit), func() ([]*models.FundingRate, error) {
+		return s.Storage.GetFundingRates(ctx, symbol, limit)
+	})
+}
+
+// GetOpenInterest получает открытый интерес из хранилища или кэша
+func (s *FallbackStorage) GetOpenInterest(ctx context.Context, symbol string, limit int) ([]*models.OpenInterest, error) {
+	return cached(s, fmt.Sprintf("open_interest|%s|%d", symbol, limit), func() ([]*models.OpenInterest, error) {
+		return s.Storage.GetOpenInterest(ctx, symbol, limit)
+	})
+}
+
+// GetSignalHistory получает историю сигналов из хранилища или кэша
+func (s *FallbackStorage) GetSignalHistory(ctx context.Context, symbol string, limit int) ([]*models.SignalResult, error) {
+	return cached(s, fmt.Sprintf("signals|%s|%d", symbol, limit), func() ([]*models.SignalResult, error) {
+		return s.Storage.GetSignalHistory(ctx, symbol, limit)
+	})
+}
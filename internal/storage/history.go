@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// HistoryReader выборки истории за период [from, to) по возрастанию времени: по ним
+// воспроизводится расчет сигналов, идет проверка стратегии на истории и проверка
+// целостности данных. Стаканы возвращаются сохраненными снимками, без изменений
+// между ними.
+type HistoryReader interface {
+	GetCandleRange(ctx context.Context, symbol string, interval models.Interval, from, to time.Time) ([]*models.Candle, error)
+	GetFundingRateRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.FundingRate, error)
+	GetOpenInterestRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.OpenInterest, error)
+	GetOrderBookRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.OrderBook, error)
+}
+
+// fluxRange возвращает начало и конец периода для range() во Flux
+func fluxRange(from, to time.Time) (string, string) {
+	return from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano)
+}
+
+// GetCandleRange получает свечи, открытые в периоде [from, to), старые первыми
+func (s *InfluxDBStorage) GetCandleRange(ctx context.Context, symbol string, interval models.Interval, from, to time.Time) ([]*models.Candle, error) {
+	start, stop := fluxRange(from, to)
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "candles")
+			|> filter(fn: (r) => r.symbol == "%s")
+			|> filter(fn: (r) => r.interval == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])
+	`, s.bucket, start, stop, symbol, interval)
+
+	return s.queryCandles(ctx, symbol, interval, query)
+}
+
+// GetFundingRateRange получает ставки финансирования за период [from, to), старые первыми
+func (s *InfluxDBStorage) GetFundingRateRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.FundingRate, error) {
+	start, stop := fluxRange(from, to)
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "funding_rates")
+			|> filter(fn: (r) => r.symbol == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])
+	`, s.bucket, start, stop, symbol)
+
+	return s.queryFundingRates(ctx, symbol, query)
+}
+
+// GetOpenInterestRange получает открытый интерес за период [from, to), старые первыми
+func (s *InfluxDBStorage) GetOpenInterestRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.OpenInterest, error) {
+	start, stop := fluxRange(from, to)
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "open_interest")
+			|> filter(fn: (r) => r.symbol == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])
+	`, s.bucket, start, stop, symbol)
+
+	return s.queryOpenInterest(ctx, symbol, query)
+}
+
+// GetOrderBookRange получает снимки стакана за период [from, to), старые первыми.
+// Изменения между снимками не применяются: снимок записывается раз в минуту, и для
+// воспроизведения истории этого достаточно.
+func (s *InfluxDBStorage) GetOrderBookRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.OrderBook, error) {
+	start, stop := fluxRange(from, to)
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "orderbooks")
+			|> filter(fn: (r) => r.symbol == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])
+	`, s.bucket, start, stop, symbol)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса стаканов: %w", err)
+	}
+
+	var orderBooks []*models.OrderBook
+	for result.Next() {
+		orderBooks = append(orderBooks, orderBookFromRecord(symbol, result.Record().Time(), result.Record().Values()))
+	}
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+	return orderBooks, nil
+}
+
+// GetCandleRange возвращает свечи, открытые в периоде [from, to), старые первыми
+func (s *MemoryStorage) GetCandleRange(ctx context.Context, symbol string, interval models.Interval, from, to time.Time) ([]*models.Candle, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return inRange(s.candles[candleKey(symbol, interval)], from, to, func(c *models.Candle) time.Time { return c.OpenTime }), nil
+}
+
+// GetFundingRateRange возвращает ставки финансирования за период [from, to), старые первыми
+func (s *MemoryStorage) GetFundingRateRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.FundingRate, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return inRange(s.fundingRates[symbol], from, to, func(r *models.FundingRate) time.Time { return r.Timestamp }), nil
+}
+
+// GetOpenInterestRange возвращает открытый интерес за период [from, to), старые первыми
+func (s *MemoryStorage) GetOpenInterestRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.OpenInterest, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return inRange(s.openInterest[symbol], from, to, func(oi *models.OpenInterest) time.Time { return oi.Timestamp }), nil
+}
+
+// GetOrderBookRange возвращает снимки стакана за период [from, to), старые первыми
+func (s *MemoryStorage) GetOrderBookRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.OrderBook, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return inRange(s.orderBooks[symbol], from, to, func(ob *models.OrderBook) time.Time { return ob.Timestamp }), nil
+}
+
+// inRange возвращает записи со временем в периоде [from, to) в порядке хранения
+func inRange[T any](items []T, from, to time.Time, at func(T) time.Time) []T {
+	var result []T
+	for _, item := range items {
+		if t := at(item); !t.Before(from) && t.Before(to) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -5,21 +5,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
 	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/usage"
+	"github.com/skalibog/bfma/pkg/logger"
 	"github.com/skalibog/bfma/pkg/models"
+	"go.uber.org/zap"
 )
 
 // InfluxDBStorage реализует интерфейс Storage с использованием InfluxDB
 type InfluxDBStorage struct {
-	client   influxdb2.Client
-	queryAPI api.QueryAPI
-	writeAPI api.WriteAPI
-	org      string
-	bucket   string
+	client    influxdb2.Client
+	queryAPI  api.QueryAPI
+	writeAPI  api.WriteAPI
+	pending   atomic.Int64   // Точек, переданных на запись, но еще не отправленных
+	writes    sync.WaitGroup // Незавершенные вызовы writePoints; их дожидается Close
+	precision time.Duration  // Точность времени в строках протокола записи
+	org       string
+	bucket    string
 }
 
 // NewInfluxDBStorage создает новое хранилище InfluxDB
@@ -27,84 +38,139 @@ func NewInfluxDBStorage(cfg config.StorageConfig) (*InfluxDBStorage, error) {
 	client := influxdb2.NewClient(cfg.URL, cfg.Token)
 
 	// Проверка соединения
-	health, err := client.Health(context.Background())
+	check, err := client.Health(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("ошибка соединения с InfluxDB: %w", err)
 	}
-	if health == nil || health.Status != "pass" {
-		return nil, fmt.Errorf("InfluxDB не в состоянии 'pass': %+v", health)
+	if check == nil || check.Status != "pass" {
+		return nil, fmt.Errorf("InfluxDB не в состоянии 'pass': %+v", check)
 	}
 
 	queryAPI := client.QueryAPI(cfg.Organization)
 	writeAPI := client.WriteAPI(cfg.Organization, cfg.Bucket)
+	precision := client.Options().WriteOptions().Precision()
+
+	// Ошибки асинхронной записи иначе теряются молча
+	go func() {
+		for err := range writeAPI.Errors() {
+			health.MarkWriteError()
+			logger.Error("Ошибка записи в InfluxDB", zap.Error(err))
+		}
+	}()
 
 	return &InfluxDBStorage{
-		client:   client,
-		queryAPI: queryAPI,
-		writeAPI: writeAPI,
-		org:      cfg.Organization,
-		bucket:   cfg.Bucket,
+		client:    client,
+		queryAPI:  queryAPI,
+		writeAPI:  writeAPI,
+		precision: precision,
+		org:       cfg.Organization,
+		bucket:    cfg.Bucket,
 	}, nil
 }
 
-// Close закрывает соединение с базой данных
+// writePoints передает точки на запись и дожидается их отправки. Точка переводится
+// в строку протокола записи один раз: эта строка и отправляется, и учитывается
+// в объеме записи.
+func (s *InfluxDBStorage) writePoints(points ...*write.Point) {
+	s.writes.Add(1)
+	defer s.writes.Done()
+	s.pending.Add(int64(len(points)))
+	defer s.pending.Add(-int64(len(points)))
+
+	for _, point := range points {
+		line := write.PointToLineProtocol(point, s.precision)
+		s.writeAPI.WriteRecord(line)
+		usage.AddBytes(pointSymbol(point), len(line))
+	}
+	s.writeAPI.Flush()
+}
+
+// pointSymbol возвращает символ точки из тега symbol; пусто, если тега нет
+func pointSymbol(point *write.Point) string {
+	for _, tag := range point.TagList() {
+		if tag.Key == "symbol" {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// queryCounterKey ключ счетчика запросов в контексте
+type queryCounterKey struct{}
+
+// WithQueryCounter возвращает контекст, в котором считаются запросы к хранилищу,
+// например за один цикл анализа
+func WithQueryCounter(ctx context.Context) (context.Context, *atomic.Int64) {
+	counter := new(atomic.Int64)
+	return context.WithValue(ctx, queryCounterKey{}, counter), counter
+}
+
+// countQuery учитывает запрос в учете нагрузки и в счетчике контекста, если он есть
+func countQuery(ctx context.Context) {
+	usage.AddQuery()
+	if counter, ok := ctx.Value(queryCounterKey{}).(*atomic.Int64); ok {
+		counter.Add(1)
+	}
+}
+
+// query выполняет Flux-запрос и учитывает его в счетчике запросов контекста
+func (s *InfluxDBStorage) query(ctx context.Context, query string) (*api.QueryTableResult, error) {
+	countQuery(ctx)
+	return s.queryAPI.Query(ctx, query)
+}
+
+// PendingWrites возвращает число точек, ожидающих отправки в InfluxDB
+func (s *InfluxDBStorage) PendingWrites() int {
+	return int(s.pending.Load())
+}
+
+// Ping проверяет доступность InfluxDB
+func (s *InfluxDBStorage) Ping(ctx context.Context) error {
+	if _, err := s.client.Ping(ctx); err != nil {
+		return fmt.Errorf("InfluxDB недоступна: %w", err)
+	}
+	return nil
+}
+
+// Close дожидается отправки точек, уже переданных на запись, отправляет буфер
+// и закрывает соединение с базой данных
 func (s *InfluxDBStorage) Close() {
+	s.writes.Wait()
 	s.client.Close()
 }
 
 // SaveCandle сохраняет свечу в базу данных
 func (s *InfluxDBStorage) SaveCandle(ctx context.Context, candle *models.Candle) error {
-	// Создаем точку для записи в InfluxDB
-	point := influxdb2.NewPoint(
-		"candles",
-		map[string]string{
-			"symbol":   candle.Symbol,
-			"interval": candle.Interval,
-		},
-		map[string]interface{}{
-			"open":   candle.Open,
-			"high":   candle.High,
-			"low":    candle.Low,
-			"close":  candle.Close,
-			"volume": candle.Volume,
-		},
-		candle.OpenTime,
-	)
-
-	// Записываем точку
-	s.writeAPI.WritePoint(point)
-	s.writeAPI.Flush()
-
+	s.writePoints(candlePoint(candle))
 	return nil
 }
 
 // SaveCandles сохраняет множество свечей
 func (s *InfluxDBStorage) SaveCandles(ctx context.Context, candles []*models.Candle) error {
+	points := make([]*write.Point, 0, len(candles))
 	for _, candle := range candles {
-		point := influxdb2.NewPoint(
-			"candles",
-			map[string]string{
-				"symbol":   candle.Symbol,
-				"interval": candle.Interval,
-			},
-			map[string]interface{}{
-				"open":   candle.Open,
-				"high":   candle.High,
-				"low":    candle.Low,
-				"close":  candle.Close,
-				"volume": candle.Volume,
-			},
-			candle.OpenTime,
-		)
-		s.writeAPI.WritePoint(point)
+		points = append(points, candlePoint(candle))
 	}
 
-	s.writeAPI.Flush()
+	s.writePoints(points...)
 	return nil
 }
 
+// candlePoint создает точку свечи для записи в InfluxDB
+func candlePoint(candle *models.Candle) *write.Point {
+	return influxdb2.NewPoint(
+		"candles",
+		map[string]string{
+			"symbol":   candle.Symbol,
+			"interval": candle.Interval.String(),
+		},
+		versioned(models.ModelCandle, candleFields(candle)),
+		candle.OpenTime,
+	)
+}
+
 // GetCandles получает исторические свечи
-func (s *InfluxDBStorage) GetCandles(ctx context.Context, symbol, interval string, limit int) ([]*models.Candle, error) {
+func (s *InfluxDBStorage) GetCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
 	// Формируем Flux-запрос
 	query := fmt.Sprintf(`
 		from(bucket: "%s")
@@ -117,8 +183,13 @@ func (s *InfluxDBStorage) GetCandles(ctx context.Context, symbol, interval strin
 			|> limit(n: %d)
 	`, s.bucket, symbol, interval, limit)
 
+	return s.queryCandles(ctx, symbol, interval, query)
+}
+
+// queryCandles выполняет запрос свечей символа и интервала
+func (s *InfluxDBStorage) queryCandles(ctx context.Context, symbol string, interval models.Interval, query string) ([]*models.Candle, error) {
 	// Выполняем запрос
-	result, err := s.queryAPI.Query(ctx, query)
+	result, err := s.query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса свечей: %w", err)
 	}
@@ -127,26 +198,31 @@ func (s *InfluxDBStorage) GetCandles(ctx context.Context, symbol, interval strin
 	var candles []*models.Candle
 	for result.Next() {
 		record := result.Record()
+		if !migrateRecord(models.ModelCandle, record.Values()) {
+			continue
+		}
 
 		// Извлекаем поля
 		timestamp := record.Time()
-		open, _ := record.ValueByKey("open").(float64)
-		high, _ := record.ValueByKey("high").(float64)
-		low, _ := record.ValueByKey("low").(float64)
-		close, _ := record.ValueByKey("close").(float64)
-		volume, _ := record.ValueByKey("volume").(float64)
+		values := record.Values()
+		// Свечи, сохраненные до появления этих полей, их не содержат
+		numTrades, _ := record.ValueByKey("num_trades").(int64)
 
 		// Создаем объект свечи
 		candle := &models.Candle{
-			Symbol:    symbol,
-			Interval:  interval,
-			OpenTime:  timestamp,
-			Open:      open,
-			High:      high,
-			Low:       low,
-			Close:     close,
-			Volume:    volume,
-			CloseTime: timestamp.Add(getIntervalDuration(interval)),
+			Symbol:              symbol,
+			Interval:            interval,
+			OpenTime:            timestamp,
+			Open:                decimalValue(values, "open"),
+			High:                decimalValue(values, "high"),
+			Low:                 decimalValue(values, "low"),
+			Close:               decimalValue(values, "close"),
+			Volume:              decimalValue(values, "volume"),
+			CloseTime:           interval.Next(timestamp),
+			QuoteVolume:         decimalValue(values, "quote_volume"),
+			NumTrades:           numTrades,
+			TakerBuyVolume:      decimalValue(values, "taker_buy_volume"),
+			TakerBuyQuoteVolume: decimalValue(values, "taker_buy_quote_volume"),
 		}
 
 		candles = append(candles, candle)
@@ -161,7 +237,7 @@ func (s *InfluxDBStorage) GetCandles(ctx context.Context, symbol, interval strin
 }
 
 // GetLatestCandles получает последние свечи
-func (s *InfluxDBStorage) GetLatestCandles(ctx context.Context, symbol, interval string, limit int) ([]*models.Candle, error) {
+func (s *InfluxDBStorage) GetLatestCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
 	return s.GetCandles(ctx, symbol, interval, limit)
 }
 
@@ -174,14 +250,37 @@ func (s *InfluxDBStorage) SaveOrderBook(ctx context.Context, orderBook *models.O
 			"symbol": orderBook.Symbol,
 		},
 		map[string]interface{}{
-			"asks": convertOrderBookLevels(orderBook.Asks),
-			"bids": convertOrderBookLevels(orderBook.Bids),
+			"asks":           convertOrderBookLevels(orderBook.Asks),
+			"bids":           convertOrderBookLevels(orderBook.Bids),
+			"last_update_id": orderBook.LastUpdateID,
 		},
 		orderBook.Timestamp,
 	)
 
-	s.writeAPI.WritePoint(point)
-	s.writeAPI.Flush()
+	s.writePoints(point)
+
+	return nil
+}
+
+// SaveOrderBookDelta сохраняет изменение стакана. Между снимками хранятся только
+// изменения: GetLatestOrderBook применяет их к последнему снимку.
+func (s *InfluxDBStorage) SaveOrderBookDelta(ctx context.Context, delta *models.OrderBookDelta) error {
+	point := influxdb2.NewPoint(
+		"orderbook_deltas",
+		map[string]string{
+			"symbol": delta.Symbol,
+		},
+		map[string]interface{}{
+			"asks":            convertOrderBookLevels(delta.Asks),
+			"bids":            convertOrderBookLevels(delta.Bids),
+			"first_update_id": delta.FirstUpdateID,
+			"last_update_id":  delta.LastUpdateID,
+			"prev_update_id":  delta.PrevUpdateID,
+		},
+		delta.Timestamp,
+	)
+
+	s.writePoints(point)
 
 	return nil
 }
@@ -200,32 +299,17 @@ func (s *InfluxDBStorage) GetLatestOrderBook(ctx context.Context, symbol string)
 	`, s.bucket, symbol)
 
 	// Выполняем запрос
-	result, err := s.queryAPI.Query(ctx, query)
+	result, err := s.query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса стакана: %w", err)
 	}
 
 	// Обрабатываем результат
 	if result.Next() {
-		record := result.Record()
-
-		// Извлекаем поля
-		timestamp := record.Time()
-		asksStr, _ := record.ValueByKey("asks").(string)
-		bidsStr, _ := record.ValueByKey("bids").(string)
-
-		// Преобразуем строки в структуры
-		asks := parseOrderBookLevels(asksStr)
-		bids := parseOrderBookLevels(bidsStr)
-
-		// Создаем объект стакана
-		orderBook := &models.OrderBook{
-			Symbol:    symbol,
-			Timestamp: timestamp,
-			Asks:      asks,
-			Bids:      bids,
+		orderBook := orderBookFromRecord(symbol, result.Record().Time(), result.Record().Values())
+		if err := s.applyOrderBookDeltas(ctx, orderBook); err != nil {
+			return nil, err
 		}
-
 		return orderBook, nil
 	}
 
@@ -237,6 +321,103 @@ func (s *InfluxDBStorage) GetLatestOrderBook(ctx context.Context, symbol string)
 	return nil, fmt.Errorf("стакан заявок для %s не найден", symbol)
 }
 
+// GetOrderBooks получает снимки стакана начиная с since, новые первыми. К последнему
+// снимку применяются изменения после него, поэтому первый стакан - текущий.
+func (s *InfluxDBStorage) GetOrderBooks(ctx context.Context, symbol string, since time.Time) ([]*models.OrderBook, error) {
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s)
+			|> filter(fn: (r) => r._measurement == "orderbooks")
+			|> filter(fn: (r) => r.symbol == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"], desc: true)
+	`, s.bucket, since.UTC().Format(time.RFC3339Nano), symbol)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса стаканов: %w", err)
+	}
+
+	var orderBooks []*models.OrderBook
+	for result.Next() {
+		orderBooks = append(orderBooks, orderBookFromRecord(symbol, result.Record().Time(), result.Record().Values()))
+	}
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+	if len(orderBooks) == 0 {
+		return nil, fmt.Errorf("стакан заявок для %s не найден", symbol)
+	}
+
+	if err := s.applyOrderBookDeltas(ctx, orderBooks[0]); err != nil {
+		return nil, err
+	}
+	return orderBooks, nil
+}
+
+// orderBookFromRecord разбирает снимок стакана из записи InfluxDB
+func orderBookFromRecord(symbol string, timestamp time.Time, values map[string]interface{}) *models.OrderBook {
+	asksStr, _ := values["asks"].(string)
+	bidsStr, _ := values["bids"].(string)
+	lastUpdateID, _ := values["last_update_id"].(int64)
+
+	return &models.OrderBook{
+		Symbol:       symbol,
+		Timestamp:    timestamp,
+		Asks:         parseOrderBookLevels(asksStr),
+		Bids:         parseOrderBookLevels(bidsStr),
+		LastUpdateID: lastUpdateID,
+	}
+}
+
+// applyOrderBookDeltas применяет к снимку стакана изменения, сохраненные после него.
+// На пропуске обновлений применение останавливается: стакан остается на последнем
+// непрерывном изменении до следующего снимка.
+func (s *InfluxDBStorage) applyOrderBookDeltas(ctx context.Context, orderBook *models.OrderBook) error {
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s)
+			|> filter(fn: (r) => r._measurement == "orderbook_deltas")
+			|> filter(fn: (r) => r.symbol == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])
+	`, s.bucket, orderBook.Timestamp.Add(time.Nanosecond).UTC().Format(time.RFC3339Nano), orderBook.Symbol)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса изменений стакана: %w", err)
+	}
+
+	for result.Next() {
+		record := result.Record()
+		asksStr, _ := record.ValueByKey("asks").(string)
+		bidsStr, _ := record.ValueByKey("bids").(string)
+		firstUpdateID, _ := record.ValueByKey("first_update_id").(int64)
+		lastUpdateID, _ := record.ValueByKey("last_update_id").(int64)
+		prevUpdateID, _ := record.ValueByKey("prev_update_id").(int64)
+
+		delta := &models.OrderBookDelta{
+			Symbol:        orderBook.Symbol,
+			Timestamp:     record.Time(),
+			FirstUpdateID: firstUpdateID,
+			LastUpdateID:  lastUpdateID,
+			PrevUpdateID:  prevUpdateID,
+			Asks:          parseOrderBookLevels(asksStr),
+			Bids:          parseOrderBookLevels(bidsStr),
+		}
+		if _, err := orderBook.Apply(delta); err != nil {
+			logger.Warn("Изменения стакана после снимка применены не полностью",
+				zap.String("symbol", orderBook.Symbol), zap.Error(err))
+			break
+		}
+	}
+
+	if result.Err() != nil {
+		return fmt.Errorf("ошибка при обработке изменений стакана: %w", result.Err())
+	}
+	return nil
+}
+
 // SaveFundingRate сохраняет ставку финансирования
 func (s *InfluxDBStorage) SaveFundingRate(ctx context.Context, rate *models.FundingRate) error {
 	// Создаем точку для записи
@@ -245,15 +426,15 @@ func (s *InfluxDBStorage) SaveFundingRate(ctx context.Context, rate *models.Fund
 		map[string]string{
 			"symbol": rate.Symbol,
 		},
-		map[string]interface{}{
-			"rate":         rate.Rate,
-			"next_funding": rate.NextFundingTime,
-		},
+		versioned(models.ModelFundingRate, map[string]interface{}{
+			"rate":            decimalField(rate.Rate),
+			"rate_bps":        rate.Bps(), // Числом - для агрегаций во Flux и дашбордах
+			"next_funding_ms": rate.NextFundingTime.UnixMilli(),
+		}),
 		rate.Timestamp,
 	)
 
-	s.writeAPI.WritePoint(point)
-	s.writeAPI.Flush()
+	s.writePoints(point)
 
 	return nil
 }
@@ -271,8 +452,13 @@ func (s *InfluxDBStorage) GetFundingRates(ctx context.Context, symbol string, li
 			|> limit(n: %d)
 	`, s.bucket, symbol, limit)
 
+	return s.queryFundingRates(ctx, symbol, query)
+}
+
+// queryFundingRates выполняет запрос ставок финансирования символа
+func (s *InfluxDBStorage) queryFundingRates(ctx context.Context, symbol, query string) ([]*models.FundingRate, error) {
 	// Выполняем запрос
-	result, err := s.queryAPI.Query(ctx, query)
+	result, err := s.query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса ставок финансирования: %w", err)
 	}
@@ -281,11 +467,22 @@ func (s *InfluxDBStorage) GetFundingRates(ctx context.Context, symbol string, li
 	var rates []*models.FundingRate
 	for result.Next() {
 		record := result.Record()
+		if !migrateRecord(models.ModelFundingRate, record.Values()) {
+			continue
+		}
 
 		// Извлекаем поля
 		timestamp := record.Time()
-		rate, _ := record.ValueByKey("rate").(string)
-		nextFunding, _ := record.ValueByKey("next_funding").(time.Time)
+		rate, err := parseDecimalField(record.ValueByKey("rate"))
+		if err != nil {
+			logger.Warn("Пропущена ставка финансирования с неверным значением",
+				zap.String("symbol", symbol), zap.Time("time", timestamp), zap.Error(err))
+			continue
+		}
+		var nextFunding time.Time
+		if ms, _ := record.ValueByKey("next_funding_ms").(int64); ms > 0 {
+			nextFunding = time.UnixMilli(ms)
+		}
 
 		// Создаем объект ставки финансирования
 		fundingRate := &models.FundingRate{
@@ -314,14 +511,13 @@ func (s *InfluxDBStorage) SaveOpenInterest(ctx context.Context, oi *models.OpenI
 		map[string]string{
 			"symbol": oi.Symbol,
 		},
-		map[string]interface{}{
-			"value": oi.Value,
-		},
+		versioned(models.ModelOpenInterest, map[string]interface{}{
+			"value": decimalField(oi.Value),
+		}),
 		oi.Timestamp,
 	)
 
-	s.writeAPI.WritePoint(point)
-	s.writeAPI.Flush()
+	s.writePoints(point)
 
 	return nil
 }
@@ -339,8 +535,13 @@ func (s *InfluxDBStorage) GetOpenInterest(ctx context.Context, symbol string, li
 			|> limit(n: %d)
 	`, s.bucket, symbol, limit)
 
+	return s.queryOpenInterest(ctx, symbol, query)
+}
+
+// queryOpenInterest выполняет запрос открытого интереса символа
+func (s *InfluxDBStorage) queryOpenInterest(ctx context.Context, symbol, query string) ([]*models.OpenInterest, error) {
 	// Выполняем запрос
-	result, err := s.queryAPI.Query(ctx, query)
+	result, err := s.query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса открытого интереса: %w", err)
 	}
@@ -349,10 +550,18 @@ func (s *InfluxDBStorage) GetOpenInterest(ctx context.Context, symbol string, li
 	var openInterest []*models.OpenInterest
 	for result.Next() {
 		record := result.Record()
+		if !migrateRecord(models.ModelOpenInterest, record.Values()) {
+			continue
+		}
 
 		// Извлекаем поля
 		timestamp := record.Time()
-		value, _ := record.ValueByKey("value").(string)
+		value, err := parseDecimalField(record.ValueByKey("value"))
+		if err != nil {
+			logger.Warn("Пропущен открытый интерес с неверным значением",
+				zap.String("symbol", symbol), zap.Time("time", timestamp), zap.Error(err))
+			continue
+		}
 
 		// Создаем объект открытого интереса
 		oi := &models.OpenInterest{
@@ -378,23 +587,43 @@ func (s *InfluxDBStorage) SaveSignal(ctx context.Context, signal *models.SignalR
 
 	componentsJSON, _ := json.Marshal(signal.Components)
 
-	point := influxdb2.NewPoint(
-		"signals",
-		map[string]string{
-			"symbol": signal.Symbol,
-		},
-		map[string]interface{}{
-			"recommendation": signal.Recommendation,
-			"strength":       signal.SignalStrength,
-			"position_size":  signal.PositionSize,
-			"price":          signal.CurrentPrice,
-			"components":     string(componentsJSON),
-		},
-		signal.Timestamp,
-	)
+	fields := map[string]interface{}{
+		"recommendation":      signal.Recommendation,
+		"recommendation_code": signal.RecommendationCode.String(),
+		"strength":            signal.SignalStrength,
+		"position_size":       signal.PositionSize,
+		"price":               signal.CurrentPrice,
+		"components":          string(componentsJSON),
+		"confidence":          signal.Confidence,
+	}
+	// Условия расчета; списки хранятся строками через запятую, веса - строкой JSON
+	if signal.ID != "" {
+		fields["id"] = signal.ID
+	}
+	if len(signal.Intervals) > 0 {
+		fields["intervals"] = strings.Join(signal.Intervals, ",")
+	}
+	if !signal.DataFrom.IsZero() {
+		fields["data_from"] = signal.DataFrom.UnixMilli()
+		fields["data_to"] = signal.DataTo.UnixMilli()
+	}
+	if signal.EngineVersion != "" {
+		fields["engine_version"] = signal.EngineVersion
+	}
+	if signal.ConfigHash != "" {
+		fields["config_hash"] = signal.ConfigHash
+	}
+	if len(signal.Weights) > 0 {
+		weightsJSON, _ := json.Marshal(signal.Weights)
+		fields["weights"] = string(weightsJSON)
+	}
+	if len(signal.Flags) > 0 {
+		fields["flags"] = strings.Join(signal.Flags, ",")
+	}
 
-	s.writeAPI.WritePoint(point)
-	s.writeAPI.Flush()
+	point := influxdb2.NewPoint("signals", map[string]string{"symbol": signal.Symbol}, versioned(models.ModelSignal, fields), signal.Timestamp)
+
+	s.writePoints(point)
 
 	return nil
 }
@@ -412,8 +641,123 @@ func (s *InfluxDBStorage) GetSignalHistory(ctx context.Context, symbol string, l
 			|> limit(n: %d)
 	`, s.bucket, symbol, limit)
 
+	return s.querySignals(ctx, symbol, query)
+}
+
+// GetSignalRange получает сигналы символа за период [from, to) в порядке времени
+func (s *InfluxDBStorage) GetSignalRange(ctx context.Context, symbol string, from, to time.Time) ([]*models.SignalResult, error) {
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "signals")
+			|> filter(fn: (r) => r.symbol == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])
+	`, s.bucket, from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano), symbol)
+
+	return s.querySignals(ctx, symbol, query)
+}
+
+// QuerySignals получает страницу истории сигналов по условиям query. Сигналы,
+// сохраненные до появления кодов рекомендаций, фильтром по рекомендации не выбираются.
+func (s *InfluxDBStorage) QuerySignals(ctx context.Context, query models.SignalQuery) (*models.SignalPage, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	cursor, _ := query.ParseCursor()
+
+	from, to := query.From, query.To
+	var filters []string
+	if len(query.Symbols) > 0 {
+		conditions := make([]string, len(query.Symbols))
+		for i, symbol := range query.Symbols {
+			conditions[i] = fmt.Sprintf(`r.symbol == %q`, symbol)
+		}
+		filters = append(filters, strings.Join(conditions, " or "))
+	}
+	if query.MinStrength > 0 {
+		filters = append(filters, fmt.Sprintf(`r.strength >= %[1]g or r.strength <= -%[1]g`, query.MinStrength))
+	}
+	if len(query.Recommendations) > 0 {
+		conditions := make([]string, len(query.Recommendations))
+		for i, recommendation := range query.Recommendations {
+			conditions[i] = fmt.Sprintf(`r.recommendation_code == %q`, recommendation)
+		}
+		filters = append(filters, strings.Join(conditions, " or "))
+	}
+	// Курсор сужает период, а при равном времени отсекает символы до курсора
+	if cursor != nil {
+		comparison := "<"
+		if query.Order == models.SignalOrderAsc {
+			comparison = ">"
+			if cursor.Timestamp.After(from) {
+				from = cursor.Timestamp
+			}
+		} else if stop := cursor.Timestamp.Add(time.Nanosecond); stop.Before(to) {
+			to = stop
+		}
+		filters = append(filters, fmt.Sprintf(`r._time != time(v: %q) or r.symbol %s %q`,
+			cursor.Timestamp.Format(time.RFC3339Nano), comparison, cursor.Symbol))
+	}
+	if !from.Before(to) {
+		return query.Page(nil), nil
+	}
+
+	var pipeline string
+	for _, filter := range filters {
+		pipeline += fmt.Sprintf("|> filter(fn: (r) => %s)\n", filter)
+	}
+
+	flux := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "signals")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			%s
+			|> group()
+			|> sort(columns: ["_time", "symbol"], desc: %t)
+			|> limit(n: %d)
+	`, s.bucket, from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano),
+		pipeline, query.Order == models.SignalOrderDesc, query.Limit+1)
+
+	// Лишний сигнал сверх страницы показывает, что есть следующая страница
+	signals, err := s.querySignals(ctx, "", flux)
+	if err != nil {
+		return nil, err
+	}
+	return query.Page(signals), nil
+}
+
+// readSignalConditions разбирает условия расчета сигнала; у сигналов, сохраненных
+// до их появления, полей нет
+func readSignalConditions(values map[string]interface{}, signal *models.SignalResult) {
+	signal.ID, _ = values["id"].(string)
+	signal.EngineVersion, _ = values["engine_version"].(string)
+	signal.ConfigHash, _ = values["config_hash"].(string)
+	signal.Confidence, _ = values["confidence"].(float64)
+	if intervals, ok := values["intervals"].(string); ok && intervals != "" {
+		signal.Intervals = strings.Split(intervals, ",")
+	}
+	if flags, ok := values["flags"].(string); ok && flags != "" {
+		signal.Flags = strings.Split(flags, ",")
+	}
+	if from, ok := values["data_from"].(int64); ok {
+		signal.DataFrom = time.UnixMilli(from)
+	}
+	if to, ok := values["data_to"].(int64); ok {
+		signal.DataTo = time.UnixMilli(to)
+	}
+	if weights, ok := values["weights"].(string); ok && weights != "" {
+		if err := json.Unmarshal([]byte(weights), &signal.Weights); err != nil {
+			logger.Warn("Ошибка разбора весов сигнала", zap.String("symbol", signal.Symbol), zap.Error(err))
+		}
+	}
+}
+
+// querySignals выполняет запрос сигналов и разбирает результаты
+func (s *InfluxDBStorage) querySignals(ctx context.Context, symbol, query string) ([]*models.SignalResult, error) {
 	// Выполняем запрос
-	result, err := s.queryAPI.Query(ctx, query)
+	result, err := s.query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса истории сигналов: %w", err)
 	}
@@ -422,24 +766,43 @@ func (s *InfluxDBStorage) GetSignalHistory(ctx context.Context, symbol string, l
 	var signals []*models.SignalResult
 	for result.Next() {
 		record := result.Record()
+		if !migrateRecord(models.ModelSignal, record.Values()) {
+			continue
+		}
 
 		// Извлекаем поля
 		timestamp := record.Time()
 		recommendation, _ := record.ValueByKey("recommendation").(string)
+		recommendationCode, _ := record.ValueByKey("recommendation_code").(string)
 		strength, _ := record.ValueByKey("strength").(float64)
 		positionSize, _ := record.ValueByKey("position_size").(float64)
 		price, _ := record.ValueByKey("price").(float64)
 
+		// Выборка по нескольким символам берет символ из тега
+		signalSymbol := symbol
+		if signalSymbol == "" {
+			signalSymbol, _ = record.ValueByKey("symbol").(string)
+		}
+
 		// Создаем объект сигнала
 		signal := &models.SignalResult{
-			Symbol:         symbol,
-			Timestamp:      timestamp,
-			Recommendation: recommendation,
-			SignalStrength: strength,
-			PositionSize:   positionSize,
-			CurrentPrice:   price,
-			Components:     make(map[string]float64),
+			Symbol:             signalSymbol,
+			Timestamp:          timestamp,
+			Recommendation:     recommendation,
+			RecommendationCode: models.Recommendation(recommendationCode),
+			SignalStrength:     strength,
+			PositionSize:       positionSize,
+			CurrentPrice:       price,
+			Components:         make(map[string]float64),
+		}
+
+		// Компоненты хранятся строкой JSON
+		if components, ok := record.ValueByKey("components").(string); ok && components != "" {
+			if err := json.Unmarshal([]byte(components), &signal.Components); err != nil {
+				logger.Warn("Ошибка разбора компонентов сигнала", zap.String("symbol", symbol), zap.Error(err))
+			}
 		}
+		readSignalConditions(record.Values(), signal)
 
 		signals = append(signals, signal)
 	}
@@ -452,6 +815,318 @@ func (s *InfluxDBStorage) GetSignalHistory(ctx context.Context, symbol string, l
 	return signals, nil
 }
 
+// SaveNote сохраняет заметку пользователя
+func (s *InfluxDBStorage) SaveNote(ctx context.Context, note *models.Note) error {
+	var signalTime int64
+	if !note.SignalTime.IsZero() {
+		signalTime = note.SignalTime.UnixNano()
+	}
+
+	point := influxdb2.NewPoint(
+		"notes",
+		map[string]string{
+			"symbol": note.Symbol,
+		},
+		map[string]interface{}{
+			"text":        note.Text,
+			"signal_time": signalTime,
+		},
+		note.Timestamp,
+	)
+
+	s.writePoints(point)
+
+	return nil
+}
+
+// GetNotes получает заметки символа (или всех символов, если symbol пуст), новые первыми
+func (s *InfluxDBStorage) GetNotes(ctx context.Context, symbol string, limit int) ([]*models.Note, error) {
+	symbolFilter := ""
+	if symbol != "" {
+		symbolFilter = fmt.Sprintf(`|> filter(fn: (r) => r.symbol == "%s")`, symbol)
+	}
+
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: -365d)
+			|> filter(fn: (r) => r._measurement == "notes")
+			%s
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> group()
+			|> sort(columns: ["_time"], desc: true)
+			|> limit(n: %d)
+	`, s.bucket, symbolFilter, limit)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса заметок: %w", err)
+	}
+
+	var notes []*models.Note
+	for result.Next() {
+		record := result.Record()
+
+		noteSymbol, _ := record.ValueByKey("symbol").(string)
+		text, _ := record.ValueByKey("text").(string)
+		signalTime, _ := record.ValueByKey("signal_time").(int64)
+
+		note := &models.Note{
+			Symbol:    noteSymbol,
+			Timestamp: record.Time(),
+			Text:      text,
+		}
+		if signalTime != 0 {
+			note.SignalTime = time.Unix(0, signalTime)
+		}
+		notes = append(notes, note)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return notes, nil
+}
+
+// EventFilter условия выборки журнала событий
+type EventFilter struct {
+	From   time.Time // Нулевое - за 30 дней до To
+	To     time.Time // Нулевое - текущий момент
+	Types  []string  // Пусто - события всех типов
+	Symbol string    // Пусто - события всех символов
+	Limit  int       // 0 - без ограничения
+}
+
+// Range возвращает границы периода выборки с учетом значений по умолчанию
+func (f EventFilter) Range() (from, to time.Time) {
+	from, to = f.From, f.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	return from, to
+}
+
+// Match проверяет, подходит ли событие под тип и символ фильтра
+func (f EventFilter) Match(event *models.Event) bool {
+	if f.Symbol != "" && event.Symbol != f.Symbol {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, eventType := range f.Types {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// CycleFilter условия выборки аудита циклов анализа
+type CycleFilter struct {
+	From        time.Time     // Нулевое - за 30 дней до To
+	To          time.Time     // Нулевое - текущий момент
+	MinDuration time.Duration // Только циклы не короче; 0 - все
+	Failed      bool          // Только циклы с ошибками
+	Limit       int           // 0 - без ограничения
+}
+
+// Range возвращает границы периода выборки с учетом значений по умолчанию
+func (f CycleFilter) Range() (from, to time.Time) {
+	return EventFilter{From: f.From, To: f.To}.Range()
+}
+
+// Match проверяет, подходит ли цикл под длительность и наличие ошибок
+func (f CycleFilter) Match(cycle *models.AnalysisCycle) bool {
+	return cycle.Duration >= f.MinDuration && (!f.Failed || len(cycle.Failures) > 0)
+}
+
+// SaveEvent добавляет запись в журнал событий
+func (s *InfluxDBStorage) SaveEvent(ctx context.Context, event *models.Event) error {
+	tags := map[string]string{"type": event.Type}
+	if event.Symbol != "" {
+		tags["symbol"] = event.Symbol
+	}
+	fields := map[string]interface{}{"message": event.Message}
+	if len(event.Fields) > 0 {
+		data, err := json.Marshal(event.Fields)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации полей события: %w", err)
+		}
+		fields["fields"] = string(data)
+	}
+
+	s.writePoints(influxdb2.NewPoint("events", tags, fields, event.Timestamp))
+
+	return nil
+}
+
+// GetEvents получает записи журнала событий по фильтру, новые первыми
+func (s *InfluxDBStorage) GetEvents(ctx context.Context, filter EventFilter) ([]*models.Event, error) {
+	from, to := filter.Range()
+
+	var filters string
+	if len(filter.Types) > 0 {
+		conditions := make([]string, len(filter.Types))
+		for i, eventType := range filter.Types {
+			conditions[i] = fmt.Sprintf(`r.type == %q`, eventType)
+		}
+		filters += fmt.Sprintf("|> filter(fn: (r) => %s)\n", strings.Join(conditions, " or "))
+	}
+	if filter.Symbol != "" {
+		filters += fmt.Sprintf(`|> filter(fn: (r) => r.symbol == %q)`, filter.Symbol)
+	}
+	limit := ""
+	if filter.Limit > 0 {
+		limit = fmt.Sprintf("|> limit(n: %d)", filter.Limit)
+	}
+
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "events")
+			%s
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> group()
+			|> sort(columns: ["_time"], desc: true)
+			%s
+	`, s.bucket, from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano), filters, limit)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса журнала событий: %w", err)
+	}
+
+	var events []*models.Event
+	for result.Next() {
+		record := result.Record()
+
+		eventType, _ := record.ValueByKey("type").(string)
+		symbol, _ := record.ValueByKey("symbol").(string)
+		message, _ := record.ValueByKey("message").(string)
+		fieldsStr, _ := record.ValueByKey("fields").(string)
+
+		event := &models.Event{
+			Timestamp: record.Time(),
+			Type:      eventType,
+			Symbol:    symbol,
+			Message:   message,
+		}
+		if fieldsStr != "" {
+			if err := json.Unmarshal([]byte(fieldsStr), &event.Fields); err != nil {
+				logger.Warn("Ошибка разбора полей события", zap.Error(err))
+			}
+		}
+		events = append(events, event)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return events, nil
+}
+
+// SaveCycle сохраняет запись аудита цикла анализа
+func (s *InfluxDBStorage) SaveCycle(ctx context.Context, cycle *models.AnalysisCycle) error {
+	fields := map[string]interface{}{
+		"id":          cycle.ID,
+		"duration_ms": cycle.Duration.Milliseconds(),
+		"symbols":     strings.Join(cycle.Symbols, ","),
+		"skipped":     strings.Join(cycle.Skipped, ","),
+		"failed":      len(cycle.Failures),
+		"queries":     cycle.Queries,
+	}
+	if len(cycle.Failures) > 0 {
+		data, err := json.Marshal(cycle.Failures)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации ошибок цикла: %w", err)
+		}
+		fields["failures"] = string(data)
+	}
+
+	s.writePoints(influxdb2.NewPoint("analysis_cycles", map[string]string{}, fields, cycle.Started))
+
+	return nil
+}
+
+// GetCycles получает записи аудита циклов анализа по фильтру, новые первыми
+func (s *InfluxDBStorage) GetCycles(ctx context.Context, filter CycleFilter) ([]*models.AnalysisCycle, error) {
+	from, to := filter.Range()
+
+	var filters string
+	if filter.MinDuration > 0 {
+		filters += fmt.Sprintf("|> filter(fn: (r) => r.duration_ms >= %d)\n", filter.MinDuration.Milliseconds())
+	}
+	if filter.Failed {
+		filters += "|> filter(fn: (r) => r.failed > 0)\n"
+	}
+	limit := ""
+	if filter.Limit > 0 {
+		limit = fmt.Sprintf("|> limit(n: %d)", filter.Limit)
+	}
+
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == "analysis_cycles")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			%s
+			|> group()
+			|> sort(columns: ["_time"], desc: true)
+			%s
+	`, s.bucket, from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano), filters, limit)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса аудита циклов анализа: %w", err)
+	}
+
+	var cycles []*models.AnalysisCycle
+	for result.Next() {
+		record := result.Record()
+
+		id, _ := record.ValueByKey("id").(string)
+		durationMs, _ := record.ValueByKey("duration_ms").(int64)
+		symbols, _ := record.ValueByKey("symbols").(string)
+		skipped, _ := record.ValueByKey("skipped").(string)
+		queries, _ := record.ValueByKey("queries").(int64)
+		failuresStr, _ := record.ValueByKey("failures").(string)
+
+		cycle := &models.AnalysisCycle{
+			ID:       id,
+			Started:  record.Time(),
+			Duration: time.Duration(durationMs) * time.Millisecond,
+			Symbols:  splitList(symbols),
+			Skipped:  splitList(skipped),
+			Queries:  int(queries),
+		}
+		if failuresStr != "" {
+			if err := json.Unmarshal([]byte(failuresStr), &cycle.Failures); err != nil {
+				logger.Warn("Ошибка разбора ошибок цикла анализа", zap.Error(err))
+			}
+		}
+		cycles = append(cycles, cycle)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return cycles, nil
+}
+
+// splitList разбирает список через запятую; пустая строка - пустой список
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
 // GetSymbols возвращает список отслеживаемых символов
 func (s *InfluxDBStorage) GetSymbols(ctx context.Context) ([]string, error) {
 	// Формируем Flux-запрос для получения уникальных символов
@@ -465,7 +1140,7 @@ func (s *InfluxDBStorage) GetSymbols(ctx context.Context) ([]string, error) {
 	`, s.bucket)
 
 	// Выполняем запрос
-	result, err := s.queryAPI.Query(ctx, query)
+	result, err := s.query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса символов: %w", err)
 	}
@@ -486,76 +1161,19 @@ func (s *InfluxDBStorage) GetSymbols(ctx context.Context) ([]string, error) {
 	return symbols, nil
 }
 
-// convertOrderBookLevels конвертирует уровни стакана в строку для хранения
-func convertOrderBookLevels(levels []models.OrderBookLevel) string {
-	result := "["
-	for i, level := range levels {
-		if i > 0 {
-			result += ","
-		}
-		result += fmt.Sprintf("{\"price\":\"%s\",\"amount\":\"%s\"}", level.Price, level.Amount)
-	}
-	result += "]"
-	return result
-}
-
-// parseOrderBookLevels парсит строку в уровни стакана
-func parseOrderBookLevels(data string) []models.OrderBookLevel {
-	var levels []models.OrderBookLevel
-	if err := json.Unmarshal([]byte(data), &levels); err != nil {
-		fmt.Printf("Ошибка парсинга стакана: %v\n", err)
-		return []models.OrderBookLevel{}
-	}
-	return levels
-}
-
-// getIntervalDuration конвертирует строковый интервал в duration
-func getIntervalDuration(interval string) time.Duration {
-	switch interval {
-	case "1m":
-		return time.Minute
-	case "3m":
-		return 3 * time.Minute
-	case "5m":
-		return 5 * time.Minute
-	case "15m":
-		return 15 * time.Minute
-	case "30m":
-		return 30 * time.Minute
-	case "1h":
-		return time.Hour
-	case "2h":
-		return 2 * time.Hour
-	case "4h":
-		return 4 * time.Hour
-	case "6h":
-		return 6 * time.Hour
-	case "8h":
-		return 8 * time.Hour
-	case "12h":
-		return 12 * time.Hour
-	case "1d":
-		return 24 * time.Hour
-	case "3d":
-		return 72 * time.Hour
-	case "1w":
-		return 7 * 24 * time.Hour
-	default:
-		return time.Hour
-	}
-}
-
 // Storage интерфейс для работы с хранилищем данных
 type Storage interface {
 	// Методы для свечей
 	SaveCandle(ctx context.Context, candle *models.Candle) error
 	SaveCandles(ctx context.Context, candles []*models.Candle) error
-	GetCandles(ctx context.Context, symbol, interval string, limit int) ([]*models.Candle, error)
-	GetLatestCandles(ctx context.Context, symbol, interval string, limit int) ([]*models.Candle, error)
+	GetCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error)
+	GetLatestCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error)
 
 	// Методы для стакана заявок
 	SaveOrderBook(ctx context.Context, orderBook *models.OrderBook) error
+	SaveOrderBookDelta(ctx context.Context, delta *models.OrderBookDelta) error
 	GetLatestOrderBook(ctx context.Context, symbol string) (*models.OrderBook, error)
+	GetOrderBooks(ctx context.Context, symbol string, since time.Time) ([]*models.OrderBook, error)
 
 	// Методы для ставок финансирования
 	SaveFundingRate(ctx context.Context, rate *models.FundingRate) error
@@ -568,8 +1186,36 @@ type Storage interface {
 	// Методы для сигналов
 	SaveSignal(ctx context.Context, signal *models.SignalResult) error
 	GetSignalHistory(ctx context.Context, symbol string, limit int) ([]*models.SignalResult, error)
+	QuerySignals(ctx context.Context, query models.SignalQuery) (*models.SignalPage, error)
+
+	// Методы для заметок
+	SaveNote(ctx context.Context, note *models.Note) error
+	GetNotes(ctx context.Context, symbol string, limit int) ([]*models.Note, error)
+
+	// Методы для журнала событий
+	SaveEvent(ctx context.Context, event *models.Event) error
+	GetEvents(ctx context.Context, filter EventFilter) ([]*models.Event, error)
+
+	// Методы для аудита циклов анализа
+	SaveCycle(ctx context.Context, cycle *models.AnalysisCycle) error
+	GetCycles(ctx context.Context, filter CycleFilter) ([]*models.AnalysisCycle, error)
+
+	// Методы для сделок
+	SaveTrade(ctx context.Context, trade *models.Trade) error
+	GetTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error)
+	GetFills(ctx context.Context, tradeID string) ([]models.Fill, error)
+
+	// Методы для результатов проверки на истории
+	SaveBacktestRun(ctx context.Context, run *models.BacktestRun) error
+	GetBacktestRuns(ctx context.Context, limit int) ([]*models.BacktestRun, error)
+	GetBacktestRun(ctx context.Context, id string) (*models.BacktestRun, error)
+
+	// Методы для параметров символов
+	SaveSymbolInfo(ctx context.Context, info *models.SymbolInfo) error
+	GetSymbolInfos(ctx context.Context) ([]*models.SymbolInfo, error)
 
 	// Вспомогательные методы
 	GetSymbols(ctx context.Context) ([]string, error)
+	PendingWrites() int
 	Close()
 }
@@ -0,0 +1,450 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// MemoryStorage хранит данные в памяти процесса. Используется там, где InfluxDB
+// не нужна: замеры производительности анализа и воспроизведение истории.
+// Выборки, как и у InfluxDBStorage, возвращают новые записи первыми.
+type MemoryStorage struct {
+	candles      map[string][]*models.Candle    // Ключ - символ и интервал, по возрастанию времени
+	orderBooks   map[string][]*models.OrderBook // Снимки по возрастанию времени; последний - текущий стакан
+	fundingRates map[string][]*models.FundingRate
+	openInterest map[string][]*models.OpenInterest
+	signals      map[string][]*models.SignalResult
+	notes        map[string][]*models.Note
+	events       []*models.Event         // По порядку записи
+	cycles       []*models.AnalysisCycle // По порядку записи
+	trades       []*models.Trade         // По порядку первого сохранения
+	backtests    []*models.BacktestRun   // По порядку первого сохранения
+	symbolInfos  map[string]*models.SymbolInfo
+	mutex        sync.RWMutex
+}
+
+// Сколько снимков стакана символа хранится в памяти
+const maxMemoryOrderBooks = 120
+
+// NewMemoryStorage создает пустое хранилище в памяти
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		candles:      make(map[string][]*models.Candle),
+		orderBooks:   make(map[string][]*models.OrderBook),
+		fundingRates: make(map[string][]*models.FundingRate),
+		openInterest: make(map[string][]*models.OpenInterest),
+		signals:      make(map[string][]*models.SignalResult),
+		notes:        make(map[string][]*models.Note),
+		symbolInfos:  make(map[string]*models.SymbolInfo),
+	}
+}
+
+// candleKey ключ свечей символа и интервала
+func candleKey(symbol string, interval models.Interval) string {
+	return symbol + "|" + interval.String()
+}
+
+// SaveCandle сохраняет свечу; свеча с тем же временем открытия заменяется
+func (s *MemoryStorage) SaveCandle(ctx context.Context, candle *models.Candle) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := candleKey(candle.Symbol, candle.Interval)
+	candles := s.candles[key]
+	i := sort.Search(len(candles), func(i int) bool { return !candles[i].OpenTime.Before(candle.OpenTime) })
+	if i < len(candles) && candles[i].OpenTime.Equal(candle.OpenTime) {
+		candles[i] = candle
+		return nil
+	}
+	candles = append(candles, nil)
+	copy(candles[i+1:], candles[i:])
+	candles[i] = candle
+	s.candles[key] = candles
+	return nil
+}
+
+// SaveCandles сохраняет свечи
+func (s *MemoryStorage) SaveCandles(ctx context.Context, candles []*models.Candle) error {
+	for _, candle := range candles {
+		if err := s.SaveCandle(ctx, candle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCandles возвращает последние limit свечей, новые первыми
+func (s *MemoryStorage) GetCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return latest(s.candles[candleKey(symbol, interval)], limit), nil
+}
+
+// GetLatestCandles возвращает последние свечи
+func (s *MemoryStorage) GetLatestCandles(ctx context.Context, symbol string, interval models.Interval, limit int) ([]*models.Candle, error) {
+	return s.GetCandles(ctx, symbol, interval, limit)
+}
+
+// SaveOrderBook сохраняет снимок стакана; хранятся последние maxMemoryOrderBooks снимков
+func (s *MemoryStorage) SaveOrderBook(ctx context.Context, orderBook *models.OrderBook) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	orderBooks := append(s.orderBooks[orderBook.Symbol], orderBook)
+	if len(orderBooks) > maxMemoryOrderBooks {
+		orderBooks = slices.Clone(orderBooks[len(orderBooks)-maxMemoryOrderBooks:])
+	}
+	s.orderBooks[orderBook.Symbol] = orderBooks
+	return nil
+}
+
+// SaveOrderBookDelta применяет изменение к последнему стакану символа
+func (s *MemoryStorage) SaveOrderBookDelta(ctx context.Context, delta *models.OrderBookDelta) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	orderBooks := s.orderBooks[delta.Symbol]
+	if len(orderBooks) == 0 {
+		return fmt.Errorf("стакан для %s не найден", delta.Symbol)
+	}
+	// Выданный ранее стакан мог остаться у читателя, поэтому изменение применяется к копии
+	updated := orderBooks[len(orderBooks)-1].Top(0)
+	if _, err := updated.Apply(delta); err != nil {
+		return err
+	}
+	orderBooks[len(orderBooks)-1] = updated
+	return nil
+}
+
+// GetLatestOrderBook возвращает последний стакан символа
+func (s *MemoryStorage) GetLatestOrderBook(ctx context.Context, symbol string) (*models.OrderBook, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	orderBooks := s.orderBooks[symbol]
+	if len(orderBooks) == 0 {
+		return nil, fmt.Errorf("стакан для %s не найден", symbol)
+	}
+	return orderBooks[len(orderBooks)-1], nil
+}
+
+// GetOrderBooks возвращает снимки стакана начиная с since, новые первыми; первый -
+// текущий стакан с примененными изменениями
+func (s *MemoryStorage) GetOrderBooks(ctx context.Context, symbol string, since time.Time) ([]*models.OrderBook, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var orderBooks []*models.OrderBook
+	for _, orderBook := range s.orderBooks[symbol] {
+		if !orderBook.Timestamp.Before(since) {
+			orderBooks = append(orderBooks, orderBook)
+		}
+	}
+	if len(orderBooks) == 0 {
+		return nil, fmt.Errorf("стакан для %s не найден", symbol)
+	}
+	return latest(orderBooks, 0), nil
+}
+
+// SaveFundingRate сохраняет ставку финансирования
+func (s *MemoryStorage) SaveFundingRate(ctx context.Context, rate *models.FundingRate) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fundingRates[rate.Symbol] = append(s.fundingRates[rate.Symbol], rate)
+	return nil
+}
+
+// GetFundingRates возвращает последние ставки финансирования, новые первыми
+func (s *MemoryStorage) GetFundingRates(ctx context.Context, symbol string, limit int) ([]*models.FundingRate, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return latest(s.fundingRates[symbol], limit), nil
+}
+
+// SaveOpenInterest сохраняет открытый интерес
+func (s *MemoryStorage) SaveOpenInterest(ctx context.Context, oi *models.OpenInterest) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.openInterest[oi.Symbol] = append(s.openInterest[oi.Symbol], oi)
+	return nil
+}
+
+// GetOpenInterest возвращает последние значения открытого интереса, новые первыми
+func (s *MemoryStorage) GetOpenInterest(ctx context.Context, symbol string, limit int) ([]*models.OpenInterest, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return latest(s.openInterest[symbol], limit), nil
+}
+
+// SaveSignal сохраняет сигнал
+func (s *MemoryStorage) SaveSignal(ctx context.Context, signal *models.SignalResult) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.signals[signal.Symbol] = append(s.signals[signal.Symbol], signal)
+	return nil
+}
+
+// GetSignalHistory возвращает последние сигналы символа, новые первыми
+func (s *MemoryStorage) GetSignalHistory(ctx context.Context, symbol string, limit int) ([]*models.SignalResult, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return latest(s.signals[symbol], limit), nil
+}
+
+// QuerySignals возвращает страницу истории сигналов по условиям query
+func (s *MemoryStorage) QuerySignals(ctx context.Context, query models.SignalQuery) (*models.SignalPage, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	cursor, _ := query.ParseCursor()
+
+	s.mutex.RLock()
+	var signals []*models.SignalResult
+	for _, history := range s.signals {
+		for _, signal := range history {
+			if query.Match(signal, cursor) {
+				signals = append(signals, signal)
+			}
+		}
+	}
+	s.mutex.RUnlock()
+
+	sort.SliceStable(signals, func(i, j int) bool { return query.Less(signals[i], signals[j]) })
+	if len(signals) > query.Limit+1 {
+		signals = signals[:query.Limit+1]
+	}
+	return query.Page(signals), nil
+}
+
+// SaveNote сохраняет заметку
+func (s *MemoryStorage) SaveNote(ctx context.Context, note *models.Note) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.notes[note.Symbol] = append(s.notes[note.Symbol], note)
+	return nil
+}
+
+// GetNotes возвращает последние заметки символа, новые первыми
+func (s *MemoryStorage) GetNotes(ctx context.Context, symbol string, limit int) ([]*models.Note, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return latest(s.notes[symbol], limit), nil
+}
+
+// SaveEvent добавляет запись в журнал событий
+func (s *MemoryStorage) SaveEvent(ctx context.Context, event *models.Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, event)
+	return nil
+}
+
+// GetEvents возвращает записи журнала событий по фильтру, новые первыми
+func (s *MemoryStorage) GetEvents(ctx context.Context, filter EventFilter) ([]*models.Event, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	from, to := filter.Range()
+	var events []*models.Event
+	for _, event := range s.events {
+		if !event.Timestamp.Before(from) && event.Timestamp.Before(to) && filter.Match(event) {
+			events = append(events, event)
+		}
+	}
+	return latest(events, filter.Limit), nil
+}
+
+// SaveCycle сохраняет запись аудита цикла анализа
+func (s *MemoryStorage) SaveCycle(ctx context.Context, cycle *models.AnalysisCycle) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cycles = append(s.cycles, cycle)
+	return nil
+}
+
+// GetCycles возвращает записи аудита циклов анализа по фильтру, новые первыми
+func (s *MemoryStorage) GetCycles(ctx context.Context, filter CycleFilter) ([]*models.AnalysisCycle, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	from, to := filter.Range()
+	var cycles []*models.AnalysisCycle
+	for _, cycle := range s.cycles {
+		if !cycle.Started.Before(from) && cycle.Started.Before(to) && filter.Match(cycle) {
+			cycles = append(cycles, cycle)
+		}
+	}
+	return latest(cycles, filter.Limit), nil
+}
+
+// SaveTrade сохраняет копию сделки; сделка с тем же ID заменяется
+func (s *MemoryStorage) SaveTrade(ctx context.Context, trade *models.Trade) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	saved := *trade
+	saved.Fills = slices.Clone(trade.Fills)
+	for i, existing := range s.trades {
+		if existing.ID == trade.ID {
+			s.trades[i] = &saved
+			return nil
+		}
+	}
+	s.trades = append(s.trades, &saved)
+	return nil
+}
+
+// GetTrades возвращает последние сделки символа (всех символов, если symbol пуст),
+// новые первыми и без исполнений, как InfluxDBStorage
+func (s *MemoryStorage) GetTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var trades []*models.Trade
+	for _, trade := range s.trades {
+		if symbol == "" || trade.Symbol == symbol {
+			copied := *trade
+			copied.Fills = nil
+			trades = append(trades, &copied)
+		}
+	}
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].OpenTime.Before(trades[j].OpenTime) })
+	return latest(trades, limit), nil
+}
+
+// GetFills возвращает исполнения сделки по порядку времени
+func (s *MemoryStorage) GetFills(ctx context.Context, tradeID string) ([]models.Fill, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, trade := range s.trades {
+		if trade.ID == tradeID {
+			return slices.Clone(trade.Fills), nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveBacktestRun сохраняет копию запуска проверки; запуск с тем же ID заменяется
+func (s *MemoryStorage) SaveBacktestRun(ctx context.Context, run *models.BacktestRun) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	saved := *run
+	saved.Trades = slices.Clone(run.Trades)
+	saved.Slippage = slices.Clone(run.Slippage)
+	for i, existing := range s.backtests {
+		if existing.ID == run.ID {
+			s.backtests[i] = &saved
+			return nil
+		}
+	}
+	s.backtests = append(s.backtests, &saved)
+	return nil
+}
+
+// GetBacktestRuns возвращает последние запуски проверки без сделок, новые первыми
+func (s *MemoryStorage) GetBacktestRuns(ctx context.Context, limit int) ([]*models.BacktestRun, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	runs := make([]*models.BacktestRun, 0, len(s.backtests))
+	for _, run := range s.backtests {
+		runs = append(runs, run.Summary())
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	return latest(runs, limit), nil
+}
+
+// GetBacktestRun возвращает копию запуска проверки со сделками; nil - запуск не найден
+func (s *MemoryStorage) GetBacktestRun(ctx context.Context, id string) (*models.BacktestRun, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, run := range s.backtests {
+		if run.ID == id {
+			copied := *run
+			copied.Trades = slices.Clone(run.Trades)
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveSymbolInfo сохраняет копию параметров символа
+func (s *MemoryStorage) SaveSymbolInfo(ctx context.Context, info *models.SymbolInfo) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	saved := *info
+	s.symbolInfos[info.Symbol] = &saved
+	return nil
+}
+
+// GetSymbolInfos возвращает параметры всех символов по алфавиту
+func (s *MemoryStorage) GetSymbolInfos(ctx context.Context) ([]*models.SymbolInfo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	infos := make([]*models.SymbolInfo, 0, len(s.symbolInfos))
+	for _, info := range s.symbolInfos {
+		copied := *info
+		infos = append(infos, &copied)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Symbol < infos[j].Symbol })
+	return infos, nil
+}
+
+// GetSymbols возвращает символы, по которым есть свечи
+func (s *MemoryStorage) GetSymbols(ctx context.Context) ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, candles := range s.candles {
+		if len(candles) > 0 && !seen[candles[0].Symbol] {
+			seen[candles[0].Symbol] = true
+			symbols = append(symbols, candles[0].Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// PendingWrites всегда 0: запись в память синхронная
+func (s *MemoryStorage) PendingWrites() int {
+	return 0
+}
+
+// Close ничего не делает
+func (s *MemoryStorage) Close() {}
+
+// latest возвращает последние limit элементов в обратном порядке (новые первыми)
+func latest[T any](items []T, limit int) []T {
+	if limit <= 0 || limit > len(items) {
+		limit = len(items)
+	}
+	result := make([]T, 0, limit)
+	for i := len(items) - 1; i >= len(items)-limit; i-- {
+		result = append(result, items[i])
+	}
+	return result
+}
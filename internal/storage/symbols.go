@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Параметры символа хранятся точкой measurement symbol_info со временем получения
+// с биржи; выборка возвращает последние параметры каждого символа за 30 дней.
+
+// SaveSymbolInfo сохраняет параметры символа
+func (s *InfluxDBStorage) SaveSymbolInfo(ctx context.Context, info *models.SymbolInfo) error {
+	point := influxdb2.NewPoint(
+		"symbol_info",
+		map[string]string{
+			"symbol": info.Symbol,
+		},
+		map[string]interface{}{
+			"base_asset":         info.BaseAsset,
+			"quote_asset":        info.QuoteAsset,
+			"margin_asset":       info.MarginAsset,
+			"contract_type":      info.ContractType,
+			"status":             info.Status,
+			"price_precision":    int64(info.PricePrecision),
+			"quantity_precision": int64(info.QuantityPrecision),
+			"tick_size":          info.TickSize,
+			"step_size":          info.StepSize,
+			"min_quantity":       info.MinQuantity,
+			"min_notional":       info.MinNotional,
+			"onboard_date":       info.OnboardDate.UnixMilli(),
+			"delivery_date":      info.DeliveryDate.UnixMilli(),
+			"funding_interval":   int64(info.FundingInterval / time.Second),
+		},
+		info.UpdateTime,
+	)
+
+	s.writePoints(point)
+	return nil
+}
+
+// GetSymbolInfos получает последние сохраненные параметры всех символов
+func (s *InfluxDBStorage) GetSymbolInfos(ctx context.Context) ([]*models.SymbolInfo, error) {
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: -30d)
+			|> filter(fn: (r) => r._measurement == "symbol_info")
+			|> last()
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> group()
+			|> sort(columns: ["symbol"])
+	`, s.bucket)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса параметров символов: %w", err)
+	}
+
+	var infos []*models.SymbolInfo
+	for result.Next() {
+		record := result.Record()
+		values := record.Values()
+
+		info := &models.SymbolInfo{UpdateTime: record.Time()}
+		info.Symbol, _ = values["symbol"].(string)
+		info.BaseAsset, _ = values["base_asset"].(string)
+		info.QuoteAsset, _ = values["quote_asset"].(string)
+		info.MarginAsset, _ = values["margin_asset"].(string)
+		info.ContractType, _ = values["contract_type"].(string)
+		info.Status, _ = values["status"].(string)
+		pricePrecision, _ := values["price_precision"].(int64)
+		quantityPrecision, _ := values["quantity_precision"].(int64)
+		info.PricePrecision, info.QuantityPrecision = int(pricePrecision), int(quantityPrecision)
+		info.TickSize, _ = values["tick_size"].(float64)
+		info.StepSize, _ = values["step_size"].(float64)
+		info.MinQuantity, _ = values["min_quantity"].(float64)
+		info.MinNotional, _ = values["min_notional"].(float64)
+		onboardDate, _ := values["onboard_date"].(int64)
+		deliveryDate, _ := values["delivery_date"].(int64)
+		info.OnboardDate, info.DeliveryDate = time.UnixMilli(onboardDate), time.UnixMilli(deliveryDate)
+		fundingInterval, _ := values["funding_interval"].(int64)
+		info.FundingInterval = time.Duration(fundingInterval) * time.Second
+		infos = append(infos, info)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return infos, nil
+}
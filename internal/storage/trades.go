@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Сделка хранится точкой measurement trades со временем первого входа: при повторном
+// сохранении точка перезаписывается, поэтому открытую сделку сохраняют после каждого
+// исполнения. Исполнения хранятся отдельно в measurement fills с тегом сделки. Цены,
+// объемы, комиссии и прибыль хранятся десятичными строками.
+
+// SaveTrade сохраняет сделку и ее исполнения
+func (s *InfluxDBStorage) SaveTrade(ctx context.Context, trade *models.Trade) error {
+	var closeTime int64
+	if trade.Closed() {
+		closeTime = trade.CloseTime.UnixMilli()
+	}
+
+	points := []*write.Point{influxdb2.NewPoint(
+		"trades",
+		map[string]string{
+			"symbol": trade.Symbol,
+			"source": trade.Source,
+		},
+		versioned(models.ModelTrade, map[string]interface{}{
+			"id":             trade.ID,
+			"side":           trade.Side.String(),
+			"signal_id":      trade.SignalID,
+			"quantity":       decimalField(trade.Quantity),
+			"entry_quantity": decimalField(trade.EntryQuantity),
+			"exit_quantity":  decimalField(trade.ExitQuantity),
+			"entry_price":    decimalField(trade.EntryPrice),
+			"exit_price":     decimalField(trade.ExitPrice),
+			"fees":           decimalField(trade.Fees),
+			"realized_pnl":   decimalField(trade.RealizedPnL),
+			"close_time":     closeTime,
+		}),
+		trade.OpenTime,
+	)}
+
+	for _, fill := range trade.Fills {
+		points = append(points, influxdb2.NewPoint(
+			"fills",
+			map[string]string{
+				"symbol": fill.Symbol,
+				"trade":  trade.ID,
+			},
+			map[string]interface{}{
+				"id":              fill.ID,
+				"side":            fill.Side.String(),
+				"reduce":          fill.Reduce,
+				"price":           decimalField(fill.Price),
+				"quantity":        decimalField(fill.Quantity),
+				"fee":             decimalField(fill.Fee),
+				"reference_price": decimalField(fill.ReferencePrice),
+			},
+			fill.Time,
+		))
+	}
+
+	s.writePoints(points...)
+	return nil
+}
+
+// GetTrades получает сделки символа (или всех символов, если symbol пуст), новые
+// первыми. Исполнения не загружаются, их возвращает GetFills.
+func (s *InfluxDBStorage) GetTrades(ctx context.Context, symbol string, limit int) ([]*models.Trade, error) {
+	symbolFilter := ""
+	if symbol != "" {
+		symbolFilter = fmt.Sprintf(`|> filter(fn: (r) => r.symbol == "%s")`, symbol)
+	}
+
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: -365d)
+			|> filter(fn: (r) => r._measurement == "trades")
+			%s
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> group()
+			|> sort(columns: ["_time"], desc: true)
+			|> limit(n: %d)
+	`, s.bucket, symbolFilter, limit)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса сделок: %w", err)
+	}
+
+	var trades []*models.Trade
+	for result.Next() {
+		record := result.Record()
+		values := record.Values()
+		if !migrateRecord(models.ModelTrade, values) {
+			continue
+		}
+
+		trade := &models.Trade{OpenTime: record.Time()}
+		trade.ID, _ = values["id"].(string)
+		trade.Symbol, _ = values["symbol"].(string)
+		trade.Source, _ = values["source"].(string)
+		trade.SignalID, _ = values["signal_id"].(string)
+		side, _ := values["side"].(string)
+		trade.Side = models.Side(side)
+		trade.Quantity = decimalValue(values, "quantity")
+		trade.EntryQuantity = decimalValue(values, "entry_quantity")
+		trade.ExitQuantity = decimalValue(values, "exit_quantity")
+		trade.EntryPrice = decimalValue(values, "entry_price")
+		trade.ExitPrice = decimalValue(values, "exit_price")
+		trade.Fees = decimalValue(values, "fees")
+		trade.RealizedPnL = decimalValue(values, "realized_pnl")
+		if closeTime, _ := values["close_time"].(int64); closeTime != 0 {
+			trade.CloseTime = time.UnixMilli(closeTime)
+		}
+		trades = append(trades, trade)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return trades, nil
+}
+
+// GetFills получает исполнения сделки по порядку времени
+func (s *InfluxDBStorage) GetFills(ctx context.Context, tradeID string) ([]models.Fill, error) {
+	query := fmt.Sprintf(`
+		from(bucket: "%s")
+			|> range(start: -365d)
+			|> filter(fn: (r) => r._measurement == "fills")
+			|> filter(fn: (r) => r.trade == "%s")
+			|> pivot(rowKey:["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> group()
+			|> sort(columns: ["_time"])
+	`, s.bucket, tradeID)
+
+	result, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса исполнений сделки: %w", err)
+	}
+
+	var fills []models.Fill
+	for result.Next() {
+		record := result.Record()
+		values := record.Values()
+
+		fill := models.Fill{TradeID: tradeID, Time: record.Time()}
+		fill.ID, _ = values["id"].(string)
+		fill.Symbol, _ = values["symbol"].(string)
+		side, _ := values["side"].(string)
+		fill.Side = models.Side(side)
+		fill.Reduce, _ = values["reduce"].(bool)
+		fill.Price = decimalValue(values, "price")
+		fill.Quantity = decimalValue(values, "quantity")
+		fill.Fee = decimalValue(values, "fee")
+		fill.ReferencePrice = decimalValue(values, "reference_price")
+		fills = append(fills, fill)
+	}
+
+	if result.Err() != nil {
+		return nil, fmt.Errorf("ошибка при обработке результатов: %w", result.Err())
+	}
+
+	return fills, nil
+}
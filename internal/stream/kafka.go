@@ -0,0 +1,112 @@
+package stream
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// kafkaProducer клиент Kafka на segmentio/kafka-go: запись с acks=all, ключ записи -
+// символ, раздел выбирается как в стандартном разделителе Kafka (murmur2), поэтому
+// сигналы символа идут по порядку в одном разделе
+type kafkaProducer struct {
+	cfg       config.StreamConfig
+	transport *kafka.Transport
+	writer    *kafka.Writer
+}
+
+// newKafkaProducer создает клиента Kafka
+func newKafkaProducer(cfg config.StreamConfig) *kafkaProducer {
+	return &kafkaProducer{cfg: cfg}
+}
+
+// connect проверяет по метаданным кластера, что темы событий существуют, и создает
+// писателя. Повторы выполняет Publisher, поэтому писатель делает одну попытку.
+func (k *kafkaProducer) connect(ctx context.Context) error {
+	k.transport = &kafka.Transport{DialTimeout: dialTimeout, ClientID: "bfma"}
+	if k.cfg.TLS {
+		k.transport.TLS = &tls.Config{}
+	}
+	if k.cfg.Username != "" {
+		k.transport.SASL = plain.Mechanism{Username: k.cfg.Username, Password: k.cfg.Password}
+	}
+	addr := kafka.TCP(k.cfg.Brokers...)
+
+	client := &kafka.Client{Addr: addr, Timeout: requestTimeout, Transport: k.transport}
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{k.topicName(EventSignal), k.topicName(EventAlert)}})
+	if err != nil {
+		k.close()
+		return err
+	}
+	for _, topic := range metadata.Topics {
+		if topic.Error != nil {
+			k.close()
+			return fmt.Errorf("тема %s недоступна (%v); создайте тему или разрешите auto.create.topics.enable", topic.Name, topic.Error)
+		}
+	}
+
+	k.writer = &kafka.Writer{
+		Addr:         addr,
+		Balancer:     &kafka.Murmur2Balancer{},
+		RequiredAcks: kafka.RequireAll,
+		MaxAttempts:  1,
+		BatchSize:    maxBatch,
+		BatchTimeout: batchTimeout,
+		ReadTimeout:  requestTimeout,
+		WriteTimeout: requestTimeout,
+		Transport:    k.transport,
+	}
+	return nil
+}
+
+// publish отправляет записи и ждет подтверждения всех реплик из ISR
+func (k *kafkaProducer) publish(ctx context.Context, records []record) error {
+	messages := make([]kafka.Message, len(records))
+	for i, r := range records {
+		messages[i] = kafkaMessage(r)
+	}
+	return k.writer.WriteMessages(ctx, messages...)
+}
+
+// close закрывает писателя и соединения с узлами
+func (k *kafkaProducer) close() {
+	if k.writer != nil {
+		k.writer.Close()
+		k.writer = nil
+	}
+	if k.transport != nil {
+		k.transport.CloseIdleConnections()
+		k.transport = nil
+	}
+}
+
+// topicName возвращает тему события
+func (k *kafkaProducer) topicName(event string) string {
+	prefix := k.cfg.Topic
+	if prefix == "" {
+		prefix = "bfma"
+	}
+	return prefix + "." + event + "s"
+}
+
+// kafkaMessage переводит запись в сообщение Kafka: ключ - символ, заголовки записи
+// передаются заголовками сообщения по порядку названий
+func kafkaMessage(r record) kafka.Message {
+	message := kafka.Message{
+		Topic:   r.topic,
+		Key:     []byte(r.key),
+		Value:   r.value,
+		Time:    r.time,
+		Headers: make([]kafka.Header, 0, len(r.headers)),
+	}
+	for name, value := range r.headers {
+		message.Headers = append(message.Headers, kafka.Header{Key: name, Value: []byte(value)})
+	}
+	sort.Slice(message.Headers, func(i, j int) bool { return message.Headers[i].Key < message.Headers[j].Key })
+	return message
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/skalibog/bfma/internal/config"
+)
+
+func TestKafkaMessage(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	message := kafkaMessage(record{
+		topic: "bfma.signals",
+		key:   "BTCUSDT",
+		value: []byte(`{"type":"signal"}`),
+		headers: map[string]string{
+			HeaderType:          EventSignal,
+			HeaderSchemaVersion: "3",
+			HeaderContentType:   ContentTypeJSON,
+		},
+		time: at,
+	})
+
+	if message.Topic != "bfma.signals" || string(message.Key) != "BTCUSDT" || string(message.Value) != `{"type":"signal"}` {
+		t.Fatalf("неверное сообщение: %+v", message)
+	}
+	if !message.Time.Equal(at) {
+		t.Fatalf("время %v, ожидалось %v", message.Time, at)
+	}
+	want := []kafka.Header{
+		{Key: HeaderSchemaVersion, Value: []byte("3")},
+		{Key: HeaderType, Value: []byte(EventSignal)},
+		{Key: HeaderContentType, Value: []byte(ContentTypeJSON)},
+	}
+	if len(message.Headers) != len(want) {
+		t.Fatalf("заголовков %d, ожидалось %d", len(message.Headers), len(want))
+	}
+	for i, header := range message.Headers {
+		if header.Key != want[i].Key || string(header.Value) != string(want[i].Value) {
+			t.Fatalf("заголовок %d: %s=%s, ожидался %s=%s", i, header.Key, header.Value, want[i].Key, want[i].Value)
+		}
+	}
+}
+
+func TestKafkaPartitionByKey(t *testing.T) {
+	// Сигналы символа должны попадать в один раздел, как у стандартного разделителя
+	balancer := &kafka.Murmur2Balancer{}
+	partitions := []int{0, 1, 2, 3, 4, 5}
+	first := balancer.Balance(kafkaMessage(record{key: "BTCUSDT"}), partitions...)
+	for i := 0; i < 10; i++ {
+		if got := balancer.Balance(kafkaMessage(record{key: "BTCUSDT", value: []byte{byte(i)}}), partitions...); got != first {
+			t.Fatalf("раздел %d, ожидался %d", got, first)
+		}
+	}
+}
+
+// TestKafkaReconnect проверяет отправку и переподключение на настоящем брокере:
+// BFMA_TEST_KAFKA=localhost:9092, темы bfma.signals и bfma.alerts должны существовать
+func TestKafkaReconnect(t *testing.T) {
+	brokers := os.Getenv("BFMA_TEST_KAFKA")
+	if brokers == "" {
+		t.Skip("BFMA_TEST_KAFKA не задан")
+	}
+	producer := newKafkaProducer(config.StreamConfig{Type: config.StreamKafka, Brokers: strings.Split(brokers, ",")})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := producer.connect(ctx); err != nil {
+			t.Fatal(err)
+		}
+		r := record{topic: "bfma.alerts", key: "BTCUSDT", value: []byte("{}"), time: time.Now()}
+		if err := producer.publish(ctx, []record{r}); err != nil {
+			t.Fatalf("попытка %d: %v", attempt+1, err)
+		}
+		producer.close()
+	}
+}
@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// natsProducer клиент NATS на nats.go. С JetStream каждое сообщение считается
+// доставленным после подтверждения потока, без него - после ответа сервера на
+// Flush. Переподключение выполняет Publisher, поэтому свое у клиента отключено.
+type natsProducer struct {
+	cfg  config.StreamConfig
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// newNATSProducer создает клиента NATS
+func newNATSProducer(cfg config.StreamConfig) *natsProducer {
+	return &natsProducer{cfg: cfg}
+}
+
+// connect подключается к первому доступному серверу из списка
+func (n *natsProducer) connect(ctx context.Context) error {
+	options := []nats.Option{
+		nats.Name("bfma"),
+		nats.Timeout(dialTimeout),
+		nats.NoReconnect(),
+		nats.DontRandomize(),
+	}
+	if n.cfg.Username != "" {
+		options = append(options, nats.UserInfo(n.cfg.Username, n.cfg.Password))
+	}
+	if n.cfg.TLS {
+		options = append(options, nats.Secure())
+	}
+
+	conn, err := nats.Connect(strings.Join(n.cfg.Brokers, ","), options...)
+	if err != nil {
+		return err
+	}
+	if n.cfg.JetStream {
+		if n.js, err = jetstream.New(conn); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	n.conn = conn
+	return nil
+}
+
+// publish отправляет сообщения. С JetStream ждет подтверждения каждого сообщения,
+// без него - Flush, после которого сервер обработал все отправленное.
+func (n *natsProducer) publish(ctx context.Context, records []record) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	for _, r := range records {
+		msg := natsMessage(r, n.conn.HeadersSupported())
+		if n.js == nil {
+			if err := n.conn.PublishMsg(msg); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := n.js.PublishMsg(ctx, msg, jetstream.WithMsgID(r.id)); err != nil {
+			if errors.Is(err, jetstream.ErrNoStreamResponse) {
+				return errors.New("нет потока JetStream для субъекта, создайте поток (nats stream add)")
+			}
+			return fmt.Errorf("ошибка JetStream: %w", err)
+		}
+	}
+	if n.js == nil {
+		return n.conn.FlushWithContext(ctx)
+	}
+	return nil
+}
+
+// close закрывает соединение
+func (n *natsProducer) close() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+		n.js = nil
+	}
+}
+
+// natsMessage переводит запись в сообщение NATS. Если сервер поддерживает
+// заголовки, передаются заголовки записи и Nats-Msg-Id для устранения повторов
+// в JetStream.
+func natsMessage(r record, headers bool) *nats.Msg {
+	msg := nats.NewMsg(r.topic)
+	msg.Data = r.value
+	if !headers {
+		return msg
+	}
+	msg.Header.Set(nats.MsgIdHdr, r.id)
+	for name, value := range r.headers {
+		msg.Header.Set(name, value)
+	}
+	return msg
+}
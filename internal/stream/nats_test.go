@@ -0,0 +1,147 @@
+package stream
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/skalibog/bfma/internal/config"
+)
+
+func TestNATSMessage(t *testing.T) {
+	r := record{
+		topic:   "bfma.signals.BTCUSDT",
+		id:      "signal-BTCUSDT-1",
+		value:   []byte(`{"type":"signal"}`),
+		headers: map[string]string{HeaderType: EventSignal, HeaderSchemaVersion: "3"},
+	}
+
+	msg := natsMessage(r, true)
+	if msg.Subject != r.topic || string(msg.Data) != string(r.value) {
+		t.Fatalf("неверное сообщение: %s %s", msg.Subject, msg.Data)
+	}
+	if msg.Header.Get(nats.MsgIdHdr) != r.id || msg.Header.Get(HeaderType) != EventSignal || msg.Header.Get(HeaderSchemaVersion) != "3" {
+		t.Fatalf("неверные заголовки: %v", msg.Header)
+	}
+
+	// Без поддержки заголовков сервером сообщение уходит обычным PUB
+	if msg := natsMessage(r, false); len(msg.Header) != 0 {
+		t.Fatalf("заголовки без поддержки сервером: %v", msg.Header)
+	}
+}
+
+// runNATSServer запускает сервер NATS с JetStream на порту port (0 - любой свободный)
+func runNATSServer(t *testing.T, port int, storeDir string) *server.Server {
+	t.Helper()
+	if port == 0 {
+		port = -1
+	}
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, JetStream: true, StoreDir: storeDir, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("сервер NATS не запустился")
+	}
+	return s
+}
+
+// streamMessages возвращает число сообщений в потоке
+func streamMessages(t *testing.T, url, name string) uint64 {
+	t.Helper()
+	conn, err := nats.Connect(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := js.Stream(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.State.Msgs
+}
+
+func TestNATSJetStreamReconnect(t *testing.T) {
+	storeDir := t.TempDir()
+	s := runNATSServer(t, 0, storeDir)
+	addr := s.Addr().String()
+	port := s.Addr().(*net.TCPAddr).Port
+	url := s.ClientURL()
+	defer func() { s.Shutdown() }()
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, _ := jetstream.New(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "BFMA", Subjects: []string{"bfma.>"}, Storage: jetstream.FileStorage}); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	producer := newNATSProducer(config.StreamConfig{Type: config.StreamNATS, Brokers: []string{addr}, JetStream: true})
+	first := record{topic: "bfma.signals.BTCUSDT", id: "signal-BTCUSDT-1", value: []byte("{}")}
+	if err := producer.connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.publish(ctx, []record{first}); err != nil {
+		t.Fatal(err)
+	}
+
+	// После остановки сервера отправка завершается ошибкой, а после его запуска
+	// на том же порту клиент подключается заново
+	s.Shutdown()
+	s.WaitForShutdown()
+	if err := producer.publish(ctx, []record{first}); err == nil {
+		t.Fatal("отправка на остановленный сервер прошла без ошибки")
+	}
+	producer.close()
+
+	s = runNATSServer(t, port, storeDir)
+	if err := producer.connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Повтор первого сообщения отбрасывается потоком по Nats-Msg-Id
+	second := record{topic: "bfma.alerts.BTCUSDT", id: "alert-BTCUSDT-2", value: []byte("{}")}
+	if err := producer.publish(ctx, []record{first, second}); err != nil {
+		t.Fatal(err)
+	}
+	producer.close()
+
+	if got := streamMessages(t, url, "BFMA"); got != 2 {
+		t.Fatalf("сообщений в потоке %d, ожидалось 2", got)
+	}
+}
+
+func TestNATSNoStream(t *testing.T) {
+	s := runNATSServer(t, 0, t.TempDir())
+	defer s.Shutdown()
+
+	producer := newNATSProducer(config.StreamConfig{Type: config.StreamNATS, Brokers: []string{s.Addr().String()}, JetStream: true})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := producer.connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer producer.close()
+	if err := producer.publish(ctx, []record{{topic: "bfma.signals.BTCUSDT", id: "1", value: []byte("{}")}}); err == nil {
+		t.Fatal("отправка без потока JetStream прошла без ошибки")
+	}
+}
@@ -0,0 +1,279 @@
+// Package stream публикует каждый сигнал и оповещение в Kafka или NATS (JetStream)
+// в JSON с версией схемы или в protobuf (сообщения bfma.v1 из pkg/signalpb), чтобы
+// сервисы исполнения и хранилища данных получали вывод bfma через брокер сообщений.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/signalpb"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Параметры публикации
+const (
+	queueSize      = 1024                  // Сообщений в очереди; при переполнении новые отбрасываются
+	maxBatch       = 256                   // Сообщений в одной отправке
+	maxAttempts    = 5                     // Попыток отправить пачку, после чего она отбрасывается
+	initialBackoff = time.Second           // Пауза перед первым повтором, дальше удваивается
+	maxBackoff     = 30 * time.Second      // Наибольшая пауза между повторами
+	dialTimeout    = 10 * time.Second      // Время на подключение к брокеру
+	requestTimeout = 10 * time.Second      // Время на ответ брокера
+	batchTimeout   = 10 * time.Millisecond // Kafka: ожидание пачки записей перед отправкой
+)
+
+// Типы событий
+const (
+	EventSignal = "signal"
+	EventAlert  = "alert"
+)
+
+// Заголовки сообщений
+const (
+	HeaderSchemaVersion = "bfma-schema-version"
+	HeaderType          = "bfma-type"
+	HeaderContentType   = "content-type"
+)
+
+// Типы содержимого сообщений
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Envelope сообщение в брокере в формате JSON. В protobuf сообщение - сам сигнал
+// (bfma.v1.Signal) или оповещение (bfma.v1.Event), а тип и версия схемы передаются
+// только заголовками.
+type Envelope struct {
+	SchemaVersion int         `json:"schema_version"` // Версия схемы сигнала в data
+	Type          string      `json:"type"`           // signal или alert
+	Symbol        string      `json:"symbol"`
+	Time          time.Time   `json:"time"`
+	Data          interface{} `json:"data"`
+}
+
+// Alert данные события alert
+type Alert struct {
+	Text     string `json:"text"`
+	Critical bool   `json:"critical"`
+}
+
+// record сообщение для отправки
+type record struct {
+	topic   string // Тема Kafka или субъект NATS
+	key     string // Символ: сообщения символа попадают в один раздел Kafka
+	id      string // Идентификатор для устранения повторов (Nats-Msg-Id)
+	value   []byte
+	headers map[string]string
+	time    time.Time
+}
+
+// producer клиент брокера
+type producer interface {
+	// connect подключается к брокеру
+	connect(ctx context.Context) error
+	// publish отправляет пачку и ждет подтверждения брокера
+	publish(ctx context.Context, records []record) error
+	// close закрывает соединения
+	close()
+}
+
+// Publisher публикует сигналы и оповещения в брокер сообщений
+type Publisher struct {
+	cfg           config.StreamConfig
+	schemaVersion func() int
+	producer      producer
+	queue         chan record
+}
+
+// NewPublisher создает издателя для брокера из настроек
+func NewPublisher(cfg config.StreamConfig, schemaVersion func() int) *Publisher {
+	p := &Publisher{cfg: cfg, schemaVersion: schemaVersion, queue: make(chan record, queueSize)}
+	switch cfg.Type {
+	case config.StreamKafka:
+		p.producer = newKafkaProducer(cfg)
+	default:
+		p.producer = newNATSProducer(cfg)
+	}
+	return p
+}
+
+// Start отправляет сообщения до отмены контекста. Пачка, которую брокер не принял,
+// повторяется с переподключением; после maxAttempts попыток она отбрасывается.
+func (p *Publisher) Start(ctx context.Context) {
+	logger.Info("Запуск публикации в брокер сообщений", zap.String("type", p.cfg.Type), zap.Strings("brokers", p.cfg.Brokers))
+	defer p.producer.close()
+
+	connected := false
+	for {
+		var batch []record
+		select {
+		case r := <-p.queue:
+			batch = append(batch, r)
+		case <-ctx.Done():
+			return
+		}
+		// Забираем уже накопившиеся сообщения, чтобы отправить их одной пачкой
+	drain:
+		for len(batch) < maxBatch {
+			select {
+			case r := <-p.queue:
+				batch = append(batch, r)
+			default:
+				break drain
+			}
+		}
+
+		backoff := initialBackoff
+		for attempt := 1; ; attempt++ {
+			err := p.send(ctx, &connected, batch)
+			if err == nil || ctx.Err() != nil {
+				break
+			}
+			if attempt == maxAttempts {
+				logger.Error("Сообщения не отправлены в брокер", zap.Int("messages", len(batch)), zap.Int("attempts", attempt), zap.Error(err))
+				break
+			}
+
+			logger.Warn("Ошибка отправки в брокер сообщений, повтор", zap.Int("attempt", attempt), zap.Duration("backoff", backoff), zap.Error(err))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(2*backoff, maxBackoff)
+		}
+	}
+}
+
+// send подключается при необходимости и отправляет пачку; после ошибки соединение
+// закрывается, следующая попытка подключается заново
+func (p *Publisher) send(ctx context.Context, connected *bool, batch []record) error {
+	if !*connected {
+		if err := p.producer.connect(ctx); err != nil {
+			return err
+		}
+		*connected = true
+	}
+	if err := p.producer.publish(ctx, batch); err != nil {
+		p.producer.close()
+		*connected = false
+		return err
+	}
+	return nil
+}
+
+// PublishSignals ставит в очередь новые сигналы
+func (p *Publisher) PublishSignals(signals map[string]*models.SignalResult) {
+	version := p.schemaVersion()
+	if version == 0 {
+		version = schema.Latest
+	}
+	for symbol, signal := range signals {
+		var data interface{} = signal
+		if p.cfg.Format != config.StreamFormatProtobuf {
+			encoded, err := schema.Encode(signal, nil, version)
+			if err != nil {
+				logger.Warn("Ошибка кодирования сигнала для брокера сообщений", zap.String("symbol", symbol), zap.Error(err))
+				continue
+			}
+			data = encoded
+		}
+		p.enqueue(Envelope{SchemaVersion: version, Type: EventSignal, Symbol: symbol, Time: timezone.In(signal.Timestamp), Data: data})
+	}
+}
+
+// PublishAlert ставит в очередь оповещение интерфейса
+func (p *Publisher) PublishAlert(symbol, text string, critical bool) {
+	version := p.schemaVersion()
+	if version == 0 {
+		version = schema.Latest
+	}
+	p.enqueue(Envelope{
+		SchemaVersion: version,
+		Type:          EventAlert,
+		Symbol:        symbol,
+		Time:          timezone.In(time.Now()),
+		Data:          Alert{Text: text, Critical: critical},
+	})
+}
+
+// enqueue кодирует сообщение и ставит его в очередь. Не блокирует: при
+// переполнении очереди сообщение отбрасывается.
+func (p *Publisher) enqueue(e Envelope) {
+	value, contentType, err := p.encode(e)
+	if err != nil {
+		logger.Warn("Ошибка кодирования сообщения для брокера", zap.String("symbol", e.Symbol), zap.Error(err))
+		return
+	}
+
+	r := record{
+		topic: p.topic(e.Type, e.Symbol),
+		key:   e.Symbol,
+		id:    e.Type + "-" + e.Symbol + "-" + strconv.FormatInt(e.Time.UnixNano(), 10),
+		value: value,
+		headers: map[string]string{
+			HeaderSchemaVersion: strconv.Itoa(e.SchemaVersion),
+			HeaderType:          e.Type,
+			HeaderContentType:   contentType,
+		},
+		time: e.Time,
+	}
+	select {
+	case p.queue <- r:
+	default:
+		logger.Warn("Очередь брокера сообщений переполнена, сообщение пропущено", zap.String("symbol", e.Symbol))
+	}
+}
+
+// encode кодирует сообщение в формате из настроек и возвращает его тип содержимого
+func (p *Publisher) encode(e Envelope) ([]byte, string, error) {
+	if p.cfg.Format != config.StreamFormatProtobuf {
+		value, err := json.Marshal(e)
+		return value, ContentTypeJSON, err
+	}
+
+	var value []byte
+	var err error
+	switch data := e.Data.(type) {
+	case *models.SignalResult:
+		// models.MarshalProto кодирует сигнал сообщением bfma.v1.Signal (signalpb.Signal)
+		value, err = models.MarshalProto(data)
+	case Alert:
+		value, err = proto.Marshal(&signalpb.Event{
+			Timestamp: timestamppb.New(e.Time),
+			Type:      EventAlert,
+			Symbol:    e.Symbol,
+			Message:   data.Text,
+			Critical:  data.Critical,
+		})
+	default:
+		err = fmt.Errorf("нет сообщения protobuf для %T", e.Data)
+	}
+	return value, ContentTypeProtobuf, err
+}
+
+// topic возвращает тему Kafka (<prefix>.signals, ключ - символ) или субъект NATS
+// (<prefix>.signals.BTCUSDT)
+func (p *Publisher) topic(event, symbol string) string {
+	prefix := p.cfg.Topic
+	if prefix == "" {
+		prefix = "bfma"
+	}
+	topic := prefix + "." + event + "s"
+	if p.cfg.Type == config.StreamKafka {
+		return topic
+	}
+	return topic + "." + symbol
+}
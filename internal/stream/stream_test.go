@@ -0,0 +1,164 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/signalpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// testSignal возвращает сигнал для проверки кодирования
+func testSignal() *models.SignalResult {
+	return &models.SignalResult{
+		Symbol:             "BTCUSDT",
+		Timestamp:          time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Recommendation:     "Покупать",
+		RecommendationCode: models.RecommendationBuy,
+		SignalStrength:     0.42,
+		CurrentPrice:       84250.5,
+		Components:         map[string]float64{"trend": 0.5},
+	}
+}
+
+// nextRecord возвращает запись из очереди издателя
+func nextRecord(t *testing.T, p *Publisher) record {
+	t.Helper()
+	select {
+	case r := <-p.queue:
+		return r
+	default:
+		t.Fatal("очередь издателя пуста")
+		return record{}
+	}
+}
+
+func TestPublishSignalJSON(t *testing.T) {
+	p := NewPublisher(config.StreamConfig{Type: config.StreamNATS, Topic: "test"}, func() int { return 0 })
+	p.PublishSignals(map[string]*models.SignalResult{"BTCUSDT": testSignal()})
+
+	r := nextRecord(t, p)
+	if r.topic != "test.signals.BTCUSDT" || r.key != "BTCUSDT" {
+		t.Fatalf("тема %q и ключ %q, ожидались test.signals.BTCUSDT и BTCUSDT", r.topic, r.key)
+	}
+	if r.headers[HeaderType] != EventSignal || r.headers[HeaderContentType] != ContentTypeJSON {
+		t.Fatalf("неверные заголовки: %v", r.headers)
+	}
+
+	var envelope struct {
+		SchemaVersion int             `json:"schema_version"`
+		Type          string          `json:"type"`
+		Symbol        string          `json:"symbol"`
+		Data          json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(r.value, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Type != EventSignal || envelope.Symbol != "BTCUSDT" || len(envelope.Data) == 0 {
+		t.Fatalf("неверное сообщение: %s", r.value)
+	}
+	if r.headers[HeaderSchemaVersion] == "" || r.headers[HeaderSchemaVersion] == "0" {
+		t.Fatalf("не задана версия схемы: %v", r.headers)
+	}
+}
+
+func TestPublishProtobuf(t *testing.T) {
+	p := NewPublisher(config.StreamConfig{Type: config.StreamKafka, Format: config.StreamFormatProtobuf}, func() int { return 0 })
+	p.PublishSignals(map[string]*models.SignalResult{"BTCUSDT": testSignal()})
+	p.PublishAlert("ETHUSDT", "Сильный сигнал", true)
+
+	r := nextRecord(t, p)
+	if r.topic != "bfma.signals" || r.headers[HeaderContentType] != ContentTypeProtobuf {
+		t.Fatalf("тема %q, заголовки %v", r.topic, r.headers)
+	}
+	signal := &signalpb.Signal{}
+	if err := proto.Unmarshal(r.value, signal); err != nil {
+		t.Fatal(err)
+	}
+	if signal.GetSymbol() != "BTCUSDT" || signal.GetRecommendation() != signalpb.Recommendation_RECOMMENDATION_BUY ||
+		signal.GetCurrentPrice() != 84250.5 || signal.GetComponents()["trend"] != 0.5 {
+		t.Fatalf("неверный сигнал: %v", signal)
+	}
+	if !signal.GetTimestamp().AsTime().Equal(testSignal().Timestamp) {
+		t.Fatalf("время %v, ожидалось %v", signal.GetTimestamp().AsTime(), testSignal().Timestamp)
+	}
+
+	r = nextRecord(t, p)
+	if r.topic != "bfma.alerts" || r.headers[HeaderType] != EventAlert {
+		t.Fatalf("тема %q, заголовки %v", r.topic, r.headers)
+	}
+	event := &signalpb.Event{}
+	if err := proto.Unmarshal(r.value, event); err != nil {
+		t.Fatal(err)
+	}
+	if event.GetSymbol() != "ETHUSDT" || event.GetMessage() != "Сильный сигнал" || !event.GetCritical() || event.GetType() != EventAlert {
+		t.Fatalf("неверное оповещение: %v", event)
+	}
+}
+
+// fakeProducer клиент брокера, первые failures отправок которого завершаются ошибкой
+type fakeProducer struct {
+	mutex     sync.Mutex
+	failures  int
+	connects  int
+	closes    int
+	published []record
+	delivered chan struct{}
+}
+
+func (f *fakeProducer) connect(ctx context.Context) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.connects++
+	return nil
+}
+
+func (f *fakeProducer) publish(ctx context.Context, records []record) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("соединение разорвано")
+	}
+	f.published = append(f.published, records...)
+	f.delivered <- struct{}{}
+	return nil
+}
+
+func (f *fakeProducer) close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.closes++
+}
+
+func TestPublisherReconnectsAfterFailure(t *testing.T) {
+	fake := &fakeProducer{failures: 1, delivered: make(chan struct{}, 1)}
+	p := NewPublisher(config.StreamConfig{Type: config.StreamNATS}, func() int { return 0 })
+	p.producer = fake
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Start(ctx)
+	p.PublishAlert("BTCUSDT", "проверка", false)
+
+	select {
+	case <-fake.delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("сообщение не отправлено после ошибки")
+	}
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if fake.connects != 2 || fake.closes < 1 {
+		t.Fatalf("подключений %d, закрытий %d; ожидалось переподключение после ошибки", fake.connects, fake.closes)
+	}
+	if len(fake.published) != 1 || fake.published[0].headers[HeaderType] != EventAlert {
+		t.Fatalf("отправлено %v", fake.published)
+	}
+}
@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Адрес Bot API по умолчанию
+const defaultAPIURL = "https://api.telegram.org"
+
+// Ограничения Bot API на длину текста
+const (
+	maxMessageLength = 4096
+	maxCaptionLength = 1024
+)
+
+// apiResponse ответ Bot API
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+// update входящее обновление getUpdates
+type update struct {
+	ID      int64    `json:"update_id"`
+	Message *message `json:"message"`
+}
+
+// message сообщение чата
+type message struct {
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text string `json:"text"`
+}
+
+// call вызывает метод Bot API с параметрами формы и разбирает result в out
+func (b *Bot) call(ctx context.Context, method string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.methodURL(method), bytes.NewBufferString(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return b.do(req, method, out)
+}
+
+// sendMessage отправляет текст в чат
+func (b *Bot) sendMessage(ctx context.Context, chatID int64, text string) error {
+	params := url.Values{
+		"chat_id":                  {strconv.FormatInt(chatID, 10)},
+		"text":                     {truncate(text, maxMessageLength)},
+		"disable_web_page_preview": {"true"},
+	}
+	return b.call(ctx, "sendMessage", params, nil)
+}
+
+// sendPhoto отправляет изображение PNG с подписью
+func (b *Bot) sendPhoto(ctx context.Context, chatID int64, caption string, photo []byte) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	w.WriteField("caption", truncate(caption, maxCaptionLength))
+	part, err := w.CreateFormFile("photo", "chart.png")
+	if err != nil {
+		return err
+	}
+	part.Write(photo)
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.methodURL("sendPhoto"), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return b.do(req, "sendPhoto", nil)
+}
+
+// do выполняет запрос. Адрес запроса содержит токен бота, поэтому в ошибку
+// попадает только причина, без адреса.
+func (b *Bot) do(req *http.Request, method string, out interface{}) error {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("ошибка запроса %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result apiResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&result); err != nil {
+		return fmt.Errorf("ошибка разбора ответа %s (%s): %w", method, resp.Status, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("ошибка %s: %s", method, result.Description)
+	}
+	if out != nil {
+		if err := json.Unmarshal(result.Result, out); err != nil {
+			return fmt.Errorf("ошибка разбора ответа %s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// methodURL возвращает адрес метода Bot API
+func (b *Bot) methodURL(method string) string {
+	base := b.cfg.APIURL
+	if base == "" {
+		base = defaultAPIURL
+	}
+	return base + "/bot" + b.cfg.Token + "/" + method
+}
+
+// truncate обрезает текст до limit символов
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,376 @@
+// Package telegram отправляет оповещения в Telegram и отвечает на команды бота:
+// /signal BTCUSDT, /signals, /mute ETHUSDT 2h, /unmute ETHUSDT, /mutes, /ack, /help.
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/chart"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры работы бота
+const (
+	pollTimeout = 30 * time.Second // Длительность long polling getUpdates
+	retryDelay  = 5 * time.Second  // Пауза после ошибки опроса
+	sendTimeout = 20 * time.Second // Время на отправку одного оповещения
+	queueSize   = 64               // Оповещений в очереди; при переполнении новые отбрасываются
+)
+
+// SignalSource источник сигналов для оповещений и ответов на команды
+type SignalSource interface {
+	LatestSignals() map[string]*models.SignalResult
+}
+
+// ChartRenderer рисует график символа для оповещения
+type ChartRenderer interface {
+	Render(ctx context.Context, symbol string) (*chart.Snapshot, error)
+}
+
+// MuteList символы с отключенными оповещениями, общий с интерфейсом. Нулевое время
+// окончания означает отключение до /unmute.
+type MuteList interface {
+	Muted(symbol string) bool
+	Symbols() map[string]time.Time
+	Mute(symbol string, until time.Time) error
+	Unmute(symbol string) error
+}
+
+// Acknowledger подтверждает вызовы эскалации сильных сигналов
+type Acknowledger interface {
+	Acknowledge(symbol string) []string
+}
+
+// notification оповещение в очереди отправки
+type notification struct {
+	symbol   string
+	text     string
+	critical bool
+}
+
+// Bot бот Telegram
+type Bot struct {
+	cfg    config.TelegramConfig
+	source SignalSource
+	client *http.Client
+	queue  chan notification
+	mutes  MuteList
+	charts ChartRenderer
+	acks   Acknowledger // nil - эскалация выключена
+}
+
+// NewBot создает бота. charts рисует графики к оповещениям при включенном telegram.charts.
+func NewBot(cfg config.TelegramConfig, source SignalSource, mutes MuteList, charts ChartRenderer) *Bot {
+	return &Bot{
+		cfg:    cfg,
+		source: source,
+		client: &http.Client{Timeout: pollTimeout + 10*time.Second},
+		queue:  make(chan notification, queueSize),
+		mutes:  mutes,
+		charts: charts,
+	}
+}
+
+// SetAcknowledger подключает команду /ack к эскалации. Вызывается до Start.
+func (b *Bot) SetAcknowledger(acks Acknowledger) {
+	b.acks = acks
+}
+
+// Start отправляет оповещения и обрабатывает команды до отмены контекста
+func (b *Bot) Start(ctx context.Context) {
+	logger.Info("Запуск бота Telegram", zap.Int("chats", len(b.cfg.ChatIDs)))
+	go b.sendLoop(ctx)
+	b.pollLoop(ctx)
+}
+
+// PublishAlert ставит оповещение в очередь отправки во все чаты. Не блокирует:
+// при переполнении очереди оповещение отбрасывается.
+func (b *Bot) PublishAlert(symbol, text string, critical bool) {
+	select {
+	case b.queue <- notification{symbol: symbol, text: text, critical: critical}:
+	default:
+		logger.Warn("Очередь оповещений Telegram переполнена, оповещение пропущено", zap.String("symbol", symbol))
+	}
+}
+
+// sendLoop отправляет оповещения из очереди
+func (b *Bot) sendLoop(ctx context.Context) {
+	for {
+		select {
+		case n := <-b.queue:
+			b.notify(ctx, n)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// notify отправляет оповещение с пояснением по компонентам сигнала и графиком
+func (b *Bot) notify(ctx context.Context, n notification) {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	text := n.symbol + ": " + n.text
+	if n.critical {
+		text = "❗ " + text
+	}
+	signal := b.source.LatestSignals()[n.symbol]
+	if signal != nil {
+		text += "\n\n" + explain(signal)
+	}
+
+	var snapshot *chart.Snapshot
+	if signal != nil && b.cfg.Charts {
+		snapshot = b.chart(ctx, n.symbol)
+	}
+	if snapshot != nil {
+		text += "\n" + snapshot.Describe()
+	}
+
+	for _, chatID := range b.cfg.ChatIDs {
+		var err error
+		if snapshot != nil {
+			err = b.sendPhoto(ctx, chatID, text, snapshot.PNG)
+		} else {
+			err = b.sendMessage(ctx, chatID, text)
+		}
+		if err != nil {
+			logger.Warn("Ошибка отправки оповещения в Telegram", zap.Int64("chat", chatID), zap.Error(err))
+		}
+	}
+}
+
+// chart рисует график символа; nil, если свечей мало или график не построен
+func (b *Bot) chart(ctx context.Context, symbol string) *chart.Snapshot {
+	snapshot, err := b.charts.Render(ctx, symbol)
+	if err != nil {
+		if !errors.Is(err, chart.ErrNotEnoughData) {
+			logger.Warn("Ошибка построения графика", zap.String("symbol", symbol), zap.Error(err))
+		}
+		return nil
+	}
+	return snapshot
+}
+
+// pollLoop получает команды через long polling getUpdates
+func (b *Bot) pollLoop(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		params := url.Values{
+			"offset":          {strconv.FormatInt(offset, 10)},
+			"timeout":         {strconv.Itoa(int(pollTimeout.Seconds()))},
+			"allowed_updates": {`["message"]`},
+		}
+		var updates []update
+		if err := b.call(ctx, "getUpdates", params, &updates); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("Ошибка получения команд Telegram", zap.Error(err))
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.ID + 1
+			if u.Message == nil || !slices.Contains(b.cfg.ChatIDs, u.Message.Chat.ID) {
+				continue
+			}
+			reply := b.handleCommand(u.Message.Text, time.Now())
+			if reply == "" {
+				continue
+			}
+			if err := b.sendMessage(ctx, u.Message.Chat.ID, reply); err != nil {
+				logger.Warn("Ошибка ответа на команду Telegram", zap.Error(err))
+			}
+		}
+	}
+}
+
+// handleCommand выполняет команду и возвращает ответ; пустой ответ - не команда
+func (b *Bot) handleCommand(text string, now time.Time) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+	// В группах команда приходит с именем бота: /signal@bfma_bot
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	args := fields[1:]
+	symbol := ""
+	if len(args) > 0 {
+		symbol = strings.ToUpper(args[0])
+	}
+
+	switch command {
+	case "/signal":
+		if symbol == "" {
+			return "Использование: /signal BTCUSDT"
+		}
+		signal, ok := b.source.LatestSignals()[symbol]
+		if !ok {
+			return fmt.Sprintf("Нет сигнала для %s: символ не отслеживается или анализ еще не выполнялся", symbol)
+		}
+		return fmt.Sprintf("%s, %s\n\n%s", symbol, timezone.In(signal.Timestamp).Format("2006-01-02 15:04:05"), explain(signal))
+
+	case "/signals":
+		return b.summary()
+
+	case "/mute":
+		if symbol == "" {
+			return "Использование: /mute ETHUSDT 2h"
+		}
+		var until time.Time
+		if len(args) > 1 {
+			duration, err := time.ParseDuration(args[1])
+			if err != nil || duration <= 0 {
+				return fmt.Sprintf("Неверная длительность %q, например 30m или 2h", args[1])
+			}
+			until = now.Add(duration)
+		}
+		if err := b.mutes.Mute(symbol, until); err != nil {
+			logger.Warn("Ошибка сохранения списка отключенных оповещений", zap.Error(err))
+		}
+		if until.IsZero() {
+			return fmt.Sprintf("Оповещения %s отключены до /unmute", symbol)
+		}
+		return fmt.Sprintf("Оповещения %s отключены до %s", symbol, timezone.In(until).Format("2006-01-02 15:04"))
+
+	case "/unmute":
+		if symbol == "" {
+			return "Использование: /unmute ETHUSDT"
+		}
+		if err := b.mutes.Unmute(symbol); err != nil {
+			logger.Warn("Ошибка сохранения списка отключенных оповещений", zap.Error(err))
+		}
+		return fmt.Sprintf("Оповещения %s включены", symbol)
+
+	case "/mutes":
+		return b.mutesList()
+
+	case "/ack":
+		if b.acks == nil {
+			return "Эскалация сильных сигналов выключена"
+		}
+		acked := b.acks.Acknowledge(symbol)
+		if len(acked) == 0 {
+			return "Нет активных вызовов"
+		}
+		return "Вызовы подтверждены: " + strings.Join(acked, ", ")
+
+	case "/start", "/help":
+		return "Команды:\n" +
+			"/signal BTCUSDT - последний сигнал с разбивкой по компонентам\n" +
+			"/signals - рекомендации всех символов\n" +
+			"/mute ETHUSDT 2h - отключить оповещения символа на время, без длительности - до /unmute\n" +
+			"/unmute ETHUSDT - включить оповещения символа\n" +
+			"/mutes - символы с отключенными оповещениями\n" +
+			"/ack BTCUSDT - подтвердить вызов эскалации символа, без символа - все вызовы"
+
+	default:
+		return fmt.Sprintf("Неизвестная команда %s, список команд: /help", command)
+	}
+}
+
+// summary возвращает рекомендации всех символов
+func (b *Bot) summary() string {
+	signals := b.source.LatestSignals()
+	if len(signals) == 0 {
+		return "Сигналов пока нет"
+	}
+
+	symbols := make([]string, 0, len(signals))
+	for symbol := range signals {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var sb strings.Builder
+	for _, symbol := range symbols {
+		signal := signals[symbol]
+		fmt.Fprintf(&sb, "%s: %s (%.1f)", symbol, signal.Recommendation, signal.SignalStrength)
+		if b.mutes.Muted(symbol) {
+			sb.WriteString(" - оповещения отключены")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// mutesList возвращает символы с отключенными оповещениями
+func (b *Bot) mutesList() string {
+	mutes := b.mutes.Symbols()
+	if len(mutes) == 0 {
+		return "Оповещения всех символов включены"
+	}
+
+	symbols := make([]string, 0, len(mutes))
+	for symbol := range mutes {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var sb strings.Builder
+	for _, symbol := range symbols {
+		if until := mutes[symbol]; until.IsZero() {
+			fmt.Fprintf(&sb, "%s: до /unmute\n", symbol)
+		} else {
+			fmt.Fprintf(&sb, "%s: до %s\n", symbol, timezone.In(until).Format("2006-01-02 15:04"))
+		}
+	}
+	return sb.String()
+}
+
+// explain описывает сигнал: рекомендация, сила, цена и вклад компонентов
+func explain(signal *models.SignalResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Рекомендация: %s\nСила сигнала: %.1f\nЦена: %s\n", signal.Recommendation, signal.SignalStrength,
+		strconv.FormatFloat(signal.CurrentPrice, 'f', -1, 64))
+	if signal.PositionSize > 0 {
+		fmt.Fprintf(&sb, "Размер позиции: %.2f\n", signal.PositionSize)
+	}
+
+	// Компоненты по убыванию влияния на сигнал
+	names := make([]string, 0, len(signal.Components))
+	for name := range signal.Components {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := signal.Components[names[i]], signal.Components[names[j]]
+		if abs(a) != abs(b) {
+			return abs(a) > abs(b)
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > 0 {
+		sb.WriteString("Компоненты:\n")
+	}
+	for _, name := range names {
+		fmt.Fprintf(&sb, "  %s: %+.1f\n", name, signal.Components[name])
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// abs возвращает модуль числа
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
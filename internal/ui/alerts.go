@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/config"
+	journal "github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры панели оповещений
+const (
+	maxAlerts     = 100             // Сколько оповещений держать в памяти
+	alertsWidth   = 56              // Ширина панели оповещений
+	flashDuration = 2 * time.Second // Длительность подсветки при важном оповещении
+)
+
+// Стили панели оповещений
+var (
+	alertsHeaderStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#ffffff")).
+				Background(secondaryColor).
+				Padding(0, 1)
+	alertsFlashStyle = alertsHeaderStyle.
+				Background(errorColor)
+	alertsSectionStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(secondaryColor).
+				Padding(0, 1).
+				Width(alertsWidth)
+	alertAckedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#777777"))
+)
+
+// alert представляет оповещение в панели
+type alert struct {
+	Time     time.Time
+	Symbol   string
+	Text     string
+	Critical bool
+	Acked    bool
+}
+
+// MuteList символы с отключенными оповещениями
+type MuteList interface {
+	Muted(symbol string) bool
+	Toggle(symbol string) (bool, error)
+}
+
+// AddAlert добавляет оповещение в панель. Важные оповещения подсвечивают панель
+// и, если включено в настройках, подают звуковой сигнал терминала. Вне торговых
+// сессий и для отключенных символов оповещение только записывается в панель;
+// во внешние каналы оповещение уходит событием events.AlertFired (SetEventBus).
+func (ui *TermUI) AddAlert(symbol, text string, critical bool) {
+	ui.addAlert("", symbol, text, critical)
+}
+
+// addAlert добавляет оповещение вида alertType (events.Alert*)
+func (ui *TermUI) addAlert(alertType, symbol, text string, critical bool) {
+	ui.alertsMutex.Lock()
+	ui.alerts = append(ui.alerts, alert{
+		Time:     time.Now(),
+		Symbol:   symbol,
+		Text:     text,
+		Critical: critical,
+	})
+	if len(ui.alerts) > maxAlerts {
+		ui.alerts = ui.alerts[len(ui.alerts)-maxAlerts:]
+	}
+	muted := ui.mutes != nil && ui.mutes.Muted(symbol)
+	bell := critical && !muted && ui.alertAllowed(config.ChannelBell)
+	if bell {
+		ui.flashUntil = time.Now().Add(flashDuration)
+	}
+	ui.alertsMutex.Unlock()
+
+	journal.Record(journal.TypeAlert, symbol, text, map[string]string{
+		"critical": strconv.FormatBool(critical),
+		"muted":    strconv.FormatBool(muted),
+	})
+
+	ui.bus.Publish(events.AlertFired{Type: alertType, Symbol: symbol, Text: text, Critical: critical, Time: time.Now()})
+
+	if ui.config.Headless && !muted && ui.alertAllowed(config.ChannelPlain) {
+		ui.headlessAlert(symbol, text, critical)
+	} else if ui.config.Plain && !muted && ui.alertAllowed(config.ChannelPlain) {
+		key := "ui.plain_alert"
+		if critical {
+			key = "ui.plain_critical"
+		}
+		ui.printPlain(ui.tr.T(key, symbol, text))
+	}
+
+	if !bell {
+		return
+	}
+
+	if ui.config.AlertBell && !ui.config.Headless {
+		os.Stdout.WriteString("\a")
+	}
+
+	// Перерисовываем экран после окончания подсветки
+	time.AfterFunc(flashDuration, func() {
+		ui.requestRefresh()
+	})
+}
+
+// SetEventBus задает шину, в которую публикуются все оповещения для внешних каналов
+// (их принимает распределитель notify.Dispatcher). Закрытые свечи и ставки
+// финансирования из шины перерисовывают экран: состояние потоков и ставки
+// обновляются сразу, а не на следующем цикле анализа.
+func (ui *TermUI) SetEventBus(bus *events.Bus) {
+	ui.bus = bus
+	events.Subscribe(bus, "ui", func(events.CandleClosed) { ui.requestRefresh() })
+	events.Subscribe(bus, "ui", func(events.FundingUpdated) { ui.requestRefresh() })
+}
+
+// SetMuteList включает отключение оповещений выбранного символа клавишей
+func (ui *TermUI) SetMuteList(mutes MuteList) {
+	ui.mutes = mutes
+}
+
+// SetAlertFilter задает проверку, подается ли оповещение в канал терминала
+// (config.ChannelBell, ChannelPlain) в текущий момент
+func (ui *TermUI) SetAlertFilter(allowed func(channel string) bool) {
+	ui.alertFilter = allowed
+}
+
+// alertAllowed сообщает, подается ли оповещение в канал; без фильтра - всегда
+func (ui *TermUI) alertAllowed(channel string) bool {
+	return ui.alertFilter == nil || ui.alertFilter(channel)
+}
+
+// toggleMuteSelected отключает или включает оповещения выбранного символа
+func (ui *TermUI) toggleMuteSelected() {
+	if ui.mutes == nil {
+		return
+	}
+
+	ui.signalsMutex.RLock()
+	symbols := ui.visibleSymbols()
+	ui.signalsMutex.RUnlock()
+
+	if ui.selectedIndex < 0 || ui.selectedIndex >= len(symbols) {
+		return
+	}
+
+	if _, err := ui.mutes.Toggle(symbols[ui.selectedIndex]); err != nil {
+		logger.Warn("Ошибка переключения оповещений символа",
+			zap.String("symbol", symbols[ui.selectedIndex]), zap.Error(err))
+	}
+}
+
+// AcknowledgeAlerts помечает все оповещения как просмотренные
+func (ui *TermUI) AcknowledgeAlerts() {
+	ui.alertsMutex.Lock()
+	defer ui.alertsMutex.Unlock()
+
+	for i := range ui.alerts {
+		ui.alerts[i].Acked = true
+	}
+	ui.flashUntil = time.Time{}
+}
+
+// detectSignalChanges создает оповещения при смене рекомендации
+func (ui *TermUI) detectSignalChanges(previous, current map[string]*models.SignalResult) {
+	for symbol, signal := range current {
+		old, ok := previous[symbol]
+		if !ok || old.RecommendationCode == "" || old.RecommendationCode == signal.RecommendationCode {
+			continue
+		}
+
+		critical := signal.RecommendationCode.Strong()
+		ui.addAlert(events.AlertSignalChange, symbol, ui.tr.T("ui.alert_signal_change",
+			old.RecommendationCode.Localize(ui.tr),
+			signal.RecommendationCode.Localize(ui.tr),
+			signal.SignalStrength), critical)
+	}
+}
+
+// renderAlertsSection отображает панель оповещений, новые сверху
+func renderAlertsSection(alerts []alert, flash bool, tr *i18n.Translator, height int) string {
+	unacked := 0
+	for _, a := range alerts {
+		if !a.Acked {
+			unacked++
+		}
+	}
+
+	headerStyle := alertsHeaderStyle
+	if flash {
+		headerStyle = alertsFlashStyle
+	}
+	header := headerStyle.Render(tr.T("ui.alerts", unacked))
+
+	rows := max(1, height-paneChrome)
+	lines := make([]string, 0, rows)
+	if len(alerts) == 0 {
+		lines = append(lines, "  "+tr.T("ui.alerts_empty"))
+	}
+	for i := len(alerts) - 1; i >= 0 && len(lines) < rows; i-- {
+		a := alerts[i]
+		line := timezone.In(a.Time).Format("15:04:05") + " " + a.Symbol + ": " + a.Text
+		if len([]rune(line)) > alertsWidth-4 {
+			line = string([]rune(line)[:alertsWidth-5]) + "…"
+		}
+
+		switch {
+		case a.Acked:
+			line = alertAckedStyle.Render("  " + line)
+		case a.Critical:
+			line = lipgloss.NewStyle().Foreground(errorColor).Bold(true).Render("! " + line)
+		default:
+			line = lipgloss.NewStyle().Foreground(warningColor).Render("• " + line)
+		}
+		lines = append(lines, line)
+	}
+
+	return alertsSectionStyle.Height(height - 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			header,
+			strings.Join(lines, "\n"),
+		),
+	)
+}
@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Утилиты буфера обмена в порядке предпочтения
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+}
+
+// selectedSignal возвращает сигнал выбранной строки или nil
+func (ui *TermUI) selectedSignal() *models.SignalResult {
+	ui.signalsMutex.RLock()
+	defer ui.signalsMutex.RUnlock()
+
+	symbols := ui.visibleSymbols()
+	if ui.selectedIndex < 0 || ui.selectedIndex >= len(symbols) {
+		return nil
+	}
+
+	signal, ok := ui.signals[symbols[ui.selectedIndex]]
+	if !ok || signal.RecommendationCode == "" {
+		return nil
+	}
+	return signal
+}
+
+// copySelectedSignal копирует выбранный сигнал с разбивкой по компонентам в буфер обмена
+// в формате JSON настроенной версии схемы
+func (ui *TermUI) copySelectedSignal() {
+	signal := ui.selectedSignal()
+	if signal == nil {
+		logger.Warn("Нет сигнала для копирования")
+		return
+	}
+
+	exported, err := schema.Encode(signal, ui.symbolNotes(signal.Symbol), int(ui.schemaVersion.Load()))
+	if err != nil {
+		logger.Warn("Ошибка сериализации сигнала", zap.Error(err))
+		return
+	}
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		logger.Warn("Ошибка сериализации сигнала", zap.Error(err))
+		return
+	}
+
+	if err := copyToClipboard(string(data)); err != nil {
+		logger.Warn("Ошибка копирования в буфер обмена", zap.Error(err))
+		return
+	}
+	logger.Info("Сигнал скопирован в буфер обмена", zap.String("symbol", signal.Symbol))
+}
+
+// copyToClipboard копирует текст через системную утилиту, а если ее нет -
+// через escape-последовательность OSC 52, которую поддерживает большинство терминалов
+func copyToClipboard(text string) error {
+	for _, command := range clipboardCommands {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("ошибка запуска %s: %w", command[0], err)
+		}
+		return nil
+	}
+
+	_, err := fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+// exportSignalsCSV сохраняет таблицу сигналов текущего представления в CSV-файл
+func (ui *TermUI) exportSignalsCSV() {
+	ui.signalsMutex.RLock()
+	var signals []*models.SignalResult
+	for _, symbol := range ui.visibleSymbols() {
+		if signal, ok := ui.signals[symbol]; ok && signal.RecommendationCode != "" {
+			signals = append(signals, signal)
+		}
+	}
+	ui.signalsMutex.RUnlock()
+
+	notes := make(map[string][]*models.Note, len(signals))
+	for _, signal := range signals {
+		notes[signal.Symbol] = ui.symbolNotes(signal.Symbol)
+	}
+
+	if len(signals) == 0 {
+		logger.Warn("Нет сигналов для экспорта")
+		return
+	}
+
+	path := filepath.Join(ui.config.ExportDir, "signals_"+timezone.Now().Format("20060102_150405")+".csv")
+	if err := writeSignalsFile(path, signals, notes); err != nil {
+		logger.Warn("Ошибка экспорта сигналов", zap.Error(err))
+		return
+	}
+	logger.Info("Сигналы экспортированы", zap.String("path", path), zap.Int("count", len(signals)))
+}
+
+// writeSignalsFile записывает сигналы в CSV-файл
+func writeSignalsFile(path string, signals []*models.SignalResult, notes map[string][]*models.Note) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла: %w", err)
+	}
+	defer file.Close()
+	return WriteSignalsCSV(file, signals, notes)
+}
+
+// WriteSignalsCSV записывает сигналы в CSV; компоненты выводятся отдельными столбцами,
+// заметки символа - одним столбцом через " | "
+func WriteSignalsCSV(out io.Writer, signals []*models.SignalResult, notes map[string][]*models.Note) error {
+	// Собираем имена всех компонентов для заголовка
+	seen := make(map[string]bool)
+	var components []string
+	for _, signal := range signals {
+		for name := range signal.Components {
+			if !seen[name] {
+				seen[name] = true
+				components = append(components, name)
+			}
+		}
+	}
+	sort.Strings(components)
+
+	w := csv.NewWriter(out)
+	header := []string{"symbol", "timestamp", "recommendation", "signal_strength", "position_size", "current_price"}
+	header = append(header, components...)
+	if err := w.Write(append(header, "notes")); err != nil {
+		return fmt.Errorf("ошибка записи CSV: %w", err)
+	}
+
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	for _, signal := range signals {
+		record := []string{
+			signal.Symbol,
+			timezone.In(signal.Timestamp).Format(time.RFC3339),
+			signal.RecommendationCode.String(),
+			formatFloat(signal.SignalStrength),
+			formatFloat(signal.PositionSize),
+			formatFloat(signal.CurrentPrice),
+		}
+		for _, name := range components {
+			value, ok := signal.Components[name]
+			if !ok {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, formatFloat(value))
+		}
+
+		texts := make([]string, 0, len(notes[signal.Symbol]))
+		for _, note := range notes[signal.Symbol] {
+			texts = append(texts, note.Text)
+		}
+		record = append(record, strings.Join(texts, " | "))
+
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("ошибка записи CSV: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("ошибка записи CSV: %w", err)
+	}
+	return nil
+}
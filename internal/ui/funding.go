@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// За сколько до расчета финансирования подсвечивать обратный отсчет
+const fundingSoon = 15 * time.Minute
+
+// Стили столбца финансирования
+var (
+	fundingStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#999999"))
+	fundingSoonStyle = lipgloss.NewStyle().Foreground(warningColor).Bold(true)
+)
+
+// FundingSource предоставляет прогнозные ставки финансирования
+type FundingSource interface {
+	Funding(symbol string) (*models.FundingRate, bool)
+}
+
+// SetFundingSource включает столбец ставки финансирования и обратного отсчета
+func (ui *TermUI) SetFundingSource(source FundingSource) {
+	ui.funding = source
+}
+
+// fundingText возвращает ставку финансирования символа и время до следующего расчета
+func (ui *TermUI) fundingText(symbol string, now time.Time) string {
+	if ui.funding == nil {
+		return ""
+	}
+
+	rate, ok := ui.funding.Funding(symbol)
+	if !ok || rate.NextFundingTime.IsZero() {
+		return ""
+	}
+
+	left := rate.NextFundingTime.Sub(now)
+	style := fundingStyle
+	if left < fundingSoon {
+		style = fundingSoonStyle
+	}
+	return style.Render(ui.tr.T("ui.signal_funding", rate.Percent(), formatCountdown(left)))
+}
+
+// formatCountdown форматирует оставшееся время как ЧЧ:ММ:СС
+func formatCountdown(left time.Duration) string {
+	if left < 0 {
+		left = 0
+	}
+	seconds := int(left.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
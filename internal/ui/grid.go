@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Ширина ячейки сетки: пробел, 6 символов имени, 4 символа силы, пробел
+const gridCellWidth = 12
+
+// Базовые цвета тепловой карты (RGB)
+var (
+	gridNeutralRGB = [3]float64{0x26, 0x26, 0x26}
+	gridBuyRGB     = [3]float64{0x00, 0xcc, 0x33}
+	gridSellRGB    = [3]float64{0xdd, 0x22, 0x00}
+)
+
+// toggleGrid включает или выключает обзор символов сеткой
+func (ui *TermUI) toggleGrid() {
+	ui.grid = !ui.grid
+	ui.history = nil
+}
+
+// moveGridSelection сдвигает выбор в сетке на delta ячеек
+func (ui *TermUI) moveGridSelection(delta int) {
+	ui.signalsMutex.RLock()
+	count := len(ui.visibleSymbols())
+	ui.signalsMutex.RUnlock()
+
+	index := ui.selectedIndex + delta
+	if index >= 0 && index < count {
+		ui.selectedIndex = index
+	}
+}
+
+// gridColor возвращает цвет ячейки: оттенок - направление сигнала,
+// насыщенность - сила относительно порога сильного сигнала
+func gridColor(strength float64, thresholds config.SignalThresholds) lipgloss.Color {
+	target, full := gridBuyRGB, thresholds.StrongBuy
+	if strength < 0 {
+		target, full = gridSellRGB, -thresholds.StrongSell
+	}
+	if full <= 0 {
+		full = 100
+	}
+
+	t := math.Min(1, math.Abs(strength)/full)
+	var rgb [3]int
+	for i := range rgb {
+		rgb[i] = int(gridNeutralRGB[i] + (target[i]-gridNeutralRGB[i])*t)
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]))
+}
+
+// renderGridSection отображает символы компактной цветной сеткой.
+// Возвращает панель и число столбцов сетки.
+func (ui *TermUI) renderGridSection(width, height int) (string, int) {
+	tr := ui.tr
+	header := signalsHeaderStyle.Render(ui.watchlistTitle()) + " " + logsStatusStyle.Render(tr.T("ui.grid_legend"))
+	contentWidth := max(gridCellWidth, width-4)
+	columns := max(1, contentWidth/gridCellWidth)
+	rows := max(1, height-paneChrome)
+
+	symbols := ui.visibleSymbols()
+
+	// Прокручиваем так, чтобы строка с выбранной ячейкой была видна
+	selectedRow := ui.selectedIndex / columns
+	if selectedRow < ui.gridOffset {
+		ui.gridOffset = selectedRow
+	} else if selectedRow >= ui.gridOffset+rows {
+		ui.gridOffset = selectedRow - rows + 1
+	}
+
+	var lines []string
+	if len(symbols) == 0 {
+		lines = append(lines, "  "+tr.T("ui.waiting"))
+	}
+
+	for r := ui.gridOffset; r < ui.gridOffset+rows && r*columns < len(symbols); r++ {
+		var line strings.Builder
+		for c := 0; c < columns && r*columns+c < len(symbols); c++ {
+			index := r*columns + c
+			line.WriteString(renderGridCell(symbols[index], ui.signals[symbols[index]],
+				index == ui.selectedIndex, ui.analyzer.IsPaused(symbols[index]), ui.analyzer.Thresholds(symbols[index])))
+		}
+		lines = append(lines, line.String())
+	}
+
+	return signalsSectionStyle.Width(contentWidth + 2).Height(height - 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			header,
+			strings.Join(lines, "\n"),
+		),
+	), columns
+}
+
+// renderGridCell отображает ячейку символа
+func renderGridCell(symbol string, signal *models.SignalResult, selected, paused bool, thresholds config.SignalThresholds) string {
+	name := strings.TrimSuffix(symbol, "USDT")
+	if len(name) > 6 {
+		name = name[:6]
+	}
+
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+	value := "   ·"
+	switch {
+	case signal == nil || signal.RecommendationCode == "":
+		style = style.Background(lipgloss.Color("#1a1a1a")).Foreground(lipgloss.Color("#777777"))
+	case paused:
+		style = style.Background(lipgloss.Color("#1a1a1a")).Foreground(lipgloss.Color("#885500"))
+		value = fmt.Sprintf("%+4.0f", signal.SignalStrength)
+	default:
+		style = style.Background(gridColor(signal.SignalStrength, thresholds))
+		value = fmt.Sprintf("%+4.0f", signal.SignalStrength)
+	}
+
+	if selected {
+		style = style.Bold(true).Underline(true)
+	}
+
+	return style.Render(fmt.Sprintf(" %-6s%4s ", name, value))
+}
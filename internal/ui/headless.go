@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Типы записей режима --headless
+const (
+	recordSignal = "signal"
+	recordAlert  = "alert"
+)
+
+// headlessRecord строка вывода режима --headless: один объект JSON на строку,
+// чтобы поток можно было разбирать построчно (bfma run --headless | jq)
+type headlessRecord struct {
+	Type     string      `json:"type"`
+	Time     time.Time   `json:"time"`
+	Symbol   string      `json:"symbol"`
+	Signal   interface{} `json:"signal,omitempty"`   // Сигнал в версии схемы output.schema_version
+	Text     string      `json:"text,omitempty"`     // Текст оповещения
+	Critical bool        `json:"critical,omitempty"` // Важное оповещение
+}
+
+// writeRecord выводит запись одной строкой JSON в stdout
+func (ui *TermUI) writeRecord(record headlessRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Error("Ошибка сериализации записи", zap.String("type", record.Type), zap.Error(err))
+		return
+	}
+
+	ui.plain.mu.Lock()
+	defer ui.plain.mu.Unlock()
+
+	if ui.plain.out == nil {
+		return
+	}
+	ui.plain.out.Write(append(data, '\n'))
+}
+
+// headlessSignals выводит каждый рассчитанный сигнал
+func (ui *TermUI) headlessSignals(signals map[string]*models.SignalResult) {
+	version := int(ui.schemaVersion.Load())
+	for _, symbol := range getSymbolsFromSignals(signals) {
+		signal := signals[symbol]
+		if signal.RecommendationCode == "" {
+			continue
+		}
+
+		encoded, err := schema.Encode(signal, nil, version)
+		if err != nil {
+			logger.Error("Ошибка преобразования сигнала в схему", zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		ui.writeRecord(headlessRecord{
+			Type:   recordSignal,
+			Time:   timezone.Now(),
+			Symbol: symbol,
+			Signal: encoded,
+		})
+	}
+}
+
+// headlessAlert выводит оповещение
+func (ui *TermUI) headlessAlert(symbol, text string, critical bool) {
+	ui.writeRecord(headlessRecord{
+		Type:     recordAlert,
+		Time:     timezone.Now(),
+		Symbol:   symbol,
+		Text:     text,
+		Critical: critical,
+	})
+}
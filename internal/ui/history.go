@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Параметры графика истории сигналов
+const (
+	historyLimit       = 500 // Сколько последних сигналов загружать
+	historyAxisWidth   = 6   // Ширина подписей оси силы сигнала
+	historyLoadTimeout = 10 * time.Second
+)
+
+// Стили графика истории
+var (
+	historyStrengthStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff")).Bold(true)
+	historyPriceStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#00cccc"))
+	historyAxisStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#999999"))
+	historyBandColors    = map[models.Recommendation]lipgloss.Color{
+		models.RecommendationStrongBuy:  lipgloss.Color("#0b4d0b"),
+		models.RecommendationBuy:        lipgloss.Color("#0a2e0a"),
+		models.RecommendationSell:       lipgloss.Color("#3d1010"),
+		models.RecommendationStrongSell: lipgloss.Color("#661a1a"),
+	}
+)
+
+// historyView хранит состояние просмотра истории сигналов символа
+type historyView struct {
+	symbol  string
+	signals []*models.SignalResult // В порядке возрастания времени
+	err     error
+	loading bool
+}
+
+// historyMsg сообщает о загрузке истории сигналов
+type historyMsg struct {
+	symbol  string
+	signals []*models.SignalResult
+	err     error
+}
+
+// loadHistory загружает историю сигналов символа в фоне
+func (ui *TermUI) loadHistory(symbol string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ui.ctx, historyLoadTimeout)
+		defer cancel()
+
+		signals, err := ui.analyzer.GetSignalHistory(ctx, symbol, historyLimit)
+		if err != nil {
+			return historyMsg{symbol: symbol, err: err}
+		}
+
+		// Хранилище возвращает новые сигналы первыми
+		for i, j := 0, len(signals)-1; i < j; i, j = i+1, j-1 {
+			signals[i], signals[j] = signals[j], signals[i]
+		}
+		return historyMsg{symbol: symbol, signals: signals}
+	}
+}
+
+// bandCode возвращает код рекомендации, соответствующий значению силы сигнала
+func bandCode(value float64, thresholds config.SignalThresholds) models.Recommendation {
+	switch {
+	case value >= thresholds.StrongBuy:
+		return models.RecommendationStrongBuy
+	case value >= thresholds.Buy:
+		return models.RecommendationBuy
+	case value <= thresholds.StrongSell:
+		return models.RecommendationStrongSell
+	case value <= thresholds.Sell:
+		return models.RecommendationSell
+	default:
+		return models.RecommendationNeutral
+	}
+}
+
+// renderHistorySection отображает график силы сигнала с зонами рекомендаций и ценой,
+// под ним - снимок рынка последнего сигнала и заметки
+func renderHistorySection(h *historyView, state *models.MarketState, notes []*models.Note, thresholds config.SignalThresholds, tr *i18n.Translator, width, height int) string {
+	header := signalsHeaderStyle.Render(tr.T("ui.history", h.symbol))
+	contentWidth := max(20, width-4)
+
+	// Снимок рынка и последние заметки выводим под графиком
+	var footer []string
+	if state != nil {
+		line := formatMarketState(state, tr)
+		if runes := []rune(line); len(runes) > contentWidth {
+			line = string(runes[:contentWidth-1]) + "…"
+		}
+		footer = append(footer, historyAxisStyle.Render(line))
+	}
+	for i, note := range notes {
+		if i >= notesInHistory {
+			break
+		}
+		line := "✎ " + formatNote(note, tr)
+		if runes := []rune(line); len(runes) > contentWidth {
+			line = string(runes[:contentWidth-1]) + "…"
+		}
+		footer = append(footer, historyAxisStyle.Render(line))
+	}
+
+	var body string
+	switch {
+	case h.loading:
+		body = "  " + tr.T("ui.history_loading")
+	case h.err != nil:
+		body = "  " + lipgloss.NewStyle().Foreground(errorColor).Render(tr.T("ui.history_error", h.err))
+	case len(h.signals) == 0:
+		body = "  " + tr.T("ui.history_empty")
+	default:
+		body = renderHistoryChart(h.signals, thresholds, tr, contentWidth, max(3, height-paneChrome-len(footer)))
+	}
+	if len(footer) > 0 {
+		body = lipgloss.JoinVertical(lipgloss.Left, body, strings.Join(footer, "\n"))
+	}
+
+	return signalsSectionStyle.Width(contentWidth + 2).Height(height - 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			header,
+			body,
+		),
+	)
+}
+
+// formatMarketState возвращает снимок рынка одной строкой: цена, спред и дисбаланс
+// стакана, ставка финансирования и открытый интерес
+func formatMarketState(state *models.MarketState, tr *i18n.Translator) string {
+	parts := []string{tr.T("ui.market_price", state.Price)}
+	if state.Book != nil {
+		parts = append(parts, tr.T("ui.market_spread", state.Book.SpreadPct), tr.T("ui.market_imbalance", state.Book.Imbalance))
+	}
+	if state.Funding != nil {
+		parts = append(parts, tr.T("ui.market_funding", state.Funding.Percent()))
+	}
+	if state.OpenInterest != nil {
+		parts = append(parts, tr.T("ui.market_oi", state.OpenInterest.Value.InexactFloat64(), state.OpenInterestChange))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// renderHistoryChart строит график: строки - уровни силы сигнала от 100 до -100,
+// столбцы - сигналы по времени. Последняя строка - ось времени.
+func renderHistoryChart(signals []*models.SignalResult, thresholds config.SignalThresholds, tr *i18n.Translator, width, height int) string {
+	rows := max(2, height-2) // Легенда и ось времени занимают две строки
+	plotWidth := max(1, width-historyAxisWidth)
+	columns := min(len(signals), plotWidth)
+
+	// Выбираем сигналы для столбцов равномерно по всей истории
+	samples := make([]*models.SignalResult, columns)
+	for c := range samples {
+		samples[c] = signals[c*len(signals)/columns]
+	}
+	samples[columns-1] = signals[len(signals)-1]
+
+	// Диапазон цен для наложения на шкалу графика
+	minPrice, maxPrice := math.MaxFloat64, -math.MaxFloat64
+	for _, s := range samples {
+		if s.CurrentPrice > 0 {
+			minPrice = math.Min(minPrice, s.CurrentPrice)
+			maxPrice = math.Max(maxPrice, s.CurrentPrice)
+		}
+	}
+	hasPrice := maxPrice >= minPrice
+
+	rowOf := func(fraction float64) int {
+		fraction = math.Max(0, math.Min(1, fraction))
+		return int(math.Round((1 - fraction) * float64(rows-1)))
+	}
+
+	strengthRows := make([]int, columns)
+	priceRows := make([]int, columns)
+	for c, s := range samples {
+		strengthRows[c] = rowOf((s.SignalStrength + 100) / 200)
+		priceRows[c] = -1
+		if hasPrice && s.CurrentPrice > 0 {
+			fraction := 0.5
+			if maxPrice > minPrice {
+				fraction = (s.CurrentPrice - minPrice) / (maxPrice - minPrice)
+			}
+			priceRows[c] = rowOf(fraction)
+		}
+	}
+
+	lines := make([]string, 0, rows+2)
+	for r := 0; r < rows; r++ {
+		value := 100 - float64(r)*200/float64(rows-1)
+
+		label := ""
+		if r == 0 || r == rows-1 || r == rows/2 {
+			label = fmt.Sprintf("%4.0f", value)
+		}
+		line := strings.Builder{}
+		line.WriteString(historyAxisStyle.Render(fmt.Sprintf("%-4s ┤", label)))
+
+		// Заливка строки цветом зоны рекомендации
+		band := lipgloss.NewStyle()
+		if color, ok := historyBandColors[bandCode(value, thresholds)]; ok {
+			band = band.Background(color)
+		}
+
+		for c := 0; c < columns; c++ {
+			switch {
+			case strengthRows[c] == r:
+				line.WriteString(historyStrengthStyle.Inherit(band).Render("●"))
+			case priceRows[c] == r:
+				line.WriteString(historyPriceStyle.Inherit(band).Render("·"))
+			default:
+				line.WriteString(band.Render(" "))
+			}
+		}
+		lines = append(lines, line.String())
+	}
+
+	// Ось времени: начало и конец истории
+	first := timezone.In(samples[0].Timestamp).Format("02.01 15:04")
+	last := timezone.In(samples[columns-1].Timestamp).Format("02.01 15:04")
+	gap := max(1, columns-len(first)-len(last))
+	lines = append(lines, historyAxisStyle.Render(strings.Repeat(" ", historyAxisWidth)+first+strings.Repeat(" ", gap)+last))
+
+	legend := historyStrengthStyle.Render("●") + " " + tr.T("ui.history_strength")
+	if hasPrice {
+		legend += "  " + historyPriceStyle.Render("·") + " " + tr.T("ui.history_price", minPrice, maxPrice)
+	}
+	lines = append(lines, legend)
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Действия, которые можно назначить на клавиши
+const (
+	actionQuit         = "quit"
+	actionUp           = "up"
+	actionDown         = "down"
+	actionTop          = "top"
+	actionBottom       = "bottom"
+	actionLeft         = "left"
+	actionRight        = "right"
+	actionGrid         = "grid"
+	actionReloadLogs   = "reload_logs"
+	actionPause        = "pause"
+	actionMute         = "mute"
+	actionAckAlerts    = "ack_alerts"
+	actionHistory      = "history"
+	actionTicket       = "ticket"
+	actionCopy         = "copy"
+	actionNote         = "note"
+	actionSignalNote   = "signal_note"
+	actionExport       = "export"
+	actionWatchlist    = "watchlist"
+	actionSort         = "sort"
+	actionAddSymbol    = "add_symbol"
+	actionRemoveSymbol = "remove_symbol"
+	actionLogLevel     = "log_level"
+	actionFollow       = "follow"
+	actionSearch       = "search"
+	actionClearSearch  = "clear_search"
+	actionJumpTime     = "jump_time"
+)
+
+// defaultKeymap назначение клавиш по умолчанию в порядке вывода в футере.
+// Последовательности клавиш записываются через пробел ("g g").
+var defaultKeymap = []struct {
+	action string
+	keys   []string
+}{
+	{actionUp, []string{"up", "k"}},
+	{actionDown, []string{"down", "j"}},
+	{actionTop, []string{"g g", "home"}},
+	{actionBottom, []string{"G", "end"}},
+	{actionLeft, []string{"left"}},
+	{actionRight, []string{"right"}},
+	{actionGrid, []string{"v"}},
+	{actionReloadLogs, []string{"r"}},
+	{actionPause, []string{"p"}},
+	{actionMute, []string{"m"}},
+	{actionAckAlerts, []string{"a"}},
+	{actionHistory, []string{"h"}},
+	{actionTicket, []string{"enter"}},
+	{actionNote, []string{"n"}},
+	{actionSignalNote, []string{"N"}},
+	{actionCopy, []string{"c"}},
+	{actionExport, []string{"e"}},
+	{actionWatchlist, []string{"w"}},
+	{actionSort, []string{"o"}},
+	{actionAddSymbol, []string{"+"}},
+	{actionRemoveSymbol, []string{"-"}},
+	{actionLogLevel, []string{"l"}},
+	{actionSearch, []string{"/"}},
+	{actionClearSearch, []string{"esc"}},
+	{actionFollow, []string{"f"}},
+	{actionJumpTime, []string{"t"}},
+	{actionQuit, []string{"q"}},
+}
+
+// Названия клавиш для футера
+var keyLabels = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+	"enter": "Enter",
+	"esc":   "Esc",
+	"home":  "Home",
+	"end":   "End",
+}
+
+// keymap сопоставляет нажатия клавиш действиям
+type keymap struct {
+	bindings map[string]string   // Клавиша или последовательность -> действие
+	keys     map[string][]string // Действие -> клавиши
+	prefixes map[string]bool     // Начала последовательностей
+	pending  string              // Набранная часть последовательности
+}
+
+// newKeymap строит раскладку из значений по умолчанию и переопределений из конфигурации.
+// Переопределение заменяет все клавиши действия.
+func newKeymap(overrides map[string][]string) (*keymap, error) {
+	k := &keymap{
+		bindings: make(map[string]string),
+		keys:     make(map[string][]string),
+		prefixes: make(map[string]bool),
+	}
+
+	for _, binding := range defaultKeymap {
+		k.keys[binding.action] = binding.keys
+	}
+
+	// Сортируем действия, чтобы ошибки не зависели от порядка обхода map
+	actions := make([]string, 0, len(overrides))
+	for action := range overrides {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	for _, action := range actions {
+		if _, ok := k.keys[action]; !ok {
+			return nil, fmt.Errorf("неизвестное действие %q в ui.keymap", action)
+		}
+		k.keys[action] = overrides[action]
+	}
+
+	for _, binding := range defaultKeymap {
+		for _, key := range k.keys[binding.action] {
+			key = strings.Join(strings.Fields(key), " ")
+			if key == "" {
+				continue
+			}
+			if other, ok := k.bindings[key]; ok && other != binding.action {
+				return nil, fmt.Errorf("клавиша %q назначена на действия %s и %s", key, other, binding.action)
+			}
+			k.bindings[key] = binding.action
+
+			parts := strings.Split(key, " ")
+			for i := 1; i < len(parts); i++ {
+				k.prefixes[strings.Join(parts[:i], " ")] = true
+			}
+		}
+	}
+
+	return k, nil
+}
+
+// resolve возвращает действие для нажатой клавиши с учетом начатой последовательности.
+// Пустая строка означает, что действие не найдено или последовательность еще не завершена.
+func (k *keymap) resolve(key string) string {
+	if k.pending != "" {
+		seq := k.pending + " " + key
+		k.pending = ""
+		if action, ok := k.bindings[seq]; ok {
+			return action
+		}
+		if k.prefixes[seq] {
+			k.pending = seq
+			return ""
+		}
+	}
+
+	if action, ok := k.bindings[key]; ok {
+		return action
+	}
+	if k.prefixes[key] {
+		k.pending = key
+	}
+	return ""
+}
+
+// help возвращает описание клавиш для футера
+func (k *keymap) help(describe func(action string) string) string {
+	parts := make([]string, 0, len(defaultKeymap))
+	for _, binding := range defaultKeymap {
+		keys := k.keys[binding.action]
+		if len(keys) == 0 {
+			continue
+		}
+
+		labels := make([]string, 0, len(keys))
+		for _, key := range keys {
+			label, ok := keyLabels[key]
+			if !ok {
+				label = strings.ReplaceAll(key, " ", "")
+			}
+			labels = append(labels, label)
+		}
+		parts = append(parts, strings.Join(labels, "/")+" - "+describe(binding.action))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,258 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Уровни логирования в порядке возрастания важности
+var logLevels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// Регулярное выражение для удаления ANSI-цветов
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Стили панели логов
+var (
+	logLevelStyles = map[string]lipgloss.Style{
+		"DEBUG": lipgloss.NewStyle().Foreground(lipgloss.Color("#9999ff")),
+		"INFO":  lipgloss.NewStyle().Foreground(successColor),
+		"WARN":  lipgloss.NewStyle().Foreground(warningColor),
+		"ERROR": lipgloss.NewStyle().Foreground(errorColor),
+	}
+	searchMatchStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#000000")).
+				Background(warningColor)
+	logsStatusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#999999"))
+)
+
+// logEntry представляет разобранную строку лога
+type logEntry struct {
+	Time  time.Time
+	Level string
+	Text  string // Отформатированная строка для вывода
+}
+
+// logView хранит состояние просмотра логов
+type logView struct {
+	minLevel int    // Индекс минимального уровня в logLevels
+	search   string // Строка поиска (без учета регистра)
+	follow   bool   // Автопрокрутка к новым записям
+	scroll   int    // Смещение от конца отфильтрованного списка
+}
+
+// parseLogLine разбирает строку JSON-лога zap
+func parseLogLine(line string) logEntry {
+	var zapLog map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &zapLog); err != nil {
+		// Не удалось распарсить JSON, добавляем как есть
+		return logEntry{Text: line}
+	}
+
+	// Получаем основные поля
+	level, _ := zapLog["level"].(string)
+	ts, _ := zapLog["ts"].(string)
+	msg, _ := zapLog["msg"].(string)
+
+	// Удаляем ANSI-цвета из уровня логирования
+	level = ansiRegex.ReplaceAllString(level, "")
+
+	entry := logEntry{Level: level}
+
+	// Форматируем сообщение
+	timestamp := ""
+	if t, err := time.Parse("02.01.2006 - 15:04:05.999999999Z07:00", ts); err == nil {
+		entry.Time = timezone.In(t)
+		timestamp = entry.Time.Format("15:04:05")
+	}
+
+	text := fmt.Sprintf("[%s] [%s] %s", timestamp, level, msg)
+
+	// Добавляем дополнительные поля, если они есть
+	for k, v := range zapLog {
+		if k != "level" && k != "ts" && k != "msg" && k != "caller" {
+			text += fmt.Sprintf(" (%s: %v)", k, v)
+		}
+	}
+	entry.Text = text
+
+	return entry
+}
+
+// levelRank возвращает важность уровня; неизвестные уровни (FATAL, PANIC) считаются ошибками
+func levelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	if level == "" {
+		return 0
+	}
+	return len(logLevels) - 1
+}
+
+// matches проверяет, проходит ли запись фильтры
+func (v *logView) matches(entry logEntry) bool {
+	if levelRank(entry.Level) < v.minLevel {
+		return false
+	}
+	if v.search != "" && !strings.Contains(strings.ToLower(entry.Text), strings.ToLower(v.search)) {
+		return false
+	}
+	return true
+}
+
+// filter возвращает записи, проходящие фильтры
+func (v *logView) filter(entries []logEntry) []logEntry {
+	if v.minLevel == 0 && v.search == "" {
+		return entries
+	}
+
+	filtered := make([]logEntry, 0, len(entries))
+	for _, entry := range entries {
+		if v.matches(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// cycleLevel переключает минимальный уровень по кругу
+func (v *logView) cycleLevel() {
+	v.minLevel = (v.minLevel + 1) % len(logLevels)
+	v.scroll = 0
+}
+
+// toggleFollow включает или выключает автопрокрутку
+func (v *logView) toggleFollow() {
+	v.follow = !v.follow
+	if v.follow {
+		v.scroll = 0
+	}
+}
+
+// appended корректирует прокрутку после добавления новых записей,
+// чтобы при выключенной автопрокрутке видимая область не сдвигалась
+func (v *logView) appended(added []logEntry) {
+	if v.follow {
+		v.scroll = 0
+		return
+	}
+	for _, entry := range added {
+		if v.matches(entry) {
+			v.scroll++
+		}
+	}
+}
+
+// jumpTo прокручивает логи к первой записи не раньше указанного времени (ЧЧ:ММ или ЧЧ:ММ:СС)
+func (v *logView) jumpTo(entries []logEntry, clock string, rows int) error {
+	target, err := time.Parse("15:04:05", clock)
+	if err != nil {
+		if target, err = time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("неверный формат времени %q, ожидается ЧЧ:ММ[:СС]", clock)
+		}
+	}
+
+	filtered := v.filter(entries)
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	// Время ищем в пределах дня последней записи с меткой времени
+	var last time.Time
+	for i := len(filtered) - 1; i >= 0 && last.IsZero(); i-- {
+		last = filtered[i].Time
+	}
+	if last.IsZero() {
+		return nil
+	}
+
+	want := time.Date(last.Year(), last.Month(), last.Day(),
+		target.Hour(), target.Minute(), target.Second(), 0, last.Location())
+
+	index := len(filtered) - 1
+	for i, entry := range filtered {
+		if !entry.Time.IsZero() && !entry.Time.Before(want) {
+			index = i
+			break
+		}
+	}
+
+	v.follow = false
+	v.scroll = max(0, len(filtered)-index-rows)
+	return nil
+}
+
+// renderLogsSection отображает отфильтрованные логи с учетом прокрутки
+func renderLogsSection(filtered []logEntry, view logView, tr *i18n.Translator, height int) string {
+	header := logsHeaderStyle.Render(tr.T("ui.logs")) + " " + logsStatusStyle.Render(logsStatus(view, tr))
+
+	// Количество строк, помещающихся в панель
+	maxLogsToShow := max(1, height-paneChrome)
+
+	end := max(0, len(filtered)-view.scroll)
+	start := max(0, end-maxLogsToShow)
+
+	lines := make([]string, 0, maxLogsToShow)
+	for _, entry := range filtered[start:end] {
+		style, ok := logLevelStyles[entry.Level]
+		if !ok {
+			style = lipgloss.NewStyle()
+		}
+		lines = append(lines, "  "+highlightMatches(entry.Text, view.search, style))
+	}
+
+	return logsSectionStyle.Height(height - 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			header,
+			strings.Join(lines, "\n"),
+		),
+	)
+}
+
+// logsStatus формирует строку состояния фильтров логов
+func logsStatus(view logView, tr *i18n.Translator) string {
+	status := []string{tr.T("ui.logs_level", logLevels[view.minLevel])}
+	if view.search != "" {
+		status = append(status, tr.T("ui.logs_search", view.search))
+	}
+	if !view.follow {
+		status = append(status, tr.T("ui.logs_follow_off"))
+	}
+	return strings.Join(status, " · ")
+}
+
+// highlightMatches выделяет вхождения строки поиска (без учета регистра)
+func highlightMatches(text, query string, style lipgloss.Style) string {
+	lowerText := strings.ToLower(text)
+	lowerQuery := strings.ToLower(query)
+
+	// Смещения совпадают только если регистр не меняет длину строки
+	if query == "" || len(lowerText) != len(text) || len(lowerQuery) != len(query) {
+		return style.Render(text)
+	}
+
+	var b strings.Builder
+	pos := 0
+	for {
+		i := strings.Index(lowerText[pos:], lowerQuery)
+		if i < 0 {
+			break
+		}
+		b.WriteString(style.Render(text[pos : pos+i]))
+		b.WriteString(searchMatchStyle.Render(text[pos+i : pos+i+len(query)]))
+		pos += i + len(query)
+	}
+	b.WriteString(style.Render(text[pos:]))
+
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры заметок
+const (
+	notesLoadLimit   = 1000 // Сколько последних заметок загружать при запуске
+	notesInHistory   = 3    // Сколько заметок показывать под графиком истории
+	notesSaveTimeout = 10 * time.Second
+)
+
+// notesMsg сообщает о загрузке заметок
+type notesMsg struct {
+	notes []*models.Note
+	err   error
+}
+
+// loadNotes загружает заметки всех символов в фоне
+func (ui *TermUI) loadNotes() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ui.ctx, historyLoadTimeout)
+		defer cancel()
+
+		notes, err := ui.analyzer.GetNotes(ctx, "", notesLoadLimit)
+		return notesMsg{notes: notes, err: err}
+	}
+}
+
+// setNotes заполняет кэш заметок; хранилище возвращает новые заметки первыми
+func (ui *TermUI) setNotes(notes []*models.Note) {
+	ui.notesMutex.Lock()
+	defer ui.notesMutex.Unlock()
+
+	ui.notes = make(map[string][]*models.Note)
+	for _, note := range notes {
+		ui.notes[note.Symbol] = append(ui.notes[note.Symbol], note)
+	}
+}
+
+// symbolNotes возвращает заметки символа, новые первыми
+func (ui *TermUI) symbolNotes(symbol string) []*models.Note {
+	ui.notesMutex.RLock()
+	defer ui.notesMutex.RUnlock()
+
+	return ui.notes[symbol]
+}
+
+// startNote открывает ввод заметки к выбранному символу или к его текущему сигналу
+func (ui *TermUI) startNote(toSignal bool) {
+	ui.signalsMutex.RLock()
+	symbols := ui.visibleSymbols()
+	ui.signalsMutex.RUnlock()
+
+	if ui.selectedIndex < 0 || ui.selectedIndex >= len(symbols) {
+		return
+	}
+
+	ui.noteTarget = models.Note{Symbol: symbols[ui.selectedIndex]}
+	ui.inputMode = "note"
+	if toSignal {
+		signal := ui.selectedSignal()
+		if signal == nil {
+			logger.Warn("Нет сигнала для заметки", zap.String("symbol", ui.noteTarget.Symbol))
+			ui.inputMode = ""
+			return
+		}
+		ui.noteTarget.SignalTime = signal.Timestamp
+	}
+	ui.input = ""
+}
+
+// saveNote сохраняет введенную заметку
+func (ui *TermUI) saveNote(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	note := ui.noteTarget
+	note.Text = text
+	note.Timestamp = time.Now()
+
+	ui.notesMutex.Lock()
+	if ui.notes == nil {
+		ui.notes = make(map[string][]*models.Note)
+	}
+	ui.notes[note.Symbol] = append([]*models.Note{&note}, ui.notes[note.Symbol]...)
+	ui.notesMutex.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ui.ctx, notesSaveTimeout)
+		defer cancel()
+
+		if err := ui.analyzer.SaveNote(ctx, &note); err != nil {
+			logger.Warn("Ошибка сохранения заметки", zap.String("symbol", note.Symbol), zap.Error(err))
+			return
+		}
+		logger.Info("Заметка сохранена", zap.String("symbol", note.Symbol))
+	}()
+}
+
+// notePrompt возвращает приглашение ввода заметки
+func (ui *TermUI) notePrompt() string {
+	if ui.noteTarget.SignalTime.IsZero() {
+		return ui.tr.T("ui.prompt_note", ui.noteTarget.Symbol, ui.input)
+	}
+	return ui.tr.T("ui.prompt_signal_note", ui.noteTarget.Symbol,
+		timezone.In(ui.noteTarget.SignalTime).Format("02.01 15:04:05"), ui.input)
+}
+
+// formatNote форматирует заметку для вывода
+func formatNote(note *models.Note, tr *i18n.Translator) string {
+	text := timezone.In(note.Timestamp).Format("02.01 15:04") + " "
+	if !note.SignalTime.IsZero() {
+		text += tr.T("ui.note_signal", timezone.In(note.SignalTime).Format("02.01 15:04:05")) + " "
+	}
+	return text + note.Text
+}
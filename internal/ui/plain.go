@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+)
+
+// Изменение силы сигнала, после которого строка символа выводится повторно
+const plainStrengthStep = 10
+
+// plainState хранит состояние текстового режима: вывод идет последовательными
+// строками без рамок и цвета, чтобы его могли читать программы экранного доступа
+type plainState struct {
+	mu      sync.Mutex
+	out     io.Writer
+	printed map[string]*models.SignalResult // Последние выведенные сигналы
+}
+
+// startPlain запускает текстовый режим и ждет завершения приложения
+func (ui *TermUI) startPlain() {
+	ui.plain.out = os.Stdout
+	ui.plain.printed = make(map[string]*models.SignalResult)
+
+	if ui.config.Headless {
+		<-ui.ctx.Done()
+		return
+	}
+	ui.printPlain(ui.tr.T("ui.title"))
+	ui.printPlain(ui.tr.T("ui.plain_started"))
+	<-ui.ctx.Done()
+}
+
+// printPlain выводит строку с отметкой времени. В режиме --headless stdout занят
+// записями JSON, поэтому текстовые строки не выводятся.
+func (ui *TermUI) printPlain(text string) {
+	ui.plain.mu.Lock()
+	defer ui.plain.mu.Unlock()
+
+	if ui.plain.out == nil || ui.config.Headless {
+		return
+	}
+	fmt.Fprintln(ui.plain.out, timezone.Now().Format("15:04:05")+" "+text)
+}
+
+// plainSignals выводит новые сигналы, а также смену рекомендации
+// и заметное изменение силы сигнала
+func (ui *TermUI) plainSignals(signals map[string]*models.SignalResult) {
+	for _, symbol := range getSymbolsFromSignals(signals) {
+		signal := signals[symbol]
+		if signal.RecommendationCode == "" {
+			continue
+		}
+
+		ui.plain.mu.Lock()
+		last, ok := ui.plain.printed[symbol]
+		changed := !ok || last.RecommendationCode != signal.RecommendationCode ||
+			math.Abs(last.SignalStrength-signal.SignalStrength) >= plainStrengthStep
+		if changed {
+			ui.plain.printed[symbol] = signal
+		}
+		ui.plain.mu.Unlock()
+
+		if changed {
+			ui.printPlain(ui.tr.T("ui.signal_line", symbol,
+				signal.RecommendationCode.Localize(ui.tr),
+				signal.SignalStrength, ui.formatPrice(symbol, signal.CurrentPrice)))
+		}
+	}
+}
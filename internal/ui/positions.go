@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Отношение позиции к текущему сигналу
+const (
+	relationAligned       = "aligned"
+	relationContradicting = "contradicting"
+	relationNeutral       = "neutral"
+)
+
+// PositionSource предоставляет открытые позиции счета
+type PositionSource interface {
+	Positions() []*models.Position
+}
+
+// positionsState хранит источник позиций и уже выданные предупреждения
+type positionsState struct {
+	source   PositionSource
+	warned   map[string]bool // Позиции (символ и сторона), против которых уже был сильный сигнал
+	warnedMu sync.Mutex
+}
+
+// SetPositionSource включает панель позиций
+func (ui *TermUI) SetPositionSource(source PositionSource) {
+	ui.positions.source = source
+	ui.positions.warned = make(map[string]bool)
+}
+
+// OnPositionsUpdate вызывается при изменении позиций на счете
+func (ui *TermUI) OnPositionsUpdate() {
+	ui.signalsMutex.RLock()
+	ui.checkPositionConflicts(ui.signals)
+	ui.signalsMutex.RUnlock()
+
+	ui.requestRefresh()
+}
+
+// positionRelation определяет, согласуется ли позиция с сигналом
+func positionRelation(position *models.Position, signal *models.SignalResult) string {
+	if signal == nil {
+		return relationNeutral
+	}
+
+	direction := signal.RecommendationCode.Side()
+	if direction == "" {
+		return relationNeutral
+	}
+
+	if position.Side == direction {
+		return relationAligned
+	}
+	return relationContradicting
+}
+
+// isStrongSignal проверяет, является ли сигнал сильным
+func isStrongSignal(signal *models.SignalResult) bool {
+	return signal != nil && signal.RecommendationCode.Strong()
+}
+
+// checkPositionConflicts создает оповещение, когда сильный сигнал направлен против открытой позиции.
+// Повторно оповещение выдается только после того, как конфликт пропал и появился снова.
+func (ui *TermUI) checkPositionConflicts(signals map[string]*models.SignalResult) {
+	if ui.positions.source == nil {
+		return
+	}
+
+	ui.positions.warnedMu.Lock()
+	defer ui.positions.warnedMu.Unlock()
+
+	conflicts := make(map[string]bool)
+	for _, position := range ui.positions.source.Positions() {
+		signal := signals[position.Symbol]
+		if !isStrongSignal(signal) || positionRelation(position, signal) != relationContradicting {
+			continue
+		}
+
+		key := position.Symbol + position.Side.String()
+		conflicts[key] = true
+		if !ui.positions.warned[key] {
+			ui.addAlert(events.AlertPositionConflict, position.Symbol, ui.tr.T("ui.alert_position_conflict",
+				position.Side.Localize(ui.tr),
+				signal.RecommendationCode.Localize(ui.tr)), true)
+		}
+	}
+	ui.positions.warned = conflicts
+}
+
+// renderPositionsSection отображает открытые позиции и их отношение к сигналам
+func renderPositionsSection(positions []*models.Position, signals map[string]*models.SignalResult, tr *i18n.Translator, height int) string {
+	header := alertsHeaderStyle.Render(tr.T("ui.positions", len(positions)))
+
+	rows := max(1, height-paneChrome)
+	lines := make([]string, 0, rows)
+	if len(positions) == 0 {
+		lines = append(lines, "  "+tr.T("ui.positions_empty"))
+	}
+
+	for _, p := range positions {
+		if len(lines) >= rows {
+			break
+		}
+
+		side := lipgloss.NewStyle().Foreground(successColor).Render(p.Side.Localize(tr))
+		if p.Side == models.PositionSideShort {
+			side = lipgloss.NewStyle().Foreground(errorColor).Render(p.Side.Localize(tr))
+		}
+
+		pnlStyle := lipgloss.NewStyle().Foreground(successColor)
+		if p.UnrealizedPnL.IsNegative() {
+			pnlStyle = lipgloss.NewStyle().Foreground(errorColor)
+		}
+
+		signal := signals[p.Symbol]
+		var marker string
+		switch positionRelation(p, signal) {
+		case relationAligned:
+			marker = lipgloss.NewStyle().Foreground(successColor).Render("✓")
+		case relationContradicting:
+			marker = lipgloss.NewStyle().Foreground(warningColor).Render("✗")
+			if isStrongSignal(signal) {
+				marker = lipgloss.NewStyle().Foreground(errorColor).Bold(true).Render("⚠")
+			}
+		default:
+			marker = alertAckedStyle.Render("·")
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %-9s %s %s @ %s x%d %s",
+			marker, p.Symbol, side, p.Amount, p.EntryPrice.StringFixed(2), p.Leverage,
+			pnlStyle.Render(fmt.Sprintf("%+.2f", p.UnrealizedPnL.InexactFloat64()))))
+	}
+
+	return alertsSectionStyle.Height(height - 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			header,
+			strings.Join(lines, "\n"),
+		),
+	)
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/i18n"
+)
+
+// Стили строки состояния по уровню серьезности
+var severityStyles = map[health.Severity]lipgloss.Style{
+	health.SeverityOK:    lipgloss.NewStyle().Foreground(successColor),
+	health.SeverityWarn:  lipgloss.NewStyle().Foreground(warningColor),
+	health.SeverityError: lipgloss.NewStyle().Foreground(errorColor).Bold(true),
+}
+
+// renderStatusBar отображает состояние потоков данных, очереди записи, цикла анализа
+// и подсистемы в режиме деградации
+func renderStatusBar(snapshot health.Snapshot, tr *i18n.Translator, width int) string {
+	now := time.Now()
+	parts := make([]string, 0, len(snapshot.Streams)+2)
+
+	if len(snapshot.Streams) == 0 {
+		parts = append(parts, severityStyles[health.SeverityWarn].Render(tr.T("ui.status_waiting")))
+	}
+
+	for _, s := range snapshot.Streams {
+		var state string
+		switch {
+		case s.WebSocket && s.Connections == 0:
+			state = tr.T("ui.status_disconnected")
+		case s.LastData.IsZero():
+			state = tr.T("ui.status_no_data")
+		default:
+			state = formatAge(now.Sub(s.LastData))
+		}
+		if s.Errors > 0 {
+			state += " " + tr.T("ui.status_errors", s.Errors)
+		}
+
+		parts = append(parts, severityStyles[s.Severity(now)].Render(
+			"● "+tr.T("stream."+s.Name)+" "+state))
+	}
+
+	queue := tr.T("ui.status_queue", snapshot.QueueDepth)
+	if snapshot.WriteErrors > 0 {
+		queue += " " + tr.T("ui.status_errors", snapshot.WriteErrors)
+	}
+	parts = append(parts, severityStyles[snapshot.QueueSeverity()].Render(queue))
+
+	analysis := tr.T("ui.status_analysis_pending")
+	if !snapshot.LastAnalysis.IsZero() {
+		analysis = tr.T("ui.status_analysis", snapshot.AnalysisDuration.Round(time.Millisecond), formatAge(now.Sub(snapshot.LastAnalysis)))
+	}
+	parts = append(parts, severityStyles[snapshot.AnalysisSeverity()].Render(analysis))
+
+	if len(snapshot.Degraded) > 0 {
+		names := make([]string, 0, len(snapshot.Degraded))
+		for _, d := range snapshot.Degraded {
+			names = append(names, tr.T("subsystem."+d.Subsystem))
+		}
+		parts = append(parts, severityStyles[health.SeverityError].Render(tr.T("ui.status_degraded", strings.Join(names, ", "))))
+	}
+
+	return footerStyle.Width(width).Render(strings.Join(parts, " · "))
+}
+
+// formatAge форматирует возраст данных с точностью до секунды
+func formatAge(age time.Duration) string {
+	if age < time.Second {
+		return "<1s"
+	}
+	return age.Round(time.Second).String()
+}
@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"strconv"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// SymbolSource предоставляет параметры символов биржи
+type SymbolSource interface {
+	Info(symbol string) (*models.SymbolInfo, bool)
+}
+
+// SetSymbolSource задает параметры символов: цены выводятся с точностью символа
+func (ui *TermUI) SetSymbolSource(source SymbolSource) {
+	ui.symbols = source
+}
+
+// formatPrice форматирует цену с точностью символа; без параметров символа - с двумя
+// знаками после запятой
+func (ui *TermUI) formatPrice(symbol string, price float64) string {
+	if ui.symbols != nil {
+		if info, ok := ui.symbols.Info(symbol); ok {
+			return info.FormatPrice(price)
+		}
+	}
+	return strconv.FormatFloat(price, 'f', 2, 64)
+}
@@ -2,22 +2,33 @@ package ui
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/skalibog/bfma/pkg/logger"
 	"go.uber.org/zap"
+	"io"
+	"maps"
+	"math"
 	"os"
-	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/skalibog/bfma/internal/analysis/aggregator"
 	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/internal/state"
+	"github.com/skalibog/bfma/internal/version"
+	"github.com/skalibog/bfma/pkg/events"
 	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
 )
 
 // Стили UI
@@ -60,30 +71,134 @@ var (
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(secondaryColor).
 				Padding(0, 1)
+	// Метка приостановленного символа
+	pausedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ffffff")).
+			Background(lipgloss.Color("#885500")).
+			Padding(0, 1)
+	mutedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ffffff")).
+			Background(lipgloss.Color("#555555")).
+			Padding(0, 1)
+	// Метка ручной поправки сигнала
+	overrideStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ffffff")).
+			Background(lipgloss.Color("#6a3d9a")).
+			Padding(0, 1)
 	// Футер - будет адаптироваться к размеру экрана
 	footerStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#999999")).
 			Padding(0, 1)
 )
 
+// Параметры раскладки экрана
+const (
+	maxLogLines       = 5000 // Сколько строк логов держать в памяти
+	minPaneHeight     = 5    // Минимальная высота панели (с рамкой и заголовком)
+	layoutOverhead    = 7    // Рамка и отступы приложения, заголовок, разделители (без футера)
+	appChromeWidth    = 6    // Рамка и горизонтальные отступы приложения
+	panesTop          = 4    // Строка экрана, с которой начинается панель сигналов
+	appPaddingLeft    = 3    // Столбец экрана, с которого начинаются панели
+	paneChrome        = 3    // Рамка и заголовок панели
+	defaultSplitRatio = 0.5
+	// Период перерисовки экрана, если refresh_rate не задан
+	defaultRefreshRate = 500 * time.Millisecond
+)
+
 // TermUI представляет терминальный интерфейс
 type TermUI struct {
 	analyzer      *aggregator.Analyzer
 	signals       map[string]*models.SignalResult
 	signalsMutex  sync.RWMutex
-	logs          []string
+	logs          []logEntry
+	logsTotal     int   // Сколько строк прочитано из файла логов
+	logOffset     int64 // Позиция в файле логов после последней прочитанной строки
 	logsMutex     sync.RWMutex
 	config        config.UIConfig
+	tr            *i18n.Translator
 	program       *tea.Program
 	selectedIndex int
+	signalsOffset int // Первая видимая строка панели сигналов
+	logView       logView
+	alerts        []alert
+	alertsMutex   sync.Mutex
+	flashUntil    time.Time    // До какого момента подсвечивать панель оповещений
+	signalsWidth  int          // Ширина панели сигналов при последней отрисовке
+	footerHeight  int          // Высота футера при последней отрисовке
+	history       *historyView // Открытый график истории сигналов (nil - таблица сигналов)
+	positions     positionsState
+	plain         plainState
+	funding       FundingSource
+	symbols       SymbolSource
+	executor      TradeExecutor
+	keymap        *keymap
+	keysHelp      string                    // Описание клавиш для футера
+	ticket        *ticketView               // Открытый тикет заявки
+	grid          bool                      // Обзор символов цветной сеткой вместо таблицы
+	gridColumns   int                       // Столбцов сетки при последней отрисовке
+	gridOffset    int                       // Первая видимая строка сетки
+	notes         map[string][]*models.Note // Заметки по символам, новые первыми
+	notesMutex    sync.RWMutex
+	noteTarget    models.Note // К чему относится вводимая заметка
+	watchlist     int         // Активный список наблюдения: 0 - все символы, иначе номер в config.Watchlists
+	trackSymbol   func(symbol string, track bool) error
+	bus           *events.Bus               // Передача оповещений во внешние каналы
+	alertFilter   func(channel string) bool // Подается ли оповещение в канал терминала (тихие часы)
+	mutes         MuteList                  // Символы с отключенными оповещениями
+	dirty         atomic.Bool               // Данные изменились с момента последней перерисовки
+	historyStale  atomic.Bool               // Появились сигналы, которых нет на открытом графике истории
+	refreshRate   atomic.Int64              // Период перерисовки в наносекундах
+	schemaVersion atomic.Int32              // Версия схемы JSON сигналов (0 - последняя)
+	signalRows    map[string]signalRow      // Кэш отрисованных строк сигналов
+	filteredLogs  []logEntry                // Кэш отфильтрованных логов
+	filteredKey   filteredLogsKey           // От чего зависит кэш отфильтрованных логов
+	cacheMutex    sync.Mutex                // Кэши отрисовки; берется после signalsMutex и logsMutex
+	ctx           context.Context
+	inputMode     string // Режим ввода: "" (нет), "search", "time", "symbol" или "note"
+	input         string
+	splitRatio    float64
+	dragging      bool      // Пользователь тянет разделитель панелей
+	uiState       *state.UI // Размер панелей и списки наблюдения, измененные в интерфейсе; nil - не сохраняются
 	width         int
 	height        int
 	logFile       string // Путь к файлу логов
 }
 
+// signalRowKey описывает все, от чего зависит отрисовка строки сигнала
+type signalRowKey struct {
+	code           models.Recommendation
+	recommendation string
+	strength       float64
+	price          float64
+	funding        string
+	noted          bool
+	selected       bool
+	paused         bool
+	muted          bool
+	override       string
+}
+
+// signalRow - отрисованная строка сигнала
+type signalRow struct {
+	key  signalRowKey
+	line string
+}
+
+// filteredLogsKey описывает состояние логов и фильтров, для которого построен кэш
+type filteredLogsKey struct {
+	total    int
+	count    int
+	minLevel int
+	search   string
+}
+
 // Сообщения для обновления UI
 type refreshMsg struct{}
 type windowSizeMsg tea.WindowSizeMsg
+type configMsg config.UIConfig
+
+// showSymbolMsg - открыть график истории символа (из уведомления рабочего стола)
+type showSymbolMsg string
 
 // bubbleModel - модель для bubbletea
 type bubbleModel struct {
@@ -91,20 +206,47 @@ type bubbleModel struct {
 }
 
 func NewTermUI(cfg config.UIConfig, analyzer *aggregator.Analyzer, ctx context.Context) (*TermUI, error) {
+	tr, err := i18n.New(cfg.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации локализации: %w", err)
+	}
+
+	keys, err := newKeymap(cfg.Keymap)
+	if err != nil {
+		return nil, err
+	}
+
 	ui := &TermUI{
 		analyzer:      analyzer,
 		signals:       make(map[string]*models.SignalResult),
-		logs:          []string{"BFMA запущен. Ожидание данных..."},
+		signalRows:    make(map[string]signalRow),
+		logs:          []logEntry{{Text: tr.T("ui.started")}},
+		logView:       logView{follow: true},
 		config:        cfg,
+		tr:            tr,
+		keymap:        keys,
+		keysHelp:      keys.help(func(action string) string { return tr.T("action." + action) }),
+		ctx:           ctx,
 		selectedIndex: 0,
+		splitRatio:    cfg.SplitRatio,
 		width:         120,
 		height:        40,
-		logFile:       "app.json.log", // Путь к файлу логов по умолчанию
+		logFile:       logger.JSONFile(),
+	}
+
+	if ui.splitRatio <= 0 || ui.splitRatio >= 1 {
+		ui.splitRatio = defaultSplitRatio
+	}
+	ui.refreshRate.Store(int64(ui.refreshInterval()))
+
+	// Приостановленные символы показываем сразу, чтобы их можно было возобновить
+	for _, symbol := range analyzer.PausedSymbols() {
+		ui.signals[symbol] = &models.SignalResult{Symbol: symbol}
 	}
 
 	// Загружаем логи из файла при запуске
 	if err := ui.loadLogsFromFile(); err != nil {
-		ui.logs = append(ui.logs, fmt.Sprintf("Ошибка загрузки логов: %v", err))
+		ui.logs = append(ui.logs, logEntry{Level: "ERROR", Text: tr.T("ui.logs_load_error", err)})
 	}
 
 	// Запускаем таймер для обновления логов
@@ -129,26 +271,181 @@ func NewTermUI(cfg config.UIConfig, analyzer *aggregator.Analyzer, ctx context.C
 }
 
 func (ui *TermUI) Start() {
+	if ui.config.Plain {
+		ui.startPlain()
+		return
+	}
+
 	model := bubbleModel{ui: ui}
-	ui.program = tea.NewProgram(model, tea.WithAltScreen())
+	ui.program = tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
+
+	// Изменения данных копятся и выводятся не чаще refresh_rate,
+	// чтобы частые обновления сигналов не вызывали мерцание
+	go func() {
+		interval := time.Duration(ui.refreshRate.Load())
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// Возраст данных в строке состояния и отсчет до финансирования меняются каждую
+		// секунду; новые данные перерисовывают экран по событиям шины (SetEventBus)
+		statusTicker := time.NewTicker(time.Second)
+		defer statusTicker.Stop()
+
+		for {
+			select {
+			case <-statusTicker.C:
+				ui.requestRefresh()
+			case <-ticker.C:
+				if ui.dirty.Swap(false) {
+					ui.program.Send(refreshMsg{})
+				}
+				// Период мог измениться при перезагрузке конфигурации
+				if d := time.Duration(ui.refreshRate.Load()); d != interval {
+					interval = d
+					ticker.Reset(d)
+				}
+			case <-ui.ctx.Done():
+				// Завершение приложения закрывает интерфейс
+				ui.program.Quit()
+				return
+			}
+		}
+	}()
 
 	// Запускаем UI
 	if err := ui.program.Start(); err != nil {
-		fmt.Printf("Ошибка запуска UI: %v\n", err)
+		fmt.Println(ui.tr.T("ui.start_error", err))
+	}
+}
+
+// refreshInterval возвращает период перерисовки экрана
+func (ui *TermUI) refreshInterval() time.Duration {
+	if ui.config.RefreshRate <= 0 {
+		return defaultRefreshRate
+	}
+	return ui.config.RefreshRate.Std()
+}
+
+// requestRefresh помечает экран как требующий перерисовки
+func (ui *TermUI) requestRefresh() {
+	ui.dirty.Store(true)
+}
+
+// SetStateStore задает файл состояния, в котором сохраняются размер панелей и списки
+// наблюдения, измененные в интерфейсе
+func (ui *TermUI) SetStateStore(store *state.UI) {
+	ui.uiState = store
+}
+
+// SetSchemaVersion задает версию схемы JSON для копируемых сигналов (0 - последняя)
+func (ui *TermUI) SetSchemaVersion(version int) {
+	ui.schemaVersion.Store(int32(version))
+}
+
+// ApplyConfig применяет перезагруженные настройки UI без перезапуска
+func (ui *TermUI) ApplyConfig(cfg config.UIConfig) {
+	if ui.program != nil {
+		ui.program.Send(configMsg(cfg))
+		return
+	}
+
+	// В текстовом режиме нет цикла bubbletea, настройки читаются при выводе сигналов
+	ui.signalsMutex.Lock()
+	defer ui.signalsMutex.Unlock()
+	if err := ui.applyConfig(cfg); err != nil {
+		logger.Error("Ошибка применения настроек UI", zap.Error(err))
 	}
 }
 
+// applyConfig заменяет настройки UI; при ошибке в локали или клавишах прежние настройки сохраняются
+func (ui *TermUI) applyConfig(cfg config.UIConfig) error {
+	tr, err := i18n.New(cfg.Locale)
+	if err != nil {
+		return fmt.Errorf("ошибка инициализации локализации: %w", err)
+	}
+	keys, err := newKeymap(cfg.Keymap)
+	if err != nil {
+		return err
+	}
+
+	cfg.Plain = ui.config.Plain
+	cfg.Headless = ui.config.Headless
+	ui.config = cfg
+	ui.tr = tr
+	ui.keymap = keys
+	ui.keysHelp = keys.help(func(action string) string { return tr.T("action." + action) })
+	if cfg.SplitRatio > 0 && cfg.SplitRatio < 1 {
+		ui.splitRatio = cfg.SplitRatio
+	}
+	if ui.watchlist > len(cfg.Watchlists) {
+		ui.watchlist = 0
+	}
+	ui.refreshRate.Store(int64(ui.refreshInterval()))
+
+	// Строки сигналов отрисованы на прежнем языке
+	ui.cacheMutex.Lock()
+	ui.signalRows = make(map[string]signalRow)
+	ui.cacheMutex.Unlock()
+	ui.requestRefresh()
+	logger.Info("Настройки UI обновлены")
+	return nil
+}
+
+// NotifyRestartRequired сообщает в панели оповещений об изменениях, требующих перезапуска
+func (ui *TermUI) NotifyRestartRequired(sections []string) {
+	ui.addAlert(events.AlertConfig, "config", ui.tr.T("ui.alert_restart_required", strings.Join(sections, ", ")), true)
+}
+
 func (ui *TermUI) UpdateSignals(signals map[string]*models.SignalResult) {
 	ui.signalsMutex.Lock()
 	defer ui.signalsMutex.Unlock()
 
-	ui.signals = signals
+	// Карту сигналов цикла читают и другие подписчики шины событий
+	signals = maps.Clone(signals)
 
-	if ui.program != nil {
-		ui.program.Send(refreshMsg{})
+	// Для приостановленных символов сохраняем последний известный сигнал
+	for symbol, signal := range ui.signals {
+		if _, ok := signals[symbol]; !ok && ui.analyzer.IsPaused(symbol) {
+			signals[symbol] = signal
+		}
+	}
+
+	// В текстовом режиме смена рекомендации видна в строке сигнала
+	switch {
+	case ui.config.Headless:
+		ui.headlessSignals(signals)
+	case ui.config.Plain:
+		ui.plainSignals(signals)
+	default:
+		ui.detectSignalChanges(ui.signals, signals)
 	}
+	ui.checkPositionConflicts(signals)
+	ui.signals = signals
+	ui.historyStale.Store(true)
+	ui.requestRefresh()
+}
+
+// OpenHistory открывает при запуске график истории сигналов символа вместо таблицы
+func (ui *TermUI) OpenHistory(symbol string) {
+	ui.history = &historyView{symbol: symbol, loading: true}
+}
+
+// RestoreSignals показывает сигналы, сохраненные до перезапуска. Оповещения не
+// создаются: смена рекомендации определяется уже относительно этих сигналов.
+func (ui *TermUI) RestoreSignals(signals map[string]*models.SignalResult) {
+	ui.signalsMutex.Lock()
+	defer ui.signalsMutex.Unlock()
+
+	ui.signals = signals
+	ui.requestRefresh()
 }
 
+// Сколько байт с конца файла логов читать при запуске
+const logTailBytes = 4 << 20
+
+// loadLogsFromFile дочитывает новые строки файла логов. При первом чтении берется
+// только конец файла (logTailBytes); если файл стал меньше прочитанного (ротация),
+// новый файл читается с начала.
 func (ui *TermUI) loadLogsFromFile() error {
 	file, err := os.Open(ui.logFile)
 	if err != nil {
@@ -160,145 +457,428 @@ func (ui *TermUI) loadLogsFromFile() error {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	var logs []string
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	ui.logsMutex.Lock()
+	defer ui.logsMutex.Unlock()
+
+	offset, partial := ui.logOffset, false
+	if info.Size() < offset {
+		offset = 0
+	}
+	if ui.logsTotal == 0 && info.Size()-offset > logTailBytes {
+		offset, partial = info.Size()-logTailBytes, true
+	}
+	if offset == info.Size() {
+		return nil
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
 
-	// Регулярное выражение для удаления ANSI-цветов
-	ansiRegex := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// Незаконченная последняя строка дочитывается в следующий раз
+	end := bytes.LastIndexByte(data, '\n') + 1
+	ui.logOffset = offset + int64(end)
+	data = data[:end]
+	if partial {
+		// Чтение началось с середины строки
+		data = data[bytes.IndexByte(data, '\n')+1:]
+	}
 
-	// Читаем строки из файла
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var added []logEntry
 	for scanner.Scan() {
-		line := scanner.Text()
+		added = append(added, parseLogLine(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		return nil
+	}
 
-		// Пытаемся распарсить JSON
-		var zapLog map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &zapLog); err == nil {
-			// Успешно распарсили JSON
+	// Ограничиваем количество логов
+	ui.logs = append(ui.logs, added...)
+	if len(ui.logs) > maxLogLines {
+		ui.logs = slices.Clone(ui.logs[len(ui.logs)-maxLogLines:])
+	}
+	if len(added) > maxLogLines {
+		added = added[len(added)-maxLogLines:]
+	}
+	// Новые строки нужны, чтобы удержать позицию при выключенной автопрокрутке
+	ui.logView.appended(added)
+	ui.logsTotal += len(added)
+	ui.requestRefresh()
+	return nil
+}
 
-			// Получаем основные поля
-			level, _ := zapLog["level"].(string)
-			ts, _ := zapLog["ts"].(string)
-			msg, _ := zapLog["msg"].(string)
+// Методы для bubbletea
+func (m bubbleModel) Init() tea.Cmd {
+	if m.ui.history != nil {
+		return tea.Batch(m.ui.loadNotes(), m.ui.loadHistory(m.ui.history.symbol))
+	}
+	return m.ui.loadNotes()
+}
 
-			// Удаляем ANSI-цвета из уровня логирования
-			level = ansiRegex.ReplaceAllString(level, "")
+func (m bubbleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 
-			// Форматируем сообщение
-			timestamp := ""
-			if t, err := time.Parse("02.01.2006 - 15:04:05.999999999Z07:00", ts); err == nil {
-				timestamp = t.Format("15:04:05")
-			}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.ui.inputMode != "" {
+			m.ui.handleInput(msg)
+			return m, nil
+		}
+		if m.ui.ticket != nil {
+			return m, m.ui.handleTicketKey(msg)
+		}
 
-			formattedMsg := fmt.Sprintf("[%s] [%s] %s", timestamp, level, msg)
+		// Ctrl+C завершает работу при любой раскладке
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
 
-			// Добавляем дополнительные поля, если они есть
-			for k, v := range zapLog {
-				if k != "level" && k != "ts" && k != "msg" && k != "caller" {
-					formattedMsg += fmt.Sprintf(" (%s: %v)", k, v)
-				}
+		switch m.ui.keymap.resolve(msg.String()) {
+		case actionQuit:
+			return m, tea.Quit
+		case actionUp:
+			if m.ui.grid {
+				m.ui.moveGridSelection(-m.ui.gridColumns)
+				break
+			}
+			m.ui.selectedIndex = max(0, m.ui.selectedIndex-1)
+		case actionDown:
+			if m.ui.grid {
+				m.ui.moveGridSelection(m.ui.gridColumns)
+				break
+			}
+			m.ui.signalsMutex.RLock()
+			symbols := m.ui.visibleSymbols()
+			m.ui.signalsMutex.RUnlock()
+			m.ui.selectedIndex = max(0, min(len(symbols)-1, m.ui.selectedIndex+1))
+		case actionTop:
+			m.ui.selectedIndex = 0
+		case actionBottom:
+			m.ui.signalsMutex.RLock()
+			symbols := m.ui.visibleSymbols()
+			m.ui.signalsMutex.RUnlock()
+			m.ui.selectedIndex = max(0, len(symbols)-1)
+		case actionLeft:
+			if m.ui.grid {
+				m.ui.moveGridSelection(-1)
 			}
+		case actionRight:
+			if m.ui.grid {
+				m.ui.moveGridSelection(1)
+			}
+		case actionGrid:
+			m.ui.toggleGrid()
+		case actionNote:
+			m.ui.startNote(false)
+		case actionSignalNote:
+			m.ui.startNote(true)
+		case actionReloadLogs:
+			if err := m.ui.loadLogsFromFile(); err != nil {
+				logger.Warn("Ошибка загрузки логов", zap.Error(err))
+			}
+		case actionPause:
+			m.ui.togglePauseSelected()
+		case actionMute:
+			m.ui.toggleMuteSelected()
+		case actionAckAlerts:
+			m.ui.AcknowledgeAlerts()
+		case actionTicket:
+			cmd = m.ui.openTicket()
+		case actionCopy:
+			m.ui.copySelectedSignal()
+		case actionExport:
+			m.ui.exportSignalsCSV()
+		case actionWatchlist:
+			m.ui.cycleWatchlist()
+		case actionSort:
+			m.ui.cycleSort()
+		case actionAddSymbol:
+			m.ui.inputMode, m.ui.input = "symbol", ""
+		case actionRemoveSymbol:
+			m.ui.removeSelectedFromWatchlist()
+		case actionHistory:
+			cmd = m.ui.toggleHistory()
+		case actionLogLevel:
+			m.ui.logsMutex.Lock()
+			m.ui.logView.cycleLevel()
+			m.ui.logsMutex.Unlock()
+		case actionFollow:
+			m.ui.logsMutex.Lock()
+			m.ui.logView.toggleFollow()
+			m.ui.logsMutex.Unlock()
+		case actionSearch:
+			m.ui.inputMode, m.ui.input = "search", ""
+		case actionJumpTime:
+			m.ui.inputMode, m.ui.input = "time", ""
+		case actionClearSearch:
+			m.ui.logsMutex.Lock()
+			m.ui.logView.search = ""
+			m.ui.logsMutex.Unlock()
+		}
+
+	case tea.MouseMsg:
+		m.ui.handleMouse(msg)
+
+	case tea.WindowSizeMsg:
+		m.ui.width = msg.Width
+		m.ui.height = msg.Height
 
-			logs = append(logs, formattedMsg)
+	case historyMsg:
+		if m.ui.history != nil && m.ui.history.symbol == msg.symbol {
+			m.ui.history.signals = msg.signals
+			m.ui.history.err = msg.err
+			m.ui.history.loading = false
+		}
+
+	case notesMsg:
+		if msg.err != nil {
+			logger.Warn("Ошибка загрузки заметок", zap.Error(msg.err))
 		} else {
-			// Не удалось распарсить JSON, добавляем как есть
-			logs = append(logs, line)
+			m.ui.setNotes(msg.notes)
 		}
 
-		// Ограничиваем количество логов
-		if len(logs) > 50 {
-			logs = logs[1:] // Удаляем самую старую запись
+	case ticketMsg:
+		if m.ui.ticket != nil && m.ui.ticket.symbol == msg.symbol {
+			m.ui.ticket.ticket = msg.ticket
+			m.ui.ticket.err = msg.err
+			m.ui.ticket.loading = false
 		}
-	}
 
-	if err := scanner.Err(); err != nil {
-		return err
-	}
+	case ticketResultMsg:
+		if m.ui.ticket != nil {
+			m.ui.ticket.sending = false
+			m.ui.ticket.confirm = false
+			m.ui.ticket.err = msg.err
+			m.ui.ticket.sent = msg.err == nil
+			if m.ui.ticket.sent {
+				m.ui.AddAlert(m.ui.ticket.symbol, m.ui.tr.T("ui.ticket_sent"), false)
+			}
+		}
 
-	// Добавляем загруженные логи
-	ui.logsMutex.Lock()
-	defer ui.logsMutex.Unlock()
+	case showSymbolMsg:
+		cmd = m.ui.showSymbol(string(msg))
+
+	case configMsg:
+		if err := m.ui.applyConfig(config.UIConfig(msg)); err != nil {
+			logger.Error("Ошибка применения настроек UI", zap.Error(err))
+		}
 
-	if len(logs) > 0 {
-		ui.logs = logs
-		// Ограничиваем количество логов
-		if len(ui.logs) > 50 {
-			ui.logs = ui.logs[len(ui.logs)-50:]
+	case refreshMsg:
+		// Открытый график истории дополняется новыми сигналами
+		if m.ui.history != nil && !m.ui.history.loading && m.ui.historyStale.Swap(false) {
+			cmd = m.ui.loadHistory(m.ui.history.symbol)
 		}
 	}
 
-	return nil
+	return m, cmd
+}
+
+// ShowSymbol выбирает символ в таблице и открывает его график истории. Безопасно
+// вызывать из других горутин; в текстовом режиме ничего не делает.
+func (ui *TermUI) ShowSymbol(symbol string) {
+	if ui.program != nil {
+		ui.program.Send(showSymbolMsg(symbol))
+	}
 }
 
-func renderLogsSection(logs []string) string {
-	header := logsHeaderStyle.Render("ЛОГИ")
-	content := strings.Builder{}
+// showSymbol выбирает символ, если он виден в текущем списке, и открывает его график
+func (ui *TermUI) showSymbol(symbol string) tea.Cmd {
+	ui.signalsMutex.RLock()
+	symbols := ui.visibleSymbols()
+	ui.signalsMutex.RUnlock()
+
+	if i := slices.Index(symbols, symbol); i >= 0 {
+		ui.selectedIndex = i
+	}
+	ui.history = &historyView{symbol: symbol, loading: true}
+	return ui.loadHistory(symbol)
+}
 
-	// Показываем последние 6 логов (или больше, если размер экрана позволяет)
-	maxLogsToShow := 50
-	if logsSectionStyle.GetHeight() > 8 {
-		maxLogsToShow = logsSectionStyle.GetHeight() - 2
+// toggleHistory открывает график истории выбранного символа или закрывает его
+func (ui *TermUI) toggleHistory() tea.Cmd {
+	if ui.history != nil {
+		ui.history = nil
+		return nil
 	}
 
-	start := 0
-	if len(logs) > maxLogsToShow {
-		start = len(logs) - maxLogsToShow
+	ui.signalsMutex.RLock()
+	symbols := ui.visibleSymbols()
+	ui.signalsMutex.RUnlock()
+
+	if ui.selectedIndex < 0 || ui.selectedIndex >= len(symbols) {
+		return nil
 	}
 
-	for i := start; i < len(logs); i++ {
-		// Форматируем лог
-		log := logs[i]
+	symbol := symbols[ui.selectedIndex]
+	ui.history = &historyView{symbol: symbol, loading: true}
+	return ui.loadHistory(symbol)
+}
+
+// handleInput обрабатывает ввод строки поиска или времени перехода
+func (ui *TermUI) handleInput(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		ui.inputMode, ui.input = "", ""
+	case tea.KeyEnter:
+		switch ui.inputMode {
+		case "symbol":
+			ui.addToWatchlist(ui.input)
+			ui.inputMode, ui.input = "", ""
+			return
+		case "note":
+			ui.saveNote(ui.input)
+			ui.inputMode, ui.input = "", ""
+			return
+		}
 
-		// Выделение по уровню логирования
-		if strings.Contains(log, "[ERROR]") {
-			log = lipgloss.NewStyle().Foreground(errorColor).Render(log)
-		} else if strings.Contains(log, "[INFO]") {
-			log = lipgloss.NewStyle().Foreground(successColor).Render(log)
-		} else if strings.Contains(log, "[WARN]") {
-			log = lipgloss.NewStyle().Foreground(warningColor).Render(log)
-		} else if strings.Contains(log, "[DEBUG]") {
-			log = lipgloss.NewStyle().Foreground(lipgloss.Color("#9999ff")).Render(log)
+		ui.logsMutex.Lock()
+		switch ui.inputMode {
+		case "search":
+			ui.logView.search = ui.input
+			ui.logView.scroll = 0
+		case "time":
+			_, logsHeight := ui.paneHeights()
+			if err := ui.logView.jumpTo(ui.logs, strings.TrimSpace(ui.input), logsHeight-paneChrome); err != nil {
+				ui.logs = append(ui.logs, logEntry{Level: "WARN", Text: err.Error()})
+			}
 		}
+		ui.logsMutex.Unlock()
+		ui.inputMode, ui.input = "", ""
+	case tea.KeyBackspace:
+		if runes := []rune(ui.input); len(runes) > 0 {
+			ui.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		ui.input += string(msg.Runes)
+	}
+}
+
+// togglePauseSelected приостанавливает или возобновляет анализ выбранного символа
+func (ui *TermUI) togglePauseSelected() {
+	ui.signalsMutex.RLock()
+	symbols := ui.visibleSymbols()
+	ui.signalsMutex.RUnlock()
 
-		content.WriteString("  " + log + "\n")
+	if ui.selectedIndex < 0 || ui.selectedIndex >= len(symbols) {
+		return
 	}
 
-	return logsSectionStyle.Render(
-		lipgloss.JoinVertical(lipgloss.Left,
-			header,
-			content.String(),
-		),
-	)
+	if _, err := ui.analyzer.TogglePause(symbols[ui.selectedIndex]); err != nil {
+		logger.Warn("Ошибка переключения паузы символа",
+			zap.String("symbol", symbols[ui.selectedIndex]), zap.Error(err))
+	}
 }
 
-// Методы для bubbletea
-func (m bubbleModel) Init() tea.Cmd {
-	return nil
+// paneHeights возвращает высоты панелей сигналов и логов с учетом размера экрана
+func (ui *TermUI) paneHeights() (int, int) {
+	available := max(2*minPaneHeight, ui.height-layoutOverhead-max(1, ui.footerHeight))
+	signalsHeight := int(float64(available) * ui.splitRatio)
+	signalsHeight = max(minPaneHeight, min(available-minPaneHeight, signalsHeight))
+	return signalsHeight, available - signalsHeight
 }
 
-func (m bubbleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return m, tea.Quit
-		case "up":
-			m.ui.selectedIndex = max(0, m.ui.selectedIndex-1)
-		case "down":
-			symbols := getSymbolsFromSignals(m.ui.signals)
-			m.ui.selectedIndex = min(len(symbols)-1, m.ui.selectedIndex+1)
-		case "r": // Добавлена клавиша для перезагрузки логов из файла
+// handleMouse обрабатывает клики, прокрутку и перетаскивание разделителя
+func (ui *TermUI) handleMouse(msg tea.MouseMsg) {
+	signalsHeight, logsHeight := ui.paneHeights()
+	splitterY := panesTop + signalsHeight // Верхняя рамка панели логов
+	signalsRowsTop := panesTop + paneChrome - 1
 
+	switch msg.Action {
+	case tea.MouseActionPress:
+		switch msg.Button {
+		case tea.MouseButtonLeft:
+			// Разделителем считаются смежные рамки панелей
+			if msg.Y == splitterY || msg.Y == splitterY-1 {
+				ui.dragging = true
+				return
+			}
+			// Клик по строке сигнала выбирает символ
+			row := msg.Y - signalsRowsTop
+			if row >= 0 && row < signalsHeight-paneChrome && msg.X < appPaddingLeft+ui.signalsWidth {
+				ui.signalsMutex.RLock()
+				count := len(ui.visibleSymbols())
+				ui.signalsMutex.RUnlock()
+				if index := ui.signalsOffset + row; index < count {
+					ui.selectedIndex = index
+				}
+			}
+		case tea.MouseButtonWheelUp:
+			if msg.Y >= splitterY {
+				ui.logsMutex.Lock()
+				maxScroll := max(0, len(ui.visibleLogs())-(logsHeight-paneChrome))
+				ui.logView.scroll = min(maxScroll, ui.logView.scroll+1)
+				ui.logView.follow = false
+				ui.logsMutex.Unlock()
+			} else {
+				ui.selectedIndex = max(0, ui.selectedIndex-1)
+			}
+		case tea.MouseButtonWheelDown:
+			if msg.Y >= splitterY {
+				ui.logsMutex.Lock()
+				ui.logView.scroll = max(0, ui.logView.scroll-1)
+				ui.logsMutex.Unlock()
+			} else {
+				ui.signalsMutex.RLock()
+				count := len(ui.visibleSymbols())
+				ui.signalsMutex.RUnlock()
+				ui.selectedIndex = max(0, min(count-1, ui.selectedIndex+1))
+			}
 		}
 
-	case tea.WindowSizeMsg:
-		m.ui.width = msg.Width
-		m.ui.height = msg.Height
+	case tea.MouseActionMotion:
+		if ui.dragging {
+			available := max(2*minPaneHeight, ui.height-layoutOverhead-max(1, ui.footerHeight))
+			ui.splitRatio = float64(msg.Y-panesTop) / float64(available)
+			ui.splitRatio = math.Max(0.1, math.Min(0.9, ui.splitRatio))
+		}
 
-	case refreshMsg:
-		// Просто обновляем UI
+	case tea.MouseActionRelease:
+		if ui.dragging {
+			ui.dragging = false
+			ui.persistLayout()
+		}
+	}
+}
+
+// persistLayout сохраняет размеры панелей в файл состояния
+func (ui *TermUI) persistLayout() {
+	ui.config.SplitRatio = math.Round(ui.splitRatio*100) / 100
+	if ui.uiState == nil {
+		return
+	}
+	ui.uiState.SetSplitRatio(ui.config.SplitRatio)
+	ui.saveState()
+}
+
+// persistWatchlists сохраняет списки наблюдения в файл состояния
+func (ui *TermUI) persistWatchlists() {
+	if ui.uiState == nil {
+		return
 	}
+	ui.uiState.SetWatchlists(ui.config.Watchlists)
+	ui.saveState()
+}
 
-	return m, nil
+// saveState записывает файл состояния в фоне. Значения уже запомнены в порядке
+// изменений, а запись берет последние из них, поэтому порядок записей не важен.
+func (ui *TermUI) saveState() {
+	go func() {
+		if err := ui.uiState.Save(); err != nil {
+			logger.Warn("Ошибка сохранения настроек UI", zap.Error(err))
+		}
+	}()
 }
 
 func (m bubbleModel) View() string {
@@ -307,82 +887,267 @@ func (m bubbleModel) View() string {
 	defer m.ui.signalsMutex.RUnlock()
 	defer m.ui.logsMutex.RUnlock()
 
+	// Строка состояния и футер переносятся по ширине экрана, поэтому их высота влияет на панели
+	tr := m.ui.tr
+	footerText := tr.T("ui.footer", m.ui.keysHelp) + " | bfma " + version.Short()
+	switch m.ui.inputMode {
+	case "search":
+		footerText = tr.T("ui.prompt_search", m.ui.input)
+	case "time":
+		footerText = tr.T("ui.prompt_time", m.ui.input)
+	case "symbol":
+		footerText = tr.T("ui.prompt_symbol", m.ui.input)
+	case "note":
+		footerText = m.ui.notePrompt()
+	}
+	footer := lipgloss.JoinVertical(lipgloss.Left,
+		renderStatusBar(health.Get(), tr, max(20, m.ui.width-appChromeWidth)),
+		footerStyle.Width(max(20, m.ui.width-appChromeWidth)).Render(footerText),
+	)
+	m.ui.footerHeight = lipgloss.Height(footer)
+
+	signalsHeight, logsHeight := m.ui.paneHeights()
+
+	// Прокручиваем панель сигналов так, чтобы выбранная строка была видна
+	rows := signalsHeight - paneChrome
+	if m.ui.selectedIndex < m.ui.signalsOffset {
+		m.ui.signalsOffset = m.ui.selectedIndex
+	} else if m.ui.selectedIndex >= m.ui.signalsOffset+rows {
+		m.ui.signalsOffset = m.ui.selectedIndex - rows + 1
+	}
+
 	// Создаем компоненты UI
-	title := titleStyle.Render("BFMA - Binance Futures Market Analyzer")
-	signals := renderSignalsSection(m.ui.signals, m.ui.selectedIndex)
-	logs := renderLogsSection(m.ui.logs)
-	footer := footerStyle.Render("Клавиши: ↑/↓ - навигация, R - перезагрузить логи, Q - выход")
+	title := titleStyle.Render(tr.T("ui.title"))
+	var top string
+	if m.ui.ticket != nil {
+		top = renderTicketSection(m.ui.ticket, tr, max(20, m.ui.width-appChromeWidth), signalsHeight)
+		m.ui.signalsWidth = 0
+	} else if m.ui.history != nil {
+		top = renderHistorySection(m.ui.history, m.ui.analyzer.MarketStates()[m.ui.history.symbol], m.ui.symbolNotes(m.ui.history.symbol), m.ui.analyzer.Thresholds(m.ui.history.symbol), tr, max(20, m.ui.width-appChromeWidth), signalsHeight)
+		m.ui.signalsWidth = 0 // Клики по строкам сигналов не обрабатываются
+	} else {
+
+		// При открытой панели позиций делим правую колонку пополам
+		alertsHeight := signalsHeight
+		var positions string
+		if m.ui.positions.source != nil && signalsHeight >= 2*minPaneHeight {
+			positionsHeight := signalsHeight / 2
+			alertsHeight = signalsHeight - positionsHeight
+			positions = renderPositionsSection(m.ui.positions.source.Positions(), m.ui.signals, tr, positionsHeight)
+		}
+
+		m.ui.alertsMutex.Lock()
+		right := renderAlertsSection(m.ui.alerts, time.Now().Before(m.ui.flashUntil), tr, alertsHeight)
+		m.ui.alertsMutex.Unlock()
+
+		if positions != "" {
+			right = lipgloss.JoinVertical(lipgloss.Left, right, positions)
+		}
+
+		var signals string
+		if m.ui.grid {
+			// Сетка занимает все место слева от колонки оповещений
+			width := max(gridCellWidth+4, m.ui.width-appChromeWidth-lipgloss.Width(right)-1)
+			signals, m.ui.gridColumns = m.ui.renderGridSection(width, signalsHeight)
+			m.ui.signalsWidth = 0 // Клики по строкам таблицы не обрабатываются
+		} else {
+			signals = m.ui.renderSignalsSection(signalsHeight)
+			m.ui.signalsWidth = lipgloss.Width(signals)
+		}
+
+		top = lipgloss.JoinHorizontal(lipgloss.Top, signals, " ", right)
+	}
+
+	logs := renderLogsSection(m.ui.visibleLogs(), m.ui.logView, tr, logsHeight)
 
 	// Собираем UI
 	return appStyle.Render(
 		lipgloss.JoinVertical(lipgloss.Left,
 			title,
-			"\n",
-			signals,
-			"\n",
+			"",
+			top,
 			logs,
-			"\n",
+			"",
 			footer,
 		),
 	)
 }
 
-// Вспомогательные функции
-func renderSignalsSection(signals map[string]*models.SignalResult, selectedIndex int) string {
-	header := signalsHeaderStyle.Render("СИГНАЛЫ")
-	content := strings.Builder{}
+// visibleLogs возвращает логи, проходящие фильтры. Результат кэшируется до появления
+// новых строк или изменения фильтров. Вызывающий должен удерживать logsMutex
+// (достаточно на чтение).
+func (ui *TermUI) visibleLogs() []logEntry {
+	ui.cacheMutex.Lock()
+	defer ui.cacheMutex.Unlock()
 
-	symbols := getSymbolsFromSignals(signals)
+	key := filteredLogsKey{
+		total:    ui.logsTotal,
+		count:    len(ui.logs),
+		minLevel: ui.logView.minLevel,
+		search:   ui.logView.search,
+	}
+	if ui.filteredLogs == nil || key != ui.filteredKey {
+		ui.filteredLogs = ui.logView.filter(ui.logs)
+		ui.filteredKey = key
+	}
+	return ui.filteredLogs
+}
 
-	if len(symbols) == 0 {
-		content.WriteString("  Ожидание данных...\n")
-	} else {
-		for i, symbol := range symbols {
-			signal := signals[symbol]
+// renderSignalsSection отображает панель сигналов. Строки перерисовываются
+// только при изменении сигнала, выбора или паузы символа. Вызывающий должен удерживать
+// signalsMutex (достаточно на чтение).
+func (ui *TermUI) renderSignalsSection(height int) string {
+	ui.cacheMutex.Lock()
+	defer ui.cacheMutex.Unlock()
 
-			// Форматируем сигнал с цветом
-			signalText := formatSignalText(signal.Recommendation, signal.SignalStrength)
+	tr := ui.tr
+	header := signalsHeaderStyle.Render(ui.watchlistTitle())
+	var lines []string
 
-			// Создаем строку данных
-			line := fmt.Sprintf("  %s: %s (%.2f) Цена: %.2f",
-				symbol, signalText, signal.SignalStrength, signal.CurrentPrice)
+	symbols := ui.visibleSymbols()
+	rows := max(1, height-paneChrome)
 
-			// Выделяем выбранную строку
-			if i == selectedIndex {
-				line = "> " + line[2:]
-				line = lipgloss.NewStyle().Background(lipgloss.Color("#222222")).Render(line)
+	if len(symbols) == 0 {
+		lines = append(lines, "  "+tr.T("ui.waiting"))
+	} else {
+		now := time.Now()
+		for i := ui.signalsOffset; i < len(symbols) && i < ui.signalsOffset+rows; i++ {
+			symbol := symbols[i]
+			signal, ok := ui.signals[symbol]
+			if !ok {
+				// Символ из списка наблюдения, для которого еще нет сигнала
+				signal = &models.SignalResult{Symbol: symbol}
+			}
+
+			key := signalRowKey{
+				code:           signal.RecommendationCode,
+				recommendation: signal.Recommendation,
+				strength:       signal.SignalStrength,
+				price:          signal.CurrentPrice,
+				funding:        ui.fundingText(symbol, now),
+				noted:          len(ui.symbolNotes(symbol)) > 0,
+				selected:       i == ui.selectedIndex,
+				paused:         ui.analyzer.IsPaused(symbol),
+				muted:          ui.mutes != nil && ui.mutes.Muted(symbol),
+				override:       ui.overrideText(symbol, now),
 			}
+			if row, ok := ui.signalRows[symbol]; ok && row.key == key {
+				lines = append(lines, row.line)
+				continue
+			}
+
+			line := renderSignalRow(symbol, signal, ui.formatPrice(symbol, signal.CurrentPrice), key.funding, key.noted, key.selected, key.paused, key.muted, key.override, tr)
+			ui.signalRows[symbol] = signalRow{key: key, line: line}
+			lines = append(lines, line)
+		}
+	}
 
-			content.WriteString(line + "\n")
+	// Убираем из кэша символы, которых больше нет на экране
+	visible := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		visible[symbol] = true
+	}
+	for symbol := range ui.signalRows {
+		if !visible[symbol] {
+			delete(ui.signalRows, symbol)
 		}
 	}
 
-	return signalsSectionStyle.Render(
+	return signalsSectionStyle.Height(height - 2).Render(
 		lipgloss.JoinVertical(lipgloss.Left,
 			header,
-			content.String(),
+			strings.Join(lines, "\n"),
 		),
 	)
 }
 
+// renderSignalRow отображает строку сигнала символа
+func renderSignalRow(symbol string, signal *models.SignalResult, price, funding string, noted, selected, paused, muted bool, override string, tr *i18n.Translator) string {
+	// Форматируем сигнал с цветом
+	signalText := formatSignalText(signal, tr)
+
+	// Создаем строку данных
+	line := "  " + tr.T("ui.signal_line",
+		symbol, signalText, signal.SignalStrength, price)
+	if funding != "" {
+		line += " " + funding
+	}
+	if noted {
+		line += " ✎"
+	}
+	if paused {
+		line += " " + pausedStyle.Render(tr.T("ui.paused"))
+	}
+	if muted {
+		line += " " + mutedStyle.Render(tr.T("ui.muted"))
+	}
+	if override != "" {
+		line += " " + overrideStyle.Render(override)
+	}
+
+	// Выделяем выбранную строку
+	if selected {
+		line = "> " + line[2:]
+		line = lipgloss.NewStyle().Background(lipgloss.Color("#222222")).Render(line)
+	}
+
+	return line
+}
+
+// overrideText возвращает действующие ручные поправки символа и время окончания
+// последней из них; пусто - поправок нет
+func (ui *TermUI) overrideText(symbol string, now time.Time) string {
+	overrides := ui.analyzer.Overrides(symbol)
+	if len(overrides) == 0 {
+		return ""
+	}
+
+	var parts []string
+	var until time.Time
+	for _, override := range overrides {
+		if override.Force != "" {
+			parts = append(parts, override.Force.Localize(ui.tr))
+		}
+		for _, component := range state.OverrideComponents {
+			if factor, ok := override.Weights[component]; ok {
+				parts = append(parts, fmt.Sprintf("%s×%v", component, factor))
+			}
+		}
+		if override.Until.After(until) {
+			until = override.Until
+		}
+	}
+	layout := "15:04"
+	if until.Sub(now) >= 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	return ui.tr.T("ui.override", strings.Join(parts, ", "), timezone.In(until).Format(layout))
+}
+
 // Вспомогательные функции
-func formatSignalText(recommendation string, strength float64) string {
+func formatSignalText(signal *models.SignalResult, tr *i18n.Translator) string {
 	var style lipgloss.Style
 
-	switch recommendation {
-	case "СИЛЬНАЯ ПОКУПКА":
+	switch signal.RecommendationCode {
+	case models.RecommendationStrongBuy:
 		style = lipgloss.NewStyle().Foreground(successColor).Bold(true)
-	case "ПОКУПКА":
+	case models.RecommendationBuy:
 		style = lipgloss.NewStyle().Foreground(successColor)
-	case "СИЛЬНАЯ ПРОДАЖА":
+	case models.RecommendationStrongSell:
 		style = lipgloss.NewStyle().Foreground(errorColor).Bold(true)
-	case "ПРОДАЖА":
+	case models.RecommendationSell:
 		style = lipgloss.NewStyle().Foreground(errorColor)
 	default:
 		style = lipgloss.NewStyle().Foreground(warningColor)
 	}
 
-	return style.Render(recommendation)
+	// Сигналы без кода (например, из старой истории) показываем как есть
+	text := signal.Recommendation
+	if signal.RecommendationCode != "" {
+		text = signal.RecommendationCode.Localize(tr)
+	}
+
+	return style.Render(text)
 }
 
 func getSymbolsFromSignals(signals map[string]*models.SignalResult) []string {
@@ -390,6 +1155,8 @@ func getSymbolsFromSignals(signals map[string]*models.SignalResult) []string {
 	for symbol := range signals {
 		symbols = append(symbols, symbol)
 	}
+	// Стабильный порядок нужен для навигации и кликов мышью
+	sort.Strings(symbols)
 	return symbols
 }
 
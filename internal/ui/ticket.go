@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/execution"
+	"github.com/skalibog/bfma/internal/i18n"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Поля тикета в порядке навигации
+const (
+	ticketFieldSide = iota
+	ticketFieldQuantity
+	ticketFieldStopLoss
+	ticketFieldTakeProfit
+	ticketFieldCount
+)
+
+// Таймаут запросов к бирже из тикета
+const ticketTimeout = 15 * time.Second
+
+// Стили тикета
+var (
+	ticketFieldStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#222222")).Bold(true)
+	ticketConfirmStyle = lipgloss.NewStyle().Foreground(warningColor).Bold(true)
+)
+
+// TradeExecutor рассчитывает и отправляет заявки
+type TradeExecutor interface {
+	SuggestTicket(ctx context.Context, signal *models.SignalResult) (*models.OrderTicket, error)
+	Recalculate(ctx context.Context, ticket *models.OrderTicket) error
+	Execute(ctx context.Context, ticket *models.OrderTicket) error
+}
+
+// ticketView хранит состояние открытого тикета заявки
+type ticketView struct {
+	symbol  string
+	ticket  *models.OrderTicket
+	field   int
+	input   string // Вводимое значение текущего поля ("" - поле не редактируется)
+	confirm bool   // Пользователь проверяет заявку перед отправкой
+	loading bool
+	sending bool
+	sent    bool
+	err     error
+}
+
+// ticketMsg сообщает о расчете предложенной заявки
+type ticketMsg struct {
+	symbol string
+	ticket *models.OrderTicket
+	err    error
+}
+
+// ticketResultMsg сообщает о результате отправки заявки
+type ticketResultMsg struct {
+	err error
+}
+
+// SetTradeExecutor включает тикет заявки (клавиша Enter на символе)
+func (ui *TermUI) SetTradeExecutor(executor TradeExecutor) {
+	ui.executor = executor
+}
+
+// openTicket открывает тикет заявки по выбранному сигналу
+func (ui *TermUI) openTicket() tea.Cmd {
+	if ui.executor == nil {
+		return nil
+	}
+
+	signal := ui.selectedSignal()
+	if signal == nil {
+		return nil
+	}
+
+	ui.ticket = &ticketView{symbol: signal.Symbol, loading: true}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ui.ctx, ticketTimeout)
+		defer cancel()
+
+		ticket, err := ui.executor.SuggestTicket(ctx, signal)
+		return ticketMsg{symbol: signal.Symbol, ticket: ticket, err: err}
+	}
+}
+
+// handleTicketKey обрабатывает клавиши в открытом тикете
+func (ui *TermUI) handleTicketKey(msg tea.KeyMsg) tea.Cmd {
+	tv := ui.ticket
+
+	// После отправки или ошибки расчета любая клавиша закрывает тикет
+	if tv.sent || (tv.ticket == nil && !tv.loading) {
+		ui.ticket = nil
+		return nil
+	}
+	if tv.loading || tv.sending {
+		if msg.Type == tea.KeyEsc {
+			ui.ticket = nil
+		}
+		return nil
+	}
+
+	if tv.confirm {
+		switch msg.String() {
+		case "y", "Y":
+			tv.sending = true
+			ticket := *tv.ticket
+			return func() tea.Msg {
+				ctx, cancel := context.WithTimeout(ui.ctx, ticketTimeout)
+				defer cancel()
+				return ticketResultMsg{err: ui.executor.Execute(ctx, &ticket)}
+			}
+		case "n", "N", "esc":
+			tv.confirm = false
+		}
+		return nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		ui.ticket = nil
+	case tea.KeyUp, tea.KeyShiftTab:
+		tv.commitInput()
+		tv.field = (tv.field + ticketFieldCount - 1) % ticketFieldCount
+	case tea.KeyDown, tea.KeyTab:
+		tv.commitInput()
+		tv.field = (tv.field + 1) % ticketFieldCount
+	case tea.KeyLeft, tea.KeyRight, tea.KeySpace:
+		if tv.field == ticketFieldSide {
+			ui.toggleTicketSide()
+		}
+	case tea.KeyBackspace:
+		if tv.input != "" {
+			tv.input = tv.input[:len(tv.input)-1]
+		}
+	case tea.KeyEnter:
+		tv.commitInput()
+		tv.err = execution.Validate(tv.ticket)
+		tv.confirm = tv.err == nil
+	case tea.KeyRunes:
+		if tv.field != ticketFieldSide {
+			for _, r := range msg.Runes {
+				if (r >= '0' && r <= '9') || r == '.' {
+					tv.input += string(r)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// toggleTicketSide меняет направление заявки и пересчитывает стоп-лосс и тейк-профит
+func (ui *TermUI) toggleTicketSide() {
+	tv := ui.ticket
+	tv.ticket.Side = tv.ticket.Side.Opposite()
+
+	ctx, cancel := context.WithTimeout(ui.ctx, ticketTimeout)
+	defer cancel()
+	tv.err = ui.executor.Recalculate(ctx, tv.ticket)
+}
+
+// commitInput применяет введенное значение к текущему полю
+func (tv *ticketView) commitInput() {
+	if tv.input == "" {
+		return
+	}
+
+	value, err := strconv.ParseFloat(tv.input, 64)
+	tv.input = ""
+	if err != nil {
+		tv.err = fmt.Errorf("неверное число: %w", err)
+		return
+	}
+
+	switch tv.field {
+	case ticketFieldQuantity:
+		tv.ticket.Quantity = value
+	case ticketFieldStopLoss:
+		tv.ticket.StopLoss = value
+	case ticketFieldTakeProfit:
+		tv.ticket.TakeProfit = value
+	}
+	tv.err = nil
+}
+
+// renderTicketSection отображает тикет заявки
+func renderTicketSection(tv *ticketView, tr *i18n.Translator, width, height int) string {
+	header := signalsHeaderStyle.Render(tr.T("ui.ticket", tv.symbol))
+	contentWidth := max(20, width-4)
+
+	var lines []string
+	switch {
+	case tv.loading:
+		lines = append(lines, "  "+tr.T("ui.ticket_loading"))
+	case tv.ticket == nil:
+		lines = append(lines, "  "+lipgloss.NewStyle().Foreground(errorColor).Render(tr.T("ui.ticket_error", tv.err)))
+	default:
+		t := tv.ticket
+		values := []string{
+			t.Side.Localize(tr),
+			strconv.FormatFloat(t.Quantity, 'f', -1, 64),
+			strconv.FormatFloat(t.StopLoss, 'f', -1, 64),
+			strconv.FormatFloat(t.TakeProfit, 'f', -1, 64),
+		}
+		labels := []string{
+			tr.T("ui.ticket_side"),
+			tr.T("ui.ticket_quantity"),
+			tr.T("ui.ticket_stop_loss"),
+			tr.T("ui.ticket_take_profit"),
+		}
+
+		lines = append(lines, "  "+tr.T("ui.ticket_entry", t.EntryPrice, t.Quantity*t.EntryPrice))
+		for i := range labels {
+			value := values[i]
+			if i == tv.field && tv.input != "" {
+				value = tv.input + "▏"
+			}
+			line := fmt.Sprintf("  %-14s %s", labels[i], value)
+			if i == tv.field && !tv.confirm {
+				line = ticketFieldStyle.Render("> " + line[2:])
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines, "")
+
+		switch {
+		case tv.sent:
+			lines = append(lines, "  "+lipgloss.NewStyle().Foreground(successColor).Render(tr.T("ui.ticket_sent")))
+		case tv.sending:
+			lines = append(lines, "  "+tr.T("ui.ticket_sending"))
+		case tv.confirm:
+			lines = append(lines, "  "+ticketConfirmStyle.Render(tr.T("ui.ticket_confirm",
+				t.Side.Localize(tr), values[1], t.Symbol, values[2], values[3])))
+		default:
+			lines = append(lines, "  "+logsStatusStyle.Render(tr.T("ui.ticket_help")))
+		}
+
+		if tv.err != nil {
+			lines = append(lines, "  "+lipgloss.NewStyle().Foreground(errorColor).Render(tv.err.Error()))
+		}
+	}
+
+	return signalsSectionStyle.Width(contentWidth + 2).Height(height - 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			header,
+			strings.Join(lines, "\n"),
+		),
+	)
+}
@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Порядки сортировки списка наблюдения
+const (
+	sortSymbol       = "symbol"
+	sortStrengthDesc = "strength_desc"
+	sortStrengthAsc  = "strength_asc"
+)
+
+var sortOrders = []string{sortSymbol, sortStrengthDesc, sortStrengthAsc}
+
+// Допустимый формат символа фьючерса
+var symbolRegex = regexp.MustCompile(`^[A-Z0-9]{2,20}$`)
+
+// SetSymbolTracker задает функцию, которая запускает (track=true) или останавливает
+// сбор данных и анализ символа при изменении списков наблюдения
+func (ui *TermUI) SetSymbolTracker(track func(symbol string, track bool) error) {
+	ui.trackSymbol = track
+}
+
+// currentWatchlist возвращает активный список наблюдения или nil, если показываются все символы
+func (ui *TermUI) currentWatchlist() *config.WatchlistConfig {
+	if ui.watchlist <= 0 || ui.watchlist > len(ui.config.Watchlists) {
+		return nil
+	}
+	return &ui.config.Watchlists[ui.watchlist-1]
+}
+
+// visibleSymbols возвращает символы активного списка в порядке отображения.
+// Вызывающий должен удерживать signalsMutex.
+func (ui *TermUI) visibleSymbols() []string {
+	wl := ui.currentWatchlist()
+	if wl == nil {
+		return getSymbolsFromSignals(ui.signals)
+	}
+
+	var symbols []string
+	if wl.Positions {
+		if ui.positions.source != nil {
+			seen := make(map[string]bool)
+			for _, p := range ui.positions.source.Positions() {
+				if !seen[p.Symbol] {
+					seen[p.Symbol] = true
+					symbols = append(symbols, p.Symbol)
+				}
+			}
+		}
+	} else {
+		symbols = append(symbols, wl.Symbols...)
+	}
+
+	strength := func(symbol string) (float64, bool) {
+		if signal, ok := ui.signals[symbol]; ok && signal.RecommendationCode != "" {
+			return signal.SignalStrength, true
+		}
+		return 0, false
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if wl.Sort == sortStrengthDesc || wl.Sort == sortStrengthAsc {
+			si, oki := strength(symbols[i])
+			sj, okj := strength(symbols[j])
+			// Символы без сигнала всегда в конце
+			if oki != okj {
+				return oki
+			}
+			if si != sj {
+				if wl.Sort == sortStrengthDesc {
+					return si > sj
+				}
+				return si < sj
+			}
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	return symbols
+}
+
+// cycleWatchlist переключает активный список наблюдения (после последнего - все символы)
+func (ui *TermUI) cycleWatchlist() {
+	ui.watchlist = (ui.watchlist + 1) % (len(ui.config.Watchlists) + 1)
+	ui.selectedIndex = 0
+	ui.signalsOffset = 0
+}
+
+// cycleSort переключает порядок сортировки активного списка и сохраняет его
+func (ui *TermUI) cycleSort() {
+	wl := ui.currentWatchlist()
+	if wl == nil {
+		return
+	}
+
+	next := 0
+	for i, order := range sortOrders {
+		if order == wl.Sort {
+			next = (i + 1) % len(sortOrders)
+		}
+	}
+	wl.Sort = sortOrders[next]
+	ui.persistWatchlists()
+}
+
+// addToWatchlist добавляет символ в активный список и запускает его отслеживание
+func (ui *TermUI) addToWatchlist(input string) {
+	symbol := strings.ToUpper(strings.TrimSpace(input))
+	wl := ui.currentWatchlist()
+
+	switch {
+	case symbol == "":
+		return
+	case wl == nil || wl.Positions:
+		logger.Warn("Символы можно добавлять только в именованный список наблюдения")
+		return
+	case !symbolRegex.MatchString(symbol):
+		logger.Warn("Неверный формат символа", zap.String("symbol", symbol))
+		return
+	}
+
+	for _, s := range wl.Symbols {
+		if s == symbol {
+			return
+		}
+	}
+	wl.Symbols = append(wl.Symbols, symbol)
+	ui.persistWatchlists()
+
+	if ui.trackSymbol == nil {
+		return
+	}
+	go func() {
+		if err := ui.trackSymbol(symbol, true); err != nil {
+			logger.Warn("Ошибка запуска отслеживания символа", zap.String("symbol", symbol), zap.Error(err))
+		}
+	}()
+}
+
+// removeSelectedFromWatchlist удаляет выбранный символ из активного списка.
+// Если символ больше не входит ни в один список, его отслеживание останавливается.
+func (ui *TermUI) removeSelectedFromWatchlist() {
+	wl := ui.currentWatchlist()
+	if wl == nil || wl.Positions {
+		logger.Warn("Символы можно удалять только из именованного списка наблюдения")
+		return
+	}
+
+	ui.signalsMutex.RLock()
+	symbols := ui.visibleSymbols()
+	ui.signalsMutex.RUnlock()
+
+	if ui.selectedIndex < 0 || ui.selectedIndex >= len(symbols) {
+		return
+	}
+	symbol := symbols[ui.selectedIndex]
+
+	for i, s := range wl.Symbols {
+		if s == symbol {
+			wl.Symbols = append(wl.Symbols[:i:i], wl.Symbols[i+1:]...)
+			break
+		}
+	}
+	ui.selectedIndex = max(0, min(len(symbols)-2, ui.selectedIndex))
+	ui.persistWatchlists()
+
+	for _, other := range ui.config.Watchlists {
+		for _, s := range other.Symbols {
+			if s == symbol {
+				return
+			}
+		}
+	}
+
+	if ui.trackSymbol == nil {
+		return
+	}
+	go func() {
+		if err := ui.trackSymbol(symbol, false); err != nil {
+			logger.Warn("Ошибка остановки отслеживания символа", zap.String("symbol", symbol), zap.Error(err))
+		}
+	}()
+}
+
+// watchlistTitle возвращает заголовок панели сигналов с названием активного списка
+func (ui *TermUI) watchlistTitle() string {
+	wl := ui.currentWatchlist()
+	if wl == nil {
+		if len(ui.config.Watchlists) == 0 {
+			return ui.tr.T("ui.signals")
+		}
+		return ui.tr.T("ui.signals_watchlist", ui.tr.T("ui.watchlist_all"), ui.tr.T("sort."+sortSymbol))
+	}
+
+	order := wl.Sort
+	if order == "" {
+		order = sortSymbol
+	}
+	return ui.tr.T("ui.signals_watchlist", wl.Name, ui.tr.T("sort."+order))
+}
@@ -0,0 +1,350 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/i18n"
+)
+
+// Поддерживаемые хранилища данных
+var storageBackends = []string{"influxdb"}
+
+// Стили мастера настройки
+var (
+	wizardHintStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#999999"))
+	wizardOptionStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#ffffff")).
+				Background(primaryColor)
+)
+
+// wizardStep шаг мастера настройки
+type wizardStep struct {
+	key     string                                   // Ключ подписи шага: wizard.<key>
+	options []string                                 // Варианты выбора; пусто - ввод текста
+	secret  bool                                     // Скрывать вводимое значение
+	get     func(cfg *config.Config) string          // Значение по умолчанию
+	set     func(cfg *config.Config, v string) error // Проверка и сохранение значения
+}
+
+// wizardSteps возвращает шаги мастера настройки
+func wizardSteps() []wizardStep {
+	return []wizardStep{
+		{
+			key:     "locale",
+			options: i18n.Locales(),
+			get:     func(cfg *config.Config) string { return cfg.UI.Locale },
+			set: func(cfg *config.Config, v string) error {
+				cfg.UI.Locale = v
+				return nil
+			},
+		},
+		{
+			key: "api_key",
+			get: func(cfg *config.Config) string { return cfg.Binance.APIKey },
+			set: func(cfg *config.Config, v string) error {
+				cfg.Binance.APIKey = v
+				return nil
+			},
+		},
+		{
+			key:    "api_secret",
+			secret: true,
+			get:    func(cfg *config.Config) string { return cfg.Binance.APISecret },
+			set: func(cfg *config.Config, v string) error {
+				if cfg.Binance.APIKey != "" && v == "" {
+					return errors.New("секрет API обязателен, если указан ключ API")
+				}
+				cfg.Binance.APISecret = v
+				return nil
+			},
+		},
+		{
+			key:     "testnet",
+			options: []string{"false", "true"},
+			get:     func(cfg *config.Config) string { return fmt.Sprint(cfg.Binance.Testnet) },
+			set: func(cfg *config.Config, v string) error {
+				cfg.Binance.Testnet = v == "true"
+				return nil
+			},
+		},
+		{
+			key: "symbols",
+			get: func(cfg *config.Config) string { return strings.Join(cfg.Trading.Symbols, ", ") },
+			set: func(cfg *config.Config, v string) error {
+				symbols := strings.FieldsFunc(strings.ToUpper(v), func(r rune) bool {
+					return r == ',' || r == ' '
+				})
+				if len(symbols) == 0 {
+					return errors.New("укажите хотя бы один символ")
+				}
+				cfg.Trading.Symbols = symbols
+				return nil
+			},
+		},
+		{
+			key:     "storage",
+			options: storageBackends,
+			get:     func(cfg *config.Config) string { return cfg.Storage.Type },
+			set: func(cfg *config.Config, v string) error {
+				cfg.Storage.Type = v
+				return nil
+			},
+		},
+		{
+			key: "storage_url",
+			get: func(cfg *config.Config) string { return cfg.Storage.URL },
+			set: func(cfg *config.Config, v string) error {
+				if !strings.HasPrefix(v, "http://") && !strings.HasPrefix(v, "https://") {
+					return fmt.Errorf("адрес должен начинаться с http:// или https://: %q", v)
+				}
+				cfg.Storage.URL = v
+				return nil
+			},
+		},
+		{
+			key:    "storage_token",
+			secret: true,
+			get:    func(cfg *config.Config) string { return cfg.Storage.Token },
+			set: func(cfg *config.Config, v string) error {
+				if v == "" {
+					return errors.New("токен InfluxDB обязателен")
+				}
+				cfg.Storage.Token = v
+				return nil
+			},
+		},
+		{
+			key: "storage_org",
+			get: func(cfg *config.Config) string { return cfg.Storage.Organization },
+			set: func(cfg *config.Config, v string) error {
+				if v == "" {
+					return errors.New("организация InfluxDB обязательна")
+				}
+				cfg.Storage.Organization = v
+				return nil
+			},
+		},
+		{
+			key: "storage_bucket",
+			get: func(cfg *config.Config) string { return cfg.Storage.Bucket },
+			set: func(cfg *config.Config, v string) error {
+				if v == "" {
+					return errors.New("bucket InfluxDB обязателен")
+				}
+				cfg.Storage.Bucket = v
+				return nil
+			},
+		},
+	}
+}
+
+// wizardModel модель мастера настройки для bubbletea
+type wizardModel struct {
+	path   string
+	cfg    *config.Config
+	tr     *i18n.Translator
+	steps  []wizardStep
+	step   int    // Текущий шаг; len(steps) - подтверждение
+	input  string // Вводимый текст
+	option int    // Выбранный вариант
+	err    error
+	saved  bool // Конфигурация записана
+}
+
+// RunSetupWizard запускает интерактивную настройку и записывает конфигурацию в path.
+// Используется при первом запуске, когда файла конфигурации еще нет.
+func RunSetupWizard(path string) (*config.Config, error) {
+	tr, err := i18n.New(i18n.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &wizardModel{
+		path:  path,
+		cfg:   config.Default(),
+		tr:    tr,
+		steps: wizardSteps(),
+	}
+	m.enterStep()
+
+	result, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка мастера настройки: %w", err)
+	}
+
+	final := result.(*wizardModel)
+	if !final.saved {
+		return nil, errors.New("настройка прервана пользователем")
+	}
+	return final.cfg, nil
+}
+
+// enterStep подставляет значение по умолчанию для текущего шага
+func (m *wizardModel) enterStep() {
+	m.err = nil
+	if m.step >= len(m.steps) {
+		return
+	}
+
+	step := m.steps[m.step]
+	value := step.get(m.cfg)
+	m.input = value
+	m.option = 0
+	for i, opt := range step.options {
+		if opt == value {
+			m.option = i
+		}
+	}
+}
+
+// Init реализует tea.Model
+func (m *wizardModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update реализует tea.Model
+func (m *wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		if m.step > 0 {
+			m.step--
+			m.enterStep()
+		}
+		return m, nil
+	}
+
+	// Подтверждение сохранения
+	if m.step >= len(m.steps) {
+		switch keyMsg.String() {
+		case "y", "Y", "enter":
+			if err := config.Save(m.path, m.cfg); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.saved = true
+			return m, tea.Quit
+		case "n", "N":
+			m.step--
+			m.enterStep()
+		}
+		return m, nil
+	}
+
+	step := m.steps[m.step]
+	switch keyMsg.Type {
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input)
+		if len(step.options) > 0 {
+			value = step.options[m.option]
+		}
+		if err := step.set(m.cfg, value); err != nil {
+			m.err = err
+			return m, nil
+		}
+
+		// Язык мастера переключается сразу после выбора
+		if step.key == "locale" {
+			if tr, err := i18n.New(value); err == nil {
+				m.tr = tr
+			}
+		}
+
+		m.step++
+		m.enterStep()
+	case tea.KeyUp, tea.KeyShiftTab:
+		if len(step.options) > 0 {
+			m.option = (m.option + len(step.options) - 1) % len(step.options)
+		}
+	case tea.KeyDown, tea.KeyTab:
+		if len(step.options) > 0 {
+			m.option = (m.option + 1) % len(step.options)
+		}
+	case tea.KeyBackspace:
+		if len(step.options) == 0 && len(m.input) > 0 {
+			runes := []rune(m.input)
+			m.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyCtrlU:
+		m.input = ""
+	case tea.KeyRunes, tea.KeySpace:
+		if len(step.options) == 0 {
+			m.input += string(keyMsg.Runes)
+		}
+	}
+
+	return m, nil
+}
+
+// View реализует tea.Model
+func (m *wizardModel) View() string {
+	tr := m.tr
+	lines := []string{
+		titleStyle.Render(tr.T("wizard.title")),
+		"",
+	}
+
+	if m.step >= len(m.steps) {
+		lines = append(lines, tr.T("wizard.confirm", m.path), "")
+		lines = append(lines, m.summary()...)
+	} else {
+		step := m.steps[m.step]
+		lines = append(lines,
+			wizardHintStyle.Render(tr.T("wizard.step", m.step+1, len(m.steps))),
+			signalsHeaderStyle.Render(tr.T("wizard."+step.key)),
+			wizardHintStyle.Render(tr.T("wizard."+step.key+"_hint")),
+			"",
+		)
+
+		if len(step.options) > 0 {
+			for i, opt := range step.options {
+				label := "  " + tr.T("wizard.option."+opt)
+				if i == m.option {
+					label = wizardOptionStyle.Render("> " + tr.T("wizard.option."+opt))
+				}
+				lines = append(lines, label)
+			}
+		} else {
+			value := m.input
+			if step.secret {
+				value = strings.Repeat("•", len([]rune(value)))
+			}
+			lines = append(lines, "> "+value+"▏")
+		}
+	}
+
+	if m.err != nil {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(errorColor).Render(tr.T("wizard.error", m.err)))
+	}
+
+	lines = append(lines, "", wizardHintStyle.Render(tr.T("wizard.keys")))
+	return appStyle.Render(strings.Join(lines, "\n"))
+}
+
+// summary возвращает введенные значения для подтверждения; секреты скрыты
+func (m *wizardModel) summary() []string {
+	lines := make([]string, 0, len(m.steps))
+	for _, step := range m.steps {
+		value := step.get(m.cfg)
+		switch {
+		case len(step.options) > 0:
+			value = m.tr.T("wizard.option." + value)
+		case step.secret && value != "":
+			value = strings.Repeat("•", 8)
+		}
+		lines = append(lines, fmt.Sprintf("  %-24s %s", m.tr.T("wizard."+step.key)+":", value))
+	}
+	return lines
+}
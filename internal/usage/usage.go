@@ -0,0 +1,216 @@
+// Package usage учитывает объем работы по символам: принятые от биржи сообщения, байты,
+// записанные в хранилище, вес запросов к REST API Binance и запросы к хранилищу. Итоги
+// копятся за все время работы и по часам, а по средним значениям на символ оценивается
+// нагрузка при другом числе символов.
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+)
+
+// Значения по умолчанию
+const (
+	defaultRetention = 48 * time.Hour
+	bucketSize       = time.Hour
+)
+
+// WeightLimit1m лимит веса запросов USDⓈ-M Futures за минуту с одного IP
+const WeightLimit1m = 2400
+
+// Counters счетчики объема работы
+type Counters struct {
+	Messages     int64 `json:"messages"`      // Сообщения биржи (события WebSocket и ответы опроса REST)
+	BytesWritten int64 `json:"bytes_written"` // Байты, записанные в хранилище (line protocol)
+	APIWeight    int64 `json:"api_weight"`    // Вес запросов к REST API по таблице весов Binance
+	Queries      int64 `json:"queries"`       // Запросы к хранилищу
+}
+
+func (c *Counters) add(other Counters) {
+	c.Messages += other.Messages
+	c.BytesWritten += other.BytesWritten
+	c.APIWeight += other.APIWeight
+	c.Queries += other.Queries
+}
+
+// scale возвращает счетчики, умноженные на factor
+func (c Counters) scale(factor float64) Counters {
+	return Counters{
+		Messages:     int64(float64(c.Messages) * factor),
+		BytesWritten: int64(float64(c.BytesWritten) * factor),
+		APIWeight:    int64(float64(c.APIWeight) * factor),
+		Queries:      int64(float64(c.Queries) * factor),
+	}
+}
+
+// Bucket счетчики за час
+type Bucket struct {
+	Start time.Time `json:"start"`
+	Counters
+	PeakWeight1m int `json:"peak_weight_1m"` // Наибольший вес за минуту по ответам Binance
+}
+
+var (
+	mutex      sync.Mutex
+	retention  = defaultRetention
+	started    = time.Now()
+	total      Counters
+	symbols    = make(map[string]*Counters)
+	buckets    []*Bucket // По возрастанию времени
+	usedWeight int       // Последний вес за минуту по ответам Binance
+)
+
+// Configure задает, сколько хранить почасовые счетчики
+func Configure(cfg config.UsageConfig) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	retention = cfg.Retention.Std()
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	prune(time.Now())
+}
+
+// bucket возвращает счетчики текущего часа. Вызывающий должен удерживать mutex.
+func bucket(now time.Time) *Bucket {
+	start := now.Truncate(bucketSize)
+	if n := len(buckets); n > 0 && buckets[n-1].Start.Equal(start) {
+		return buckets[n-1]
+	}
+	b := &Bucket{Start: start}
+	buckets = append(buckets, b)
+	prune(now)
+	return b
+}
+
+// prune удаляет почасовые счетчики старше retention. Вызывающий должен удерживать mutex.
+func prune(now time.Time) {
+	cutoff := now.Add(-retention)
+	i := 0
+	for i < len(buckets) && buckets[i].Start.Add(bucketSize).Before(cutoff) {
+		i++
+	}
+	buckets = buckets[i:]
+}
+
+// add учитывает счетчики символа; пустой символ попадает только в итоги
+func add(symbol string, c Counters) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	total.add(c)
+	bucket(time.Now()).add(c)
+	if symbol == "" {
+		return
+	}
+	s, ok := symbols[symbol]
+	if !ok {
+		s = &Counters{}
+		symbols[symbol] = s
+	}
+	s.add(c)
+}
+
+// AddMessage учитывает сообщение биржи по символу
+func AddMessage(symbol string) {
+	add(symbol, Counters{Messages: 1})
+}
+
+// AddBytes учитывает байты, записанные в хранилище по символу
+func AddBytes(symbol string, n int) {
+	add(symbol, Counters{BytesWritten: int64(n)})
+}
+
+// AddWeight учитывает вес запроса к REST API по символу
+func AddWeight(symbol string, weight int) {
+	add(symbol, Counters{APIWeight: int64(weight)})
+}
+
+// AddQuery учитывает запрос к хранилищу
+func AddQuery() {
+	add("", Counters{Queries: 1})
+}
+
+// ObserveUsedWeight запоминает вес за текущую минуту из ответа Binance
+// (заголовок X-MBX-USED-WEIGHT-1M)
+func ObserveUsedWeight(weight int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	usedWeight = weight
+	b := bucket(time.Now())
+	b.PeakWeight1m = max(b.PeakWeight1m, weight)
+}
+
+// SymbolCounters счетчики символа
+type SymbolCounters struct {
+	Symbol string `json:"symbol"`
+	Counters
+}
+
+// Report итоги учета
+type Report struct {
+	Since        time.Time        `json:"since"`
+	Total        Counters         `json:"total"`
+	Symbols      []SymbolCounters `json:"symbols"` // По убыванию записанных байт
+	Hours        []Bucket         `json:"hours"`
+	UsedWeight1m int              `json:"used_weight_1m"` // Последний вес за минуту по ответам Binance
+	PeakWeight1m int              `json:"peak_weight_1m"` // Наибольший вес за минуту за хранимые часы
+}
+
+// Get возвращает итоги учета
+func Get() Report {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	report := Report{
+		Since:        started,
+		Total:        total,
+		Symbols:      make([]SymbolCounters, 0, len(symbols)),
+		Hours:        make([]Bucket, 0, len(buckets)),
+		UsedWeight1m: usedWeight,
+	}
+	for symbol, c := range symbols {
+		report.Symbols = append(report.Symbols, SymbolCounters{Symbol: symbol, Counters: *c})
+	}
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		if report.Symbols[i].BytesWritten != report.Symbols[j].BytesWritten {
+			return report.Symbols[i].BytesWritten > report.Symbols[j].BytesWritten
+		}
+		return report.Symbols[i].Symbol < report.Symbols[j].Symbol
+	})
+	for _, b := range buckets {
+		report.Hours = append(report.Hours, *b)
+		report.PeakWeight1m = max(report.PeakWeight1m, b.PeakWeight1m)
+	}
+	return report
+}
+
+// Projection оценка нагрузки при заданном числе символов
+type Projection struct {
+	Symbols      int      `json:"symbols"`
+	PerHour      Counters `json:"per_hour"`
+	PerDay       Counters `json:"per_day"`
+	PeakWeight1m int      `json:"peak_weight_1m"` // Оценка наибольшего веса за минуту
+	WeightLimit  int      `json:"weight_limit_1m"`
+}
+
+// Project оценивает нагрузку при числе символов target по средней нагрузке
+// на символ за время работы. Нагрузка считается пропорциональной числу символов.
+func (r Report) Project(target int, now time.Time) Projection {
+	p := Projection{Symbols: target, WeightLimit: WeightLimit1m}
+	hours := now.Sub(r.Since).Hours()
+	if len(r.Symbols) == 0 || hours <= 0 {
+		return p
+	}
+
+	factor := float64(target) / float64(len(r.Symbols))
+	p.PerHour = r.Total.scale(factor / hours)
+	p.PerDay = p.PerHour.scale(24)
+	p.PeakWeight1m = int(float64(r.PeakWeight1m) * factor)
+	return p
+}
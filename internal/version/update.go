@@ -0,0 +1,143 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Адрес списка релизов на GitHub
+const releasesURL = "https://api.github.com/repos/skalibog/bfma/releases?per_page=30"
+
+// Слова в описании релиза, по которым строка считается относящейся к конфигурации
+var configKeywords = []string{"config", "конфигурац", "миграц", "migrat", "schema", "схем"}
+
+// Release релиз на GitHub
+type Release struct {
+	Tag        string `json:"tag_name"`
+	URL        string `json:"html_url"`
+	Notes      string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// Update доступное обновление
+type Update struct {
+	Latest      Release  // Последний релиз
+	Missed      []string // Теги релизов новее текущей версии, от новых к старым
+	ConfigNotes []string // Строки описаний пропущенных релизов об изменениях конфигурации
+}
+
+// CheckUpdate запрашивает релизы на GitHub и возвращает обновление или nil, если
+// установлена последняя версия. Сборки без версии (dev) не сравниваются.
+func CheckUpdate(ctx context.Context) (*Update, error) {
+	if _, ok := parseVersion(Version); !ok {
+		return nil, fmt.Errorf("версия сборки %q не является номером релиза, сравнение с релизами невозможно", Version)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "bfma/"+Version)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса релизов: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка запроса релизов: ответ %s", resp.Status)
+	}
+
+	var releases []Release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("ошибка разбора списка релизов: %w", err)
+	}
+	return newerReleases(releases, Version), nil
+}
+
+// newerReleases выбирает релизы новее current; nil, если таких нет
+func newerReleases(releases []Release, current string) *Update {
+	var newer []Release
+	for _, release := range releases {
+		if !release.Draft && !release.Prerelease && Newer(release.Tag, current) {
+			newer = append(newer, release)
+		}
+	}
+	if len(newer) == 0 {
+		return nil
+	}
+	sort.Slice(newer, func(i, j int) bool { return Newer(newer[i].Tag, newer[j].Tag) })
+
+	update := &Update{Latest: newer[0]}
+	for _, release := range newer {
+		update.Missed = append(update.Missed, release.Tag)
+		for _, line := range configNotes(release.Notes) {
+			update.ConfigNotes = append(update.ConfigNotes, release.Tag+": "+line)
+		}
+	}
+	return update
+}
+
+// configNotes возвращает строки описания релиза об изменениях конфигурации
+func configNotes(notes string) []string {
+	var result []string
+	for _, line := range strings.Split(notes, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*"))
+		lower := strings.ToLower(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, keyword := range configKeywords {
+			if strings.Contains(lower, keyword) {
+				result = append(result, line)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Newer сообщает, что версия a новее b. Версии сравниваются по числам vX.Y.Z,
+// суффиксы (-rc1) отбрасываются; версии не в этом формате не считаются новее.
+func Newer(a, b string) bool {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i]
+		}
+	}
+	return false
+}
+
+// parseVersion разбирает vX.Y.Z (v и недостающие части необязательны)
+func parseVersion(value string) ([3]int, bool) {
+	var result [3]int
+	value = strings.TrimPrefix(value, "v")
+	if i := strings.IndexAny(value, "-+"); i >= 0 {
+		value = value[:i]
+	}
+	parts := strings.Split(value, ".")
+	if value == "" || len(parts) > 3 {
+		return result, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return result, false
+		}
+		result[i] = n
+	}
+	return result, true
+}
@@ -0,0 +1,70 @@
+// Package version содержит сведения о сборке bfma. Значения задаются при сборке:
+//
+//	go build -ldflags "-X github.com/skalibog/bfma/internal/version.Version=v1.4.0 \
+//	  -X github.com/skalibog/bfma/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/skalibog/bfma/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/bfma
+//
+// Без -ldflags коммит и дата берутся из сведений о сборке Go (go build в рабочей копии git).
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Значения, задаваемые через -ldflags -X
+var (
+	Version   = "dev" // Версия релиза (тег git, например v1.4.0)
+	Commit    = ""    // Сокращенный хеш коммита
+	BuildDate = ""    // Время сборки в RFC 3339
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	// go install github.com/skalibog/bfma/cmd/bfma@v1.4.0 записывает версию модуля.
+	// Псевдоверсии сборок из рабочей копии (v0.0.0-20240501...-abc+dirty) не считаются релизом.
+	if main := info.Main.Version; Version == "dev" && strings.HasPrefix(main, "v") && !strings.ContainsAny(main, "-+") {
+		Version = main
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if Commit == "" {
+				Commit = setting.Value
+				if len(Commit) > 7 {
+					Commit = Commit[:7]
+				}
+			}
+		case "vcs.time":
+			if BuildDate == "" {
+				BuildDate = setting.Value
+			}
+		}
+	}
+}
+
+// Short возвращает версию для строки состояния интерфейса: v1.4.0 или dev-abc1234
+func Short() string {
+	if Version == "dev" && Commit != "" {
+		return Version + "-" + Commit
+	}
+	return Version
+}
+
+// String возвращает полные сведения о сборке для bfma version
+func String() string {
+	commit, date := Commit, BuildDate
+	if commit == "" {
+		commit = "неизвестен"
+	}
+	if date == "" {
+		date = "неизвестна"
+	}
+	return fmt.Sprintf("bfma %s (коммит %s, сборка %s, %s %s/%s)",
+		Version, commit, date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
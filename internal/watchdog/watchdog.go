@@ -0,0 +1,171 @@
+// Package watchdog следит за бюджетом ошибок подсистем (хранилище, анализ, потоки
+// WebSocket, запросы к бирже). Подсистема, у которой ошибок за окно больше бюджета,
+// переводится в режим деградации: он виден в строке состояния и в /api/v1/health.
+// Для подсистем с известным способом восстановления (переподключение потоков,
+// чтение из кэша) watchdog пробует восстановить их сам.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/events"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию
+const (
+	defaultCheckInterval = 15 * time.Second
+	defaultWindow        = 5 * time.Minute
+	defaultCooldown      = 2 * time.Minute
+	defaultStorage       = 10
+	defaultAnalysis      = 20
+	defaultWebSocket     = 3
+	defaultExchange      = 10
+)
+
+// Подсистемы в порядке проверки
+var subsystems = []string{health.SubsystemStorage, health.SubsystemAnalysis, health.SubsystemWebSocket, health.SubsystemExchange}
+
+// Remedy способ восстановления подсистемы
+type Remedy struct {
+	Apply   func(ctx context.Context) error // Вызывается при деградации, не чаще cooldown
+	Restore func()                          // Вызывается при снятии деградации; может быть nil
+}
+
+// Watchdog периодически сравнивает число ошибок подсистем с бюджетом
+type Watchdog struct {
+	cfg      config.WatchdogConfig
+	remedies map[string]Remedy
+	degraded map[string]bool      // Подсистемы в режиме деградации
+	applied  map[string]time.Time // Подсистема -> время последней попытки восстановления
+}
+
+// NewWatchdog создает наблюдение за бюджетом ошибок
+func NewWatchdog(cfg config.WatchdogConfig) *Watchdog {
+	return &Watchdog{
+		cfg:      cfg,
+		remedies: make(map[string]Remedy),
+		degraded: make(map[string]bool),
+		applied:  make(map[string]time.Time),
+	}
+}
+
+// SetRemedy задает способ восстановления подсистемы. Вызывается до Start.
+func (w *Watchdog) SetRemedy(subsystem string, remedy Remedy) {
+	w.remedies[subsystem] = remedy
+}
+
+// Start проверяет бюджет до отмены контекста
+func (w *Watchdog) Start(ctx context.Context) {
+	interval := orDefault(w.cfg.CheckInterval, defaultCheckInterval)
+	logger.Info("Запуск контроля бюджета ошибок", zap.Duration("interval", interval),
+		zap.Duration("window", orDefault(w.cfg.Window, defaultWindow)), zap.Bool("remediate", w.cfg.Remediate))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check(ctx, time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check переводит подсистемы в режим деградации и обратно
+func (w *Watchdog) check(ctx context.Context, now time.Time) {
+	window := orDefault(w.cfg.Window, defaultWindow)
+	for _, subsystem := range subsystems {
+		failures := health.Failures(subsystem, now.Add(-window))
+		budget := w.budget(subsystem)
+
+		if failures <= budget {
+			if w.degraded[subsystem] {
+				w.recover(subsystem, failures)
+			}
+			continue
+		}
+
+		reason := fmt.Sprintf("ошибок за %s: %d при бюджете %d", window, failures, budget)
+		health.SetDegraded(subsystem, reason)
+		if !w.degraded[subsystem] {
+			w.degraded[subsystem] = true
+			logger.Warn("Подсистема в режиме деградации", zap.String("subsystem", subsystem),
+				zap.Int("failures", failures), zap.Int("budget", budget))
+			events.Record(events.TypeHealth, "", "подсистема в режиме деградации", map[string]string{
+				"subsystem": subsystem,
+				"failures":  strconv.Itoa(failures),
+				"budget":    strconv.Itoa(budget),
+			})
+		}
+		w.remediate(ctx, subsystem, now)
+	}
+}
+
+// recover снимает режим деградации подсистемы
+func (w *Watchdog) recover(subsystem string, failures int) {
+	delete(w.degraded, subsystem)
+	delete(w.applied, subsystem)
+	health.ClearDegraded(subsystem)
+	if remedy, ok := w.remedies[subsystem]; ok && remedy.Restore != nil {
+		remedy.Restore()
+	}
+
+	logger.Info("Подсистема восстановлена", zap.String("subsystem", subsystem), zap.Int("failures", failures))
+	events.Record(events.TypeHealth, "", "подсистема восстановлена", map[string]string{"subsystem": subsystem})
+}
+
+// remediate пробует восстановить подсистему, если прошло не меньше cooldown с прошлой попытки
+func (w *Watchdog) remediate(ctx context.Context, subsystem string, now time.Time) {
+	remedy, ok := w.remedies[subsystem]
+	if !w.cfg.Remediate || !ok || remedy.Apply == nil {
+		return
+	}
+	if last, ok := w.applied[subsystem]; ok && now.Sub(last) < orDefault(w.cfg.Cooldown, defaultCooldown) {
+		return
+	}
+	w.applied[subsystem] = now
+
+	logger.Info("Восстановление подсистемы", zap.String("subsystem", subsystem))
+	fields := map[string]string{"subsystem": subsystem}
+	if err := remedy.Apply(ctx); err != nil {
+		logger.Error("Ошибка восстановления подсистемы", zap.String("subsystem", subsystem), zap.Error(err))
+		fields["error"] = err.Error()
+	}
+	events.Record(events.TypeHealth, "", "восстановление подсистемы", fields)
+}
+
+// budget возвращает бюджет ошибок подсистемы из настроек или по умолчанию
+func (w *Watchdog) budget(subsystem string) int {
+	switch subsystem {
+	case health.SubsystemStorage:
+		return orDefaultInt(w.cfg.Budgets.Storage, defaultStorage)
+	case health.SubsystemAnalysis:
+		return orDefaultInt(w.cfg.Budgets.Analysis, defaultAnalysis)
+	case health.SubsystemWebSocket:
+		return orDefaultInt(w.cfg.Budgets.WebSocket, defaultWebSocket)
+	default:
+		return orDefaultInt(w.cfg.Budgets.Exchange, defaultExchange)
+	}
+}
+
+func orDefault(value config.Duration, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value.Std()
+	}
+	return fallback
+}
+
+func orDefaultInt(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
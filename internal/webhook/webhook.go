@@ -0,0 +1,349 @@
+// Package webhook отправляет сигналы и оповещения POST-запросами на внешние адреса:
+// тело по шаблону, подпись HMAC-SHA256, повторы с экспоненциальной паузой и журнал
+// недоставленных событий.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/schema"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Параметры доставки
+const (
+	queueSize         = 256              // Событий в очереди вебхука; при переполнении новые отбрасываются
+	defaultMaxRetries = 5                // Повторов после неудачной доставки
+	defaultTimeout    = 10 * time.Second // Время на один запрос
+	initialBackoff    = time.Second      // Пауза перед первым повтором, дальше удваивается
+	maxBackoff        = time.Minute      // Наибольшая пауза между повторами
+	maxErrorBody      = 512              // Сколько байт ответа с ошибкой попадает в журнал
+)
+
+// Заголовки запроса
+const (
+	HeaderSignature = "X-BFMA-Signature" // sha256=<hex> от тела запроса
+	HeaderEvent     = "X-BFMA-Event"     // signal или alert
+	HeaderDelivery  = "X-BFMA-Delivery"  // Идентификатор доставки, одинаковый во всех повторах
+)
+
+// Event событие, передаваемое вебхуку. В шаблоне тела доступны его поля:
+// {{.Type}}, {{.Symbol}}, {{.Time}}, {{.Data.Text}}, {{.Data.Recommendation}}.
+type Event struct {
+	Type   string      `json:"type"`
+	Symbol string      `json:"symbol"`
+	Time   time.Time   `json:"time"`
+	Data   interface{} `json:"data"`
+}
+
+// Alert данные события alert
+type Alert struct {
+	Text     string `json:"text"`
+	Critical bool   `json:"critical"`
+}
+
+// DeadLetter запись журнала недоставленных событий
+type DeadLetter struct {
+	Time     time.Time `json:"time"`
+	Webhook  string    `json:"webhook"`
+	Delivery string    `json:"delivery"`
+	Event    string    `json:"event"`
+	Symbol   string    `json:"symbol"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Body     string    `json:"body"`
+}
+
+// Функции, доступные в шаблонах тела
+var templateFuncs = template.FuncMap{
+	// json кодирует значение в JSON, в том числе строку с кавычками и экранированием
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// errPermanent ошибка, после которой повторять запрос бессмысленно
+var errPermanent = errors.New("постоянная ошибка")
+
+// target вебхук с очередью событий
+type target struct {
+	cfg      config.WebhookConfig
+	template *template.Template // nil - событие в JSON
+	client   *http.Client
+	queue    chan Event
+}
+
+// Publisher рассылает события всем вебхукам конфигурации
+type Publisher struct {
+	targets       []*target
+	schemaVersion func() int
+	deadLetter    string // Путь журнала недоставленных событий
+	mutex         sync.Mutex
+}
+
+// NewPublisher создает рассылку. Шаблоны тела разбираются сразу, чтобы ошибка
+// в шаблоне обнаружилась при запуске, а не при первом событии.
+func NewPublisher(webhooks []config.WebhookConfig, deadLetterPath string, schemaVersion func() int) (*Publisher, error) {
+	p := &Publisher{schemaVersion: schemaVersion, deadLetter: deadLetterPath}
+	for _, cfg := range webhooks {
+		t := &target{cfg: cfg, queue: make(chan Event, queueSize)}
+		if cfg.Template != "" {
+			tmpl, err := template.New(cfg.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(cfg.Template)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора шаблона вебхука %s: %w", cfg.Name, err)
+			}
+			t.template = tmpl
+		}
+		timeout := cfg.Timeout.Std()
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+		t.client = &http.Client{Timeout: timeout}
+		p.targets = append(p.targets, t)
+	}
+	return p, nil
+}
+
+// Start доставляет события до отмены контекста; каждый вебхук обслуживается
+// отдельно, чтобы недоступный адрес не задерживал остальные
+func (p *Publisher) Start(ctx context.Context) {
+	logger.Info("Запуск отправки вебхуков", zap.Int("webhooks", len(p.targets)))
+	var wg sync.WaitGroup
+	for _, t := range p.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.deliverLoop(ctx, t)
+		}()
+	}
+	wg.Wait()
+}
+
+// PublishSignals ставит в очередь новые сигналы
+func (p *Publisher) PublishSignals(signals map[string]*models.SignalResult) {
+	for symbol, signal := range signals {
+		data, err := schema.Encode(signal, nil, p.schemaVersion())
+		if err != nil {
+			logger.Warn("Ошибка кодирования сигнала для вебхука", zap.String("symbol", symbol), zap.Error(err))
+			continue
+		}
+		p.publish(Event{Type: config.WebhookSignal, Symbol: symbol, Time: timezone.In(signal.Timestamp), Data: data})
+	}
+}
+
+// PublishAlert ставит в очередь оповещение интерфейса
+func (p *Publisher) PublishAlert(symbol, text string, critical bool) {
+	p.publish(Event{
+		Type:   config.WebhookAlert,
+		Symbol: symbol,
+		Time:   timezone.In(time.Now()),
+		Data:   Alert{Text: text, Critical: critical},
+	})
+}
+
+// publish передает событие вебхукам, которые на него подписаны. Не блокирует:
+// при переполнении очереди событие записывается в журнал недоставленных.
+func (p *Publisher) publish(event Event) {
+	for _, t := range p.targets {
+		if !t.wants(event) {
+			continue
+		}
+		select {
+		case t.queue <- event:
+		default:
+			logger.Warn("Очередь вебхука переполнена, событие пропущено", zap.String("webhook", t.cfg.Name), zap.String("symbol", event.Symbol))
+			p.writeDeadLetter(t, event, "", 0, nil, errors.New("очередь переполнена"))
+		}
+	}
+}
+
+// wants сообщает, подписан ли вебхук на событие
+func (t *target) wants(event Event) bool {
+	if len(t.cfg.Events) > 0 && !slices.Contains(t.cfg.Events, event.Type) {
+		return false
+	}
+	return len(t.cfg.Symbols) == 0 || slices.Contains(t.cfg.Symbols, event.Symbol)
+}
+
+// deliverLoop доставляет события вебхука по порядку
+func (p *Publisher) deliverLoop(ctx context.Context, t *target) {
+	for {
+		select {
+		case event := <-t.queue:
+			p.deliver(ctx, t, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver отправляет событие с повторами; после последней неудачи событие
+// записывается в журнал недоставленных
+func (p *Publisher) deliver(ctx context.Context, t *target, event Event) {
+	body, err := t.render(event)
+	if err != nil {
+		logger.Warn("Ошибка формирования тела вебхука", zap.String("webhook", t.cfg.Name), zap.Error(err))
+		p.writeDeadLetter(t, event, "", 0, nil, err)
+		return
+	}
+
+	delivery := newDeliveryID()
+	retries := t.cfg.MaxRetries
+	if retries == 0 {
+		retries = defaultMaxRetries
+	}
+	backoff := initialBackoff
+
+	attempt := 0
+	for {
+		attempt++
+		err = t.send(ctx, event.Type, delivery, body)
+		if err == nil {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errPermanent) || attempt > retries {
+			break
+		}
+
+		logger.Debug("Повтор доставки вебхука", zap.String("webhook", t.cfg.Name), zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+
+	logger.Warn("Событие не доставлено вебхуку", zap.String("webhook", t.cfg.Name), zap.String("event", event.Type),
+		zap.String("symbol", event.Symbol), zap.Int("attempts", attempt), zap.Error(err))
+	p.writeDeadLetter(t, event, delivery, attempt, body, err)
+}
+
+// render формирует тело запроса по шаблону или в JSON
+func (t *target) render(event Event) ([]byte, error) {
+	if t.template == nil {
+		return json.Marshal(event)
+	}
+	var buf bytes.Buffer
+	if err := t.template.Execute(&buf, event); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// send выполняет один запрос. Ответы 2xx - доставлено; 408, 429 и 5xx
+// повторяются, остальные коды - постоянная ошибка.
+func (t *target) send(ctx context.Context, event, delivery string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bfma-webhook")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, delivery)
+	if t.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(t.cfg.Secret, body))
+	}
+	for name, value := range t.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// Адрес может содержать токен в пути, поэтому в журнал попадает только причина
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	err = fmt.Errorf("ответ %s", resp.Status)
+	if data = bytes.TrimSpace(data); len(data) > 0 {
+		err = fmt.Errorf("ответ %s: %s", resp.Status, data)
+	}
+	if resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return fmt.Errorf("%w: %v", errPermanent, err)
+}
+
+// Sign возвращает подпись тела для заголовка X-BFMA-Signature: sha256=<hex HMAC-SHA256>.
+// Получатель проверяет ее тем же ключом, чтобы отличить запросы bfma от поддельных.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// writeDeadLetter дописывает событие в журнал недоставленных (по одной записи JSON в строке)
+func (p *Publisher) writeDeadLetter(t *target, event Event, delivery string, attempts int, body []byte, cause error) {
+	if body == nil {
+		body, _ = json.Marshal(event)
+	}
+	entry := DeadLetter{
+		Time:     timezone.In(time.Now()),
+		Webhook:  t.cfg.Name,
+		Delivery: delivery,
+		Event:    event.Type,
+		Symbol:   event.Symbol,
+		Attempts: attempts,
+		Error:    cause.Error(),
+		Body:     string(body),
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	file, err := os.OpenFile(p.deadLetter, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		logger.Warn("Ошибка открытия журнала недоставленных событий", zap.Error(err))
+		return
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(entry); err != nil {
+		logger.Warn("Ошибка записи журнала недоставленных событий", zap.Error(err))
+	}
+}
+
+// newDeliveryID возвращает случайный идентификатор доставки
+func newDeliveryID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
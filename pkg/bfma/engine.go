@@ -0,0 +1,275 @@
+// Package bfma встраивает сбор рыночных данных и анализ bfma в другие программы
+// на Go: без терминального интерфейса, уведомлений и разбора флагов командной строки.
+//
+//	engine, err := bfma.NewEngine(bfma.Options{
+//		Symbols: []string{"BTCUSDT"},
+//		Storage: bfma.StorageOptions{Type: "memory"},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	go engine.Start(ctx)
+//	for signal := range engine.Signals() {
+//		fmt.Println(signal.Symbol, signal.RecommendationCode, signal.SignalStrength)
+//	}
+//
+// Журнал пишется через pkg/logger; чтобы писать его по своим настройкам, вызовите
+// logger.Configure до NewEngine.
+package bfma
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/skalibog/bfma/internal/analysis/aggregator"
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/engine"
+	"github.com/skalibog/bfma/internal/exchange"
+	"github.com/skalibog/bfma/internal/health"
+	"github.com/skalibog/bfma/internal/latency"
+	"github.com/skalibog/bfma/internal/storage"
+	"github.com/skalibog/bfma/internal/usage"
+	"github.com/skalibog/bfma/pkg/events"
+	"github.com/skalibog/bfma/pkg/logger"
+	"github.com/skalibog/bfma/pkg/models"
+	"github.com/skalibog/bfma/pkg/timezone"
+	"go.uber.org/zap"
+)
+
+// Сколько сигналов ждет чтения из Signals; при переполнении новые сигналы отбрасываются
+const signalsBuffer = 256
+
+// Engine сборщики данных и агрегатор сигналов символов из trading.symbols,
+// групп и списков наблюдения
+type Engine struct {
+	config     *config.Config
+	store      storage.Storage
+	closeStore func()
+	analyzer   *aggregator.Analyzer
+	collectors *exchange.SymbolCollectors
+	bus        *events.Bus
+	signals    chan models.SignalResult
+	closed     bool // Канал signals закрыт
+	mutex      sync.Mutex
+	started    atomic.Bool
+	dropped    atomic.Int64
+}
+
+// NewEngine создает движок с параметрами opts. Хранилище выбирается по Storage.Type:
+// influxdb (по умолчанию; обязательны Storage.Token и Storage.Organization, см. Options)
+// или memory - данные в памяти процесса, внешние службы не нужны.
+func NewEngine(opts Options) (*Engine, error) {
+	setup := opts.Setup
+	if setup == nil {
+		setup = &engine.Setup{}
+	}
+	cfg := setup.Config
+	if cfg == nil {
+		var err error
+		if cfg, err = opts.config(); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := timezone.Set(cfg.Timezone); err != nil {
+		return nil, err
+	}
+	latency.Configure(cfg.Latency)
+	usage.Configure(cfg.Usage)
+
+	e := &Engine{config: cfg, bus: events.NewBus(setup.OnPanic), signals: make(chan models.SignalResult, signalsBuffer)}
+	var influx *storage.InfluxDBStorage
+	if cfg.Storage.Type == "memory" {
+		memory := storage.NewMemoryStorage()
+		health.SetQueueDepth(memory.PendingWrites)
+		e.store, e.closeStore = memory, memory.Close
+	} else {
+		var err error
+		if influx, err = storage.NewInfluxDBStorage(cfg.Storage); err != nil {
+			return nil, fmt.Errorf("ошибка инициализации хранилища: %w", err)
+		}
+		health.SetQueueDepth(influx.PendingWrites)
+		e.store, e.closeStore = influx, influx.Close
+	}
+
+	client, err := exchange.NewBinanceClient(cfg.Binance)
+	if err != nil {
+		e.closeStore()
+		return nil, fmt.Errorf("ошибка инициализации клиента биржи: %w", err)
+	}
+
+	// Параметры символов: точность цены и объема, статус торгов. Снятые с торгов
+	// и неизвестные бирже символы исключаются из анализа.
+	symbolDirectory := exchange.NewSymbolDirectory(client, e.store)
+	if err := symbolDirectory.Load(context.Background()); err != nil {
+		logger.Warn("Сохраненные параметры символов не загружены", zap.Error(err))
+	}
+	if err := symbolDirectory.Refresh(context.Background()); err != nil {
+		logger.Warn("Ошибка обновления параметров символов, используются сохраненные", zap.Error(err))
+	}
+
+	// При сбоях хранилища анализ продолжается на последних прочитанных данных
+	cache := storage.NewFallbackStorage(e.store)
+	e.analyzer = aggregator.NewAnalyzer(cfg.Analysis, cache, client, symbolDirectory.Tradable(cfg.TrackedSymbols()), setup.Pauses)
+	if len(cfg.Groups) > 0 {
+		e.analyzer.UpdateGroups(cfg.Groups)
+	}
+	e.analyzer.SetEventBus(e.bus)
+	// Закрытые свечи сборщики ведут в памяти, анализаторы читают их без обращения к хранилищу
+	candleSeries := models.NewCandleSeriesSet()
+	e.analyzer.SetCandleSeries(candleSeries)
+	e.analyzer.SetFundingIntervals(symbolDirectory.FundingInterval)
+	if opts.Setup == nil {
+		events.Subscribe(e.bus, "engine", e.publish)
+	}
+
+	// Сборщики новых символов создаются по действующей конфигурации
+	current := setup.Current
+	if current == nil {
+		current = func() *config.Config { return cfg }
+	}
+	paused := func(string) bool { return false }
+	if setup.Pauses != nil {
+		paused = setup.Pauses.IsPaused
+	}
+	e.collectors = exchange.NewSymbolCollectors(func(symbol string) []exchange.DataCollector {
+		cfg := current()
+		symbols := []string{symbol}
+		candles := exchange.NewCandleCollector(client, e.store, symbols, cfg.IntervalFor(symbol))
+		candles.SetCandleSeries(candleSeries)
+		collectors := []exchange.DataCollector{
+			candles,
+			exchange.NewOrderBookCollector(client, e.store, symbols, cfg.AnalysisFor(symbol).OrderBook.Depth),
+			exchange.NewFundingRateCollector(client, e.store, symbols),
+			exchange.NewOpenInterestCollector(client, e.store, symbols),
+		}
+		if setup.ExtraCollectors != nil {
+			collectors = append(collectors, setup.ExtraCollectors(symbol)...)
+		}
+		return collectors
+	}, paused)
+	e.collectors.SetEventBus(e.bus)
+
+	setup.Parts = engine.Parts{
+		Store:        influx,
+		Cache:        cache,
+		Client:       client,
+		Symbols:      symbolDirectory,
+		Analyzer:     e.analyzer,
+		Collectors:   e.collectors,
+		CandleSeries: candleSeries,
+		Bus:          e.bus,
+	}
+	return e, nil
+}
+
+// Start запускает сборщики данных и расчет сигналов и блокируется до отмены контекста.
+// После возврата сборщики остановлены, хранилище закрыто, канал Signals закрыт;
+// движок нельзя запустить повторно.
+func (e *Engine) Start(ctx context.Context) error {
+	if !e.started.CompareAndSwap(false, true) {
+		return errors.New("движок уже запущен")
+	}
+	defer func() {
+		e.collectors.StopAll()
+		e.closeStore()
+
+		e.mutex.Lock()
+		e.closed = true
+		close(e.signals)
+		e.mutex.Unlock()
+	}()
+
+	for _, symbol := range e.analyzer.Symbols() {
+		if err := e.collectors.Add(ctx, symbol); err != nil {
+			logger.Error("Ошибка запуска сборщиков данных", zap.String("symbol", symbol), zap.Error(err))
+		}
+	}
+
+	active := func() bool { return e.config.Schedule.SignalsActive(timezone.Now()) }
+	e.analyzer.Run(ctx, e.config.Analysis.Period.Std(), nil, active)
+	return nil
+}
+
+// publish передает рассчитанные сигналы в канал Signals, не дожидаясь читателя
+func (e *Engine) publish(event events.SignalChanged) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return
+	}
+	for _, signal := range event.Signals {
+		select {
+		case e.signals <- *signal:
+		default:
+			if e.dropped.Add(1) == 1 {
+				logger.Warn("Канал сигналов переполнен, сигналы отбрасываются", zap.Int("buffer", signalsBuffer))
+			}
+		}
+	}
+	latency.MarkEmitted(slices.Collect(maps.Keys(event.Signals)))
+}
+
+// Signals возвращает канал рассчитанных сигналов. Канал общий для всех читателей
+// и закрывается после остановки движка. Если сигналы не читаются, после заполнения
+// буфера новые сигналы отбрасываются, а расчет не останавливается.
+func (e *Engine) Signals() <-chan models.SignalResult {
+	return e.signals
+}
+
+// Events возвращает шину событий движка: закрытые свечи, обновления стакана и ставок
+// финансирования, сигналы циклов анализа и ошибки сборщиков.
+//
+//	events.Subscribe(engine.Events(), "my-app", func(e events.CandleClosed) { ... })
+func (e *Engine) Events() *events.Bus {
+	return e.bus
+}
+
+// Latest возвращает последние сигналы отслеживаемых символов
+func (e *Engine) Latest() map[string]*models.SignalResult {
+	return e.analyzer.LatestSignals()
+}
+
+// MarketStates возвращает снимки рынка, по которым рассчитаны последние сигналы
+func (e *Engine) MarketStates() map[string]*models.MarketState {
+	return e.analyzer.MarketStates()
+}
+
+// History возвращает до limit последних сохраненных сигналов символа, новые первыми
+func (e *Engine) History(ctx context.Context, symbol string, limit int) ([]*models.SignalResult, error) {
+	return e.analyzer.GetSignalHistory(ctx, symbol, limit)
+}
+
+// Query возвращает страницу сохраненных сигналов по условиям query; следующая
+// страница запрашивается с Cursor = NextCursor предыдущей
+func (e *Engine) Query(ctx context.Context, query models.SignalQuery) (*models.SignalPage, error) {
+	return e.analyzer.QuerySignals(ctx, query)
+}
+
+// Symbols возвращает отслеживаемые символы
+func (e *Engine) Symbols() []string {
+	return e.analyzer.Symbols()
+}
+
+// AddSymbol начинает сбор данных и расчет сигналов символа во время работы
+func (e *Engine) AddSymbol(ctx context.Context, symbol string) error {
+	if err := e.collectors.Add(ctx, symbol); err != nil {
+		return err
+	}
+	e.analyzer.AddSymbol(symbol)
+	return nil
+}
+
+// RemoveSymbol прекращает сбор данных и расчет сигналов символа
+func (e *Engine) RemoveSymbol(symbol string) {
+	e.analyzer.RemoveSymbol(symbol)
+	e.collectors.Remove(symbol)
+}
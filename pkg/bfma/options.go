@@ -0,0 +1,102 @@
+package bfma
+
+import (
+	"time"
+
+	"github.com/skalibog/bfma/internal/config"
+	"github.com/skalibog/bfma/internal/engine"
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Options параметры движка. Они накладываются на конфигурацию по умолчанию или на
+// файл ConfigFile; нулевые значения полей ее не меняют. Остальные параметры анализа
+// (веса, пороги, группы символов) задаются в ConfigFile в формате config.yaml.
+//
+// Конфигурация по умолчанию хранит данные в InfluxDB, поэтому нулевые Options не
+// проходят проверку: задайте Storage.Type = "memory" или Storage.Token и
+// Storage.Organization - в Options или в ConfigFile (к нему применяются и переменные
+// BFMA_STORAGE_TOKEN и BFMA_STORAGE_ORGANIZATION).
+type Options struct {
+	// Файл или каталог конфигурации, как у bfma run --config, с переопределениями из
+	// переменных окружения BFMA_*; пусто - конфигурация по умолчанию
+	ConfigFile string
+
+	Symbols        []string        // Символы; пусто - trading.symbols
+	Interval       models.Interval // Интервал свечей; пусто - trading.interval
+	AnalysisPeriod time.Duration   // Период расчета сигналов; 0 - analysis.period
+	Timezone       string          // Часовой пояс: local, UTC или имя IANA; пусто - timezone
+
+	Storage StorageOptions
+	Binance BinanceOptions
+
+	// Подключение bfma run (cmd/bfma); тип из внутреннего пакета, внешним программам
+	// недоступен и остается nil
+	Setup *engine.Setup
+}
+
+// StorageOptions хранилище данных движка
+type StorageOptions struct {
+	Type         string // influxdb или memory (данные в памяти процесса); пусто - storage.type
+	URL          string // Адрес InfluxDB; пусто - storage.url (http://localhost:8086)
+	Token        string // Токен InfluxDB; обязателен для influxdb
+	Organization string // Организация InfluxDB; обязательна для influxdb
+	Bucket       string // Бакет InfluxDB; пусто - storage.bucket (bfma)
+}
+
+// BinanceOptions доступ к бирже. Для публичных данных ключи не нужны.
+type BinanceOptions struct {
+	APIKey    string
+	APISecret string
+	Testnet   bool // Тестовая сеть биржи; false не отключает testnet из ConfigFile
+}
+
+// config возвращает внутреннюю конфигурацию движка по параметрам
+func (o Options) config() (*config.Config, error) {
+	cfg := config.Default()
+	if o.ConfigFile != "" {
+		var err error
+		if cfg, err = config.Load(o.ConfigFile, config.LoadOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(o.Symbols) > 0 {
+		cfg.Trading.Symbols = append([]string(nil), o.Symbols...)
+	}
+	if o.Interval != "" {
+		cfg.Trading.Interval = o.Interval
+	}
+	if o.AnalysisPeriod > 0 {
+		cfg.Analysis.Period = config.Duration(o.AnalysisPeriod)
+	}
+	if o.Timezone != "" {
+		cfg.Timezone = o.Timezone
+	}
+
+	if o.Storage.Type != "" {
+		cfg.Storage.Type = o.Storage.Type
+	}
+	if o.Storage.URL != "" {
+		cfg.Storage.URL = o.Storage.URL
+	}
+	if o.Storage.Token != "" {
+		cfg.Storage.Token = o.Storage.Token
+	}
+	if o.Storage.Organization != "" {
+		cfg.Storage.Organization = o.Storage.Organization
+	}
+	if o.Storage.Bucket != "" {
+		cfg.Storage.Bucket = o.Storage.Bucket
+	}
+
+	if o.Binance.APIKey != "" {
+		cfg.Binance.APIKey = o.Binance.APIKey
+	}
+	if o.Binance.APISecret != "" {
+		cfg.Binance.APISecret = o.Binance.APISecret
+	}
+	if o.Binance.Testnet {
+		cfg.Binance.Testnet = true
+	}
+	return cfg, nil
+}
@@ -0,0 +1,48 @@
+// Package clock отделяет компоненты от системного времени: сборщики данных,
+// цикл анализа и агрегатор получают время и таймеры через Clock, поэтому
+// воспроизведение истории и тесты могут управлять временем сами.
+package clock
+
+import "time"
+
+// Clock источник времени и таймеров
+type Clock interface {
+	Now() time.Time
+	// NewTicker создает периодический таймер, как time.NewTicker
+	NewTicker(d time.Duration) Ticker
+	// After возвращает канал, в который придет время через d, как time.After
+	After(d time.Duration) <-chan time.Time
+}
+
+// Ticker периодический таймер
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// Real системные часы
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// realTicker обертка над time.Ticker
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,151 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Simulated часы, время которых меняется только вызовами Advance и Set. Таймеры
+// срабатывают, когда время доходит до их срока; как и у time.Ticker, пропущенные
+// срабатывания не накапливаются, если получатель не успевает читать канал.
+type Simulated struct {
+	now    time.Time
+	timers []*simulatedTimer
+	mutex  sync.Mutex
+}
+
+// simulatedTimer таймер или периодический таймер симулированных часов
+type simulatedTimer struct {
+	clock  *Simulated
+	c      chan time.Time
+	next   time.Time     // Время следующего срабатывания
+	period time.Duration // Период; 0 - однократный таймер
+	active bool
+}
+
+// NewSimulated создает симулированные часы, показывающие start
+func NewSimulated(start time.Time) *Simulated {
+	return &Simulated{now: start}
+}
+
+// Now возвращает текущее симулированное время
+func (s *Simulated) Now() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.now
+}
+
+// NewTicker создает периодический таймер с первым срабатыванием через d
+func (s *Simulated) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: период таймера должен быть положительным")
+	}
+	return s.add(d, d)
+}
+
+// After возвращает канал, в который придет время через d
+func (s *Simulated) After(d time.Duration) <-chan time.Time {
+	return s.add(d, 0).c
+}
+
+// add регистрирует таймер
+func (s *Simulated) add(d, period time.Duration) *simulatedTimer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t := &simulatedTimer{
+		clock:  s,
+		c:      make(chan time.Time, 1),
+		next:   s.now.Add(d),
+		period: period,
+		active: true,
+	}
+	s.timers = append(s.timers, t)
+	if d <= 0 {
+		s.fire()
+	}
+	return t
+}
+
+// Advance сдвигает время вперед на d и запускает наступившие таймеры
+func (s *Simulated) Advance(d time.Duration) {
+	s.Set(s.Now().Add(d))
+}
+
+// Set устанавливает время и запускает наступившие таймеры. Время не идет назад.
+func (s *Simulated) Set(t time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if t.After(s.now) {
+		s.now = t
+	}
+	s.fire()
+}
+
+// fire отправляет время в каналы наступивших таймеров; вызывается под mutex
+func (s *Simulated) fire() {
+	active := s.timers[:0]
+	for _, t := range s.timers {
+		if t.active && !t.next.After(s.now) {
+			select {
+			case t.c <- s.now:
+			default:
+			}
+			if t.period > 0 {
+				// Следующий срок после текущего времени: пропущенные срабатывания отбрасываются
+				for !t.next.After(s.now) {
+					t.next = t.next.Add(t.period)
+				}
+			} else {
+				t.active = false
+			}
+		}
+		if t.active {
+			active = append(active, t)
+		}
+	}
+	s.timers = active
+}
+
+// Timers возвращает число активных таймеров; удобно, чтобы дождаться, пока
+// проверяемый код создаст таймер, прежде чем сдвигать время
+func (s *Simulated) Timers() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.timers)
+}
+
+func (t *simulatedTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *simulatedTimer) Stop() {
+	s := t.clock
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t.active = false
+	for i, other := range s.timers {
+		if other == t {
+			s.timers = append(s.timers[:i], s.timers[i+1:]...)
+			break
+		}
+	}
+}
+
+func (t *simulatedTimer) Reset(d time.Duration) {
+	s := t.clock
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if d <= 0 {
+		panic("clock: период таймера должен быть положительным")
+	}
+	if !t.active {
+		s.timers = append(s.timers, t)
+	}
+	t.period = d
+	t.next = s.now.Add(d)
+	t.active = true
+}
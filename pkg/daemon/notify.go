@@ -0,0 +1,82 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Сообщения протокола sd_notify
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	watchdog = "WATCHDOG=1"
+)
+
+// Notify отправляет состояние менеджеру служб systemd через NOTIFY_SOCKET.
+// Без NOTIFY_SOCKET (запуск не из systemd) ничего не делает.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// Абстрактный сокет Linux обозначается @ в начале имени
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к NOTIFY_SOCKET: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("ошибка отправки уведомления systemd: %w", err)
+	}
+	return nil
+}
+
+// Status возвращает сообщение sd_notify с текстом состояния службы
+func Status(text string) string {
+	return "STATUS=" + text
+}
+
+// WatchdogInterval возвращает период сторожевого таймера из WATCHDOG_USEC
+// (WatchdogSec в unit-файле) или 0, если он не включен для этого процесса
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// RunWatchdog отправляет WATCHDOG=1 с половинным периодом сторожевого таймера,
+// пока alive сообщает, что приложение работает, и не отменен ctx. Если alive
+// вернул false, уведомления прекращаются и systemd перезапускает службу.
+func RunWatchdog(ctx context.Context, alive func() bool) {
+	interval := WatchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if alive() {
+				Notify(watchdog)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// WritePIDFile записывает PID процесса в файл. Если в файле PID другого
+// работающего процесса, возвращает ошибку, чтобы не запустить второй экземпляр.
+// Возвращает функцию удаления файла при завершении.
+func WritePIDFile(path string) (func(), error) {
+	if data, err := os.ReadFile(path); err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && pid != os.Getpid() && processAlive(pid) {
+			return nil, fmt.Errorf("процесс уже запущен: PID %d из %s", pid, path)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("ошибка записи PID-файла: %w", err)
+	}
+	return func() { os.Remove(path) }, nil
+}
+
+// processAlive проверяет, существует ли процесс с указанным PID
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
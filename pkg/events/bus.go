@@ -0,0 +1,120 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/skalibog/bfma/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Сколько событий ждет обработки у одного подписчика
+const queueSize = 256
+
+// Bus шина событий. Publish не блокирует источник: у каждого подписчика своя очередь
+// и горутина, при переполнении очереди событие для этого подписчика отбрасывается.
+// Методы nil-шины ничего не делают, поэтому источникам без шины проверки не нужны.
+type Bus struct {
+	mutex       sync.RWMutex
+	subscribers map[string][]*subscriber // Ключ - вид события
+	onPanic     func()                   // Вызывается через defer в горутине подписчика; nil - паника не перехватывается
+	dropped     atomic.Int64
+}
+
+// subscriber очередь и обработчик одного подписчика
+type subscriber struct {
+	name    string
+	queue   chan Event
+	handle  func(Event)
+	onPanic func()
+	dropped atomic.Int64
+}
+
+// NewBus создает шину без подписчиков. onPanic вызывается через defer в горутине
+// каждого подписчика и сам вызывает recover, например записывает отчет о падении;
+// nil - паника обработчика завершает процесс как обычно.
+func NewBus(onPanic func()) *Bus {
+	return &Bus{subscribers: make(map[string][]*subscriber), onPanic: onPanic}
+}
+
+// Subscribe подписывает handler на события типа E; name называет подписчика в журнале.
+// События одного подписчика обрабатываются по очереди в порядке публикации. Возвращает
+// функцию отписки; после нее очередь дообрабатывается и горутина подписчика завершается.
+func Subscribe[E Event](b *Bus, name string, handler func(E)) (unsubscribe func()) {
+	if b == nil {
+		return func() {}
+	}
+
+	var zero E
+	kind := zero.Kind()
+	s := &subscriber{
+		name:    name,
+		queue:   make(chan Event, queueSize),
+		handle:  func(event Event) { handler(event.(E)) },
+		onPanic: b.onPanic,
+	}
+
+	b.mutex.Lock()
+	b.subscribers[kind] = append(b.subscribers[kind], s)
+	b.mutex.Unlock()
+
+	go s.run()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+
+			subscribers := b.subscribers[kind]
+			for i, existing := range subscribers {
+				if existing == s {
+					b.subscribers[kind] = append(subscribers[:i:i], subscribers[i+1:]...)
+					break
+				}
+			}
+			close(s.queue)
+		})
+	}
+}
+
+// run обрабатывает события подписчика до закрытия очереди
+func (s *subscriber) run() {
+	if s.onPanic != nil {
+		defer s.onPanic()
+	}
+
+	for event := range s.queue {
+		s.handle(event)
+	}
+}
+
+// Publish передает событие подписчикам его вида
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, s := range b.subscribers[event.Kind()] {
+		select {
+		case s.queue <- event:
+		default:
+			b.dropped.Add(1)
+			if s.dropped.Add(1) == 1 {
+				logger.Warn("Очередь подписчика шины событий переполнена, события отбрасываются",
+					zap.String("subscriber", s.name), zap.String("kind", event.Kind()))
+			}
+		}
+	}
+}
+
+// Dropped возвращает, сколько событий отброшено из-за переполнения очередей подписчиков
+func (b *Bus) Dropped() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.dropped.Load()
+}
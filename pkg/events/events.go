@@ -0,0 +1,104 @@
+// Package events связывает части bfma через шину событий: сборщики данных сообщают
+// о закрытых свечах, обновлениях стакана и ставок финансирования, агрегатор - о новых
+// сигналах, интерфейс - об оповещениях. Подписчики (интерфейс, уведомления, публикации
+// во внешние системы) получают события в своих горутинах и не задерживают источник.
+//
+// Журнал событий для API администрирования ведет internal/events; эта шина ничего
+// не сохраняет.
+package events
+
+import (
+	"time"
+
+	"github.com/skalibog/bfma/pkg/models"
+)
+
+// Виды событий
+const (
+	KindCandleClosed   = "candle_closed"
+	KindBookUpdated    = "book_updated"
+	KindFundingUpdated = "funding_updated"
+	KindSignalChanged  = "signal_changed"
+	KindAlertFired     = "alert_fired"
+	KindCollectorError = "collector_error"
+)
+
+// Event событие шины
+type Event interface {
+	Kind() string
+}
+
+// CandleClosed свеча закрыта и сохранена
+type CandleClosed struct {
+	Candle *models.Candle
+}
+
+// Kind возвращает вид события
+func (CandleClosed) Kind() string { return KindCandleClosed }
+
+// BookUpdated стакан обновлен и сохранен
+type BookUpdated struct {
+	OrderBook *models.OrderBook
+}
+
+// Kind возвращает вид события
+func (BookUpdated) Kind() string { return KindBookUpdated }
+
+// FundingUpdated получена и сохранена ставка финансирования
+type FundingUpdated struct {
+	Rate *models.FundingRate
+}
+
+// Kind возвращает вид события
+func (FundingUpdated) Kind() string { return KindFundingUpdated }
+
+// SignalChanged сигналы цикла анализа. Previous - сигналы тех же символов из прошлого
+// цикла; символа нет, если сигнал рассчитан впервые. Подписчики получают одни и те же
+// карты и не должны их менять.
+type SignalChanged struct {
+	Signals  map[string]*models.SignalResult
+	Previous map[string]*models.SignalResult
+}
+
+// Kind возвращает вид события
+func (SignalChanged) Kind() string { return KindSignalChanged }
+
+// Changed сообщает, что рекомендация символа изменилась или рассчитана впервые
+func (e SignalChanged) Changed(symbol string) bool {
+	signal, ok := e.Signals[symbol]
+	if !ok {
+		return false
+	}
+	previous, ok := e.Previous[symbol]
+	return !ok || previous.RecommendationCode != signal.RecommendationCode
+}
+
+// Виды оповещений AlertFired; у остальных оповещений вид пустой
+const (
+	AlertSignalChange     = "signal_change"     // Смена рекомендации символа
+	AlertPositionConflict = "position_conflict" // Сильный сигнал против открытой позиции
+	AlertConfig           = "config"            // Изменения конфигурации, требующие перезапуска
+)
+
+// AlertFired оповещение интерфейса для внешних каналов
+type AlertFired struct {
+	Type     string // Вид оповещения: Alert*
+	Symbol   string
+	Text     string
+	Critical bool
+	Time     time.Time
+}
+
+// Kind возвращает вид события
+func (AlertFired) Kind() string { return KindAlertFired }
+
+// CollectorError ошибка сборщика данных: обрыв потока WebSocket или сбой запроса
+type CollectorError struct {
+	Collector string // Поток данных: health.Stream*
+	Symbol    string // Пусто - ошибка общего потока нескольких символов
+	Err       error
+	Time      time.Time
+}
+
+// Kind возвращает вид события
+func (CollectorError) Kind() string { return KindCollectorError }
@@ -0,0 +1,54 @@
+package logger
+
+// Форматы читаемого журнала
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Off отключает вывод в файл
+const Off = "off"
+
+// Значения по умолчанию
+const (
+	defaultLevel    = "debug"
+	defaultFile     = "app.log"
+	defaultJSONFile = "app.json.log"
+	defaultMaxSize  = 100 // МБ
+)
+
+// Config настройки логирования
+type Config struct {
+	Level       string            `yaml:"level"`            // debug, info, warn или error
+	Levels      map[string]string `yaml:"levels,omitempty"` // Уровни компонентов: exchange: debug, ui: warn
+	Format      string            `yaml:"format"`           // Формат читаемого журнала и stdout: console или json
+	File        string            `yaml:"file"`             // Читаемый журнал (по умолчанию app.log, off - отключить)
+	JSONFile    string            `yaml:"json_file"`        // JSON-журнал для панели логов UI (по умолчанию app.json.log)
+	Stdout      bool              `yaml:"stdout"`           // Дублировать журнал в stdout (для --plain и запуска без TUI)
+	Stderr      bool              `yaml:"stderr"`           // Дублировать журнал в stderr (для --headless, где stdout занят записями JSON)
+	MaxSizeMB   int               `yaml:"max_size_mb"`      // Размер файла, после которого он уходит в архив (по умолчанию 100)
+	RotateHours int               `yaml:"rotate_hours"`     // Через сколько часов файл уходит в архив независимо от размера (0 - только по размеру)
+	MaxAgeDays  int               `yaml:"max_age_days"`     // Сколько дней хранить архивы (0 - не ограничено)
+	MaxBackups  int               `yaml:"max_backups"`      // Сколько архивов хранить (0 - не ограничено)
+	Compress    bool              `yaml:"compress"`         // Сжимать архивы gzip
+}
+
+// withDefaults подставляет значения по умолчанию для незаданных параметров
+func (c Config) withDefaults() Config {
+	if c.Level == "" {
+		c.Level = defaultLevel
+	}
+	if c.Format == "" {
+		c.Format = FormatConsole
+	}
+	if c.File == "" {
+		c.File = defaultFile
+	}
+	if c.JSONFile == "" {
+		c.JSONFile = defaultJSONFile
+	}
+	if c.MaxSizeMB == 0 {
+		c.MaxSizeMB = defaultMaxSize
+	}
+	return c
+}
@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Уровни логирования по компонентам. Компонент - пакет, из которого идет запись: путь
+// пакета внутри модуля без internal/ и pkg/ через точку (exchange, ui, analysis.technical),
+// для cmd/bfma - main. Уровень компонента действует и на вложенные пакеты: analysis задает
+// уровень analysis.technical, если у того нет своего.
+
+// Путь модуля, от которого отсчитываются имена компонентов
+const modulePath = "github.com/skalibog/bfma/"
+
+// levelSet действующие уровни
+type levelSet struct {
+	global     zapcore.Level
+	components map[string]zapcore.Level
+}
+
+var (
+	// Наименьший уровень из всех действующих: его проверяют ядра zap, остальное
+	// отсекается по компоненту в enabled
+	baseLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	levels    atomic.Pointer[levelSet]
+	forced    atomic.Bool // Отладочный уровень для всех компонентов (SIGUSR2)
+	levelsMu  sync.Mutex  // Изменение уровней
+	callers   sync.Map    // Адрес вызова -> компонент
+)
+
+func init() {
+	levels.Store(&levelSet{global: zapcore.DebugLevel})
+}
+
+// parseLevels разбирает общий уровень (пустой - по умолчанию) и уровни компонентов
+func parseLevels(level string, components map[string]string) (*levelSet, error) {
+	if level == "" {
+		level = defaultLevel
+	}
+	global, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестный уровень логирования %q: %w", level, err)
+	}
+	set := &levelSet{global: global, components: make(map[string]zapcore.Level, len(components))}
+	for component, value := range components {
+		parsed, err := zapcore.ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("неизвестный уровень логирования %q компонента %s: %w", value, component, err)
+		}
+		set.components[component] = parsed
+	}
+	return set, nil
+}
+
+// SetLevels заменяет общий уровень (пустой - debug) и уровни компонентов
+func SetLevels(level string, components map[string]string) error {
+	set, err := parseLevels(level, components)
+	if err != nil {
+		return err
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	apply(set)
+	return nil
+}
+
+// SetLevel меняет уровень компонента (пустой компонент - общий уровень). Пустой
+// уровень снимает уровень компонента, после чего для него действует общий.
+func SetLevel(component, level string) error {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	current := levels.Load()
+	set := &levelSet{global: current.global, components: maps.Clone(current.components)}
+	if set.components == nil {
+		set.components = make(map[string]zapcore.Level)
+	}
+	switch {
+	case component == "" && level == "":
+		return errors.New("не указан общий уровень логирования")
+	case level == "":
+		delete(set.components, component)
+	default:
+		parsed, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("неизвестный уровень логирования %q: %w", level, err)
+		}
+		if component == "" {
+			set.global = parsed
+		} else {
+			set.components[component] = parsed
+		}
+	}
+	apply(set)
+	return nil
+}
+
+// Levels возвращает общий уровень и уровни компонентов
+func Levels() (level string, components map[string]string) {
+	set := levels.Load()
+	components = make(map[string]string, len(set.components))
+	for component, value := range set.components {
+		components[component] = value.String()
+	}
+	return set.global.String(), components
+}
+
+// ToggleDebug включает отладочный уровень для всех компонентов или возвращает
+// настроенные уровни. Возвращает true, если отладочный уровень включен.
+func ToggleDebug() bool {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	forced.Store(!forced.Load())
+	apply(levels.Load())
+	return forced.Load()
+}
+
+// Debugging сообщает, включен ли отладочный уровень для всех компонентов
+func Debugging() bool {
+	return forced.Load()
+}
+
+// apply делает уровни действующими. Вызывающий должен удерживать levelsMu.
+func apply(set *levelSet) {
+	levels.Store(set)
+
+	lowest := set.global
+	for _, level := range set.components {
+		lowest = min(lowest, level)
+	}
+	if forced.Load() {
+		lowest = zapcore.DebugLevel
+	}
+	baseLevel.SetLevel(lowest)
+}
+
+// enabled проверяет уровень записи для компонента, из которого вызвана
+// функция пакета (Info, Debug и т.д.)
+func enabled(level zapcore.Level) bool {
+	if forced.Load() {
+		return true
+	}
+	set := levels.Load()
+	if len(set.components) == 0 {
+		return level >= set.global
+	}
+
+	// 0 - runtime.Callers, 1 - enabled, 2 - функция пакета, 3 - вызывающий
+	pcs := make([]uintptr, 1)
+	if runtime.Callers(3, pcs) == 0 {
+		return level >= set.global
+	}
+	return level >= set.level(callerComponent(pcs))
+}
+
+// level возвращает уровень компонента с учетом вложенности
+func (s *levelSet) level(component string) zapcore.Level {
+	for component != "" {
+		if level, ok := s.components[component]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(component, '.')
+		if i < 0 {
+			break
+		}
+		component = component[:i]
+	}
+	return s.global
+}
+
+// callerComponent возвращает компонент по адресу вызова
+func callerComponent(pcs []uintptr) string {
+	if component, ok := callers.Load(pcs[0]); ok {
+		return component.(string)
+	}
+
+	// CallersFrames учитывает встроенные (inline) функции
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	component := packageComponent(frame.Function)
+	callers.Store(pcs[0], component)
+	return component
+}
+
+// packageComponent возвращает компонент по полному имени функции, например
+// github.com/skalibog/bfma/internal/exchange.(*BinanceClient).Start.func1 - exchange
+func packageComponent(name string) string {
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		name = name[:slash+1+dot]
+	}
+	if name == "main" || name == modulePath+"cmd/bfma" {
+		return "main"
+	}
+	name = strings.TrimPrefix(name, modulePath)
+	name = strings.TrimPrefix(name, "internal/")
+	name = strings.TrimPrefix(name, "pkg/")
+	return strings.ReplaceAll(name, "/", ".")
+}
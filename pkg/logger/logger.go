@@ -1,29 +1,99 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"sync"
+	"syscall"
+	"time"
 
+	"github.com/skalibog/bfma/pkg/timezone"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Формат времени в журналах; часовой пояс задается пакетом timezone
+const timeLayout = "02.01.2006 - 15:04:05.000000000Z07:00"
+
 // Глобальный экземпляр логгера
 var (
 	globalLogger *zap.Logger
+	jsonFile     = defaultJSONFile // JSON-журнал, который читает панель логов UI
+	files        []*rotatingFile   // Файлы текущего логгера для Reopen
+	filesMutex   sync.Mutex
 	once         sync.Once
 )
 
-// Init инициализирует глобальный логгер
+// Init инициализирует глобальный логгер с настройками по умолчанию
 func Init() {
 	once.Do(func() {
-		globalLogger = newLogger()
+		l, opened, err := newLogger(Config{}.withDefaults())
+		if err != nil {
+			panic(err)
+		}
+		globalLogger = l
+		setFiles(opened)
 	})
+}
 
-	// Очистка логов при перезапуске
-	if err := os.Truncate("app.json.log", 0); err != nil {
-		panic(err)
+// Configure перестраивает глобальный логгер по настройкам из конфигурации.
+// Вызывается один раз после загрузки конфигурации, до запуска остальных горутин.
+func Configure(cfg Config) error {
+	cfg = cfg.withDefaults()
+
+	set, err := parseLevels(cfg.Level, cfg.Levels)
+	if err != nil {
+		return err
+	}
+	l, opened, err := newLogger(cfg)
+	if err != nil {
+		return err
+	}
+	levelsMu.Lock()
+	apply(set)
+	levelsMu.Unlock()
+
+	old := globalLogger
+	globalLogger = l
+	jsonFile = Off
+	for _, file := range opened {
+		if file.path == cfg.JSONFile {
+			jsonFile = cfg.JSONFile
+		}
+	}
+	setFiles(opened)
+	if old != nil {
+		old.Sync()
 	}
+	return nil
+}
+
+// Reopen заново открывает файлы журнала. Вызывается по SIGUSR1 после того, как
+// внешняя ротация (logrotate) переименовала файлы.
+func Reopen() error {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+
+	for _, file := range files {
+		if err := file.reopen(); err != nil {
+			return fmt.Errorf("ошибка повторного открытия файла логов %s: %w", file.path, err)
+		}
+	}
+	return nil
+}
+
+// setFiles запоминает файлы текущего логгера
+func setFiles(opened []*rotatingFile) {
+	filesMutex.Lock()
+	files = opened
+	filesMutex.Unlock()
+}
+
+// JSONFile возвращает путь к JSON-журналу ("off", если он отключен)
+func JSONFile() string {
+	return jsonFile
 }
 
 // GetLogger возвращает глобальный экземпляр логгера
@@ -34,64 +104,105 @@ func GetLogger() *zap.Logger {
 	return globalLogger
 }
 
-// Вспомогательные функции для удобства использования
+// Вспомогательные функции для удобства использования. Запись проходит, если ее
+// уровень не ниже уровня компонента, из которого вызвана функция.
 func Info(msg string, fields ...zap.Field) {
-	GetLogger().Info(msg, fields...)
+	if enabled(zapcore.InfoLevel) {
+		GetLogger().Info(msg, fields...)
+	}
 }
 
 func Error(msg string, fields ...zap.Field) {
-	GetLogger().Error(msg, fields...)
+	if enabled(zapcore.ErrorLevel) {
+		GetLogger().Error(msg, fields...)
+	}
 }
 
 func Debug(msg string, fields ...zap.Field) {
-	GetLogger().Debug(msg, fields...)
+	if enabled(zapcore.DebugLevel) {
+		GetLogger().Debug(msg, fields...)
+	}
 }
 
 func Warn(msg string, fields ...zap.Field) {
-	GetLogger().Warn(msg, fields...)
+	if enabled(zapcore.WarnLevel) {
+		GetLogger().Warn(msg, fields...)
+	}
 }
 
 func Fatal(msg string, fields ...zap.Field) {
 	GetLogger().Fatal(msg, fields...)
 }
 
-// newLogger создает новый экземпляр логгера (ваша существующая функция New)
-func newLogger() *zap.Logger {
+// newLogger создает новый экземпляр логгера по настройкам и возвращает открытые им файлы.
+// Уровень записей задается уровнями компонентов (SetLevels).
+func newLogger(cfg Config) (*zap.Logger, []*rotatingFile, error) {
 	// Конфигурация энкодера
 	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("02.01.2006 - 15:04:05.000000000Z07:00")
+	encoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString(timezone.In(t).Format(timeLayout))
+	}
 	encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
 
 	// Создание энкодеров
-	//consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-	readableFileEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-	jsonFileEncoder := zapcore.NewJSONEncoder(encoderConfig)
-
-	// Файлы
-	readableFile, err := os.OpenFile("app.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		panic(err)
+	var readableEncoder zapcore.Encoder
+	switch cfg.Format {
+	case FormatConsole:
+		readableEncoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case FormatJSON:
+		readableEncoder = zapcore.NewJSONEncoder(encoderConfig)
+	default:
+		return nil, nil, fmt.Errorf("неизвестный формат логов %q, допустимы: %s, %s", cfg.Format, FormatConsole, FormatJSON)
 	}
-	jsonFile, err := os.OpenFile("app.json.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		panic(err)
+	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
+
+	var cores []zapcore.Core
+	var opened []*rotatingFile
+	var skipped []zap.Field // Файлы, недоступные для записи
+
+	// Читаемый файл и JSON-файл, который читает панель логов UI
+	for _, target := range []struct {
+		path    string
+		encoder zapcore.Encoder
+	}{
+		{cfg.File, readableEncoder},
+		{cfg.JSONFile, jsonEncoder},
+	} {
+		if target.path == Off {
+			continue
+		}
+		file, err := openRotating(target.path, cfg)
+		if err != nil {
+			// В контейнере с файловой системой только для чтения журнал пишется
+			// в stderr, а не прерывает запуск
+			if unwritable(err) {
+				skipped = append(skipped, zap.String(target.path, err.Error()))
+				continue
+			}
+			return nil, nil, fmt.Errorf("ошибка открытия файла логов %s: %w", target.path, err)
+		}
+		cores = append(cores, zapcore.NewCore(target.encoder, file, baseLevel))
+		opened = append(opened, file)
 	}
 
-	// Writers
-	//consoleWriter := zapcore.AddSync(os.Stdout)
-	readableFileWriter := zapcore.AddSync(readableFile)
-	jsonFileWriter := zapcore.AddSync(jsonFile)
-
-	// Уровень логирования
-	level := zapcore.DebugLevel
+	// Консоль занята TUI, поэтому вывод в stdout включается явно
+	if cfg.Stdout {
+		cores = append(cores, zapcore.NewCore(readableEncoder, zapcore.Lock(os.Stdout), baseLevel))
+	}
+	if cfg.Stderr || (!cfg.Stdout && len(skipped) > 0) {
+		cores = append(cores, zapcore.NewCore(readableEncoder, zapcore.Lock(os.Stderr), baseLevel))
+	}
 
-	// Tee: console + читаемый файл + JSON файл
-	core := zapcore.NewTee(
-		//zapcore.NewCore(consoleEncoder, consoleWriter, level),
-		zapcore.NewCore(readableFileEncoder, readableFileWriter, level),
-		zapcore.NewCore(jsonFileEncoder, jsonFileWriter, level),
-	)
+	l := zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddCallerSkip(1))
+	if len(skipped) > 0 {
+		l.Warn("Файлы логов недоступны для записи, журнал выводится в консоль", skipped...)
+	}
+	return l, opened, nil
+}
 
-	return zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
+// unwritable сообщает, что файл нельзя создать или изменить: файловая система
+// только для чтения или нет прав
+func unwritable(err error) bool {
+	return errors.Is(err, syscall.EROFS) || errors.Is(err, fs.ErrPermission)
 }
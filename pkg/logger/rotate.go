@@ -0,0 +1,224 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Формат метки времени в имени архива журнала; время в UTC, чтобы имена не зависели
+// от часового пояса и перехода на летнее время
+const backupTimeFormat = "20060102-150405.000"
+
+// Расширение сжатого архива
+const compressedExt = ".gz"
+
+// rotatingFile - файл журнала с ротацией по размеру и времени, сжатием и удалением
+// старых архивов
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64         // Размер, после которого файл уходит в архив (0 - без ротации)
+	interval   time.Duration // Через сколько файл уходит в архив (0 - только по размеру)
+	maxAge     time.Duration // Сколько хранить архивы (0 - не ограничено)
+	maxBackups int           // Сколько архивов хранить (0 - не ограничено)
+	compress   bool          // Сжимать архивы gzip
+	file       *os.File
+	size       int64
+	opened     time.Time // Начало текущего файла для ротации по времени
+
+	cleanupMu sync.Mutex // Сжатие и удаление архивов выполняются по одному
+}
+
+// openRotating открывает файл журнала на дозапись
+func openRotating(path string, cfg Config) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		interval:   time.Duration(cfg.RotateHours) * time.Hour,
+		maxAge:     time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+		maxBackups: cfg.MaxBackups,
+		compress:   cfg.Compress,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.cleanup()
+	return r, nil
+}
+
+// open открывает текущий файл журнала
+func (r *rotatingFile) open() error {
+	if dir := filepath.Dir(r.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	// Файл, оставшийся от прошлого запуска, ведется с момента последней записи в него
+	r.file, r.size, r.opened = file, info.Size(), time.Now()
+	if r.size > 0 && info.ModTime().Before(r.opened) {
+		r.opened = info.ModTime()
+	}
+	return nil
+}
+
+// Write реализует zapcore.WriteSyncer
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	full := r.maxSize > 0 && r.size+int64(len(p)) > r.maxSize
+	expired := r.interval > 0 && time.Since(r.opened) >= r.interval
+	if r.size > 0 && (full || expired) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync реализует zapcore.WriteSyncer
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Sync()
+}
+
+// reopen закрывает файл и открывает его заново по тому же пути
+func (r *rotatingFile) reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// rotate переименовывает текущий файл в архив с меткой времени и начинает новый
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(r.path)
+	backup := strings.TrimSuffix(r.path, ext) + "-" + time.Now().UTC().Format(backupTimeFormat) + ext
+	if err := os.Rename(r.path, backup); err != nil {
+		return err
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	go r.cleanup()
+	return nil
+}
+
+// cleanup сжимает несжатые архивы и удаляет архивы старше maxAge и сверх maxBackups
+func (r *rotatingFile) cleanup() {
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+
+	ext := filepath.Ext(r.path)
+	pattern := strings.TrimSuffix(r.path, ext) + "-*" + ext
+	if r.compress {
+		plain, _ := filepath.Glob(pattern)
+		// Не сжатый из-за ошибки архив сжимается при следующей ротации
+		for _, backup := range plain {
+			compressFile(backup)
+		}
+	}
+	if r.maxAge <= 0 && r.maxBackups <= 0 {
+		return
+	}
+
+	plain, err := filepath.Glob(pattern)
+	if err != nil {
+		return
+	}
+	compressed, err := filepath.Glob(pattern + compressedExt)
+	if err != nil {
+		return
+	}
+	// Архивы упорядочиваются по времени ротации, новые первыми. Время изменения
+	// архива - время ротации (сжатие его сохраняет); по именам порядок неверен для
+	// архивов прежних версий с меткой в местном времени.
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, path := range append(plain, compressed...) {
+		if info, err := os.Stat(path); err == nil {
+			backups = append(backups, backup{path, info.ModTime()})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].modTime.After(backups[j].modTime) })
+
+	for i, b := range backups {
+		expired := r.maxBackups > 0 && i >= r.maxBackups
+		if r.maxAge > 0 && time.Since(b.modTime) > r.maxAge {
+			expired = true
+		}
+		if expired {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// compressFile сжимает файл gzip в path.gz и удаляет исходный. Время изменения
+// сохраняется, чтобы срок хранения архива отсчитывался от ротации.
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	target := path + compressedExt
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(file)
+	_, err = io.Copy(writer, source)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+
+	if err := os.Chtimes(target, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,167 @@
+// Package migrate приводит сохраненные модели прошлых версий к текущей. Модель хранится
+// набором полей (точка InfluxDB, объект JSON) с номером версии в поле VersionField;
+// записи без номера считаются версией 1. Для каждой версии модели регистрируется
+// преобразование в следующую, при чтении они применяются по цепочке.
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// VersionField поле с версией модели
+const VersionField = "model_version"
+
+// Record поля сохраненной модели
+type Record map[string]interface{}
+
+// Converter преобразует поля модели версии n в версию n+1
+type Converter func(record Record) error
+
+// Registry преобразования версий моделей
+type Registry struct {
+	mutex sync.RWMutex
+	steps map[string][]Converter // Ключ - модель; steps[i] переводит версию i+1 в i+2
+}
+
+// NewRegistry создает пустой реестр: все модели версии 1
+func NewRegistry() *Registry {
+	return &Registry{steps: make(map[string][]Converter)}
+}
+
+// Register регистрирует преобразование модели из версии from в from+1. Версии
+// регистрируются по порядку, начиная с 1; нарушение порядка - ошибка программы.
+func (r *Registry) Register(model string, from int, convert Converter) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if expected := len(r.steps[model]) + 1; from != expected {
+		panic(fmt.Sprintf("migrate: преобразование %s из версии %d зарегистрировано вне очереди, ожидается версия %d", model, from, expected))
+	}
+	r.steps[model] = append(r.steps[model], convert)
+}
+
+// Latest возвращает текущую версию модели
+func (r *Registry) Latest(model string) int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.steps[model]) + 1
+}
+
+// Stamp записывает в поля текущую версию модели; вызывается перед сохранением
+func (r *Registry) Stamp(model string, fields map[string]interface{}) {
+	fields[VersionField] = int64(r.Latest(model))
+}
+
+// Version возвращает версию модели из поля VersionField: 1, если поля нет
+func Version(record Record) (int, error) {
+	switch v := record[VersionField].(type) {
+	case nil:
+		return 1, nil
+	case int64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case float64: // Числа JSON
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("неверный тип версии модели %T", v)
+	}
+}
+
+// Migrate приводит поля модели к текущей версии на месте. Модель версии новее
+// текущей - ошибка: ее сохранила более новая программа.
+func (r *Registry) Migrate(model string, record Record) error {
+	version, err := Version(record)
+	if err != nil {
+		return fmt.Errorf("%s: %w", model, err)
+	}
+
+	r.mutex.RLock()
+	steps := r.steps[model]
+	r.mutex.RUnlock()
+
+	latest := len(steps) + 1
+	switch {
+	case version < 1:
+		return fmt.Errorf("%s: неверная версия модели %d", model, version)
+	case version > latest:
+		return fmt.Errorf("%s: версия модели %d новее поддерживаемой %d", model, version, latest)
+	}
+
+	for v := version; v < latest; v++ {
+		if err := steps[v-1](record); err != nil {
+			return fmt.Errorf("%s: ошибка преобразования версии %d в %d: %w", model, v, v+1, err)
+		}
+	}
+	record[VersionField] = int64(latest)
+	return nil
+}
+
+// Decode разбирает модель из JSON, приводя ее к текущей версии: для наборов данных,
+// сохраненных в файлы прошлыми версиями программы
+func (r *Registry) Decode(model string, data []byte, v interface{}) error {
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return fmt.Errorf("%s: %w", model, err)
+	}
+	if err := r.Migrate(model, record); err != nil {
+		return err
+	}
+	migrated, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("%s: %w", model, err)
+	}
+	return json.Unmarshal(migrated, v)
+}
+
+// Rename переименовывает поле; отсутствующее поле пропускается
+func Rename(from, to string) Converter {
+	return func(record Record) error {
+		if value, ok := record[from]; ok {
+			record[to] = value
+			delete(record, from)
+		}
+		return nil
+	}
+}
+
+// Convert меняет значение поля функцией convert; отсутствующее поле пропускается
+func Convert(field string, convert func(value interface{}) (interface{}, error)) Converter {
+	return func(record Record) error {
+		value, ok := record[field]
+		if !ok {
+			return nil
+		}
+		converted, err := convert(value)
+		if err != nil {
+			return fmt.Errorf("поле %s: %w", field, err)
+		}
+		record[field] = converted
+		return nil
+	}
+}
+
+// Default задает значение поля, которого нет в записи
+func Default(field string, value interface{}) Converter {
+	return func(record Record) error {
+		if _, ok := record[field]; !ok {
+			record[field] = value
+		}
+		return nil
+	}
+}
+
+// Chain объединяет преобразования одной версии
+func Chain(converters ...Converter) Converter {
+	return func(record Record) error {
+		for _, convert := range converters {
+			if err := convert(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// TradeRecord закрытая сделка в результатах проверки на истории: без исполнений,
+// с итоговой прибылью
+type TradeRecord struct {
+	TradeID    string    `json:"trade_id"`
+	Symbol     string    `json:"symbol"`
+	Side       Side      `json:"side"`
+	SignalID   string    `json:"signal_id,omitempty"`
+	EntryTime  time.Time `json:"entry_time"`
+	ExitTime   time.Time `json:"exit_time"`
+	EntryPrice Decimal   `json:"entry_price"`
+	ExitPrice  Decimal   `json:"exit_price"`
+	Quantity   Decimal   `json:"quantity"` // Объем всех входов
+	Fees       Decimal   `json:"fees"`
+	PnL        Decimal   `json:"pnl"`         // Чистая прибыль за вычетом комиссий
+	ReturnPct  float64   `json:"return_pct"`  // Чистая прибыль в процентах от стоимости входов
+	DurationMs int64     `json:"duration_ms"` // Время от первого входа до полного выхода
+}
+
+// NewTradeRecord возвращает запись сделки; для открытой сделки время выхода нулевое,
+// а прибыль учитывает только закрытый объем
+func NewTradeRecord(trade *Trade) TradeRecord {
+	record := TradeRecord{
+		TradeID:    trade.ID,
+		Symbol:     trade.Symbol,
+		Side:       trade.Side,
+		SignalID:   trade.SignalID,
+		EntryTime:  trade.OpenTime,
+		ExitTime:   trade.CloseTime,
+		EntryPrice: trade.EntryPrice,
+		ExitPrice:  trade.ExitPrice,
+		Quantity:   trade.EntryQuantity,
+		Fees:       trade.Fees,
+		PnL:        trade.NetPnL(),
+		ReturnPct:  trade.Return(),
+	}
+	if trade.Closed() {
+		record.DurationMs = trade.CloseTime.Sub(trade.OpenTime).Milliseconds()
+	}
+	return record
+}
+
+// PerformanceStats показатели результатов торговли по списку сделок. Это статистика
+// для сравнения запусков, поэтому суммы в ней float64, в отличие от сделок.
+type PerformanceStats struct {
+	Trades            int     `json:"trades"`
+	Wins              int     `json:"wins"`
+	Losses            int     `json:"losses"`
+	WinRate           float64 `json:"win_rate"` // Доля прибыльных сделок, %
+	GrossProfit       float64 `json:"gross_profit"`
+	GrossLoss         float64 `json:"gross_loss"` // Сумма убытков, положительное число
+	NetPnL            float64 `json:"net_pnl"`
+	Fees              float64 `json:"fees"`
+	ProfitFactor      float64 `json:"profit_factor"` // GrossProfit / GrossLoss; 0 без убыточных сделок
+	AverageWin        float64 `json:"average_win"`
+	AverageLoss       float64 `json:"average_loss"`     // Средний убыток, положительное число
+	AverageReturn     float64 `json:"average_return"`   // Средняя доходность сделки, %
+	MaxDrawdown       float64 `json:"max_drawdown"`     // Наибольшее падение капитала от максимума
+	MaxDrawdownPct    float64 `json:"max_drawdown_pct"` // То же в процентах от максимума капитала
+	ReturnPct         float64 `json:"return_pct"`       // Чистая прибыль в процентах от начального баланса
+	SharpeRatio       float64 `json:"sharpe_ratio"`     // Средняя доходность сделки к ее стандартному отклонению
+	AverageDurationMs int64   `json:"average_duration_ms"`
+}
+
+// ComputePerformanceStats рассчитывает показатели по сделкам в порядке закрытия.
+// Просадка считается по капиталу после каждой сделки от начального баланса
+// initialBalance; при нулевом балансе проценты просадки и доходности не считаются.
+func ComputePerformanceStats(records []TradeRecord, initialBalance float64) PerformanceStats {
+	stats := PerformanceStats{Trades: len(records)}
+	if len(records) == 0 {
+		return stats
+	}
+
+	equity, peak := initialBalance, initialBalance
+	var returns, duration float64
+	for _, record := range records {
+		pnl := record.PnL.InexactFloat64()
+		switch {
+		case pnl > 0:
+			stats.Wins++
+			stats.GrossProfit += pnl
+		case pnl < 0:
+			stats.Losses++
+			stats.GrossLoss -= pnl
+		}
+		stats.NetPnL += pnl
+		stats.Fees += record.Fees.InexactFloat64()
+		returns += record.ReturnPct
+		duration += float64(record.DurationMs)
+
+		equity += pnl
+		peak = math.Max(peak, equity)
+		if drawdown := peak - equity; drawdown > stats.MaxDrawdown {
+			stats.MaxDrawdown = drawdown
+			if peak > 0 {
+				stats.MaxDrawdownPct = drawdown / peak * 100
+			}
+		}
+	}
+
+	n := float64(len(records))
+	stats.WinRate = float64(stats.Wins) / n * 100
+	stats.AverageReturn = returns / n
+	stats.AverageDurationMs = int64(duration / n)
+	if stats.Wins > 0 {
+		stats.AverageWin = stats.GrossProfit / float64(stats.Wins)
+	}
+	if stats.Losses > 0 {
+		stats.AverageLoss = stats.GrossLoss / float64(stats.Losses)
+		stats.ProfitFactor = stats.GrossProfit / stats.GrossLoss
+	}
+	if initialBalance > 0 {
+		stats.ReturnPct = stats.NetPnL / initialBalance * 100
+	}
+
+	if len(records) > 1 {
+		var variance float64
+		for _, record := range records {
+			variance += (record.ReturnPct - stats.AverageReturn) * (record.ReturnPct - stats.AverageReturn)
+		}
+		if deviation := math.Sqrt(variance / (n - 1)); deviation > 0 {
+			stats.SharpeRatio = stats.AverageReturn / deviation
+		}
+	}
+	return stats
+}
+
+// BacktestRun запуск проверки стратегии на истории: параметры, показатели и сделки
+type BacktestRun struct {
+	ID             string            `json:"id"` // <время запуска>-<название>
+	Name           string            `json:"name"`
+	Symbols        []string          `json:"symbols"`
+	Interval       Interval          `json:"interval"`
+	From           time.Time         `json:"from"` // Начало периода истории
+	To             time.Time         `json:"to"`   // Конец периода истории
+	InitialBalance float64           `json:"initial_balance"`
+	Parameters     map[string]string `json:"parameters,omitempty"` // Веса, пороги и другие параметры стратегии
+	StartedAt      time.Time         `json:"started_at"`
+	FinishedAt     time.Time         `json:"finished_at"`
+	Stats          PerformanceStats  `json:"stats"`
+	Slippage       []SlippageStats   `json:"slippage,omitempty"` // Проскальзывание исполнений по символам
+	Trades         []TradeRecord     `json:"trades,omitempty"`
+}
+
+// NewBacktestRun создает запуск проверки с идентификатором по времени запуска
+func NewBacktestRun(name string, started time.Time) *BacktestRun {
+	return &BacktestRun{
+		ID:        started.UTC().Format("20060102T150405Z") + "-" + name,
+		Name:      name,
+		StartedAt: started,
+	}
+}
+
+// Finish записывает сделки запуска, рассчитывает показатели и отмечает время завершения
+func (r *BacktestRun) Finish(trades []TradeRecord, finished time.Time) {
+	r.Trades = trades
+	r.Stats = ComputePerformanceStats(trades, r.InitialBalance)
+	r.FinishedAt = finished
+}
+
+// RecordSlippage рассчитывает проскальзывание исполнений запуска по символам
+func (r *BacktestRun) RecordSlippage(fills []Fill) {
+	r.Slippage = ComputeSlippageStats(fills)
+}
+
+// Summary возвращает копию запуска без сделок - для списков запусков
+func (r *BacktestRun) Summary() *BacktestRun {
+	summary := *r
+	summary.Trades = nil
+	return &summary
+}
+
+// Compare возвращает разницу показателей запуска с запуском base: положительные
+// значения - показатель этого запуска больше
+func (s PerformanceStats) Compare(base PerformanceStats) PerformanceStats {
+	return PerformanceStats{
+		Trades:            s.Trades - base.Trades,
+		Wins:              s.Wins - base.Wins,
+		Losses:            s.Losses - base.Losses,
+		WinRate:           s.WinRate - base.WinRate,
+		GrossProfit:       s.GrossProfit - base.GrossProfit,
+		GrossLoss:         s.GrossLoss - base.GrossLoss,
+		NetPnL:            s.NetPnL - base.NetPnL,
+		Fees:              s.Fees - base.Fees,
+		ProfitFactor:      s.ProfitFactor - base.ProfitFactor,
+		AverageWin:        s.AverageWin - base.AverageWin,
+		AverageLoss:       s.AverageLoss - base.AverageLoss,
+		AverageReturn:     s.AverageReturn - base.AverageReturn,
+		MaxDrawdown:       s.MaxDrawdown - base.MaxDrawdown,
+		MaxDrawdownPct:    s.MaxDrawdownPct - base.MaxDrawdownPct,
+		ReturnPct:         s.ReturnPct - base.ReturnPct,
+		SharpeRatio:       s.SharpeRatio - base.SharpeRatio,
+		AverageDurationMs: s.AverageDurationMs - base.AverageDurationMs,
+	}
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"sort"
+	"sync"
+)
+
+// Сколько закрытых свечей интервала хранит CandleSeries: минутных - за сутки, чтобы
+// хватало анализу дельты объемов, остальных - как при загрузке истории сборщиком
+const (
+	candleSeriesMinuteCapacity  = 1440
+	candleSeriesDefaultCapacity = 500
+)
+
+// CandleSeriesCapacity возвращает, сколько последних свечей интервала хранит CandleSeries
+func CandleSeriesCapacity(interval Interval) int {
+	if interval == Interval1m {
+		return candleSeriesMinuteCapacity
+	}
+	return candleSeriesDefaultCapacity
+}
+
+// CandleSeries последние закрытые свечи символа в памяти по интервалам. Ряд ведет
+// сборщик свечей: свеча добавляется при закрытии, самые старые вытесняются по
+// достижении CandleSeriesCapacity. Анализаторы читают свечи из ряда без обращения
+// к хранилищу.
+type CandleSeries struct {
+	Symbol  string
+	mutex   sync.RWMutex
+	candles map[Interval][]*Candle // По возрастанию времени открытия
+}
+
+// NewCandleSeries создает пустой ряд свечей символа
+func NewCandleSeries(symbol string) *CandleSeries {
+	return &CandleSeries{
+		Symbol:  symbol,
+		candles: make(map[Interval][]*Candle),
+	}
+}
+
+// Append добавляет закрытую свечу: свеча с тем же временем открытия заменяется,
+// свеча старее самой старой в заполненном ряду пропускается
+func (s *CandleSeries) Append(candle *Candle) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	capacity := CandleSeriesCapacity(candle.Interval)
+	candles := s.candles[candle.Interval]
+	i := sort.Search(len(candles), func(i int) bool { return !candles[i].OpenTime.Before(candle.OpenTime) })
+	switch {
+	case i < len(candles) && candles[i].OpenTime.Equal(candle.OpenTime):
+		candles[i] = candle
+		return
+	case i == 0 && len(candles) >= capacity:
+		return
+	}
+	candles = append(candles, nil)
+	copy(candles[i+1:], candles[i:])
+	candles[i] = candle
+	if len(candles) > capacity {
+		// Новый массив, чтобы вытесненные свечи не удерживались в памяти
+		candles = append([]*Candle(nil), candles[len(candles)-capacity:]...)
+	}
+	s.candles[candle.Interval] = candles
+}
+
+// Candles возвращает последние limit свечей интервала, новые первыми, как выборки
+// хранилища; limit <= 0 - все свечи
+func (s *CandleSeries) Candles(interval Interval, limit int) []*Candle {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	candles := s.candles[interval]
+	if limit <= 0 || limit > len(candles) {
+		limit = len(candles)
+	}
+	result := make([]*Candle, 0, limit)
+	for i := len(candles) - 1; i >= len(candles)-limit; i-- {
+		result = append(result, candles[i])
+	}
+	return result
+}
+
+// Len возвращает число свечей интервала в ряду
+func (s *CandleSeries) Len(interval Interval) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.candles[interval])
+}
+
+// CandleSeriesSet ряды свечей отслеживаемых символов: общий для сборщиков свечей,
+// которые их ведут, и агрегатора, который передает их анализаторам
+type CandleSeriesSet struct {
+	mutex  sync.RWMutex
+	series map[string]*CandleSeries
+}
+
+// NewCandleSeriesSet создает пустой набор рядов свечей
+func NewCandleSeriesSet() *CandleSeriesSet {
+	return &CandleSeriesSet{series: make(map[string]*CandleSeries)}
+}
+
+// Series возвращает ряд свечей символа, создавая его при первом обращении
+func (s *CandleSeriesSet) Series(symbol string) *CandleSeries {
+	s.mutex.RLock()
+	series, ok := s.series[symbol]
+	s.mutex.RUnlock()
+	if ok {
+		return series
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if series, ok = s.series[symbol]; !ok {
+		series = NewCandleSeries(symbol)
+		s.series[symbol] = series
+	}
+	return series
+}
+
+// Lookup возвращает ряд свечей символа; nil - свечи символа не собираются
+func (s *CandleSeriesSet) Lookup(symbol string) *CandleSeries {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.series[symbol]
+}
+
+// Remove удаляет ряд свечей символа, исключенного из отслеживания
+func (s *CandleSeriesSet) Remove(symbol string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.series, symbol)
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Decimal десятичное число без потери точности. Цены и объемы стакана, ставки
+// финансирования и открытый интерес биржа передает строками; в Decimal они хранятся
+// в точности как переданы, без накопления ошибки округления float64 при повторных
+// преобразованиях (заметной на символах с ценой меньше цента). В JSON значение
+// записывается строкой: "0.00001234".
+type Decimal = decimal.Decimal
+
+// ZeroDecimal нулевое значение
+var ZeroDecimal = decimal.Zero
+
+// ParseDecimal разбирает десятичное число из строки биржи или хранилища
+func ParseDecimal(value string) (Decimal, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return ZeroDecimal, fmt.Errorf("неверное десятичное число %q: %w", value, err)
+	}
+	return d, nil
+}
+
+// MustDecimal разбирает десятичное число и паникует при ошибке; для констант
+// и заведомо верных строк
+func MustDecimal(value string) Decimal {
+	return decimal.RequireFromString(value)
+}
+
+// DecimalFromFloat переводит float64 в десятичное число по кратчайшему представлению,
+// которое при обратном переводе дает то же float64
+func DecimalFromFloat(value float64) Decimal {
+	return decimal.NewFromFloat(value)
+}
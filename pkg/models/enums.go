@@ -0,0 +1,159 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Translator переводит ключ локализации в текст (i18n.Translator)
+type Translator interface {
+	T(key string, args ...interface{}) string
+}
+
+// Recommendation код рекомендации сигнала, не зависящий от локали. Программы сравнивают
+// коды, а текст для людей получают через Localize.
+type Recommendation string
+
+// Коды рекомендаций
+const (
+	RecommendationStrongBuy  Recommendation = "STRONG_BUY"
+	RecommendationBuy        Recommendation = "BUY"
+	RecommendationNeutral    Recommendation = "NEUTRAL"
+	RecommendationSell       Recommendation = "SELL"
+	RecommendationStrongSell Recommendation = "STRONG_SELL"
+)
+
+// Recommendations коды рекомендаций от сильной покупки до сильной продажи
+var Recommendations = []Recommendation{
+	RecommendationStrongBuy,
+	RecommendationBuy,
+	RecommendationNeutral,
+	RecommendationSell,
+	RecommendationStrongSell,
+}
+
+// Тексты рекомендаций в сигналах, сохраненных до появления кодов
+var legacyRecommendations = map[string]Recommendation{
+	"СИЛЬНАЯ ПОКУПКА": RecommendationStrongBuy,
+	"ПОКУПКА":         RecommendationBuy,
+	"НЕЙТРАЛЬНО":      RecommendationNeutral,
+	"ПРОДАЖА":         RecommendationSell,
+	"СИЛЬНАЯ ПРОДАЖА": RecommendationStrongSell,
+}
+
+// ParseRecommendation разбирает код рекомендации без учета регистра. Принимается
+// и текст рекомендации старых сигналов («СИЛЬНАЯ ПОКУПКА»).
+func ParseRecommendation(value string) (Recommendation, error) {
+	value = strings.TrimSpace(value)
+	if r := Recommendation(strings.ToUpper(value)); r.Valid() {
+		return r, nil
+	}
+	if r, ok := legacyRecommendations[strings.ToUpper(value)]; ok {
+		return r, nil
+	}
+	return "", fmt.Errorf("неизвестная рекомендация %q, допустимы STRONG_BUY, BUY, NEUTRAL, SELL, STRONG_SELL", value)
+}
+
+// String возвращает код рекомендации
+func (r Recommendation) String() string {
+	return string(r)
+}
+
+// Valid сообщает, что код рекомендации известен
+func (r Recommendation) Valid() bool {
+	switch r {
+	case RecommendationStrongBuy, RecommendationBuy, RecommendationNeutral, RecommendationSell, RecommendationStrongSell:
+		return true
+	}
+	return false
+}
+
+// Direction возвращает направление рекомендации: 1 - покупка, -1 - продажа, 0 - нейтрально
+func (r Recommendation) Direction() int {
+	switch r {
+	case RecommendationStrongBuy, RecommendationBuy:
+		return 1
+	case RecommendationStrongSell, RecommendationSell:
+		return -1
+	}
+	return 0
+}
+
+// Strong сообщает, что рекомендация сильная
+func (r Recommendation) Strong() bool {
+	return r == RecommendationStrongBuy || r == RecommendationStrongSell
+}
+
+// Side возвращает сторону позиции по рекомендации; для нейтральной - пустую
+func (r Recommendation) Side() Side {
+	switch r.Direction() {
+	case 1:
+		return PositionSideLong
+	case -1:
+		return PositionSideShort
+	}
+	return ""
+}
+
+// Key возвращает ключ локализации текста рекомендации
+func (r Recommendation) Key() string {
+	return "recommendation." + string(r)
+}
+
+// Localize возвращает текст рекомендации на языке переводчика
+func (r Recommendation) Localize(tr Translator) string {
+	return tr.T(r.Key())
+}
+
+// Side сторона позиции
+type Side string
+
+// Стороны позиции
+const (
+	PositionSideLong  Side = "LONG"
+	PositionSideShort Side = "SHORT"
+)
+
+// ParseSide разбирает сторону позиции без учета регистра
+func ParseSide(value string) (Side, error) {
+	if s := Side(strings.ToUpper(strings.TrimSpace(value))); s.Valid() {
+		return s, nil
+	}
+	return "", fmt.Errorf("неизвестная сторона позиции %q, допустимы LONG и SHORT", value)
+}
+
+// String возвращает код стороны позиции
+func (s Side) String() string {
+	return string(s)
+}
+
+// Valid сообщает, что сторона позиции известна
+func (s Side) Valid() bool {
+	return s == PositionSideLong || s == PositionSideShort
+}
+
+// Opposite возвращает противоположную сторону
+func (s Side) Opposite() Side {
+	if s == PositionSideLong {
+		return PositionSideShort
+	}
+	return PositionSideLong
+}
+
+// Sign возвращает 1 для длинной позиции и -1 для короткой
+func (s Side) Sign() float64 {
+	if s == PositionSideShort {
+		return -1
+	}
+	return 1
+}
+
+// Key возвращает ключ локализации названия стороны
+func (s Side) Key() string {
+	return "position." + string(s)
+}
+
+// Localize возвращает название стороны на языке переводчика
+func (s Side) Localize(tr Translator) string {
+	return tr.T(s.Key())
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Расчетов финансирования в сутки у большинства бессрочных контрактов Binance:
+// каждые 8 часов
+const DefaultFundingIntervalsPerDay = 3
+
+// DefaultFundingInterval период финансирования большинства бессрочных контрактов.
+// Часть символов биржа переводит на 4 или 1 час; пороги анализа заданы для 8 часов.
+const DefaultFundingInterval = 24 * time.Hour / DefaultFundingIntervalsPerDay
+
+// Float возвращает ставку долей за период финансирования: 0.0001 - это 0.01%.
+// Анализаторы и интерфейс получают числовую ставку только через методы FundingRate,
+// чтобы единицы не расходились.
+func (f *FundingRate) Float() float64 {
+	return f.Rate.InexactFloat64()
+}
+
+// Percent возвращает ставку за период финансирования в процентах
+func (f *FundingRate) Percent() float64 {
+	return f.Float() * 100
+}
+
+// Bps возвращает ставку за период финансирования в базисных пунктах (0.01%)
+func (f *FundingRate) Bps() float64 {
+	return f.Float() * 10000
+}
+
+// Annualized возвращает годовую ставку в процентах без реинвестирования при
+// intervalsPerDay расчетах в сутки; при intervalsPerDay <= 0 используется
+// DefaultFundingIntervalsPerDay
+func (f *FundingRate) Annualized(intervalsPerDay int) float64 {
+	if intervalsPerDay <= 0 {
+		intervalsPerDay = DefaultFundingIntervalsPerDay
+	}
+	return f.Percent() * float64(intervalsPerDay) * 365
+}
+
+// Per8h возвращает копию ставки, приведенную к 8-часовому периоду: ставка за 1 час
+// умножается на 8, за 4 часа - на 2. При interval <= 0 ставка не меняется.
+func (f *FundingRate) Per8h(interval time.Duration) *FundingRate {
+	copied := *f
+	if interval > 0 && interval != DefaultFundingInterval {
+		copied.Rate = f.Rate.Mul(DecimalFromFloat(float64(DefaultFundingInterval) / float64(interval)))
+	}
+	return &copied
+}
+
+// FundingIntervals определяет по собранным ставкам (новые первыми) период финансирования
+// каждой: это разница между временем следующего расчета ставки и предыдущим, отличным
+// от него. Разрывы длиннее DefaultFundingInterval - пропуски сбора - не учитываются.
+// Ставкам до первой смены времени расчета назначается первый найденный период, а если
+// период не найден ни для одной ставки - fallback.
+func FundingIntervals(rates []*FundingRate, fallback time.Duration) []time.Duration {
+	intervals := make([]time.Duration, len(rates))
+	var previous time.Time
+	var current time.Duration
+	for i := len(rates) - 1; i >= 0; i-- {
+		next := rates[i].NextFundingTime
+		if !next.IsZero() && next.After(previous) {
+			if !previous.IsZero() {
+				if gap := next.Sub(previous).Round(time.Hour); gap > 0 && gap <= DefaultFundingInterval {
+					current = gap
+				}
+			}
+			previous = next
+		}
+		intervals[i] = current
+	}
+
+	first := fallback
+	for i := len(intervals) - 1; i >= 0; i-- {
+		if intervals[i] > 0 {
+			first = intervals[i]
+			break
+		}
+	}
+	for i := range intervals {
+		if intervals[i] == 0 {
+			intervals[i] = first
+		}
+	}
+	return intervals
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval интервал свечей в обозначениях Binance: 1m, 4h, 1d, 1w, 1M
+type Interval string
+
+// Интервалы свечей, поддерживаемые Binance Futures
+const (
+	Interval1m  Interval = "1m"
+	Interval3m  Interval = "3m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval30m Interval = "30m"
+	Interval1h  Interval = "1h"
+	Interval2h  Interval = "2h"
+	Interval4h  Interval = "4h"
+	Interval6h  Interval = "6h"
+	Interval8h  Interval = "8h"
+	Interval12h Interval = "12h"
+	Interval1d  Interval = "1d"
+	Interval3d  Interval = "3d"
+	Interval1w  Interval = "1w"
+	Interval1M  Interval = "1M"
+)
+
+// Intervals все поддерживаемые интервалы по возрастанию длительности
+var Intervals = []Interval{
+	Interval1m, Interval3m, Interval5m, Interval15m, Interval30m,
+	Interval1h, Interval2h, Interval4h, Interval6h, Interval8h, Interval12h,
+	Interval1d, Interval3d, Interval1w, Interval1M,
+}
+
+// Длительности интервалов; месяц календарный, здесь - его средняя длительность
+var intervalDurations = map[Interval]time.Duration{
+	Interval1m:  time.Minute,
+	Interval3m:  3 * time.Minute,
+	Interval5m:  5 * time.Minute,
+	Interval15m: 15 * time.Minute,
+	Interval30m: 30 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval2h:  2 * time.Hour,
+	Interval4h:  4 * time.Hour,
+	Interval6h:  6 * time.Hour,
+	Interval8h:  8 * time.Hour,
+	Interval12h: 12 * time.Hour,
+	Interval1d:  24 * time.Hour,
+	Interval3d:  3 * 24 * time.Hour,
+	Interval1w:  7 * 24 * time.Hour,
+	Interval1M:  30 * 24 * time.Hour,
+}
+
+// Недели Binance начинаются в понедельник, а 1 января 1970 года - четверг
+const weekOffset = 4 * 24 * time.Hour
+
+// ParseInterval разбирает интервал свечей. Регистр важен: 1m - минута, 1M - месяц.
+func ParseInterval(value string) (Interval, error) {
+	interval := Interval(value)
+	if !interval.Valid() {
+		return "", fmt.Errorf("неизвестный интервал %q, допустимы: %s", value, joinIntervals(Intervals))
+	}
+	return interval, nil
+}
+
+// joinIntervals перечисляет интервалы через запятую
+func joinIntervals(intervals []Interval) string {
+	names := make([]string, len(intervals))
+	for i, interval := range intervals {
+		names[i] = string(interval)
+	}
+	return strings.Join(names, ", ")
+}
+
+// String возвращает обозначение интервала
+func (i Interval) String() string {
+	return string(i)
+}
+
+// Valid сообщает, что интервал поддерживается Binance
+func (i Interval) Valid() bool {
+	_, ok := intervalDurations[i]
+	return ok
+}
+
+// Duration возвращает длительность интервала; для месяца - 30 дней, для неизвестного
+// интервала - 0
+func (i Interval) Duration() time.Duration {
+	return intervalDurations[i]
+}
+
+// Truncate возвращает время открытия свечи интервала, в которую попадает t. Свечи
+// отсчитываются в UTC, как на бирже: сутки с полуночи, недели с понедельника, месяцы
+// с первого числа. Для неизвестного интервала t возвращается без изменений.
+func (i Interval) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if i == Interval1M {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	d := int64(i.Duration())
+	if d == 0 {
+		return t
+	}
+	var offset int64
+	if i == Interval1w {
+		offset = int64(weekOffset)
+	}
+	n := t.UnixNano() - offset
+	return time.Unix(0, n-n%d+offset).UTC()
+}
+
+// Next возвращает время открытия свечи, следующей за свечой, в которую попадает t
+func (i Interval) Next(t time.Time) time.Time {
+	start := i.Truncate(t)
+	if i == Interval1M {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.Add(i.Duration())
+}
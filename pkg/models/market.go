@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Параметры сводки стакана
+const (
+	bookSummaryDepthPct = 1  // Глубина считается в пределах 1% от средней цены
+	bookSummaryLevels   = 20 // Дисбаланс считается по 20 лучшим уровням
+)
+
+// BookSummary сводка стакана без уровней
+type BookSummary struct {
+	Timestamp time.Time `json:"timestamp"`
+	MidPrice  float64   `json:"mid_price"`
+	Spread    float64   `json:"spread"`
+	SpreadPct float64   `json:"spread_pct"` // Спред в процентах от средней цены
+	BidDepth  float64   `json:"bid_depth"`  // Объем бидов в пределах 1% от средней цены
+	AskDepth  float64   `json:"ask_depth"`  // Объем асков в пределах 1% от средней цены
+	Imbalance float64   `json:"imbalance"`  // Дисбаланс 20 лучших уровней, от -1 до 1
+}
+
+// Summary возвращает сводку стакана
+func (ob *OrderBook) Summary() *BookSummary {
+	summary := &BookSummary{
+		Timestamp: ob.Timestamp,
+		MidPrice:  ob.MidPrice(),
+		Spread:    ob.Spread(),
+		Imbalance: ob.Imbalance(bookSummaryLevels),
+	}
+	if summary.MidPrice > 0 {
+		summary.SpreadPct = summary.Spread / summary.MidPrice * 100
+	}
+	summary.BidDepth, summary.AskDepth = ob.DepthWithin(bookSummaryDepthPct)
+	return summary
+}
+
+// MarketState снимок рынка символа, по которому рассчитан сигнал цикла анализа:
+// последняя свеча, сводка стакана, ставка финансирования, открытый интерес и
+// производные показатели. Части, которые не удалось прочитать, равны nil.
+type MarketState struct {
+	Symbol       string        `json:"symbol"`
+	Timestamp    time.Time     `json:"timestamp"`
+	SignalID     string        `json:"signal_id,omitempty"`
+	Price        float64       `json:"price"` // Закрытие последней свечи; без свечей - средняя цена стакана
+	Candle       *Candle       `json:"candle,omitempty"`
+	Book         *BookSummary  `json:"book,omitempty"`
+	Funding      *FundingRate  `json:"funding,omitempty"`
+	OpenInterest *OpenInterest `json:"open_interest,omitempty"`
+
+	Delta              float64 `json:"delta"`                // Дельта объема последней свечи
+	OpenInterestChange float64 `json:"open_interest_change"` // Изменение открытого интереса к предыдущему значению, %
+	FundingBps         float64 `json:"funding_bps"`          // Ставка финансирования в базисных пунктах
+	FundingAnnualized  float64 `json:"funding_annualized"`   // Годовая ставка финансирования при расчете каждые 8 часов, %
+}
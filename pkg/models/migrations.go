@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"github.com/skalibog/bfma/pkg/migrate"
+)
+
+// Названия моделей в реестре версий: хранилища отмечают ими сохраненные записи
+const (
+	ModelCandle       = "candle"
+	ModelFundingRate  = "funding_rate"
+	ModelOpenInterest = "open_interest"
+	ModelSignal       = "signal"
+	ModelTrade        = "trade"
+	ModelBacktestRun  = "backtest_run"
+)
+
+// Migrations преобразования сохраненных моделей прошлых версий к текущей. Ими
+// читаются и точки InfluxDB, и JSON моделей: если поле в них называется по-разному,
+// преобразование обрабатывает оба названия.
+var Migrations = newMigrations()
+
+// newMigrations регистрирует преобразования версий моделей. Новое преобразование
+// добавляется в конец цепочки модели, старые не меняются: по ним читаются данные,
+// сохраненные прошлыми версиями программы.
+func newMigrations() *migrate.Registry {
+	r := migrate.NewRegistry()
+
+	// Сигнал 1 -> 2: у сигналов до появления кодов рекомендаций код восстанавливается
+	// по тексту рекомендации; сигнал с незнакомым текстом остается без кода
+	r.Register(ModelSignal, 1, func(record migrate.Record) error {
+		if code, _ := record["recommendation_code"].(string); code != "" {
+			return nil
+		}
+		text, _ := record["recommendation"].(string)
+		if code, err := ParseRecommendation(text); err == nil {
+			record["recommendation_code"] = code.String()
+		}
+		return nil
+	})
+
+	// Ставка финансирования 1 -> 2: время следующего расчета хранилось полем next_funding,
+	// которое клиент InfluxDB записывал строкой RFC3339, и при чтении терялось; теперь
+	// это next_funding_ms в миллисекундах Unix. Строка, которую не удалось разобрать,
+	// дает нулевое время.
+	r.Register(ModelFundingRate, 1, migrate.Chain(
+		migrate.Convert("next_funding", func(value interface{}) (interface{}, error) {
+			switch v := value.(type) {
+			case time.Time:
+				return v.UnixMilli(), nil
+			case string:
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					return t.UnixMilli(), nil
+				}
+			}
+			return int64(0), nil
+		}),
+		migrate.Rename("next_funding", "next_funding_ms"),
+	))
+
+	// Свеча 1 -> 2: цены и объемы были числами float64, теперь это десятичные строки
+	r.Register(ModelCandle, 1, decimalStrings(candleDecimalFields...))
+
+	// Свеча 2 -> 3: в InfluxDB свечи версии 2 хранили каждую цену и объем дважды,
+	// числом в поле <поле> и точной строкой в поле <поле>_decimal; теперь только
+	// строкой в поле <поле>. Точная строка заменяет число.
+	r.Register(ModelCandle, 2, func(record migrate.Record) error {
+		for _, field := range candleDecimalFields {
+			if value, ok := record[field+"_decimal"]; ok {
+				record[field] = value
+				delete(record, field+"_decimal")
+			}
+		}
+		return nil
+	})
+
+	// Сделка 1 -> 2: объемы, цены, комиссии и прибыль были числами float64, теперь это
+	// десятичные строки
+	r.Register(ModelTrade, 1, decimalStrings(
+		"quantity", "entry_quantity", "exit_quantity", "entry_price", "exit_price",
+		"fees", "realized_pnl",
+	))
+
+	return r
+}
+
+// candleDecimalFields поля цен и объемов свечи
+var candleDecimalFields = []string{
+	"open", "high", "low", "close", "volume",
+	"quote_volume", "taker_buy_volume", "taker_buy_quote_volume",
+}
+
+// decimalStrings переводит числа float64 в полях fields в десятичные строки по
+// кратчайшему представлению; строки и отсутствующие поля не меняются
+func decimalStrings(fields ...string) migrate.Converter {
+	converters := make([]migrate.Converter, 0, len(fields))
+	for _, field := range fields {
+		converters = append(converters, migrate.Convert(field, func(value interface{}) (interface{}, error) {
+			switch v := value.(type) {
+			case float64:
+				return DecimalFromFloat(v).String(), nil
+			case int64:
+				return DecimalFromFloat(float64(v)).String(), nil
+			}
+			return value, nil
+		}))
+	}
+	return migrate.Chain(converters...)
+}
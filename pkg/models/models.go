@@ -1,58 +1,222 @@
 package models
 
 import (
+	"slices"
 	"time"
 )
 
-// Candle представляет свечу
+// Candle представляет свечу. Цены и объемы хранятся десятичными числами, как их
+// передает биржа; анализаторы переводят их в float64 (InexactFloat64) только для
+// расчета индикаторов.
 type Candle struct {
-	Symbol    string
-	Interval  string
-	OpenTime  time.Time
-	Open      float64
-	High      float64
-	Low       float64
-	Close     float64
-	Volume    float64
-	CloseTime time.Time
+	Symbol    string    `json:"symbol" protobuf:"bytes,1,opt,name=symbol"`
+	Interval  Interval  `json:"interval" protobuf:"bytes,2,opt,name=interval"`
+	OpenTime  time.Time `json:"open_time" protobuf:"bytes,3,opt,name=open_time"`
+	Open      Decimal   `json:"open" protobuf:"bytes,14,opt,name=open"`
+	High      Decimal   `json:"high" protobuf:"bytes,15,opt,name=high"`
+	Low       Decimal   `json:"low" protobuf:"bytes,16,opt,name=low"`
+	Close     Decimal   `json:"close" protobuf:"bytes,17,opt,name=close"`
+	Volume    Decimal   `json:"volume" protobuf:"bytes,18,opt,name=volume"`
+	CloseTime time.Time `json:"close_time" protobuf:"bytes,9,opt,name=close_time"`
+
+	// Объем в валюте котировки, число сделок и объемы агрессивных покупок (taker buy).
+	// У свечей, сохраненных до появления этих полей, они нулевые: см. HasTakerVolume.
+	QuoteVolume         Decimal `json:"quote_volume" protobuf:"bytes,19,opt,name=quote_volume"`
+	NumTrades           int64   `json:"num_trades" protobuf:"varint,11,opt,name=num_trades"`
+	TakerBuyVolume      Decimal `json:"taker_buy_volume" protobuf:"bytes,20,opt,name=taker_buy_volume"`
+	TakerBuyQuoteVolume Decimal `json:"taker_buy_quote_volume" protobuf:"bytes,21,opt,name=taker_buy_quote_volume"`
+}
+
+// HasTakerVolume сообщает, что у свечи есть объемы агрессивных покупок и продаж
+func (c *Candle) HasTakerVolume() bool {
+	return c.NumTrades > 0 || c.TakerBuyVolume.IsPositive()
+}
+
+// TakerSellVolume возвращает объем агрессивных продаж в базовом активе
+func (c *Candle) TakerSellVolume() Decimal {
+	return c.Volume.Sub(c.TakerBuyVolume)
+}
+
+// Delta возвращает дельту объема: агрессивные покупки минус агрессивные продажи
+func (c *Candle) Delta() Decimal {
+	return c.TakerBuyVolume.Sub(c.TakerSellVolume())
 }
 
 // OrderBookLevel представляет уровень стакана
 type OrderBookLevel struct {
-	Price  string
-	Amount string
+	Price  Decimal `json:"price" protobuf:"bytes,1,opt,name=price"`
+	Amount Decimal `json:"amount" protobuf:"bytes,2,opt,name=amount"`
 }
 
 // OrderBook представляет стакан заявок
 type OrderBook struct {
-	Symbol    string
-	Timestamp time.Time
-	Bids      []OrderBookLevel
-	Asks      []OrderBookLevel
+	Symbol    string           `json:"symbol" protobuf:"bytes,1,opt,name=symbol"`
+	Timestamp time.Time        `json:"timestamp" protobuf:"bytes,2,opt,name=timestamp"`
+	Bids      []OrderBookLevel `json:"bids" protobuf:"bytes,3,rep,name=bids"`
+	Asks      []OrderBookLevel `json:"asks" protobuf:"bytes,4,rep,name=asks"`
+
+	// Номер последнего учтенного обновления биржи; 0 - стакан собран без номеров
+	LastUpdateID int64 `json:"last_update_id,omitempty" protobuf:"varint,5,opt,name=last_update_id"`
+}
+
+// OrderBookDelta изменения стакана за одно или несколько обновлений биржи: уровни
+// с новым объемом, нулевой объем удаляет уровень. Номера обновлений - как в потоке
+// изменений стакана Binance: PrevUpdateID равен LastUpdateID предыдущего изменения.
+type OrderBookDelta struct {
+	Symbol        string           `json:"symbol" protobuf:"bytes,1,opt,name=symbol"`
+	Timestamp     time.Time        `json:"timestamp" protobuf:"bytes,2,opt,name=timestamp"`
+	FirstUpdateID int64            `json:"first_update_id" protobuf:"varint,3,opt,name=first_update_id"`
+	LastUpdateID  int64            `json:"last_update_id" protobuf:"varint,4,opt,name=last_update_id"`
+	PrevUpdateID  int64            `json:"prev_update_id" protobuf:"varint,5,opt,name=prev_update_id"`
+	Bids          []OrderBookLevel `json:"bids" protobuf:"bytes,6,rep,name=bids"`
+	Asks          []OrderBookLevel `json:"asks" protobuf:"bytes,7,rep,name=asks"`
 }
 
 // FundingRate представляет ставку финансирования
 type FundingRate struct {
-	Symbol          string
-	Rate            string
-	Timestamp       time.Time
-	NextFundingTime time.Time
+	Symbol          string    `json:"symbol" protobuf:"bytes,1,opt,name=symbol"`
+	Rate            Decimal   `json:"rate" protobuf:"bytes,2,opt,name=rate"`
+	Timestamp       time.Time `json:"timestamp" protobuf:"bytes,3,opt,name=timestamp"`
+	NextFundingTime time.Time `json:"next_funding_time" protobuf:"bytes,4,opt,name=next_funding_time"`
 }
 
 // OpenInterest представляет открытый интерес
 type OpenInterest struct {
-	Symbol    string
-	Value     string
-	Timestamp time.Time
+	Symbol    string    `json:"symbol" protobuf:"bytes,1,opt,name=symbol"`
+	Value     Decimal   `json:"value" protobuf:"bytes,2,opt,name=value"`
+	Timestamp time.Time `json:"timestamp" protobuf:"bytes,3,opt,name=timestamp"`
 }
 
-// SignalResult представляет результат сигнала
+// SignalResult представляет результат сигнала. В Protobuf передается сообщением
+// bfma.v1.Signal: код рекомендации - перечислением Recommendation, текст - полем
+// recommendation_text.
 type SignalResult struct {
-	Symbol         string
-	Timestamp      time.Time
-	Recommendation string
-	SignalStrength float64
-	PositionSize   float64
-	CurrentPrice   float64
-	Components     map[string]float64
-}
\ No newline at end of file
+	Symbol             string             `json:"symbol" protobuf:"bytes,1,opt,name=symbol"`
+	Timestamp          time.Time          `json:"timestamp" protobuf:"bytes,2,opt,name=timestamp"`
+	Recommendation     string             `json:"recommendation" protobuf:"bytes,4,opt,name=recommendation_text"` // Текст для людей; программы сравнивают RecommendationCode
+	RecommendationCode Recommendation     `json:"recommendation_code" protobuf:"varint,3,opt,name=recommendation,enum=bfma.v1.Recommendation"`
+	SignalStrength     float64            `json:"signal_strength" protobuf:"fixed64,5,opt,name=signal_strength"`
+	PositionSize       float64            `json:"position_size" protobuf:"fixed64,6,opt,name=position_size"`
+	CurrentPrice       float64            `json:"current_price" protobuf:"fixed64,7,opt,name=current_price"`
+	Components         map[string]float64 `json:"components" protobuf:"bytes,8,rep,name=components" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+
+	// Условия расчета: по ним сигнал из истории можно понять и после смены настроек.
+	// У сигналов, сохраненных до появления этих полей, они пустые.
+
+	// ID идентификатор сигнала: <символ>-<цикл анализа>, как в аудите циклов
+	ID string `json:"id,omitempty" protobuf:"bytes,9,opt,name=id"`
+	// Intervals интервалы свечей, прочитанных анализаторами
+	Intervals []string `json:"intervals,omitempty" protobuf:"bytes,10,rep,name=intervals"`
+	// DataFrom и DataTo время самых ранних и самых свежих данных анализа
+	DataFrom time.Time `json:"data_from" protobuf:"bytes,11,opt,name=data_from"`
+	DataTo   time.Time `json:"data_to" protobuf:"bytes,12,opt,name=data_to"`
+	// EngineVersion версия bfma, рассчитавшей сигнал
+	EngineVersion string `json:"engine_version,omitempty" protobuf:"bytes,13,opt,name=engine_version"`
+	// ConfigHash отпечаток действующих настроек анализа символа
+	ConfigHash string `json:"config_hash,omitempty" protobuf:"bytes,14,opt,name=config_hash"`
+	// Weights веса компонентов с учетом ручных поправок
+	Weights map[string]float64 `json:"weights,omitempty" protobuf:"bytes,15,rep,name=weights" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	// Confidence уверенность от 0 до 1: доля веса компонентов с данными, умноженная
+	// на согласованность их направлений
+	Confidence float64 `json:"confidence" protobuf:"fixed64,16,opt,name=confidence"`
+	// Flags признаки SignalFlag*
+	Flags []string `json:"flags,omitempty" protobuf:"bytes,17,rep,name=flags"`
+}
+
+// Признаки сигнала, измененного или рассчитанного не полностью
+const (
+	SignalFlagForced     = "forced"     // Рекомендация задана ручной поправкой
+	SignalFlagReweighted = "reweighted" // Веса компонентов изменены ручной поправкой
+	SignalFlagDegraded   = "degraded"   // Часть анализаторов не рассчитана
+	SignalFlagLongOnly   = "long_only"  // Сигнал на продажу снят ограничением analysis.bias
+	SignalFlagShortOnly  = "short_only" // Сигнал на покупку снят ограничением analysis.bias
+	SignalFlagCapped     = "capped"     // Сила сигнала ограничена analysis.bias.max_strength
+)
+
+// HasFlag сообщает, что у сигнала есть признак flag
+func (s *SignalResult) HasFlag(flag string) bool {
+	return slices.Contains(s.Flags, flag)
+}
+
+// Note заметка пользователя к символу или к конкретному сигналу
+type Note struct {
+	Symbol     string
+	Timestamp  time.Time
+	Text       string
+	SignalTime time.Time // Время сигнала, к которому относится заметка (нулевое - заметка к символу)
+}
+
+// Event запись журнала событий: смена сигнала, оповещение, перезапуск сборщиков,
+// перезагрузка конфигурации, торговая операция
+type Event struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Type      string            `json:"type"`
+	Symbol    string            `json:"symbol,omitempty"` // Пусто - событие не относится к символу
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// AnalysisCycle запись аудита цикла анализа: что рассчитывалось, сколько длилось и что
+// не удалось. По ней разбираются медленные циклы и частичные сбои.
+type AnalysisCycle struct {
+	ID       string
+	Started  time.Time
+	Duration time.Duration
+	Symbols  []string       // Символы, для которых рассчитывался сигнал
+	Skipped  []string       // Приостановленные символы
+	Failures []CycleFailure // Ошибки анализаторов и расчета сигналов
+	Queries  int            // Запросов к хранилищу за цикл
+}
+
+// CycleFailure ошибка анализатора или расчета сигнала символа в цикле анализа
+type CycleFailure struct {
+	Symbol    string
+	Component string // technical, orderbook, funding, openInterest, volumeDelta; пусто - сигнал символа не рассчитан
+	Error     string
+	Timeout   bool // Истек срок запроса
+}
+
+// Position представляет открытую позицию на фьючерсном счете или открытый объем
+// бумажной сделки (Trade.Position)
+type Position struct {
+	Symbol        string
+	Side          Side
+	Amount        Decimal
+	EntryPrice    Decimal
+	MarkPrice     Decimal
+	UnrealizedPnL Decimal
+	RealizedPnL   Decimal // Зафиксированная прибыль частичных выходов; биржа не передает
+	Fees          Decimal // Комиссии; биржа не передает
+	Leverage      int
+	UpdateTime    time.Time
+}
+
+// Mark пересчитывает нереализованную прибыль по цене mark
+func (p *Position) Mark(mark Decimal) {
+	p.MarkPrice = mark
+	p.UnrealizedPnL = signed(p.Side, mark.Sub(p.EntryPrice).Mul(p.Amount))
+}
+
+// Notional возвращает стоимость позиции по последней цене
+func (p *Position) Notional() Decimal {
+	return p.Amount.Mul(p.MarkPrice)
+}
+
+// OrderTicket описывает заявку на открытие позиции со стоп-лоссом и тейк-профитом
+type OrderTicket struct {
+	Symbol     string
+	Side       Side
+	Quantity   float64
+	EntryPrice float64 // Ориентировочная цена входа (вход по рынку)
+	StopLoss   float64
+	TakeProfit float64
+}
+
+// SymbolFilters ограничения биржи на цену и объем заявок символа
+type SymbolFilters struct {
+	Symbol      string
+	StepSize    float64 // Шаг объема
+	MinQuantity float64
+	MinNotional float64 // Минимальная стоимость заявки; 0 - не ограничена
+	TickSize    float64 // Шаг цены
+}
@@ -0,0 +1,263 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ErrOrderBookGap изменение стакана не продолжает последнее учтенное обновление:
+// часть обновлений пропущена, стакан нужно загрузить заново
+var ErrOrderBookGap = errors.New("пропущены обновления стакана")
+
+// PriceLevel уровень стакана в числах для расчетов
+type PriceLevel struct {
+	Price  float64
+	Amount float64
+}
+
+// Levels переводит десятичные уровни стакана в числа: биды по убыванию цены, аски
+// по возрастанию, лучшие уровни первыми
+func (ob *OrderBook) Levels() (bids, asks []PriceLevel) {
+	return floatLevels(ob.Bids, true), floatLevels(ob.Asks, false)
+}
+
+// floatLevels переводит уровни в числа и сортирует их от лучшей цены
+func floatLevels(levels []OrderBookLevel, descending bool) []PriceLevel {
+	result := make([]PriceLevel, len(levels))
+	for i, level := range levels {
+		result[i] = PriceLevel{
+			Price:  level.Price.InexactFloat64(),
+			Amount: level.Amount.InexactFloat64(),
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if descending {
+			return result[i].Price > result[j].Price
+		}
+		return result[i].Price < result[j].Price
+	})
+	return result
+}
+
+// best возвращает лучшие цены бида и аска; ok false - одна из сторон пуста
+func (ob *OrderBook) best() (bid, ask float64, ok bool) {
+	if len(ob.Bids) == 0 || len(ob.Asks) == 0 {
+		return 0, 0, false
+	}
+	bid = ob.Bids[0].Price.InexactFloat64()
+	for _, level := range ob.Bids[1:] {
+		bid = max(bid, level.Price.InexactFloat64())
+	}
+	ask = ob.Asks[0].Price.InexactFloat64()
+	for _, level := range ob.Asks[1:] {
+		ask = min(ask, level.Price.InexactFloat64())
+	}
+	return bid, ask, true
+}
+
+// MidPrice возвращает среднюю цену между лучшим бидом и лучшим аском; 0 - одна из
+// сторон стакана пуста
+func (ob *OrderBook) MidPrice() float64 {
+	bid, ask, ok := ob.best()
+	if !ok {
+		return 0
+	}
+	return (bid + ask) / 2
+}
+
+// Spread возвращает разницу лучшего аска и лучшего бида в валюте котировки; 0 - одна
+// из сторон стакана пуста
+func (ob *OrderBook) Spread() float64 {
+	bid, ask, ok := ob.best()
+	if !ok {
+		return 0
+	}
+	return ask - bid
+}
+
+// DepthWithin возвращает объемы бидов и асков в пределах pct процентов от средней цены
+func (ob *OrderBook) DepthWithin(pct float64) (bidVolume, askVolume float64) {
+	mid := ob.MidPrice()
+	if mid == 0 {
+		return 0, 0
+	}
+	limit := pct / 100
+	for _, level := range ob.Bids {
+		if 1-level.Price.InexactFloat64()/mid <= limit {
+			bidVolume += level.Amount.InexactFloat64()
+		}
+	}
+	for _, level := range ob.Asks {
+		if level.Price.InexactFloat64()/mid-1 <= limit {
+			askVolume += level.Amount.InexactFloat64()
+		}
+	}
+	return bidVolume, askVolume
+}
+
+// Imbalance возвращает дисбаланс объемов levels лучших уровней каждой стороны от -1
+// (только аски) до 1 (только биды); levels <= 0 - все уровни. Пустой стакан - 0.
+func (ob *OrderBook) Imbalance(levels int) float64 {
+	bids, asks := ob.Levels()
+	bidVolume, askVolume := sumAmounts(bids, levels), sumAmounts(asks, levels)
+	if bidVolume+askVolume == 0 {
+		return 0
+	}
+	return (bidVolume - askVolume) / (bidVolume + askVolume)
+}
+
+// sumAmounts суммирует объемы первых count уровней; count <= 0 - всех уровней
+func sumAmounts(levels []PriceLevel, count int) float64 {
+	if count <= 0 || count > len(levels) {
+		count = len(levels)
+	}
+	var total float64
+	for _, level := range levels[:count] {
+		total += level.Amount
+	}
+	return total
+}
+
+// VWAP возвращает среднюю цену рыночной заявки объемом qty: покупка (PositionSideLong)
+// исполняется по аскам, продажа (PositionSideShort) - по бидам. ok false - глубины
+// стакана не хватает на весь объем, цена тогда рассчитана по всей доступной глубине.
+func (ob *OrderBook) VWAP(side Side, qty float64) (price float64, ok bool) {
+	bids, asks := ob.Levels()
+	levels := asks
+	if side == PositionSideShort {
+		levels = bids
+	}
+
+	var filled, notional float64
+	for _, level := range levels {
+		take := min(level.Amount, qty-filled)
+		filled += take
+		notional += take * level.Price
+		if filled >= qty {
+			break
+		}
+	}
+	if filled == 0 {
+		return 0, false
+	}
+	return notional / filled, filled >= qty
+}
+
+// Top возвращает копию стакана с depth лучшими уровнями каждой стороны, отсортированными
+// от лучшей цены; depth <= 0 - все уровни
+func (ob *OrderBook) Top(depth int) *OrderBook {
+	top := *ob
+	top.Bids = topLevels(ob.Bids, depth, true)
+	top.Asks = topLevels(ob.Asks, depth, false)
+	return &top
+}
+
+// topLevels копирует и сортирует уровни от лучшей цены, оставляя первые depth
+func topLevels(levels []OrderBookLevel, depth int, descending bool) []OrderBookLevel {
+	result := slices.Clone(levels)
+	sortLevels(result, descending)
+	if depth > 0 && depth < len(result) {
+		result = result[:depth]
+	}
+	return result
+}
+
+// sortLevels сортирует уровни от лучшей цены: биды по убыванию, аски по возрастанию
+func sortLevels(levels []OrderBookLevel, descending bool) {
+	slices.SortFunc(levels, func(a, b OrderBookLevel) int {
+		if descending {
+			return b.Price.Cmp(a.Price)
+		}
+		return a.Price.Cmp(b.Price)
+	})
+}
+
+// Apply применяет изменение к стакану. Изменение, уже учтенное стаканом (снимок
+// новее), пропускается с applied false. Изменение, после которого пропущены
+// обновления, не применяется и возвращает ErrOrderBookGap. Стакан без номера
+// обновления (LastUpdateID 0) принимает любое изменение.
+func (ob *OrderBook) Apply(delta *OrderBookDelta) (applied bool, err error) {
+	if delta.Symbol != ob.Symbol {
+		return false, fmt.Errorf("изменение стакана %s не относится к стакану %s", delta.Symbol, ob.Symbol)
+	}
+	if ob.LastUpdateID != 0 {
+		if delta.LastUpdateID <= ob.LastUpdateID {
+			return false, nil
+		}
+		// Первое изменение после снимка перекрывает его номер, следующие продолжают
+		// предыдущее изменение
+		if delta.PrevUpdateID != ob.LastUpdateID && delta.FirstUpdateID > ob.LastUpdateID {
+			return false, fmt.Errorf("%w %s: ожидалось продолжение %d, получено %d-%d",
+				ErrOrderBookGap, ob.Symbol, ob.LastUpdateID, delta.FirstUpdateID, delta.LastUpdateID)
+		}
+	}
+
+	ob.Bids = applyLevels(ob.Bids, delta.Bids, true)
+	ob.Asks = applyLevels(ob.Asks, delta.Asks, false)
+	ob.LastUpdateID = delta.LastUpdateID
+	ob.Timestamp = delta.Timestamp
+	return true, nil
+}
+
+// applyLevels заменяет объемы уровней, удаляет уровни с нулевым объемом и сортирует
+// результат от лучшей цены
+func applyLevels(levels, changes []OrderBookLevel, descending bool) []OrderBookLevel {
+	for _, change := range changes {
+		i := slices.IndexFunc(levels, func(level OrderBookLevel) bool { return level.Price.Equal(change.Price) })
+		switch {
+		case i < 0 && !change.Amount.IsZero():
+			levels = append(levels, change)
+		case i >= 0 && change.Amount.IsZero():
+			levels = slices.Delete(levels, i, i+1)
+		case i >= 0:
+			levels[i].Amount = change.Amount
+		}
+	}
+	sortLevels(levels, descending)
+	return levels
+}
+
+// DiffOrderBooks возвращает изменение, превращающее стакан prev в next: новые уровни и уровни
+// с другим объемом, а уровни, которых нет в next, - с нулевым объемом. Применяется
+// и к стаканам, обрезанным Top: уровень, ушедший за глубину, удаляется.
+func DiffOrderBooks(prev, next *OrderBook) *OrderBookDelta {
+	return &OrderBookDelta{
+		Symbol:        next.Symbol,
+		Timestamp:     next.Timestamp,
+		FirstUpdateID: prev.LastUpdateID + 1,
+		LastUpdateID:  next.LastUpdateID,
+		PrevUpdateID:  prev.LastUpdateID,
+		Bids:          diffLevels(prev.Bids, next.Bids),
+		Asks:          diffLevels(prev.Asks, next.Asks),
+	}
+}
+
+// diffLevels возвращает изменения уровней одной стороны стакана
+func diffLevels(prev, next []OrderBookLevel) []OrderBookLevel {
+	amounts := make(map[string]Decimal, len(prev))
+	for _, level := range prev {
+		amounts[level.Price.String()] = level.Amount
+	}
+
+	var changes []OrderBookLevel
+	for _, level := range next {
+		key := level.Price.String()
+		if amount, ok := amounts[key]; !ok || !amount.Equal(level.Amount) {
+			changes = append(changes, level)
+		}
+		delete(amounts, key)
+	}
+	for _, level := range prev {
+		if _, removed := amounts[level.Price.String()]; removed {
+			changes = append(changes, OrderBookLevel{Price: level.Price, Amount: ZeroDecimal})
+		}
+	}
+	return changes
+}
+
+// Empty сообщает, что изменение не затрагивает ни одного уровня
+func (d *OrderBookDelta) Empty() bool {
+	return len(d.Bids) == 0 && len(d.Asks) == 0
+}
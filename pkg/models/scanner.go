@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MarketTicker суточная статистика символа из общего запроса по всем символам биржи
+type MarketTicker struct {
+	Symbol         string    `json:"symbol"`
+	LastPrice      float64   `json:"last_price"`
+	PriceChangePct float64   `json:"price_change_pct"` // Изменение цены за 24 часа, %
+	QuoteVolume    float64   `json:"quote_volume"`     // Оборот за 24 часа в валюте котировки
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// AnomalyKind вид аномалии, найденной обзором рынка
+type AnomalyKind string
+
+// Виды аномалий обзора рынка
+const (
+	AnomalyVolume       AnomalyKind = "volume"        // Резкий рост суточного оборота
+	AnomalyOpenInterest AnomalyKind = "open_interest" // Резкое изменение открытого интереса
+	AnomalyFunding      AnomalyKind = "funding"       // Крайняя ставка финансирования
+)
+
+// ScanCandidate символ вне отслеживаемых, у которого обзор рынка нашел аномалии:
+// кандидат в список наблюдения
+type ScanCandidate struct {
+	Symbol                string        `json:"symbol"`
+	Anomalies             []AnomalyKind `json:"anomalies"`
+	Price                 float64       `json:"price"`
+	PriceChangePct        float64       `json:"price_change_pct"`                   // За 24 часа, %
+	QuoteVolume           float64       `json:"quote_volume"`                       // Оборот за 24 часа
+	VolumeGrowthPct       float64       `json:"volume_growth_pct"`                  // Рост суточного оборота с прошлого обзора, %
+	OpenInterestChangePct float64       `json:"open_interest_change_pct,omitempty"` // Изменение открытого интереса с прошлого обзора, %
+	FundingBps            float64       `json:"funding_bps"`                        // Ставка финансирования, б.п.
+	Score                 float64       `json:"score"`                              // Сумма превышений порогов: больше - необычнее
+	DetectedAt            time.Time     `json:"detected_at"`
+}
+
+// Has сообщает, что у кандидата найдена аномалия kind
+func (c *ScanCandidate) Has(kind AnomalyKind) bool {
+	return slices.Contains(c.Anomalies, kind)
+}
+
+// Describe возвращает описание аномалий кандидата для оповещения
+func (c *ScanCandidate) Describe() string {
+	var parts []string
+	if c.Has(AnomalyVolume) {
+		parts = append(parts, fmt.Sprintf("оборот +%.0f%%", c.VolumeGrowthPct))
+	}
+	if c.Has(AnomalyOpenInterest) {
+		parts = append(parts, fmt.Sprintf("открытый интерес %+.1f%%", c.OpenInterestChangePct))
+	}
+	if c.Has(AnomalyFunding) {
+		parts = append(parts, fmt.Sprintf("финансирование %+.1f б.п.", c.FundingBps))
+	}
+	return fmt.Sprintf("кандидат в список наблюдения: %s (цена %+.1f%% за 24ч)", strings.Join(parts, ", "), c.PriceChangePct)
+}
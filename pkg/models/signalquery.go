@@ -0,0 +1,175 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ограничения страницы истории сигналов
+const (
+	DefaultSignalPageSize = 100
+	MaxSignalPageSize     = 1000
+)
+
+// Период выборки истории сигналов по умолчанию
+const defaultSignalQueryPeriod = 30 * 24 * time.Hour
+
+// SignalOrder порядок сигналов в выборке
+type SignalOrder string
+
+// Порядки выборки: по времени сигнала, при равном времени - по символу
+const (
+	SignalOrderDesc SignalOrder = "desc" // Новые первыми, по умолчанию
+	SignalOrderAsc  SignalOrder = "asc"  // Старые первыми
+)
+
+// SignalQuery условия выборки истории сигналов по страницам. Сигналы упорядочены по
+// времени, при равном времени - по символу, поэтому курсор однозначно указывает
+// место продолжения: сигналы, сохраненные во время обхода, не сдвигают страницы.
+type SignalQuery struct {
+	Symbols         []string         // Пусто - все символы
+	From            time.Time        // Начало периода включительно; нулевое - 30 дней до To
+	To              time.Time        // Конец периода не включительно; нулевое - текущий момент
+	MinStrength     float64          // Модуль силы сигнала не меньше; 0 - без ограничения
+	Recommendations []Recommendation // Пусто - все рекомендации
+	Order           SignalOrder      // Пусто - SignalOrderDesc
+	Cursor          string           // NextCursor предыдущей страницы; пусто - первая страница
+	Limit           int              // 1..MaxSignalPageSize; 0 - DefaultSignalPageSize
+}
+
+// SignalPage страница истории сигналов
+type SignalPage struct {
+	Signals    []*SignalResult `json:"signals"`
+	NextCursor string          `json:"next_cursor,omitempty"` // Пусто - страниц больше нет
+}
+
+// SignalCursor место продолжения выборки: время и символ последнего сигнала страницы
+type SignalCursor struct {
+	Timestamp time.Time
+	Symbol    string
+}
+
+// Normalize проверяет условия и подставляет значения по умолчанию
+func (q *SignalQuery) Normalize() error {
+	switch q.Order {
+	case "":
+		q.Order = SignalOrderDesc
+	case SignalOrderAsc, SignalOrderDesc:
+	default:
+		return fmt.Errorf("неизвестный порядок %q, допустимы asc, desc", q.Order)
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultSignalPageSize
+	case q.Limit < 0 || q.Limit > MaxSignalPageSize:
+		return fmt.Errorf("limit должен быть в диапазоне 1..%d, задано %d", MaxSignalPageSize, q.Limit)
+	}
+
+	if q.MinStrength < 0 || q.MinStrength > 100 {
+		return fmt.Errorf("min_strength должен быть в диапазоне 0..100, задано %v", q.MinStrength)
+	}
+	for _, r := range q.Recommendations {
+		if !r.Valid() {
+			return fmt.Errorf("неизвестная рекомендация %q", r)
+		}
+	}
+	symbols := make([]string, len(q.Symbols))
+	for i, symbol := range q.Symbols {
+		symbols[i] = strings.ToUpper(symbol)
+	}
+	q.Symbols = symbols
+
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-defaultSignalQueryPeriod)
+	}
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("начало периода %s должно быть раньше конца %s", q.From.Format(time.RFC3339), q.To.Format(time.RFC3339))
+	}
+
+	if _, err := q.ParseCursor(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseCursor разбирает курсор выборки; nil - первая страница
+func (q *SignalQuery) ParseCursor() (*SignalCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("неверный курсор %q", q.Cursor)
+	}
+	nanos, symbol, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return nil, fmt.Errorf("неверный курсор %q", q.Cursor)
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("неверный курсор %q", q.Cursor)
+	}
+	return &SignalCursor{Timestamp: time.Unix(0, n).UTC(), Symbol: symbol}, nil
+}
+
+// String возвращает курсор в виде строки для SignalQuery.Cursor
+func (c SignalCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.Timestamp.UnixNano(), 10) + "|" + c.Symbol))
+}
+
+// CursorOf возвращает курсор, продолжающий выборку после сигнала
+func CursorOf(signal *SignalResult) string {
+	return SignalCursor{Timestamp: signal.Timestamp, Symbol: signal.Symbol}.String()
+}
+
+// Less сообщает, что сигнал a идет в выборке раньше сигнала b
+func (q *SignalQuery) Less(a, b *SignalResult) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp) == (q.Order == SignalOrderAsc)
+	}
+	if a.Symbol == b.Symbol {
+		return false
+	}
+	return (a.Symbol < b.Symbol) == (q.Order == SignalOrderAsc)
+}
+
+// Match сообщает, что сигнал подходит под условия выборки и идет после курсора cursor
+func (q *SignalQuery) Match(signal *SignalResult, cursor *SignalCursor) bool {
+	switch {
+	case len(q.Symbols) > 0 && !slices.Contains(q.Symbols, signal.Symbol):
+		return false
+	case signal.Timestamp.Before(q.From) || !signal.Timestamp.Before(q.To):
+		return false
+	case math.Abs(signal.SignalStrength) < q.MinStrength:
+		return false
+	case len(q.Recommendations) > 0 && !slices.Contains(q.Recommendations, signal.RecommendationCode):
+		return false
+	}
+	if cursor == nil {
+		return true
+	}
+	return q.Less(&SignalResult{Timestamp: cursor.Timestamp, Symbol: cursor.Symbol}, signal)
+}
+
+// Page возвращает страницу из упорядоченных сигналов, подходящих под условия: если
+// их больше Limit, курсор указывает на последний сигнал страницы
+func (q *SignalQuery) Page(signals []*SignalResult) *SignalPage {
+	page := &SignalPage{Signals: signals}
+	if len(signals) > q.Limit {
+		page.Signals = signals[:q.Limit]
+		page.NextCursor = CursorOf(page.Signals[q.Limit-1])
+	}
+	if page.Signals == nil {
+		page.Signals = []*SignalResult{}
+	}
+	return page
+}
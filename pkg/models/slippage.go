@@ -0,0 +1,118 @@
+package models
+
+import (
+	"math"
+	"sort"
+)
+
+// Buy сообщает, что исполнение покупает: вход в длинную позицию или выход из короткой
+func (f *Fill) Buy() bool {
+	return (f.Side == PositionSideLong) != f.Reduce
+}
+
+// Slippage возвращает проскальзывание исполнения относительно ReferencePrice в базисных
+// пунктах: положительное - цена хуже расчетной. 0 - расчетная цена не задана.
+func (f *Fill) Slippage() float64 {
+	if f.ReferencePrice.IsZero() {
+		return 0
+	}
+	slippage := f.Price.Div(f.ReferencePrice).InexactFloat64()*10000 - 10000
+	if !f.Buy() {
+		slippage = -slippage
+	}
+	return slippage
+}
+
+// SlippageCost возвращает потери от проскальзывания в валюте котировки; отрицательное
+// значение - исполнение лучше расчетной цены
+func (f *Fill) SlippageCost() Decimal {
+	if f.ReferencePrice.IsZero() {
+		return ZeroDecimal
+	}
+	cost := f.Price.Sub(f.ReferencePrice).Mul(f.Quantity)
+	if !f.Buy() {
+		cost = cost.Neg()
+	}
+	return cost
+}
+
+// SimulateFill возвращает исполнение рыночной заявки по глубине стакана вместо
+// расчетной цены fill.Price (например, закрытия свечи): цена - средняя по уровням,
+// которые съедает объем заявки, а расчетная цена сохраняется в ReferencePrice.
+// ok false - глубины стакана не хватило на весь объем: цена рассчитана по всей
+// доступной глубине, а при пустой стороне стакана исполнение остается по расчетной цене.
+func (ob *OrderBook) SimulateFill(fill Fill) (Fill, bool) {
+	if fill.ReferencePrice.IsZero() {
+		fill.ReferencePrice = fill.Price
+	}
+	// Уровни копируются отсортированными от лучшей цены; расчет идет в Decimal,
+	// чтобы цена исполнения не отличалась от цены уровня из-за округления
+	book := ob.Top(0)
+	levels := book.Bids
+	if fill.Buy() {
+		levels = book.Asks
+	}
+
+	filled, notional := ZeroDecimal, ZeroDecimal
+	for _, level := range levels {
+		take := fill.Quantity.Sub(filled)
+		if level.Amount.LessThan(take) {
+			take = level.Amount
+		}
+		filled = filled.Add(take)
+		notional = notional.Add(take.Mul(level.Price))
+		if !filled.LessThan(fill.Quantity) {
+			break
+		}
+	}
+	if filled.IsPositive() {
+		fill.Price = notional.Div(filled)
+	}
+	return fill, filled.IsPositive() && !filled.LessThan(fill.Quantity)
+}
+
+// SlippageStats проскальзывание исполнений символа
+type SlippageStats struct {
+	Symbol   string  `json:"symbol"`
+	Fills    int     `json:"fills"`    // Исполнений с расчетной ценой
+	Average  float64 `json:"average"`  // Среднее проскальзывание, б.п.
+	Weighted float64 `json:"weighted"` // Среднее, взвешенное по стоимости исполнений, б.п.
+	Max      float64 `json:"max"`      // Наибольшее проскальзывание, б.п.
+	Cost     Decimal `json:"cost"`     // Потери от проскальзывания в валюте котировки
+}
+
+// ComputeSlippageStats рассчитывает проскальзывание по символам, символы по алфавиту.
+// Исполнения без расчетной цены (ReferencePrice 0) не учитываются.
+func ComputeSlippageStats(fills []Fill) []SlippageStats {
+	bySymbol := make(map[string]*SlippageStats)
+	notional := make(map[string]float64)
+	for _, fill := range fills {
+		if fill.ReferencePrice.IsZero() {
+			continue
+		}
+		stats, ok := bySymbol[fill.Symbol]
+		if !ok {
+			stats = &SlippageStats{Symbol: fill.Symbol, Max: math.Inf(-1)}
+			bySymbol[fill.Symbol] = stats
+		}
+		slippage := fill.Slippage()
+		value := fill.Notional().InexactFloat64()
+		stats.Fills++
+		stats.Average += slippage
+		stats.Weighted += slippage * value
+		stats.Max = math.Max(stats.Max, slippage)
+		stats.Cost = stats.Cost.Add(fill.SlippageCost())
+		notional[fill.Symbol] += value
+	}
+
+	result := make([]SlippageStats, 0, len(bySymbol))
+	for symbol, stats := range bySymbol {
+		stats.Average /= float64(stats.Fills)
+		if notional[symbol] > 0 {
+			stats.Weighted /= notional[symbol]
+		}
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Статусы символов Binance Futures
+const (
+	SymbolStatusTrading        = "TRADING"
+	SymbolStatusPendingTrading = "PENDING_TRADING"
+	SymbolStatusSettling       = "SETTLING" // Расчет перед делистингом
+	SymbolStatusClose          = "CLOSE"    // Торги закрыты, символ снят с биржи
+)
+
+// Тип бессрочного контракта Binance Futures
+const ContractPerpetual = "PERPETUAL"
+
+// SymbolInfo параметры символа из exchangeInfo биржи
+type SymbolInfo struct {
+	Symbol            string        `json:"symbol"`
+	BaseAsset         string        `json:"base_asset"`
+	QuoteAsset        string        `json:"quote_asset"`
+	MarginAsset       string        `json:"margin_asset"`
+	ContractType      string        `json:"contract_type"` // PERPETUAL, CURRENT_QUARTER, NEXT_QUARTER
+	Status            string        `json:"status"`        // SymbolStatus*
+	PricePrecision    int           `json:"price_precision"`
+	QuantityPrecision int           `json:"quantity_precision"`
+	TickSize          float64       `json:"tick_size"` // Шаг цены
+	StepSize          float64       `json:"step_size"` // Шаг объема
+	MinQuantity       float64       `json:"min_quantity"`
+	MinNotional       float64       `json:"min_notional"` // Минимальная стоимость заявки в валюте котировки
+	OnboardDate       time.Time     `json:"onboard_date"`
+	DeliveryDate      time.Time     `json:"delivery_date"`              // У бессрочных контрактов - дата в далеком будущем
+	FundingInterval   time.Duration `json:"funding_interval,omitempty"` // Период финансирования бессрочного контракта; 0 - неизвестен
+	UpdateTime        time.Time     `json:"update_time"`                // Когда параметры получены с биржи
+}
+
+// Trading сообщает, что символ торгуется
+func (s *SymbolInfo) Trading() bool {
+	return s.Status == SymbolStatusTrading
+}
+
+// Filters возвращает ограничения символа на цену и объем заявок
+func (s *SymbolInfo) Filters() *SymbolFilters {
+	return &SymbolFilters{
+		Symbol:      s.Symbol,
+		StepSize:    s.StepSize,
+		MinQuantity: s.MinQuantity,
+		MinNotional: s.MinNotional,
+		TickSize:    s.TickSize,
+	}
+}
+
+// FormatPrice форматирует цену с точностью символа
+func (s *SymbolInfo) FormatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', s.PricePrecision, 64)
+}
+
+// FormatQuantity форматирует объем с точностью символа
+func (s *SymbolInfo) FormatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', s.QuantityPrecision, 64)
+}
+
+// Validate проверяет, что символ торгуется, а заявка объемом quantity по цене price
+// проходит ограничения биржи на минимальный объем и стоимость
+func (s *SymbolInfo) Validate(quantity, price float64) error {
+	switch {
+	case !s.Trading():
+		return fmt.Errorf("символ %s не торгуется (статус %s)", s.Symbol, s.Status)
+	case quantity < s.MinQuantity:
+		return fmt.Errorf("объем %s меньше минимального %s для %s", s.FormatQuantity(quantity), s.FormatQuantity(s.MinQuantity), s.Symbol)
+	case s.MinNotional > 0 && quantity*price < s.MinNotional:
+		return fmt.Errorf("стоимость заявки %.2f %s меньше минимальной %.2f для %s", quantity*price, s.QuoteAsset, s.MinNotional, s.Symbol)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Источники сделок
+const (
+	TradeSourceExchange = "exchange" // Сделка на бирже
+	TradeSourcePaper    = "paper"    // Бумажная торговля по сигналам в реальном времени
+	TradeSourceBacktest = "backtest" // Проверка стратегии на истории
+)
+
+// Fill исполнение заявки по одной цене: вход в сделку (увеличение позиции) или
+// выход из нее (сокращение позиции). Цены, объемы и комиссии - десятичные числа,
+// как у свечей и стакана: частичные выходы и усреднение входов не накапливают
+// ошибку округления, и позиция закрывается ровно в ноль.
+type Fill struct {
+	ID       string  `json:"id"` // По умолчанию <сделка>-<номер исполнения>
+	TradeID  string  `json:"trade_id"`
+	Symbol   string  `json:"symbol"`
+	Side     Side    `json:"side"`   // Сторона позиции, а не направление заявки
+	Reduce   bool    `json:"reduce"` // Выход: исполнение сокращает позицию
+	Price    Decimal `json:"price"`
+	Quantity Decimal `json:"quantity"` // Объем в базовом активе, больше 0
+	Fee      Decimal `json:"fee"`      // Комиссия в валюте котировки
+	// Расчетная цена без проскальзывания, например закрытие свечи; 0 - исполнение
+	// не моделировалось
+	ReferencePrice Decimal   `json:"reference_price"`
+	Time           time.Time `json:"time"`
+}
+
+// Notional возвращает стоимость исполнения в валюте котировки
+func (f *Fill) Notional() Decimal {
+	return f.Price.Mul(f.Quantity)
+}
+
+// Trade сделка от первого входа до полного выхода. Цена входа - средняя по открытому
+// объему: частичный выход фиксирует прибыль и не меняет цену входа.
+type Trade struct {
+	ID            string    `json:"id"` // <символ>-<время первого входа>
+	Symbol        string    `json:"symbol"`
+	Side          Side      `json:"side"`
+	Source        string    `json:"source"`              // TradeSource*
+	SignalID      string    `json:"signal_id,omitempty"` // Сигнал, по которому открыта сделка
+	Quantity      Decimal   `json:"quantity"`            // Открытый объем
+	EntryQuantity Decimal   `json:"entry_quantity"`      // Объем всех входов
+	ExitQuantity  Decimal   `json:"exit_quantity"`       // Объем всех выходов
+	EntryPrice    Decimal   `json:"entry_price"`         // Средняя цена входа
+	ExitPrice     Decimal   `json:"exit_price"`          // Средняя цена выхода; 0 - выходов не было
+	Fees          Decimal   `json:"fees"`                // Комиссии всех исполнений
+	RealizedPnL   Decimal   `json:"realized_pnl"`        // Зафиксированная прибыль без комиссий
+	OpenTime      time.Time `json:"open_time"`
+	CloseTime     time.Time `json:"close_time"` // Нулевое - сделка открыта
+	Fills         []Fill    `json:"fills,omitempty"`
+}
+
+// NewTrade открывает сделку первым входом fill
+func NewTrade(source string, fill Fill) (*Trade, error) {
+	if fill.Reduce {
+		return nil, fmt.Errorf("сделка %s открывается входом, а не выходом", fill.Symbol)
+	}
+	trade := &Trade{
+		ID:     fill.Symbol + "-" + fill.Time.UTC().Format("20060102T150405.000Z"),
+		Symbol: fill.Symbol,
+		Side:   fill.Side,
+		Source: source,
+	}
+	if err := trade.Apply(fill); err != nil {
+		return nil, err
+	}
+	return trade, nil
+}
+
+// Apply учитывает исполнение: вход усредняет цену входа, выход фиксирует прибыль
+// и закрывает сделку, когда открытый объем исчерпан
+func (t *Trade) Apply(fill Fill) error {
+	switch {
+	case fill.Symbol != t.Symbol || fill.Side != t.Side:
+		return fmt.Errorf("исполнение %s %s не относится к сделке %s %s", fill.Symbol, fill.Side, t.Symbol, t.Side)
+	case !fill.Price.IsPositive() || !fill.Quantity.IsPositive():
+		return fmt.Errorf("исполнение %s: цена и объем должны быть больше 0", fill.Symbol)
+	case t.Closed():
+		return fmt.Errorf("сделка %s уже закрыта", t.ID)
+	case fill.Reduce && fill.Quantity.GreaterThan(t.Quantity):
+		return fmt.Errorf("выход %s больше открытого объема %s сделки %s", fill.Quantity, t.Quantity, t.ID)
+	}
+
+	if fill.Reduce {
+		t.RealizedPnL = t.RealizedPnL.Add(signed(t.Side, fill.Price.Sub(t.EntryPrice).Mul(fill.Quantity)))
+		exitQuantity := t.ExitQuantity.Add(fill.Quantity)
+		t.ExitPrice = t.ExitPrice.Mul(t.ExitQuantity).Add(fill.Notional()).Div(exitQuantity)
+		t.ExitQuantity = exitQuantity
+		t.Quantity = t.Quantity.Sub(fill.Quantity)
+		if t.Quantity.IsZero() {
+			t.CloseTime = fill.Time
+		}
+	} else {
+		quantity := t.Quantity.Add(fill.Quantity)
+		t.EntryPrice = t.EntryPrice.Mul(t.Quantity).Add(fill.Notional()).Div(quantity)
+		t.EntryQuantity = t.EntryQuantity.Add(fill.Quantity)
+		t.Quantity = quantity
+		if t.OpenTime.IsZero() {
+			t.OpenTime = fill.Time
+		}
+	}
+
+	t.Fees = t.Fees.Add(fill.Fee)
+	fill.TradeID = t.ID
+	if fill.ID == "" {
+		fill.ID = fmt.Sprintf("%s-%d", t.ID, len(t.Fills)+1)
+	}
+	t.Fills = append(t.Fills, fill)
+	return nil
+}
+
+// Closed сообщает, что весь объем сделки закрыт
+func (t *Trade) Closed() bool {
+	return !t.CloseTime.IsZero()
+}
+
+// UnrealizedPnL возвращает прибыль открытого объема по цене mark
+func (t *Trade) UnrealizedPnL(mark Decimal) Decimal {
+	return signed(t.Side, mark.Sub(t.EntryPrice).Mul(t.Quantity))
+}
+
+// NetPnL возвращает зафиксированную прибыль за вычетом комиссий
+func (t *Trade) NetPnL() Decimal {
+	return t.RealizedPnL.Sub(t.Fees)
+}
+
+// Return возвращает чистую прибыль в процентах от стоимости входов. Сделка,
+// загруженная из хранилища без исполнений, оценивается по средней цене входа.
+// Доходность - показатель для статистики, поэтому она float64.
+func (t *Trade) Return() float64 {
+	cost := ZeroDecimal
+	for _, fill := range t.Fills {
+		if !fill.Reduce {
+			cost = cost.Add(fill.Notional())
+		}
+	}
+	if cost.IsZero() {
+		cost = t.EntryPrice.Mul(t.EntryQuantity)
+	}
+	if cost.IsZero() {
+		return 0
+	}
+	return t.NetPnL().Div(cost).InexactFloat64() * 100
+}
+
+// Position возвращает открытый объем сделки позицией по цене mark; время обновления -
+// время последнего исполнения
+func (t *Trade) Position(mark Decimal) *Position {
+	var updated time.Time
+	if len(t.Fills) > 0 {
+		updated = t.Fills[len(t.Fills)-1].Time
+	}
+	return &Position{
+		Symbol:        t.Symbol,
+		Side:          t.Side,
+		Amount:        t.Quantity,
+		EntryPrice:    t.EntryPrice,
+		MarkPrice:     mark,
+		UnrealizedPnL: t.UnrealizedPnL(mark),
+		RealizedPnL:   t.RealizedPnL,
+		Fees:          t.Fees,
+		Leverage:      1,
+		UpdateTime:    updated,
+	}
+}
+
+// signed возвращает value со знаком стороны позиции: для короткой - с обратным
+func signed(side Side, value Decimal) Decimal {
+	if side == PositionSideShort {
+		return value.Neg()
+	}
+	return value
+}
@@ -0,0 +1,418 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Единый формат передачи моделей для API, шин сообщений и экспорта. В JSON модель
+// оборачивается в конверт с типом и версией формата; в Protobuf передается сообщением
+// из api/proto/bfma/v1 (models.proto, signals.proto). Номера и имена полей заданы
+// тегами json и protobuf моделей.
+
+// WireVersion версия формата передачи. Новые поля не меняют версию; версия растет
+// при несовместимых изменениях, и UnmarshalJSON отклоняет данные более новой версии.
+//
+// Версия 2: цены и объемы свечи передаются десятичными строками. Данные версии 1, где
+// они числа, по-прежнему разбираются.
+const WireVersion = 2
+
+// Типы моделей в конверте JSON
+const (
+	WireCandle         = "candle"
+	WireOrderBook      = "order_book"
+	WireOrderBookDelta = "order_book_delta"
+	WireFundingRate    = "funding_rate"
+	WireOpenInterest   = "open_interest"
+	WireSignal         = "signal"
+)
+
+// WireModel модель, передаваемая в едином формате
+type WireModel interface {
+	wireType() string
+}
+
+func (*Candle) wireType() string         { return WireCandle }
+func (*OrderBook) wireType() string      { return WireOrderBook }
+func (*OrderBookDelta) wireType() string { return WireOrderBookDelta }
+func (*FundingRate) wireType() string    { return WireFundingRate }
+func (*OpenInterest) wireType() string   { return WireOpenInterest }
+func (*SignalResult) wireType() string   { return WireSignal }
+
+// Envelope конверт JSON: тип модели, версия формата и сама модель
+type Envelope struct {
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// MarshalJSON кодирует модель в конверт JSON
+func MarshalJSON(m WireModel) ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{Type: m.wireType(), Version: WireVersion, Data: data})
+}
+
+// UnmarshalJSON декодирует модель из конверта JSON, проверяя тип и версию формата
+func UnmarshalJSON(data []byte, m WireModel) error {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("ошибка разбора конверта: %w", err)
+	}
+	if envelope.Type != m.wireType() {
+		return fmt.Errorf("ожидалась модель %s, получена %q", m.wireType(), envelope.Type)
+	}
+	if envelope.Version < 1 || envelope.Version > WireVersion {
+		return fmt.Errorf("неподдерживаемая версия формата %d, допустимы 1..%d", envelope.Version, WireVersion)
+	}
+	if err := json.Unmarshal(envelope.Data, m); err != nil {
+		return fmt.Errorf("ошибка разбора модели %s: %w", envelope.Type, err)
+	}
+	return nil
+}
+
+// MarshalProto кодирует модель в Protobuf. Поля со значением по умолчанию не
+// записываются, ключи компонентов сигнала упорядочены, поэтому одинаковые модели
+// дают одинаковые байты.
+func MarshalProto(m WireModel) ([]byte, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, fmt.Errorf("нужен указатель на модель, получен %T", m)
+	}
+	return appendMessage(nil, v.Elem())
+}
+
+// UnmarshalProto декодирует модель из Protobuf. Неизвестные поля пропускаются.
+func UnmarshalProto(data []byte, m WireModel) error {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("нужен указатель на модель, получен %T", m)
+	}
+	v = v.Elem()
+	v.SetZero()
+	return consumeMessage(data, v)
+}
+
+// Значения перечисления bfma.v1.Recommendation
+var recommendationEnum = map[Recommendation]protowire.Number{
+	RecommendationStrongBuy:  1,
+	RecommendationBuy:        2,
+	RecommendationNeutral:    3,
+	RecommendationSell:       4,
+	RecommendationStrongSell: 5,
+}
+
+// wireField поле модели по тегу protobuf
+type wireField struct {
+	index  int
+	number protowire.Number
+	enum   bool
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(Decimal{})
+	wireFields  sync.Map // reflect.Type -> []wireField
+)
+
+// fieldsOf возвращает поля типа с тегом protobuf
+func fieldsOf(t reflect.Type) []wireField {
+	if cached, ok := wireFields.Load(t); ok {
+		return cached.([]wireField)
+	}
+
+	var fields []wireField
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("protobuf")
+		if tag == "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		number, err := strconv.Atoi(parts[1])
+		if err != nil {
+			panic(fmt.Sprintf("неверный тег protobuf поля %s.%s: %q", t.Name(), t.Field(i).Name, tag))
+		}
+		field := wireField{index: i, number: protowire.Number(number)}
+		for _, part := range parts[2:] {
+			if strings.HasPrefix(part, "enum=") {
+				field.enum = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	wireFields.Store(t, fields)
+	return fields
+}
+
+// appendMessage дописывает поля структуры v
+func appendMessage(b []byte, v reflect.Value) ([]byte, error) {
+	for _, f := range fieldsOf(v.Type()) {
+		fv := v.Field(f.index)
+		switch {
+		case fv.Type() == timeType:
+			if t := fv.Interface().(time.Time); !t.IsZero() {
+				b = protowire.AppendTag(b, f.number, protowire.BytesType)
+				b = protowire.AppendBytes(b, appendTimestamp(nil, t))
+			}
+		case fv.Type() == decimalType:
+			if d := fv.Interface().(Decimal); !d.IsZero() {
+				b = protowire.AppendTag(b, f.number, protowire.BytesType)
+				b = protowire.AppendString(b, d.String())
+			}
+		case f.enum:
+			code := Recommendation(fv.String())
+			if code == "" {
+				continue
+			}
+			n, ok := recommendationEnum[code]
+			if !ok {
+				return nil, fmt.Errorf("неизвестный код рекомендации %q", code)
+			}
+			b = protowire.AppendTag(b, f.number, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(n))
+		case fv.Kind() == reflect.String:
+			if s := fv.String(); s != "" {
+				b = protowire.AppendTag(b, f.number, protowire.BytesType)
+				b = protowire.AppendString(b, s)
+			}
+		case fv.Kind() == reflect.Float64:
+			if x := fv.Float(); x != 0 {
+				b = protowire.AppendTag(b, f.number, protowire.Fixed64Type)
+				b = protowire.AppendFixed64(b, math.Float64bits(x))
+			}
+		case fv.Kind() == reflect.Int64:
+			if x := fv.Int(); x != 0 {
+				b = protowire.AppendTag(b, f.number, protowire.VarintType)
+				b = protowire.AppendVarint(b, uint64(x))
+			}
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+			for i := 0; i < fv.Len(); i++ {
+				b = protowire.AppendTag(b, f.number, protowire.BytesType)
+				b = protowire.AppendString(b, fv.Index(i).String())
+			}
+		case fv.Kind() == reflect.Slice:
+			for i := 0; i < fv.Len(); i++ {
+				item, err := appendMessage(nil, fv.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				b = protowire.AppendTag(b, f.number, protowire.BytesType)
+				b = protowire.AppendBytes(b, item)
+			}
+		case fv.Kind() == reflect.Map:
+			keys := make([]string, 0, fv.Len())
+			for _, key := range fv.MapKeys() {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				var entry []byte
+				entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+				entry = protowire.AppendString(entry, key)
+				entry = protowire.AppendTag(entry, 2, protowire.Fixed64Type)
+				entry = protowire.AppendFixed64(entry, math.Float64bits(fv.MapIndex(reflect.ValueOf(key)).Float()))
+				b = protowire.AppendTag(b, f.number, protowire.BytesType)
+				b = protowire.AppendBytes(b, entry)
+			}
+		default:
+			return nil, fmt.Errorf("поле %s.%s типа %s не поддерживается", v.Type().Name(), v.Type().Field(f.index).Name, fv.Type())
+		}
+	}
+	return b, nil
+}
+
+// consumeMessage разбирает поля структуры v
+func consumeMessage(b []byte, v reflect.Value) error {
+	fields := make(map[protowire.Number]wireField)
+	for _, f := range fieldsOf(v.Type()) {
+		fields[f.number] = f
+	}
+
+	for len(b) > 0 {
+		number, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f, ok := fields[number]
+		if !ok {
+			n = protowire.ConsumeFieldValue(number, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+
+		fv := v.Field(f.index)
+		var err error
+		switch {
+		case f.enum:
+			if typ != protowire.VarintType {
+				return fmt.Errorf("поле %d: неверный тип %d", number, typ)
+			}
+			var value uint64
+			value, n = protowire.ConsumeVarint(b)
+			for code, enum := range recommendationEnum {
+				if uint64(enum) == value {
+					fv.SetString(string(code))
+				}
+			}
+		case fv.Kind() == reflect.Float64:
+			if typ != protowire.Fixed64Type {
+				return fmt.Errorf("поле %d: неверный тип %d", number, typ)
+			}
+			var value uint64
+			value, n = protowire.ConsumeFixed64(b)
+			fv.SetFloat(math.Float64frombits(value))
+		case fv.Kind() == reflect.Int64:
+			if typ != protowire.VarintType {
+				return fmt.Errorf("поле %d: неверный тип %d", number, typ)
+			}
+			var value uint64
+			value, n = protowire.ConsumeVarint(b)
+			fv.SetInt(int64(value))
+		default:
+			if typ != protowire.BytesType {
+				return fmt.Errorf("поле %d: неверный тип %d", number, typ)
+			}
+			var value []byte
+			value, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				err = setBytesField(fv, value)
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err != nil {
+			return fmt.Errorf("поле %d: %w", number, err)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// setBytesField записывает в поле значение с типом передачи bytes: строку, десятичное
+// число, время, элемент списка (строку или сообщение) или пару ключ-значение
+func setBytesField(fv reflect.Value, value []byte) error {
+	switch {
+	case fv.Type() == timeType:
+		t, err := consumeTimestamp(value)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+	case fv.Type() == decimalType:
+		d, err := ParseDecimal(string(value))
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(d))
+	case fv.Kind() == reflect.String:
+		fv.SetString(string(value))
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+		fv.Set(reflect.Append(fv, reflect.ValueOf(string(value)).Convert(fv.Type().Elem())))
+	case fv.Kind() == reflect.Slice:
+		item := reflect.New(fv.Type().Elem()).Elem()
+		if err := consumeMessage(value, item); err != nil {
+			return err
+		}
+		fv.Set(reflect.Append(fv, item))
+	case fv.Kind() == reflect.Map:
+		key, val, err := consumeMapEntry(value)
+		if err != nil {
+			return err
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.MakeMap(fv.Type()))
+		}
+		fv.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(val))
+	default:
+		return fmt.Errorf("тип %s не поддерживается", fv.Type())
+	}
+	return nil
+}
+
+// consumeMapEntry разбирает пару map<string, double>
+func consumeMapEntry(b []byte) (string, float64, error) {
+	var key string
+	var value float64
+	for len(b) > 0 {
+		number, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", 0, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case number == 1 && typ == protowire.BytesType:
+			var s []byte
+			s, n = protowire.ConsumeBytes(b)
+			key = string(s)
+		case number == 2 && typ == protowire.Fixed64Type:
+			var bits uint64
+			bits, n = protowire.ConsumeFixed64(b)
+			value = math.Float64frombits(bits)
+		default:
+			n = protowire.ConsumeFieldValue(number, typ, b)
+		}
+		if n < 0 {
+			return "", 0, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return key, value, nil
+}
+
+// appendTimestamp кодирует время сообщением google.protobuf.Timestamp
+func appendTimestamp(b []byte, t time.Time) []byte {
+	if seconds := t.Unix(); seconds != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(seconds))
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(nanos))
+	}
+	return b
+}
+
+// consumeTimestamp разбирает сообщение google.protobuf.Timestamp
+func consumeTimestamp(b []byte) (time.Time, error) {
+	var seconds, nanos int64
+	for len(b) > 0 {
+		number, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return time.Time{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.VarintType && (number == 1 || number == 2) {
+			var value uint64
+			value, n = protowire.ConsumeVarint(b)
+			if number == 1 {
+				seconds = int64(value)
+			} else {
+				nanos = int64(int32(value))
+			}
+		} else {
+			n = protowire.ConsumeFieldValue(number, typ, b)
+		}
+		if n < 0 {
+			return time.Time{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return time.Unix(seconds, nanos), nil
+}
@@ -0,0 +1,10 @@
+// Package signalpb содержит клиент, сервер и типы gRPC API сигналов bfma,
+// сгенерированные из api/proto/bfma/v1/signals.proto и models.proto. Сервер
+// встроен в приложение (api.grpc_listen); клиенту достаточно этого пакета и
+// google.golang.org/grpc.
+//
+// После изменения .proto файлы пересоздаются командой go generate; для нее нужны
+// protoc, protoc-gen-go и protoc-gen-go-grpc.
+package signalpb
+
+//go:generate protoc -I ../../api/proto --go_out=../.. --go_opt=module=github.com/skalibog/bfma --go-grpc_out=../.. --go-grpc_opt=module=github.com/skalibog/bfma bfma/v1/signals.proto bfma/v1/models.proto
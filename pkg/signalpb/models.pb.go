@@ -0,0 +1,639 @@
+// Модели рыночных данных bfma в едином формате передачи для API, шин сообщений
+// и экспорта. Кодирование реализовано в pkg/models (MarshalProto/UnmarshalProto);
+// тот же формат в JSON - MarshalJSON/UnmarshalJSON с полями, названными как здесь.
+//
+// Цены, объемы, ставки и открытый интерес передаются десятичными строками без потери
+// точности. Сигнал (SignalResult) передается сообщением Signal из signals.proto.
+//
+// Совместимость: номера полей не переиспользуются, новые поля добавляются с новыми
+// номерами; несовместимые изменения выходят в пакете bfma.v2.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: bfma/v1/models.proto
+
+package signalpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Цены и объемы - десятичные числа. До версии формата 2 они передавались числами
+// double с номерами 4-8, 10, 12 и 13; номера не используются повторно.
+type Candle struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Symbol              string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Interval            string                 `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"` // 1m, 5m, 1h...
+	OpenTime            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=open_time,json=openTime,proto3" json:"open_time,omitempty"`
+	Open                string                 `protobuf:"bytes,14,opt,name=open,proto3" json:"open,omitempty"`
+	High                string                 `protobuf:"bytes,15,opt,name=high,proto3" json:"high,omitempty"`
+	Low                 string                 `protobuf:"bytes,16,opt,name=low,proto3" json:"low,omitempty"`
+	Close               string                 `protobuf:"bytes,17,opt,name=close,proto3" json:"close,omitempty"`
+	Volume              string                 `protobuf:"bytes,18,opt,name=volume,proto3" json:"volume,omitempty"`
+	CloseTime           *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=close_time,json=closeTime,proto3" json:"close_time,omitempty"`
+	QuoteVolume         string                 `protobuf:"bytes,19,opt,name=quote_volume,json=quoteVolume,proto3" json:"quote_volume,omitempty"`                             // Объем в валюте котировки
+	NumTrades           int64                  `protobuf:"varint,11,opt,name=num_trades,json=numTrades,proto3" json:"num_trades,omitempty"`                                  // Число сделок
+	TakerBuyVolume      string                 `protobuf:"bytes,20,opt,name=taker_buy_volume,json=takerBuyVolume,proto3" json:"taker_buy_volume,omitempty"`                  // Объем агрессивных покупок в базовом активе
+	TakerBuyQuoteVolume string                 `protobuf:"bytes,21,opt,name=taker_buy_quote_volume,json=takerBuyQuoteVolume,proto3" json:"taker_buy_quote_volume,omitempty"` // Объем агрессивных покупок в валюте котировки
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Candle) Reset() {
+	*x = Candle{}
+	mi := &file_bfma_v1_models_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Candle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Candle) ProtoMessage() {}
+
+func (x *Candle) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_models_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Candle.ProtoReflect.Descriptor instead.
+func (*Candle) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_models_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Candle) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Candle) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *Candle) GetOpenTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OpenTime
+	}
+	return nil
+}
+
+func (x *Candle) GetOpen() string {
+	if x != nil {
+		return x.Open
+	}
+	return ""
+}
+
+func (x *Candle) GetHigh() string {
+	if x != nil {
+		return x.High
+	}
+	return ""
+}
+
+func (x *Candle) GetLow() string {
+	if x != nil {
+		return x.Low
+	}
+	return ""
+}
+
+func (x *Candle) GetClose() string {
+	if x != nil {
+		return x.Close
+	}
+	return ""
+}
+
+func (x *Candle) GetVolume() string {
+	if x != nil {
+		return x.Volume
+	}
+	return ""
+}
+
+func (x *Candle) GetCloseTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CloseTime
+	}
+	return nil
+}
+
+func (x *Candle) GetQuoteVolume() string {
+	if x != nil {
+		return x.QuoteVolume
+	}
+	return ""
+}
+
+func (x *Candle) GetNumTrades() int64 {
+	if x != nil {
+		return x.NumTrades
+	}
+	return 0
+}
+
+func (x *Candle) GetTakerBuyVolume() string {
+	if x != nil {
+		return x.TakerBuyVolume
+	}
+	return ""
+}
+
+func (x *Candle) GetTakerBuyQuoteVolume() string {
+	if x != nil {
+		return x.TakerBuyQuoteVolume
+	}
+	return ""
+}
+
+type OrderBookLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`   // Десятичное число
+	Amount        string                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"` // Десятичное число
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBookLevel) Reset() {
+	*x = OrderBookLevel{}
+	mi := &file_bfma_v1_models_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBookLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBookLevel) ProtoMessage() {}
+
+func (x *OrderBookLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_models_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBookLevel.ProtoReflect.Descriptor instead.
+func (*OrderBookLevel) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_models_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OrderBookLevel) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *OrderBookLevel) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+type OrderBook struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Bids          []*OrderBookLevel      `protobuf:"bytes,3,rep,name=bids,proto3" json:"bids,omitempty"`                                        // По убыванию цены
+	Asks          []*OrderBookLevel      `protobuf:"bytes,4,rep,name=asks,proto3" json:"asks,omitempty"`                                        // По возрастанию цены
+	LastUpdateId  int64                  `protobuf:"varint,5,opt,name=last_update_id,json=lastUpdateId,proto3" json:"last_update_id,omitempty"` // Последнее учтенное обновление биржи, 0 - неизвестно
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBook) Reset() {
+	*x = OrderBook{}
+	mi := &file_bfma_v1_models_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBook) ProtoMessage() {}
+
+func (x *OrderBook) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_models_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBook.ProtoReflect.Descriptor instead.
+func (*OrderBook) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_models_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OrderBook) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderBook) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *OrderBook) GetBids() []*OrderBookLevel {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *OrderBook) GetAsks() []*OrderBookLevel {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+func (x *OrderBook) GetLastUpdateId() int64 {
+	if x != nil {
+		return x.LastUpdateId
+	}
+	return 0
+}
+
+// Изменения стакана: уровни с новым объемом, нулевой объем удаляет уровень.
+// Применяется к стакану, last_update_id которого равен prev_update_id.
+type OrderBookDelta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	FirstUpdateId int64                  `protobuf:"varint,3,opt,name=first_update_id,json=firstUpdateId,proto3" json:"first_update_id,omitempty"`
+	LastUpdateId  int64                  `protobuf:"varint,4,opt,name=last_update_id,json=lastUpdateId,proto3" json:"last_update_id,omitempty"`
+	PrevUpdateId  int64                  `protobuf:"varint,5,opt,name=prev_update_id,json=prevUpdateId,proto3" json:"prev_update_id,omitempty"`
+	Bids          []*OrderBookLevel      `protobuf:"bytes,6,rep,name=bids,proto3" json:"bids,omitempty"`
+	Asks          []*OrderBookLevel      `protobuf:"bytes,7,rep,name=asks,proto3" json:"asks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBookDelta) Reset() {
+	*x = OrderBookDelta{}
+	mi := &file_bfma_v1_models_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBookDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBookDelta) ProtoMessage() {}
+
+func (x *OrderBookDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_models_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBookDelta.ProtoReflect.Descriptor instead.
+func (*OrderBookDelta) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_models_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OrderBookDelta) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderBookDelta) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *OrderBookDelta) GetFirstUpdateId() int64 {
+	if x != nil {
+		return x.FirstUpdateId
+	}
+	return 0
+}
+
+func (x *OrderBookDelta) GetLastUpdateId() int64 {
+	if x != nil {
+		return x.LastUpdateId
+	}
+	return 0
+}
+
+func (x *OrderBookDelta) GetPrevUpdateId() int64 {
+	if x != nil {
+		return x.PrevUpdateId
+	}
+	return 0
+}
+
+func (x *OrderBookDelta) GetBids() []*OrderBookLevel {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *OrderBookDelta) GetAsks() []*OrderBookLevel {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+type FundingRate struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Symbol          string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Rate            string                 `protobuf:"bytes,2,opt,name=rate,proto3" json:"rate,omitempty"` // Десятичное число, доля (0.0001 = 0.01%)
+	Timestamp       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	NextFundingTime *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=next_funding_time,json=nextFundingTime,proto3" json:"next_funding_time,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *FundingRate) Reset() {
+	*x = FundingRate{}
+	mi := &file_bfma_v1_models_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FundingRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FundingRate) ProtoMessage() {}
+
+func (x *FundingRate) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_models_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FundingRate.ProtoReflect.Descriptor instead.
+func (*FundingRate) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_models_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FundingRate) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *FundingRate) GetRate() string {
+	if x != nil {
+		return x.Rate
+	}
+	return ""
+}
+
+func (x *FundingRate) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *FundingRate) GetNextFundingTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextFundingTime
+	}
+	return nil
+}
+
+type OpenInterest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"` // Десятичное число, в контрактах
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenInterest) Reset() {
+	*x = OpenInterest{}
+	mi := &file_bfma_v1_models_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenInterest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenInterest) ProtoMessage() {}
+
+func (x *OpenInterest) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_models_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenInterest.ProtoReflect.Descriptor instead.
+func (*OpenInterest) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_models_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OpenInterest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OpenInterest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *OpenInterest) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+var File_bfma_v1_models_proto protoreflect.FileDescriptor
+
+const file_bfma_v1_models_proto_rawDesc = "" +
+	"\n" +
+	"\x14bfma/v1/models.proto\x12\abfma.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd1\x03\n" +
+	"\x06Candle\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\tR\binterval\x127\n" +
+	"\topen_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bopenTime\x12\x12\n" +
+	"\x04open\x18\x0e \x01(\tR\x04open\x12\x12\n" +
+	"\x04high\x18\x0f \x01(\tR\x04high\x12\x10\n" +
+	"\x03low\x18\x10 \x01(\tR\x03low\x12\x14\n" +
+	"\x05close\x18\x11 \x01(\tR\x05close\x12\x16\n" +
+	"\x06volume\x18\x12 \x01(\tR\x06volume\x129\n" +
+	"\n" +
+	"close_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcloseTime\x12!\n" +
+	"\fquote_volume\x18\x13 \x01(\tR\vquoteVolume\x12\x1d\n" +
+	"\n" +
+	"num_trades\x18\v \x01(\x03R\tnumTrades\x12(\n" +
+	"\x10taker_buy_volume\x18\x14 \x01(\tR\x0etakerBuyVolume\x123\n" +
+	"\x16taker_buy_quote_volume\x18\x15 \x01(\tR\x13takerBuyQuoteVolumeJ\x04\b\x04\x10\tJ\x04\b\n" +
+	"\x10\vJ\x04\b\f\x10\rJ\x04\b\r\x10\x0e\">\n" +
+	"\x0eOrderBookLevel\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\tR\x05price\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\tR\x06amount\"\xdd\x01\n" +
+	"\tOrderBook\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12+\n" +
+	"\x04bids\x18\x03 \x03(\v2\x17.bfma.v1.OrderBookLevelR\x04bids\x12+\n" +
+	"\x04asks\x18\x04 \x03(\v2\x17.bfma.v1.OrderBookLevelR\x04asks\x12$\n" +
+	"\x0elast_update_id\x18\x05 \x01(\x03R\flastUpdateId\"\xb0\x02\n" +
+	"\x0eOrderBookDelta\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12&\n" +
+	"\x0ffirst_update_id\x18\x03 \x01(\x03R\rfirstUpdateId\x12$\n" +
+	"\x0elast_update_id\x18\x04 \x01(\x03R\flastUpdateId\x12$\n" +
+	"\x0eprev_update_id\x18\x05 \x01(\x03R\fprevUpdateId\x12+\n" +
+	"\x04bids\x18\x06 \x03(\v2\x17.bfma.v1.OrderBookLevelR\x04bids\x12+\n" +
+	"\x04asks\x18\a \x03(\v2\x17.bfma.v1.OrderBookLevelR\x04asks\"\xbb\x01\n" +
+	"\vFundingRate\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04rate\x18\x02 \x01(\tR\x04rate\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12F\n" +
+	"\x11next_funding_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x0fnextFundingTime\"v\n" +
+	"\fOpenInterest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestampB0Z.github.com/skalibog/bfma/pkg/signalpb;signalpbb\x06proto3"
+
+var (
+	file_bfma_v1_models_proto_rawDescOnce sync.Once
+	file_bfma_v1_models_proto_rawDescData []byte
+)
+
+func file_bfma_v1_models_proto_rawDescGZIP() []byte {
+	file_bfma_v1_models_proto_rawDescOnce.Do(func() {
+		file_bfma_v1_models_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bfma_v1_models_proto_rawDesc), len(file_bfma_v1_models_proto_rawDesc)))
+	})
+	return file_bfma_v1_models_proto_rawDescData
+}
+
+var file_bfma_v1_models_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_bfma_v1_models_proto_goTypes = []any{
+	(*Candle)(nil),                // 0: bfma.v1.Candle
+	(*OrderBookLevel)(nil),        // 1: bfma.v1.OrderBookLevel
+	(*OrderBook)(nil),             // 2: bfma.v1.OrderBook
+	(*OrderBookDelta)(nil),        // 3: bfma.v1.OrderBookDelta
+	(*FundingRate)(nil),           // 4: bfma.v1.FundingRate
+	(*OpenInterest)(nil),          // 5: bfma.v1.OpenInterest
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_bfma_v1_models_proto_depIdxs = []int32{
+	6,  // 0: bfma.v1.Candle.open_time:type_name -> google.protobuf.Timestamp
+	6,  // 1: bfma.v1.Candle.close_time:type_name -> google.protobuf.Timestamp
+	6,  // 2: bfma.v1.OrderBook.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 3: bfma.v1.OrderBook.bids:type_name -> bfma.v1.OrderBookLevel
+	1,  // 4: bfma.v1.OrderBook.asks:type_name -> bfma.v1.OrderBookLevel
+	6,  // 5: bfma.v1.OrderBookDelta.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 6: bfma.v1.OrderBookDelta.bids:type_name -> bfma.v1.OrderBookLevel
+	1,  // 7: bfma.v1.OrderBookDelta.asks:type_name -> bfma.v1.OrderBookLevel
+	6,  // 8: bfma.v1.FundingRate.timestamp:type_name -> google.protobuf.Timestamp
+	6,  // 9: bfma.v1.FundingRate.next_funding_time:type_name -> google.protobuf.Timestamp
+	6,  // 10: bfma.v1.OpenInterest.timestamp:type_name -> google.protobuf.Timestamp
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_bfma_v1_models_proto_init() }
+func file_bfma_v1_models_proto_init() {
+	if File_bfma_v1_models_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bfma_v1_models_proto_rawDesc), len(file_bfma_v1_models_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_bfma_v1_models_proto_goTypes,
+		DependencyIndexes: file_bfma_v1_models_proto_depIdxs,
+		MessageInfos:      file_bfma_v1_models_proto_msgTypes,
+	}.Build()
+	File_bfma_v1_models_proto = out.File
+	file_bfma_v1_models_proto_goTypes = nil
+	file_bfma_v1_models_proto_depIdxs = nil
+}
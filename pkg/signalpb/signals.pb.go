@@ -0,0 +1,788 @@
+// Потоковый API сигналов bfma для ботов исполнения.
+// Формат сигнала соответствует схеме JSON версии 2 (internal/schema).
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: bfma/v1/signals.proto
+
+package signalpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Order int32
+
+const (
+	Order_ORDER_UNSPECIFIED Order = 0 // Новые первыми
+	Order_ORDER_DESC        Order = 1
+	Order_ORDER_ASC         Order = 2
+)
+
+// Enum value maps for Order.
+var (
+	Order_name = map[int32]string{
+		0: "ORDER_UNSPECIFIED",
+		1: "ORDER_DESC",
+		2: "ORDER_ASC",
+	}
+	Order_value = map[string]int32{
+		"ORDER_UNSPECIFIED": 0,
+		"ORDER_DESC":        1,
+		"ORDER_ASC":         2,
+	}
+)
+
+func (x Order) Enum() *Order {
+	p := new(Order)
+	*p = x
+	return p
+}
+
+func (x Order) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Order) Descriptor() protoreflect.EnumDescriptor {
+	return file_bfma_v1_signals_proto_enumTypes[0].Descriptor()
+}
+
+func (Order) Type() protoreflect.EnumType {
+	return &file_bfma_v1_signals_proto_enumTypes[0]
+}
+
+func (x Order) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Order.Descriptor instead.
+func (Order) EnumDescriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{0}
+}
+
+type Recommendation int32
+
+const (
+	Recommendation_RECOMMENDATION_UNSPECIFIED Recommendation = 0
+	Recommendation_RECOMMENDATION_STRONG_BUY  Recommendation = 1
+	Recommendation_RECOMMENDATION_BUY         Recommendation = 2
+	Recommendation_RECOMMENDATION_NEUTRAL     Recommendation = 3
+	Recommendation_RECOMMENDATION_SELL        Recommendation = 4
+	Recommendation_RECOMMENDATION_STRONG_SELL Recommendation = 5
+)
+
+// Enum value maps for Recommendation.
+var (
+	Recommendation_name = map[int32]string{
+		0: "RECOMMENDATION_UNSPECIFIED",
+		1: "RECOMMENDATION_STRONG_BUY",
+		2: "RECOMMENDATION_BUY",
+		3: "RECOMMENDATION_NEUTRAL",
+		4: "RECOMMENDATION_SELL",
+		5: "RECOMMENDATION_STRONG_SELL",
+	}
+	Recommendation_value = map[string]int32{
+		"RECOMMENDATION_UNSPECIFIED": 0,
+		"RECOMMENDATION_STRONG_BUY":  1,
+		"RECOMMENDATION_BUY":         2,
+		"RECOMMENDATION_NEUTRAL":     3,
+		"RECOMMENDATION_SELL":        4,
+		"RECOMMENDATION_STRONG_SELL": 5,
+	}
+)
+
+func (x Recommendation) Enum() *Recommendation {
+	p := new(Recommendation)
+	*p = x
+	return p
+}
+
+func (x Recommendation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Recommendation) Descriptor() protoreflect.EnumDescriptor {
+	return file_bfma_v1_signals_proto_enumTypes[1].Descriptor()
+}
+
+func (Recommendation) Type() protoreflect.EnumType {
+	return &file_bfma_v1_signals_proto_enumTypes[1]
+}
+
+func (x Recommendation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Recommendation.Descriptor instead.
+func (Recommendation) EnumDescriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{1}
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbols       []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_bfma_v1_signals_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_signals_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubscribeRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+type SignalsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbols       []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalsRequest) Reset() {
+	*x = SignalsRequest{}
+	mi := &file_bfma_v1_signals_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalsRequest) ProtoMessage() {}
+
+func (x *SignalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_signals_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalsRequest.ProtoReflect.Descriptor instead.
+func (*SignalsRequest) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SignalsRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+type HistoryRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Symbol          string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`                                                       // Один символ; объединяется с symbols
+	Limit           int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                                                        // 1..1000, по умолчанию 100
+	Symbols         []string               `protobuf:"bytes,3,rep,name=symbols,proto3" json:"symbols,omitempty"`                                                     // Пусто вместе с symbol - все символы
+	From            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`                                                           // Включительно; по умолчанию 30 дней до to
+	To              *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`                                                               // Не включительно; по умолчанию текущий момент
+	MinStrength     float64                `protobuf:"fixed64,6,opt,name=min_strength,json=minStrength,proto3" json:"min_strength,omitempty"`                        // Модуль силы сигнала не меньше, 0..100
+	Recommendations []Recommendation       `protobuf:"varint,7,rep,packed,name=recommendations,proto3,enum=bfma.v1.Recommendation" json:"recommendations,omitempty"` // Пусто - все рекомендации
+	Order           Order                  `protobuf:"varint,8,opt,name=order,proto3,enum=bfma.v1.Order" json:"order,omitempty"`
+	Cursor          string                 `protobuf:"bytes,9,opt,name=cursor,proto3" json:"cursor,omitempty"` // next_cursor предыдущей страницы; условия выборки должны совпадать
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_bfma_v1_signals_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_signals_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HistoryRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *HistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *HistoryRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *HistoryRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *HistoryRequest) GetMinStrength() float64 {
+	if x != nil {
+		return x.MinStrength
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetRecommendations() []Recommendation {
+	if x != nil {
+		return x.Recommendations
+	}
+	return nil
+}
+
+func (x *HistoryRequest) GetOrder() Order {
+	if x != nil {
+		return x.Order
+	}
+	return Order_ORDER_UNSPECIFIED
+}
+
+func (x *HistoryRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type SignalsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Signals       []*Signal              `protobuf:"bytes,1,rep,name=signals,proto3" json:"signals,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"` // Пусто - страниц больше нет
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalsResponse) Reset() {
+	*x = SignalsResponse{}
+	mi := &file_bfma_v1_signals_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalsResponse) ProtoMessage() {}
+
+func (x *SignalsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_signals_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalsResponse.ProtoReflect.Descriptor instead.
+func (*SignalsResponse) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SignalsResponse) GetSignals() []*Signal {
+	if x != nil {
+		return x.Signals
+	}
+	return nil
+}
+
+func (x *SignalsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// Сигнал; pkg/models кодирует SignalResult этим сообщением (models.MarshalProto).
+type Signal struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Symbol             string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Timestamp          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Recommendation     Recommendation         `protobuf:"varint,3,opt,name=recommendation,proto3,enum=bfma.v1.Recommendation" json:"recommendation,omitempty"`
+	RecommendationText string                 `protobuf:"bytes,4,opt,name=recommendation_text,json=recommendationText,proto3" json:"recommendation_text,omitempty"` // Текст рекомендации на языке интерфейса
+	SignalStrength     float64                `protobuf:"fixed64,5,opt,name=signal_strength,json=signalStrength,proto3" json:"signal_strength,omitempty"`
+	PositionSize       float64                `protobuf:"fixed64,6,opt,name=position_size,json=positionSize,proto3" json:"position_size,omitempty"`
+	CurrentPrice       float64                `protobuf:"fixed64,7,opt,name=current_price,json=currentPrice,proto3" json:"current_price,omitempty"`
+	Components         map[string]float64     `protobuf:"bytes,8,rep,name=components,proto3" json:"components,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	// Условия расчета; у сигналов, сохраненных до их появления, пустые
+	Id            string                 `protobuf:"bytes,9,opt,name=id,proto3" json:"id,omitempty"`                              // <символ>-<цикл анализа>
+	Intervals     []string               `protobuf:"bytes,10,rep,name=intervals,proto3" json:"intervals,omitempty"`               // Интервалы свечей, прочитанных анализаторами
+	DataFrom      *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=data_from,json=dataFrom,proto3" json:"data_from,omitempty"` // Время самых ранних данных анализа
+	DataTo        *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=data_to,json=dataTo,proto3" json:"data_to,omitempty"`       // Время самых свежих данных анализа
+	EngineVersion string                 `protobuf:"bytes,13,opt,name=engine_version,json=engineVersion,proto3" json:"engine_version,omitempty"`
+	ConfigHash    string                 `protobuf:"bytes,14,opt,name=config_hash,json=configHash,proto3" json:"config_hash,omitempty"`                                                     // Отпечаток настроек анализа символа
+	Weights       map[string]float64     `protobuf:"bytes,15,rep,name=weights,proto3" json:"weights,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"` // Веса компонентов с учетом ручных поправок
+	Confidence    float64                `protobuf:"fixed64,16,opt,name=confidence,proto3" json:"confidence,omitempty"`                                                                     // 0..1
+	Flags         []string               `protobuf:"bytes,17,rep,name=flags,proto3" json:"flags,omitempty"`                                                                                 // forced, reweighted, degraded, long_only, short_only, capped
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Signal) Reset() {
+	*x = Signal{}
+	mi := &file_bfma_v1_signals_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Signal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Signal) ProtoMessage() {}
+
+func (x *Signal) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_signals_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Signal.ProtoReflect.Descriptor instead.
+func (*Signal) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Signal) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Signal) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Signal) GetRecommendation() Recommendation {
+	if x != nil {
+		return x.Recommendation
+	}
+	return Recommendation_RECOMMENDATION_UNSPECIFIED
+}
+
+func (x *Signal) GetRecommendationText() string {
+	if x != nil {
+		return x.RecommendationText
+	}
+	return ""
+}
+
+func (x *Signal) GetSignalStrength() float64 {
+	if x != nil {
+		return x.SignalStrength
+	}
+	return 0
+}
+
+func (x *Signal) GetPositionSize() float64 {
+	if x != nil {
+		return x.PositionSize
+	}
+	return 0
+}
+
+func (x *Signal) GetCurrentPrice() float64 {
+	if x != nil {
+		return x.CurrentPrice
+	}
+	return 0
+}
+
+func (x *Signal) GetComponents() map[string]float64 {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *Signal) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Signal) GetIntervals() []string {
+	if x != nil {
+		return x.Intervals
+	}
+	return nil
+}
+
+func (x *Signal) GetDataFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DataFrom
+	}
+	return nil
+}
+
+func (x *Signal) GetDataTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DataTo
+	}
+	return nil
+}
+
+func (x *Signal) GetEngineVersion() string {
+	if x != nil {
+		return x.EngineVersion
+	}
+	return ""
+}
+
+func (x *Signal) GetConfigHash() string {
+	if x != nil {
+		return x.ConfigHash
+	}
+	return ""
+}
+
+func (x *Signal) GetWeights() map[string]float64 {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+func (x *Signal) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Signal) GetFlags() []string {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"` // signal_change, position_conflict, config; alert - прочие оповещения
+	Symbol        string                 `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	Critical      bool                   `protobuf:"varint,5,opt,name=critical,proto3" json:"critical,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_bfma_v1_signals_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_bfma_v1_signals_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_bfma_v1_signals_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Event) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Event) GetCritical() bool {
+	if x != nil {
+		return x.Critical
+	}
+	return false
+}
+
+var File_bfma_v1_signals_proto protoreflect.FileDescriptor
+
+const file_bfma_v1_signals_proto_rawDesc = "" +
+	"\n" +
+	"\x15bfma/v1/signals.proto\x12\abfma.v1\x1a\x1fgoogle/protobuf/timestamp.proto\",\n" +
+	"\x10SubscribeRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\"*\n" +
+	"\x0eSignalsRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\"\xd8\x02\n" +
+	"\x0eHistoryRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x18\n" +
+	"\asymbols\x18\x03 \x03(\tR\asymbols\x12.\n" +
+	"\x04from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12!\n" +
+	"\fmin_strength\x18\x06 \x01(\x01R\vminStrength\x12A\n" +
+	"\x0frecommendations\x18\a \x03(\x0e2\x17.bfma.v1.RecommendationR\x0frecommendations\x12$\n" +
+	"\x05order\x18\b \x01(\x0e2\x0e.bfma.v1.OrderR\x05order\x12\x16\n" +
+	"\x06cursor\x18\t \x01(\tR\x06cursor\"]\n" +
+	"\x0fSignalsResponse\x12)\n" +
+	"\asignals\x18\x01 \x03(\v2\x0f.bfma.v1.SignalR\asignals\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\xcd\x06\n" +
+	"\x06Signal\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12?\n" +
+	"\x0erecommendation\x18\x03 \x01(\x0e2\x17.bfma.v1.RecommendationR\x0erecommendation\x12/\n" +
+	"\x13recommendation_text\x18\x04 \x01(\tR\x12recommendationText\x12'\n" +
+	"\x0fsignal_strength\x18\x05 \x01(\x01R\x0esignalStrength\x12#\n" +
+	"\rposition_size\x18\x06 \x01(\x01R\fpositionSize\x12#\n" +
+	"\rcurrent_price\x18\a \x01(\x01R\fcurrentPrice\x12?\n" +
+	"\n" +
+	"components\x18\b \x03(\v2\x1f.bfma.v1.Signal.ComponentsEntryR\n" +
+	"components\x12\x0e\n" +
+	"\x02id\x18\t \x01(\tR\x02id\x12\x1c\n" +
+	"\tintervals\x18\n" +
+	" \x03(\tR\tintervals\x127\n" +
+	"\tdata_from\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\bdataFrom\x123\n" +
+	"\adata_to\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\x06dataTo\x12%\n" +
+	"\x0eengine_version\x18\r \x01(\tR\rengineVersion\x12\x1f\n" +
+	"\vconfig_hash\x18\x0e \x01(\tR\n" +
+	"configHash\x126\n" +
+	"\aweights\x18\x0f \x03(\v2\x1c.bfma.v1.Signal.WeightsEntryR\aweights\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x10 \x01(\x01R\n" +
+	"confidence\x12\x14\n" +
+	"\x05flags\x18\x11 \x03(\tR\x05flags\x1a=\n" +
+	"\x0fComponentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\x1a:\n" +
+	"\fWeightsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"\xa3\x01\n" +
+	"\x05Event\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06symbol\x18\x03 \x01(\tR\x06symbol\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1a\n" +
+	"\bcritical\x18\x05 \x01(\bR\bcritical*=\n" +
+	"\x05Order\x12\x15\n" +
+	"\x11ORDER_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"ORDER_DESC\x10\x01\x12\r\n" +
+	"\tORDER_ASC\x10\x02*\xbc\x01\n" +
+	"\x0eRecommendation\x12\x1e\n" +
+	"\x1aRECOMMENDATION_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19RECOMMENDATION_STRONG_BUY\x10\x01\x12\x16\n" +
+	"\x12RECOMMENDATION_BUY\x10\x02\x12\x1a\n" +
+	"\x16RECOMMENDATION_NEUTRAL\x10\x03\x12\x17\n" +
+	"\x13RECOMMENDATION_SELL\x10\x04\x12\x1e\n" +
+	"\x1aRECOMMENDATION_STRONG_SELL\x10\x052\x9f\x02\n" +
+	"\rSignalService\x12@\n" +
+	"\x10SubscribeSignals\x12\x19.bfma.v1.SubscribeRequest\x1a\x0f.bfma.v1.Signal0\x01\x12>\n" +
+	"\x0fSubscribeEvents\x12\x19.bfma.v1.SubscribeRequest\x1a\x0e.bfma.v1.Event0\x01\x12E\n" +
+	"\x10GetLatestSignals\x12\x17.bfma.v1.SignalsRequest\x1a\x18.bfma.v1.SignalsResponse\x12E\n" +
+	"\x10GetSignalHistory\x12\x17.bfma.v1.HistoryRequest\x1a\x18.bfma.v1.SignalsResponseB0Z.github.com/skalibog/bfma/pkg/signalpb;signalpbb\x06proto3"
+
+var (
+	file_bfma_v1_signals_proto_rawDescOnce sync.Once
+	file_bfma_v1_signals_proto_rawDescData []byte
+)
+
+func file_bfma_v1_signals_proto_rawDescGZIP() []byte {
+	file_bfma_v1_signals_proto_rawDescOnce.Do(func() {
+		file_bfma_v1_signals_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bfma_v1_signals_proto_rawDesc), len(file_bfma_v1_signals_proto_rawDesc)))
+	})
+	return file_bfma_v1_signals_proto_rawDescData
+}
+
+var file_bfma_v1_signals_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_bfma_v1_signals_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_bfma_v1_signals_proto_goTypes = []any{
+	(Order)(0),                    // 0: bfma.v1.Order
+	(Recommendation)(0),           // 1: bfma.v1.Recommendation
+	(*SubscribeRequest)(nil),      // 2: bfma.v1.SubscribeRequest
+	(*SignalsRequest)(nil),        // 3: bfma.v1.SignalsRequest
+	(*HistoryRequest)(nil),        // 4: bfma.v1.HistoryRequest
+	(*SignalsResponse)(nil),       // 5: bfma.v1.SignalsResponse
+	(*Signal)(nil),                // 6: bfma.v1.Signal
+	(*Event)(nil),                 // 7: bfma.v1.Event
+	nil,                           // 8: bfma.v1.Signal.ComponentsEntry
+	nil,                           // 9: bfma.v1.Signal.WeightsEntry
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_bfma_v1_signals_proto_depIdxs = []int32{
+	10, // 0: bfma.v1.HistoryRequest.from:type_name -> google.protobuf.Timestamp
+	10, // 1: bfma.v1.HistoryRequest.to:type_name -> google.protobuf.Timestamp
+	1,  // 2: bfma.v1.HistoryRequest.recommendations:type_name -> bfma.v1.Recommendation
+	0,  // 3: bfma.v1.HistoryRequest.order:type_name -> bfma.v1.Order
+	6,  // 4: bfma.v1.SignalsResponse.signals:type_name -> bfma.v1.Signal
+	10, // 5: bfma.v1.Signal.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 6: bfma.v1.Signal.recommendation:type_name -> bfma.v1.Recommendation
+	8,  // 7: bfma.v1.Signal.components:type_name -> bfma.v1.Signal.ComponentsEntry
+	10, // 8: bfma.v1.Signal.data_from:type_name -> google.protobuf.Timestamp
+	10, // 9: bfma.v1.Signal.data_to:type_name -> google.protobuf.Timestamp
+	9,  // 10: bfma.v1.Signal.weights:type_name -> bfma.v1.Signal.WeightsEntry
+	10, // 11: bfma.v1.Event.timestamp:type_name -> google.protobuf.Timestamp
+	2,  // 12: bfma.v1.SignalService.SubscribeSignals:input_type -> bfma.v1.SubscribeRequest
+	2,  // 13: bfma.v1.SignalService.SubscribeEvents:input_type -> bfma.v1.SubscribeRequest
+	3,  // 14: bfma.v1.SignalService.GetLatestSignals:input_type -> bfma.v1.SignalsRequest
+	4,  // 15: bfma.v1.SignalService.GetSignalHistory:input_type -> bfma.v1.HistoryRequest
+	6,  // 16: bfma.v1.SignalService.SubscribeSignals:output_type -> bfma.v1.Signal
+	7,  // 17: bfma.v1.SignalService.SubscribeEvents:output_type -> bfma.v1.Event
+	5,  // 18: bfma.v1.SignalService.GetLatestSignals:output_type -> bfma.v1.SignalsResponse
+	5,  // 19: bfma.v1.SignalService.GetSignalHistory:output_type -> bfma.v1.SignalsResponse
+	16, // [16:20] is the sub-list for method output_type
+	12, // [12:16] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_bfma_v1_signals_proto_init() }
+func file_bfma_v1_signals_proto_init() {
+	if File_bfma_v1_signals_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bfma_v1_signals_proto_rawDesc), len(file_bfma_v1_signals_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bfma_v1_signals_proto_goTypes,
+		DependencyIndexes: file_bfma_v1_signals_proto_depIdxs,
+		EnumInfos:         file_bfma_v1_signals_proto_enumTypes,
+		MessageInfos:      file_bfma_v1_signals_proto_msgTypes,
+	}.Build()
+	File_bfma_v1_signals_proto = out.File
+	file_bfma_v1_signals_proto_goTypes = nil
+	file_bfma_v1_signals_proto_depIdxs = nil
+}
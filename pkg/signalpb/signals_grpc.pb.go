@@ -0,0 +1,255 @@
+// Потоковый API сигналов bfma для ботов исполнения.
+// Формат сигнала соответствует схеме JSON версии 2 (internal/schema).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: bfma/v1/signals.proto
+
+package signalpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SignalService_SubscribeSignals_FullMethodName = "/bfma.v1.SignalService/SubscribeSignals"
+	SignalService_SubscribeEvents_FullMethodName  = "/bfma.v1.SignalService/SubscribeEvents"
+	SignalService_GetLatestSignals_FullMethodName = "/bfma.v1.SignalService/GetLatestSignals"
+	SignalService_GetSignalHistory_FullMethodName = "/bfma.v1.SignalService/GetSignalHistory"
+)
+
+// SignalServiceClient is the client API for SignalService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SignalServiceClient interface {
+	// Новые сигналы по мере расчета. Пустой список symbols - все отслеживаемые символы.
+	SubscribeSignals(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Signal], error)
+	// События приложения: смена рекомендации, конфликт с позицией, перезагрузка конфигурации.
+	SubscribeEvents(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// Последние рассчитанные сигналы.
+	GetLatestSignals(ctx context.Context, in *SignalsRequest, opts ...grpc.CallOption) (*SignalsResponse, error)
+	// Сохраненные сигналы по страницам (models.SignalQuery): по времени, при равном
+	// времени - по символу. Следующая страница - запрос с cursor = next_cursor ответа.
+	GetSignalHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*SignalsResponse, error)
+}
+
+type signalServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSignalServiceClient(cc grpc.ClientConnInterface) SignalServiceClient {
+	return &signalServiceClient{cc}
+}
+
+func (c *signalServiceClient) SubscribeSignals(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Signal], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SignalService_ServiceDesc.Streams[0], SignalService_SubscribeSignals_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Signal]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SignalService_SubscribeSignalsClient = grpc.ServerStreamingClient[Signal]
+
+func (c *signalServiceClient) SubscribeEvents(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SignalService_ServiceDesc.Streams[1], SignalService_SubscribeEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SignalService_SubscribeEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *signalServiceClient) GetLatestSignals(ctx context.Context, in *SignalsRequest, opts ...grpc.CallOption) (*SignalsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SignalsResponse)
+	err := c.cc.Invoke(ctx, SignalService_GetLatestSignals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signalServiceClient) GetSignalHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*SignalsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SignalsResponse)
+	err := c.cc.Invoke(ctx, SignalService_GetSignalHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SignalServiceServer is the server API for SignalService service.
+// All implementations must embed UnimplementedSignalServiceServer
+// for forward compatibility.
+type SignalServiceServer interface {
+	// Новые сигналы по мере расчета. Пустой список symbols - все отслеживаемые символы.
+	SubscribeSignals(*SubscribeRequest, grpc.ServerStreamingServer[Signal]) error
+	// События приложения: смена рекомендации, конфликт с позицией, перезагрузка конфигурации.
+	SubscribeEvents(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error
+	// Последние рассчитанные сигналы.
+	GetLatestSignals(context.Context, *SignalsRequest) (*SignalsResponse, error)
+	// Сохраненные сигналы по страницам (models.SignalQuery): по времени, при равном
+	// времени - по символу. Следующая страница - запрос с cursor = next_cursor ответа.
+	GetSignalHistory(context.Context, *HistoryRequest) (*SignalsResponse, error)
+	mustEmbedUnimplementedSignalServiceServer()
+}
+
+// UnimplementedSignalServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSignalServiceServer struct{}
+
+func (UnimplementedSignalServiceServer) SubscribeSignals(*SubscribeRequest, grpc.ServerStreamingServer[Signal]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeSignals not implemented")
+}
+func (UnimplementedSignalServiceServer) SubscribeEvents(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeEvents not implemented")
+}
+func (UnimplementedSignalServiceServer) GetLatestSignals(context.Context, *SignalsRequest) (*SignalsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatestSignals not implemented")
+}
+func (UnimplementedSignalServiceServer) GetSignalHistory(context.Context, *HistoryRequest) (*SignalsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSignalHistory not implemented")
+}
+func (UnimplementedSignalServiceServer) mustEmbedUnimplementedSignalServiceServer() {}
+func (UnimplementedSignalServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeSignalServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SignalServiceServer will
+// result in compilation errors.
+type UnsafeSignalServiceServer interface {
+	mustEmbedUnimplementedSignalServiceServer()
+}
+
+func RegisterSignalServiceServer(s grpc.ServiceRegistrar, srv SignalServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSignalServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SignalService_ServiceDesc, srv)
+}
+
+func _SignalService_SubscribeSignals_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SignalServiceServer).SubscribeSignals(m, &grpc.GenericServerStream[SubscribeRequest, Signal]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SignalService_SubscribeSignalsServer = grpc.ServerStreamingServer[Signal]
+
+func _SignalService_SubscribeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SignalServiceServer).SubscribeEvents(m, &grpc.GenericServerStream[SubscribeRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SignalService_SubscribeEventsServer = grpc.ServerStreamingServer[Event]
+
+func _SignalService_GetLatestSignals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignalServiceServer).GetLatestSignals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignalService_GetLatestSignals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignalServiceServer).GetLatestSignals(ctx, req.(*SignalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SignalService_GetSignalHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignalServiceServer).GetSignalHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignalService_GetSignalHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignalServiceServer).GetSignalHistory(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SignalService_ServiceDesc is the grpc.ServiceDesc for SignalService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SignalService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bfma.v1.SignalService",
+	HandlerType: (*SignalServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLatestSignals",
+			Handler:    _SignalService_GetLatestSignals_Handler,
+		},
+		{
+			MethodName: "GetSignalHistory",
+			Handler:    _SignalService_GetSignalHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeSignals",
+			Handler:       _SignalService_SubscribeSignals_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeEvents",
+			Handler:       _SignalService_SubscribeEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bfma/v1/signals.proto",
+}
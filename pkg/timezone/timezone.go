@@ -0,0 +1,62 @@
+package timezone
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	// База часовых поясов встроена, чтобы имена IANA работали и в контейнерах без tzdata
+	_ "time/tzdata"
+)
+
+// Local имя системного часового пояса
+const Local = "local"
+
+// Часовой пояс, в котором выводится время; nil - системный
+var location atomic.Pointer[time.Location]
+
+// Load возвращает часовой пояс по имени: local (или пусто) - системный,
+// UTC или имя из базы IANA, например Europe/Moscow
+func Load(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, Local) {
+		return time.Local, nil
+	}
+	if strings.EqualFold(name, "utc") {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестный часовой пояс %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// Set задает часовой пояс для вывода времени в интерфейсе, журналах и экспорте
+func Set(name string) error {
+	loc, err := Load(name)
+	if err != nil {
+		return err
+	}
+	location.Store(loc)
+	return nil
+}
+
+// Location возвращает часовой пояс вывода времени
+func Location() *time.Location {
+	if loc := location.Load(); loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// In переводит время в часовой пояс вывода
+func In(t time.Time) time.Time {
+	return t.In(Location())
+}
+
+// Now возвращает текущее время в часовом поясе вывода
+func Now() time.Time {
+	return In(time.Now())
+}